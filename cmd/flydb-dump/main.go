@@ -47,6 +47,7 @@ Connection Options:
 	-db <name>              Database name (default: "default")
 	--connect-timeout <dur> Connection timeout (default: 10s)
 	--query-timeout <dur>   Query timeout (default: 30s)
+	--wire-compression <a>  Protocol compression: zstd, lz4, snappy, none (default: zstd)
 
 Export Options:
 
@@ -135,6 +136,7 @@ import (
 	"time"
 
 	"flydb/internal/auth"
+	"flydb/internal/compression"
 	"flydb/internal/protocol"
 	"flydb/internal/sql"
 	"flydb/internal/storage"
@@ -193,8 +195,9 @@ var (
 	promptPassphrase     = flag.Bool("prompt-passphrase", false, "Prompt for encryption passphrase (local mode only)")
 
 	// Cluster options
-	connectTimeout = flag.Duration("connect-timeout", ConnectionTimeout, "Connection timeout for remote connections")
-	queryTimeout   = flag.Duration("query-timeout", QueryTimeout, "Query timeout for remote connections")
+	connectTimeout  = flag.Duration("connect-timeout", ConnectionTimeout, "Connection timeout for remote connections")
+	queryTimeout    = flag.Duration("query-timeout", QueryTimeout, "Query timeout for remote connections")
+	wireCompression = flag.String("wire-compression", "zstd", "Protocol compression for remote connections: zstd, lz4, snappy, none")
 
 	// TLS options
 	noTLS       = flag.Bool("no-tls", false, "Disable TLS and use plain TCP connection")
@@ -350,6 +353,7 @@ func printUsage() {
 	fmt.Printf("  %-28s %s\n", cli.Info("-db <name>"), "Database name (default: default)")
 	fmt.Printf("  %-28s %s\n", cli.Info("--connect-timeout <dur>"), "Connection timeout (default: 10s)")
 	fmt.Printf("  %-28s %s\n", cli.Info("--query-timeout <dur>"), "Query timeout (default: 30s)")
+	fmt.Printf("  %-28s %s\n", cli.Info("--wire-compression <a>"), "Protocol compression: zstd, lz4, snappy, none (default: zstd)")
 	fmt.Println()
 
 	fmt.Println(cli.Highlight("EXPORT OPTIONS:"))
@@ -885,6 +889,8 @@ func formatSQLValue(val interface{}) string {
 	case string:
 		escaped := strings.ReplaceAll(v, "'", "''")
 		return fmt.Sprintf("'%s'", escaped)
+	case int64:
+		return fmt.Sprintf("%d", v)
 	case float64:
 		if v == float64(int64(v)) {
 			return fmt.Sprintf("%d", int64(v))
@@ -1290,20 +1296,24 @@ func (s *SQLScanner) Err() error {
 
 // BinaryClient wraps the binary protocol connection for remote database access.
 type BinaryClient struct {
-	conn       net.Conn
-	reader     *bufio.Reader
-	writer     *bufio.Writer
-	authed     bool
-	serverAddr string
-	database   string
+	conn        net.Conn
+	reader      *bufio.Reader
+	writer      *bufio.Writer
+	frames      *protocol.FrameWriter
+	compression *protocol.Compression // nil until the server accepts compression
+	authed      bool
+	serverAddr  string
+	database    string
 }
 
 // NewBinaryClient creates a new binary protocol client.
 func NewBinaryClient(conn net.Conn, serverAddr string) *BinaryClient {
+	writer, frames := protocol.NewCompressedWriter(conn)
 	return &BinaryClient{
 		conn:       conn,
 		reader:     bufio.NewReader(conn),
-		writer:     bufio.NewWriter(conn),
+		writer:     writer,
+		frames:     frames,
 		authed:     false,
 		serverAddr: serverAddr,
 		database:   *database,
@@ -1322,7 +1332,7 @@ func (c *BinaryClient) Ping() error {
 		return fmt.Errorf("failed to flush PING: %w", err)
 	}
 
-	msg, err := protocol.ReadMessage(c.reader)
+	msg, err := c.compression.ReadMessage(c.reader)
 	if err != nil {
 		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
 			return fmt.Errorf("server did not respond within %v", PingTimeout)
@@ -1344,19 +1354,23 @@ func (c *BinaryClient) Auth(username, password string) error {
 		Password: password,
 		Database: c.database,
 	}
+	if *wireCompression != "" && *wireCompression != "none" {
+		authMsg.Compression = []string{*wireCompression}
+	}
 	data, err := authMsg.Encode()
 	if err != nil {
 		return fmt.Errorf("failed to encode auth message: %w", err)
 	}
 
-	if err := protocol.WriteMessage(c.writer, protocol.MsgAuth, data); err != nil {
+	// Request binary result rows
+	if err := protocol.WriteMessageVersion(c.writer, protocol.ProtocolVersionBinary, protocol.MsgAuth, data); err != nil {
 		return fmt.Errorf("failed to send auth message: %w", err)
 	}
 	if err := c.writer.Flush(); err != nil {
 		return fmt.Errorf("failed to flush auth message: %w", err)
 	}
 
-	msg, err := protocol.ReadMessage(c.reader)
+	msg, err := c.compression.ReadMessage(c.reader)
 	if err != nil {
 		return fmt.Errorf("failed to read auth response: %w", err)
 	}
@@ -1379,6 +1393,16 @@ func (c *BinaryClient) Auth(username, password string) error {
 		return fmt.Errorf("authentication failed: %s", result.Message)
 	}
 
+	// Compress both directions if the server accepted an algorithm
+	if result.Compression != "" {
+		algorithm, err := compression.ParseAlgorithm(result.Compression)
+		if err != nil {
+			return fmt.Errorf("server chose unsupported compression: %w", err)
+		}
+		c.compression = protocol.NewCompression(algorithm)
+		c.frames.SetCompression(c.compression)
+	}
+
 	c.authed = true
 	return nil
 }
@@ -1403,7 +1427,7 @@ func (c *BinaryClient) Query(query string) (string, error) {
 		return "", fmt.Errorf("failed to flush query: %w", err)
 	}
 
-	msg, err := protocol.ReadMessage(c.reader)
+	msg, err := c.compression.ReadMessage(c.reader)
 	if err != nil {
 		return "", fmt.Errorf("failed to read query response: %w", err)
 	}
@@ -1417,7 +1441,7 @@ func (c *BinaryClient) Query(query string) (string, error) {
 		return "", fmt.Errorf("unexpected response type: %d", msg.Header.Type)
 	}
 
-	result, err := protocol.DecodeQueryResultMessage(msg.Payload)
+	result, err := protocol.DecodeQueryResult(msg)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode query result: %w", err)
 	}
@@ -1449,7 +1473,7 @@ func (c *BinaryClient) QueryWithResult(query string) (*protocol.QueryResultMessa
 		return nil, fmt.Errorf("failed to flush query: %w", err)
 	}
 
-	msg, err := protocol.ReadMessage(c.reader)
+	msg, err := c.compression.ReadMessage(c.reader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read query response: %w", err)
 	}
@@ -1463,7 +1487,7 @@ func (c *BinaryClient) QueryWithResult(query string) (*protocol.QueryResultMessa
 		return nil, fmt.Errorf("unexpected response type: %d", msg.Header.Type)
 	}
 
-	result, err := protocol.DecodeQueryResultMessage(msg.Payload)
+	result, err := protocol.DecodeQueryResult(msg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode query result: %w", err)
 	}
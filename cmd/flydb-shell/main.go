@@ -2513,6 +2513,8 @@ func printHelp() {
 	fmt.Printf("    %s <name>     Detailed info for a role\n", cli.Info("INSPECT ROLE"))
 	fmt.Printf("    %s [WHERE ...] Show audit trail logs\n", cli.Info("INSPECT AUDIT"))
 	fmt.Printf("    %s          Show audit statistics\n", cli.Info("INSPECT AUDIT STATS"))
+	fmt.Printf("    %s         Show the planned partition rebalance\n", cli.Info("INSPECT CLUSTER"))
+	fmt.Printf("    %s <n>  Repair the replicas of a partition\n", cli.Info("REPAIR PARTITION"))
 	fmt.Println()
 
 	// Database Management
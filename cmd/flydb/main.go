@@ -75,6 +75,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -107,6 +108,7 @@ func printUsage() {
 
 	fmt.Println(cli.Highlight("CORE OPTIONS:"))
 	fmt.Printf("  %s <port>      Server port for binary protocol connections (default: 8889)\n", cli.Info("-port"))
+	fmt.Printf("  %s <port> PostgreSQL wire protocol port (default: 0, disabled)\n", cli.Info("-postgres-port"))
 	fmt.Printf("  %s <role>      Server role: standalone, cluster (default: standalone)\n", cli.Info("-role"))
 	fmt.Printf("  %s <path>     Directory for database storage (default: %s)\n", cli.Info("-data-dir"), config.GetDefaultDataDir())
 	fmt.Printf("  %s <file>     Path to configuration file\n", cli.Info("-config"))
@@ -126,7 +128,10 @@ func printUsage() {
 	fmt.Printf("  %s <port>   Cluster communication port (default: 9998)\n", cli.Info("-cluster-port"))
 	fmt.Printf("  %s <port>      Replication port for cluster sync (default: 9999)\n", cli.Info("-repl-port"))
 	fmt.Printf("  %s <peers> Comma-separated list of cluster peers (host:port)\n", cli.Info("-cluster-peers"))
-	fmt.Printf("  %s         Enable Raft consensus for leader election (default: true)\n", cli.Info("-enable-raft"))
+	fmt.Printf("  %s         Replicate writes through Raft consensus (default: true)\n", cli.Info("-enable-raft"))
+	fmt.Printf("  %s <port>      Raft consensus port (default: 9997)\n", cli.Info("-raft-port"))
+	fmt.Printf("  %s           Join a running Raft cluster via ALTER CLUSTER ADD NODE\n", cli.Info("-raft-join"))
+	fmt.Printf("  %s <peers>  Comma-separated Raft addresses of the other voters (host:raft-port)\n", cli.Info("-raft-peers"))
 	fmt.Println()
 
 	fmt.Println(cli.Highlight("PERFORMANCE OPTIONS (01.26.17+):"))
@@ -134,6 +139,11 @@ func printUsage() {
 	fmt.Printf("  %s <algo>  Compression algorithm: lz4, snappy, zstd (default: lz4)\n", cli.Info("-compression-algorithm"))
 	fmt.Printf("  %s   Enable zero-copy buffer pooling (default: true)\n", cli.Info("-enable-zero-copy"))
 	fmt.Printf("  %s <n> Buffer pool size in bytes, 0 = auto (default: 0)\n", cli.Info("-buffer-pool-size-bytes"))
+	fmt.Printf("  %s <mode> WAL durability: always, batch(10ms), off (default: always)\n", cli.Info("-synchronous-commit"))
+	fmt.Printf("  %s <f> Re-analyze a table after this fraction of rows change, 0 = off (default: 0.2)\n", cli.Info("-auto-analyze-fraction"))
+	fmt.Printf("  %s <n> Seconds between TTL reaper passes, 0 = off (default: 60)\n", cli.Info("-ttl-reaper-interval"))
+	fmt.Printf("  %s <n> Expired rows deleted per batch (default: 1000)\n", cli.Info("-ttl-reaper-batch-size"))
+	fmt.Printf("  %s <n> Memory for open cursor rows in MB, 0 = unlimited (default: 256)\n", cli.Info("-cursor-memory-limit-mb"))
 	fmt.Println()
 
 	fmt.Println(cli.Highlight("OBSERVABILITY & LOGGING:"))
@@ -146,6 +156,7 @@ func printUsage() {
 	fmt.Println(cli.Highlight("ENVIRONMENT VARIABLES:"))
 	fmt.Printf("  %s         Data directory for database storage\n", cli.Info("FLYDB_DATA_DIR"))
 	fmt.Printf("  %s             Server port for client connections\n", cli.Info("FLYDB_PORT"))
+	fmt.Printf("  %s    PostgreSQL wire protocol port (0 disables)\n", cli.Info("FLYDB_POSTGRES_PORT"))
 	fmt.Printf("  %s  Encryption passphrase (required if encryption enabled)\n", cli.Info("FLYDB_ENCRYPTION_PASSPHRASE"))
 	fmt.Printf("  %s       Admin password for first-time setup\n", cli.Info("FLYDB_ADMIN_PASSWORD"))
 	fmt.Println()
@@ -159,7 +170,7 @@ func printUsage() {
 	fmt.Println("  flydb -role standalone -data-dir ./data")
 	fmt.Println()
 	fmt.Println("  " + cli.Dimmed("# Start cluster node"))
-	fmt.Println("  flydb -role cluster -cluster-peers node1:9998,node2:9998")
+	fmt.Println("  flydb -role cluster -cluster-peers node1:9998,node2:9998 -raft-peers node1:9997,node2:9997")
 	fmt.Println()
 
 	fmt.Println(cli.Highlight("CONNECTING:"))
@@ -177,6 +188,35 @@ func getHostname() string {
 	return hostname
 }
 
+// raftWriteStore applies committed Raft writes to the database manager.
+type raftWriteStore struct {
+	*storage.DatabaseManager
+}
+
+// ApplyCommittedAt applies the writes of a committed Raft log entry.
+func (s raftWriteStore) ApplyCommittedAt(database string, index uint64, writes []cluster.RaftWrite) error {
+	ops := make([]storage.TxOperation, len(writes))
+	for i, w := range writes {
+		ops[i] = storage.TxOperation(w)
+	}
+	return s.DatabaseManager.ApplyCommittedAt(database, index, ops)
+}
+
+// txnCoordinator commits SQL transactions through the cluster's
+// distributed transaction coordinator.
+type txnCoordinator struct {
+	*cluster.UnifiedClusterManager
+}
+
+// CommitTransaction commits the writes of a transaction to database.
+func (c txnCoordinator) CommitTransaction(database string, ops []storage.TxOperation) error {
+	writes := make([]cluster.TxnWrite, len(ops))
+	for i, op := range ops {
+		writes[i] = cluster.TxnWrite{Op: op.Op, Key: op.Key, Value: op.Value}
+	}
+	return c.UnifiedClusterManager.CommitTransaction(database, writes)
+}
+
 // main is the entry point for the FlyDB server application.
 // It orchestrates the initialization of all subsystems and starts the server.
 func main() {
@@ -199,6 +239,7 @@ func main() {
 	// Default values come from the loaded configuration.
 	port := flag.String("port", strconv.Itoa(cfg.Port), "Server port for client connections (binary protocol)")
 	replPort := flag.String("repl-port", strconv.Itoa(cfg.ReplPort), "Replication port")
+	postgresPort := flag.Int("postgres-port", cfg.PostgresPort, "PostgreSQL wire protocol port (0 disables)")
 	role := flag.String("role", cfg.Role, "Server role: 'cluster' or 'standalone'")
 	dbPath := flag.String("db", cfg.DBPath, "Path to the WAL database file")
 	dataDir := flag.String("data-dir", cfg.DataDir, "Directory for multi-database storage (enables multi-database mode)")
@@ -214,6 +255,9 @@ func main() {
 	enableRaft := flag.Bool("enable-raft", cfg.EnableRaft, "Enable Raft consensus for leader election (replaces Bully)")
 	raftElectionTimeout := flag.Int("raft-election-timeout", cfg.RaftElectionTimeout, "Raft election timeout in milliseconds")
 	raftHeartbeatInterval := flag.Int("raft-heartbeat-interval", cfg.RaftHeartbeatInterval, "Raft heartbeat interval in milliseconds")
+	raftPort := flag.Int("raft-port", cfg.RaftPort, "Raft consensus port")
+	raftJoin := flag.Bool("raft-join", cfg.RaftJoin, "Join a running Raft cluster instead of bootstrapping one")
+	raftPeers := flag.String("raft-peers", strings.Join(cfg.RaftPeers, ","), "Comma-separated Raft addresses of the other voters")
 
 	// Compression flags (01.26.17+)
 	enableCompression := flag.Bool("enable-compression", cfg.EnableCompression, "Enable compression for WAL and replication")
@@ -232,6 +276,11 @@ func main() {
 	// Performance flags (01.26.17+)
 	enableZeroCopy := flag.Bool("enable-zero-copy", cfg.EnableZeroCopy, "Enable zero-copy buffer pooling")
 	bufferPoolSizeBytes := flag.Int("buffer-pool-size-bytes", cfg.BufferPoolSizeBytes, "Buffer pool size in bytes (0 = auto)")
+	synchronousCommit := flag.String("synchronous-commit", cfg.SynchronousCommit, "WAL durability for commits: always, batch(interval), off")
+	autoAnalyzeFraction := flag.Float64("auto-analyze-fraction", cfg.AutoAnalyzeFraction, "Fraction of changed rows that re-analyzes a table (0 = disabled)")
+	ttlReaperInterval := flag.Int("ttl-reaper-interval", cfg.TTLReaperInterval, "Seconds between TTL reaper passes (0 = disabled)")
+	ttlReaperBatchSize := flag.Int("ttl-reaper-batch-size", cfg.TTLReaperBatchSize, "Expired rows deleted per TTL reaper batch")
+	cursorMemoryLimitMB := flag.Int("cursor-memory-limit-mb", cfg.CursorMemoryLimitMB, "Memory for the rows of open cursors in MB (0 = unlimited)")
 
 	// Custom usage function
 	flag.Usage = printUsage
@@ -299,6 +348,8 @@ func main() {
 				if replPortInt, err := strconv.Atoi(*replPort); err == nil {
 					cfg.ReplPort = replPortInt
 				}
+			case "postgres-port":
+				cfg.PostgresPort = *postgresPort
 			case "role":
 				cfg.Role = *role
 			case "db":
@@ -322,6 +373,14 @@ func main() {
 				cfg.RaftElectionTimeout = *raftElectionTimeout
 			case "raft-heartbeat-interval":
 				cfg.RaftHeartbeatInterval = *raftHeartbeatInterval
+			case "raft-port":
+				cfg.RaftPort = *raftPort
+			case "raft-join":
+				cfg.RaftJoin = *raftJoin
+			case "raft-peers":
+				if *raftPeers != "" {
+					cfg.RaftPeers = strings.Split(*raftPeers, ",")
+				}
 			// Compression flags (01.26.17+)
 			case "enable-compression":
 				cfg.EnableCompression = *enableCompression
@@ -346,6 +405,16 @@ func main() {
 				cfg.EnableZeroCopy = *enableZeroCopy
 			case "buffer-pool-size-bytes":
 				cfg.BufferPoolSizeBytes = *bufferPoolSizeBytes
+			case "synchronous-commit":
+				cfg.SynchronousCommit = *synchronousCommit
+			case "auto-analyze-fraction":
+				cfg.AutoAnalyzeFraction = *autoAnalyzeFraction
+			case "ttl-reaper-interval":
+				cfg.TTLReaperInterval = *ttlReaperInterval
+			case "ttl-reaper-batch-size":
+				cfg.TTLReaperBatchSize = *ttlReaperBatchSize
+			case "cursor-memory-limit-mb":
+				cfg.CursorMemoryLimitMB = *cursorMemoryLimitMB
 			}
 		})
 	}
@@ -487,6 +556,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Apply the default commit durability; sessions may override it
+	if cfg.SynchronousCommit != "" {
+		policy, err := storage.ParseSyncPolicy(cfg.SynchronousCommit)
+		if err != nil {
+			log.Error("Invalid synchronous_commit", "error", err)
+			os.Exit(1)
+		}
+		dbManager.SetCommitPolicy(policy)
+	}
+	log.Info("WAL commit durability", "synchronous_commit", dbManager.CommitPolicy().String())
+
 	// Get the system database's KVStore for admin initialization
 	// Users are stored in the system database for global access across all databases
 	systemDB, err := dbManager.GetSystemDatabase()
@@ -661,36 +741,68 @@ func main() {
 		// Wire up WAL and storage for integrated replication
 		clusterMgr.SetWAL(unified.WAL())
 		clusterMgr.SetStore(store)
-
-		// Set up callback for leader transitions - start integrated replication leader
-		clusterMgr.SetLeaderCallback(func() {
-			replLog.Info("This node is now the LEADER - starting integrated replication leader")
-			go func() {
-				if err := clusterMgr.StartReplicationLeader(fmt.Sprintf(":%d", cfg.ReplPort)); err != nil {
-					replLog.Error("Replication leader error", "error", err)
-				}
-			}()
+		clusterMgr.SetDatabaseStores(func(name string) (cluster.StorageEngine, error) {
+			db, err := dbManager.GetDatabase(name)
+			if err != nil {
+				return nil, err
+			}
+			return db.Store, nil
 		})
 
-		// Set up callback for follower transitions - start integrated replication follower
-		clusterMgr.SetFollowerCallback(func(leaderID string) {
-			replLog.Info("This node is now a FOLLOWER", "leader", leaderID)
-
-			// Get leader node to find its data port
-			leaderNode := clusterMgr.GetNode(leaderID)
-			if leaderNode == nil {
-				replLog.Error("Failed to find leader node details", "leader_id", leaderID)
-				return
-			}
+		if cfg.EnableRaft {
+			// Every write is proposed to the Raft log and acknowledged once a
+			// quorum has committed it, so WAL shipping is not started.
+			raftConfig := cluster.DefaultRaftConfig(clusterConfig.NodeID, fmt.Sprintf("%s:%d", getHostname(), cfg.RaftPort))
+			raftConfig.ClusterPort = cfg.RaftPort
+			raftConfig.Peers = cfg.RaftPeers
+			raftConfig.ElectionTimeout = time.Duration(cfg.RaftElectionTimeout) * time.Millisecond
+			raftConfig.HeartbeatInterval = time.Duration(cfg.RaftHeartbeatInterval) * time.Millisecond
+			raftConfig.EnablePreVote = cfg.EnablePreVote
+			raftConfig.DataDir = filepath.Join(cfg.DataDir, "raft")
+			raftConfig.Join = cfg.RaftJoin
+
+			raftWriter := cluster.NewRaftWriter(raftConfig, raftWriteStore{dbManager})
+			raftWriter.SetTimeout(time.Duration(cfg.SyncTimeout) * time.Millisecond)
+			clusterMgr.SetRaftWriter(raftWriter, storage.SystemDatabaseName)
+			dbManager.SetWriteProposer(func(database string, ops []storage.TxOperation) error {
+				writes := make([]cluster.RaftWrite, len(ops))
+				for i, op := range ops {
+					writes[i] = cluster.RaftWrite(op)
+				}
+				return raftWriter.ProposeBatch(database, writes)
+			})
+			replLog.Info("Writes are replicated through Raft", "raft_port", cfg.RaftPort, "peers", raftConfig.Peers)
+		} else {
+			// Set up callback for leader transitions - start integrated replication leader
+			clusterMgr.SetLeaderCallback(func() {
+				replLog.Info("This node is now the LEADER - starting integrated replication leader")
+				go func() {
+					if err := clusterMgr.StartReplicationLeader(fmt.Sprintf(":%d", cfg.ReplPort)); err != nil {
+						replLog.Error("Replication leader error", "error", err)
+					}
+				}()
+			})
+
+			// Set up callback for follower transitions - start integrated replication follower
+			clusterMgr.SetFollowerCallback(func(leaderID string) {
+				replLog.Info("This node is now a FOLLOWER", "leader", leaderID)
+
+				// Get leader node to find its data port
+				leaderNode := clusterMgr.GetNode(leaderID)
+				if leaderNode == nil {
+					replLog.Error("Failed to find leader node details", "leader_id", leaderID)
+					return
+				}
 
-			leaderAddr := fmt.Sprintf("%s:%d", leaderNode.Addr, leaderNode.DataPort)
+				leaderAddr := fmt.Sprintf("%s:%d", leaderNode.Addr, leaderNode.DataPort)
 
-			go func() {
-				if err := clusterMgr.StartReplicationFollower(leaderAddr); err != nil {
-					replLog.Error("Replication follower error", "error", err)
-				}
-			}()
-		})
+				go func() {
+					if err := clusterMgr.StartReplicationFollower(leaderAddr); err != nil {
+						replLog.Error("Replication follower error", "error", err)
+					}
+				}()
+			})
+		}
 
 		// Set up event callback for logging cluster events
 		clusterMgr.OnEvent(func(event cluster.ClusterEvent) {
@@ -713,6 +825,13 @@ func main() {
 				log.Info("Cluster event: partition rebalancing complete")
 			case cluster.EventPartitionMoved:
 				log.Info("Cluster event: partition moved", "partition", event.PartitionID)
+			case cluster.EventRepairStarted, cluster.EventRepairComplete:
+				m := clusterMgr.GetMetrics()
+				metrics.Get().SetRepairProgress(uint64(m.RepairRuns), uint64(m.RepairRangesStreamed),
+					uint64(m.RepairKeysStreamed), int64(m.ActiveRepairs))
+				if event.Type == cluster.EventRepairComplete {
+					log.Info("Cluster event: partition repaired", "partition", event.PartitionID, "result", event.Details)
+				}
 			}
 		})
 
@@ -799,6 +918,33 @@ func main() {
 		"", // binaryAddr is deprecated - all connections use binary protocol on Port
 		dbManager,
 	)
+	srv.SetAutoAnalyzeFraction(cfg.AutoAnalyzeFraction)
+	if clusterMgr != nil {
+		// Push SELECTs down to the partition owners
+		clusterMgr.SetFragmentExecutor(srv.ExecuteFragment)
+		srv.SetDistributedScanner(clusterMgr)
+		srv.SetClusterInspector(clusterMgr)
+		srv.SetClusterRepairer(clusterMgr)
+		srv.SetTransactionCoordinator(txnCoordinator{clusterMgr})
+	}
+	if clusterMgr != nil && clusterMgr.IsRaftEnabled() {
+		srv.SetClusterAdmin(clusterMgr)
+		if raftWriter := clusterMgr.GetRaftWriter(); raftWriter != nil {
+			srv.SetReadBarrier(raftWriter)
+		}
+	}
+	srv.SetTTLReaper(time.Duration(cfg.TTLReaperInterval)*time.Second, cfg.TTLReaperBatchSize)
+	srv.SetCursorMemoryLimit(int64(cfg.CursorMemoryLimitMB) << 20)
+	if cfg.PostgresPort != 0 {
+		srv.SetPostgresAddr(fmt.Sprintf(":%d", cfg.PostgresPort))
+	}
+	if cfg.Observability.Admin.Enabled {
+		if !cfg.Observability.Admin.AuthEnabled {
+			log.Warn("HTTP API authentication is disabled; requests without credentials run as admin",
+				"addr", cfg.Observability.Admin.Addr)
+		}
+		srv.SetHTTPAddr(cfg.Observability.Admin.Addr, cfg.Observability.Admin.AuthEnabled)
+	}
 
 	// Configure TLS if enabled
 	if cfg.TLSEnabled {
@@ -50,8 +50,9 @@ Storage Schema:
 User and permission data is stored in the same KVStore as application data,
 using reserved key prefixes:
 
-  - _sys_users:<username>  : Stores User JSON (username, password)
+  - _sys_users:<username>  : Stores User JSON (username, password hash, SCRAM verifier)
   - _sys_privs:<user>:<table> : Stores Permission JSON (table, RLS condition)
+  - _sys_tokens:<hash>     : Stores APIToken JSON (user, expiry) by token hash
 
 Security Considerations:
 ========================
@@ -64,7 +65,6 @@ This implementation uses bcrypt for secure password hashing:
 
 For additional security in production, consider:
   - Implement rate limiting for authentication attempts
-  - Add session management with tokens
   - Use TLS for encrypted connections
 */
 package auth
@@ -125,6 +125,7 @@ const (
 	privKeyPrefix   = "_sys_privs:"    // Prefix for permission records (legacy)
 	dbPrivKeyPrefix = "_sys_db_privs:" // Prefix for database-scoped permissions
 	dbAccessPrefix  = "_sys_db_access:" // Prefix for database access permissions
+	tokenKeyPrefix  = "_sys_tokens:"    // Prefix for API token records
 )
 
 // WildcardDatabase represents access to all databases.
@@ -173,6 +174,10 @@ type User struct {
 	CreatedAt    string   `json:"created_at"`    // When the user was created
 	LastLogin    string   `json:"last_login"`    // Last successful login time
 	Status       string   `json:"status"`        // Account status: active, locked, expired
+
+	// SCRAMVerifier authenticates PostgreSQL wire protocol clients (see
+	// NewSCRAMVerifier). Users created before it was stored have none.
+	SCRAMVerifier string `json:"scram_verifier,omitempty"`
 }
 
 // Permission defines access rights for a user on a specific table.
@@ -240,11 +245,17 @@ func (m *AuthManager) CreateUser(username, password string) error {
 		return errors.New("failed to hash password: " + err.Error())
 	}
 
+	verifier, err := NewSCRAMVerifier(password)
+	if err != nil {
+		return err
+	}
+
 	// Create the user record with hashed password and serialize to JSON.
 	user := User{
-		Username:     username,
-		PasswordHash: string(hashedPassword),
-		CreatedAt:    time.Now().Format(time.RFC3339),
+		Username:      username,
+		PasswordHash:  string(hashedPassword),
+		CreatedAt:     time.Now().Format(time.RFC3339),
+		SCRAMVerifier: verifier,
 	}
 	data, err := json.Marshal(user)
 	if err != nil {
@@ -290,6 +301,11 @@ func (m *AuthManager) Authenticate(username, password string) bool {
 	if err == nil {
 		// Update last login time
 		user.LastLogin = time.Now().Format(time.RFC3339)
+		// Users created before SCRAM verifiers were stored get one now, so
+		// PostgreSQL clients can log in as them from then on
+		if user.SCRAMVerifier == "" {
+			user.SCRAMVerifier, _ = NewSCRAMVerifier(password)
+		}
 		if data, err := json.Marshal(user); err == nil {
 			m.store.Put(key, data)
 		}
@@ -403,8 +419,13 @@ func (m *AuthManager) AlterUser(username, newPassword string) error {
 		return errors.New("failed to hash password: " + err.Error())
 	}
 
+	verifier, err := NewSCRAMVerifier(newPassword)
+	if err != nil {
+		return err
+	}
+
 	// Create the updated user record with new hashed password and serialize to JSON.
-	user := User{Username: username, PasswordHash: string(hashedPassword)}
+	user := User{Username: username, PasswordHash: string(hashedPassword), SCRAMVerifier: verifier}
 	data, err := json.Marshal(user)
 	if err != nil {
 		return err
@@ -425,6 +446,12 @@ func (m *AuthManager) DropUser(username string) error {
 		return fmt.Errorf("user not found: %s", username)
 	}
 
+	// Revoke the user's API tokens, so that they do not authenticate a
+	// later user of the same name
+	if err := m.revokeUserTokens(username); err != nil {
+		return err
+	}
+
 	// Delete the user
 	return m.store.Delete(key)
 }
@@ -518,13 +545,19 @@ func (m *AuthManager) CreateAdminUser(username, password string) error {
 		return errors.New("failed to hash password: " + err.Error())
 	}
 
+	verifier, err := NewSCRAMVerifier(password)
+	if err != nil {
+		return err
+	}
+
 	// Create the admin user record with hashed password and serialize to JSON.
 	user := User{
-		Username:     username,
-		PasswordHash: string(hashedPassword),
-		IsAdmin:      true, // Legacy flag for backward compatibility
-		CreatedAt:    time.Now().Format(time.RFC3339),
-		Status:       "active",
+		Username:      username,
+		PasswordHash:  string(hashedPassword),
+		IsAdmin:       true, // Legacy flag for backward compatibility
+		CreatedAt:     time.Now().Format(time.RFC3339),
+		Status:        "active",
+		SCRAMVerifier: verifier,
 	}
 	data, err := json.Marshal(user)
 	if err != nil {
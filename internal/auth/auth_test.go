@@ -17,8 +17,12 @@
 package auth
 
 import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -340,6 +344,113 @@ func TestAlterUserNonExistent(t *testing.T) {
 	}
 }
 
+func TestSCRAMVerifier(t *testing.T) {
+	authMgr, cleanup := setupTestAuthManager(t)
+	defer cleanup()
+
+	if err := authMgr.CreateUser("scram", "secret"); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	verifier, ok := authMgr.SCRAMVerifier("scram")
+	if !ok {
+		t.Fatal("Expected a SCRAM verifier for a new user")
+	}
+
+	// The StoredKey is derived from the password with the stored salt
+	fields := strings.FieldsFunc(strings.TrimPrefix(verifier, "SCRAM-SHA-256$"), func(r rune) bool { return r == '$' || r == ':' })
+	if len(fields) != 4 {
+		t.Fatalf("Malformed verifier %q", verifier)
+	}
+	iterations, _ := strconv.Atoi(fields[0])
+	salt, storedKey, serverKey := fields[1], fields[2], fields[3]
+	rawSalt, _ := base64.StdEncoding.DecodeString(salt)
+	salted, _ := pbkdf2.Key(sha256.New, "secret", rawSalt, iterations, sha256.Size)
+	want := sha256.Sum256(scramHMAC(salted, "Client Key"))
+	if iterations != SCRAMIterations || storedKey != base64.StdEncoding.EncodeToString(want[:]) ||
+		serverKey != base64.StdEncoding.EncodeToString(scramHMAC(salted, "Server Key")) {
+		t.Errorf("Verifier %q does not match the password", verifier)
+	}
+
+	// Changing the password replaces the verifier
+	if err := authMgr.AlterUser("scram", "changed"); err != nil {
+		t.Fatalf("AlterUser failed: %v", err)
+	}
+	if changed, ok := authMgr.SCRAMVerifier("scram"); !ok || changed == verifier {
+		t.Error("Expected AlterUser to replace the SCRAM verifier")
+	}
+
+	if _, ok := authMgr.SCRAMVerifier("nobody"); ok {
+		t.Error("Expected no SCRAM verifier for a missing user")
+	}
+
+	// A user created before verifiers were stored gets one on login
+	data, _ := authMgr.store.Get(userKeyPrefix + "scram")
+	var user User
+	json.Unmarshal(data, &user)
+	user.SCRAMVerifier = ""
+	data, _ = json.Marshal(user)
+	authMgr.store.Put(userKeyPrefix+"scram", data)
+	if _, ok := authMgr.SCRAMVerifier("scram"); ok {
+		t.Fatal("Expected no SCRAM verifier after removing it")
+	}
+	if !authMgr.Authenticate("scram", "changed") {
+		t.Fatal("Authenticate failed")
+	}
+	if _, ok := authMgr.SCRAMVerifier("scram"); !ok {
+		t.Error("Expected Authenticate to store a SCRAM verifier")
+	}
+}
+
+func TestAPITokens(t *testing.T) {
+	authMgr, cleanup := setupTestAuthManager(t)
+	defer cleanup()
+
+	if err := authMgr.CreateUser("alice", "secret"); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	token, err := authMgr.CreateToken("alice", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if !strings.HasPrefix(token, TokenPrefix) {
+		t.Errorf("Token %q lacks the %q prefix", token, TokenPrefix)
+	}
+	if user, ok := authMgr.AuthenticateToken(token); !ok || user != "alice" {
+		t.Errorf("AuthenticateToken = %q, %v, want alice", user, ok)
+	}
+	if _, ok := authMgr.AuthenticateToken(token + "x"); ok {
+		t.Error("Expected a modified token to be rejected")
+	}
+	if _, err := authMgr.CreateToken("nobody", 0); err == nil {
+		t.Error("Expected CreateToken to fail for a missing user")
+	}
+
+	// Revoked tokens stop working
+	if err := authMgr.RevokeToken(token); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if _, ok := authMgr.AuthenticateToken(token); ok {
+		t.Error("Expected a revoked token to be rejected")
+	}
+
+	// Expired tokens stop working
+	expiring, _ := authMgr.CreateToken("alice", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := authMgr.AuthenticateToken(expiring); ok {
+		t.Error("Expected an expired token to be rejected")
+	}
+
+	// Dropping a user revokes its tokens, also for a new user of the name
+	token, _ = authMgr.CreateToken("alice", 0)
+	if err := authMgr.DropUser("alice"); err != nil {
+		t.Fatalf("DropUser failed: %v", err)
+	}
+	authMgr.CreateUser("alice", "other")
+	if _, ok := authMgr.AuthenticateToken(token); ok {
+		t.Error("Expected the token of a dropped user to be rejected")
+	}
+}
+
 func TestRevoke(t *testing.T) {
 	authMgr, cleanup := setupTestAuthManager(t)
 	defer cleanup()
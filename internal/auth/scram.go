@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// SCRAMIterations is the PBKDF2 iteration count of new SCRAM-SHA-256
+// verifiers, the PostgreSQL default.
+const SCRAMIterations = 4096
+
+// scramSaltLength is the length of the salt of new verifiers in bytes.
+const scramSaltLength = 16
+
+// NewSCRAMVerifier returns a SCRAM-SHA-256 verifier of password (RFC 7677)
+// in the format PostgreSQL stores them in:
+//
+//	SCRAM-SHA-256$<iterations>:<salt>$<StoredKey>:<ServerKey>
+//
+// The verifier lets PostgreSQL wire protocol clients authenticate without
+// sending the password; it cannot be used to log in by itself.
+func NewSCRAMVerifier(password string) (string, error) {
+	salt := make([]byte, scramSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	salted, err := pbkdf2.Key(sha256.New, password, salt, SCRAMIterations, sha256.Size)
+	if err != nil {
+		return "", err
+	}
+	clientKey := scramHMAC(salted, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	serverKey := scramHMAC(salted, "Server Key")
+
+	enc := base64.StdEncoding
+	return fmt.Sprintf("SCRAM-SHA-256$%d:%s$%s:%s", SCRAMIterations,
+		enc.EncodeToString(salt), enc.EncodeToString(storedKey[:]), enc.EncodeToString(serverKey)), nil
+}
+
+// scramHMAC returns HMAC-SHA-256(key, message).
+func scramHMAC(key []byte, message string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// SCRAMVerifier returns the SCRAM-SHA-256 verifier of a user. ok is false
+// if the user does not exist or was created before verifiers were stored;
+// Authenticate stores one on such a user's next successful login.
+func (m *AuthManager) SCRAMVerifier(username string) (verifier string, ok bool) {
+	val, err := m.store.Get(userKeyPrefix + username)
+	if err != nil {
+		return "", false
+	}
+	var user User
+	if err := json.Unmarshal(val, &user); err != nil || user.SCRAMVerifier == "" {
+		return "", false
+	}
+	return user.SCRAMVerifier, true
+}
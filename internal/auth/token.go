@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// TokenPrefix starts every API token, so that leaked tokens are easy to
+// recognize in logs and by secret scanners.
+const TokenPrefix = "flydb_"
+
+// tokenLength is the number of random bytes of an API token.
+const tokenLength = 32
+
+// APIToken is the stored record of an API token. Only the SHA-256 hash of
+// the token is stored, as the key "_sys_tokens:<hash>", so the store does
+// not reveal usable tokens.
+type APIToken struct {
+	Username  string `json:"username"`             // User the token authenticates as
+	CreatedAt string `json:"created_at"`           // When the token was issued
+	ExpiresAt string `json:"expires_at,omitempty"` // When the token stops working; empty if never
+}
+
+// tokenKey returns the storage key of a token.
+func tokenKey(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return tokenKeyPrefix + hex.EncodeToString(hash[:])
+}
+
+// CreateToken issues an API token that authenticates as username until ttl
+// has passed, or until it is revoked if ttl is 0. The token is returned
+// only here; it cannot be recovered later.
+//
+// Example:
+//
+//	token, err := authMgr.CreateToken("alice", 24*time.Hour)
+func (m *AuthManager) CreateToken(username string, ttl time.Duration) (string, error) {
+	if ttl < 0 {
+		return "", errors.New("token lifetime must not be negative")
+	}
+	if _, err := m.store.Get(userKeyPrefix + username); err != nil {
+		return "", errors.New("user does not exist")
+	}
+
+	random := make([]byte, tokenLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", errors.New("failed to generate token: " + err.Error())
+	}
+	token := TokenPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	now := time.Now()
+	record := APIToken{Username: username, CreatedAt: now.Format(time.RFC3339)}
+	if ttl > 0 {
+		record.ExpiresAt = now.Add(ttl).Format(time.RFC3339)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	if err := m.store.Put(tokenKey(token), data); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// AuthenticateToken returns the user an API token authenticates as. ok is
+// false if the token is unknown, revoked or expired. Expired tokens are
+// deleted.
+func (m *AuthManager) AuthenticateToken(token string) (username string, ok bool) {
+	if !strings.HasPrefix(token, TokenPrefix) {
+		return "", false
+	}
+	key := tokenKey(token)
+	val, err := m.store.Get(key)
+	if err != nil {
+		return "", false
+	}
+	var record APIToken
+	if err := json.Unmarshal(val, &record); err != nil {
+		return "", false
+	}
+
+	if record.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, record.ExpiresAt)
+		if err != nil || !time.Now().Before(expiresAt) {
+			m.store.Delete(key)
+			return "", false
+		}
+	}
+	if _, err := m.store.Get(userKeyPrefix + record.Username); err != nil {
+		return "", false
+	}
+	return record.Username, true
+}
+
+// RevokeToken deletes an API token. Revoking an unknown token is not an
+// error.
+func (m *AuthManager) RevokeToken(token string) error {
+	return m.store.Delete(tokenKey(token))
+}
+
+// revokeUserTokens deletes all API tokens of a user.
+func (m *AuthManager) revokeUserTokens(username string) error {
+	data, err := m.store.Scan(tokenKeyPrefix)
+	if err != nil {
+		return err
+	}
+	for key, val := range data {
+		var record APIToken
+		if err := json.Unmarshal(val, &record); err != nil || record.Username != username {
+			continue
+		}
+		if err := m.store.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -425,8 +425,12 @@ func printObservabilityInfo(w io.Writer, cfg *config.Config) {
 
 	// Admin endpoint
 	if cfg.Observability.Admin.Enabled {
-		endpoints = append(endpoints, fmt.Sprintf("%sAdmin:%s %shttp://localhost%s%s",
-			AnsiDim, AnsiReset, AnsiGreen, cfg.Observability.Admin.Addr, AnsiReset))
+		scheme := "http"
+		if cfg.TLSEnabled {
+			scheme = "https"
+		}
+		endpoints = append(endpoints, fmt.Sprintf("%sAdmin:%s %s%s://localhost%s/v1%s",
+			AnsiDim, AnsiReset, AnsiGreen, scheme, cfg.Observability.Admin.Addr, AnsiReset))
 	}
 
 	// Print endpoints or "disabled" message
@@ -0,0 +1,756 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Anti-Entropy Repair:
+====================
+
+Replicas of a partition can diverge when a replication message is lost or
+a node misses writes during a network partition. Anti-entropy repair finds
+and fixes the differences without copying the whole partition.
+
+Every write the cluster manager makes to a key is stamped with a version
+from a hybrid clock (wall-clock nanoseconds, kept strictly increasing and
+moved past every version the node sees). The version is stored next to
+the value as a version record, _sys_version:<key>, and a delete leaves its
+version record behind as a tombstone. Keys written without the cluster
+manager, such as SQL rows, have no version record; their database's own
+replication keeps them in sync, and repair leaves them alone.
+
+Each node summarizes a partition as a Merkle tree of its versioned
+entries. The keys are divided into 2^merkleDepth ranges by the top bits
+of their FNV-64a hash; a leaf hashes the keys, versions and value hashes
+of one range, and every inner node hashes its two children:
+
+	         root
+	       /      \
+	    h(0-31)  h(32-63)
+	     ...        ...
+	range 0 ... range 63
+
+The partition leader repairs each replica in turn:
+
+ 1. Fetch the replica's tree with a MERKLE_TREE message
+ 2. Descend from the root into the subtrees whose hashes differ
+ 3. Re-read each differing range from the store and stream it with a
+    REPAIR_RANGE message
+
+Both sides merge by last-writer-wins: an entry is written only over an
+older version, so a write that lands while a range is in flight is never
+reverted. The replica answers with its entries that are newer than the
+leader's, and the leader applies those the same way. Keys are deleted
+only by a newer tombstone; a key one side lacks without a tombstone is
+copied, never deleted. A key and its version record are written as one
+batch.
+
+Each node indexes its versioned keys by partition in memory, so a
+partition's tree and ranges are read from its own keys rather than from
+every version record. Tombstones older than TombstoneTTL are collected on
+every node at each repair interval; a replica that was down for longer
+than that can copy a deleted key back.
+
+Repairs run every RepairInterval on the leader of each replicated
+partition, and on demand through RepairPartition (REPAIR PARTITION n in
+SQL). Progress is kept per partition and reported by GetStatus and
+GetMetrics. Under Raft every replica applies the same log, so repair is
+disabled.
+*/
+package cluster
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+
+	"flydb/internal/storage"
+)
+
+// merkleDepth is the depth of the Merkle trees compared by repair;
+// a tree has 2^merkleDepth key ranges.
+const merkleDepth = 6
+
+// DefaultRepairInterval is how often partition leaders repair replicas
+const DefaultRepairInterval = 10 * time.Minute
+
+// DefaultTombstoneTTL is how long the version record of a delete is kept
+const DefaultTombstoneTTL = 7 * 24 * time.Hour
+
+// errRepairUnderRaft is returned for repairs requested under Raft
+var errRepairUnderRaft = fmt.Errorf("anti-entropy repair is disabled under Raft; replicas catch up from the log")
+
+// ============================================================================
+// Entry Versions
+// ============================================================================
+
+// entryVersionPrefix prefixes the version record of every versioned key
+const entryVersionPrefix = "_sys_version:"
+
+// entryVersion is the version record of a key: the version of its last
+// write and whether that write was a delete
+type entryVersion struct {
+	Version uint64
+	Deleted bool
+}
+
+func (v entryVersion) encode() []byte {
+	buf := make([]byte, 9)
+	binary.BigEndian.PutUint64(buf, v.Version)
+	if v.Deleted {
+		buf[8] = 1
+	}
+	return buf
+}
+
+func decodeEntryVersion(data []byte) (entryVersion, bool) {
+	if len(data) != 9 {
+		return entryVersion{}, false
+	}
+	return entryVersion{Version: binary.BigEndian.Uint64(data), Deleted: data[8] == 1}, true
+}
+
+// isEntryVersionKey reports whether key holds a version record
+func isEntryVersionKey(key string) bool {
+	return strings.HasPrefix(key, entryVersionPrefix)
+}
+
+// nextVersionLocked returns a version newer than any this node has
+// issued or seen. The caller holds versionMu.
+func (ucm *UnifiedClusterManager) nextVersionLocked() uint64 {
+	v := uint64(time.Now().UnixNano())
+	if v <= ucm.versionClock {
+		v = ucm.versionClock + 1
+	}
+	ucm.versionClock = v
+	return v
+}
+
+// observeVersionLocked moves the clock past a version written elsewhere.
+// The caller holds versionMu.
+func (ucm *UnifiedClusterManager) observeVersionLocked(v uint64) {
+	if v > ucm.versionClock {
+		ucm.versionClock = v
+	}
+}
+
+// entryVersionOf returns the version record of key; keys without one
+// have version 0
+func (ucm *UnifiedClusterManager) entryVersionOf(key string) (entryVersion, error) {
+	data, err := ucm.store.Get(entryVersionPrefix + key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return entryVersion{}, nil
+	}
+	if err != nil || data == nil {
+		return entryVersion{}, err
+	}
+	v, _ := decodeEntryVersion(data)
+	return v, nil
+}
+
+// putEntryLocked writes a key and its version record as one batch. The
+// caller holds versionMu.
+func (ucm *UnifiedClusterManager) putEntryLocked(key string, value []byte, v entryVersion) error {
+	write := storage.TxOperation{Op: storage.OpPut, Key: key, Value: value}
+	if v.Deleted {
+		write = storage.TxOperation{Op: storage.OpDelete, Key: key}
+	}
+	ops := []storage.TxOperation{write, {Op: storage.OpPut, Key: entryVersionPrefix + key, Value: v.encode()}}
+	if err := ucm.writeBatch(ops); err != nil {
+		return err
+	}
+	ucm.indexVersionedKey(key)
+	return nil
+}
+
+// writeBatch applies ops to the store as one batch when it is a storage
+// engine, and one at a time otherwise
+func (ucm *UnifiedClusterManager) writeBatch(ops []storage.TxOperation) error {
+	if engine, ok := ucm.store.(storage.Engine); ok {
+		return storage.WriteBatch(engine, ops)
+	}
+	for _, op := range ops {
+		var err error
+		if op.Op == storage.OpDelete {
+			err = ucm.store.Delete(op.Key)
+		} else {
+			err = ucm.store.Put(op.Key, op.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexWrite updates the partition indexes for a write to key, which may
+// be a version record
+func (ucm *UnifiedClusterManager) indexWrite(op byte, key string) {
+	if isEntryVersionKey(key) {
+		key = strings.TrimPrefix(key, entryVersionPrefix)
+		if op == WALOpPut {
+			ucm.indexVersionedKey(key)
+		} else {
+			ucm.unindexVersionedKey(key)
+		}
+		return
+	}
+	if op == WALOpPut {
+		ucm.addKeyToPartitionIndex(key)
+	} else {
+		ucm.removeKeyFromPartitionIndex(key)
+	}
+}
+
+// indexVersionedKey records that key has a version record, tombstones
+// included
+func (ucm *UnifiedClusterManager) indexVersionedKey(key string) {
+	partitionID := ucm.GetPartitionForKey(key)
+	ucm.partitionKeysMu.Lock()
+	defer ucm.partitionKeysMu.Unlock()
+	if ucm.versionedKeys[partitionID] == nil {
+		ucm.versionedKeys[partitionID] = make(map[string]struct{})
+	}
+	ucm.versionedKeys[partitionID][key] = struct{}{}
+}
+
+func (ucm *UnifiedClusterManager) unindexVersionedKey(key string) {
+	partitionID := ucm.GetPartitionForKey(key)
+	ucm.partitionKeysMu.Lock()
+	defer ucm.partitionKeysMu.Unlock()
+	delete(ucm.versionedKeys[partitionID], key)
+}
+
+// versionedKeysOf returns the versioned keys of a partition. With ranges
+// set, only the keys in those Merkle ranges are returned.
+func (ucm *UnifiedClusterManager) versionedKeysOf(partitionID int, ranges map[int]bool) []string {
+	ucm.partitionKeysMu.RLock()
+	defer ucm.partitionKeysMu.RUnlock()
+	keys := make([]string, 0, len(ucm.versionedKeys[partitionID]))
+	for key := range ucm.versionedKeys[partitionID] {
+		if ranges == nil || ranges[merkleRange(key, merkleDepth)] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// collectTombstones deletes the version records of deletes older than
+// TombstoneTTL and returns how many it deleted
+func (ucm *UnifiedClusterManager) collectTombstones() (int, error) {
+	if ucm.store == nil {
+		return 0, nil
+	}
+	cutoff := uint64(time.Now().Add(-ucm.config.TombstoneTTL).UnixNano())
+
+	ucm.partitionKeysMu.RLock()
+	var keys []string
+	for _, versioned := range ucm.versionedKeys {
+		for key := range versioned {
+			keys = append(keys, key)
+		}
+	}
+	ucm.partitionKeysMu.RUnlock()
+
+	collected := 0
+	for _, key := range keys {
+		ok, err := ucm.collectTombstone(key, cutoff)
+		if err != nil {
+			return collected, err
+		}
+		if ok {
+			collected++
+		}
+	}
+	return collected, nil
+}
+
+// collectTombstone deletes the version record of key if it is a tombstone
+// older than cutoff
+func (ucm *UnifiedClusterManager) collectTombstone(key string, cutoff uint64) (bool, error) {
+	ucm.versionMu.Lock()
+	defer ucm.versionMu.Unlock()
+
+	v, err := ucm.entryVersionOf(key)
+	if err != nil || !v.Deleted || v.Version >= cutoff {
+		return false, err
+	}
+	if err := ucm.store.Delete(entryVersionPrefix + key); err != nil {
+		return false, err
+	}
+	ucm.unindexVersionedKey(key)
+	return true, nil
+}
+
+// writeVersioned applies a write accepted by this node to the cluster
+// store and stamps it with a new version
+func (ucm *UnifiedClusterManager) writeVersioned(op byte, key string, value []byte) error {
+	ucm.versionMu.Lock()
+	defer ucm.versionMu.Unlock()
+	return ucm.putEntryLocked(key, value, entryVersion{Version: ucm.nextVersionLocked(), Deleted: op == WALOpDelete})
+}
+
+// applyNewerEntry writes e unless the key already has the same or a newer
+// version, and reports whether it was written
+func (ucm *UnifiedClusterManager) applyNewerEntry(e repairEntry) (bool, error) {
+	ucm.versionMu.Lock()
+	defer ucm.versionMu.Unlock()
+
+	ucm.observeVersionLocked(e.Version)
+	current, err := ucm.entryVersionOf(e.Key)
+	if err != nil {
+		return false, err
+	}
+	if e.Version <= current.Version {
+		return false, nil
+	}
+	if err := ucm.putEntryLocked(e.Key, e.Value, entryVersion{Version: e.Version, Deleted: e.Deleted}); err != nil {
+		return false, err
+	}
+	if e.Deleted {
+		ucm.removeKeyFromPartitionIndex(e.Key)
+	} else {
+		ucm.addKeyToPartitionIndex(e.Key)
+	}
+	return true, nil
+}
+
+// ============================================================================
+// Merkle Trees
+// ============================================================================
+
+// merkleTree is a complete binary hash tree stored in heap order: node i
+// has children 2i+1 and 2i+2, and the leaves are the key ranges. Empty
+// subtrees have a nil hash.
+type merkleTree struct {
+	Depth int      `json:"depth"`
+	Nodes [][]byte `json:"nodes"`
+}
+
+// merkleRange returns the key range of a key in a tree of the given depth
+func merkleRange(key string, depth int) int {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int(h.Sum64() >> (64 - uint(depth)))
+}
+
+// buildMerkleTree builds the Merkle tree of a partition's entries
+func buildMerkleTree(entries map[string]repairEntry, depth int) *merkleTree {
+	ranges := make([][]string, 1<<uint(depth))
+	for key := range entries {
+		r := merkleRange(key, depth)
+		ranges[r] = append(ranges[r], key)
+	}
+
+	leaves := len(ranges)
+	t := &merkleTree{Depth: depth, Nodes: make([][]byte, 2*leaves-1)}
+	for r, keys := range ranges {
+		if len(keys) == 0 {
+			continue
+		}
+		sort.Strings(keys)
+		h := sha256.New()
+		for _, key := range keys {
+			e := entries[key]
+			valueHash := sha256.Sum256(e.Value)
+			binary.Write(h, binary.BigEndian, uint32(len(key)))
+			h.Write([]byte(key))
+			h.Write(entryVersion{Version: e.Version, Deleted: e.Deleted}.encode())
+			h.Write(valueHash[:])
+		}
+		t.Nodes[leaves-1+r] = h.Sum(nil)
+	}
+
+	var empty [sha256.Size]byte
+	for i := leaves - 2; i >= 0; i-- {
+		left, right := t.Nodes[2*i+1], t.Nodes[2*i+2]
+		if left == nil && right == nil {
+			continue
+		}
+		if left == nil {
+			left = empty[:]
+		}
+		if right == nil {
+			right = empty[:]
+		}
+		sum := sha256.Sum256(append(append([]byte(nil), left...), right...))
+		t.Nodes[i] = sum[:]
+	}
+	return t
+}
+
+// diff returns the key ranges whose hashes differ between two trees
+func (t *merkleTree) diff(other *merkleTree) []int {
+	leaves := 1 << uint(t.Depth)
+	if other == nil || other.Depth != t.Depth || len(other.Nodes) != len(t.Nodes) {
+		all := make([]int, leaves)
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+
+	var ranges []int
+	var walk func(i int)
+	walk = func(i int) {
+		if bytes.Equal(t.Nodes[i], other.Nodes[i]) {
+			return
+		}
+		if i >= leaves-1 {
+			ranges = append(ranges, i-(leaves-1))
+			return
+		}
+		walk(2*i + 1)
+		walk(2*i + 2)
+	}
+	walk(0)
+	return ranges
+}
+
+// Repair states
+const (
+	RepairRunning  = "RUNNING"
+	RepairComplete = "COMPLETE"
+	RepairFailed   = "FAILED"
+)
+
+// RepairStatus reports the progress of a partition's last repair
+type RepairStatus struct {
+	PartitionID    int       `json:"partition_id"`
+	State          string    `json:"state"`
+	Replica        string    `json:"replica,omitempty"` // Replica being repaired
+	RangesTotal    int       `json:"ranges_total"`      // Differing ranges found so far
+	RangesRepaired int       `json:"ranges_repaired"`
+	KeysStreamed   int       `json:"keys_streamed"`
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// repairEntry is a versioned entry streamed by repair. A deleted entry is
+// a tombstone.
+type repairEntry struct {
+	Key     string `json:"key"`
+	Value   []byte `json:"value,omitempty"`
+	Version uint64 `json:"version"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// partitionEntries reads the versioned entries of a partition from the
+// store, tombstones included. With ranges set, only the keys in those
+// Merkle ranges are returned.
+func (ucm *UnifiedClusterManager) partitionEntries(partitionID int, ranges map[int]bool) (map[string]repairEntry, error) {
+	if ucm.store == nil {
+		return nil, fmt.Errorf("store not configured")
+	}
+
+	entries := make(map[string]repairEntry)
+	for _, key := range ucm.versionedKeysOf(partitionID, ranges) {
+		v, err := ucm.entryVersionOf(key)
+		if err != nil {
+			return nil, err
+		}
+		if v.Version == 0 {
+			// Collected since the keys were listed
+			continue
+		}
+		e := repairEntry{Key: key, Version: v.Version, Deleted: v.Deleted}
+		if !v.Deleted {
+			if e.Value, err = ucm.store.Get(key); err != nil {
+				return nil, err
+			}
+		}
+		entries[key] = e
+	}
+	return entries, nil
+}
+
+// partitionMerkleTree builds the Merkle tree of a local partition
+func (ucm *UnifiedClusterManager) partitionMerkleTree(partitionID int) (*merkleTree, error) {
+	entries, err := ucm.partitionEntries(partitionID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return buildMerkleTree(entries, merkleDepth), nil
+}
+
+// applyRepairRange merges the leader's entries of the given ranges into
+// this replica by last-writer-wins. It returns the number of keys changed
+// and the replica's entries that are newer than the leader's.
+func (ucm *UnifiedClusterManager) applyRepairRange(partitionID int, ranges []int, entries []repairEntry) (int, []repairEntry, error) {
+	if ucm.IsRaftEnabled() && ucm.raftWriter != nil {
+		return 0, nil, errRepairUnderRaft
+	}
+	inRange := make(map[int]bool, len(ranges))
+	for _, r := range ranges {
+		inRange[r] = true
+	}
+	local, err := ucm.partitionEntries(partitionID, inRange)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	changed := 0
+	leader := make(map[string]uint64, len(entries))
+	for _, e := range entries {
+		leader[e.Key] = e.Version
+		ok, err := ucm.applyNewerEntry(e)
+		if err != nil {
+			return changed, nil, err
+		}
+		if ok {
+			changed++
+		}
+	}
+
+	var newer []repairEntry
+	for key, e := range local {
+		if e.Version > leader[key] {
+			newer = append(newer, e)
+		}
+	}
+	sort.Slice(newer, func(i, j int) bool { return newer[i].Key < newer[j].Key })
+	return changed, newer, nil
+}
+
+// RepairPartition compares the replicas of a partition with its leader
+// and streams the key ranges that differ. It runs on the partition leader;
+// on another node the request is sent to the leader.
+func (ucm *UnifiedClusterManager) RepairPartition(partitionID int) (RepairStatus, error) {
+	partition := ucm.GetPartition(partitionID)
+	if partition == nil {
+		return RepairStatus{}, fmt.Errorf("partition %d not found", partitionID)
+	}
+
+	ucm.partitionsMu.RLock()
+	leader := partition.Leader
+	replicas := append([]string(nil), partition.Replicas...)
+	ucm.partitionsMu.RUnlock()
+
+	if ucm.IsRaftEnabled() && ucm.raftWriter != nil {
+		return RepairStatus{}, errRepairUnderRaft
+	}
+	if leader == "" {
+		return RepairStatus{}, fmt.Errorf("partition %d has no leader", partitionID)
+	}
+	if leader != ucm.nodeID {
+		resp, err := ucm.sendTxnMessage(leader, forwardMessage{Type: "REPAIR_PARTITION", PartitionID: partitionID})
+		if err != nil {
+			return RepairStatus{}, err
+		}
+		var status RepairStatus
+		if err := json.Unmarshal(resp.Value, &status); err != nil {
+			return RepairStatus{}, err
+		}
+		return status, nil
+	}
+
+	return ucm.repairReplicas(partitionID, replicas)
+}
+
+// repairReplicas repairs every replica of a partition this node leads
+func (ucm *UnifiedClusterManager) repairReplicas(partitionID int, replicas []string) (RepairStatus, error) {
+	ucm.repairsMu.Lock()
+	if s, ok := ucm.repairs[partitionID]; ok && s.State == RepairRunning {
+		ucm.repairsMu.Unlock()
+		return *s, fmt.Errorf("repair of partition %d is already running", partitionID)
+	}
+	status := &RepairStatus{PartitionID: partitionID, State: RepairRunning, StartedAt: time.Now()}
+	ucm.repairs[partitionID] = status
+	ucm.repairsMu.Unlock()
+
+	ucm.emitEvent(ClusterEvent{
+		Type:        EventRepairStarted,
+		NodeID:      ucm.nodeID,
+		PartitionID: partitionID,
+		Details:     fmt.Sprintf("repairing %d replicas", len(replicas)-1),
+	})
+
+	err := ucm.repairEachReplica(partitionID, replicas, status)
+
+	ucm.repairsMu.Lock()
+	status.Replica = ""
+	status.FinishedAt = time.Now()
+	status.State = RepairComplete
+	if err != nil {
+		status.State = RepairFailed
+		status.Error = err.Error()
+	}
+	result := *status
+	ucm.repairsMu.Unlock()
+
+	ucm.metricsMu.Lock()
+	ucm.metrics.RepairRuns++
+	ucm.metricsMu.Unlock()
+
+	ucm.emitEvent(ClusterEvent{
+		Type:        EventRepairComplete,
+		NodeID:      ucm.nodeID,
+		PartitionID: partitionID,
+		Details:     fmt.Sprintf("%s: %d ranges, %d keys", result.State, result.RangesRepaired, result.KeysStreamed),
+	})
+	return result, err
+}
+
+func (ucm *UnifiedClusterManager) repairEachReplica(partitionID int, replicas []string, status *RepairStatus) error {
+	for _, replica := range replicas {
+		if replica == ucm.nodeID {
+			continue
+		}
+		ucm.repairsMu.Lock()
+		status.Replica = replica
+		ucm.repairsMu.Unlock()
+
+		tree, err := ucm.partitionMerkleTree(partitionID)
+		if err != nil {
+			return err
+		}
+		resp, err := ucm.sendTxnMessage(replica, forwardMessage{Type: "MERKLE_TREE", PartitionID: partitionID})
+		if err != nil {
+			return fmt.Errorf("replica %s: %w", replica, err)
+		}
+		var remote merkleTree
+		if err := json.Unmarshal(resp.Value, &remote); err != nil {
+			return fmt.Errorf("replica %s: %w", replica, err)
+		}
+
+		ranges := tree.diff(&remote)
+		ucm.repairsMu.Lock()
+		status.RangesTotal += len(ranges)
+		ucm.repairsMu.Unlock()
+
+		// Stream the leader's entries one range at a time, read afresh
+		// so that writes since the tree was built are included
+		for _, r := range ranges {
+			local, err := ucm.partitionEntries(partitionID, map[int]bool{r: true})
+			if err != nil {
+				return err
+			}
+			entries := make([]repairEntry, 0, len(local))
+			for _, e := range local {
+				entries = append(entries, e)
+			}
+			sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+
+			msg := forwardMessage{Type: "REPAIR_RANGE", PartitionID: partitionID, Ranges: []int{r}, Entries: entries}
+			resp, err := ucm.sendTxnMessage(replica, msg)
+			if err != nil {
+				return fmt.Errorf("replica %s range %d: %w", replica, r, err)
+			}
+
+			// Take the replica's newer entries
+			var newer []repairEntry
+			if len(resp.Value) > 0 {
+				if err := json.Unmarshal(resp.Value, &newer); err != nil {
+					return fmt.Errorf("replica %s range %d: %w", replica, r, err)
+				}
+			}
+			for _, e := range newer {
+				if _, err := ucm.applyNewerEntry(e); err != nil {
+					return err
+				}
+			}
+
+			ucm.repairsMu.Lock()
+			status.RangesRepaired++
+			status.KeysStreamed += len(entries) + len(newer)
+			ucm.repairsMu.Unlock()
+
+			ucm.metricsMu.Lock()
+			ucm.metrics.RepairRangesStreamed++
+			ucm.metrics.RepairKeysStreamed += int64(len(entries) + len(newer))
+			ucm.metricsMu.Unlock()
+		}
+	}
+	return nil
+}
+
+// RepairPartitionStats repairs a partition like RepairPartition and
+// returns the number of key ranges and keys streamed, for REPAIR PARTITION
+func (ucm *UnifiedClusterManager) RepairPartitionStats(id int) (int, int, error) {
+	status, err := ucm.RepairPartition(id)
+	return status.RangesRepaired, status.KeysStreamed, err
+}
+
+// GetRepairStatus returns the progress of the last repair of every
+// partition repaired by this node, ordered by partition
+func (ucm *UnifiedClusterManager) GetRepairStatus() []RepairStatus {
+	ucm.repairsMu.Lock()
+	defer ucm.repairsMu.Unlock()
+
+	statuses := make([]RepairStatus, 0, len(ucm.repairs))
+	for _, s := range ucm.repairs {
+		statuses = append(statuses, *s)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].PartitionID < statuses[j].PartitionID
+	})
+	return statuses
+}
+
+// activeRepairs returns the number of repairs in progress
+func (ucm *UnifiedClusterManager) activeRepairs() int {
+	ucm.repairsMu.Lock()
+	defer ucm.repairsMu.Unlock()
+
+	active := 0
+	for _, s := range ucm.repairs {
+		if s.State == RepairRunning {
+			active++
+		}
+	}
+	return active
+}
+
+// repairLoop periodically repairs the replicas of the partitions this
+// node leads
+func (ucm *UnifiedClusterManager) repairLoop() {
+	defer ucm.wg.Done()
+	if ucm.IsRaftEnabled() && ucm.raftWriter != nil {
+		return
+	}
+
+	ticker := time.NewTicker(ucm.config.RepairInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ucm.stopCh:
+			return
+		case <-ticker.C:
+			if _, err := ucm.collectTombstones(); err != nil {
+				fmt.Printf("Tombstone collection failed: %v\n", err)
+			}
+			for _, p := range ucm.GetAllPartitions() {
+				ucm.partitionsMu.RLock()
+				leader := p.Leader
+				replicas := append([]string(nil), p.Replicas...)
+				ucm.partitionsMu.RUnlock()
+				if leader != ucm.nodeID || len(replicas) < 2 {
+					continue
+				}
+				if _, err := ucm.repairReplicas(p.ID, replicas); err != nil {
+					fmt.Printf("Repair of partition %d failed: %v\n", p.ID, err)
+				}
+			}
+		}
+	}
+}
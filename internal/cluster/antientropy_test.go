@@ -0,0 +1,326 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"flydb/internal/storage"
+)
+
+func TestMerkleTreeDiff(t *testing.T) {
+	entries := make(map[string]repairEntry)
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		entries[key] = repairEntry{Key: key, Value: []byte(fmt.Sprint(i)), Version: 1}
+	}
+	a := buildMerkleTree(entries, merkleDepth)
+	if ranges := a.diff(buildMerkleTree(entries, merkleDepth)); len(ranges) != 0 {
+		t.Fatalf("Expected identical trees, got differing ranges %v", ranges)
+	}
+
+	// A changed value, a newer version, a tombstone, a missing key and an
+	// extra key each mark one range
+	changed := make(map[string]repairEntry, len(entries))
+	for k, v := range entries {
+		changed[k] = v
+	}
+	changed["key-7"] = repairEntry{Key: "key-7", Value: []byte("stale"), Version: 1}
+	changed["key-8"] = repairEntry{Key: "key-8", Value: []byte("8"), Version: 2}
+	changed["key-9"] = repairEntry{Key: "key-9", Version: 2, Deleted: true}
+	delete(changed, "key-42")
+	changed["extra"] = repairEntry{Key: "extra", Value: []byte("x"), Version: 1}
+
+	want := make(map[int]bool)
+	for _, key := range []string{"key-7", "key-8", "key-9", "key-42", "extra"} {
+		want[merkleRange(key, merkleDepth)] = true
+	}
+	ranges := a.diff(buildMerkleTree(changed, merkleDepth))
+	if len(ranges) != len(want) {
+		t.Fatalf("Expected ranges %v, got %v", want, ranges)
+	}
+	for _, r := range ranges {
+		if !want[r] {
+			t.Errorf("Range %d reported but unchanged", r)
+		}
+	}
+
+	if ranges := a.diff(nil); len(ranges) != 1<<merkleDepth {
+		t.Errorf("Expected every range to differ from a missing tree, got %d", len(ranges))
+	}
+}
+
+// newRepairTestNodes returns a leader and a replica of every partition.
+func newRepairTestNodes(t *testing.T) (*UnifiedClusterManager, *UnifiedClusterManager, *mockStore, *mockStore) {
+	storeA, storeB := newMockStore(), newMockStore()
+	a, b := newRepairTestPair(t, storeA, storeB)
+	return a, b, storeA, storeB
+}
+
+// newRepairTestPair returns a leader and a replica of every partition
+// with the given stores.
+func newRepairTestPair(t *testing.T, storeA, storeB StorageEngine) (*UnifiedClusterManager, *UnifiedClusterManager) {
+	a := newTxnTestNode(t, "node1", "", storeA)
+	b := newTxnTestNode(t, "node2", "", storeB)
+	linkTxnTestNodes(a, b)
+	for _, m := range []*UnifiedClusterManager{a, b} {
+		for _, p := range m.partitions {
+			p.Leader = "node1"
+			p.Replicas = []string{"node1", "node2"}
+			p.State = PartitionHealthy
+		}
+	}
+	return a, b
+}
+
+// partitionTestKeys returns n keys of partition pid with the given prefix.
+func partitionTestKeys(ucm *UnifiedClusterManager, pid, n int, prefix string) []string {
+	var keys []string
+	for i := 0; len(keys) < n; i++ {
+		if key := fmt.Sprintf("%s-%d", prefix, i); ucm.GetPartitionForKey(key) == pid {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestRepairPartition(t *testing.T) {
+	a, b, storeA, storeB := newRepairTestNodes(t)
+	pid := a.GetPartitionForKey("key-0")
+	keys := partitionTestKeys(a, pid, 20, "key")
+	put := func(nodes []*UnifiedClusterManager, key, value string, version uint64) {
+		for _, m := range nodes {
+			if _, err := m.applyNewerEntry(repairEntry{Key: key, Value: []byte(value), Version: version}); err != nil {
+				t.Fatalf("applyNewerEntry failed: %v", err)
+			}
+		}
+	}
+	both := []*UnifiedClusterManager{a, b}
+	for _, key := range keys {
+		put(both, key, "v1", 1)
+	}
+
+	// The replica missed an update and a delete, wrote one key the leader
+	// missed and has a key the leader never had
+	put(both[:1], keys[3], "v2", 2)
+	a.applyNewerEntry(repairEntry{Key: keys[5], Version: 2, Deleted: true})
+	put(both[1:], keys[7], "v3", 3)
+	orphan := partitionTestKeys(a, pid, 1, "orphan")[0]
+	put(both[1:], orphan, "x", 1)
+
+	// Keys without a version record are left alone
+	unversioned := partitionTestKeys(a, pid, 1, "row")[0]
+	storeB.Put(unversioned, []byte("sql"))
+
+	// Repairs requested on a replica run on the leader
+	status, err := b.RepairPartition(pid)
+	if err != nil {
+		t.Fatalf("RepairPartition failed: %v", err)
+	}
+	if status.State != RepairComplete || status.RangesRepaired == 0 || status.RangesRepaired != status.RangesTotal {
+		t.Errorf("Unexpected repair status: %+v", status)
+	}
+	if status.RangesRepaired > 4 || status.KeysStreamed >= len(keys) {
+		t.Errorf("Repair streamed more than the differing ranges: %+v", status)
+	}
+	want := map[string]string{keys[3]: "v2", keys[7]: "v3", orphan: "x"}
+	for _, key := range keys {
+		if _, ok := want[key]; !ok && key != keys[5] {
+			want[key] = "v1"
+		}
+	}
+	for _, store := range []*mockStore{storeA, storeB} {
+		for key, v := range want {
+			if value, _ := store.Get(key); string(value) != v {
+				t.Errorf("Key %s is %q after repair, want %q", key, value, v)
+			}
+		}
+		if value, _ := store.Get(keys[5]); value != nil {
+			t.Error("Tombstoned key not deleted")
+		}
+	}
+	if value, _ := storeA.Get(unversioned); value != nil {
+		t.Error("Unversioned key was copied")
+	}
+	if value, _ := storeB.Get(unversioned); string(value) != "sql" {
+		t.Error("Unversioned key was changed")
+	}
+
+	// A second repair finds nothing to stream
+	status, err = a.RepairPartition(pid)
+	if err != nil || status.RangesTotal != 0 {
+		t.Errorf("Expected a clean repair, got %+v, %v", status, err)
+	}
+
+	if repairs := a.GetStatus().Repairs; len(repairs) != 1 || repairs[0].PartitionID != pid {
+		t.Errorf("Expected the repair in the cluster status, got %+v", repairs)
+	}
+	if m := a.GetMetrics(); m.RepairRuns != 2 || m.RepairRangesStreamed == 0 || m.ActiveRepairs != 0 {
+		t.Errorf("Unexpected repair metrics: %+v", m)
+	}
+}
+
+func TestRepairConcurrentWrites(t *testing.T) {
+	a, b, _, storeB := newRepairTestNodes(t)
+	pid := a.GetPartitionForKey("key-0")
+	keys := partitionTestKeys(a, pid, 10, "key")
+
+	// A write that reaches the replica after the leader read its range is
+	// not reverted, and an older tombstone does not delete it
+	b.applyNewerEntry(repairEntry{Key: keys[0], Value: []byte("new"), Version: 9})
+	changed, newer, err := b.applyRepairRange(pid, []int{merkleRange(keys[0], merkleDepth)}, []repairEntry{
+		{Key: keys[0], Value: []byte("old"), Version: 5},
+	})
+	if err != nil || changed != 0 {
+		t.Fatalf("Expected no change, got %d, %v", changed, err)
+	}
+	if len(newer) != 1 || newer[0].Key != keys[0] || newer[0].Version != 9 {
+		t.Fatalf("Expected the replica's newer entry back, got %+v", newer)
+	}
+	b.applyRepairRange(pid, []int{merkleRange(keys[0], merkleDepth)}, []repairEntry{
+		{Key: keys[0], Version: 7, Deleted: true},
+	})
+	if value, _ := storeB.Get(keys[0]); string(value) != "new" {
+		t.Fatalf("Newer write was changed to %q", value)
+	}
+
+	// Repairs running while both nodes take writes never revert one. Each
+	// write reaches the replica first, as if the leader's copy lagged.
+	const rounds = 200
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, err := a.RepairPartition(pid); err != nil {
+				t.Errorf("RepairPartition failed: %v", err)
+				return
+			}
+		}
+	}()
+	last := make(map[string]string)
+	for i := 0; i < rounds; i++ {
+		key := keys[i%len(keys)]
+		e := repairEntry{Key: key, Value: []byte(fmt.Sprint(i)), Version: uint64(100 + i)}
+		if i%7 == 0 {
+			e.Value, e.Deleted = nil, true
+		}
+		b.applyNewerEntry(e)
+		a.applyNewerEntry(e)
+		last[key] = string(e.Value)
+	}
+	close(stop)
+	wg.Wait()
+
+	for key, want := range last {
+		if value, _ := storeB.Get(key); string(value) != want {
+			t.Errorf("Replica has %q for %s, want %q", value, key, want)
+		}
+	}
+}
+
+func TestRepairPartitionOnStorageEngine(t *testing.T) {
+	open := func() *storage.UnifiedStorageEngine {
+		engine, err := storage.NewStorageEngine(storage.StorageConfig{DataDir: t.TempDir(), BufferPoolSize: 64})
+		if err != nil {
+			t.Fatalf("NewStorageEngine failed: %v", err)
+		}
+		t.Cleanup(func() { engine.Close() })
+		return engine
+	}
+	storeA, storeB := open(), open()
+	a, b := newRepairTestPair(t, storeA, storeB)
+	pid := a.GetPartitionForKey("key-0")
+	keys := partitionTestKeys(a, pid, 10, "key")
+
+	// The replica has none of the leader's keys, so none has a version
+	// record there yet, and it missed a delete
+	for _, key := range keys {
+		if err := a.writeVersioned(WALOpPut, key, []byte("v-"+key)); err != nil {
+			t.Fatalf("writeVersioned failed: %v", err)
+		}
+	}
+	if _, err := b.applyNewerEntry(repairEntry{Key: keys[0], Value: []byte("old"), Version: 1}); err != nil {
+		t.Fatalf("applyNewerEntry failed: %v", err)
+	}
+	if err := a.writeVersioned(WALOpDelete, keys[0], nil); err != nil {
+		t.Fatalf("writeVersioned failed: %v", err)
+	}
+
+	status, err := a.RepairPartition(pid)
+	if err != nil {
+		t.Fatalf("RepairPartition failed: %v", err)
+	}
+	if status.State != RepairComplete || status.KeysStreamed == 0 {
+		t.Errorf("Unexpected repair status: %+v", status)
+	}
+	for _, key := range keys[1:] {
+		if value, err := storeB.Get(key); err != nil || string(value) != "v-"+key {
+			t.Errorf("Key %s is %q after repair (%v)", key, value, err)
+		}
+	}
+	if _, err := storeB.Get(keys[0]); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the deleted key to be gone from the replica, got %v", err)
+	}
+	if status, err := a.RepairPartition(pid); err != nil || status.RangesTotal != 0 {
+		t.Errorf("Expected a clean repair, got %+v, %v", status, err)
+	}
+}
+
+func TestCollectTombstones(t *testing.T) {
+	a, _, store, _ := newRepairTestNodes(t)
+	pid := a.GetPartitionForKey("key-0")
+	keys := partitionTestKeys(a, pid, 3, "key")
+
+	old := uint64(time.Now().Add(-2 * a.config.TombstoneTTL).UnixNano())
+	a.applyNewerEntry(repairEntry{Key: keys[0], Version: old, Deleted: true})
+	a.applyNewerEntry(repairEntry{Key: keys[1], Value: []byte("v"), Version: old})
+	if err := a.writeVersioned(WALOpDelete, keys[2], nil); err != nil {
+		t.Fatalf("writeVersioned failed: %v", err)
+	}
+
+	collected, err := a.collectTombstones()
+	if err != nil || collected != 1 {
+		t.Fatalf("Expected one tombstone collected, got %d, %v", collected, err)
+	}
+	if record, _ := store.Get(entryVersionPrefix + keys[0]); record != nil {
+		t.Error("Old tombstone was not collected")
+	}
+	entries, err := a.partitionEntries(pid, nil)
+	if err != nil {
+		t.Fatalf("partitionEntries failed: %v", err)
+	}
+	if _, ok := entries[keys[0]]; ok {
+		t.Error("Collected tombstone is still listed")
+	}
+	if e, ok := entries[keys[1]]; !ok || e.Deleted {
+		t.Error("Live key lost its version record")
+	}
+	if e, ok := entries[keys[2]]; !ok || !e.Deleted {
+		t.Error("Recent tombstone was collected")
+	}
+}
@@ -42,6 +42,39 @@ Term-Based Leadership:
 
 Time is divided into terms (monotonically increasing integers).
 Each term has at most one leader. Terms act as logical clocks.
+
+Persistence:
+============
+
+When DataDir is set, the current term, the vote and the log are kept in a
+RaftLogStore (see raft_log.go) and reloaded by Start. They are fsynced
+before the node replies to RequestVote or AppendEntries, before a candidate
+asks for votes, and before Propose returns. With an empty DataDir the node
+keeps its state in memory only.
+
+Snapshots:
+==========
+
+With a RaftStateMachine set, the node snapshots the state machine every
+SnapshotThreshold applied entries and discards the log up to the snapshot
+(see raft_snapshot.go). A follower that needs entries the leader has
+discarded, such as a new node, is sent the snapshot with InstallSnapshot.
+log[0] is a sentinel for the last entry covered by the snapshot, or for
+index 0 before the first snapshot.
+
+Membership:
+===========
+
+The set of voters and learners is itself replicated through the log as
+LogEntryConfig entries, one server at a time (see raft_membership.go).
+Learners receive the log but do not vote or count towards commit.
+
+Reads:
+======
+
+A voter that has heard from the leader within the election timeout refuses
+votes, unless the candidate was asked to take over by the leader. This lets
+the leader serve lease reads (see raft_read.go).
 */
 package cluster
 
@@ -87,7 +120,10 @@ type RaftConfig struct {
 	ElectionTimeout   time.Duration `json:"election_timeout"`
 	HeartbeatInterval time.Duration `json:"heartbeat_interval"`
 	EnablePreVote     bool          `json:"enable_pre_vote"`
-	DataDir           string        `json:"data_dir"`
+	DataDir           string        `json:"data_dir"` // Directory for the log and hard state; empty keeps them in memory
+	SnapshotThreshold uint64        `json:"snapshot_threshold"`  // Applied entries between snapshots; 0 disables them
+	SnapshotChunkSize int64         `json:"snapshot_chunk_size"` // Bytes per InstallSnapshot RPC
+	Join              bool          `json:"join"`                // Start outside the cluster and wait to be added by its leader
 }
 
 // DefaultRaftConfig returns a RaftConfig with sensible defaults
@@ -101,6 +137,8 @@ func DefaultRaftConfig(nodeID, nodeAddr string) RaftConfig {
 		HeartbeatInterval: 150 * time.Millisecond,
 		EnablePreVote:     true,
 		DataDir:           "./data/raft",
+		SnapshotThreshold: 10000,
+		SnapshotChunkSize: 1 << 20, // 1MB
 	}
 }
 
@@ -130,6 +168,8 @@ type RaftNode struct {
 	currentTerm uint64
 	votedFor    string
 	log         []LogEntry
+	logStore    *RaftLogStore // nil if DataDir is empty
+	snapshot    *RaftSnapshotMeta // Latest snapshot, nil if none
 
 	// Volatile state on all servers
 	commitIndex uint64
@@ -140,13 +180,26 @@ type RaftNode struct {
 	nextIndex  map[string]uint64
 	matchIndex map[string]uint64
 
-	// Cluster membership
-	peers   map[string]*RaftPeer
-	peersMu sync.RWMutex
+	// Cluster membership. peers holds every other voter and learner.
+	peers             map[string]*RaftPeer
+	peersMu           sync.RWMutex
+	membership        RaftMembership // Latest configuration in the log
+	membershipIndex   uint64         // Index of its config entry; 0 if it predates the log
+	initialMembership RaftMembership // Configuration before any config entry
+	transferTarget    string         // Voter leadership is being transferred to
+	transferSent      bool           // TimeoutNow was sent this term; leases are void
+
+	// Reads
+	acks          map[string]time.Time // Send time of the latest heartbeat each peer answered this term
+	leaderContact time.Time            // When the leader was last heard from
+	freshIndex    uint64               // Commit index the leader reported at freshAt
+	freshAt       time.Time            // When the log last matched the leader's
 
 	// Channels
 	applyCh     chan LogEntry
 	stopCh      chan struct{}
+	stopOnce    sync.Once
+	stopErr     error
 	heartbeatCh chan struct{}
 	voteCh      chan *VoteResult
 
@@ -159,6 +212,13 @@ type RaftNode struct {
 	leaderAddr string
 	leaderMu   sync.RWMutex
 
+	// Snapshots
+	stateMachine RaftStateMachine
+	snapshotMu   sync.Mutex // Serializes taking, receiving and installing snapshots
+	snapshotRecv *raftSnapshotReceive
+	snapshotting int32 // atomic; 1 while an automatic snapshot is running
+	zeroCopy     *ZeroCopyManager
+
 	// Callbacks
 	onBecomeLeader   func()
 	onBecomeFollower func(leaderID string)
@@ -173,6 +233,7 @@ type RaftPeer struct {
 	lastContact   time.Time
 	isHealthy     bool
 	failedAttempts int
+	sendingSnapshot bool
 }
 
 // VoteResult represents the result of a vote request
@@ -191,6 +252,13 @@ const (
 	RaftMsgPreVote         byte = 0x14
 	RaftMsgPreVoteResp     byte = 0x15
 	RaftMsgInstallSnapshot byte = 0x16
+	RaftMsgInstallSnapshotResp byte = 0x17
+	RaftMsgTimeoutNow      byte = 0x18
+	RaftMsgTimeoutNowResp  byte = 0x19
+	RaftMsgMembershipChange     byte = 0x1A
+	RaftMsgMembershipChangeResp byte = 0x1B
+	RaftMsgReadIndex            byte = 0x1C
+	RaftMsgReadIndexResp        byte = 0x1D
 )
 
 // RequestVoteArgs contains arguments for RequestVote RPC
@@ -200,6 +268,7 @@ type RequestVoteArgs struct {
 	LastLogIndex uint64 `json:"last_log_index"`
 	LastLogTerm  uint64 `json:"last_log_term"`
 	PreVote      bool   `json:"pre_vote"`
+	Transfer     bool   `json:"transfer,omitempty"` // Candidate was asked to take over by the leader
 }
 
 // RequestVoteReply contains the reply for RequestVote RPC
@@ -212,6 +281,7 @@ type RequestVoteReply struct {
 type AppendEntriesArgs struct {
 	Term         uint64     `json:"term"`
 	LeaderID     string     `json:"leader_id"`
+	LeaderAddr   string     `json:"leader_addr,omitempty"`
 	PrevLogIndex uint64     `json:"prev_log_index"`
 	PrevLogTerm  uint64     `json:"prev_log_term"`
 	Entries      []LogEntry `json:"entries"`
@@ -237,24 +307,18 @@ func NewRaftNode(config RaftConfig, applyCh chan LogEntry) *RaftNode {
 		lastApplied: 0,
 		nextIndex:   make(map[string]uint64),
 		matchIndex:  make(map[string]uint64),
+		acks:        make(map[string]time.Time),
 		peers:       make(map[string]*RaftPeer),
 		applyCh:     applyCh,
 		stopCh:      make(chan struct{}),
 		heartbeatCh: make(chan struct{}, 1),
 		voteCh:      make(chan *VoteResult, 100),
+		zeroCopy:    NewZeroCopyManager(),
 	}
 
 	// Initialize as follower
 	atomic.StoreInt32(&rn.state, int32(StateFollower))
 
-	// Add initial peers
-	for _, peerAddr := range config.Peers {
-		rn.peers[peerAddr] = &RaftPeer{
-			Addr:      peerAddr,
-			isHealthy: true,
-		}
-	}
-
 	// Append initial no-op entry to simplify log handling
 	rn.log = append(rn.log, LogEntry{
 		Term:  0,
@@ -262,11 +326,22 @@ func NewRaftNode(config RaftConfig, applyCh chan LogEntry) *RaftNode {
 		Type:  LogEntryNoop,
 	})
 
+	// Bootstrap from the configured peers unless joining an existing cluster
+	if !config.Join {
+		rn.initialMembership = bootstrapMembership(config)
+	}
+	rn.refreshMembership()
+
 	return rn
 }
 
 // Start begins the Raft consensus protocol
 func (rn *RaftNode) Start() error {
+	// Reload the term, vote and log before taking part in the protocol
+	if err := rn.recover(); err != nil {
+		return err
+	}
+
 	// Start network listener
 	addr := fmt.Sprintf(":%d", rn.config.ClusterPort)
 	ln, err := net.Listen("tcp", addr)
@@ -286,16 +361,157 @@ func (rn *RaftNode) Start() error {
 	return nil
 }
 
-// Stop gracefully shuts down the Raft node
+// Stop gracefully shuts down the Raft node. Only the first call has any
+// effect; later calls wait for it and return its result.
 func (rn *RaftNode) Stop() error {
-	close(rn.stopCh)
-	if rn.listener != nil {
-		rn.listener.Close()
+	rn.stopOnce.Do(func() {
+		close(rn.stopCh)
+		if rn.listener != nil {
+			rn.listener.Close()
+		}
+		rn.wg.Wait()
+
+		rn.mu.Lock()
+		defer rn.mu.Unlock()
+		if rn.logStore != nil {
+			rn.stopErr = rn.logStore.Close()
+		}
+	})
+	return rn.stopErr
+}
+
+// recover loads the persistent state from DataDir.
+func (rn *RaftNode) recover() error {
+	if rn.config.DataDir == "" {
+		return nil
+	}
+
+	store, err := OpenRaftLogStore(rn.config.DataDir)
+	if err != nil {
+		return err
+	}
+	hs, entries, err := store.Load()
+	if err != nil {
+		store.Close()
+		return fmt.Errorf("failed to recover Raft state: %w", err)
+	}
+	snapshot, err := store.LoadSnapshot()
+	if err != nil {
+		store.Close()
+		return fmt.Errorf("failed to recover Raft snapshot: %w", err)
+	}
+
+	// Entries covered by the snapshot may survive in the first segment
+	sentinel := LogEntry{Type: LogEntryNoop}
+	if snapshot != nil {
+		sentinel.Index, sentinel.Term = snapshot.Index, snapshot.Term
+	}
+	for len(entries) > 0 && entries[0].Index <= sentinel.Index {
+		entries = entries[1:]
+	}
+	if len(entries) > 0 && entries[0].Index != sentinel.Index+1 {
+		store.Close()
+		return fmt.Errorf("failed to recover Raft state: log starts at index %d after snapshot at %d", entries[0].Index, sentinel.Index)
+	}
+
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	rn.logStore = store
+	rn.snapshot = snapshot
+	rn.currentTerm = hs.Term
+	rn.votedFor = hs.VotedFor
+	rn.log = append([]LogEntry{sentinel}, entries...)
+	rn.commitIndex = sentinel.Index
+	rn.lastApplied = sentinel.Index
+	rn.refreshMembership()
+
+	// A crash while restoring a received snapshot leaves the state machine
+	// half replaced; restore it again
+	if snapshot != nil && rn.stateMachine != nil && store.restoreInterrupted() {
+		if err := rn.restoreSnapshot(*snapshot); err != nil {
+			return err
+		}
+	}
+
+	if len(entries) > 0 || hs.Term > 0 {
+		fmt.Printf("Raft node %s recovered term %d with %d log entries after index %d\n", rn.config.NodeID, hs.Term, len(entries), sentinel.Index)
+	}
+	return nil
+}
+
+// persistHardState durably saves the current term and vote.
+// Must be called with rn.mu held.
+func (rn *RaftNode) persistHardState() error {
+	if rn.logStore == nil {
+		return nil
+	}
+	return rn.logStore.SaveHardState(RaftHardState{Term: rn.currentTerm, VotedFor: rn.votedFor})
+}
+
+// appendLog durably appends entries to the log.
+// Must be called with rn.mu held.
+func (rn *RaftNode) appendLog(entries ...LogEntry) error {
+	if rn.logStore != nil {
+		if err := rn.logStore.Append(entries); err != nil {
+			return err
+		}
+	}
+	rn.log = append(rn.log, entries...)
+	for _, entry := range entries {
+		if entry.Type == LogEntryConfig {
+			// A configuration takes effect as soon as it is in the log
+			rn.refreshMembership()
+			break
+		}
+	}
+	return nil
+}
+
+// truncateLog durably removes the entries from index onwards.
+// Must be called with rn.mu held.
+func (rn *RaftNode) truncateLog(index uint64) error {
+	if rn.logStore != nil {
+		if err := rn.logStore.TruncateFrom(index); err != nil {
+			return err
+		}
+	}
+	rn.log = rn.log[:index-rn.log[0].Index]
+	if rn.membershipIndex >= index {
+		// The configuration was discarded; fall back to the previous one
+		rn.refreshMembership()
 	}
-	rn.wg.Wait()
 	return nil
 }
 
+// lastLogIndex returns the index of the last log entry.
+// Must be called with rn.mu held.
+func (rn *RaftNode) lastLogIndex() uint64 {
+	return rn.log[0].Index + uint64(len(rn.log)) - 1
+}
+
+// entryAt returns the log entry at index, or false if it was discarded by
+// a snapshot or does not exist yet.
+// Must be called with rn.mu held.
+func (rn *RaftNode) entryAt(index uint64) (LogEntry, bool) {
+	first := rn.log[0].Index
+	if index <= first || index > rn.lastLogIndex() {
+		return LogEntry{}, false
+	}
+	return rn.log[index-first], true
+}
+
+// termAt returns the term of the entry at index. The last index covered by
+// the snapshot reports the snapshot's term; other missing indexes report 0.
+// Must be called with rn.mu held.
+func (rn *RaftNode) termAt(index uint64) uint64 {
+	first := rn.log[0].Index
+	if index < first || index > rn.lastLogIndex() {
+		return 0
+	}
+	return rn.log[index-first].Term
+}
+
 // GetState returns the current state of the Raft node
 func (rn *RaftNode) GetState() RaftState {
 	return RaftState(atomic.LoadInt32(&rn.state))
@@ -374,6 +590,14 @@ func (rn *RaftNode) handleConnection(conn net.Conn) {
 		rn.handleRequestVote(conn, msgType[0] == RaftMsgPreVote)
 	case RaftMsgAppendEntries:
 		rn.handleAppendEntries(conn)
+	case RaftMsgInstallSnapshot:
+		rn.handleInstallSnapshot(conn)
+	case RaftMsgTimeoutNow:
+		rn.handleTimeoutNow(conn)
+	case RaftMsgReadIndex:
+		rn.handleReadIndex(conn)
+	case RaftMsgMembershipChange:
+		rn.handleMembershipChange(conn)
 	}
 }
 
@@ -399,7 +623,7 @@ func (rn *RaftNode) runElectionTimer() {
 			continue
 		case <-time.After(timeout):
 			if state != StateLeader {
-				rn.startElection()
+				rn.startElection(false)
 			}
 		}
 	}
@@ -412,12 +636,19 @@ func (rn *RaftNode) randomElectionTimeout() time.Duration {
 	return base + jitter
 }
 
-// startElection initiates a leader election
-func (rn *RaftNode) startElection() {
+// startElection initiates a leader election. An election started because
+// the leader is transferring leadership skips the pre-vote.
+func (rn *RaftNode) startElection(transfer bool) {
 	rn.mu.Lock()
 
+	// Learners and servers outside the cluster never stand for election
+	if !rn.membership.IsVoter(rn.config.NodeAddr) {
+		rn.mu.Unlock()
+		return
+	}
+
 	// If pre-vote is enabled, do pre-vote first
-	if rn.config.EnablePreVote && rn.GetState() == StateFollower {
+	if rn.config.EnablePreVote && rn.GetState() == StateFollower && !transfer {
 		rn.mu.Unlock()
 		if !rn.conductPreVote() {
 			return // Pre-vote failed, don't start real election
@@ -429,39 +660,46 @@ func (rn *RaftNode) startElection() {
 	atomic.StoreInt32(&rn.state, int32(StateCandidate))
 	rn.currentTerm++
 	rn.votedFor = rn.config.NodeID
+	if err := rn.persistHardState(); err != nil {
+		atomic.StoreInt32(&rn.state, int32(StateFollower))
+		rn.mu.Unlock()
+		fmt.Printf("Node %s failed to persist term %d: %v\n", rn.config.NodeID, rn.currentTerm, err)
+		return
+	}
 	currentTerm := rn.currentTerm
-	lastLogIndex := uint64(len(rn.log) - 1)
-	lastLogTerm := rn.log[lastLogIndex].Term
-
-	rn.mu.Unlock()
+	lastLogIndex := rn.lastLogIndex()
+	lastLogTerm := rn.termAt(lastLogIndex)
 
 	fmt.Printf("Node %s starting election for term %d\n", rn.config.NodeID, currentTerm)
 
 	// Vote for self
 	votesReceived := 1
-	votesNeeded := (len(rn.peers) + 1) / 2 + 1
+	votesNeeded := rn.membership.quorum()
+	voters := rn.membership.otherVoters(rn.config.NodeAddr)
+
+	// A single voter is its own majority
+	if votesReceived >= votesNeeded {
+		rn.becomeLeader()
+		rn.mu.Unlock()
+		return
+	}
+	rn.mu.Unlock()
 
 	// Request votes from all peers
 	var votesMu sync.Mutex
 	var wg sync.WaitGroup
 
-	rn.peersMu.RLock()
-	peers := make([]*RaftPeer, 0, len(rn.peers))
-	for _, peer := range rn.peers {
-		peers = append(peers, peer)
-	}
-	rn.peersMu.RUnlock()
-
-	for _, peer := range peers {
+	for _, voter := range voters {
 		wg.Add(1)
-		go func(p *RaftPeer) {
+		go func(addr string) {
 			defer wg.Done()
-			reply := rn.sendRequestVote(p.Addr, RequestVoteArgs{
+			reply := rn.sendRequestVote(addr, RequestVoteArgs{
 				Term:         currentTerm,
 				CandidateID:  rn.config.NodeID,
 				LastLogIndex: lastLogIndex,
 				LastLogTerm:  lastLogTerm,
 				PreVote:      false,
+				Transfer:     transfer,
 			})
 
 			if reply == nil {
@@ -484,7 +722,7 @@ func (rn *RaftNode) startElection() {
 				}
 				votesMu.Unlock()
 			}
-		}(peer)
+		}(voter)
 	}
 
 	wg.Wait()
@@ -495,28 +733,22 @@ func (rn *RaftNode) startElection() {
 func (rn *RaftNode) conductPreVote() bool {
 	rn.mu.RLock()
 	currentTerm := rn.currentTerm + 1 // Hypothetical next term
-	lastLogIndex := uint64(len(rn.log) - 1)
-	lastLogTerm := rn.log[lastLogIndex].Term
+	lastLogIndex := rn.lastLogIndex()
+	lastLogTerm := rn.termAt(lastLogIndex)
+	votesNeeded := rn.membership.quorum()
+	voters := rn.membership.otherVoters(rn.config.NodeAddr)
 	rn.mu.RUnlock()
 
 	votesReceived := 1 // Vote for self
-	votesNeeded := (len(rn.peers) + 1) / 2 + 1
 
 	var votesMu sync.Mutex
 	var wg sync.WaitGroup
 
-	rn.peersMu.RLock()
-	peers := make([]*RaftPeer, 0, len(rn.peers))
-	for _, peer := range rn.peers {
-		peers = append(peers, peer)
-	}
-	rn.peersMu.RUnlock()
-
-	for _, peer := range peers {
+	for _, voter := range voters {
 		wg.Add(1)
-		go func(p *RaftPeer) {
+		go func(addr string) {
 			defer wg.Done()
-			reply := rn.sendRequestVote(p.Addr, RequestVoteArgs{
+			reply := rn.sendRequestVote(addr, RequestVoteArgs{
 				Term:         currentTerm,
 				CandidateID:  rn.config.NodeID,
 				LastLogIndex: lastLogIndex,
@@ -529,7 +761,7 @@ func (rn *RaftNode) conductPreVote() bool {
 				votesReceived++
 				votesMu.Unlock()
 			}
-		}(peer)
+		}(voter)
 	}
 
 	wg.Wait()
@@ -539,12 +771,21 @@ func (rn *RaftNode) conductPreVote() bool {
 // becomeFollower transitions the node to follower state
 func (rn *RaftNode) becomeFollower(term uint64, leaderID string) {
 	atomic.StoreInt32(&rn.state, int32(StateFollower))
-	rn.currentTerm = term
-	rn.votedFor = ""
+	if term > rn.currentTerm {
+		// The vote only resets with a new term; clearing it within a
+		// term would let the node vote twice
+		rn.currentTerm = term
+		rn.votedFor = ""
+		if err := rn.persistHardState(); err != nil {
+			fmt.Printf("Node %s failed to persist term %d: %v\n", rn.config.NodeID, term, err)
+		}
+	}
 
 	rn.leaderMu.Lock()
 	rn.leaderID = leaderID
+	rn.leaderAddr = ""
 	rn.leaderMu.Unlock()
+	rn.transferTarget = ""
 
 	if rn.onBecomeFollower != nil {
 		go rn.onBecomeFollower(leaderID)
@@ -561,20 +802,27 @@ func (rn *RaftNode) becomeLeader() {
 	rn.leaderID = rn.config.NodeID
 	rn.leaderAddr = rn.config.NodeAddr
 	rn.leaderMu.Unlock()
+	rn.acks = make(map[string]time.Time)
+	rn.transferSent = false
 
 	// Initialize leader state
-	lastLogIndex := uint64(len(rn.log))
+	nextIndex := rn.lastLogIndex() + 1
 	for peerAddr := range rn.peers {
-		rn.nextIndex[peerAddr] = lastLogIndex
+		rn.nextIndex[peerAddr] = nextIndex
 		rn.matchIndex[peerAddr] = 0
 	}
 
 	// Append no-op entry to commit entries from previous terms
-	rn.log = append(rn.log, LogEntry{
+	if err := rn.appendLog(LogEntry{
 		Term:  rn.currentTerm,
-		Index: lastLogIndex,
+		Index: nextIndex,
 		Type:  LogEntryNoop,
-	})
+	}); err != nil {
+		fmt.Printf("Node %s failed to persist no-op entry: %v\n", rn.config.NodeID, err)
+		rn.becomeFollower(rn.currentTerm, "")
+		return
+	}
+	rn.updateCommitIndex()
 
 	if rn.onBecomeLeader != nil {
 		go rn.onBecomeLeader()
@@ -622,20 +870,25 @@ func (rn *RaftNode) broadcastAppendEntries() {
 func (rn *RaftNode) sendAppendEntriesToPeer(peer *RaftPeer) {
 	rn.mu.RLock()
 	nextIdx := rn.nextIndex[peer.Addr]
-	prevLogIndex := nextIdx - 1
-	prevLogTerm := uint64(0)
-	if prevLogIndex > 0 && prevLogIndex < uint64(len(rn.log)) {
-		prevLogTerm = rn.log[prevLogIndex].Term
+	first := rn.log[0].Index
+	if nextIdx <= first && rn.snapshot != nil {
+		// The peer needs entries that were discarded by the snapshot
+		rn.mu.RUnlock()
+		rn.sendSnapshotToPeer(peer)
+		return
 	}
+	prevLogIndex := nextIdx - 1
+	prevLogTerm := rn.termAt(prevLogIndex)
 
 	entries := make([]LogEntry, 0)
-	if nextIdx < uint64(len(rn.log)) {
-		entries = rn.log[nextIdx:]
+	if nextIdx > first && nextIdx <= rn.lastLogIndex() {
+		entries = rn.log[nextIdx-first:]
 	}
 
 	args := AppendEntriesArgs{
 		Term:         rn.currentTerm,
 		LeaderID:     rn.config.NodeID,
+		LeaderAddr:   rn.config.NodeAddr,
 		PrevLogIndex: prevLogIndex,
 		PrevLogTerm:  prevLogTerm,
 		Entries:      entries,
@@ -643,6 +896,7 @@ func (rn *RaftNode) sendAppendEntriesToPeer(peer *RaftPeer) {
 	}
 	rn.mu.RUnlock()
 
+	sent := time.Now()
 	reply := rn.sendAppendEntries(peer.Addr, args)
 	if reply == nil {
 		return
@@ -656,6 +910,12 @@ func (rn *RaftNode) sendAppendEntriesToPeer(peer *RaftPeer) {
 		return
 	}
 
+	// The peer still followed this leader when the request was sent
+	if rn.GetState() == StateLeader && reply.Term == args.Term && rn.currentTerm == args.Term &&
+		sent.After(rn.acks[peer.Addr]) {
+		rn.acks[peer.Addr] = sent
+	}
+
 	if reply.Success {
 		rn.nextIndex[peer.Addr] = nextIdx + uint64(len(entries))
 		rn.matchIndex[peer.Addr] = rn.nextIndex[peer.Addr] - 1
@@ -673,12 +933,17 @@ func (rn *RaftNode) sendAppendEntriesToPeer(peer *RaftPeer) {
 
 // updateCommitIndex updates the commit index based on matchIndex values
 func (rn *RaftNode) updateCommitIndex() {
-	// Find the highest index that a majority of servers have replicated
-	matchIndexes := make([]uint64, 0, len(rn.peers)+1)
-	matchIndexes = append(matchIndexes, uint64(len(rn.log)-1)) // Leader's own index
-
-	for _, idx := range rn.matchIndex {
-		matchIndexes = append(matchIndexes, idx)
+	// Find the highest index that a majority of voters have replicated
+	if len(rn.membership.Voters) == 0 {
+		return
+	}
+	matchIndexes := make([]uint64, 0, len(rn.membership.Voters))
+	for _, voter := range rn.membership.Voters {
+		if voter == rn.config.NodeAddr {
+			matchIndexes = append(matchIndexes, rn.lastLogIndex()) // Leader's own index
+		} else {
+			matchIndexes = append(matchIndexes, rn.matchIndex[voter])
+		}
 	}
 
 	// Sort to find median
@@ -694,9 +959,16 @@ func (rn *RaftNode) updateCommitIndex() {
 	majorityIdx := matchIndexes[(len(matchIndexes)-1)/2]
 
 	// Only update if the entry at majorityIdx is from current term
-	if majorityIdx > rn.commitIndex && rn.log[majorityIdx].Term == rn.currentTerm {
+	if majorityIdx > rn.commitIndex && rn.termAt(majorityIdx) == rn.currentTerm {
 		rn.commitIndex = majorityIdx
 	}
+
+	// A leader that committed its own removal steps down
+	if rn.GetState() == StateLeader && !rn.membership.IsVoter(rn.config.NodeAddr) &&
+		rn.commitIndex >= rn.membershipIndex {
+		fmt.Printf("Node %s is no longer a voter and steps down\n", rn.config.NodeID)
+		rn.becomeFollower(rn.currentTerm, "")
+	}
 }
 
 // applyCommittedEntries applies committed log entries to the state machine
@@ -710,20 +982,46 @@ func (rn *RaftNode) applyCommittedEntries() {
 		default:
 		}
 
+		// Collect the newly committed entries under the lock and hand them
+		// to the state machine without it, so a slow consumer of applyCh
+		// never stalls elections, heartbeats or replication. This goroutine
+		// is the only sender, so entries still arrive in log order; an entry
+		// a snapshot installed meanwhile already covers is skipped by the
+		// state machine.
 		rn.mu.Lock()
+		var entries []LogEntry
 		if rn.lastApplied < rn.commitIndex {
 			for i := rn.lastApplied + 1; i <= rn.commitIndex; i++ {
-				if i < uint64(len(rn.log)) {
-					entry := rn.log[i]
+				if entry, ok := rn.entryAt(i); ok {
 					if entry.Type == LogEntryCommand && rn.applyCh != nil {
-						rn.applyCh <- entry
+						entries = append(entries, entry)
 					}
 					rn.lastApplied = i
 				}
 			}
 		}
+		snapshotDue := rn.snapshotDue()
 		rn.mu.Unlock()
 
+		for _, entry := range entries {
+			select {
+			case rn.applyCh <- entry:
+			case <-rn.stopCh:
+				return
+			}
+		}
+
+		if snapshotDue && atomic.CompareAndSwapInt32(&rn.snapshotting, 0, 1) {
+			rn.wg.Add(1)
+			go func() {
+				defer rn.wg.Done()
+				defer atomic.StoreInt32(&rn.snapshotting, 0)
+				if err := rn.TakeSnapshot(); err != nil {
+					fmt.Printf("Node %s failed to take snapshot: %v\n", rn.config.NodeID, err)
+				}
+			}()
+		}
+
 		time.Sleep(10 * time.Millisecond)
 	}
 }
@@ -756,6 +1054,13 @@ func (rn *RaftNode) handleRequestVote(conn net.Conn, isPreVote bool) {
 		VoteGranted: false,
 	}
 
+	// Ignore candidates while the leader is known to be alive, so that a
+	// leader lease stays valid for its whole duration
+	if !args.Transfer && rn.leaderAlive() {
+		rn.sendRequestVoteReply(conn, reply, isPreVote)
+		return
+	}
+
 	// If RPC term is greater, update current term
 	if args.Term > rn.currentTerm && !isPreVote {
 		rn.becomeFollower(args.Term, "")
@@ -763,8 +1068,8 @@ func (rn *RaftNode) handleRequestVote(conn net.Conn, isPreVote bool) {
 
 	// Check if we can grant vote
 	if args.Term >= rn.currentTerm {
-		lastLogIndex := uint64(len(rn.log) - 1)
-		lastLogTerm := rn.log[lastLogIndex].Term
+		lastLogIndex := rn.lastLogIndex()
+		lastLogTerm := rn.termAt(lastLogIndex)
 
 		// Check if candidate's log is at least as up-to-date as ours
 		logOK := args.LastLogTerm > lastLogTerm ||
@@ -776,20 +1081,29 @@ func (rn *RaftNode) handleRequestVote(conn net.Conn, isPreVote bool) {
 		} else {
 			// For real vote, also check votedFor
 			if (rn.votedFor == "" || rn.votedFor == args.CandidateID) && logOK {
+				// The vote must be on disk before the candidate hears of it
 				rn.votedFor = args.CandidateID
-				reply.VoteGranted = true
-				// Reset election timer
-				select {
-				case rn.heartbeatCh <- struct{}{}:
-				default:
+				if err := rn.persistHardState(); err != nil {
+					rn.votedFor = ""
+					fmt.Printf("Node %s failed to persist vote: %v\n", rn.config.NodeID, err)
+				} else {
+					reply.VoteGranted = true
+					// Reset election timer
+					select {
+					case rn.heartbeatCh <- struct{}{}:
+					default:
+					}
 				}
 			}
 		}
 	}
 
 	reply.Term = rn.currentTerm
+	rn.sendRequestVoteReply(conn, reply, isPreVote)
+}
 
-	// Send reply
+// sendRequestVoteReply sends a RequestVote or PreVote reply
+func (rn *RaftNode) sendRequestVoteReply(conn net.Conn, reply RequestVoteReply, isPreVote bool) {
 	replyData, _ := json.Marshal(reply)
 	respType := RaftMsgRequestVoteResp
 	if isPreVote {
@@ -850,21 +1164,25 @@ func (rn *RaftNode) handleAppendEntries(conn net.Conn) {
 	// Update leader info
 	rn.leaderMu.Lock()
 	rn.leaderID = args.LeaderID
+	rn.leaderAddr = args.LeaderAddr
 	rn.leaderMu.Unlock()
-
-	// Check if log contains entry at prevLogIndex with prevLogTerm
-	if args.PrevLogIndex > 0 {
-		if args.PrevLogIndex >= uint64(len(rn.log)) {
-			reply.ConflictIndex = uint64(len(rn.log))
+	rn.leaderContact = time.Now()
+
+	// Check if log contains entry at prevLogIndex with prevLogTerm. Entries
+	// up to the snapshot are committed and therefore match the leader's.
+	first := rn.log[0].Index
+	if args.PrevLogIndex > first {
+		if args.PrevLogIndex > rn.lastLogIndex() {
+			reply.ConflictIndex = rn.lastLogIndex() + 1
 			rn.sendAppendEntriesReply(conn, reply)
 			return
 		}
-		if rn.log[args.PrevLogIndex].Term != args.PrevLogTerm {
+		if rn.termAt(args.PrevLogIndex) != args.PrevLogTerm {
 			// Find first index of conflicting term
-			conflictTerm := rn.log[args.PrevLogIndex].Term
+			conflictTerm := rn.termAt(args.PrevLogIndex)
 			reply.ConflictTerm = conflictTerm
-			for i := args.PrevLogIndex; i > 0; i-- {
-				if rn.log[i-1].Term != conflictTerm {
+			for i := args.PrevLogIndex; i > first; i-- {
+				if rn.termAt(i-1) != conflictTerm {
 					reply.ConflictIndex = i
 					break
 				}
@@ -874,18 +1192,31 @@ func (rn *RaftNode) handleAppendEntries(conn net.Conn) {
 		}
 	}
 
-	// Append new entries
+	// Skip entries we already have, then persist the rest before replying
+	var newEntries []LogEntry
 	for i, entry := range args.Entries {
 		idx := args.PrevLogIndex + 1 + uint64(i)
-		if idx < uint64(len(rn.log)) {
-			if rn.log[idx].Term != entry.Term {
-				// Delete conflicting entry and all that follow
-				rn.log = rn.log[:idx]
-				rn.log = append(rn.log, entry)
+		if idx <= first {
+			continue
+		}
+		if idx <= rn.lastLogIndex() {
+			if rn.termAt(idx) == entry.Term {
+				continue
+			}
+			// Delete conflicting entry and all that follow
+			if err := rn.truncateLog(idx); err != nil {
+				fmt.Printf("Node %s failed to truncate log: %v\n", rn.config.NodeID, err)
+				rn.sendAppendEntriesReply(conn, reply)
+				return
 			}
-		} else {
-			rn.log = append(rn.log, entry)
 		}
+		newEntries = args.Entries[i:]
+		break
+	}
+	if err := rn.appendLog(newEntries...); err != nil {
+		fmt.Printf("Node %s failed to append to log: %v\n", rn.config.NodeID, err)
+		rn.sendAppendEntriesReply(conn, reply)
+		return
 	}
 
 	// Update commit index
@@ -898,6 +1229,10 @@ func (rn *RaftNode) handleAppendEntries(conn net.Conn) {
 		}
 	}
 
+	// Everything the leader had committed when it sent this is now in the log
+	rn.freshIndex = args.LeaderCommit
+	rn.freshAt = rn.leaderContact
+
 	reply.Success = true
 	reply.Term = rn.currentTerm
 	rn.sendAppendEntriesReply(conn, reply)
@@ -1007,56 +1342,53 @@ func (rn *RaftNode) sendAppendEntries(addr string, args AppendEntriesArgs) *Appe
 
 // Propose proposes a new command to be replicated
 func (rn *RaftNode) Propose(command []byte) error {
+	_, _, err := rn.ProposeEntry(command)
+	return err
+}
+
+// ProposeEntry proposes a new command to be replicated and returns the index
+// and term of its log entry. The command has been committed once an entry
+// with that index is applied, provided the entry still has that term.
+func (rn *RaftNode) ProposeEntry(command []byte) (uint64, uint64, error) {
 	if !rn.IsLeader() {
-		return fmt.Errorf("not the leader")
+		return 0, 0, ErrNotRaftLeader
 	}
 
 	rn.mu.Lock()
 	defer rn.mu.Unlock()
 
+	if rn.transferTarget != "" {
+		return 0, 0, fmt.Errorf("leadership is being transferred to %s", rn.transferTarget)
+	}
+
 	entry := LogEntry{
 		Term:    rn.currentTerm,
-		Index:   uint64(len(rn.log)),
+		Index:   rn.lastLogIndex() + 1,
 		Command: command,
 		Type:    LogEntryCommand,
 	}
 
-	rn.log = append(rn.log, entry)
+	if err := rn.appendLog(entry); err != nil {
+		return 0, 0, fmt.Errorf("failed to persist log entry: %w", err)
+	}
+	rn.updateCommitIndex()
 
 	// Trigger immediate replication
 	go rn.broadcastAppendEntries()
 
-	return nil
+	return entry.Index, entry.Term, nil
 }
 
-// AddPeer adds a new peer to the cluster
-func (rn *RaftNode) AddPeer(id, addr string) {
-	rn.peersMu.Lock()
-	defer rn.peersMu.Unlock()
-
-	rn.peers[addr] = &RaftPeer{
-		ID:        id,
-		Addr:      addr,
-		isHealthy: true,
+// EntryTerm returns the term of the log entry at index. It returns false if
+// the entry does not exist or has been discarded by a snapshot.
+func (rn *RaftNode) EntryTerm(index uint64) (uint64, bool) {
+	rn.mu.RLock()
+	defer rn.mu.RUnlock()
+	if index <= rn.log[0].Index {
+		return 0, false
 	}
-
-	rn.mu.Lock()
-	rn.nextIndex[addr] = uint64(len(rn.log))
-	rn.matchIndex[addr] = 0
-	rn.mu.Unlock()
-}
-
-// RemovePeer removes a peer from the cluster
-func (rn *RaftNode) RemovePeer(addr string) {
-	rn.peersMu.Lock()
-	defer rn.peersMu.Unlock()
-
-	delete(rn.peers, addr)
-
-	rn.mu.Lock()
-	delete(rn.nextIndex, addr)
-	delete(rn.matchIndex, addr)
-	rn.mu.Unlock()
+	entry, ok := rn.entryAt(index)
+	return entry.Term, ok
 }
 
 // GetClusterStatus returns the current cluster status
@@ -1082,6 +1414,9 @@ func (rn *RaftNode) GetClusterStatus() map[string]interface{} {
 		"commit_index": rn.commitIndex,
 		"last_applied": rn.lastApplied,
 		"log_length":   len(rn.log),
+		"snapshot_index": rn.log[0].Index,
 		"peers":        peerList,
+		"voters":       append([]string(nil), rn.membership.Voters...),
+		"learners":     append([]string(nil), rn.membership.Learners...),
 	}
 }
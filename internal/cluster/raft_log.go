@@ -0,0 +1,434 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Raft Log Storage:
+=================
+
+Raft is only safe if a node remembers its current term, the candidate it
+voted for in that term, and its log across restarts. RaftLogStore keeps
+this state in the node's data directory:
+
+	<data_dir>/hardstate                    current term and vote (JSON)
+	<data_dir>/log-<first index>.seg        log segments
+	<data_dir>/snapshot.meta                latest snapshot (see raft_snapshot.go)
+	<data_dir>/snapshot-<index>.dat         snapshot data
+
+Each segment holds consecutive entries, starting with the index in its
+name, as length-prefixed records:
+
+	+----------------+----------------+------------------+
+	| Length (4B BE) | CRC32 (4B BE)  | Entry (JSON)     |
+	+----------------+----------------+------------------+
+
+A new segment is started once the current one reaches raftSegmentMaxBytes.
+Every write is fsynced before it returns, so RaftNode can persist before it
+replies to RequestVote and AppendEntries. A torn record at the end of the
+last segment, left by a crash during an append, is truncated on load.
+Segments whose entries are all covered by a snapshot are removed by Compact.
+*/
+package cluster
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	raftHardStateFile    = "hardstate"
+	raftSegmentPrefix    = "log-"
+	raftSegmentSuffix    = ".seg"
+	raftSegmentMaxBytes  = 16 << 20 // 16MB
+	raftRecordHeaderSize = 8
+)
+
+// RaftHardState is the part of a node's state that must survive restarts
+// besides the log.
+type RaftHardState struct {
+	Term     uint64 `json:"term"`
+	VotedFor string `json:"voted_for"`
+}
+
+// raftSegment describes one segment file.
+type raftSegment struct {
+	first   uint64  // Index of the first entry
+	path    string  // File path
+	offsets []int64 // offsets[i] is where entry first+i starts
+	size    int64   // File size
+}
+
+// RaftLogStore persists the Raft log and hard state.
+type RaftLogStore struct {
+	dir             string
+	segmentMaxBytes int64
+
+	mu       sync.Mutex
+	segments []*raftSegment
+	tail     *os.File // Open handle of the last segment
+}
+
+// OpenRaftLogStore opens the log store in dir, creating the directory if
+// needed. Call Load before appending.
+func OpenRaftLogStore(dir string) (*RaftLogStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create Raft data directory: %w", err)
+	}
+	return &RaftLogStore{dir: dir, segmentMaxBytes: raftSegmentMaxBytes}, nil
+}
+
+// Load reads the hard state and every log entry from disk.
+func (s *RaftLogStore) Load() (RaftHardState, []LogEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var hs RaftHardState
+	data, err := os.ReadFile(filepath.Join(s.dir, raftHardStateFile))
+	if err == nil {
+		if err := json.Unmarshal(data, &hs); err != nil {
+			return hs, nil, fmt.Errorf("corrupt Raft hard state: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return hs, nil, fmt.Errorf("failed to read Raft hard state: %w", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(s.dir, raftSegmentPrefix+"*"+raftSegmentSuffix))
+	if err != nil {
+		return hs, nil, err
+	}
+	s.segments = s.segments[:0]
+	for _, path := range paths {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), raftSegmentPrefix), raftSegmentSuffix)
+		first, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		s.segments = append(s.segments, &raftSegment{first: first, path: path})
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].first < s.segments[j].first })
+
+	var entries []LogEntry
+	for i, seg := range s.segments {
+		segEntries, torn, err := readRaftSegment(seg)
+		if err != nil {
+			return hs, nil, err
+		}
+		if torn {
+			if i != len(s.segments)-1 {
+				return hs, nil, fmt.Errorf("corrupt Raft log segment %s", seg.path)
+			}
+			// A crash interrupted the last append; drop the partial record
+			if err := os.Truncate(seg.path, seg.size); err != nil {
+				return hs, nil, fmt.Errorf("failed to truncate torn Raft log record: %w", err)
+			}
+		}
+		for j, entry := range segEntries {
+			if entry.Index != seg.first+uint64(j) {
+				return hs, nil, fmt.Errorf("Raft log segment %s has entry %d at position %d", seg.path, entry.Index, j)
+			}
+		}
+		if len(entries) > 0 && len(segEntries) > 0 && segEntries[0].Index != entries[len(entries)-1].Index+1 {
+			return hs, nil, fmt.Errorf("gap in Raft log before segment %s", seg.path)
+		}
+		entries = append(entries, segEntries...)
+	}
+
+	if err := s.openTail(); err != nil {
+		return hs, nil, err
+	}
+	return hs, entries, nil
+}
+
+// readRaftSegment reads the entries of a segment and records their offsets.
+// It reports whether the segment ends in a torn or corrupt record.
+func readRaftSegment(seg *raftSegment) ([]LogEntry, bool, error) {
+	data, err := os.ReadFile(seg.path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read Raft log segment: %w", err)
+	}
+
+	var entries []LogEntry
+	seg.offsets = seg.offsets[:0]
+	offset := int64(0)
+	for offset < int64(len(data)) {
+		if int64(len(data))-offset < raftRecordHeaderSize {
+			break
+		}
+		length := int64(binary.BigEndian.Uint32(data[offset:]))
+		checksum := binary.BigEndian.Uint32(data[offset+4:])
+		end := offset + raftRecordHeaderSize + length
+		if end > int64(len(data)) {
+			break
+		}
+		payload := data[offset+raftRecordHeaderSize : end]
+		if crc32.ChecksumIEEE(payload) != checksum {
+			break
+		}
+		var entry LogEntry
+		if err := json.Unmarshal(payload, &entry); err != nil {
+			break
+		}
+		seg.offsets = append(seg.offsets, offset)
+		entries = append(entries, entry)
+		offset = end
+	}
+	seg.size = offset
+	return entries, offset != int64(len(data)), nil
+}
+
+// SaveHardState durably replaces the hard state.
+func (s *RaftLogStore) SaveHardState(hs RaftHardState) error {
+	data, err := json.Marshal(hs)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, raftHardStateFile), data); err != nil {
+		return fmt.Errorf("failed to write Raft hard state: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic durably replaces a file by writing a temporary file,
+// fsyncing it and renaming it over the original.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// LastIndex returns the index of the last stored entry, or 0 if the log is
+// empty.
+func (s *RaftLogStore) LastIndex() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastIndex()
+}
+
+func (s *RaftLogStore) lastIndex() uint64 {
+	for i := len(s.segments) - 1; i >= 0; i-- {
+		if seg := s.segments[i]; len(seg.offsets) > 0 {
+			return seg.first + uint64(len(seg.offsets)) - 1
+		}
+	}
+	return 0
+}
+
+// Append durably appends entries, which must continue the stored log.
+func (s *RaftLogStore) Append(entries []LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last := s.lastIndex(); last > 0 && entries[0].Index != last+1 {
+		return fmt.Errorf("Raft log append at index %d does not follow %d", entries[0].Index, last)
+	}
+
+	for _, entry := range entries {
+		payload, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		record := make([]byte, raftRecordHeaderSize+len(payload))
+		binary.BigEndian.PutUint32(record, uint32(len(payload)))
+		binary.BigEndian.PutUint32(record[4:], crc32.ChecksumIEEE(payload))
+		copy(record[raftRecordHeaderSize:], payload)
+
+		seg := s.tailSegment()
+		if seg == nil || seg.size >= s.segmentMaxBytes {
+			if seg, err = s.startSegment(entry.Index); err != nil {
+				return err
+			}
+		}
+		if _, err := s.tail.Write(record); err != nil {
+			return fmt.Errorf("failed to append to Raft log: %w", err)
+		}
+		seg.offsets = append(seg.offsets, seg.size)
+		seg.size += int64(len(record))
+	}
+
+	if err := s.tail.Sync(); err != nil {
+		return fmt.Errorf("failed to sync Raft log: %w", err)
+	}
+	return nil
+}
+
+// TruncateFrom durably removes every entry with an index of at least index.
+func (s *RaftLogStore) TruncateFrom(index uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tail != nil {
+		s.tail.Close()
+		s.tail = nil
+	}
+
+	for len(s.segments) > 0 {
+		seg := s.segments[len(s.segments)-1]
+		if seg.first < index {
+			break
+		}
+		if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove Raft log segment: %w", err)
+		}
+		s.segments = s.segments[:len(s.segments)-1]
+	}
+
+	if seg := s.tailSegment(); seg != nil && index-seg.first < uint64(len(seg.offsets)) {
+		keep := index - seg.first
+		seg.size = seg.offsets[keep]
+		seg.offsets = seg.offsets[:keep]
+		if err := os.Truncate(seg.path, seg.size); err != nil {
+			return fmt.Errorf("failed to truncate Raft log segment: %w", err)
+		}
+	}
+
+	if err := s.openTail(); err != nil {
+		return err
+	}
+	if s.tail != nil {
+		if err := s.tail.Sync(); err != nil {
+			return err
+		}
+	}
+	return syncDir(s.dir)
+}
+
+// Compact removes the segments whose entries all have an index of at most
+// index, once a snapshot covers them. The last segment is always kept, so
+// appends continue where the log left off; Load may therefore still return
+// a few entries that the snapshot covers.
+func (s *RaftLogStore) Compact(index uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for removed < len(s.segments)-1 && s.segments[removed+1].first <= index+1 {
+		if err := os.Remove(s.segments[removed].path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove Raft log segment: %w", err)
+		}
+		removed++
+	}
+	if removed == 0 {
+		return nil
+	}
+	s.segments = append(s.segments[:0], s.segments[removed:]...)
+	return syncDir(s.dir)
+}
+
+// Close closes the open segment.
+func (s *RaftLogStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tail == nil {
+		return nil
+	}
+	err := s.tail.Close()
+	s.tail = nil
+	return err
+}
+
+// tailSegment returns the last segment, or nil if there is none.
+func (s *RaftLogStore) tailSegment() *raftSegment {
+	if len(s.segments) == 0 {
+		return nil
+	}
+	return s.segments[len(s.segments)-1]
+}
+
+// openTail opens the last segment for appending.
+func (s *RaftLogStore) openTail() error {
+	seg := s.tailSegment()
+	if seg == nil {
+		return nil
+	}
+	f, err := os.OpenFile(seg.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open Raft log segment: %w", err)
+	}
+	s.tail = f
+	return nil
+}
+
+// startSegment closes the current segment and starts a new one whose first
+// entry is first.
+func (s *RaftLogStore) startSegment(first uint64) (*raftSegment, error) {
+	if s.tail != nil {
+		if err := s.tail.Sync(); err != nil {
+			return nil, fmt.Errorf("failed to sync Raft log: %w", err)
+		}
+		s.tail.Close()
+		s.tail = nil
+	}
+
+	seg := &raftSegment{
+		first: first,
+		path:  filepath.Join(s.dir, fmt.Sprintf("%s%020d%s", raftSegmentPrefix, first, raftSegmentSuffix)),
+	}
+	f, err := os.OpenFile(seg.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Raft log segment: %w", err)
+	}
+	if err := syncDir(s.dir); err != nil {
+		f.Close()
+		return nil, err
+	}
+	s.tail = f
+	s.segments = append(s.segments, seg)
+	return seg, nil
+}
+
+// syncDir fsyncs a directory so that created, renamed and removed files
+// survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to sync directory %s: %w", dir, err)
+	}
+	return nil
+}
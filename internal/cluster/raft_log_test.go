@@ -0,0 +1,186 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// Raft Log Store Tests
+// ============================================================================
+
+func raftEntries(first, last, term uint64) []LogEntry {
+	var entries []LogEntry
+	for i := first; i <= last; i++ {
+		entries = append(entries, LogEntry{Term: term, Index: i, Command: []byte("cmd"), Type: LogEntryCommand})
+	}
+	return entries
+}
+
+func openLoadedRaftLogStore(t *testing.T, dir string) (*RaftLogStore, RaftHardState, []LogEntry) {
+	t.Helper()
+	store, err := OpenRaftLogStore(dir)
+	if err != nil {
+		t.Fatalf("OpenRaftLogStore failed: %v", err)
+	}
+	hs, entries, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	return store, hs, entries
+}
+
+func TestRaftLogStore_Recovery(t *testing.T) {
+	dir := t.TempDir()
+	store, _, _ := openLoadedRaftLogStore(t, dir)
+	store.segmentMaxBytes = 200 // Force several segments
+
+	if err := store.SaveHardState(RaftHardState{Term: 3, VotedFor: "node2"}); err != nil {
+		t.Fatalf("SaveHardState failed: %v", err)
+	}
+	if err := store.Append(raftEntries(1, 10, 1)); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := store.Append(raftEntries(12, 12, 1)); err == nil {
+		t.Error("Expected a gap in the log to be rejected")
+	}
+
+	// Replace the tail with entries from a later term
+	if err := store.TruncateFrom(6); err != nil {
+		t.Fatalf("TruncateFrom failed: %v", err)
+	}
+	if err := store.Append(raftEntries(6, 7, 3)); err != nil {
+		t.Fatalf("Append after truncate failed: %v", err)
+	}
+	store.Close()
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "log-*.seg"))
+	if len(segments) < 2 {
+		t.Errorf("Expected several segments, got %d", len(segments))
+	}
+
+	store, hs, entries := openLoadedRaftLogStore(t, dir)
+	defer store.Close()
+
+	if hs.Term != 3 || hs.VotedFor != "node2" {
+		t.Errorf("Expected term 3 and vote for node2, got %+v", hs)
+	}
+	if len(entries) != 7 {
+		t.Fatalf("Expected 7 entries, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		wantTerm := uint64(1)
+		if i >= 5 {
+			wantTerm = 3
+		}
+		if entry.Index != uint64(i+1) || entry.Term != wantTerm {
+			t.Errorf("Entry %d: got index %d term %d", i, entry.Index, entry.Term)
+		}
+	}
+	if store.LastIndex() != 7 {
+		t.Errorf("Expected last index 7, got %d", store.LastIndex())
+	}
+}
+
+func TestRaftLogStore_TornRecord(t *testing.T) {
+	dir := t.TempDir()
+	store, _, _ := openLoadedRaftLogStore(t, dir)
+	if err := store.Append(raftEntries(1, 3, 1)); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	store.Close()
+
+	// Simulate a crash in the middle of writing a record
+	segments, _ := filepath.Glob(filepath.Join(dir, "log-*.seg"))
+	f, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open segment: %v", err)
+	}
+	f.Write([]byte{0, 0, 0, 40, 1, 2, 3})
+	f.Close()
+
+	store, _, entries := openLoadedRaftLogStore(t, dir)
+	if len(entries) != 3 {
+		t.Fatalf("Expected the 3 complete entries, got %d", len(entries))
+	}
+	if err := store.Append(raftEntries(4, 4, 1)); err != nil {
+		t.Fatalf("Append after recovery failed: %v", err)
+	}
+	store.Close()
+
+	store, _, entries = openLoadedRaftLogStore(t, dir)
+	defer store.Close()
+	if len(entries) != 4 {
+		t.Errorf("Expected 4 entries, got %d", len(entries))
+	}
+}
+
+func TestRaftNode_RecoversPersistentState(t *testing.T) {
+	dir := t.TempDir()
+	store, _, _ := openLoadedRaftLogStore(t, dir)
+	if err := store.Append(raftEntries(1, 5, 2)); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := store.SaveHardState(RaftHardState{Term: 2, VotedFor: "node9"}); err != nil {
+		t.Fatalf("SaveHardState failed: %v", err)
+	}
+	store.Close()
+
+	config := DefaultRaftConfig("node1", "127.0.0.1:0")
+	config.ClusterPort = 0
+	config.DataDir = dir
+	config.ElectionTimeout = 20 * time.Millisecond
+
+	node := NewRaftNode(config, nil)
+	if err := node.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if status := node.GetClusterStatus(); status["log_length"] != 6 {
+		t.Errorf("Expected the sentinel and 5 recovered entries, got %v", status["log_length"])
+	}
+	if node.GetTerm() < 2 {
+		t.Errorf("Expected at least the recovered term 2, got %d", node.GetTerm())
+	}
+
+	// Without peers the node is its own majority and elects itself
+	deadline := time.Now().Add(2 * time.Second)
+	for node.GetTerm() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	node.Stop()
+	term := node.GetTerm()
+	if term < 3 {
+		t.Fatalf("Expected an election to start a new term, still at %d", term)
+	}
+	if !node.IsLeader() {
+		t.Errorf("Expected the node to elect itself, state is %s", node.GetState())
+	}
+
+	// The new term and the vote for itself survive a restart
+	_, hs, entries := openLoadedRaftLogStore(t, dir)
+	if hs.Term != term || hs.VotedFor != "node1" {
+		t.Errorf("Expected term %d and a vote for node1, got %+v", term, hs)
+	}
+	// The recovered entries and the new leader's no-op
+	if len(entries) != 6 || entries[5].Type != LogEntryNoop {
+		t.Errorf("Expected 5 entries and a no-op on disk, got %d", len(entries))
+	}
+}
@@ -0,0 +1,676 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Raft Membership Changes:
+========================
+
+The cluster configuration, its voters and learners, is replicated through
+the log as LogEntryConfig entries. Servers are identified by their Raft
+address. Each node uses the latest configuration in its log, committed or
+not, and falls back to the previous one if that entry is overwritten.
+
+Changes are made one server at a time. Any two majorities of configurations
+that differ by a single voter overlap, so the cluster never has two leaders
+for a term. The leader refuses a change until the previous one has
+committed and until it has committed an entry of its own term.
+
+Adding a voter takes two changes:
+
+	AddServer(addr) -> addr joins as a learner and receives the log
+	                -> once it has caught up, it is promoted to voter
+
+so the new server never holds up commits while it replays the log. A server
+added with learner set stays a learner.
+
+Removing the leader first transfers leadership: the leader stops accepting
+proposals, brings a voter's log up to date and sends it TimeoutNow, which
+makes it start an election at once. The new leader then removes the old
+one. A leader that commits its own removal anyway steps down.
+
+AddServer and RemoveServer called on a follower are forwarded to the leader.
+A new server is started with RaftConfig.Join so it waits to be added instead
+of electing itself.
+*/
+package cluster
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"time"
+)
+
+// raftMembershipTimeout bounds how long a membership change or a leadership
+// transfer may take.
+const raftMembershipTimeout = 30 * time.Second
+
+// RaftMembership is the configuration of a Raft cluster. Servers are
+// identified by their Raft address.
+type RaftMembership struct {
+	Voters   []string `json:"voters"`
+	Learners []string `json:"learners,omitempty"`
+}
+
+// IsVoter reports whether addr is a voter.
+func (m RaftMembership) IsVoter(addr string) bool {
+	return containsString(m.Voters, addr)
+}
+
+// IsLearner reports whether addr is a learner.
+func (m RaftMembership) IsLearner(addr string) bool {
+	return containsString(m.Learners, addr)
+}
+
+// quorum returns the number of votes that make a majority of the voters.
+func (m RaftMembership) quorum() int {
+	return len(m.Voters)/2 + 1
+}
+
+// otherVoters returns the voters other than self.
+func (m RaftMembership) otherVoters(self string) []string {
+	voters := make([]string, 0, len(m.Voters))
+	for _, voter := range m.Voters {
+		if voter != self {
+			voters = append(voters, voter)
+		}
+	}
+	return voters
+}
+
+// clone returns a copy that shares no slices with m.
+func (m RaftMembership) clone() RaftMembership {
+	return RaftMembership{
+		Voters:   append([]string(nil), m.Voters...),
+		Learners: append([]string(nil), m.Learners...),
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, s string) []string {
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		if item != s {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// bootstrapMembership returns the initial configuration of a node that
+// forms a cluster with its configured peers: all of them are voters.
+func bootstrapMembership(config RaftConfig) RaftMembership {
+	m := RaftMembership{Voters: []string{config.NodeAddr}}
+	for _, peer := range config.Peers {
+		if !containsString(m.Voters, peer) {
+			m.Voters = append(m.Voters, peer)
+		}
+	}
+	sort.Strings(m.Voters)
+	return m
+}
+
+// MembershipChangeArgs asks the leader to change the cluster configuration.
+type MembershipChangeArgs struct {
+	Action  string `json:"action"` // "add" or "remove"
+	Addr    string `json:"addr"`
+	Learner bool   `json:"learner,omitempty"`
+}
+
+// MembershipChangeReply contains the reply for a membership change request
+type MembershipChangeReply struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// TimeoutNowArgs asks a voter to start an election immediately.
+type TimeoutNowArgs struct {
+	Term     uint64 `json:"term"`
+	LeaderID string `json:"leader_id"`
+}
+
+// TimeoutNowReply contains the reply for TimeoutNow RPC
+type TimeoutNowReply struct {
+	Term    uint64 `json:"term"`
+	Success bool   `json:"success"`
+}
+
+// ============================================================================
+// Current Configuration
+// ============================================================================
+
+// Membership returns the cluster configuration the node currently uses.
+func (rn *RaftNode) Membership() RaftMembership {
+	rn.mu.RLock()
+	defer rn.mu.RUnlock()
+	return rn.membership.clone()
+}
+
+// membershipAt returns the configuration in effect at index, and the index
+// of the entry that set it.
+// Must be called with rn.mu held.
+func (rn *RaftNode) membershipAt(index uint64) (RaftMembership, uint64) {
+	first := rn.log[0].Index
+	if index > rn.lastLogIndex() {
+		index = rn.lastLogIndex()
+	}
+	for i := index; i > first; i-- {
+		entry := rn.log[i-first]
+		if entry.Type != LogEntryConfig {
+			continue
+		}
+		var m RaftMembership
+		if err := json.Unmarshal(entry.Command, &m); err != nil {
+			fmt.Printf("Node %s ignoring malformed config entry at index %d: %v\n", rn.config.NodeID, i, err)
+			continue
+		}
+		return m, i
+	}
+	if rn.snapshot != nil && rn.snapshot.Membership != nil {
+		return rn.snapshot.Membership.clone(), rn.snapshot.Index
+	}
+	return rn.initialMembership.clone(), 0
+}
+
+// refreshMembership switches to the latest configuration in the log and
+// updates the peers to match it.
+// Must be called with rn.mu held.
+func (rn *RaftNode) refreshMembership() {
+	rn.membership, rn.membershipIndex = rn.membershipAt(rn.lastLogIndex())
+
+	self := rn.config.NodeAddr
+	servers := append(append([]string(nil), rn.membership.Voters...), rn.membership.Learners...)
+
+	rn.peersMu.Lock()
+	defer rn.peersMu.Unlock()
+	for _, addr := range servers {
+		if addr == self {
+			continue
+		}
+		if _, ok := rn.peers[addr]; !ok {
+			rn.peers[addr] = &RaftPeer{Addr: addr, isHealthy: true}
+			rn.nextIndex[addr] = rn.lastLogIndex() + 1
+			rn.matchIndex[addr] = 0
+		}
+	}
+	for addr := range rn.peers {
+		if !containsString(servers, addr) {
+			delete(rn.peers, addr)
+			delete(rn.nextIndex, addr)
+			delete(rn.matchIndex, addr)
+		}
+	}
+}
+
+// ============================================================================
+// Changing the Configuration
+// ============================================================================
+
+// AddServer adds the server at addr to the cluster. It joins as a learner
+// and, unless learner is set, is promoted to voter once it has caught up
+// with the leader's log. Adding a learner that is already a member as a
+// voter promotes it.
+func (rn *RaftNode) AddServer(addr string, learner bool) error {
+	if !rn.IsLeader() {
+		return rn.forwardMembershipChange(MembershipChangeArgs{Action: "add", Addr: addr, Learner: learner})
+	}
+
+	current := rn.Membership()
+	if current.IsVoter(addr) {
+		if learner {
+			return fmt.Errorf("%s is already a voter", addr)
+		}
+		return nil
+	}
+	if !current.IsLearner(addr) {
+		err := rn.changeMembership(func(m *RaftMembership) error {
+			m.Learners = append(m.Learners, addr)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Node %s added %s as a learner\n", rn.config.NodeID, addr)
+	}
+	if learner {
+		return nil
+	}
+
+	if err := rn.waitCaughtUp(addr); err != nil {
+		return fmt.Errorf("%s remains a learner: %w", addr, err)
+	}
+	err := rn.changeMembership(func(m *RaftMembership) error {
+		if !m.IsLearner(addr) {
+			return fmt.Errorf("%s is no longer a learner", addr)
+		}
+		m.Learners = removeString(m.Learners, addr)
+		m.Voters = append(m.Voters, addr)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Node %s promoted %s to voter\n", rn.config.NodeID, addr)
+	return nil
+}
+
+// RemoveServer removes the server at addr from the cluster. If addr is the
+// leader, leadership is transferred to another voter first.
+func (rn *RaftNode) RemoveServer(addr string) error {
+	if !rn.IsLeader() {
+		return rn.forwardMembershipChange(MembershipChangeArgs{Action: "remove", Addr: addr})
+	}
+
+	if addr == rn.config.NodeAddr {
+		if err := rn.TransferLeadership(""); err != nil {
+			return fmt.Errorf("cannot remove the leader: %w", err)
+		}
+		return rn.forwardMembershipChange(MembershipChangeArgs{Action: "remove", Addr: addr})
+	}
+
+	current := rn.Membership()
+	if !current.IsVoter(addr) && !current.IsLearner(addr) {
+		return nil
+	}
+	err := rn.changeMembership(func(m *RaftMembership) error {
+		if m.IsVoter(addr) && len(m.Voters) == 1 {
+			return fmt.Errorf("cannot remove the last voter")
+		}
+		m.Voters = removeString(m.Voters, addr)
+		m.Learners = removeString(m.Learners, addr)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Node %s removed %s from the cluster\n", rn.config.NodeID, addr)
+	return nil
+}
+
+// changeMembership appends a configuration changed by fn and waits for it
+// to commit. fn may change at most one voter.
+func (rn *RaftNode) changeMembership(fn func(m *RaftMembership) error) error {
+	deadline := time.Now().Add(raftMembershipTimeout)
+
+	// A new leader may not change the configuration before it has
+	// committed an entry of its own term, and only one change may be
+	// uncommitted at a time
+	var entry LogEntry
+	for {
+		rn.mu.Lock()
+		if rn.GetState() != StateLeader {
+			rn.mu.Unlock()
+			return ErrNotRaftLeader
+		}
+		if rn.transferTarget != "" {
+			rn.mu.Unlock()
+			return fmt.Errorf("leadership is being transferred to %s", rn.transferTarget)
+		}
+		if rn.termAt(rn.commitIndex) == rn.currentTerm && rn.membershipIndex <= rn.commitIndex {
+			break
+		}
+		rn.mu.Unlock()
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for the previous membership change to commit")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	next := rn.membership.clone()
+	if err := fn(&next); err != nil {
+		rn.mu.Unlock()
+		return err
+	}
+	if changed := votersChanged(rn.membership, next); changed > 1 {
+		rn.mu.Unlock()
+		return fmt.Errorf("a membership change may add or remove only one voter, got %d", changed)
+	}
+	sort.Strings(next.Voters)
+	sort.Strings(next.Learners)
+
+	command, _ := json.Marshal(next)
+	entry = LogEntry{
+		Term:    rn.currentTerm,
+		Index:   rn.lastLogIndex() + 1,
+		Command: command,
+		Type:    LogEntryConfig,
+	}
+	if err := rn.appendLog(entry); err != nil {
+		rn.mu.Unlock()
+		return fmt.Errorf("failed to persist config entry: %w", err)
+	}
+	rn.updateCommitIndex()
+	rn.mu.Unlock()
+
+	go rn.broadcastAppendEntries()
+	return rn.waitCommitted(entry.Index, entry.Term, deadline)
+}
+
+// votersChanged returns how many voters are in only one of a and b.
+func votersChanged(a, b RaftMembership) int {
+	changed := 0
+	for _, voter := range a.Voters {
+		if !b.IsVoter(voter) {
+			changed++
+		}
+	}
+	for _, voter := range b.Voters {
+		if !a.IsVoter(voter) {
+			changed++
+		}
+	}
+	return changed
+}
+
+// waitCommitted waits until the entry at index, proposed in term, commits.
+func (rn *RaftNode) waitCommitted(index, term uint64, deadline time.Time) error {
+	for {
+		rn.mu.RLock()
+		committed := rn.commitIndex >= index
+		entryTerm := rn.termAt(index)
+		compacted := index < rn.log[0].Index
+		rn.mu.RUnlock()
+
+		if committed {
+			if !compacted && entryTerm != term {
+				return fmt.Errorf("entry at index %d was lost to a leader change", index)
+			}
+			return nil
+		}
+		if entryTerm != term && !compacted {
+			return fmt.Errorf("entry at index %d was lost to a leader change", index)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for entry at index %d to commit", index)
+		}
+		select {
+		case <-rn.stopCh:
+			return fmt.Errorf("raft node stopped")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// waitCaughtUp waits until the server at addr has replicated everything
+// the leader has committed.
+func (rn *RaftNode) waitCaughtUp(addr string) error {
+	deadline := time.Now().Add(raftMembershipTimeout)
+	for {
+		rn.mu.RLock()
+		leader := rn.GetState() == StateLeader
+		caughtUp := rn.matchIndex[addr] >= rn.commitIndex
+		rn.mu.RUnlock()
+
+		if !leader {
+			return ErrNotRaftLeader
+		}
+		if caughtUp {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for %s to catch up", addr)
+		}
+		select {
+		case <-rn.stopCh:
+			return fmt.Errorf("raft node stopped")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+// ============================================================================
+// Leadership Transfer
+// ============================================================================
+
+// TransferLeadership hands leadership to the voter at addr, or to the most
+// up-to-date voter if addr is empty. Proposals are refused while the
+// transfer is in progress.
+func (rn *RaftNode) TransferLeadership(addr string) error {
+	rn.mu.Lock()
+	if rn.GetState() != StateLeader {
+		rn.mu.Unlock()
+		return ErrNotRaftLeader
+	}
+	if rn.transferTarget != "" {
+		rn.mu.Unlock()
+		return fmt.Errorf("leadership is already being transferred to %s", rn.transferTarget)
+	}
+	if addr == "" {
+		for _, voter := range rn.membership.otherVoters(rn.config.NodeAddr) {
+			if addr == "" || rn.matchIndex[voter] > rn.matchIndex[addr] {
+				addr = voter
+			}
+		}
+		if addr == "" {
+			rn.mu.Unlock()
+			return fmt.Errorf("no other voter to transfer leadership to")
+		}
+	}
+	if addr == rn.config.NodeAddr {
+		rn.mu.Unlock()
+		return nil
+	}
+	if !rn.membership.IsVoter(addr) {
+		rn.mu.Unlock()
+		return fmt.Errorf("%s is not a voter", addr)
+	}
+	rn.transferTarget = addr
+	term := rn.currentTerm
+	rn.mu.Unlock()
+
+	fmt.Printf("Node %s transferring leadership to %s\n", rn.config.NodeID, addr)
+
+	err := rn.transferLeadership(addr, term)
+
+	rn.mu.Lock()
+	if rn.transferTarget == addr {
+		rn.transferTarget = ""
+	}
+	rn.mu.Unlock()
+	return err
+}
+
+// transferLeadership brings addr up to date, sends it TimeoutNow and waits
+// for this node to step down.
+func (rn *RaftNode) transferLeadership(addr string, term uint64) error {
+	deadline := time.Now().Add(2 * rn.config.ElectionTimeout)
+	if deadline.Before(time.Now().Add(time.Second)) {
+		deadline = time.Now().Add(time.Second)
+	}
+
+	sent := false
+	for {
+		rn.mu.RLock()
+		leader := rn.GetState() == StateLeader && rn.currentTerm == term
+		caughtUp := rn.matchIndex[addr] >= rn.lastLogIndex()
+		rn.mu.RUnlock()
+		rn.peersMu.RLock()
+		peer := rn.peers[addr]
+		rn.peersMu.RUnlock()
+
+		if !leader {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out transferring leadership to %s", addr)
+		}
+		if peer == nil {
+			return fmt.Errorf("%s is no longer a member", addr)
+		}
+
+		if !caughtUp {
+			rn.sendAppendEntriesToPeer(peer)
+		} else if !sent {
+			// The target may win before this node hears of it
+			rn.mu.Lock()
+			rn.transferSent = true
+			rn.mu.Unlock()
+			reply := rn.sendTimeoutNow(addr, TimeoutNowArgs{Term: term, LeaderID: rn.config.NodeID})
+			sent = reply != nil && reply.Success
+		}
+
+		select {
+		case <-rn.stopCh:
+			return fmt.Errorf("raft node stopped")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// handleTimeoutNow handles incoming TimeoutNow RPCs
+func (rn *RaftNode) handleTimeoutNow(conn net.Conn) {
+	var args TimeoutNowArgs
+	if !readRaftMessage(conn, &args) {
+		return
+	}
+
+	rn.mu.Lock()
+	reply := TimeoutNowReply{Term: rn.currentTerm}
+	if args.Term == rn.currentTerm && rn.membership.IsVoter(rn.config.NodeAddr) {
+		reply.Success = true
+	}
+	rn.mu.Unlock()
+
+	writeRaftMessage(conn, RaftMsgTimeoutNowResp, reply)
+	if reply.Success {
+		fmt.Printf("Node %s asked by %s to start an election\n", rn.config.NodeID, args.LeaderID)
+		go rn.startElection(true)
+	}
+}
+
+// sendTimeoutNow sends a TimeoutNow RPC to a peer
+func (rn *RaftNode) sendTimeoutNow(addr string, args TimeoutNowArgs) *TimeoutNowReply {
+	var reply TimeoutNowReply
+	if !callRaft(addr, RaftMsgTimeoutNow, RaftMsgTimeoutNowResp, args, &reply, time.Second) {
+		return nil
+	}
+	return &reply
+}
+
+// ============================================================================
+// Forwarding to the Leader
+// ============================================================================
+
+// forwardMembershipChange asks the leader to make a membership change.
+func (rn *RaftNode) forwardMembershipChange(args MembershipChangeArgs) error {
+	// Wait briefly for a leader, which may be being elected
+	deadline := time.Now().Add(2 * rn.config.ElectionTimeout)
+	var leaderAddr string
+	for {
+		_, leaderAddr = rn.GetLeader()
+		if leaderAddr != "" && leaderAddr != rn.config.NodeAddr {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w and no leader is known", ErrNotRaftLeader)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	var reply MembershipChangeReply
+	if !callRaft(leaderAddr, RaftMsgMembershipChange, RaftMsgMembershipChangeResp, args, &reply, raftMembershipTimeout+5*time.Second) {
+		return fmt.Errorf("failed to reach the leader at %s", leaderAddr)
+	}
+	if !reply.Success {
+		return fmt.Errorf("leader %s: %s", leaderAddr, reply.Error)
+	}
+	return nil
+}
+
+// handleMembershipChange handles membership changes forwarded by followers
+func (rn *RaftNode) handleMembershipChange(conn net.Conn) {
+	var args MembershipChangeArgs
+	if !readRaftMessage(conn, &args) {
+		return
+	}
+	conn.SetDeadline(time.Now().Add(raftMembershipTimeout + 5*time.Second))
+
+	var err error
+	switch {
+	case !rn.IsLeader():
+		// Forwarding again could loop between nodes with stale leaders
+		err = ErrNotRaftLeader
+	case args.Action == "add":
+		err = rn.AddServer(args.Addr, args.Learner)
+	case args.Action == "remove":
+		err = rn.RemoveServer(args.Addr)
+	default:
+		err = fmt.Errorf("unknown membership change %q", args.Action)
+	}
+
+	reply := MembershipChangeReply{Success: err == nil}
+	if err != nil {
+		reply.Error = err.Error()
+	}
+	writeRaftMessage(conn, RaftMsgMembershipChangeResp, reply)
+}
+
+// readRaftMessage reads the length-prefixed JSON body of a request whose
+// type byte has already been read.
+func readRaftMessage(conn net.Conn, v interface{}) bool {
+	lenBuf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, lenBuf); err != nil {
+		return false
+	}
+	body := make([]byte, binary.BigEndian.Uint32(lenBuf))
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return false
+	}
+	return json.Unmarshal(body, v) == nil
+}
+
+// writeRaftMessage writes a message type followed by a length-prefixed JSON body.
+func writeRaftMessage(conn net.Conn, msgType byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 5, 5+len(data))
+	buf[0] = msgType
+	binary.BigEndian.PutUint32(buf[1:], uint32(len(data)))
+	_, err = conn.Write(append(buf, data...))
+	return err
+}
+
+// callRaft sends a request to addr and decodes its reply.
+func callRaft(addr string, reqType, respType byte, args, reply interface{}, timeout time.Duration) bool {
+	conn, err := net.DialTimeout("tcp", addr, 500*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := writeRaftMessage(conn, reqType, args); err != nil {
+		return false
+	}
+	msgType := make([]byte, 1)
+	if _, err := io.ReadFull(conn, msgType); err != nil || msgType[0] != respType {
+		return false
+	}
+	return readRaftMessage(conn, reply)
+}
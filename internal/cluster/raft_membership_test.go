@@ -0,0 +1,183 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// Membership Change Tests
+// ============================================================================
+
+func TestRaftMembership_Quorum(t *testing.T) {
+	m := bootstrapMembership(RaftConfig{NodeAddr: "b:1", Peers: []string{"a:1", "c:1", "b:1"}})
+	if len(m.Voters) != 3 || m.Voters[0] != "a:1" {
+		t.Fatalf("Expected 3 sorted voters, got %v", m.Voters)
+	}
+	if m.quorum() != 2 {
+		t.Errorf("Expected quorum 2, got %d", m.quorum())
+	}
+	if others := m.otherVoters("b:1"); len(others) != 2 {
+		t.Errorf("Expected 2 other voters, got %v", others)
+	}
+
+	next := m.clone()
+	next.Voters = append(next.Voters, "d:1", "e:1")
+	if changed := votersChanged(m, next); changed != 2 {
+		t.Errorf("Expected 2 changed voters, got %d", changed)
+	}
+}
+
+func membershipTestConfig(t *testing.T, id string) RaftConfig {
+	t.Helper()
+	port := freePort(t)
+	config := DefaultRaftConfig(id, fmt.Sprintf("127.0.0.1:%d", port))
+	config.ClusterPort = port
+	config.DataDir = t.TempDir()
+	config.ElectionTimeout = 150 * time.Millisecond
+	config.HeartbeatInterval = 30 * time.Millisecond
+	return config
+}
+
+func TestRaftNode_MembershipChanges(t *testing.T) {
+	var configs []RaftConfig
+	for i := 0; i < 3; i++ {
+		configs = append(configs, membershipTestConfig(t, fmt.Sprintf("node%d", i+1)))
+	}
+	for i := range configs {
+		for j := range configs {
+			if i != j {
+				configs[i].Peers = append(configs[i].Peers, configs[j].NodeAddr)
+			}
+		}
+	}
+
+	// The new node joins with an empty configuration and waits to be added
+	joinConfig := membershipTestConfig(t, "node4")
+	joinConfig.Join = true
+	joinerAddr := joinConfig.NodeAddr
+	configs = append(configs, joinConfig)
+
+	var writers []*RaftWriter
+	var stores []*mockWriteStore
+	for _, config := range configs {
+		store := newMockWriteStore()
+		writers = append(writers, NewRaftWriter(config, store))
+		stores = append(stores, store)
+	}
+	joiner, joinerStore := writers[3], stores[3]
+
+	for _, w := range writers {
+		w := w
+		w.Start()
+		if err := w.Node().Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		t.Cleanup(func() {
+			w.Node().Stop()
+			w.Stop()
+		})
+	}
+
+	leaderOf := func() *RaftWriter {
+		var leader *RaftWriter
+		waitFor(t, "a leader", func() bool {
+			for _, w := range writers {
+				if w.Node().IsLeader() {
+					leader = w
+					return true
+				}
+			}
+			return false
+		})
+		return leader
+	}
+	leader := leaderOf()
+	if joiner.Node().IsLeader() {
+		t.Fatal("A joining node elected itself")
+	}
+	if err := leader.Propose("appdb", WALOpPut, "k1", []byte("v1")); err != nil {
+		t.Fatalf("Propose failed: %v", err)
+	}
+
+	// Adding through a follower is forwarded to the leader
+	var follower *RaftWriter
+	for _, w := range writers[:3] {
+		if w != leader {
+			follower = w
+			break
+		}
+	}
+	if err := follower.Node().AddServer(joinerAddr, false); err != nil {
+		t.Fatalf("AddServer failed: %v", err)
+	}
+	waitFor(t, "every node to see four voters", func() bool {
+		for _, w := range writers {
+			if m := w.Node().Membership(); len(m.Voters) != 4 || len(m.Learners) != 0 {
+				return false
+			}
+		}
+		return true
+	})
+	waitFor(t, "the new node to catch up", func() bool {
+		value, _ := joinerStore.get("appdb", "k1")
+		return value == "v1"
+	})
+
+	// Removing the leader hands leadership to another voter first
+	oldLeader := leader
+	if err := oldLeader.Node().RemoveServer(oldLeader.node.config.NodeAddr); err != nil {
+		t.Fatalf("RemoveServer of the leader failed: %v", err)
+	}
+	leader = leaderOf()
+	if leader == oldLeader {
+		t.Fatal("The removed leader is still the leader")
+	}
+	m := leader.Node().Membership()
+	if len(m.Voters) != 3 || m.IsVoter(oldLeader.node.config.NodeAddr) {
+		t.Fatalf("Expected the old leader to be removed, got %v", m.Voters)
+	}
+
+	// The remaining three voters still commit writes
+	if err := leader.Propose("appdb", WALOpPut, "k2", []byte("v2")); err != nil {
+		t.Fatalf("Propose after removal failed: %v", err)
+	}
+	for i, w := range writers {
+		if w == oldLeader {
+			continue
+		}
+		store := stores[i]
+		waitFor(t, "the remaining nodes to apply the write", func() bool {
+			value, _ := store.get("appdb", "k2")
+			return value == "v2"
+		})
+	}
+
+	// Learners do not count towards the quorum
+	if err := leader.Node().AddServer("127.0.0.1:1", true); err != nil {
+		t.Fatalf("AddServer of a learner failed: %v", err)
+	}
+	if m := leader.Node().Membership(); !m.IsLearner("127.0.0.1:1") || m.quorum() != 2 {
+		t.Errorf("Expected an unreachable learner outside the quorum, got %+v", m)
+	}
+	if err := leader.Propose("appdb", WALOpPut, "k3", []byte("v3")); err != nil {
+		t.Fatalf("Propose with an unreachable learner failed: %v", err)
+	}
+}
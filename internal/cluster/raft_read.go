@@ -0,0 +1,386 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Raft Reads:
+===========
+
+Reads are served from the local state machine. A session's read_consistency
+decides how fresh that state must be first:
+
+	local                 no check; a follower may be arbitrarily stale
+	linearizable          read-index: ask the leader for its commit index,
+	                      wait until it is applied locally
+	lease                 as linearizable, but a leader holding a lease
+	                      answers without contacting the followers
+	bounded_staleness(ms) serve if the local state was current at most
+	                      ms milliseconds ago, else fail
+
+Read-index:
+
+The leader records its commit index, then confirms it is still the leader
+by getting a heartbeat acknowledged by a quorum of voters, each sent after
+the read arrived. Any write acknowledged before the read is then below the
+recorded index. A new leader first commits an entry of its own term, so
+its commit index covers everything committed by earlier leaders.
+
+Leases:
+
+A voter does not vote for another candidate until an election timeout has
+passed since it last heard from the leader. Once a quorum of voters has
+acknowledged a heartbeat sent at time t, no other leader can be elected
+before t + election timeout. The leader serves reads without a round trip
+until 90% of that, leaving a margin for clock rate differences. A leader
+that has sent TimeoutNow gives up its lease for the rest of its term, as
+the transfer candidate is exempt from the rule.
+
+Bounded staleness:
+
+A follower remembers the leader's commit index from the last heartbeat
+that matched its log. Once that index is applied, the local state is at
+most as old as the heartbeat.
+*/
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadMode selects how a read is kept consistent with the Raft log.
+type ReadMode int
+
+const (
+	// ReadLocal serves reads from local state without any check.
+	ReadLocal ReadMode = iota
+
+	// ReadLinearizable confirms the leader's commit index with a quorum
+	// and waits until it is applied locally.
+	ReadLinearizable
+
+	// ReadLease is ReadLinearizable, except that a leader holding a lease
+	// skips the quorum round trip.
+	ReadLease
+
+	// ReadBoundedStaleness serves reads from state that was current within
+	// MaxStaleness.
+	ReadBoundedStaleness
+)
+
+// ReadConsistency is a read_consistency setting.
+//
+// The zero value is ReadLocal.
+type ReadConsistency struct {
+	Mode         ReadMode
+	MaxStaleness time.Duration
+}
+
+// ParseReadConsistency parses a read_consistency value.
+//
+// Accepted forms are "local", "linearizable", "lease" and
+// "bounded_staleness(<ms>)", where <ms> is a number of milliseconds or a
+// duration in time.ParseDuration syntax. An empty value is "local".
+func ParseReadConsistency(s string) (ReadConsistency, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	switch v {
+	case "", "local":
+		return ReadConsistency{Mode: ReadLocal}, nil
+	case "linearizable":
+		return ReadConsistency{Mode: ReadLinearizable}, nil
+	case "lease":
+		return ReadConsistency{Mode: ReadLease}, nil
+	}
+
+	if strings.HasPrefix(v, "bounded_staleness(") && strings.HasSuffix(v, ")") {
+		arg := strings.TrimSpace(v[len("bounded_staleness(") : len(v)-1])
+		bound, err := time.ParseDuration(arg)
+		if err != nil {
+			ms, convErr := strconv.Atoi(arg)
+			bound, err = time.Duration(ms)*time.Millisecond, convErr
+		}
+		if err != nil || bound <= 0 {
+			return ReadConsistency{}, fmt.Errorf("invalid read_consistency %q: staleness must be a positive number of milliseconds", s)
+		}
+		return ReadConsistency{Mode: ReadBoundedStaleness, MaxStaleness: bound}, nil
+	}
+
+	return ReadConsistency{}, fmt.Errorf("invalid read_consistency %q (must be local, linearizable, lease, or bounded_staleness(ms))", s)
+}
+
+// String returns the setting in the form accepted by ParseReadConsistency.
+func (c ReadConsistency) String() string {
+	switch c.Mode {
+	case ReadLinearizable:
+		return "linearizable"
+	case ReadLease:
+		return "lease"
+	case ReadBoundedStaleness:
+		return fmt.Sprintf("bounded_staleness(%d)", c.MaxStaleness.Milliseconds())
+	default:
+		return "local"
+	}
+}
+
+// ReadIndexArgs asks the leader for a commit index that is safe to read at.
+type ReadIndexArgs struct {
+	Lease bool `json:"lease,omitempty"`
+}
+
+// ReadIndexReply contains the reply for ReadIndex RPC
+type ReadIndexReply struct {
+	Index   uint64 `json:"index"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ============================================================================
+// Leader Side
+// ============================================================================
+
+// leaseDuration returns how long a quorum acknowledgement keeps the lease.
+func (rn *RaftNode) leaseDuration() time.Duration {
+	return rn.config.ElectionTimeout * 9 / 10
+}
+
+// quorumAckTime returns the latest time by which a quorum of voters,
+// counting this node, had acknowledged this node as leader.
+// Must be called with rn.mu held.
+func (rn *RaftNode) quorumAckTime(now time.Time) time.Time {
+	if !rn.membership.IsVoter(rn.config.NodeAddr) {
+		return time.Time{}
+	}
+	times := make([]time.Time, 0, len(rn.membership.Voters))
+	for _, voter := range rn.membership.Voters {
+		if voter == rn.config.NodeAddr {
+			times = append(times, now)
+		} else {
+			times = append(times, rn.acks[voter])
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].After(times[j]) })
+	return times[rn.membership.quorum()-1]
+}
+
+// leaseValid reports whether no other leader can have been elected yet.
+// Must be called with rn.mu held.
+func (rn *RaftNode) leaseValid(now time.Time) bool {
+	if rn.GetState() != StateLeader || rn.transferTarget != "" || rn.transferSent {
+		return false
+	}
+	return now.Sub(rn.quorumAckTime(now)) < rn.leaseDuration()
+}
+
+// leaderAlive reports whether this node knows of a live leader, itself
+// included, and should therefore refuse votes.
+// Must be called with rn.mu held.
+func (rn *RaftNode) leaderAlive() bool {
+	switch rn.GetState() {
+	case StateLeader:
+		return rn.leaseValid(time.Now())
+	case StateFollower:
+		return !rn.leaderContact.IsZero() && time.Since(rn.leaderContact) < rn.config.ElectionTimeout
+	default:
+		return false
+	}
+}
+
+// ReadIndex returns a commit index that includes every write acknowledged
+// before the call. A read served once the index is applied locally is
+// linearizable. With lease set, a leader holding a lease answers without
+// contacting the followers. Followers ask the leader.
+func (rn *RaftNode) ReadIndex(lease bool) (uint64, error) {
+	if !rn.IsLeader() {
+		return rn.forwardReadIndex(lease)
+	}
+	return rn.leaderReadIndex(lease)
+}
+
+// leaderReadIndex implements ReadIndex on the leader.
+func (rn *RaftNode) leaderReadIndex(lease bool) (uint64, error) {
+	deadline := time.Now().Add(2 * rn.config.ElectionTimeout)
+
+	// The commit index is only known to be current once an entry of this
+	// term has committed
+	var index, term uint64
+	var start time.Time
+	for {
+		rn.mu.RLock()
+		leader := rn.GetState() == StateLeader
+		ready := rn.termAt(rn.commitIndex) == rn.currentTerm
+		index, term, start = rn.commitIndex, rn.currentTerm, time.Now()
+		leased := ready && lease && rn.leaseValid(start)
+		rn.mu.RUnlock()
+
+		if !leader {
+			return 0, ErrNotRaftLeader
+		}
+		if leased {
+			return index, nil
+		}
+		if ready {
+			break
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("timed out waiting for the leader to commit an entry of its term")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Confirm leadership with heartbeats sent after the read arrived
+	go rn.broadcastAppendEntries()
+	for {
+		rn.mu.RLock()
+		leader := rn.GetState() == StateLeader && rn.currentTerm == term
+		confirmed := !rn.quorumAckTime(time.Now()).Before(start)
+		rn.mu.RUnlock()
+
+		if !leader {
+			return 0, ErrNotRaftLeader
+		}
+		if confirmed {
+			return index, nil
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("timed out confirming leadership with a quorum")
+		}
+		select {
+		case <-rn.stopCh:
+			return 0, fmt.Errorf("raft node stopped")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+// forwardReadIndex asks the leader for a read index.
+func (rn *RaftNode) forwardReadIndex(lease bool) (uint64, error) {
+	_, leaderAddr := rn.GetLeader()
+	if leaderAddr == "" || leaderAddr == rn.config.NodeAddr {
+		return 0, fmt.Errorf("%w and no leader is known", ErrNotRaftLeader)
+	}
+
+	var reply ReadIndexReply
+	timeout := 2*rn.config.ElectionTimeout + time.Second
+	if !callRaft(leaderAddr, RaftMsgReadIndex, RaftMsgReadIndexResp, ReadIndexArgs{Lease: lease}, &reply, timeout) {
+		return 0, fmt.Errorf("failed to reach the leader at %s", leaderAddr)
+	}
+	if !reply.Success {
+		return 0, fmt.Errorf("leader %s: %s", leaderAddr, reply.Error)
+	}
+	return reply.Index, nil
+}
+
+// handleReadIndex handles read index requests from followers
+func (rn *RaftNode) handleReadIndex(conn net.Conn) {
+	var args ReadIndexArgs
+	if !readRaftMessage(conn, &args) {
+		return
+	}
+
+	var reply ReadIndexReply
+	index, err := rn.leaderReadIndex(args.Lease)
+	if err != nil {
+		reply.Error = err.Error()
+	} else {
+		reply.Index, reply.Success = index, true
+	}
+	writeRaftMessage(conn, RaftMsgReadIndexResp, reply)
+}
+
+// ============================================================================
+// Follower Side
+// ============================================================================
+
+// Freshness returns a commit index and the time at which it was last known
+// to be the leader's. Once the index is applied, the local state is at most
+// time.Since(at) behind the leader. The time is zero if the node has not
+// heard from a leader.
+func (rn *RaftNode) Freshness() (uint64, time.Time) {
+	rn.mu.RLock()
+	defer rn.mu.RUnlock()
+
+	if rn.GetState() == StateLeader {
+		now := time.Now()
+		return rn.commitIndex, rn.quorumAckTime(now)
+	}
+	return rn.freshIndex, rn.freshAt
+}
+
+// ============================================================================
+// Reads Through the Writer
+// ============================================================================
+
+// ValidateReadConsistency returns an error if policy is not a valid
+// read_consistency setting.
+func (w *RaftWriter) ValidateReadConsistency(policy string) error {
+	_, err := ParseReadConsistency(policy)
+	return err
+}
+
+// WaitReadable waits until this node's databases may serve a read under
+// the read_consistency setting policy.
+func (w *RaftWriter) WaitReadable(policy string) error {
+	rc, err := ParseReadConsistency(policy)
+	if err != nil {
+		return err
+	}
+
+	switch rc.Mode {
+	case ReadLinearizable, ReadLease:
+		index, err := w.node.ReadIndex(rc.Mode == ReadLease)
+		if err != nil {
+			return fmt.Errorf("%s read failed: %w", rc, err)
+		}
+		return w.waitApplied(index, w.timeout)
+
+	case ReadBoundedStaleness:
+		return w.waitFresh(rc.MaxStaleness)
+
+	default:
+		return nil
+	}
+}
+
+// waitFresh waits until the applied state is at most bound behind the
+// leader. It gives up after a couple of heartbeats.
+func (w *RaftWriter) waitFresh(bound time.Duration) error {
+	deadline := time.Now().Add(2*w.node.config.HeartbeatInterval + bound)
+	for {
+		index, at := w.node.Freshness()
+		if !at.IsZero() && time.Since(at) <= bound && w.sm.AppliedIndex() >= index {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			lag := "no contact with the leader"
+			if !at.IsZero() {
+				lag = fmt.Sprintf("last in sync %s ago", time.Since(at).Round(time.Millisecond))
+			}
+			msg := fmt.Sprintf("replica is staler than %s (%s)", bound, lag)
+			if _, leaderAddr := w.node.GetLeader(); leaderAddr != "" && !w.node.IsLeader() {
+				msg += fmt.Sprintf("; leader is %s", leaderAddr)
+			}
+			return fmt.Errorf("%s", msg)
+		}
+		select {
+		case <-w.stopCh:
+			return fmt.Errorf("raft writer stopped")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
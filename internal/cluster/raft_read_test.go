@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// Read Consistency Tests
+// ============================================================================
+
+func TestParseReadConsistency(t *testing.T) {
+	tests := []struct {
+		input string
+		want  ReadConsistency
+	}{
+		{"", ReadConsistency{Mode: ReadLocal}},
+		{"LOCAL", ReadConsistency{Mode: ReadLocal}},
+		{"linearizable", ReadConsistency{Mode: ReadLinearizable}},
+		{"lease", ReadConsistency{Mode: ReadLease}},
+		{"bounded_staleness(250)", ReadConsistency{Mode: ReadBoundedStaleness, MaxStaleness: 250 * time.Millisecond}},
+		{"bounded_staleness(2s)", ReadConsistency{Mode: ReadBoundedStaleness, MaxStaleness: 2 * time.Second}},
+	}
+	for _, tt := range tests {
+		got, err := ParseReadConsistency(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseReadConsistency(%q) = %+v, %v; want %+v", tt.input, got, err, tt.want)
+		}
+	}
+	if got, _ := ParseReadConsistency("bounded_staleness(250)"); got.String() != "bounded_staleness(250)" {
+		t.Errorf("Unexpected String() %q", got.String())
+	}
+
+	for _, input := range []string{"strong", "bounded_staleness", "bounded_staleness(0)", "bounded_staleness(x)"} {
+		if _, err := ParseReadConsistency(input); err == nil {
+			t.Errorf("ParseReadConsistency(%q) should fail", input)
+		}
+	}
+}
+
+func TestRaftWriter_ReadConsistency(t *testing.T) {
+	var configs []RaftConfig
+	for i := 0; i < 3; i++ {
+		port := freePort(t)
+		config := DefaultRaftConfig(fmt.Sprintf("node%d", i+1), fmt.Sprintf("127.0.0.1:%d", port))
+		config.ClusterPort = port
+		config.DataDir = t.TempDir()
+		config.ElectionTimeout = 150 * time.Millisecond
+		config.HeartbeatInterval = 30 * time.Millisecond
+		configs = append(configs, config)
+	}
+	for i := range configs {
+		for j := range configs {
+			if i != j {
+				configs[i].Peers = append(configs[i].Peers, configs[j].NodeAddr)
+			}
+		}
+	}
+
+	var writers []*RaftWriter
+	var stores []*mockWriteStore
+	var stops []func()
+	for _, config := range configs {
+		store := newMockWriteStore()
+		w := NewRaftWriter(config, store)
+		w.Start()
+		if err := w.Node().Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		var once sync.Once
+		stop := func() {
+			once.Do(func() {
+				w.Node().Stop()
+				w.Stop()
+			})
+		}
+		t.Cleanup(stop)
+		writers = append(writers, w)
+		stores = append(stores, store)
+		stops = append(stops, stop)
+	}
+
+	var leader *RaftWriter
+	waitFor(t, "a leader", func() bool {
+		for _, w := range writers {
+			if w.Node().IsLeader() {
+				leader = w
+				return true
+			}
+		}
+		return false
+	})
+
+	// A linearizable read on any node sees every acknowledged write
+	for i, w := range writers {
+		key := fmt.Sprintf("k%d", i)
+		if err := leader.Propose("appdb", WALOpPut, key, []byte("v")); err != nil {
+			t.Fatalf("Propose failed: %v", err)
+		}
+		if err := w.WaitReadable("linearizable"); err != nil {
+			t.Fatalf("Linearizable read on node %d failed: %v", i+1, err)
+		}
+		if value, _ := stores[i].get("appdb", key); value != "v" {
+			t.Errorf("Linearizable read on node %d missed the write", i+1)
+		}
+	}
+
+	// The leader serves lease reads once a quorum has answered a heartbeat
+	waitFor(t, "the leader to hold a lease", func() bool {
+		leader.node.mu.RLock()
+		defer leader.node.mu.RUnlock()
+		return leader.node.leaseValid(time.Now())
+	})
+	if err := leader.WaitReadable("lease"); err != nil {
+		t.Errorf("Lease read failed: %v", err)
+	}
+
+	// Followers in contact with the leader serve bounded-staleness reads
+	for _, w := range writers {
+		if err := w.WaitReadable("bounded_staleness(200)"); err != nil {
+			t.Errorf("Bounded-staleness read on %s failed: %v", w.node.config.NodeID, err)
+		}
+	}
+
+	if err := leader.WaitReadable("bogus"); err == nil {
+		t.Error("Expected an invalid read_consistency to be rejected")
+	}
+
+	// A follower cut off from the rest of the cluster is soon too stale
+	var isolated *RaftWriter
+	for i, w := range writers {
+		if w != leader && isolated == nil {
+			isolated = w
+			continue
+		}
+		stops[i]()
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := isolated.WaitReadable("bounded_staleness(20)"); err == nil {
+		t.Error("An isolated follower served a bounded-staleness read")
+	}
+	if err := isolated.WaitReadable("local"); err != nil {
+		t.Errorf("Local read failed: %v", err)
+	}
+}
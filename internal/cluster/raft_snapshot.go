@@ -0,0 +1,882 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Raft Snapshots:
+===============
+
+Without snapshots the Raft log grows forever, and a follower that falls
+behind can only catch up by replaying it from the start. Once
+SnapshotThreshold entries have been applied since the last snapshot, the
+node writes its RaftStateMachine to a snapshot file and discards the log
+entries the snapshot covers:
+
+	<data_dir>/snapshot.meta                index, term, size and CRC32 (JSON)
+	<data_dir>/snapshot-<index>.dat         state machine data
+
+The data file is renamed into place before the metadata, so snapshot.meta
+always describes a complete file.
+
+When a follower needs entries that the leader has already discarded, the
+leader sends its snapshot with InstallSnapshot RPCs instead, one chunk of
+SnapshotChunkSize bytes per RPC. Each chunk is a JSON header followed by
+the raw bytes, which are sent from the snapshot file with
+ZeroCopyManager.SendFileRange. Once the last chunk arrives and its CRC32
+matches, the follower replaces its state machine with the snapshot and
+keeps only the log entries that follow it. A new node bootstraps the
+same way.
+
+A crash while a received snapshot is being restored is detected through
+the snapshot.restoring marker, and the restore is repeated on startup.
+*/
+package cluster
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	raftSnapshotMetaFile         = "snapshot.meta"
+	raftSnapshotPrefix           = "snapshot-"
+	raftSnapshotSuffix           = ".dat"
+	raftSnapshotTempFile         = "snapshot.tmp"
+	raftSnapshotRecvFile         = "snapshot.recv"
+	raftSnapshotRestoringFile    = "snapshot.restoring"
+	defaultRaftSnapshotChunkSize = 1 << 20 // 1MB
+)
+
+// RaftStateMachine is the state that committed log entries are applied to.
+// The node snapshots it to compact the log and restores it from snapshots
+// sent by the leader.
+type RaftStateMachine interface {
+	// AppliedIndex returns the index of the last entry the state reflects.
+	AppliedIndex() uint64
+	// Snapshot writes the state to w and returns the index of the last
+	// entry it reflects.
+	Snapshot(w io.Writer) (uint64, error)
+	// Restore replaces the state with a snapshot that reflects the entries
+	// up to index.
+	Restore(r io.Reader, index uint64) error
+}
+
+// RaftSnapshotMeta describes a snapshot file.
+type RaftSnapshotMeta struct {
+	Index uint64 `json:"index"` // Last log entry covered by the snapshot
+	Term  uint64 `json:"term"`  // Term of that entry
+	Size  int64  `json:"size"`  // Size of the data file in bytes
+	CRC   uint32 `json:"crc"`   // CRC32 (IEEE) of the data file
+
+	// Membership is the cluster configuration as of Index
+	Membership *RaftMembership `json:"membership,omitempty"`
+}
+
+// sameSnapshot reports whether m and other describe the same snapshot.
+func (m RaftSnapshotMeta) sameSnapshot(other RaftSnapshotMeta) bool {
+	return m.Index == other.Index && m.Term == other.Term && m.Size == other.Size && m.CRC == other.CRC
+}
+
+// InstallSnapshotArgs contains arguments for InstallSnapshot RPC.
+// Length bytes of snapshot data follow the JSON header.
+type InstallSnapshotArgs struct {
+	Term              uint64 `json:"term"`
+	LeaderID          string `json:"leader_id"`
+	LastIncludedIndex uint64 `json:"last_included_index"`
+	LastIncludedTerm  uint64 `json:"last_included_term"`
+	Offset            int64  `json:"offset"`
+	Length            int64  `json:"length"`
+	Size              int64  `json:"size"`
+	CRC               uint32 `json:"crc"`
+	Done              bool   `json:"done"`
+
+	Membership *RaftMembership `json:"membership,omitempty"`
+}
+
+// InstallSnapshotReply contains the reply for InstallSnapshot RPC
+type InstallSnapshotReply struct {
+	Term    uint64 `json:"term"`
+	Success bool   `json:"success"`
+}
+
+// raftSnapshotReceive tracks a snapshot a follower is receiving.
+type raftSnapshotReceive struct {
+	meta   RaftSnapshotMeta
+	path   string
+	file   *os.File
+	crc    hash.Hash32
+	offset int64
+}
+
+// ============================================================================
+// Storage Engine State Machine
+// ============================================================================
+
+// StoreStateMachine is a RaftStateMachine over a StorageEngine. A snapshot
+// holds every key in the store, in key order, as length-prefixed records:
+//
+//	[key length (4B BE)][key][value length (4B BE)][value]
+//
+// The applied index is kept in memory and starts from the last snapshot
+// when the node restarts. Stores that must not apply an entry twice persist
+// the index with each write, as RaftWriteStore.ApplyCommittedAt does.
+type StoreStateMachine struct {
+	store StorageEngine
+
+	mu      sync.RWMutex
+	applied uint64
+}
+
+// NewStoreStateMachine creates a state machine over store.
+func NewStoreStateMachine(store StorageEngine) *StoreStateMachine {
+	return &StoreStateMachine{store: store}
+}
+
+// Apply runs fn to apply the entry at index to the store, unless the store
+// already reflects it because a newer snapshot was restored. Snapshots
+// never see an entry half applied.
+func (m *StoreStateMachine) Apply(index uint64, fn func(store StorageEngine) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if index <= m.applied {
+		return nil
+	}
+	if err := fn(m.store); err != nil {
+		return err
+	}
+	m.applied = index
+	return nil
+}
+
+// AppliedIndex returns the index of the last applied entry.
+func (m *StoreStateMachine) AppliedIndex() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.applied
+}
+
+// Snapshot writes every key in the store to w, in key order. Keys are
+// listed a batch at a time, so only one batch of values is held in memory.
+func (m *StoreStateMachine) Snapshot(w io.Writer) (uint64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	var lenBuf [4]byte
+	keys, err := newStoreKeyCursor(m.store)
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan store for snapshot: %w", err)
+	}
+	for {
+		key, ok, err := keys.peek("")
+		if err != nil {
+			return 0, fmt.Errorf("failed to scan store for snapshot: %w", err)
+		}
+		if !ok {
+			break
+		}
+		keys.pop()
+		value, err := m.store.Get(key)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %s for snapshot: %w", key, err)
+		}
+		for _, field := range [][]byte{[]byte(key), value} {
+			binary.BigEndian.PutUint32(lenBuf[:], uint32(len(field)))
+			bw.Write(lenBuf[:])
+			bw.Write(field)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return m.applied, nil
+}
+
+// Restore replaces the contents of the store with the snapshot. Snapshot
+// records are in key order, so the store's keys are walked alongside them
+// and a key is deleted as soon as the snapshot is known not to hold it;
+// neither the snapshot nor the store is loaded into memory.
+func (m *StoreStateMachine) Restore(r io.Reader, index uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, err := newStoreKeyCursor(m.store)
+	if err != nil {
+		return fmt.Errorf("failed to scan store: %w", err)
+	}
+	floor := ""
+	for {
+		key, value, err := readSnapshotRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if string(key) < floor {
+			return fmt.Errorf("snapshot key %q is out of order", key)
+		}
+		if err := m.deleteKeys(existing, floor, string(key), true); err != nil {
+			return err
+		}
+		if err := m.store.Put(string(key), value); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", key, err)
+		}
+		floor = string(key) + "\x00"
+	}
+	if err := m.deleteKeys(existing, floor, "", false); err != nil {
+		return err
+	}
+
+	m.applied = index
+	return nil
+}
+
+// deleteKeys deletes the keys of the store listed by keys that are not less
+// than floor and, if bounded, less than limit.
+func (m *StoreStateMachine) deleteKeys(keys *storeKeyCursor, floor, limit string, bounded bool) error {
+	for {
+		key, ok, err := keys.peek(floor)
+		if err != nil {
+			return fmt.Errorf("failed to scan store: %w", err)
+		}
+		if !ok || (bounded && key >= limit) {
+			return nil
+		}
+		keys.pop()
+		if err := m.store.Delete(key); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+	}
+}
+
+// storeKeyScanner is implemented by StorageEngines that can list their keys
+// in order without reading values.
+type storeKeyScanner interface {
+	// ScanKeys calls fn with each key matching prefix that is not less
+	// than start, in key order, until fn returns false.
+	ScanKeys(prefix, start string, fn func(key string) bool) error
+}
+
+// snapshotKeyBatch is the number of keys a storeKeyCursor lists at a time.
+const snapshotKeyBatch = 1024
+
+// storeKeyCursor walks the keys of a store in order, listing them a batch
+// at a time so the store can be read and written between batches. Stores
+// that do not implement storeKeyScanner are listed in full up front.
+type storeKeyCursor struct {
+	scanner storeKeyScanner
+	keys    []string
+	next    string // Start of the next batch
+	done    bool
+}
+
+func newStoreKeyCursor(store StorageEngine) (*storeKeyCursor, error) {
+	if scanner, ok := store.(storeKeyScanner); ok {
+		return &storeKeyCursor{scanner: scanner}, nil
+	}
+	data, err := store.Scan("")
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return &storeKeyCursor{keys: keys, done: true}, nil
+}
+
+// peek returns the next key not less than floor, without consuming it.
+func (c *storeKeyCursor) peek(floor string) (string, bool, error) {
+	for len(c.keys) > 0 && c.keys[0] < floor {
+		c.keys = c.keys[1:]
+	}
+	if len(c.keys) == 0 && !c.done {
+		start := c.next
+		if floor > start {
+			start = floor
+		}
+		err := c.scanner.ScanKeys("", start, func(key string) bool {
+			c.keys = append(c.keys, key)
+			return len(c.keys) < snapshotKeyBatch
+		})
+		if err != nil {
+			return "", false, err
+		}
+		if len(c.keys) < snapshotKeyBatch {
+			c.done = true
+		} else {
+			c.next = c.keys[len(c.keys)-1] + "\x00"
+		}
+	}
+	if len(c.keys) == 0 {
+		return "", false, nil
+	}
+	return c.keys[0], true, nil
+}
+
+// pop consumes the key returned by peek.
+func (c *storeKeyCursor) pop() {
+	c.keys = c.keys[1:]
+}
+
+// readSnapshotRecord reads one key and value written by Snapshot. It
+// returns io.EOF at the end of the snapshot.
+func readSnapshotRecord(r io.Reader) ([]byte, []byte, error) {
+	var fields [2][]byte
+	var lenBuf [4]byte
+	for i := range fields {
+		if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+			if err == io.EOF && i == 0 {
+				return nil, nil, io.EOF
+			}
+			return nil, nil, fmt.Errorf("truncated snapshot: %w", err)
+		}
+		fields[i] = make([]byte, binary.BigEndian.Uint32(lenBuf[:]))
+		if _, err := io.ReadFull(r, fields[i]); err != nil {
+			return nil, nil, fmt.Errorf("truncated snapshot: %w", err)
+		}
+	}
+	return fields[0], fields[1], nil
+}
+
+// ============================================================================
+// Snapshot Files
+// ============================================================================
+
+// snapshotPath returns the path of the data file of the snapshot at index.
+func (s *RaftLogStore) snapshotPath(index uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s%020d%s", raftSnapshotPrefix, index, raftSnapshotSuffix))
+}
+
+// LoadSnapshot returns the metadata of the latest snapshot, or nil if there
+// is none.
+func (s *RaftLogStore) LoadSnapshot() (*RaftSnapshotMeta, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, raftSnapshotMetaFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot metadata: %w", err)
+	}
+
+	var meta RaftSnapshotMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("corrupt snapshot metadata: %w", err)
+	}
+	info, err := os.Stat(s.snapshotPath(meta.Index))
+	if err != nil {
+		return nil, fmt.Errorf("snapshot data for index %d is missing: %w", meta.Index, err)
+	}
+	if info.Size() != meta.Size {
+		return nil, fmt.Errorf("snapshot data for index %d has %d bytes, expected %d", meta.Index, info.Size(), meta.Size)
+	}
+	return &meta, nil
+}
+
+// SaveSnapshot durably makes the fsynced data file at path the latest
+// snapshot and removes older snapshots.
+func (s *RaftLogStore) SaveSnapshot(path string, meta RaftSnapshotMeta) error {
+	dst := s.snapshotPath(meta.Index)
+	if err := os.Rename(path, dst); err != nil {
+		return fmt.Errorf("failed to install snapshot: %w", err)
+	}
+	if err := syncDir(s.dir); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, raftSnapshotMetaFile), data); err != nil {
+		return fmt.Errorf("failed to write snapshot metadata: %w", err)
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(s.dir, raftSnapshotPrefix+"*"+raftSnapshotSuffix))
+	for _, p := range paths {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(p), raftSnapshotPrefix), raftSnapshotSuffix)
+		if index, err := strconv.ParseUint(name, 10, 64); err == nil && index < meta.Index {
+			os.Remove(p)
+		}
+	}
+	return nil
+}
+
+// markRestoring creates or removes the marker that a snapshot is being
+// restored into the state machine.
+func (s *RaftLogStore) markRestoring(restoring bool) error {
+	path := filepath.Join(s.dir, raftSnapshotRestoringFile)
+	if restoring {
+		return writeFileAtomic(path, nil)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return syncDir(s.dir)
+}
+
+// restoreInterrupted reports whether a crash interrupted a restore.
+func (s *RaftLogStore) restoreInterrupted() bool {
+	_, err := os.Stat(filepath.Join(s.dir, raftSnapshotRestoringFile))
+	return err == nil
+}
+
+// ============================================================================
+// Taking and Installing Snapshots
+// ============================================================================
+
+// SetStateMachine sets the state machine that snapshots are taken from and
+// restored to. Call this before Start().
+func (rn *RaftNode) SetStateMachine(sm RaftStateMachine) {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	rn.stateMachine = sm
+}
+
+// snapshotDue reports whether SnapshotThreshold entries were applied since
+// the last snapshot.
+// Must be called with rn.mu held.
+func (rn *RaftNode) snapshotDue() bool {
+	if rn.stateMachine == nil || rn.logStore == nil || rn.config.SnapshotThreshold == 0 {
+		return false
+	}
+	applied := rn.stateMachine.AppliedIndex()
+	first := rn.log[0].Index
+	return applied > first && applied-first >= rn.config.SnapshotThreshold
+}
+
+// TakeSnapshot snapshots the state machine and discards the log entries
+// the snapshot covers.
+func (rn *RaftNode) TakeSnapshot() error {
+	rn.snapshotMu.Lock()
+	defer rn.snapshotMu.Unlock()
+
+	rn.mu.RLock()
+	store, sm := rn.logStore, rn.stateMachine
+	rn.mu.RUnlock()
+	if sm == nil {
+		return fmt.Errorf("no state machine to snapshot")
+	}
+	if store == nil {
+		return fmt.Errorf("snapshots require a data directory")
+	}
+
+	tmp := filepath.Join(store.dir, raftSnapshotTempFile)
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	crc := crc32.NewIEEE()
+	index, err := sm.Snapshot(io.MultiWriter(f, crc))
+	if err == nil {
+		err = f.Sync()
+	}
+	var size int64
+	if err == nil {
+		size, err = f.Seek(0, io.SeekCurrent)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	if index <= rn.log[0].Index {
+		// Nothing applied since the last snapshot
+		os.Remove(tmp)
+		return nil
+	}
+	if index > rn.lastLogIndex() {
+		os.Remove(tmp)
+		return fmt.Errorf("state machine reports index %d beyond the log end %d", index, rn.lastLogIndex())
+	}
+
+	membership, _ := rn.membershipAt(index)
+	meta := RaftSnapshotMeta{Index: index, Term: rn.termAt(index), Size: size, CRC: crc.Sum32(), Membership: &membership}
+	if err := store.SaveSnapshot(tmp, meta); err != nil {
+		return err
+	}
+	rn.snapshot = &meta
+	rn.compactLog(index, meta.Term)
+
+	fmt.Printf("Node %s took snapshot at index %d (%d bytes)\n", rn.config.NodeID, index, size)
+	return nil
+}
+
+// compactLog discards the log entries up to index, which a snapshot covers.
+// Must be called with rn.mu held.
+func (rn *RaftNode) compactLog(index, term uint64) {
+	log := []LogEntry{{Index: index, Term: term, Type: LogEntryNoop}}
+	if first := rn.log[0].Index; index >= first && index < rn.lastLogIndex() {
+		log = append(log, rn.log[index-first+1:]...)
+	}
+	rn.log = log
+	rn.refreshMembership()
+
+	if rn.logStore != nil {
+		if err := rn.logStore.Compact(index); err != nil {
+			fmt.Printf("Node %s failed to compact log: %v\n", rn.config.NodeID, err)
+		}
+	}
+}
+
+// restoreSnapshot replaces the state machine with the snapshot.
+// Must be called with rn.mu held.
+func (rn *RaftNode) restoreSnapshot(meta RaftSnapshotMeta) error {
+	if err := rn.logStore.markRestoring(true); err != nil {
+		return fmt.Errorf("failed to mark snapshot restore: %w", err)
+	}
+
+	f, err := os.Open(rn.logStore.snapshotPath(meta.Index))
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	if err := rn.stateMachine.Restore(bufio.NewReader(f), meta.Index); err != nil {
+		return fmt.Errorf("failed to restore snapshot at index %d: %w", meta.Index, err)
+	}
+	return rn.logStore.markRestoring(false)
+}
+
+// installSnapshot makes a received snapshot the node's state: it replaces
+// the state machine and keeps only the log entries that follow the
+// snapshot, if the log agrees with it.
+// Must be called with rn.snapshotMu held.
+func (rn *RaftNode) installSnapshot(meta RaftSnapshotMeta, path string) error {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	if meta.Index <= rn.lastApplied {
+		// The state machine is already past the snapshot
+		os.Remove(path)
+		return nil
+	}
+
+	if err := rn.logStore.SaveSnapshot(path, meta); err != nil {
+		return err
+	}
+	rn.snapshot = &meta
+	if rn.stateMachine != nil {
+		if err := rn.restoreSnapshot(meta); err != nil {
+			return err
+		}
+	}
+
+	if rn.termAt(meta.Index) == meta.Term {
+		rn.compactLog(meta.Index, meta.Term)
+	} else {
+		if err := rn.logStore.TruncateFrom(0); err != nil {
+			return fmt.Errorf("failed to discard log: %w", err)
+		}
+		rn.log = []LogEntry{{Index: meta.Index, Term: meta.Term, Type: LogEntryNoop}}
+		rn.refreshMembership()
+	}
+
+	if rn.commitIndex < meta.Index {
+		rn.commitIndex = meta.Index
+	}
+	rn.lastApplied = meta.Index
+
+	fmt.Printf("Node %s installed snapshot at index %d (term %d)\n", rn.config.NodeID, meta.Index, meta.Term)
+	return nil
+}
+
+// ============================================================================
+// InstallSnapshot RPC
+// ============================================================================
+
+// sendSnapshotToPeer sends the latest snapshot to a peer in chunks. Only one
+// transfer per peer runs at a time.
+func (rn *RaftNode) sendSnapshotToPeer(peer *RaftPeer) {
+	peer.mu.Lock()
+	if peer.sendingSnapshot {
+		peer.mu.Unlock()
+		return
+	}
+	peer.sendingSnapshot = true
+	peer.mu.Unlock()
+	defer func() {
+		peer.mu.Lock()
+		peer.sendingSnapshot = false
+		peer.mu.Unlock()
+	}()
+
+	rn.mu.RLock()
+	if rn.snapshot == nil {
+		rn.mu.RUnlock()
+		return
+	}
+	term := rn.currentTerm
+	meta := *rn.snapshot
+	path := rn.logStore.snapshotPath(meta.Index)
+	rn.mu.RUnlock()
+
+	// The open file stays readable even if a newer snapshot replaces it
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	chunkSize := rn.config.SnapshotChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultRaftSnapshotChunkSize
+	}
+
+	for offset := int64(0); ; {
+		length := meta.Size - offset
+		if length > chunkSize {
+			length = chunkSize
+		}
+		args := InstallSnapshotArgs{
+			Term:              term,
+			LeaderID:          rn.config.NodeID,
+			LastIncludedIndex: meta.Index,
+			LastIncludedTerm:  meta.Term,
+			Offset:            offset,
+			Length:            length,
+			Size:              meta.Size,
+			CRC:               meta.CRC,
+			Done:              offset+length == meta.Size,
+			Membership:        meta.Membership,
+		}
+
+		reply := rn.sendInstallSnapshot(peer.Addr, args, f)
+		if reply == nil {
+			return
+		}
+		if reply.Term > term {
+			rn.mu.Lock()
+			if reply.Term > rn.currentTerm {
+				rn.becomeFollower(reply.Term, "")
+			}
+			rn.mu.Unlock()
+			return
+		}
+		if !reply.Success {
+			return
+		}
+		if args.Done {
+			break
+		}
+		offset += length
+	}
+
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	if rn.currentTerm == term && rn.GetState() == StateLeader {
+		if rn.matchIndex[peer.Addr] < meta.Index {
+			rn.matchIndex[peer.Addr] = meta.Index
+		}
+		rn.nextIndex[peer.Addr] = rn.matchIndex[peer.Addr] + 1
+	}
+
+	fmt.Printf("Node %s sent snapshot at index %d to %s\n", rn.config.NodeID, meta.Index, peer.Addr)
+}
+
+// sendInstallSnapshot sends one InstallSnapshot chunk, read from f, to a peer
+func (rn *RaftNode) sendInstallSnapshot(addr string, args InstallSnapshotArgs, f *os.File) *InstallSnapshotReply {
+	conn, err := net.DialTimeout("tcp", addr, 500*time.Millisecond)
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	// Send message type
+	conn.Write([]byte{RaftMsgInstallSnapshot})
+
+	// Send header, then the chunk straight from the snapshot file
+	data, _ := json.Marshal(args)
+	binary.Write(conn, binary.BigEndian, uint32(len(data)))
+	conn.Write(data)
+	if args.Length > 0 {
+		n, err := rn.zeroCopy.SendFileRange(conn, f, args.Offset, args.Length)
+		if err != nil || n != args.Length {
+			return nil
+		}
+	}
+
+	// Read response type and length
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(conn, header); err != nil || header[0] != RaftMsgInstallSnapshotResp {
+		return nil
+	}
+	respLen := binary.BigEndian.Uint32(header[1:])
+
+	// Read response body
+	body := make([]byte, respLen)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return nil
+	}
+
+	var reply InstallSnapshotReply
+	if err := json.Unmarshal(body, &reply); err != nil {
+		return nil
+	}
+
+	return &reply
+}
+
+// handleInstallSnapshot handles incoming InstallSnapshot RPCs
+func (rn *RaftNode) handleInstallSnapshot(conn net.Conn) {
+	// Read request length
+	lenBuf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, lenBuf); err != nil {
+		return
+	}
+	msgLen := binary.BigEndian.Uint32(lenBuf)
+
+	// Read request header
+	body := make([]byte, msgLen)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return
+	}
+
+	var args InstallSnapshotArgs
+	if err := json.Unmarshal(body, &args); err != nil {
+		return
+	}
+
+	rn.mu.Lock()
+	reply := InstallSnapshotReply{Term: rn.currentTerm}
+
+	// Reply false if term < currentTerm
+	if args.Term < rn.currentTerm {
+		rn.mu.Unlock()
+		rn.sendInstallSnapshotReply(conn, reply)
+		return
+	}
+
+	if args.Term > rn.currentTerm || rn.GetState() != StateFollower {
+		rn.becomeFollower(args.Term, args.LeaderID)
+	}
+	reply.Term = rn.currentTerm
+
+	// Reset election timer
+	select {
+	case rn.heartbeatCh <- struct{}{}:
+	default:
+	}
+
+	// Update leader info
+	rn.leaderMu.Lock()
+	rn.leaderID = args.LeaderID
+	rn.leaderMu.Unlock()
+
+	store := rn.logStore
+	rn.mu.Unlock()
+
+	if store == nil {
+		fmt.Printf("Node %s cannot receive a snapshot without a data directory\n", rn.config.NodeID)
+		rn.sendInstallSnapshotReply(conn, reply)
+		return
+	}
+	if err := rn.receiveSnapshotChunk(conn, store, args); err != nil {
+		fmt.Printf("Node %s failed to receive snapshot: %v\n", rn.config.NodeID, err)
+		rn.sendInstallSnapshotReply(conn, reply)
+		return
+	}
+
+	reply.Success = true
+	rn.sendInstallSnapshotReply(conn, reply)
+}
+
+// receiveSnapshotChunk writes a chunk to the snapshot being received, and
+// installs the snapshot once it is complete.
+func (rn *RaftNode) receiveSnapshotChunk(conn net.Conn, store *RaftLogStore, args InstallSnapshotArgs) error {
+	rn.snapshotMu.Lock()
+	defer rn.snapshotMu.Unlock()
+
+	meta := RaftSnapshotMeta{
+		Index:      args.LastIncludedIndex,
+		Term:       args.LastIncludedTerm,
+		Size:       args.Size,
+		CRC:        args.CRC,
+		Membership: args.Membership,
+	}
+	recv := rn.snapshotRecv
+	if args.Offset == 0 {
+		// A new transfer replaces any unfinished one
+		if recv != nil {
+			recv.file.Close()
+		}
+		path := filepath.Join(store.dir, raftSnapshotRecvFile)
+		f, err := os.Create(path)
+		if err != nil {
+			rn.snapshotRecv = nil
+			return fmt.Errorf("failed to create snapshot file: %w", err)
+		}
+		recv = &raftSnapshotReceive{meta: meta, path: path, file: f, crc: crc32.NewIEEE()}
+		rn.snapshotRecv = recv
+	} else if recv == nil || !recv.meta.sameSnapshot(meta) || recv.offset != args.Offset {
+		return fmt.Errorf("unexpected chunk at offset %d of snapshot at index %d", args.Offset, meta.Index)
+	}
+
+	if _, err := io.CopyN(io.MultiWriter(recv.file, recv.crc), conn, args.Length); err != nil {
+		recv.file.Close()
+		rn.snapshotRecv = nil
+		return fmt.Errorf("failed to read snapshot chunk: %w", err)
+	}
+	recv.offset += args.Length
+	if !args.Done {
+		return nil
+	}
+
+	rn.snapshotRecv = nil
+	err := recv.file.Sync()
+	if closeErr := recv.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && (recv.offset != meta.Size || recv.crc.Sum32() != meta.CRC) {
+		err = fmt.Errorf("snapshot at index %d failed its checksum", meta.Index)
+	}
+	if err != nil {
+		os.Remove(recv.path)
+		return err
+	}
+	return rn.installSnapshot(meta, recv.path)
+}
+
+// sendInstallSnapshotReply sends an InstallSnapshot reply
+func (rn *RaftNode) sendInstallSnapshotReply(conn net.Conn, reply InstallSnapshotReply) {
+	replyData, _ := json.Marshal(reply)
+	conn.Write([]byte{RaftMsgInstallSnapshotResp})
+	binary.Write(conn, binary.BigEndian, uint32(len(replyData)))
+	conn.Write(replyData)
+}
@@ -0,0 +1,245 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// Raft Snapshot Tests
+// ============================================================================
+
+func TestStoreStateMachine_SnapshotRestore(t *testing.T) {
+	source := newMockStore()
+	sm := NewStoreStateMachine(source)
+	for i := uint64(1); i <= 3; i++ {
+		key := fmt.Sprintf("row:t:%d", i)
+		if err := sm.Apply(i, func(store StorageEngine) error {
+			return store.Put(key, []byte(fmt.Sprintf(`{"id":%d}`, i)))
+		}); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	index, err := sm.Snapshot(&buf)
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	if index != 3 {
+		t.Errorf("Expected snapshot at index 3, got %d", index)
+	}
+
+	target := newMockStore()
+	target.Put("row:t:stale", []byte("{}"))
+	restored := NewStoreStateMachine(target)
+	if err := restored.Restore(&buf, index); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if len(target.data) != 3 || string(target.data["row:t:2"]) != `{"id":2}` {
+		t.Errorf("Unexpected restored data: %v", target.data)
+	}
+	if restored.AppliedIndex() != 3 {
+		t.Errorf("Expected applied index 3, got %d", restored.AppliedIndex())
+	}
+
+	// Entries the snapshot covers are not applied again
+	restored.Apply(2, func(store StorageEngine) error {
+		t.Error("Entry covered by the snapshot was applied")
+		return nil
+	})
+}
+
+func TestRaftLogStore_Compact(t *testing.T) {
+	dir := t.TempDir()
+	store, _, _ := openLoadedRaftLogStore(t, dir)
+	store.segmentMaxBytes = 200
+	if err := store.Append(raftEntries(1, 20, 1)); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := store.Compact(10); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	store.Close()
+
+	store, _, entries := openLoadedRaftLogStore(t, dir)
+	defer store.Close()
+	if len(entries) == 0 || entries[0].Index == 1 || entries[0].Index > 11 {
+		t.Fatalf("Expected the log to start after index 1 and by index 11, got %d entries", len(entries))
+	}
+	if last := entries[len(entries)-1].Index; last != 20 {
+		t.Errorf("Expected the log to end at 20, got %d", last)
+	}
+}
+
+// raftTestNode is a node of an in-process test cluster whose commands are
+// "key=value" pairs applied to a mock store.
+type raftTestNode struct {
+	node *RaftNode
+	sm   *StoreStateMachine
+	data *mockStore
+	done chan struct{}
+}
+
+func startRaftTestNode(t *testing.T, config RaftConfig) *raftTestNode {
+	t.Helper()
+	applyCh := make(chan LogEntry)
+	tn := &raftTestNode{
+		node: NewRaftNode(config, applyCh),
+		data: newMockStore(),
+		done: make(chan struct{}),
+	}
+	tn.sm = NewStoreStateMachine(tn.data)
+	tn.node.SetStateMachine(tn.sm)
+
+	go func() {
+		for {
+			select {
+			case <-tn.done:
+				return
+			case entry := <-applyCh:
+				kv := strings.SplitN(string(entry.Command), "=", 2)
+				tn.sm.Apply(entry.Index, func(store StorageEngine) error {
+					return store.Put(kv[0], []byte(kv[1]))
+				})
+			}
+		}
+	}()
+
+	if err := tn.node.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() {
+		tn.node.Stop()
+		close(tn.done)
+	})
+	return tn
+}
+
+// keys returns the number of keys in the node's store.
+func (tn *raftTestNode) keys() int {
+	tn.sm.mu.RLock()
+	defer tn.sm.mu.RUnlock()
+	return len(tn.data.data)
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestRaftNode_InstallSnapshot(t *testing.T) {
+	var configs []RaftConfig
+	var addrs []string
+	for i := 0; i < 3; i++ {
+		port := freePort(t)
+		addr := fmt.Sprintf("127.0.0.1:%d", port)
+		config := DefaultRaftConfig(fmt.Sprintf("node%d", i+1), addr)
+		config.ClusterPort = port
+		config.DataDir = t.TempDir()
+		config.ElectionTimeout = 150 * time.Millisecond
+		config.HeartbeatInterval = 30 * time.Millisecond
+		config.SnapshotThreshold = 0 // Snapshots are taken explicitly
+		config.SnapshotChunkSize = 64 // Force several chunks
+		configs = append(configs, config)
+		addrs = append(addrs, addr)
+	}
+	for i := range configs {
+		for j, addr := range addrs {
+			if i != j {
+				configs[i].Peers = append(configs[i].Peers, addr)
+			}
+		}
+	}
+
+	// Two of three nodes form a quorum
+	nodes := []*raftTestNode{startRaftTestNode(t, configs[0]), startRaftTestNode(t, configs[1])}
+	var leader *raftTestNode
+	waitFor(t, "a leader", func() bool {
+		for _, n := range nodes {
+			if n.node.IsLeader() {
+				leader = n
+				return true
+			}
+		}
+		return false
+	})
+
+	for i := 0; i < 20; i++ {
+		if err := leader.node.Propose([]byte(fmt.Sprintf("key%02d=value%02d", i, i))); err != nil {
+			t.Fatalf("Propose failed: %v", err)
+		}
+	}
+	waitFor(t, "the leader to apply the commands", func() bool { return leader.keys() == 20 })
+
+	if err := leader.node.TakeSnapshot(); err != nil {
+		t.Fatalf("TakeSnapshot failed: %v", err)
+	}
+	status := leader.node.GetClusterStatus()
+	snapshotIndex := status["snapshot_index"].(uint64)
+	if snapshotIndex < 20 {
+		t.Fatalf("Expected a snapshot covering the commands, got index %d", snapshotIndex)
+	}
+	if status["log_length"].(int) != 1 {
+		t.Errorf("Expected the snapshot to discard the log, %d entries remain", status["log_length"])
+	}
+
+	// The third node has an empty log and can only catch up from the snapshot
+	late := startRaftTestNode(t, configs[2])
+	waitFor(t, "the new node to install the snapshot", func() bool { return late.keys() == 20 })
+	if got := late.node.GetClusterStatus()["snapshot_index"].(uint64); got != snapshotIndex {
+		t.Errorf("Expected the new node's log to start at %d, got %d", snapshotIndex, got)
+	}
+
+	// Replication continues after the snapshot
+	if err := leader.node.Propose([]byte("after=snapshot")); err != nil {
+		t.Fatalf("Propose failed: %v", err)
+	}
+	waitFor(t, "the new node to apply a later entry", func() bool { return late.keys() == 21 })
+
+	// The snapshot is durable
+	store, err := OpenRaftLogStore(configs[2].DataDir)
+	if err != nil {
+		t.Fatalf("OpenRaftLogStore failed: %v", err)
+	}
+	meta, err := store.LoadSnapshot()
+	if err != nil || meta == nil || meta.Index != snapshotIndex {
+		t.Errorf("Expected a snapshot at %d on disk, got %+v (%v)", snapshotIndex, meta, err)
+	}
+}
@@ -0,0 +1,431 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Raft-Replicated Writes:
+=======================
+
+With Raft enabled, storage writes are not applied where they are issued.
+The leader encodes the writes of a statement or transaction, or a single
+Put or Delete, as one RaftWriteCommand and proposes it to the Raft log.
+Every node, the leader included, applies commands in log order as they come
+out of applyCh, each as one atomic batch:
+
+	client -> statement -> RaftWriter.ProposeBatch -> RaftNode.ProposeEntry
+	                                                        |
+	                                              quorum commit, applyCh
+	                                                        |
+	          RaftWriter.applyLoop -> RaftWriteStore.ApplyCommittedAt
+
+Propose returns only once the entry it proposed has been applied locally
+and still carries the term it was proposed in. A write is therefore
+acknowledged only after a quorum has committed it, and a new leader, which
+always holds every committed entry, can never lose it. If leadership
+changes before the entry commits, the entry is overwritten and Propose
+fails instead of waiting for its timeout.
+
+Writes issued on a follower fail with ErrNotRaftLeader; clients retry
+against the leader reported by RaftNode.GetLeader.
+
+A committed entry that cannot be applied is never skipped, since the node
+would silently diverge from its peers. The proposer waiting on the entry
+gets the apply error, the writer stops applying and refuses new writes, and
+the Raft node is stopped so that it neither leads nor votes. After a
+restart the node replays the log and tries the entry again.
+
+Each database persists the index of the last entry applied to it together
+with the writes themselves (RaftWriteStore.ApplyCommittedAt). After a restart the
+node replays the log from its last snapshot, and every database skips the
+entries it already holds, so each entry is applied exactly once.
+
+The writer is also the node's RaftStateMachine. A snapshot holds every key
+of every database, with keys qualified by their database name.
+*/
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotRaftLeader is returned for proposals made on a node that is not the
+// Raft leader.
+var ErrNotRaftLeader = errors.New("not the leader")
+
+// DefaultRaftWriteTimeout bounds how long a proposed write waits to commit.
+const DefaultRaftWriteTimeout = 5 * time.Second
+
+// raftWriteKeySeparator separates the database name from the key in
+// snapshots. Database names cannot contain it.
+const raftWriteKeySeparator = "\x00"
+
+// RaftWrite is a single Put or Delete of a RaftWriteCommand.
+type RaftWrite struct {
+	Op    byte   `json:"op"` // WALOpPut or WALOpDelete
+	Key   string `json:"key"`
+	Value []byte `json:"value,omitempty"`
+}
+
+// RaftWriteCommand is a batch of storage writes to one database replicated
+// through the Raft log as a single entry.
+type RaftWriteCommand struct {
+	Database string      `json:"database"`
+	Writes   []RaftWrite `json:"writes"`
+}
+
+// RaftWriteStore is the local storage that committed writes are applied to.
+type RaftWriteStore interface {
+	// ApplyCommitted applies a committed write to a database, creating the
+	// database if it does not exist.
+	ApplyCommitted(database string, op byte, key string, value []byte) error
+	// ApplyCommittedAt applies the writes of the log entry at index to a
+	// database as one atomic batch, like ApplyCommitted. The database
+	// persists index atomically with the writes and skips entries up to the
+	// last index it persisted.
+	ApplyCommittedAt(database string, index uint64, writes []RaftWrite) error
+	// ListDatabases returns the names of all databases.
+	ListDatabases() []string
+	// ScanDatabaseKeys calls fn with each key of a database that matches
+	// prefix and is not less than start, in key order, until fn returns
+	// false.
+	ScanDatabaseKeys(database, prefix, start string, fn func(key string) bool) error
+	// GetFromDatabase returns the value of a key in a database.
+	GetFromDatabase(database, key string) ([]byte, error)
+}
+
+// RaftWriter proposes storage writes to the Raft log and applies committed
+// writes to a RaftWriteStore.
+type RaftWriter struct {
+	node    *RaftNode
+	sm      *StoreStateMachine
+	storage *raftWriteStorage
+	applyCh chan LogEntry
+	timeout time.Duration
+
+	// appliedCh is closed and replaced whenever entries are applied, and
+	// closed for good once an entry fails to apply
+	mu        sync.Mutex
+	appliedCh chan struct{}
+	failed    error // Why applying stopped
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewRaftWriter creates a writer and the Raft node whose log it replicates
+// writes through. The node is started separately, usually by
+// UnifiedClusterManager.Start after SetRaftNode.
+func NewRaftWriter(config RaftConfig, store RaftWriteStore) *RaftWriter {
+	storage := &raftWriteStorage{store: store}
+	w := &RaftWriter{
+		sm:        NewStoreStateMachine(storage),
+		storage:   storage,
+		applyCh:   make(chan LogEntry),
+		timeout:   DefaultRaftWriteTimeout,
+		appliedCh: make(chan struct{}),
+		stopCh:    make(chan struct{}),
+	}
+	w.node = NewRaftNode(config, w.applyCh)
+	w.node.SetStateMachine(w.sm)
+	return w
+}
+
+// Node returns the Raft node.
+func (w *RaftWriter) Node() *RaftNode {
+	return w.node
+}
+
+// SetTimeout sets how long Propose waits for a write to commit.
+func (w *RaftWriter) SetTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		w.timeout = timeout
+	}
+}
+
+// SetApplyHook sets a function called with the writes of every committed
+// entry once they are applied, and with every key a snapshot restores.
+// Call this before Start.
+func (w *RaftWriter) SetApplyHook(fn func(database string, writes []RaftWrite)) {
+	w.storage.onApply = fn
+}
+
+// Get returns the committed value of key in database on this node.
+func (w *RaftWriter) Get(database, key string) ([]byte, error) {
+	return w.storage.store.GetFromDatabase(database, key)
+}
+
+// Start starts applying committed writes.
+func (w *RaftWriter) Start() {
+	w.wg.Add(1)
+	go w.applyLoop()
+}
+
+// Stop stops applying committed writes. Pending proposals fail.
+func (w *RaftWriter) Stop() {
+	close(w.stopCh)
+	w.wg.Wait()
+}
+
+// Propose replicates a write to a database and returns once it has been
+// committed by a quorum and applied on this node.
+func (w *RaftWriter) Propose(database string, op byte, key string, value []byte) error {
+	return w.ProposeBatch(database, []RaftWrite{{Op: op, Key: key, Value: value}})
+}
+
+// ProposeBatch replicates writes to a database as a single log entry, so
+// they are applied together on every node, and returns once the entry has
+// been committed by a quorum and applied on this node.
+func (w *RaftWriter) ProposeBatch(database string, writes []RaftWrite) error {
+	if strings.Contains(database, raftWriteKeySeparator) {
+		return fmt.Errorf("invalid database name %q", database)
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+	if err := w.applyError(); err != nil {
+		return err
+	}
+	command, err := json.Marshal(RaftWriteCommand{Database: database, Writes: writes})
+	if err != nil {
+		return fmt.Errorf("failed to encode write: %w", err)
+	}
+
+	index, term, err := w.node.ProposeEntry(command)
+	if err != nil {
+		if errors.Is(err, ErrNotRaftLeader) {
+			if _, leaderAddr := w.node.GetLeader(); leaderAddr != "" {
+				return fmt.Errorf("%w (leader is %s)", err, leaderAddr)
+			}
+		}
+		return err
+	}
+
+	if err := w.waitApplied(index, w.timeout); err != nil {
+		return err
+	}
+	if applied, ok := w.node.EntryTerm(index); !ok || applied != term {
+		if !ok {
+			return fmt.Errorf("outcome of write at index %d is unknown: the entry was compacted", index)
+		}
+		return fmt.Errorf("write at index %d was lost to a leader change", index)
+	}
+	return nil
+}
+
+// applyError returns why the writer stopped applying entries, or nil.
+func (w *RaftWriter) applyError() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.failed
+}
+
+// waitApplied waits up to limit until the entry at index has been applied.
+// It fails if the writer stops applying entries first.
+func (w *RaftWriter) waitApplied(index uint64, limit time.Duration) error {
+	timeout := time.NewTimer(limit)
+	defer timeout.Stop()
+
+	for {
+		w.mu.Lock()
+		ch, failed := w.appliedCh, w.failed
+		w.mu.Unlock()
+		if w.sm.AppliedIndex() >= index {
+			return nil
+		}
+		if failed != nil {
+			return failed
+		}
+
+		select {
+		case <-ch:
+		case <-timeout.C:
+			return fmt.Errorf("timed out waiting for index %d to be applied", index)
+		case <-w.stopCh:
+			return fmt.Errorf("raft writer stopped")
+		}
+	}
+}
+
+// applyLoop applies committed entries in log order.
+func (w *RaftWriter) applyLoop() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case entry := <-w.applyCh:
+			if err := w.apply(entry); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// apply applies a single committed entry and wakes proposers waiting on it.
+// If the entry cannot be applied the writer is fenced: waiting and later
+// proposals fail with the error and the Raft node is stopped.
+func (w *RaftWriter) apply(entry LogEntry) error {
+	var cmd RaftWriteCommand
+	err := json.Unmarshal(entry.Command, &cmd)
+	if err == nil {
+		err = w.sm.Apply(entry.Index, func(store StorageEngine) error {
+			return store.(*raftWriteStorage).apply(entry.Index, cmd)
+		})
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	close(w.appliedCh)
+	if err != nil {
+		w.failed = fmt.Errorf("failed to apply write at index %d: %w", entry.Index, err)
+		fmt.Printf("Node %s stopped applying writes: %v\n", w.node.config.NodeID, w.failed)
+		go w.node.Stop()
+		return w.failed
+	}
+	w.appliedCh = make(chan struct{})
+	return nil
+}
+
+// raftWriteStorage presents a RaftWriteStore as a single StorageEngine so
+// the StoreStateMachine can snapshot and restore all databases. Its keys are
+// "<database>\x00<key>".
+type raftWriteStorage struct {
+	store   RaftWriteStore
+	onApply func(database string, writes []RaftWrite) // Called after writes are applied
+}
+
+func (s *raftWriteStorage) apply(index uint64, cmd RaftWriteCommand) error {
+	for _, write := range cmd.Writes {
+		if write.Op != WALOpPut && write.Op != WALOpDelete {
+			return fmt.Errorf("unknown write operation %d", write.Op)
+		}
+	}
+	if err := s.store.ApplyCommittedAt(cmd.Database, index, cmd.Writes); err != nil {
+		return err
+	}
+	s.applied(cmd.Database, cmd.Writes...)
+	return nil
+}
+
+func (s *raftWriteStorage) applied(database string, writes ...RaftWrite) {
+	if s.onApply != nil {
+		s.onApply(database, writes)
+	}
+}
+
+func splitRaftWriteKey(key string) (string, string, error) {
+	database, key, ok := strings.Cut(key, raftWriteKeySeparator)
+	if !ok {
+		return "", "", fmt.Errorf("snapshot key %q has no database", key)
+	}
+	return database, key, nil
+}
+
+func (s *raftWriteStorage) Put(key string, value []byte) error {
+	database, key, err := splitRaftWriteKey(key)
+	if err != nil {
+		return err
+	}
+	if err := s.store.ApplyCommitted(database, WALOpPut, key, value); err != nil {
+		return err
+	}
+	s.applied(database, RaftWrite{Op: WALOpPut, Key: key, Value: value})
+	return nil
+}
+
+func (s *raftWriteStorage) Delete(key string) error {
+	database, key, err := splitRaftWriteKey(key)
+	if err != nil {
+		return err
+	}
+	if err := s.store.ApplyCommitted(database, WALOpDelete, key, nil); err != nil {
+		return err
+	}
+	s.applied(database, RaftWrite{Op: WALOpDelete, Key: key})
+	return nil
+}
+
+func (s *raftWriteStorage) Get(key string) ([]byte, error) {
+	database, key, err := splitRaftWriteKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.GetFromDatabase(database, key)
+}
+
+func (s *raftWriteStorage) Scan(prefix string) (map[string][]byte, error) {
+	result := make(map[string][]byte)
+	var keys []string
+	err := s.ScanKeys(prefix, "", func(key string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		value, err := s.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// ScanKeys lists the keys of every database in order. Databases are visited
+// by name, which orders their qualified keys correctly because the
+// separator sorts before any character of a name.
+func (s *raftWriteStorage) ScanKeys(prefix, start string, fn func(key string) bool) error {
+	databases := s.store.ListDatabases()
+	sort.Strings(databases)
+
+	for _, database := range databases {
+		qualified := database + raftWriteKeySeparator
+		if !strings.HasPrefix(qualified, prefix) && !strings.HasPrefix(prefix, qualified) {
+			continue
+		}
+		keyPrefix := ""
+		if len(prefix) > len(qualified) {
+			keyPrefix = prefix[len(qualified):]
+		}
+		keyStart := ""
+		if strings.HasPrefix(start, qualified) {
+			keyStart = start[len(qualified):]
+		} else if start > qualified {
+			// Every key of the database sorts before start
+			continue
+		}
+
+		stopped := false
+		err := s.store.ScanDatabaseKeys(database, keyPrefix, keyStart, func(key string) bool {
+			if !fn(qualified + key) {
+				stopped = true
+			}
+			return !stopped
+		})
+		if err != nil || stopped {
+			return err
+		}
+	}
+	return nil
+}
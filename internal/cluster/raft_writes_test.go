@@ -0,0 +1,325 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// Raft-Replicated Write Tests
+// ============================================================================
+
+// mockWriteStore is an in-memory RaftWriteStore.
+type mockWriteStore struct {
+	mu        sync.Mutex
+	databases map[string]map[string][]byte
+	applied   map[string]uint64
+}
+
+func newMockWriteStore() *mockWriteStore {
+	return &mockWriteStore{databases: make(map[string]map[string][]byte), applied: make(map[string]uint64)}
+}
+
+func (s *mockWriteStore) ApplyCommittedAt(database string, index uint64, writes []RaftWrite) error {
+	s.mu.Lock()
+	if index <= s.applied[database] {
+		s.mu.Unlock()
+		return nil
+	}
+	s.applied[database] = index
+	s.mu.Unlock()
+	for _, w := range writes {
+		if err := s.ApplyCommitted(database, w.Op, w.Key, w.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *mockWriteStore) ApplyCommitted(database string, op byte, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.databases[database] == nil {
+		s.databases[database] = make(map[string][]byte)
+	}
+	if op == WALOpDelete {
+		delete(s.databases[database], key)
+	} else {
+		s.databases[database][key] = value
+	}
+	return nil
+}
+
+func (s *mockWriteStore) ListDatabases() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.databases))
+	for name := range s.databases {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (s *mockWriteStore) ScanDatabaseKeys(database, prefix, start string, fn func(key string) bool) error {
+	s.mu.Lock()
+	var keys []string
+	for key := range s.databases[database] {
+		if strings.HasPrefix(key, prefix) && key >= start {
+			keys = append(keys, key)
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !fn(key) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *mockWriteStore) GetFromDatabase(database, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.databases[database][key]
+	if !ok {
+		return nil, fmt.Errorf("key not found")
+	}
+	return value, nil
+}
+
+func (s *mockWriteStore) get(database, key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.databases[database][key]
+	return string(value), ok
+}
+
+func TestRaftWriter_SnapshotRestore(t *testing.T) {
+	source := newMockWriteStore()
+	writer := NewRaftWriter(DefaultRaftConfig("node1", "127.0.0.1:0"), source)
+	writer.apply(LogEntry{Index: 1, Command: []byte(`{"database":"default","writes":[{"op":1,"key":"k1","value":"djE="}]}`)})
+	writer.apply(LogEntry{Index: 2, Command: []byte(`{"database":"appdb","writes":[{"op":1,"key":"k1","value":"djI="}]}`)})
+	// Enough rows that the snapshot and the restore list keys in several batches
+	for i := 0; i < 2*snapshotKeyBatch+10; i++ {
+		source.ApplyCommitted("bulk", WALOpPut, fmt.Sprintf("row:%05d", i), []byte("v"))
+	}
+
+	var buf bytes.Buffer
+	index, err := writer.sm.Snapshot(&buf)
+	if err != nil || index != 2 {
+		t.Fatalf("Snapshot failed at index %d: %v", index, err)
+	}
+
+	target := newMockWriteStore()
+	target.ApplyCommitted("appdb", WALOpPut, "stale", []byte("x"))
+	target.ApplyCommitted("aaa", WALOpPut, "stale", []byte("x"))
+	target.ApplyCommitted("zzz", WALOpPut, "stale", []byte("x"))
+	for i := 0; i < 2*snapshotKeyBatch+20; i += 3 {
+		target.ApplyCommitted("bulk", WALOpPut, fmt.Sprintf("row:%05d-stale", i), []byte("x"))
+	}
+	restored := NewRaftWriter(DefaultRaftConfig("node2", "127.0.0.1:0"), target)
+	if err := restored.sm.Restore(&buf, index); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if value, _ := target.get("default", "k1"); value != "v1" {
+		t.Errorf("Expected v1 in default, got %q", value)
+	}
+	if value, _ := target.get("appdb", "k1"); value != "v2" {
+		t.Errorf("Expected v2 in appdb, got %q", value)
+	}
+	for _, database := range []string{"aaa", "appdb", "zzz"} {
+		if _, ok := target.get(database, "stale"); ok {
+			t.Errorf("Key missing from the snapshot survived the restore in %s", database)
+		}
+	}
+	if rows := len(target.databases["bulk"]); rows != 2*snapshotKeyBatch+10 {
+		t.Errorf("Expected %d rows in bulk after the restore, got %d", 2*snapshotKeyBatch+10, rows)
+	}
+}
+
+func TestRaftWriter_ReplicatesCommittedWrites(t *testing.T) {
+	var configs []RaftConfig
+	var addrs []string
+	for i := 0; i < 3; i++ {
+		port := freePort(t)
+		addr := fmt.Sprintf("127.0.0.1:%d", port)
+		config := DefaultRaftConfig(fmt.Sprintf("node%d", i+1), addr)
+		config.ClusterPort = port
+		config.DataDir = t.TempDir()
+		config.ElectionTimeout = 150 * time.Millisecond
+		config.HeartbeatInterval = 30 * time.Millisecond
+		configs = append(configs, config)
+		addrs = append(addrs, addr)
+	}
+
+	var writers []*RaftWriter
+	var stores []*mockWriteStore
+	for i := range configs {
+		for j, addr := range addrs {
+			if i != j {
+				configs[i].Peers = append(configs[i].Peers, addr)
+			}
+		}
+		store := newMockWriteStore()
+		writer := NewRaftWriter(configs[i], store)
+		writer.Start()
+		if err := writer.Node().Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		t.Cleanup(func() {
+			writer.Node().Stop()
+			writer.Stop()
+		})
+		writers = append(writers, writer)
+		stores = append(stores, store)
+	}
+
+	var leader, follower *RaftWriter
+	waitFor(t, "a leader", func() bool {
+		for _, w := range writers {
+			if w.Node().IsLeader() {
+				leader = w
+				return true
+			}
+		}
+		return false
+	})
+	for _, w := range writers {
+		if w != leader {
+			follower = w
+		}
+	}
+
+	// Once Propose returns, the write has been applied on the leader
+	if err := leader.Propose("appdb", WALOpPut, "row:t:1", []byte("one")); err != nil {
+		t.Fatalf("Propose failed: %v", err)
+	}
+	for i, w := range writers {
+		if w == leader {
+			if value, _ := stores[i].get("appdb", "row:t:1"); value != "one" {
+				t.Errorf("Leader acknowledged a write it has not applied, got %q", value)
+			}
+		}
+	}
+
+	// A batch is a single entry
+	before := leader.sm.AppliedIndex()
+	err := leader.ProposeBatch("appdb", []RaftWrite{
+		{Op: WALOpDelete, Key: "row:t:1"},
+		{Op: WALOpPut, Key: "row:t:2", Value: []byte("two")},
+	})
+	if err != nil {
+		t.Fatalf("ProposeBatch failed: %v", err)
+	}
+	if applied := leader.sm.AppliedIndex(); applied != before+1 {
+		t.Errorf("Expected the batch to take one entry, took %d", applied-before)
+	}
+
+	for i := range stores {
+		store := stores[i]
+		waitFor(t, "every node to apply the writes", func() bool {
+			value, _ := store.get("appdb", "row:t:2")
+			return value == "two"
+		})
+		if _, ok := store.get("appdb", "row:t:1"); ok {
+			t.Errorf("Node %d did not apply the delete", i+1)
+		}
+	}
+
+	// A transaction is a single entry too, without two-phase commit
+	ucm := NewUnifiedClusterManager(ClusterConfig{NodeID: "node1", NodeAddr: "127.0.0.1", PartitionCount: 16})
+	ucm.SetRaftWriter(leader, "_system")
+	before = leader.sm.AppliedIndex()
+	err = ucm.CommitTransaction("appdb", []TxnWrite{
+		{Op: WALOpPut, Key: "row:t:3", Value: []byte("three")},
+		{Op: WALOpPut, Key: "row:t:4", Value: []byte("four")},
+	})
+	if err != nil {
+		t.Fatalf("CommitTransaction failed: %v", err)
+	}
+	if applied := leader.sm.AppliedIndex(); applied != before+1 {
+		t.Errorf("Expected the transaction to take one entry, took %d", applied-before)
+	}
+	for i, w := range writers {
+		if w == leader {
+			if value, _ := stores[i].get("appdb", "row:t:4"); value != "four" {
+				t.Errorf("Transaction not applied on the leader, got %q", value)
+			}
+		}
+	}
+
+	// Followers do not accept writes
+	err = follower.Propose("appdb", WALOpPut, "row:t:3", []byte("three"))
+	if !errors.Is(err, ErrNotRaftLeader) {
+		t.Errorf("Expected ErrNotRaftLeader from a follower, got %v", err)
+	}
+}
+
+// failingWriteStore fails to apply log entries.
+type failingWriteStore struct {
+	*mockWriteStore
+	err error
+}
+
+func (s *failingWriteStore) ApplyCommittedAt(database string, index uint64, writes []RaftWrite) error {
+	return s.err
+}
+
+func TestRaftWriter_ApplyFailureFencesWriter(t *testing.T) {
+	store := &failingWriteStore{mockWriteStore: newMockWriteStore(), err: errors.New("disk full")}
+	writer := NewRaftWriter(DefaultRaftConfig("node1", "127.0.0.1:0"), store)
+
+	waited := make(chan error, 1)
+	go func() { waited <- writer.waitApplied(1, 5*time.Second) }()
+
+	err := writer.apply(LogEntry{Index: 1, Command: []byte(`{"database":"default","writes":[{"op":1,"key":"k1","value":"djE="}]}`)})
+	if !errors.Is(err, store.err) {
+		t.Fatalf("Expected the apply error, got %v", err)
+	}
+	if applied := writer.sm.AppliedIndex(); applied != 0 {
+		t.Errorf("Expected the failed entry not to count as applied, got index %d", applied)
+	}
+
+	// The proposer waiting on the entry learns that it failed
+	select {
+	case err := <-waited:
+		if !errors.Is(err, store.err) {
+			t.Errorf("Expected the waiting proposer to get the apply error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Waiting proposer was not woken")
+	}
+
+	// New writes are refused and the node is stopped
+	if err := writer.Propose("default", WALOpPut, "k2", []byte("v2")); !errors.Is(err, store.err) {
+		t.Errorf("Expected proposals to fail after the apply error, got %v", err)
+	}
+	select {
+	case <-writer.node.stopCh:
+	case <-time.After(time.Second):
+		t.Error("Expected the Raft node to be stopped")
+	}
+}
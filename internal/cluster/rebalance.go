@@ -0,0 +1,1094 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Partition Split, Merge and Load Balancing:
+==========================================
+
+PartitionCount base partitions are created at startup. A key's base
+partition is hash % PartitionCount, and the remaining bits of the hash,
+hash / PartitionCount, pick among the partitions split off from it:
+
+	base partition 7 (depth 0)
+	├── bit 0 = 1 → partition 256 (depth 1)
+	│               └── bit 1 = 1 → partition 258 (depth 2)
+	└── bit 1 = 1 → partition 257 (depth 2)
+
+Splitting partition p adds a child that takes the keys of p whose next
+unused hash bit is 1. Keys never rehash: each key either stays in p or
+moves to the new child, and the child starts on the nodes of p so no data
+is copied. Merging is the reverse and is only allowed for the last child
+of a partition, when both halves are cold and led by the same node.
+
+The cluster leader runs the balancer every BalancerConfig.Interval:
+
+ 1. Collect the key count and QPS of every partition from its leader
+ 2. Split partitions above SplitKeys or SplitQPS
+ 3. Merge cold split pairs below MergeKeys and MergeQPS
+ 4. Move partitions from the most to the least loaded node while their
+    load differs by more than Imbalance, at most MaxMoves per round
+ 5. Send the new partition map to every node
+
+Node load is the LoadScore of the node's NodeLoad in the metadata store
+when one is set, and the summed QPS of the partitions it leads otherwise.
+PlanRebalance runs steps 1-4 without changing anything; INSPECT CLUSTER
+shows its result.
+*/
+package cluster
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BalancerConfig holds the thresholds of the partition balancer
+type BalancerConfig struct {
+	// Interval is how often the leader plans and applies a rebalance
+	Interval time.Duration `json:"interval"`
+
+	// SplitKeys and SplitQPS split a partition above either threshold
+	SplitKeys int     `json:"split_keys"`
+	SplitQPS  float64 `json:"split_qps"`
+
+	// MergeKeys and MergeQPS merge a split pair below both thresholds
+	MergeKeys int     `json:"merge_keys"`
+	MergeQPS  float64 `json:"merge_qps"`
+
+	// Imbalance is how far, as a fraction of the average node load, the
+	// busiest node may exceed the idlest before partitions move
+	Imbalance float64 `json:"imbalance"`
+
+	// MaxMoves limits the partition migrations per round
+	MaxMoves int `json:"max_moves"`
+
+	// MaxSplitDepth limits how many times a base partition's hash bits
+	// can be split
+	MaxSplitDepth int `json:"max_split_depth"`
+}
+
+// DefaultBalancerConfig returns sensible balancer defaults
+func DefaultBalancerConfig() BalancerConfig {
+	return BalancerConfig{
+		Interval:      30 * time.Second,
+		SplitKeys:     100000,
+		SplitQPS:      2000,
+		MergeKeys:     25000,
+		MergeQPS:      200,
+		Imbalance:     0.25,
+		MaxMoves:      1,
+		MaxSplitDepth: 16,
+	}
+}
+
+// withDefaults fills unset thresholds from DefaultBalancerConfig
+func (c BalancerConfig) withDefaults() BalancerConfig {
+	d := DefaultBalancerConfig()
+	if c.Interval <= 0 {
+		c.Interval = d.Interval
+	}
+	if c.SplitKeys <= 0 {
+		c.SplitKeys = d.SplitKeys
+	}
+	if c.SplitQPS <= 0 {
+		c.SplitQPS = d.SplitQPS
+	}
+	if c.MergeKeys <= 0 {
+		c.MergeKeys = d.MergeKeys
+	}
+	if c.MergeQPS <= 0 {
+		c.MergeQPS = d.MergeQPS
+	}
+	if c.Imbalance <= 0 {
+		c.Imbalance = d.Imbalance
+	}
+	if c.MaxMoves <= 0 {
+		c.MaxMoves = d.MaxMoves
+	}
+	if c.MaxSplitDepth <= 0 {
+		c.MaxSplitDepth = d.MaxSplitDepth
+	}
+	return c
+}
+
+// ============================================================================
+// Split Routing
+// ============================================================================
+
+// partitionSplits records which partitions were split off from which
+type partitionSplits struct {
+	mu       sync.RWMutex
+	children map[int][]int // Children in split order
+	depth    map[int]int   // Hash bits consumed to reach a partition
+}
+
+func newPartitionSplits() *partitionSplits {
+	return &partitionSplits{
+		children: make(map[int][]int),
+		depth:    make(map[int]int),
+	}
+}
+
+// resolve returns the partition a key of base partition id belongs to,
+// given the hash bits left after picking the base partition
+func (s *partitionSplits) resolve(id int, bits uint32) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for {
+		next := -1
+		depth := s.depth[id]
+		for i, child := range s.children[id] {
+			if bits>>uint(depth+i)&1 == 1 {
+				next = child
+				break
+			}
+		}
+		if next < 0 {
+			return id
+		}
+		id = next
+	}
+}
+
+// add records child as the next split of id
+func (s *partitionSplits) add(id, child, maxDepth int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	depth := s.depth[id] + len(s.children[id]) + 1
+	if depth > maxDepth {
+		return fmt.Errorf("partition %d cannot be split further", id)
+	}
+	s.children[id] = append(s.children[id], child)
+	s.depth[child] = depth
+	return nil
+}
+
+// parent returns the partition id was last split off from
+func (s *partitionSplits) parent(id int) (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for p, children := range s.children {
+		if children[len(children)-1] == id {
+			return p, true
+		}
+	}
+	return 0, false
+}
+
+// isSplit reports whether id has children
+func (s *partitionSplits) isSplit(id int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.children[id]) > 0
+}
+
+// remove undoes the last split of parent
+func (s *partitionSplits) remove(parent int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	children := s.children[parent]
+	delete(s.depth, children[len(children)-1])
+	if len(children) == 1 {
+		delete(s.children, parent)
+	} else {
+		s.children[parent] = children[:len(children)-1]
+	}
+}
+
+// snapshot returns a copy of the split tree
+func (s *partitionSplits) snapshot() map[int][]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	children := make(map[int][]int, len(s.children))
+	for id, c := range s.children {
+		children[id] = append([]int(nil), c...)
+	}
+	return children
+}
+
+// restore replaces the split tree with children
+func (s *partitionSplits) restore(children map[int][]int) {
+	isChild := make(map[int]bool)
+	for _, c := range children {
+		for _, id := range c {
+			isChild[id] = true
+		}
+	}
+
+	depth := make(map[int]int)
+	var walk func(id, d int)
+	walk = func(id, d int) {
+		for i, child := range children[id] {
+			depth[child] = d + i + 1
+			walk(child, d+i+1)
+		}
+	}
+	for id := range children {
+		if !isChild[id] {
+			walk(id, 0)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.children = make(map[int][]int, len(children))
+	for id, c := range children {
+		s.children[id] = append([]int(nil), c...)
+	}
+	s.depth = depth
+}
+
+// ============================================================================
+// Partition Load
+// ============================================================================
+
+// partitionLoadTracker counts the operations each partition serves and
+// turns them into a rate once per balancer interval
+type partitionLoadTracker struct {
+	mu    sync.Mutex
+	ops   map[int]uint64
+	qps   map[int]float64
+	since time.Time
+}
+
+func newPartitionLoadTracker() *partitionLoadTracker {
+	return &partitionLoadTracker{
+		ops:   make(map[int]uint64),
+		qps:   make(map[int]float64),
+		since: time.Now(),
+	}
+}
+
+func (t *partitionLoadTracker) record(id int) {
+	t.mu.Lock()
+	t.ops[id]++
+	t.mu.Unlock()
+}
+
+// rotate computes the QPS of the interval that just ended
+func (t *partitionLoadTracker) rotate() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elapsed := time.Since(t.since).Seconds()
+	t.qps = make(map[int]float64, len(t.ops))
+	if elapsed > 0 {
+		for id, n := range t.ops {
+			t.qps[id] = float64(n) / elapsed
+		}
+	}
+	t.ops = make(map[int]uint64)
+	t.since = time.Now()
+}
+
+func (t *partitionLoadTracker) rate(id int) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.qps[id]
+}
+
+// PartitionStats is the size and load of a partition as seen by its leader
+type PartitionStats struct {
+	ID     int     `json:"id"`
+	Leader string  `json:"leader"`
+	Keys   int     `json:"keys"`
+	QPS    float64 `json:"qps"`
+}
+
+// recordPartitionOp counts an operation served for the partition of key
+func (ucm *UnifiedClusterManager) recordPartitionOp(key string) {
+	ucm.partitionLoad.record(ucm.GetPartitionForKey(key))
+}
+
+// localPartitionStats returns the stats of the partitions this node leads
+func (ucm *UnifiedClusterManager) localPartitionStats() []PartitionStats {
+	ucm.partitionsMu.RLock()
+	var led []int
+	for id, p := range ucm.partitions {
+		if p.Leader == ucm.nodeID {
+			led = append(led, id)
+		}
+	}
+	ucm.partitionsMu.RUnlock()
+
+	ucm.partitionKeysMu.RLock()
+	defer ucm.partitionKeysMu.RUnlock()
+	stats := make([]PartitionStats, 0, len(led))
+	for _, id := range led {
+		stats = append(stats, PartitionStats{
+			ID:     id,
+			Leader: ucm.nodeID,
+			Keys:   len(ucm.partitionKeys[id]),
+			QPS:    ucm.partitionLoad.rate(id),
+		})
+	}
+	return stats
+}
+
+// collectPartitionStats gathers the stats of every partition from the
+// alive nodes. Nodes that do not answer are left out of the round.
+func (ucm *UnifiedClusterManager) collectPartitionStats() map[int]PartitionStats {
+	all := make(map[int]PartitionStats)
+	for _, node := range ucm.GetAliveNodes() {
+		var stats []PartitionStats
+		if node.ID == ucm.nodeID {
+			stats = ucm.localPartitionStats()
+		} else {
+			resp, err := ucm.sendTxnMessage(node.ID, forwardMessage{Type: "PARTITION_STATS"})
+			if err != nil || json.Unmarshal(resp.Value, &stats) != nil {
+				continue
+			}
+		}
+		for _, s := range stats {
+			all[s.ID] = s
+		}
+	}
+	return all
+}
+
+// SetMetadataStore makes the balancer weigh nodes by the NodeLoad kept in
+// store instead of by the QPS of their partitions.
+func (ucm *UnifiedClusterManager) SetMetadataStore(store *ClusterMetadataStore) {
+	ucm.balancerMu.Lock()
+	defer ucm.balancerMu.Unlock()
+	ucm.metadata = store
+}
+
+// ============================================================================
+// Split and Merge
+// ============================================================================
+
+// SplitPartition splits off a new partition that takes half of the keys
+// of partition id and returns its ID. The new partition starts on the
+// nodes of id.
+func (ucm *UnifiedClusterManager) SplitPartition(id int) (int, error) {
+	ucm.partitionsMu.Lock()
+	parent, ok := ucm.partitions[id]
+	if !ok {
+		ucm.partitionsMu.Unlock()
+		return 0, fmt.Errorf("partition %d not found", id)
+	}
+	child := 0
+	for pid := range ucm.partitions {
+		if pid >= child {
+			child = pid + 1
+		}
+	}
+	if err := ucm.splits.add(id, child, ucm.balancer.MaxSplitDepth); err != nil {
+		ucm.partitionsMu.Unlock()
+		return 0, err
+	}
+	ucm.partitions[child] = &Partition{
+		ID:           child,
+		Leader:       parent.Leader,
+		Replicas:     append([]string(nil), parent.Replicas...),
+		Version:      1,
+		State:        parent.State,
+		LastModified: time.Now(),
+	}
+	parent.Version++
+	parent.LastModified = time.Now()
+	count := len(ucm.partitions)
+	ucm.partitionsMu.Unlock()
+
+	ucm.reindexPartitionKeys(id)
+	ucm.setTotalPartitions(count)
+	ucm.emitEvent(ClusterEvent{
+		Type:        EventPartitionSplit,
+		NodeID:      ucm.nodeID,
+		PartitionID: id,
+		Details:     fmt.Sprintf("split into %d and %d", id, child),
+	})
+	return child, nil
+}
+
+// MergePartition merges partition id back into the partition it was last
+// split off from. Both must be led by the same node.
+func (ucm *UnifiedClusterManager) MergePartition(id int) error {
+	parentID, ok := ucm.splits.parent(id)
+	if !ok {
+		return fmt.Errorf("partition %d is not the last split of another partition", id)
+	}
+	if ucm.splits.isSplit(id) {
+		return fmt.Errorf("partition %d has been split itself", id)
+	}
+
+	ucm.partitionsMu.Lock()
+	parent, child := ucm.partitions[parentID], ucm.partitions[id]
+	if parent == nil || child == nil {
+		ucm.partitionsMu.Unlock()
+		return fmt.Errorf("partition %d not found", id)
+	}
+	if parent.Leader != child.Leader {
+		ucm.partitionsMu.Unlock()
+		return fmt.Errorf("partitions %d and %d have different leaders", parentID, id)
+	}
+	ucm.splits.remove(parentID)
+	delete(ucm.partitions, id)
+	parent.Version++
+	parent.LastModified = time.Now()
+	count := len(ucm.partitions)
+	ucm.partitionsMu.Unlock()
+
+	ucm.reindexPartitionKeys(id)
+	ucm.setTotalPartitions(count)
+	ucm.emitEvent(ClusterEvent{
+		Type:        EventPartitionMerged,
+		NodeID:      ucm.nodeID,
+		PartitionID: parentID,
+		Details:     fmt.Sprintf("merged %d into %d", id, parentID),
+	})
+	return nil
+}
+
+// reindexPartitionKeys moves the keys indexed under partition id to the
+// partition they route to now
+func (ucm *UnifiedClusterManager) reindexPartitionKeys(id int) {
+	ucm.partitionKeysMu.Lock()
+	keys, versioned := ucm.partitionKeys[id], ucm.versionedKeys[id]
+	delete(ucm.partitionKeys, id)
+	delete(ucm.versionedKeys, id)
+	ucm.partitionKeysMu.Unlock()
+
+	for key := range keys {
+		ucm.addKeyToPartitionIndex(key)
+	}
+	for key := range versioned {
+		ucm.indexVersionedKey(key)
+	}
+}
+
+func (ucm *UnifiedClusterManager) setTotalPartitions(count int) {
+	ucm.metricsMu.Lock()
+	ucm.metrics.TotalPartitions = count
+	ucm.metricsMu.Unlock()
+}
+
+// ============================================================================
+// Planning
+// ============================================================================
+
+// Rebalance action types
+const (
+	RebalanceSplit = "SPLIT"
+	RebalanceMerge = "MERGE"
+	RebalanceMove  = "MOVE"
+)
+
+// RebalanceAction is one step of a rebalance plan
+type RebalanceAction struct {
+	Type        string `json:"type"`
+	PartitionID int    `json:"partition_id"`
+	Into        int    `json:"into,omitempty"` // MERGE target
+	From        string `json:"from,omitempty"` // MOVE source
+	To          string `json:"to,omitempty"`   // MOVE destination
+	Reason      string `json:"reason"`
+}
+
+// RebalancePlan lists the actions the balancer would take next
+type RebalancePlan struct {
+	Actions  []RebalanceAction  `json:"actions"`
+	NodeLoad map[string]float64 `json:"node_load"`
+}
+
+// PlanRebalance returns the actions the next balancer round would take
+// without carrying any of them out
+func (ucm *UnifiedClusterManager) PlanRebalance() RebalancePlan {
+	return ucm.planRebalance(ucm.collectPartitionStats())
+}
+
+// RebalancePlanRows returns the rebalance plan as rows of action,
+// partition, source, destination and reason for INSPECT CLUSTER
+func (ucm *UnifiedClusterManager) RebalancePlanRows() [][]string {
+	plan := ucm.PlanRebalance()
+	rows := make([][]string, 0, len(plan.Actions))
+	for _, a := range plan.Actions {
+		row := []string{a.Type, fmt.Sprint(a.PartitionID), "", "", a.Reason}
+		switch a.Type {
+		case RebalanceMerge:
+			row[3] = fmt.Sprintf("partition %d", a.Into)
+		case RebalanceMove:
+			row[2], row[3] = a.From, a.To
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// planRebalance plans splits, merges and moves from partition stats
+func (ucm *UnifiedClusterManager) planRebalance(stats map[int]PartitionStats) RebalancePlan {
+	cfg := ucm.balancer
+	plan := RebalancePlan{NodeLoad: make(map[string]float64)}
+
+	ids := make([]int, 0, len(stats))
+	for id := range stats {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	touched := make(map[int]bool)
+
+	// Split hot or large partitions
+	for _, id := range ids {
+		s := stats[id]
+		reason := ""
+		if s.Keys > cfg.SplitKeys {
+			reason = fmt.Sprintf("%d keys > %d", s.Keys, cfg.SplitKeys)
+		} else if s.QPS > cfg.SplitQPS {
+			reason = fmt.Sprintf("%.0f qps > %.0f", s.QPS, cfg.SplitQPS)
+		}
+		if reason != "" {
+			plan.Actions = append(plan.Actions, RebalanceAction{Type: RebalanceSplit, PartitionID: id, Reason: reason})
+			touched[id] = true
+		}
+	}
+
+	// Merge cold split pairs back together
+	for _, id := range ids {
+		parentID, ok := ucm.splits.parent(id)
+		if !ok || touched[id] || touched[parentID] || ucm.splits.isSplit(id) {
+			continue
+		}
+		child, parent := stats[id], stats[parentID]
+		if parent.Leader == "" || child.Leader != parent.Leader {
+			continue
+		}
+		keys, qps := child.Keys+parent.Keys, child.QPS+parent.QPS
+		if keys < cfg.MergeKeys && qps < cfg.MergeQPS {
+			plan.Actions = append(plan.Actions, RebalanceAction{
+				Type:        RebalanceMerge,
+				PartitionID: id,
+				Into:        parentID,
+				Reason:      fmt.Sprintf("%d keys, %.0f qps combined", keys, qps),
+			})
+			touched[id], touched[parentID] = true, true
+		}
+	}
+
+	// Weigh nodes and the partitions they lead
+	nodeQPS := make(map[string]float64)
+	for _, node := range ucm.GetAliveNodes() {
+		nodeQPS[node.ID] = 0
+	}
+	for _, s := range stats {
+		if _, ok := nodeQPS[s.Leader]; ok {
+			nodeQPS[s.Leader] += s.QPS
+		}
+	}
+	ucm.balancerMu.RLock()
+	metadata := ucm.metadata
+	ucm.balancerMu.RUnlock()
+	for node, qps := range nodeQPS {
+		plan.NodeLoad[node] = qps
+		if metadata != nil {
+			if meta, ok := metadata.GetNode(node); ok && !meta.Load.UpdatedAt.IsZero() {
+				plan.NodeLoad[node] = meta.Load.LoadScore
+			}
+		}
+	}
+	share := func(s PartitionStats) float64 {
+		if nodeQPS[s.Leader] == 0 {
+			return 0
+		}
+		return s.QPS / nodeQPS[s.Leader] * plan.NodeLoad[s.Leader]
+	}
+
+	// Move partitions from the busiest to the idlest node
+	load := make(map[string]float64, len(plan.NodeLoad))
+	for node, l := range plan.NodeLoad {
+		load[node] = l
+	}
+	for moves := 0; moves < cfg.MaxMoves && len(load) > 1; moves++ {
+		busiest, idlest, total := "", "", 0.0
+		for node, l := range load {
+			total += l
+			if busiest == "" || l > load[busiest] || (l == load[busiest] && node < busiest) {
+				busiest = node
+			}
+			if idlest == "" || l < load[idlest] || (l == load[idlest] && node < idlest) {
+				idlest = node
+			}
+		}
+		gap := load[busiest] - load[idlest]
+		if total == 0 || gap <= cfg.Imbalance*total/float64(len(load)) {
+			break
+		}
+
+		// The partition whose load best halves the gap
+		best, bestDiff := -1, math.Inf(1)
+		for _, id := range ids {
+			s := stats[id]
+			l := share(s)
+			if s.Leader != busiest || touched[id] || l <= 0 || l >= gap {
+				continue
+			}
+			if diff := math.Abs(gap/2 - l); diff < bestDiff {
+				best, bestDiff = id, diff
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		l := share(stats[best])
+		plan.Actions = append(plan.Actions, RebalanceAction{
+			Type:        RebalanceMove,
+			PartitionID: best,
+			From:        busiest,
+			To:          idlest,
+			Reason:      fmt.Sprintf("node load %.2f vs %.2f", load[busiest], load[idlest]),
+		})
+		touched[best] = true
+		load[busiest] -= l
+		load[idlest] += l
+	}
+
+	return plan
+}
+
+// ============================================================================
+// Applying
+// ============================================================================
+
+// balancerLoop measures partition load on every node and, on the leader,
+// runs a balancer round once per interval
+func (ucm *UnifiedClusterManager) balancerLoop() {
+	defer ucm.wg.Done()
+
+	ticker := time.NewTicker(ucm.balancer.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ucm.stopCh:
+			return
+		case <-ticker.C:
+			ucm.partitionLoad.rotate()
+			if !ucm.IsLeader() {
+				continue
+			}
+			if err := ucm.retryPartitionMap(); err != nil {
+				fmt.Printf("Partition map sync failed: %v\n", err)
+			}
+			if ucm.config.EnableAutoRebalance {
+				if err := ucm.runBalancer(); err != nil {
+					fmt.Printf("Partition balancer round failed: %v\n", err)
+				}
+			}
+		}
+	}
+}
+
+// runBalancer plans a rebalance, applies it and sends the new partition
+// map to the other nodes
+func (ucm *UnifiedClusterManager) runBalancer() error {
+	plan := ucm.PlanRebalance()
+	if len(plan.Actions) == 0 {
+		return nil
+	}
+
+	ucm.emitEvent(ClusterEvent{
+		Type:    EventRebalanceStarted,
+		NodeID:  ucm.nodeID,
+		Details: fmt.Sprintf("%d rebalance actions planned", len(plan.Actions)),
+	})
+
+	var firstErr error
+	for _, a := range plan.Actions {
+		var err error
+		switch a.Type {
+		case RebalanceSplit:
+			_, err = ucm.SplitPartition(a.PartitionID)
+		case RebalanceMerge:
+			err = ucm.MergePartition(a.PartitionID)
+		case RebalanceMove:
+			err = ucm.movePartition(a.PartitionID, a.From, a.To)
+		}
+		if err != nil {
+			fmt.Printf("Rebalance %s of partition %d failed: %v\n", a.Type, a.PartitionID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if err := ucm.publishPartitionMap(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	ucm.emitEvent(ClusterEvent{
+		Type:    EventRebalanceComplete,
+		NodeID:  ucm.nodeID,
+		Details: "load rebalancing complete",
+	})
+	return firstErr
+}
+
+// movePartition migrates a partition's data from one node to another and
+// makes the destination its leader
+func (ucm *UnifiedClusterManager) movePartition(id int, from, to string) error {
+	if from == ucm.nodeID {
+		if err := ucm.MigratePartition(id, from, to); err != nil {
+			return err
+		}
+	} else if err := ucm.requestPartitionMigration(from, id, to); err != nil {
+		return err
+	}
+
+	ucm.partitionsMu.Lock()
+	defer ucm.partitionsMu.Unlock()
+	p, ok := ucm.partitions[id]
+	if !ok {
+		return fmt.Errorf("partition %d not found", id)
+	}
+	p.Leader = to
+	replicas := []string{to}
+	for _, r := range p.Replicas {
+		if r != to && r != from {
+			replicas = append(replicas, r)
+		}
+	}
+	p.Replicas = replicas
+	p.State = PartitionHealthy
+	p.Version++
+	p.LastModified = time.Now()
+	return nil
+}
+
+// requestPartitionMigration asks node from to migrate partition id to node
+// to and waits until it has. Migration copies the whole partition, so the
+// request gets the same deadline as the data transfer.
+func (ucm *UnifiedClusterManager) requestPartitionMigration(from string, id int, to string) error {
+	addr := ucm.getNodeDataAddr(from)
+	if addr == "" {
+		return fmt.Errorf("node %s not found", from)
+	}
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Minute))
+
+	msg := forwardMessage{Type: "MIGRATE_PARTITION", PartitionID: id, Target: to}
+	if err := json.NewEncoder(conn).Encode(msg); err != nil {
+		return fmt.Errorf("failed to send migration request: %w", err)
+	}
+	var resp forwardResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return fmt.Errorf("failed to read migration response: %w", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("migration of partition %d on %s failed: %s", id, from, resp.Error)
+	}
+	return nil
+}
+
+// ============================================================================
+// Partition Map
+// ============================================================================
+
+// The partition map is kept as partitionMapKey in the Raft writer's
+// database when writes go through Raft, and in partitionMapFile under
+// DataDir otherwise.
+const (
+	partitionMapKey  = "_sys_partition_map"
+	partitionMapFile = "partition_map.json"
+)
+
+// partitionMapMessage carries the leader's partition map to the other nodes
+type partitionMapMessage struct {
+	Term       uint64        `json:"term"`
+	Version    uint64        `json:"version"`
+	Partitions []*Partition  `json:"partitions"`
+	Splits     map[int][]int `json:"splits,omitempty"`
+}
+
+// encodePartitionMap returns the current partition map with the next
+// version number
+func (ucm *UnifiedClusterManager) encodePartitionMap() ([]byte, error) {
+	ucm.partitionsMu.RLock()
+	defer ucm.partitionsMu.RUnlock()
+	msg := partitionMapMessage{
+		Term:       ucm.GetTerm(),
+		Version:    ucm.mapVersion + 1,
+		Partitions: make([]*Partition, 0, len(ucm.partitions)),
+		Splits:     ucm.splits.snapshot(),
+	}
+	for _, p := range ucm.partitions {
+		msg.Partitions = append(msg.Partitions, p)
+	}
+	sort.Slice(msg.Partitions, func(i, j int) bool { return msg.Partitions[i].ID < msg.Partitions[j].ID })
+	return json.Marshal(msg)
+}
+
+// publishPartitionMap makes the partition map durable and sends it to the
+// other nodes. Under Raft it is committed to the log and every node installs
+// it as it applies the entry. Otherwise it is written to DataDir and sent to
+// each node until the node acknowledges it; retryPartitionMap resends it to
+// the nodes that have not.
+func (ucm *UnifiedClusterManager) publishPartitionMap() error {
+	data, err := ucm.encodePartitionMap()
+	if err != nil {
+		return err
+	}
+
+	if ucm.raftWriter != nil {
+		err := ucm.raftWriter.ProposeBatch(ucm.raftWriterDB, []RaftWrite{{Op: WALOpPut, Key: partitionMapKey, Value: data}})
+		ucm.balancerMu.Lock()
+		ucm.mapStale = err != nil
+		ucm.balancerMu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to commit partition map: %w", err)
+		}
+		return nil
+	}
+
+	var msg partitionMapMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	ucm.partitionsMu.Lock()
+	ucm.mapTerm, ucm.mapVersion = msg.Term, msg.Version
+	ucm.partitionsMu.Unlock()
+	if err := ucm.savePartitionMap(data); err != nil {
+		return err
+	}
+
+	pending := make(map[string]bool)
+	for _, node := range ucm.GetAllNodes() {
+		if node.ID != ucm.nodeID {
+			pending[node.ID] = true
+		}
+	}
+	ucm.balancerMu.Lock()
+	ucm.mapPending = pending
+	ucm.balancerMu.Unlock()
+	return ucm.syncPartitionMap(data)
+}
+
+// retryPartitionMap finishes publishing a partition map that a node has
+// not acknowledged or that Raft did not commit
+func (ucm *UnifiedClusterManager) retryPartitionMap() error {
+	ucm.balancerMu.RLock()
+	stale, pending := ucm.mapStale, len(ucm.mapPending)
+	ucm.balancerMu.RUnlock()
+	if stale {
+		return ucm.publishPartitionMap()
+	}
+	if pending == 0 {
+		return nil
+	}
+	data, err := ucm.loadPartitionMapFile()
+	if err != nil || data == nil {
+		return err
+	}
+	return ucm.syncPartitionMap(data)
+}
+
+// syncPartitionMap sends the partition map to every node that has not
+// acknowledged it yet
+func (ucm *UnifiedClusterManager) syncPartitionMap(data []byte) error {
+	ucm.balancerMu.RLock()
+	nodes := make([]string, 0, len(ucm.mapPending))
+	for id := range ucm.mapPending {
+		nodes = append(nodes, id)
+	}
+	ucm.balancerMu.RUnlock()
+	sort.Strings(nodes)
+
+	var failed []string
+	for _, id := range nodes {
+		if err := ucm.sendPartitionMap(id, data); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", id, err))
+			continue
+		}
+		ucm.balancerMu.Lock()
+		delete(ucm.mapPending, id)
+		ucm.balancerMu.Unlock()
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("partition map not acknowledged by %v", failed)
+	}
+	return nil
+}
+
+// sendPartitionMap sends the partition map to one node and waits for it to
+// acknowledge it
+func (ucm *UnifiedClusterManager) sendPartitionMap(nodeID string, data []byte) error {
+	node := ucm.GetNode(nodeID)
+	if node == nil {
+		return fmt.Errorf("node %s not found", nodeID)
+	}
+	addr := net.JoinHostPort(node.Addr, fmt.Sprint(node.ClusterPort))
+	conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := conn.Write([]byte{msgPartitionSync}); err != nil {
+		return err
+	}
+	if err := binary.Write(conn, binary.BigEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	if _, err := conn.Write(data); err != nil {
+		return err
+	}
+	ack := make([]byte, 1)
+	if _, err := io.ReadFull(conn, ack); err != nil {
+		return err
+	}
+	if ack[0] != 1 {
+		return fmt.Errorf("partition map rejected")
+	}
+	return nil
+}
+
+// applyPartitionMap installs a partition map sent by the leader of the
+// given term and reports whether this node now has it. A map this node
+// already has is acknowledged again without being reinstalled.
+func (ucm *UnifiedClusterManager) applyPartitionMap(msg partitionMapMessage) bool {
+	if msg.Term < ucm.GetTerm() || len(msg.Partitions) == 0 {
+		return false
+	}
+	ucm.partitionsMu.RLock()
+	current := msg.Term == ucm.mapTerm && msg.Version <= ucm.mapVersion
+	ucm.partitionsMu.RUnlock()
+	if current {
+		return true
+	}
+
+	if ucm.raftWriter == nil {
+		data, err := json.Marshal(msg)
+		if err != nil || ucm.savePartitionMap(data) != nil {
+			return false
+		}
+	}
+	ucm.installPartitionMap(msg)
+	return true
+}
+
+// applyRaftPartitionMap is the Raft writer's apply hook. It installs every
+// partition map committed to the log, in log order.
+func (ucm *UnifiedClusterManager) applyRaftPartitionMap(database string, writes []RaftWrite) {
+	if database != ucm.raftWriterDB {
+		return
+	}
+	for _, w := range writes {
+		if w.Op != WALOpPut || w.Key != partitionMapKey {
+			continue
+		}
+		var msg partitionMapMessage
+		if err := json.Unmarshal(w.Value, &msg); err != nil || len(msg.Partitions) == 0 {
+			continue
+		}
+		ucm.installPartitionMap(msg)
+	}
+}
+
+// installPartitionMap replaces the partition map
+func (ucm *UnifiedClusterManager) installPartitionMap(msg partitionMapMessage) {
+	partitions := make(map[int]*Partition, len(msg.Partitions))
+	for _, p := range msg.Partitions {
+		partitions[p.ID] = p
+	}
+	ucm.partitionsMu.Lock()
+	ucm.splits.restore(msg.Splits)
+	ucm.partitions = partitions
+	ucm.mapTerm, ucm.mapVersion = msg.Term, msg.Version
+	ucm.partitionsMu.Unlock()
+	ucm.setTotalPartitions(len(partitions))
+
+	// Every key may route differently now
+	ucm.partitionKeysMu.Lock()
+	old := ucm.partitionKeys
+	ucm.partitionKeys = make(map[int]map[string]struct{})
+	ucm.partitionKeysMu.Unlock()
+	for _, keys := range old {
+		for key := range keys {
+			ucm.addKeyToPartitionIndex(key)
+		}
+	}
+}
+
+// loadPartitionMap installs the partition map this node last persisted,
+// if any
+func (ucm *UnifiedClusterManager) loadPartitionMap() error {
+	var data []byte
+	var err error
+	if ucm.raftWriter != nil {
+		data, err = ucm.raftWriter.Get(ucm.raftWriterDB, partitionMapKey)
+		if err != nil {
+			// Nothing committed yet
+			return nil
+		}
+	} else {
+		data, err = ucm.loadPartitionMapFile()
+		if err != nil {
+			return fmt.Errorf("failed to load partition map: %w", err)
+		}
+	}
+	if data == nil {
+		return nil
+	}
+
+	var msg partitionMapMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("failed to decode partition map: %w", err)
+	}
+	if len(msg.Partitions) > 0 {
+		ucm.installPartitionMap(msg)
+	}
+	return nil
+}
+
+// savePartitionMap writes the partition map to DataDir
+func (ucm *UnifiedClusterManager) savePartitionMap(data []byte) error {
+	if ucm.config.DataDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(ucm.config.DataDir, 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(ucm.config.DataDir, partitionMapFile), data)
+}
+
+// loadPartitionMapFile reads the partition map from DataDir. It returns nil
+// if none has been saved.
+func (ucm *UnifiedClusterManager) loadPartitionMapFile() ([]byte, error) {
+	if ucm.config.DataDir == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(ucm.config.DataDir, partitionMapFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
@@ -0,0 +1,336 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSplitAndMergePartition(t *testing.T) {
+	ucm := NewUnifiedClusterManager(ClusterConfig{NodeID: "node1", PartitionCount: 4})
+	for id, p := range ucm.partitions {
+		p.Leader = "node1"
+		p.Replicas = []string{"node1"}
+		p.State = PartitionHealthy
+		ucm.partitions[id] = p
+	}
+
+	before := make(map[string]int)
+	for i := 0; i < 400; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		ucm.addKeyToPartitionIndex(key)
+		before[key] = ucm.GetPartitionForKey(key)
+	}
+	parentKeys := len(ucm.partitionKeys[1])
+
+	child, err := ucm.SplitPartition(1)
+	if err != nil {
+		t.Fatalf("SplitPartition failed: %v", err)
+	}
+	if child != 4 {
+		t.Errorf("Expected new partition 4, got %d", child)
+	}
+	if p := ucm.GetPartition(child); p == nil || p.Leader != "node1" {
+		t.Fatalf("New partition not led by the parent's leader: %+v", p)
+	}
+	if got := ucm.GetMetrics().TotalPartitions; got != 5 {
+		t.Errorf("Expected 5 partitions, got %d", got)
+	}
+
+	// Only keys of the split partition move, and only to the new one
+	for key, old := range before {
+		now := ucm.GetPartitionForKey(key)
+		if old != 1 && now != old {
+			t.Errorf("Key %s moved from %d to %d", key, old, now)
+		}
+		if old == 1 && now != 1 && now != child {
+			t.Errorf("Key %s of partition 1 moved to %d", key, now)
+		}
+	}
+	moved := len(ucm.partitionKeys[child])
+	if moved == 0 || moved == parentKeys || len(ucm.partitionKeys[1])+moved != parentKeys {
+		t.Errorf("Split %d keys into %d and %d", parentKeys, len(ucm.partitionKeys[1]), moved)
+	}
+
+	// A second split of the parent uses the next hash bit
+	second, err := ucm.SplitPartition(1)
+	if err != nil {
+		t.Fatalf("SplitPartition failed: %v", err)
+	}
+	if err := ucm.MergePartition(child); err == nil {
+		t.Error("Expected an error merging a partition that is not the last split")
+	}
+	if err := ucm.MergePartition(second); err != nil {
+		t.Fatalf("MergePartition failed: %v", err)
+	}
+	if err := ucm.MergePartition(child); err != nil {
+		t.Fatalf("MergePartition failed: %v", err)
+	}
+	if len(ucm.partitionKeys[1]) != parentKeys {
+		t.Errorf("Expected %d keys after merging, got %d", parentKeys, len(ucm.partitionKeys[1]))
+	}
+	for key, old := range before {
+		if now := ucm.GetPartitionForKey(key); now != old {
+			t.Errorf("Key %s routes to %d after merging, want %d", key, now, old)
+		}
+	}
+	if err := ucm.MergePartition(0); err == nil {
+		t.Error("Expected an error merging a base partition")
+	}
+}
+
+func TestSplitDepthLimit(t *testing.T) {
+	ucm := NewUnifiedClusterManager(ClusterConfig{
+		NodeID:         "node1",
+		PartitionCount: 2,
+		Balancer:       BalancerConfig{MaxSplitDepth: 2},
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := ucm.SplitPartition(0); err != nil {
+			t.Fatalf("SplitPartition %d failed: %v", i, err)
+		}
+	}
+	if _, err := ucm.SplitPartition(0); err == nil {
+		t.Error("Expected an error splitting beyond the maximum depth")
+	}
+}
+
+func TestPlanRebalance(t *testing.T) {
+	ucm := NewUnifiedClusterManager(ClusterConfig{
+		NodeID:         "node1",
+		PartitionCount: 8,
+		Balancer: BalancerConfig{
+			SplitKeys: 1000,
+			SplitQPS:  500,
+			MergeKeys: 100,
+			MergeQPS:  10,
+			MaxMoves:  2,
+		},
+	})
+	for _, id := range []string{"node2", "node3"} {
+		ucm.nodes[id] = &ClusterNode{ID: id, State: NodeAlive}
+	}
+	child, err := ucm.SplitPartition(5)
+	if err != nil {
+		t.Fatalf("SplitPartition failed: %v", err)
+	}
+
+	stats := map[int]PartitionStats{
+		0:     {ID: 0, Leader: "node1", Keys: 5000, QPS: 10},
+		1:     {ID: 1, Leader: "node1", Keys: 10, QPS: 300},
+		2:     {ID: 2, Leader: "node1", Keys: 10, QPS: 200},
+		3:     {ID: 3, Leader: "node2", Keys: 10, QPS: 100},
+		4:     {ID: 4, Leader: "node2", Keys: 10, QPS: 50},
+		5:     {ID: 5, Leader: "node3", Keys: 20, QPS: 2},
+		child: {ID: child, Leader: "node3", Keys: 20, QPS: 2},
+	}
+	plan := ucm.planRebalance(stats)
+
+	byType := make(map[string][]RebalanceAction)
+	for _, a := range plan.Actions {
+		byType[a.Type] = append(byType[a.Type], a)
+	}
+	if s := byType[RebalanceSplit]; len(s) != 1 || s[0].PartitionID != 0 {
+		t.Errorf("Expected partition 0 to split, got %+v", s)
+	}
+	if m := byType[RebalanceMerge]; len(m) != 1 || m[0].PartitionID != child || m[0].Into != 5 {
+		t.Errorf("Expected partition %d to merge into 5, got %+v", child, m)
+	}
+	moves := byType[RebalanceMove]
+	if len(moves) == 0 || moves[0].From != "node1" || moves[0].To != "node3" {
+		t.Fatalf("Expected a move from node1 to node3, got %+v", moves)
+	}
+	if moves[0].PartitionID == 0 {
+		t.Error("A partition being split should not also move")
+	}
+	if plan.NodeLoad["node1"] != 510 || plan.NodeLoad["node2"] != 150 {
+		t.Errorf("Unexpected node load: %v", plan.NodeLoad)
+	}
+
+	// Balanced load plans nothing
+	balanced := map[int]PartitionStats{
+		0: {ID: 0, Leader: "node1", QPS: 100},
+		1: {ID: 1, Leader: "node2", QPS: 100},
+		2: {ID: 2, Leader: "node3", QPS: 90},
+	}
+	if plan := ucm.planRebalance(balanced); len(plan.Actions) != 0 {
+		t.Errorf("Expected no actions, got %+v", plan.Actions)
+	}
+
+	// The metadata store's load score takes precedence over QPS
+	store := NewClusterMetadataStore(ucm.config, "")
+	for node, score := range map[string]float64{"node1": 0.1, "node2": 0.9, "node3": 0.1} {
+		store.AddNode(&NodeMetadata{ID: node, Load: NodeLoad{LoadScore: score, UpdatedAt: time.Now()}})
+	}
+	ucm.SetMetadataStore(store)
+	balanced[3] = PartitionStats{ID: 3, Leader: "node2", QPS: 100}
+	plan = ucm.planRebalance(balanced)
+	if len(plan.Actions) == 0 || plan.Actions[0].From != "node2" {
+		t.Errorf("Expected a move off node2, got %+v", plan.Actions)
+	}
+}
+
+func TestPartitionStatsAndMapSync(t *testing.T) {
+	a := newTxnTestNode(t, "node1", "", newMockStore())
+	b := newTxnTestNode(t, "node2", "", newMockStore())
+	linkTxnTestNodes(a, b)
+	for _, m := range []*UnifiedClusterManager{a, b} {
+		for id, p := range m.partitions {
+			p.Leader = []string{"node1", "node2"}[id%2]
+			p.Replicas = []string{p.Leader}
+			p.State = PartitionHealthy
+		}
+	}
+
+	key := "row:t:1"
+	pid := a.GetPartitionForKey(key)
+	if err := a.Put(key, []byte("v")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	for _, m := range []*UnifiedClusterManager{a, b} {
+		m.partitionLoad.rotate()
+	}
+
+	// The leader of the key's partition reports its load
+	stats := a.collectPartitionStats()
+	if len(stats) != 16 {
+		t.Fatalf("Expected stats for 16 partitions, got %d", len(stats))
+	}
+	if s := stats[pid]; s.QPS <= 0 || s.Leader != []string{"node1", "node2"}[pid%2] {
+		t.Errorf("Unexpected stats for partition %d: %+v", pid, s)
+	}
+
+	// A partition map from the current term replaces the follower's
+	child, err := a.SplitPartition(pid)
+	if err != nil {
+		t.Fatalf("SplitPartition failed: %v", err)
+	}
+	if !b.applyPartitionMap(partitionMapMessage{
+		Term:       a.GetTerm(),
+		Version:    1,
+		Partitions: a.GetAllPartitions(),
+		Splits:     a.splits.snapshot(),
+	}) {
+		t.Fatal("Partition map rejected")
+	}
+	if b.GetPartition(child) == nil {
+		t.Fatal("Split partition missing after sync")
+	}
+	for i := 0; i < 100; i++ {
+		k := fmt.Sprintf("key-%d", i)
+		if a.GetPartitionForKey(k) != b.GetPartitionForKey(k) {
+			t.Fatalf("Nodes route %s differently after sync", k)
+		}
+	}
+}
+
+// listenCluster serves ucm's cluster protocol on a local port.
+func listenCluster(t *testing.T, ucm *UnifiedClusterManager) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go ucm.handleClusterConnection(conn)
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestPublishPartitionMap(t *testing.T) {
+	dirA, dirB := t.TempDir(), t.TempDir()
+	a := newTxnTestNode(t, "node1", "", newMockStore())
+	b := newTxnTestNode(t, "node2", "", newMockStore())
+	a.config.DataDir, b.config.DataDir = dirA, dirB
+	linkTxnTestNodes(a, b)
+
+	// node2 is down: the map is saved and kept pending for it
+	a.nodes["node2"].ClusterPort = 1
+	child, err := a.SplitPartition(3)
+	if err != nil {
+		t.Fatalf("SplitPartition failed: %v", err)
+	}
+	if err := a.publishPartitionMap(); err == nil {
+		t.Fatal("Expected an unacknowledged partition map")
+	}
+	if len(a.mapPending) != 1 {
+		t.Fatalf("Expected node2 pending, got %v", a.mapPending)
+	}
+
+	// The retry delivers it once node2 is reachable
+	a.nodes["node2"].ClusterPort = listenCluster(t, b)
+	if err := a.retryPartitionMap(); err != nil {
+		t.Fatalf("retryPartitionMap failed: %v", err)
+	}
+	if len(a.mapPending) != 0 || b.GetPartition(child) == nil {
+		t.Fatal("Partition map not delivered by the retry")
+	}
+
+	// Both nodes reload it after a restart
+	for _, dir := range []string{dirA, dirB} {
+		c := newTxnTestNode(t, "node3", "", newMockStore())
+		c.config.DataDir = dir
+		if err := c.loadPartitionMap(); err != nil {
+			t.Fatalf("loadPartitionMap failed: %v", err)
+		}
+		if c.GetPartition(child) == nil || c.mapVersion != 1 {
+			t.Errorf("Partition map not reloaded from %s", dir)
+		}
+	}
+
+	// A map this node already has is acknowledged without reinstalling
+	if !b.applyPartitionMap(partitionMapMessage{Term: a.GetTerm(), Version: 1, Partitions: a.GetAllPartitions()[:1]}) {
+		t.Error("Expected a repeated map to be acknowledged")
+	}
+	if b.GetPartition(child) == nil {
+		t.Error("Repeated map was reinstalled")
+	}
+}
+
+func TestRaftPartitionMap(t *testing.T) {
+	a := newTxnTestNode(t, "node1", "", newMockStore())
+	b := newTxnTestNode(t, "node2", "", newMockStore())
+	child, err := a.SplitPartition(3)
+	if err != nil {
+		t.Fatalf("SplitPartition failed: %v", err)
+	}
+	data, err := a.encodePartitionMap()
+	if err != nil {
+		t.Fatalf("encodePartitionMap failed: %v", err)
+	}
+
+	// A committed map is installed whatever its term
+	b.raftWriterDB = "_system"
+	b.applyRaftPartitionMap("other", []RaftWrite{{Op: WALOpPut, Key: partitionMapKey, Value: data}})
+	if b.GetPartition(child) != nil {
+		t.Fatal("Map from another database installed")
+	}
+	b.applyRaftPartitionMap("_system", []RaftWrite{{Op: WALOpPut, Key: partitionMapKey, Value: data}})
+	if b.GetPartition(child) == nil {
+		t.Fatal("Committed partition map not installed")
+	}
+}
@@ -39,6 +39,7 @@ func createTestClusterManager(t *testing.T, nodeCount int) *UnifiedClusterManage
 	ucm := &UnifiedClusterManager{
 		nodes:      make(map[string]*ClusterNode),
 		partitions: make(map[int]*Partition),
+		splits:     newPartitionSplits(),
 		config:     config,
 	}
 
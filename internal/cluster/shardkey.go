@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Shard Keys:
+===========
+
+By default a key is placed by hashing the whole key, so the rows of one
+tenant end up scattered over every partition. A key can instead carry a
+hash tag, a substring enclosed in braces, and only the tag is hashed:
+
+	row:orders:{3f2a9c01}17    placed by "3f2a9c01"
+	row:invoices:{3f2a9c01}4   same partition as the order above
+	row:countries:{}2          reference key, stored on every node
+
+Keys with the same tag always share a partition, which is how the SQL
+layer co-locates the rows of tables sharded by the same value. The empty
+tag marks a key of a reference table: it is written to every node and a
+query fragment reads it only on the coordinator.
+*/
+package cluster
+
+import "strings"
+
+// ReferenceTag is the hash tag of keys replicated to every node.
+const ReferenceTag = "{}"
+
+// ShardKey returns the part of a key that is hashed to place it: the
+// first hash tag if the key has one, otherwise the whole key.
+func ShardKey(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+	end := strings.IndexByte(key[start+1:], '}')
+	if end < 0 {
+		return key
+	}
+	return key[start+1 : start+1+end]
+}
+
+// IsReferenceKey reports whether a key belongs to a reference table.
+func IsReferenceKey(key string) bool {
+	start := strings.IndexByte(key, '{')
+	return start >= 0 && strings.HasPrefix(key[start:], ReferenceTag)
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestShardKey(t *testing.T) {
+	tests := []struct {
+		key       string
+		shardKey  string
+		reference bool
+	}{
+		{"row:orders:17", "row:orders:17", false},
+		{"row:orders:{3f2a}17", "3f2a", false},
+		{"row:orders:{3f2a}{9}17", "3f2a", false},
+		{"row:orders:{3f2a", "row:orders:{3f2a", false},
+		{"row:countries:{}2", "", true},
+	}
+	for _, tt := range tests {
+		if got := ShardKey(tt.key); got != tt.shardKey {
+			t.Errorf("ShardKey(%q) = %q, want %q", tt.key, got, tt.shardKey)
+		}
+		if got := IsReferenceKey(tt.key); got != tt.reference {
+			t.Errorf("IsReferenceKey(%q) = %v, want %v", tt.key, got, tt.reference)
+		}
+	}
+
+	// Keys with the same tag share a partition whatever the rest of the key
+	ucm := NewUnifiedClusterManager(ClusterConfig{NodeID: "node1", PartitionCount: 16})
+	want := ucm.GetPartitionForKey("row:orders:{tenant-7}1")
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("row:t%d:{tenant-7}%d", i, i)
+		if got := ucm.GetPartitionForKey(key); got != want {
+			t.Fatalf("%s placed on partition %d, want %d", key, got, want)
+		}
+	}
+}
+
+func TestReferenceKeys(t *testing.T) {
+	storeA, storeB := newMockStore(), newMockStore()
+	a := newTxnTestNode(t, "node1", "", storeA)
+	b := newTxnTestNode(t, "node2", "", storeB)
+	linkTxnTestNodes(a, b)
+	for id, p := range a.partitions {
+		p.Leader = []string{"node1", "node2"}[id%2]
+		p.Replicas = []string{p.Leader}
+	}
+
+	// A reference write reaches every node and is read locally
+	key := "row:countries:{}1"
+	if err := a.Put(key, []byte("NL")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	for name, store := range map[string]*mockStore{"node1": storeA, "node2": storeB} {
+		if value, _ := store.Get(key); string(value) != "NL" {
+			t.Errorf("Reference key missing on %s: %q", name, value)
+		}
+	}
+	if err := b.Delete(key); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if value, _ := storeA.Get(key); value != nil {
+		t.Error("Reference delete did not reach node1")
+	}
+
+	// Every node takes part in a transaction writing a reference key
+	if got := len(a.txnParticipants([]TxnWrite{{Op: WALOpPut, Key: key}})); got != 2 {
+		t.Errorf("Expected 2 participants, got %d", got)
+	}
+
+	// A fragment reads reference keys on the coordinator only
+	keys := []string{key, "row:orders:{a}1", "row:orders:{b}2", "row:orders:{c}3"}
+	executor := func(fragment []byte, owns func(key string) bool) ([]byte, error) {
+		var owned []string
+		for _, k := range keys {
+			if owns(k) {
+				owned = append(owned, k)
+			}
+		}
+		return json.Marshal(owned)
+	}
+	a.SetFragmentExecutor(executor)
+	b.SetFragmentExecutor(executor)
+	results, err := a.ScatterGatherFragment([]byte("q"))
+	if err != nil {
+		t.Fatalf("ScatterGatherFragment failed: %v", err)
+	}
+	seen := make(map[string]int)
+	for _, result := range results {
+		var owned []string
+		json.Unmarshal(result, &owned)
+		for _, k := range owned {
+			seen[k]++
+		}
+	}
+	for _, k := range keys {
+		if seen[k] != 1 {
+			t.Errorf("Key %s read %d times, want once", k, seen[k])
+		}
+	}
+}
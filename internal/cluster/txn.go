@@ -0,0 +1,944 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Distributed Transactions:
+=========================
+
+A DistributedTxn buffers writes until Commit, which makes them atomic
+across partition leaders with two-phase commit over the data port. The
+node that commits is the coordinator; the leader of every partition a
+write touches is a participant. SQL transactions commit the same way
+through CommitTransaction. A database's writes are replicated with the
+database, so the coordinator is their only participant.
+
+With Raft, a transaction is instead proposed as a single log entry, which
+every node applies atomically. No locks are taken and no records are
+written, as Raft already makes the commit durable and all-or-nothing.
+
+	Coordinator                          Participants
+	-----------                          ------------
+	TXN_PREPARE (writes)   ───────>      lock keys, log PREPARE, vote
+	all voted yes: log DECIDE
+	TXN_COMMIT             ───────>      log COMMIT, apply, unlock, log END
+	all acknowledged: log END
+	any vote no: TXN_ABORT ───────>      log ABORT, unlock
+
+Both roles keep their records in a transaction WAL, <data_dir>/txn.wal,
+using the record framing of the Raft log. Every record is fsynced before
+the step that depends on it, so a participant never votes yes without a
+durable PREPARE and the coordinator never sends TXN_COMMIT without a
+durable DECIDE. Without a data directory there is no transaction WAL, and
+two-phase commit is refused.
+
+Locks are taken without waiting: a prepare that finds a key locked by
+another transaction votes no, so transactions never deadlock. Writes
+outside a transaction to a locked key fail until the lock is released.
+
+Recovery uses presumed abort. On restart the WAL is replayed:
+  - a DECIDE without END: the coordinator resends TXN_COMMIT
+  - a COMMIT without END: the participant applies the writes again
+  - a PREPARE alone: the participant keeps the locks and asks the
+    coordinator with TXN_STATUS, which answers committed for a decided
+    transaction, pending for one still preparing, and aborted otherwise,
+    since a coordinator that restarted before DECIDE never committed it
+
+A background loop retries both until they succeed, and the WAL is
+compacted to the unresolved records on every restart.
+*/
+package cluster
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	txnLogFile         = "txn.wal"
+	txnResolveInterval = 2 * time.Second
+)
+
+// Transaction WAL record types
+const (
+	txnRecordPrepare = "prepare" // Participant voted yes
+	txnRecordCommit  = "commit"  // Participant learned the commit decision
+	txnRecordAbort   = "abort"   // Participant learned the abort decision
+	txnRecordDecide  = "decide"  // Coordinator decided to commit
+	txnRecordEnd     = "end"     // Transaction fully resolved
+)
+
+// Answers to TXN_STATUS
+const (
+	txnStatusCommitted = "committed"
+	txnStatusAborted   = "aborted"
+	txnStatusPending   = "pending"
+)
+
+// TxnWrite is one write of a distributed transaction.
+type TxnWrite struct {
+	Op       byte   `json:"op"` // WALOpPut or WALOpDelete
+	Key      string `json:"key"`
+	Value    []byte `json:"value,omitempty"`
+	Database string `json:"database,omitempty"` // Empty for the cluster store
+}
+
+// lockKey returns the key a transaction locks for the write.
+func (w TxnWrite) lockKey() string {
+	if w.Database == "" {
+		return w.Key
+	}
+	return w.Database + "\x00" + w.Key
+}
+
+// errNoTxnLog is returned for two-phase commit on a node without a
+// transaction WAL.
+var errNoTxnLog = fmt.Errorf("distributed transactions require a data directory for the transaction log")
+
+// txnRecord is a record of the transaction WAL.
+type txnRecord struct {
+	Type         string     `json:"type"`
+	TxnID        string     `json:"txn_id"`
+	Coordinator  string     `json:"coordinator,omitempty"`  // PREPARE: node to ask for the outcome
+	Participants []string   `json:"participants,omitempty"` // DECIDE: nodes to send TXN_COMMIT to
+	Writes       []TxnWrite `json:"writes,omitempty"`       // PREPARE: the participant's writes
+}
+
+// ============================================================================
+// Transaction WAL
+// ============================================================================
+
+// txnLog is an append-only file of txnRecords.
+type txnLog struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+}
+
+// openTxnLog opens the transaction WAL in dir, returning its records.
+// A torn record at the end, left by a crash during an append, is dropped.
+func openTxnLog(dir string) (*txnLog, []txnRecord, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create transaction log directory: %w", err)
+	}
+	path := filepath.Join(dir, txnLogFile)
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to read transaction log: %w", err)
+	}
+	var records []txnRecord
+	offset := 0
+	for len(data)-offset >= raftRecordHeaderSize {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		checksum := binary.BigEndian.Uint32(data[offset+4:])
+		end := offset + raftRecordHeaderSize + length
+		if end > len(data) {
+			break
+		}
+		payload := data[offset+raftRecordHeaderSize : end]
+		if crc32.ChecksumIEEE(payload) != checksum {
+			break
+		}
+		var rec txnRecord
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			break
+		}
+		records = append(records, rec)
+		offset = end
+	}
+
+	return &txnLog{path: path}, records, nil
+}
+
+// rewrite durably replaces the log with records and opens it for appends.
+func (l *txnLog) rewrite(records []txnRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var data []byte
+	for _, rec := range records {
+		record, err := encodeTxnRecord(rec)
+		if err != nil {
+			return err
+		}
+		data = append(data, record...)
+	}
+	if err := writeFileAtomic(l.path, data); err != nil {
+		return fmt.Errorf("failed to compact transaction log: %w", err)
+	}
+
+	if l.file != nil {
+		l.file.Close()
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction log: %w", err)
+	}
+	l.file = f
+	return nil
+}
+
+// append durably appends a record.
+func (l *txnLog) append(rec txnRecord) error {
+	record, err := encodeTxnRecord(rec)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(record); err != nil {
+		return fmt.Errorf("failed to append to transaction log: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync transaction log: %w", err)
+	}
+	return nil
+}
+
+func (l *txnLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
+// encodeTxnRecord frames a record like a Raft log entry.
+func encodeTxnRecord(rec txnRecord) ([]byte, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	record := make([]byte, raftRecordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record, uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:], crc32.ChecksumIEEE(payload))
+	copy(record[raftRecordHeaderSize:], payload)
+	return record, nil
+}
+
+// ============================================================================
+// Transaction Manager
+// ============================================================================
+
+// preparedTxn is a transaction this node voted yes for as a participant.
+type preparedTxn struct {
+	record     txnRecord
+	committed  bool      // COMMIT logged, writes not yet applied
+	preparedAt time.Time // Zero for transactions recovered from the log
+}
+
+// decidedTxn is a committed transaction this node coordinates whose
+// participants have not all acknowledged TXN_COMMIT.
+type decidedTxn struct {
+	record  txnRecord
+	pending map[string]bool // Participants yet to acknowledge
+}
+
+// txnManager holds the coordinator and participant state of a node.
+type txnManager struct {
+	ucm *UnifiedClusterManager
+	log *txnLog // nil if DataDir is empty
+
+	seq uint64
+
+	mu       sync.Mutex
+	locks    map[string]string       // Key -> ID of the transaction holding it
+	prepared map[string]*preparedTxn // Participant: voted yes, outcome unknown or unapplied
+	decided  map[string]*decidedTxn  // Coordinator: committed, not acknowledged by all
+	active   map[string]bool         // Coordinator: preparing, not yet decided
+}
+
+func newTxnManager(ucm *UnifiedClusterManager) *txnManager {
+	return &txnManager{
+		ucm:      ucm,
+		locks:    make(map[string]string),
+		prepared: make(map[string]*preparedTxn),
+		decided:  make(map[string]*decidedTxn),
+		active:   make(map[string]bool),
+	}
+}
+
+// recover replays the transaction WAL in dir and compacts it to the
+// records of unresolved transactions.
+func (tm *txnManager) recover(dir string) error {
+	log, records, err := openTxnLog(dir)
+	if err != nil {
+		return err
+	}
+
+	tm.mu.Lock()
+	for _, rec := range records {
+		switch rec.Type {
+		case txnRecordPrepare:
+			tm.prepared[rec.TxnID] = &preparedTxn{record: rec}
+		case txnRecordCommit:
+			if p := tm.prepared[rec.TxnID]; p != nil {
+				p.committed = true
+			}
+		case txnRecordAbort:
+			delete(tm.prepared, rec.TxnID)
+		case txnRecordDecide:
+			d := &decidedTxn{record: rec, pending: make(map[string]bool)}
+			for _, node := range rec.Participants {
+				d.pending[node] = true
+			}
+			tm.decided[rec.TxnID] = d
+		case txnRecordEnd:
+			delete(tm.prepared, rec.TxnID)
+			delete(tm.decided, rec.TxnID)
+		}
+	}
+
+	var live []txnRecord
+	for id, p := range tm.prepared {
+		for _, w := range p.record.Writes {
+			tm.locks[w.lockKey()] = id
+		}
+		live = append(live, p.record)
+		if p.committed {
+			live = append(live, txnRecord{Type: txnRecordCommit, TxnID: id})
+		}
+	}
+	for _, d := range tm.decided {
+		live = append(live, d.record)
+	}
+	inDoubt := len(tm.prepared) + len(tm.decided)
+	tm.mu.Unlock()
+
+	if err := log.rewrite(live); err != nil {
+		return err
+	}
+	tm.log = log
+
+	if inDoubt > 0 {
+		fmt.Printf("Node %s recovered %d unresolved distributed transactions\n", tm.ucm.nodeID, inDoubt)
+	}
+	return nil
+}
+
+// logRecord durably appends a record to the transaction WAL.
+func (tm *txnManager) logRecord(rec txnRecord) error {
+	if tm.log == nil {
+		return errNoTxnLog
+	}
+	return tm.log.append(rec)
+}
+
+// lockOwner returns the ID of the transaction holding key of the cluster
+// store, or "".
+func (tm *txnManager) lockOwner(key string) string {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	return tm.locks[key]
+}
+
+// writeUnlocked runs write, a non-transactional write of key to the
+// cluster store, unless a transaction holds key. The lock table stays
+// locked until write returns, so no transaction can lock key in between.
+func (tm *txnManager) writeUnlocked(key string, write func() error) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if owner := tm.locks[key]; owner != "" {
+		return fmt.Errorf("key %s is locked by transaction %s", key, owner)
+	}
+	return write()
+}
+
+// releaseLocks releases the locks of a transaction. Called with tm.mu held.
+func (tm *txnManager) releaseLocks(id string, writes []TxnWrite) {
+	for _, w := range writes {
+		if tm.locks[w.lockKey()] == id {
+			delete(tm.locks, w.lockKey())
+		}
+	}
+}
+
+// prepare locks the keys of a transaction and durably records the vote.
+func (tm *txnManager) prepare(id, coordinator string, writes []TxnWrite) error {
+	if tm.log == nil {
+		return errNoTxnLog
+	}
+
+	tm.mu.Lock()
+	if _, ok := tm.prepared[id]; ok {
+		tm.mu.Unlock()
+		return nil
+	}
+	for _, w := range writes {
+		if owner, ok := tm.locks[w.lockKey()]; ok && owner != id {
+			tm.mu.Unlock()
+			return fmt.Errorf("key %s is locked by transaction %s", w.Key, owner)
+		}
+	}
+	for _, w := range writes {
+		tm.locks[w.lockKey()] = id
+	}
+	rec := txnRecord{Type: txnRecordPrepare, TxnID: id, Coordinator: coordinator, Writes: writes}
+	tm.prepared[id] = &preparedTxn{record: rec, preparedAt: time.Now()}
+	tm.mu.Unlock()
+
+	if err := tm.logRecord(rec); err != nil {
+		tm.mu.Lock()
+		delete(tm.prepared, id)
+		tm.releaseLocks(id, writes)
+		tm.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// commit applies the writes of a prepared transaction. Committing an
+// unknown transaction succeeds, since it was already committed.
+func (tm *txnManager) commit(id string) error {
+	tm.mu.Lock()
+	p := tm.prepared[id]
+	tm.mu.Unlock()
+	if p == nil {
+		return nil
+	}
+
+	tm.mu.Lock()
+	committed := p.committed
+	tm.mu.Unlock()
+	if !committed {
+		if err := tm.logRecord(txnRecord{Type: txnRecordCommit, TxnID: id}); err != nil {
+			return err
+		}
+		tm.mu.Lock()
+		p.committed = true
+		tm.mu.Unlock()
+	}
+
+	if err := tm.ucm.applyTxnWrites(p.record.Writes); err != nil {
+		return fmt.Errorf("failed to apply transaction %s: %w", id, err)
+	}
+	if err := tm.logRecord(txnRecord{Type: txnRecordEnd, TxnID: id}); err != nil {
+		return err
+	}
+
+	tm.mu.Lock()
+	delete(tm.prepared, id)
+	tm.releaseLocks(id, p.record.Writes)
+	tm.mu.Unlock()
+	return nil
+}
+
+// abort discards a prepared transaction. Aborting an unknown transaction
+// succeeds.
+func (tm *txnManager) abort(id string) error {
+	tm.mu.Lock()
+	p := tm.prepared[id]
+	committed := p != nil && p.committed
+	tm.mu.Unlock()
+	if p == nil {
+		return nil
+	}
+	if committed {
+		return fmt.Errorf("transaction %s is already committed", id)
+	}
+
+	if err := tm.logRecord(txnRecord{Type: txnRecordAbort, TxnID: id}); err != nil {
+		return err
+	}
+
+	tm.mu.Lock()
+	delete(tm.prepared, id)
+	tm.releaseLocks(id, p.record.Writes)
+	tm.mu.Unlock()
+	return nil
+}
+
+// status returns the outcome of a transaction this node coordinates.
+func (tm *txnManager) status(id string) string {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if _, ok := tm.decided[id]; ok {
+		return txnStatusCommitted
+	}
+	if tm.active[id] {
+		return txnStatusPending
+	}
+	return txnStatusAborted
+}
+
+// commitTransaction runs two-phase commit for writes as the coordinator.
+// With Raft, the writes are proposed as one log entry instead.
+func (tm *txnManager) commitTransaction(writes []TxnWrite) error {
+	if len(writes) == 0 {
+		return nil
+	}
+	if tm.ucm.IsRaftEnabled() && tm.ucm.raftWriter != nil {
+		return tm.ucm.applyTxnWrites(writes)
+	}
+	if tm.log == nil {
+		return errNoTxnLog
+	}
+
+	id := fmt.Sprintf("%s-%d-%d", tm.ucm.nodeID, time.Now().UnixNano(), atomic.AddUint64(&tm.seq, 1))
+	participants := tm.ucm.txnParticipants(writes)
+	nodes := make([]string, 0, len(participants))
+	for node := range participants {
+		nodes = append(nodes, node)
+	}
+
+	tm.mu.Lock()
+	tm.active[id] = true
+	tm.mu.Unlock()
+
+	// Phase 1: every participant locks its keys and votes
+	if err := tm.forEachParticipant(nodes, func(node string) error {
+		return tm.ucm.sendTxnPrepare(node, id, participants[node])
+	}); err != nil {
+		tm.mu.Lock()
+		delete(tm.active, id)
+		tm.mu.Unlock()
+		// Participants that miss the abort resolve it through TXN_STATUS
+		tm.forEachParticipant(nodes, func(node string) error {
+			return tm.ucm.sendTxnDecision(node, "TXN_ABORT", id)
+		})
+		return fmt.Errorf("transaction %s aborted: %w", id, err)
+	}
+
+	// The transaction is committed once the decision is durable
+	decide := txnRecord{Type: txnRecordDecide, TxnID: id, Participants: nodes}
+	if err := tm.logRecord(decide); err != nil {
+		tm.mu.Lock()
+		delete(tm.active, id)
+		tm.mu.Unlock()
+		tm.forEachParticipant(nodes, func(node string) error {
+			return tm.ucm.sendTxnDecision(node, "TXN_ABORT", id)
+		})
+		return fmt.Errorf("transaction %s aborted: %w", id, err)
+	}
+	d := &decidedTxn{record: decide, pending: make(map[string]bool)}
+	for _, node := range nodes {
+		d.pending[node] = true
+	}
+	tm.mu.Lock()
+	tm.decided[id] = d
+	delete(tm.active, id)
+	tm.mu.Unlock()
+
+	// Phase 2: participants that cannot be reached now are retried by the
+	// resolver, so the commit stands either way
+	tm.finishDecided(id)
+	return nil
+}
+
+// forEachParticipant runs fn for every participant in parallel and returns
+// the first error.
+func (tm *txnManager) forEachParticipant(nodes []string, fn func(node string) error) error {
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(nodes))
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node string) {
+			defer wg.Done()
+			if err := fn(node); err != nil {
+				errCh <- fmt.Errorf("%s: %w", node, err)
+			}
+		}(node)
+	}
+	wg.Wait()
+	close(errCh)
+	return <-errCh
+}
+
+// finishDecided sends TXN_COMMIT to the participants of a decided
+// transaction that have not acknowledged it, and forgets the transaction
+// once all have.
+func (tm *txnManager) finishDecided(id string) {
+	tm.mu.Lock()
+	d := tm.decided[id]
+	if d == nil {
+		tm.mu.Unlock()
+		return
+	}
+	var nodes []string
+	for node := range d.pending {
+		nodes = append(nodes, node)
+	}
+	tm.mu.Unlock()
+
+	tm.forEachParticipant(nodes, func(node string) error {
+		if err := tm.ucm.sendTxnDecision(node, "TXN_COMMIT", id); err != nil {
+			return err
+		}
+		tm.mu.Lock()
+		delete(d.pending, node)
+		tm.mu.Unlock()
+		return nil
+	})
+
+	tm.mu.Lock()
+	done := len(d.pending) == 0
+	tm.mu.Unlock()
+
+	if done && tm.logRecord(txnRecord{Type: txnRecordEnd, TxnID: id}) == nil {
+		tm.mu.Lock()
+		delete(tm.decided, id)
+		tm.mu.Unlock()
+	}
+}
+
+// resolve retries unfinished commits as coordinator and settles in-doubt
+// transactions as participant.
+func (tm *txnManager) resolve() {
+	tm.mu.Lock()
+	var decided, inDoubt []string
+	for id := range tm.decided {
+		decided = append(decided, id)
+	}
+	for id, p := range tm.prepared {
+		// Leave the coordinator time to deliver its decision
+		if p.committed || time.Since(p.preparedAt) >= txnResolveInterval {
+			inDoubt = append(inDoubt, id)
+		}
+	}
+	tm.mu.Unlock()
+
+	for _, id := range decided {
+		tm.finishDecided(id)
+	}
+
+	for _, id := range inDoubt {
+		tm.mu.Lock()
+		p := tm.prepared[id]
+		committed := p != nil && p.committed
+		tm.mu.Unlock()
+		if p == nil {
+			continue
+		}
+		if committed {
+			tm.commit(id)
+			continue
+		}
+
+		status, err := tm.ucm.queryTxnStatus(p.record.Coordinator, id)
+		if err != nil {
+			continue
+		}
+		switch status {
+		case txnStatusCommitted:
+			tm.commit(id)
+		case txnStatusAborted:
+			tm.abort(id)
+		}
+	}
+}
+
+// ============================================================================
+// Cluster Manager Integration
+// ============================================================================
+
+// DistributedTxn buffers the writes of a distributed transaction until
+// Commit applies them atomically on every partition leader they touch.
+type DistributedTxn struct {
+	ucm    *UnifiedClusterManager
+	writes []TxnWrite
+	index  map[string]int // Key -> position of its latest write
+	done   bool
+}
+
+// BeginTransaction starts a distributed transaction coordinated by this
+// node.
+func (ucm *UnifiedClusterManager) BeginTransaction() *DistributedTxn {
+	return &DistributedTxn{ucm: ucm, index: make(map[string]int)}
+}
+
+func (t *DistributedTxn) buffer(w TxnWrite) error {
+	if t.done {
+		return fmt.Errorf("transaction is no longer active")
+	}
+	if i, ok := t.index[w.Key]; ok {
+		t.writes[i] = w
+		return nil
+	}
+	t.index[w.Key] = len(t.writes)
+	t.writes = append(t.writes, w)
+	return nil
+}
+
+// Put buffers a write of key.
+func (t *DistributedTxn) Put(key string, value []byte) error {
+	return t.buffer(TxnWrite{Op: WALOpPut, Key: key, Value: value})
+}
+
+// Delete buffers a deletion of key.
+func (t *DistributedTxn) Delete(key string) error {
+	return t.buffer(TxnWrite{Op: WALOpDelete, Key: key})
+}
+
+// Get returns the value of key, including the transaction's own writes.
+func (t *DistributedTxn) Get(key string) ([]byte, error) {
+	if i, ok := t.index[key]; ok {
+		if t.writes[i].Op == WALOpDelete {
+			return nil, fmt.Errorf("key not found: %s", key)
+		}
+		return t.writes[i].Value, nil
+	}
+	return t.ucm.Get(key)
+}
+
+// Commit atomically applies the buffered writes with two-phase commit.
+func (t *DistributedTxn) Commit() error {
+	if t.done {
+		return fmt.Errorf("transaction is no longer active")
+	}
+	t.done = true
+	return t.ucm.txns.commitTransaction(t.writes)
+}
+
+// Rollback discards the buffered writes.
+func (t *DistributedTxn) Rollback() error {
+	if t.done {
+		return fmt.Errorf("transaction is no longer active")
+	}
+	t.done = true
+	t.writes = nil
+	return nil
+}
+
+// CommitTransaction atomically commits the writes of a SQL transaction to
+// database, in order.
+func (ucm *UnifiedClusterManager) CommitTransaction(database string, writes []TxnWrite) error {
+	for i := range writes {
+		writes[i].Database = database
+	}
+	return ucm.txns.commitTransaction(writes)
+}
+
+// txnParticipants groups writes by the node that leads their partition.
+// With Raft every write is replicated through the local log, and writes
+// to partitions without a leader are applied locally, as are writes to a
+// database, which replicates them itself. Without Raft, every alive node
+// takes part in writes to reference keys of the cluster store.
+func (ucm *UnifiedClusterManager) txnParticipants(writes []TxnWrite) map[string][]TxnWrite {
+	participants := make(map[string][]TxnWrite)
+	for _, w := range writes {
+		node := ucm.nodeID
+		if w.Database != "" {
+			participants[node] = append(participants[node], w)
+			continue
+		}
+		if !ucm.IsRaftEnabled() && IsReferenceKey(w.Key) {
+			for _, n := range ucm.GetAliveNodes() {
+				participants[n.ID] = append(participants[n.ID], w)
+			}
+			continue
+		}
+		if !ucm.IsRaftEnabled() {
+			if p := ucm.GetPartition(ucm.GetPartitionForKey(w.Key)); p != nil && p.Leader != "" {
+				node = p.Leader
+			}
+		}
+		participants[node] = append(participants[node], w)
+	}
+	return participants
+}
+
+// applyTxnWrites applies committed writes on this participant. With Raft
+// they are proposed as one entry, so they apply on every node or on none.
+// Otherwise they are applied in order; a participant that fails part way
+// applies them all again, which is safe as each write is idempotent.
+func (ucm *UnifiedClusterManager) applyTxnWrites(writes []TxnWrite) error {
+	if ucm.IsRaftEnabled() && ucm.raftWriter != nil {
+		database := writes[0].Database
+		batch := make([]RaftWrite, len(writes))
+		for i, w := range writes {
+			if w.Database != database {
+				return fmt.Errorf("a transaction cannot span databases")
+			}
+			batch[i] = RaftWrite{Op: w.Op, Key: w.Key, Value: w.Value}
+		}
+		if database == "" {
+			database = ucm.raftWriterDB
+		}
+		if err := ucm.raftWriter.ProposeBatch(database, batch); err != nil {
+			return err
+		}
+		for _, w := range writes {
+			ucm.updatePartitionIndex(w)
+		}
+		return nil
+	}
+
+	for _, w := range writes {
+		if err := ucm.applyTxnWrite(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyTxnWrite applies a committed write without Raft.
+func (ucm *UnifiedClusterManager) applyTxnWrite(w TxnWrite) error {
+	store := ucm.store
+	if w.Database != "" {
+		if ucm.databaseStores == nil {
+			return fmt.Errorf("no store for database %s", w.Database)
+		}
+		var err error
+		if store, err = ucm.databaseStores(w.Database); err != nil {
+			return err
+		}
+	}
+	// A database replicates its own writes
+	if w.Database != "" {
+		if w.Op == WALOpDelete {
+			return store.Delete(w.Key)
+		}
+		return store.Put(w.Key, w.Value)
+	}
+	if err := ucm.writeVersioned(w.Op, w.Key, w.Value); err != nil {
+		return err
+	}
+	ucm.updatePartitionIndex(w)
+	partitionID := ucm.GetPartitionForKey(w.Key)
+	if p := ucm.GetPartition(partitionID); p == nil || p.Leader != ucm.nodeID {
+		return nil
+	}
+	var data []byte
+	if w.Op == WALOpPut {
+		data = w.Value
+	}
+	return ucm.ReplicateData(partitionID, w.Key, data, ucm.config.DefaultConsistency)
+}
+
+// updatePartitionIndex records a committed write to the cluster store in
+// the partition key index.
+func (ucm *UnifiedClusterManager) updatePartitionIndex(w TxnWrite) {
+	if w.Database != "" {
+		return
+	}
+	if w.Op == WALOpDelete {
+		ucm.removeKeyFromPartitionIndex(w.Key)
+	} else {
+		ucm.addKeyToPartitionIndex(w.Key)
+	}
+}
+
+// txnResolveLoop periodically resolves unfinished transactions.
+func (ucm *UnifiedClusterManager) txnResolveLoop() {
+	defer ucm.wg.Done()
+
+	ticker := time.NewTicker(txnResolveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ucm.stopCh:
+			return
+		case <-ticker.C:
+			ucm.txns.resolve()
+		}
+	}
+}
+
+// sendTxnPrepare asks a participant to prepare its writes.
+func (ucm *UnifiedClusterManager) sendTxnPrepare(node, id string, writes []TxnWrite) error {
+	if node == ucm.nodeID {
+		return ucm.txns.prepare(id, ucm.nodeID, writes)
+	}
+	_, err := ucm.sendTxnMessage(node, forwardMessage{Type: "TXN_PREPARE", TxnID: id, Coordinator: ucm.nodeID, Writes: writes})
+	return err
+}
+
+// sendTxnDecision sends TXN_COMMIT or TXN_ABORT to a participant.
+func (ucm *UnifiedClusterManager) sendTxnDecision(node, msgType, id string) error {
+	if node == ucm.nodeID {
+		if msgType == "TXN_COMMIT" {
+			return ucm.txns.commit(id)
+		}
+		return ucm.txns.abort(id)
+	}
+	_, err := ucm.sendTxnMessage(node, forwardMessage{Type: msgType, TxnID: id})
+	return err
+}
+
+// queryTxnStatus asks a coordinator for the outcome of a transaction.
+func (ucm *UnifiedClusterManager) queryTxnStatus(coordinator, id string) (string, error) {
+	if coordinator == ucm.nodeID {
+		return ucm.txns.status(id), nil
+	}
+	resp, err := ucm.sendTxnMessage(coordinator, forwardMessage{Type: "TXN_STATUS", TxnID: id})
+	if err != nil {
+		return "", err
+	}
+	return resp.Status, nil
+}
+
+// sendTxnMessage sends a transaction message to another node's data port.
+func (ucm *UnifiedClusterManager) sendTxnMessage(node string, msg forwardMessage) (*forwardResponse, error) {
+	addr := ucm.getNodeDataAddr(node)
+	if addr == "" {
+		return nil, fmt.Errorf("node %s not found", node)
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(ucm.config.SyncTimeout))
+
+	if err := json.NewEncoder(conn).Encode(msg); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var resp forwardResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("remote error: %s", resp.Error)
+	}
+	return &resp, nil
+}
+
+// handleTxnMessage handles a TXN_* message from a coordinator or participant.
+func (ucm *UnifiedClusterManager) handleTxnMessage(msg forwardMessage) forwardResponse {
+	var err error
+	var resp forwardResponse
+	switch msg.Type {
+	case "TXN_PREPARE":
+		err = ucm.txns.prepare(msg.TxnID, msg.Coordinator, msg.Writes)
+	case "TXN_COMMIT":
+		err = ucm.txns.commit(msg.TxnID)
+	case "TXN_ABORT":
+		err = ucm.txns.abort(msg.TxnID)
+	case "TXN_STATUS":
+		resp.Status = ucm.txns.status(msg.TxnID)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Success = true
+	}
+	return resp
+}
@@ -0,0 +1,266 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// Distributed Transaction Tests
+// ============================================================================
+
+// newTxnTestNode returns a cluster manager that serves forwarded requests
+// on a local data port without starting the rest of the cluster machinery.
+// With a dir, its transaction WAL is recovered from there.
+func newTxnTestNode(t *testing.T, nodeID, dir string, store StorageEngine) *UnifiedClusterManager {
+	t.Helper()
+	ucm := NewUnifiedClusterManager(ClusterConfig{
+		NodeID:         nodeID,
+		NodeAddr:       "127.0.0.1",
+		PartitionCount: 16,
+		VirtualNodes:   100,
+		SyncTimeout:    5 * time.Second,
+	})
+	ucm.SetStore(store)
+	if dir != "" {
+		if err := ucm.txns.recover(dir); err != nil {
+			t.Fatalf("recover failed: %v", err)
+		}
+		t.Cleanup(ucm.txns.log.close)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go ucm.handleDataConnection(conn)
+		}
+	}()
+	ucm.nodes[nodeID].DataPort = ln.Addr().(*net.TCPAddr).Port
+	return ucm
+}
+
+// linkTxnTestNodes makes every node known to the others.
+func linkTxnTestNodes(nodes ...*UnifiedClusterManager) {
+	for _, a := range nodes {
+		for _, b := range nodes {
+			if a != b {
+				self := b.nodes[b.nodeID]
+				a.nodes[b.nodeID] = &ClusterNode{ID: self.ID, Addr: self.Addr, DataPort: self.DataPort}
+			}
+		}
+	}
+}
+
+// keyOnNode returns a key whose partition is led by node.
+func keyOnNode(t *testing.T, ucm *UnifiedClusterManager, node, prefix string) string {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("%s:%d", prefix, i)
+		if ucm.GetPartition(ucm.GetPartitionForKey(key)).Leader == node {
+			return key
+		}
+	}
+	t.Fatalf("No key found on %s", node)
+	return ""
+}
+
+func TestDistributedTxn_Commit(t *testing.T) {
+	storeA, storeB := newMockStore(), newMockStore()
+	a := newTxnTestNode(t, "node1", t.TempDir(), storeA)
+	b := newTxnTestNode(t, "node2", t.TempDir(), storeB)
+	linkTxnTestNodes(a, b)
+	for id, p := range a.partitions {
+		p.Leader = []string{"node1", "node2"}[id%2]
+		p.Replicas = []string{p.Leader}
+	}
+
+	// A transfer between accounts on different nodes commits on both
+	from := keyOnNode(t, a, "node1", "row:accounts")
+	to := keyOnNode(t, a, "node2", "row:accounts")
+	txn := a.BeginTransaction()
+	txn.Put(from, []byte("50"))
+	txn.Put(to, []byte("150"))
+	if value, _ := txn.Get(to); string(value) != "150" {
+		t.Errorf("Transaction did not read its own write: %q", value)
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if value, _ := storeA.Get(from); string(value) != "50" {
+		t.Errorf("Expected 50 on node1, got %q", value)
+	}
+	if value, _ := storeB.Get(to); string(value) != "150" {
+		t.Errorf("Expected 150 on node2, got %q", value)
+	}
+	if len(a.txns.decided) != 0 || len(b.txns.prepared) != 0 || len(b.txns.locks) != 0 {
+		t.Error("Committed transaction left state behind")
+	}
+
+	// A participant that cannot lock a key votes no and nothing is applied
+	if err := b.txns.prepare("other", "node1", []TxnWrite{{Op: WALOpPut, Key: to, Value: []byte("0")}}); err != nil {
+		t.Fatalf("prepare failed: %v", err)
+	}
+	if err := b.txns.writeUnlocked(to, func() error { return nil }); err == nil {
+		t.Error("Expected writes to a locked key to fail")
+	}
+	txn = a.BeginTransaction()
+	txn.Delete(from)
+	txn.Put(to, []byte("200"))
+	if err := txn.Commit(); err == nil {
+		t.Fatal("Expected a conflicting transaction to abort")
+	}
+	if value, _ := storeA.Get(from); string(value) != "50" {
+		t.Errorf("Aborted transaction changed node1: %q", value)
+	}
+	if value, _ := storeB.Get(to); string(value) != "150" {
+		t.Errorf("Aborted transaction changed node2: %q", value)
+	}
+	if a.txns.lockOwner(from) != "" {
+		t.Error("Aborted transaction kept its lock on node1")
+	}
+
+	if err := b.txns.abort("other"); err != nil || b.txns.lockOwner(to) != "" {
+		t.Errorf("abort failed: %v", err)
+	}
+}
+
+func TestDistributedTxn_Recovery(t *testing.T) {
+	dirA, dirB := t.TempDir(), t.TempDir()
+	storeA, storeB := newMockStore(), newMockStore()
+
+	// The coordinator crashes after deciding t1; t2 was never decided
+	a := newTxnTestNode(t, "node1", dirA, storeA)
+	if err := a.txns.logRecord(txnRecord{Type: txnRecordDecide, TxnID: "t1", Participants: []string{"node2"}}); err != nil {
+		t.Fatalf("logRecord failed: %v", err)
+	}
+
+	// The participant crashes after voting yes for both
+	b := newTxnTestNode(t, "node2", dirB, storeB)
+	for _, id := range []string{"t1", "t2"} {
+		writes := []TxnWrite{{Op: WALOpPut, Key: "row:accounts:" + id, Value: []byte(id)}}
+		if err := b.txns.prepare(id, "node1", writes); err != nil {
+			t.Fatalf("prepare failed: %v", err)
+		}
+	}
+
+	// After restarting, the participant still holds the locks
+	a = newTxnTestNode(t, "node1", dirA, storeA)
+	b = newTxnTestNode(t, "node2", dirB, storeB)
+	linkTxnTestNodes(a, b)
+	if b.txns.lockOwner("row:accounts:t1") != "t1" || b.txns.lockOwner("row:accounts:t2") != "t2" {
+		t.Fatal("Recovered participant lost its locks")
+	}
+	if len(a.txns.decided) != 1 {
+		t.Fatal("Recovered coordinator lost its decision")
+	}
+
+	// The participant asks the coordinator: t1 committed, t2 presumed aborted
+	b.txns.resolve()
+	if value, _ := storeB.Get("row:accounts:t1"); string(value) != "t1" {
+		t.Errorf("In-doubt committed transaction not applied: %q", value)
+	}
+	if value, _ := storeB.Get("row:accounts:t2"); value != nil {
+		t.Error("In-doubt aborted transaction was applied")
+	}
+	if len(b.txns.prepared) != 0 || len(b.txns.locks) != 0 {
+		t.Error("Resolved transactions left state behind")
+	}
+
+	// The coordinator finishes t1 once the participant acknowledges it
+	a.txns.resolve()
+	if len(a.txns.decided) != 0 {
+		t.Error("Coordinator did not finish the decided transaction")
+	}
+
+	// Both logs are compacted to nothing on the next restart
+	a = newTxnTestNode(t, "node1", dirA, storeA)
+	b = newTxnTestNode(t, "node2", dirB, storeB)
+	if len(a.txns.decided) != 0 || len(b.txns.prepared) != 0 {
+		t.Error("Resolved transactions were recovered again")
+	}
+}
+
+func TestDistributedTxn_Databases(t *testing.T) {
+	store, orders := newMockStore(), newMockStore()
+	a := newTxnTestNode(t, "node1", t.TempDir(), store)
+	a.SetDatabaseStores(func(database string) (StorageEngine, error) {
+		if database != "shop" {
+			return nil, fmt.Errorf("database %s not found", database)
+		}
+		return orders, nil
+	})
+	b := newTxnTestNode(t, "node2", t.TempDir(), newMockStore())
+	linkTxnTestNodes(a, b)
+	for id, p := range a.partitions {
+		p.Leader = []string{"node1", "node2"}[id%2]
+	}
+
+	// A database's writes stay on the coordinator, in order
+	key := keyOnNode(t, a, "node2", "row:orders")
+	writes := []TxnWrite{
+		{Op: WALOpPut, Key: key, Value: []byte("1")},
+		{Op: WALOpDelete, Key: key},
+		{Op: WALOpPut, Key: "row:orders:2", Value: []byte("2")},
+	}
+	if err := a.CommitTransaction("shop", writes); err != nil {
+		t.Fatalf("CommitTransaction failed: %v", err)
+	}
+	if value, _ := orders.Get(key); value != nil {
+		t.Errorf("Expected %s to be deleted, got %q", key, value)
+	}
+	if value, _ := orders.Get("row:orders:2"); string(value) != "2" {
+		t.Errorf("Expected 2 in the database, got %q", value)
+	}
+	if value, _ := store.Get("row:orders:2"); value != nil {
+		t.Error("Database write reached the cluster store")
+	}
+
+	// A database key does not lock the cluster store key of the same name
+	if err := a.txns.prepare("t1", "node1", []TxnWrite{{Op: WALOpPut, Key: "row:orders:2", Database: "shop"}}); err != nil {
+		t.Fatalf("prepare failed: %v", err)
+	}
+	if err := a.txns.writeUnlocked("row:orders:2", func() error { return nil }); err != nil {
+		t.Errorf("Cluster store write blocked by a database lock: %v", err)
+	}
+	a.txns.abort("t1")
+
+	// Two-phase commit is refused without a transaction WAL
+	c := newTxnTestNode(t, "node3", "", newMockStore())
+	if err := c.BeginTransaction().Commit(); err != nil {
+		t.Errorf("Empty commit failed: %v", err)
+	}
+	txn := c.BeginTransaction()
+	txn.Put("row:accounts:1", []byte("1"))
+	if err := txn.Commit(); err == nil {
+		t.Error("Expected a commit without a transaction log to fail")
+	}
+	if err := c.txns.prepare("t2", "node1", writes); err == nil {
+		t.Error("Expected a prepare without a transaction log to fail")
+	}
+}
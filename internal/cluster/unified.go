@@ -198,6 +198,18 @@ type ClusterConfig struct {
 	// EnableAutoRebalance enables automatic partition rebalancing
 	EnableAutoRebalance bool `json:"enable_auto_rebalance"`
 
+	// Balancer holds the thresholds for splitting, merging and moving
+	// partitions by size and load
+	Balancer BalancerConfig `json:"balancer"`
+
+	// RepairInterval is how often partition leaders compare their
+	// replicas' Merkle trees and repair the differences
+	RepairInterval time.Duration `json:"repair_interval"`
+
+	// TombstoneTTL is how long the version record of a delete is kept.
+	// A replica that is down for longer can bring a deleted key back.
+	TombstoneTTL time.Duration `json:"tombstone_ttl"`
+
 	// DataDir is the directory for storing cluster metadata
 	DataDir string `json:"data_dir"`
 
@@ -222,6 +234,9 @@ func DefaultClusterConfig(nodeID, nodeAddr string) ClusterConfig {
 		ElectionTimeout:     DefaultElectionTimeout,
 		SyncTimeout:         DefaultSyncTimeout,
 		EnableAutoRebalance: true,
+		Balancer:            DefaultBalancerConfig(),
+		RepairInterval:      DefaultRepairInterval,
+		TombstoneTTL:        DefaultTombstoneTTL,
 		DataDir:             "./data/cluster",
 	}
 }
@@ -426,7 +441,7 @@ func (h *HashRing) GetNode(key string) *ClusterNode {
 		return nil
 	}
 
-	hash := h.hash(key)
+	hash := h.hash(ShardKey(key))
 
 	// Binary search for the first node with hash >= key hash
 	idx := sort.Search(len(h.ring), func(i int) bool {
@@ -450,7 +465,7 @@ func (h *HashRing) GetNodes(key string, n int) []*ClusterNode {
 		return nil
 	}
 
-	hash := h.hash(key)
+	hash := h.hash(ShardKey(key))
 
 	// Binary search for the first node
 	idx := sort.Search(len(h.ring), func(i int) bool {
@@ -478,9 +493,10 @@ func (h *HashRing) GetNodes(key string, n int) []*ClusterNode {
 	return nodes
 }
 
-// GetPartition returns the partition ID for a key
+// GetPartition returns the partition ID for a key, hashing only its
+// hash tag if it has one
 func (h *HashRing) GetPartition(key string, partitionCount int) int {
-	hash := h.hash(key)
+	hash := h.hash(ShardKey(key))
 	return int(hash) % partitionCount
 }
 
@@ -518,10 +534,14 @@ const (
 	EventLeaderElected      ClusterEventType = "LEADER_ELECTED"
 	EventPartitionMoved     ClusterEventType = "PARTITION_MOVED"
 	EventPartitionMigrating ClusterEventType = "PARTITION_MIGRATING"
+	EventPartitionSplit     ClusterEventType = "PARTITION_SPLIT"
+	EventPartitionMerged    ClusterEventType = "PARTITION_MERGED"
 	EventRebalanceStarted   ClusterEventType = "REBALANCE_STARTED"
 	EventRebalanceComplete  ClusterEventType = "REBALANCE_COMPLETE"
 	EventQuorumLost         ClusterEventType = "QUORUM_LOST"
 	EventQuorumRestored     ClusterEventType = "QUORUM_RESTORED"
+	EventRepairStarted      ClusterEventType = "REPAIR_STARTED"
+	EventRepairComplete     ClusterEventType = "REPAIR_COMPLETE"
 )
 
 // ClusterEvent represents a cluster state change event
@@ -560,6 +580,17 @@ type ClusterMetrics struct {
 
 	// BytesReplicated is the total bytes replicated
 	BytesReplicated int64 `json:"bytes_replicated"`
+
+	// RepairRuns is the number of anti-entropy repairs finished
+	RepairRuns int64 `json:"repair_runs"`
+
+	// RepairRangesStreamed and RepairKeysStreamed count the differing key
+	// ranges and the keys streamed to replicas by repairs
+	RepairRangesStreamed int64 `json:"repair_ranges_streamed"`
+	RepairKeysStreamed   int64 `json:"repair_keys_streamed"`
+
+	// ActiveRepairs is the number of repairs in progress
+	ActiveRepairs int `json:"active_repairs"`
 }
 
 // WALInterface defines the interface for WAL operations.
@@ -642,12 +673,44 @@ type UnifiedClusterManager struct {
 	raftNode *RaftNode
 	useRaft  bool // Enable Raft-based consensus
 
+	// Raft-replicated writes; nil when writes use partition replication
+	raftWriter   *RaftWriter
+	raftWriterDB string // Database that store belongs to
+
+	// Executes query fragments pushed down by ScatterGatherFragment
+	fragmentExecutor FragmentExecutor
+	fragmentMu       sync.RWMutex
+
+	// Distributed transaction coordinator and participant state
+	txns *txnManager
+
 	// Hash ring for consistent hashing
 	ring *HashRing
 
 	// Partition management
 	partitions   map[int]*Partition
 	partitionsMu sync.RWMutex
+	splits       *partitionSplits
+
+	// Load-based balancing
+	balancer      BalancerConfig
+	partitionLoad *partitionLoadTracker
+	metadata      *ClusterMetadataStore
+	balancerMu    sync.RWMutex
+
+	// Partition map published by the balancer
+	mapTerm    uint64          // Term the partition map was published in, guarded by partitionsMu
+	mapVersion uint64          // Version of the partition map, guarded by partitionsMu
+	mapPending map[string]bool // Nodes yet to acknowledge it, guarded by balancerMu
+	mapStale   bool            // Not yet committed to the Raft log, guarded by balancerMu
+
+	// Anti-entropy repair progress per partition
+	repairs   map[int]*RepairStatus
+	repairsMu sync.Mutex
+
+	// Entry versions for anti-entropy repair
+	versionClock uint64     // Last version issued or seen
+	versionMu    sync.Mutex // Serializes versioned writes with repairs and replicated writes
 
 	// Node management
 	nodes   map[string]*ClusterNode
@@ -660,10 +723,12 @@ type UnifiedClusterManager struct {
 	// WAL-based replication
 	wal                WALInterface
 	store              StorageEngine
+	databaseStores     func(database string) (StorageEngine, error) // Stores of the databases transactions write to
 	followers          map[string]*FollowerReplicationState
 	followersMu        sync.RWMutex
 	partitionKeys      map[int]map[string]struct{} // Per-partition key index
-	partitionKeysMu    sync.RWMutex
+	versionedKeys      map[int]map[string]struct{} // Per-partition index of keys with a version record
+	partitionKeysMu    sync.RWMutex                // Guards partitionKeys and versionedKeys
 	migrationSignals   map[int]chan struct{}
 	migrationSignalsMu sync.Mutex
 	replListener       net.Listener
@@ -720,6 +785,13 @@ func NewUnifiedClusterManager(config ClusterConfig) *UnifiedClusterManager {
 	if config.VirtualNodes <= 0 {
 		config.VirtualNodes = DefaultVirtualNodes
 	}
+	config.Balancer = config.Balancer.withDefaults()
+	if config.RepairInterval <= 0 {
+		config.RepairInterval = DefaultRepairInterval
+	}
+	if config.TombstoneTTL <= 0 {
+		config.TombstoneTTL = DefaultTombstoneTTL
+	}
 
 	ucm := &UnifiedClusterManager{
 		config:           config,
@@ -730,10 +802,15 @@ func NewUnifiedClusterManager(config ClusterConfig) *UnifiedClusterManager {
 		useRaft:          true, // Enable Raft by default
 		ring:             NewHashRing(config.VirtualNodes),
 		partitions:       make(map[int]*Partition),
+		splits:           newPartitionSplits(),
+		balancer:         config.Balancer,
+		partitionLoad:    newPartitionLoadTracker(),
+		repairs:          make(map[int]*RepairStatus),
 		nodes:            make(map[string]*ClusterNode),
 		replicationState: make(map[int]*PartitionReplicationState),
 		followers:        make(map[string]*FollowerReplicationState),
 		partitionKeys:    make(map[int]map[string]struct{}),
+		versionedKeys:    make(map[int]map[string]struct{}),
 		migrationSignals: make(map[int]chan struct{}),
 		replPollInterval: 100 * time.Millisecond,
 		metrics:          &ClusterMetrics{TotalPartitions: config.PartitionCount},
@@ -764,6 +841,7 @@ func NewUnifiedClusterManager(config ClusterConfig) *UnifiedClusterManager {
 	}
 	ucm.nodes[config.NodeID] = selfNode
 	ucm.ring.AddNode(selfNode)
+	ucm.txns = newTxnManager(ucm)
 
 	return ucm
 }
@@ -781,12 +859,18 @@ func (ucm *UnifiedClusterManager) SetStore(store StorageEngine) {
 		results, err := store.Scan("")
 		if err == nil {
 			for key := range results {
-				ucm.addKeyToPartitionIndex(key)
+				ucm.indexWrite(WALOpPut, key)
 			}
 		}
 	}
 }
 
+// SetDatabaseStores sets how the store of a database is found, for
+// distributed transactions that write to a database other than store
+func (ucm *UnifiedClusterManager) SetDatabaseStores(fn func(database string) (StorageEngine, error)) {
+	ucm.databaseStores = fn
+}
+
 func (ucm *UnifiedClusterManager) addKeyToPartitionIndex(key string) {
 	partitionID := ucm.GetPartitionForKey(key)
 	ucm.partitionKeysMu.Lock()
@@ -812,6 +896,29 @@ func (ucm *UnifiedClusterManager) SetRaftNode(raft *RaftNode) {
 	ucm.useRaft = raft != nil
 }
 
+// SetRaftWriter replicates writes through the Raft log of w instead of
+// partition replication. w's node becomes the cluster's Raft node, and Put
+// and Delete propose their writes to database, the database store belongs to.
+func (ucm *UnifiedClusterManager) SetRaftWriter(w *RaftWriter, database string) {
+	ucm.raftWriter = w
+	ucm.raftWriterDB = database
+	ucm.SetRaftNode(w.Node())
+	w.SetApplyHook(ucm.applyRaftPartitionMap)
+}
+
+// SetFragmentExecutor sets the executor that evaluates query fragments
+// pushed down to this node by ScatterGatherFragment
+func (ucm *UnifiedClusterManager) SetFragmentExecutor(fn FragmentExecutor) {
+	ucm.fragmentMu.Lock()
+	defer ucm.fragmentMu.Unlock()
+	ucm.fragmentExecutor = fn
+}
+
+// GetRaftWriter returns the Raft writer if configured
+func (ucm *UnifiedClusterManager) GetRaftWriter() *RaftWriter {
+	return ucm.raftWriter
+}
+
 // EnableRaft enables or disables Raft-based consensus
 func (ucm *UnifiedClusterManager) EnableRaft(enable bool) {
 	ucm.useRaft = enable
@@ -827,8 +934,44 @@ func (ucm *UnifiedClusterManager) IsRaftEnabled() bool {
 	return ucm.useRaft && ucm.raftNode != nil
 }
 
+// AddNode adds the server at the Raft address addr to the Raft cluster,
+// as a learner if learner is set and as a voter otherwise.
+func (ucm *UnifiedClusterManager) AddNode(addr string, learner bool) error {
+	if !ucm.IsRaftEnabled() {
+		return fmt.Errorf("cluster membership changes require Raft")
+	}
+	return ucm.raftNode.AddServer(addr, learner)
+}
+
+// RemoveNode removes the server at the Raft address addr from the Raft cluster.
+func (ucm *UnifiedClusterManager) RemoveNode(addr string) error {
+	if !ucm.IsRaftEnabled() {
+		return fmt.Errorf("cluster membership changes require Raft")
+	}
+	return ucm.raftNode.RemoveServer(addr)
+}
+
+// TransferLeadership hands Raft leadership to the voter at addr, or to the
+// most up-to-date voter if addr is empty. It must be called on the leader.
+func (ucm *UnifiedClusterManager) TransferLeadership(addr string) error {
+	if !ucm.IsRaftEnabled() {
+		return fmt.Errorf("leadership transfer requires Raft")
+	}
+	return ucm.raftNode.TransferLeadership(addr)
+}
+
 // Start initializes and starts the cluster manager
 func (ucm *UnifiedClusterManager) Start() error {
+	// Recover distributed transactions before serving requests
+	if ucm.config.DataDir != "" {
+		if err := ucm.txns.recover(ucm.config.DataDir); err != nil {
+			return fmt.Errorf("failed to recover transactions: %w", err)
+		}
+	}
+	if err := ucm.loadPartitionMap(); err != nil {
+		return err
+	}
+
 	// Start cluster communication listener
 	clusterAddr := fmt.Sprintf(":%d", ucm.config.ClusterPort)
 	ln, err := net.Listen("tcp", clusterAddr)
@@ -851,6 +994,9 @@ func (ucm *UnifiedClusterManager) Start() error {
 
 	// Start Raft consensus if enabled
 	if ucm.useRaft && ucm.raftNode != nil {
+		if ucm.raftWriter != nil {
+			ucm.raftWriter.Start()
+		}
 		if err := ucm.raftNode.Start(); err != nil {
 			ucm.clusterListener.Close()
 			ucm.dataListener.Close()
@@ -868,11 +1014,14 @@ func (ucm *UnifiedClusterManager) Start() error {
 	}
 
 	// Start background goroutines
-	ucm.wg.Add(4)
+	ucm.wg.Add(7)
 	go ucm.acceptClusterConnections()
 	go ucm.acceptDataConnections()
 	go ucm.heartbeatLoop()
 	go ucm.eventDispatcher()
+	go ucm.txnResolveLoop()
+	go ucm.balancerLoop()
+	go ucm.repairLoop()
 
 	// Join cluster if seeds are provided
 	if len(ucm.config.Seeds) > 0 {
@@ -893,6 +1042,9 @@ func (ucm *UnifiedClusterManager) Stop() error {
 	if ucm.raftNode != nil {
 		ucm.raftNode.Stop()
 	}
+	if ucm.raftWriter != nil {
+		ucm.raftWriter.Stop()
+	}
 
 	if ucm.clusterListener != nil {
 		ucm.clusterListener.Close()
@@ -902,6 +1054,9 @@ func (ucm *UnifiedClusterManager) Stop() error {
 	}
 
 	ucm.wg.Wait()
+	if ucm.txns.log != nil {
+		ucm.txns.log.close()
+	}
 	return nil
 }
 
@@ -980,14 +1135,21 @@ func (ucm *UnifiedClusterManager) GetNodeID() string {
 
 // GetMetrics returns a copy of the current cluster metrics
 func (ucm *UnifiedClusterManager) GetMetrics() ClusterMetrics {
+	active := ucm.activeRepairs()
+
 	ucm.metricsMu.RLock()
 	defer ucm.metricsMu.RUnlock()
-	return *ucm.metrics
+	metrics := *ucm.metrics
+	metrics.ActiveRepairs = active
+	return metrics
 }
 
-// GetPartitionForKey returns the partition ID for a given key
+// GetPartitionForKey returns the partition ID for a given key, following
+// any splits of its base partition
 func (ucm *UnifiedClusterManager) GetPartitionForKey(key string) int {
-	return ucm.ring.GetPartition(key, ucm.config.PartitionCount)
+	count := ucm.config.PartitionCount
+	hash := ucm.ring.hash(ShardKey(key))
+	return ucm.splits.resolve(int(hash%uint32(count)), hash/uint32(count))
 }
 
 // GetNodesForKey returns the nodes responsible for a key
@@ -1208,6 +1370,11 @@ func (ucm *UnifiedClusterManager) handleForwardedRequest(conn net.Conn, reader *
 
 	var resp forwardResponse
 
+	switch msg.Type {
+	case "GET", "PUT", "DELETE":
+		ucm.recordPartitionOp(msg.Key)
+	}
+
 	switch msg.Type {
 	case "GET":
 		value, err := ucm.store.Get(msg.Key)
@@ -1220,7 +1387,9 @@ func (ucm *UnifiedClusterManager) handleForwardedRequest(conn net.Conn, reader *
 		}
 
 	case "PUT":
-		if err := ucm.store.Put(msg.Key, msg.Value); err != nil {
+		if err := ucm.txns.writeUnlocked(msg.Key, func() error {
+			return ucm.writeVersioned(WALOpPut, msg.Key, msg.Value)
+		}); err != nil {
 			resp.Success = false
 			resp.Error = err.Error()
 		} else {
@@ -1228,7 +1397,9 @@ func (ucm *UnifiedClusterManager) handleForwardedRequest(conn net.Conn, reader *
 		}
 
 	case "DELETE":
-		if err := ucm.store.Delete(msg.Key); err != nil {
+		if err := ucm.txns.writeUnlocked(msg.Key, func() error {
+			return ucm.writeVersioned(WALOpDelete, msg.Key, nil)
+		}); err != nil {
 			resp.Success = false
 			resp.Error = err.Error()
 		} else {
@@ -1252,6 +1423,60 @@ func (ucm *UnifiedClusterManager) handleForwardedRequest(conn net.Conn, reader *
 			return
 		}
 
+	case "TXN_PREPARE", "TXN_COMMIT", "TXN_ABORT", "TXN_STATUS":
+		resp = ucm.handleTxnMessage(msg)
+
+	case "FRAGMENT":
+		result, err := ucm.executeFragmentAt(msg.Value, msg.Partitions, msg.ReadIndex)
+		if err != nil {
+			resp.Success = false
+			resp.Error = err.Error()
+		} else {
+			resp.Success = true
+			resp.Value = result
+		}
+
+	case "PARTITION_STATS":
+		resp.Value, _ = json.Marshal(ucm.localPartitionStats())
+		resp.Success = true
+
+	case "MIGRATE_PARTITION":
+		// Move a partition this node leads to the target node
+		if err := ucm.MigratePartition(msg.PartitionID, ucm.nodeID, msg.Target); err != nil {
+			resp.Success = false
+			resp.Error = err.Error()
+		} else {
+			resp.Success = true
+		}
+
+	case "MERKLE_TREE":
+		if tree, err := ucm.partitionMerkleTree(msg.PartitionID); err != nil {
+			resp.Success = false
+			resp.Error = err.Error()
+		} else {
+			resp.Success = true
+			resp.Value, _ = json.Marshal(tree)
+		}
+
+	case "REPAIR_RANGE":
+		if _, newer, err := ucm.applyRepairRange(msg.PartitionID, msg.Ranges, msg.Entries); err != nil {
+			resp.Success = false
+			resp.Error = err.Error()
+		} else {
+			resp.Success = true
+			resp.Value, _ = json.Marshal(newer)
+		}
+
+	case "REPAIR_PARTITION":
+		status, err := ucm.RepairPartition(msg.PartitionID)
+		if err != nil {
+			resp.Success = false
+			resp.Error = err.Error()
+		} else {
+			resp.Success = true
+			resp.Value, _ = json.Marshal(status)
+		}
+
 	case "MIGRATION_START":
 		// Handle migration start
 		fmt.Printf("Node %s starting migration for partition %d\n", ucm.nodeID, msg.PartitionID)
@@ -1502,9 +1727,27 @@ func (ucm *UnifiedClusterManager) handleJoinRequest(conn net.Conn) {
 	conn.Write(respData)
 }
 
-// handlePartitionSync processes partition synchronization
+// handlePartitionSync applies a partition map sent by the leader
 func (ucm *UnifiedClusterManager) handlePartitionSync(conn net.Conn) {
-	// Partition sync handling
+	var length uint32
+	if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+		return
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(conn, data); err != nil {
+		return
+	}
+
+	var msg partitionMapMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+	ack := byte(0)
+	if ucm.applyPartitionMap(msg) {
+		ack = 1
+	}
+	conn.Write([]byte{ack})
 }
 
 // markNodeHealthy marks a node as healthy
@@ -2230,6 +2473,10 @@ type ClusterStatus struct {
 	HealthyPartitions int          `json:"healthy_partitions"`
 	LeaderPartitions  int          `json:"leader_partitions"`
 	Nodes             []NodeStatus `json:"nodes"`
+
+	// Repairs is the progress of the last anti-entropy repair of each
+	// partition this node leads
+	Repairs []RepairStatus `json:"repairs,omitempty"`
 }
 
 // NodeStatus provides status information for a single node
@@ -2281,6 +2528,7 @@ func (ucm *UnifiedClusterManager) GetStatus() ClusterStatus {
 		HealthyPartitions: ucm.GetHealthyPartitionCount(),
 		LeaderPartitions:  leaderCount,
 		Nodes:             nodeStatuses,
+		Repairs:           ucm.GetRepairStatus(),
 	}
 }
 
@@ -2631,6 +2879,14 @@ func (ucm *UnifiedClusterManager) connectAndSyncWithLeader(leaderAddr string, st
 		// 1. Write to local WAL first (for crash recovery)
 		// 2. Apply to storage WITHOUT re-writing to WAL
 		//
+		// Repairs read and write versioned entries under versionMu
+		ucm.versionMu.Lock()
+		if isEntryVersionKey(key) && op == WALOpPut {
+			if v, ok := decodeEntryVersion(valBuf); ok {
+				ucm.observeVersionLocked(v.Version)
+			}
+		}
+
 		// Check if store supports ReplicatedStorageEngine interface
 		if replStore, ok := ucm.store.(ReplicatedStorageEngine); ok {
 			// Step 1: Write to local WAL for crash recovery
@@ -2645,12 +2901,12 @@ func (ucm *UnifiedClusterManager) connectAndSyncWithLeader(leaderAddr string, st
 				if err := replStore.ApplyReplicatedPut(key, valBuf); err != nil {
 					fmt.Printf("Failed to apply replicated PUT %s: %v\n", key, err)
 				}
-				ucm.addKeyToPartitionIndex(key)
+				ucm.indexWrite(op, key)
 			case WALOpDelete:
 				if err := replStore.ApplyReplicatedDelete(key); err != nil {
 					fmt.Printf("Failed to apply replicated DELETE %s: %v\n", key, err)
 				}
-				ucm.removeKeyFromPartitionIndex(key)
+				ucm.indexWrite(op, key)
 			}
 		} else {
 			// Fallback: use regular Put/Delete (will write to WAL twice)
@@ -2659,14 +2915,15 @@ func (ucm *UnifiedClusterManager) connectAndSyncWithLeader(leaderAddr string, st
 				if err := ucm.store.Put(key, valBuf); err != nil {
 					fmt.Printf("Failed to apply PUT %s: %v\n", key, err)
 				}
-				ucm.addKeyToPartitionIndex(key)
+				ucm.indexWrite(op, key)
 			case WALOpDelete:
 				if err := ucm.store.Delete(key); err != nil {
 					fmt.Printf("Failed to apply DELETE %s: %v\n", key, err)
 				}
-				ucm.removeKeyFromPartitionIndex(key)
+				ucm.indexWrite(op, key)
 			}
 		}
+		ucm.versionMu.Unlock()
 	}
 }
 
@@ -2826,6 +3083,11 @@ func (ucm *UnifiedClusterManager) RouteKey(key string) (*RouteResponse, error) {
 // If this node owns the partition, it reads locally
 // Otherwise, it forwards the request to the owning node
 func (ucm *UnifiedClusterManager) Get(key string) ([]byte, error) {
+	// Every node holds a copy of a reference key
+	if IsReferenceKey(key) {
+		return ucm.store.Get(key)
+	}
+
 	route, err := ucm.RouteKey(key)
 	if err != nil {
 		return nil, fmt.Errorf("routing failed: %w", err)
@@ -2833,6 +3095,7 @@ func (ucm *UnifiedClusterManager) Get(key string) ([]byte, error) {
 
 	if route.IsLocal {
 		// This node owns the partition - read locally
+		ucm.recordPartitionOp(key)
 		return ucm.store.Get(key)
 	}
 
@@ -2843,7 +3106,24 @@ func (ucm *UnifiedClusterManager) Get(key string) ([]byte, error) {
 // Put performs a partition-aware PUT operation
 // If this node owns the partition, it writes locally and replicates
 // Otherwise, it forwards the request to the owning node
+// With a Raft writer, the write is proposed to the Raft log instead
 func (ucm *UnifiedClusterManager) Put(key string, value []byte) error {
+	// Transactions under Raft commit as one log entry and take no locks
+	if ucm.IsRaftEnabled() && ucm.raftWriter != nil {
+		// Acknowledged once a quorum has committed the write
+		if err := ucm.raftWriter.Propose(ucm.raftWriterDB, WALOpPut, key, value); err != nil {
+			return err
+		}
+		ucm.addKeyToPartitionIndex(key)
+		ucm.recordPartitionOp(key)
+		return nil
+	}
+
+	// Reference keys are stored on every node
+	if IsReferenceKey(key) {
+		return ucm.writeReference(WALOpPut, key, value)
+	}
+
 	route, err := ucm.RouteKey(key)
 	if err != nil {
 		return fmt.Errorf("routing failed: %w", err)
@@ -2851,7 +3131,10 @@ func (ucm *UnifiedClusterManager) Put(key string, value []byte) error {
 
 	if route.IsLocal {
 		// This node owns the partition - write locally
-		if err := ucm.store.Put(key, value); err != nil {
+		ucm.recordPartitionOp(key)
+		if err := ucm.txns.writeUnlocked(key, func() error {
+			return ucm.writeVersioned(WALOpPut, key, value)
+		}); err != nil {
 			return err
 		}
 
@@ -2868,6 +3151,19 @@ func (ucm *UnifiedClusterManager) Put(key string, value []byte) error {
 
 // Delete performs a partition-aware DELETE operation
 func (ucm *UnifiedClusterManager) Delete(key string) error {
+	if ucm.IsRaftEnabled() && ucm.raftWriter != nil {
+		if err := ucm.raftWriter.Propose(ucm.raftWriterDB, WALOpDelete, key, nil); err != nil {
+			return err
+		}
+		ucm.removeKeyFromPartitionIndex(key)
+		ucm.recordPartitionOp(key)
+		return nil
+	}
+
+	if IsReferenceKey(key) {
+		return ucm.writeReference(WALOpDelete, key, nil)
+	}
+
 	route, err := ucm.RouteKey(key)
 	if err != nil {
 		return fmt.Errorf("routing failed: %w", err)
@@ -2875,7 +3171,10 @@ func (ucm *UnifiedClusterManager) Delete(key string) error {
 
 	if route.IsLocal {
 		// This node owns the partition - delete locally
-		if err := ucm.store.Delete(key); err != nil {
+		ucm.recordPartitionOp(key)
+		if err := ucm.txns.writeUnlocked(key, func() error {
+			return ucm.writeVersioned(WALOpDelete, key, nil)
+		}); err != nil {
 			return err
 		}
 
@@ -2890,6 +3189,33 @@ func (ucm *UnifiedClusterManager) Delete(key string) error {
 	return ucm.forwardDelete(route.RedirectAddr, key)
 }
 
+// writeReference applies a write to a reference key locally and on every
+// other alive node. Reads of reference keys are then always local.
+func (ucm *UnifiedClusterManager) writeReference(op byte, key string, value []byte) error {
+	err := ucm.txns.writeUnlocked(key, func() error {
+		return ucm.writeVersioned(op, key, value)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, node := range ucm.GetAliveNodes() {
+		if node.ID == ucm.nodeID {
+			continue
+		}
+		addr := fmt.Sprintf("%s:%d", node.Addr, node.DataPort)
+		if op == WALOpDelete {
+			err = ucm.forwardDelete(addr, key)
+		} else {
+			err = ucm.forwardPut(addr, key, value)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write reference key on %s: %w", node.ID, err)
+		}
+	}
+	return nil
+}
+
 // forwardGet forwards a GET request to another node
 func (ucm *UnifiedClusterManager) forwardGet(addr string, key string) ([]byte, error) {
 	conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
@@ -2992,16 +3318,25 @@ func (ucm *UnifiedClusterManager) forwardDelete(addr string, key string) error {
 
 // forwardMessage represents a forwarded operation
 type forwardMessage struct {
-	Type        string `json:"type"` // GET, PUT, DELETE, SCAN, MIGRATION_START, MIGRATION_DATA, MIGRATION_COMPLETE
-	PartitionID int    `json:"partition_id,omitempty"`
-	Key         string `json:"key,omitempty"`
-	Value       []byte `json:"value,omitempty"`
+	Type        string        `json:"type"` // GET, PUT, DELETE, SCAN, FRAGMENT, TXN_*, MIGRATION_*, PARTITION_STATS, MIGRATE_PARTITION, MERKLE_TREE, REPAIR_*
+	PartitionID int           `json:"partition_id,omitempty"`
+	Partitions  []int         `json:"partitions,omitempty"` // Partitions a FRAGMENT is evaluated for
+	Key         string        `json:"key,omitempty"`
+	Value       []byte        `json:"value,omitempty"`
+	TxnID       string        `json:"txn_id,omitempty"`      // Distributed transaction of a TXN_* message
+	Coordinator string        `json:"coordinator,omitempty"` // TXN_PREPARE: node coordinating the transaction
+	Writes      []TxnWrite    `json:"writes,omitempty"`      // TXN_PREPARE: writes to lock and prepare
+	Ranges      []int         `json:"ranges,omitempty"`      // REPAIR_RANGE: Merkle key ranges being repaired
+	ReadIndex   uint64        `json:"read_index,omitempty"`  // FRAGMENT: Raft index to apply before evaluating
+	Target      string        `json:"target,omitempty"`      // MIGRATE_PARTITION: node to move the partition to
+	Entries     []repairEntry `json:"entries,omitempty"`     // REPAIR_RANGE: the leader's versioned entries
 }
 
 // forwardResponse represents a response to a forwarded operation
 type forwardResponse struct {
 	Success bool   `json:"success"`
 	Value   []byte `json:"value,omitempty"`
+	Status  string `json:"status,omitempty"` // TXN_STATUS: committed, aborted or pending
 	Error   string `json:"error,omitempty"`
 }
 
@@ -3301,3 +3636,167 @@ type scanResponse struct {
 	Results map[string][]byte `json:"results,omitempty"`
 	Error   string            `json:"error,omitempty"`
 }
+
+// FragmentExecutor evaluates a query fragment against the local store and
+// returns its partial result. owns reports whether a key belongs to one of
+// the partitions the fragment is evaluated for, so that a row replicated to
+// several nodes is only read by one of them.
+type FragmentExecutor func(fragment []byte, owns func(key string) bool) ([]byte, error)
+
+// ScatterGatherFragment sends a query fragment to the leader of every
+// partition and returns the partial results, one per node. Unlike
+// ScatterGatherScan, the rows never leave their node: each node evaluates
+// the fragment over the partitions it leads and the caller only merges
+// the partial results. The query fails if a partition has no leader, as
+// its rows could not be read. Keys of reference tables exist on every node
+// and are read only by this node, the coordinator.
+//
+// With Raft-replicated writes, every node first waits until it has applied
+// the log up to this node's applied index. The coordinator has passed the
+// session's read barrier, so each fragment reads state at least as fresh
+// as the coordinator's, including the session's own writes.
+func (ucm *UnifiedClusterManager) ScatterGatherFragment(fragment []byte) ([][]byte, error) {
+	// Group partitions by node
+	nodePartitions := make(map[string][]int)
+	var leaderless []int
+	ucm.partitionsMu.RLock()
+	for _, p := range ucm.partitions {
+		if p.Leader == "" {
+			leaderless = append(leaderless, p.ID)
+			continue
+		}
+		nodePartitions[p.Leader] = append(nodePartitions[p.Leader], p.ID)
+	}
+	ucm.partitionsMu.RUnlock()
+	if len(leaderless) > 0 {
+		sort.Ints(leaderless)
+		return nil, fmt.Errorf("partitions %v have no leader", leaderless)
+	}
+	if _, ok := nodePartitions[ucm.nodeID]; !ok {
+		nodePartitions[ucm.nodeID] = nil
+	}
+
+	var readIndex uint64
+	if ucm.raftWriter != nil {
+		readIndex = ucm.raftWriter.sm.AppliedIndex()
+	}
+
+	// Query each node in parallel
+	var wg sync.WaitGroup
+	var resultsMu sync.Mutex
+	results := make([][]byte, 0, len(nodePartitions))
+	errCh := make(chan error, len(nodePartitions))
+
+	for nodeID, partitionIDs := range nodePartitions {
+		wg.Add(1)
+		go func(nid string, pids []int) {
+			defer wg.Done()
+
+			var result []byte
+			var err error
+			if nid == ucm.nodeID {
+				result, err = ucm.executeFragmentLocal(fragment, pids, true)
+			} else {
+				result, err = ucm.executeFragmentRemote(nid, fragment, pids, readIndex)
+			}
+			if err != nil {
+				errCh <- fmt.Errorf("fragment on %s failed: %w", nid, err)
+				return
+			}
+
+			resultsMu.Lock()
+			results = append(results, result)
+			resultsMu.Unlock()
+		}(nodeID, partitionIDs)
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	// Check for errors
+	if len(errCh) > 0 {
+		return nil, <-errCh
+	}
+
+	return results, nil
+}
+
+// executeFragmentLocal evaluates a query fragment over the rows of the
+// given partitions held by this node, and over the reference keys if this
+// node coordinates the query
+func (ucm *UnifiedClusterManager) executeFragmentLocal(fragment []byte, partitionIDs []int, coordinator bool) ([]byte, error) {
+	ucm.fragmentMu.RLock()
+	execute := ucm.fragmentExecutor
+	ucm.fragmentMu.RUnlock()
+	if execute == nil {
+		return nil, fmt.Errorf("fragment executor not configured")
+	}
+
+	owned := make(map[int]bool, len(partitionIDs))
+	for _, id := range partitionIDs {
+		owned[id] = true
+	}
+	return execute(fragment, func(key string) bool {
+		if IsReferenceKey(key) {
+			return coordinator
+		}
+		return owned[ucm.GetPartitionForKey(key)]
+	})
+}
+
+// executeFragmentAt evaluates a query fragment forwarded by a coordinator
+// once this node has applied the Raft log up to readIndex, so that it does
+// not read older state than the coordinator did
+func (ucm *UnifiedClusterManager) executeFragmentAt(fragment []byte, partitionIDs []int, readIndex uint64) ([]byte, error) {
+	if readIndex > 0 {
+		if ucm.raftWriter == nil {
+			return nil, fmt.Errorf("fragment requires Raft index %d but this node does not replicate writes through Raft", readIndex)
+		}
+		if err := ucm.raftWriter.waitApplied(readIndex, ucm.raftWriter.timeout); err != nil {
+			return nil, err
+		}
+	}
+	return ucm.executeFragmentLocal(fragment, partitionIDs, false)
+}
+
+// executeFragmentRemote evaluates a query fragment on another node once it
+// has applied the Raft log up to readIndex
+func (ucm *UnifiedClusterManager) executeFragmentRemote(nodeID string, fragment []byte, partitionIDs []int, readIndex uint64) ([]byte, error) {
+	node := ucm.GetNode(nodeID)
+	if node == nil {
+		return nil, fmt.Errorf("node %s not found", nodeID)
+	}
+
+	addr := net.JoinHostPort(node.Addr, fmt.Sprint(node.DataPort))
+	conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(ucm.config.SyncTimeout))
+
+	// Send fragment request
+	msg := forwardMessage{
+		Type:       "FRAGMENT",
+		Partitions: partitionIDs,
+		Value:      fragment,
+		ReadIndex:  readIndex,
+	}
+
+	if err := json.NewEncoder(conn).Encode(msg); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	// Read response
+	var resp forwardResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("remote error: %s", resp.Error)
+	}
+
+	return resp.Value, nil
+}
@@ -17,6 +17,11 @@
 package cluster
 
 import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -370,6 +375,7 @@ func (m *mockWAL) Close() error {
 
 // mockStore implements StorageEngine for testing
 type mockStore struct {
+	mu   sync.RWMutex
 	data map[string][]byte
 }
 
@@ -380,16 +386,22 @@ func newMockStore() *mockStore {
 }
 
 func (m *mockStore) Put(key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.data[key] = value
 	return nil
 }
 
 func (m *mockStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	delete(m.data, key)
 	return nil
 }
 
 func (m *mockStore) Get(key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	if v, ok := m.data[key]; ok {
 		return v, nil
 	}
@@ -397,6 +409,8 @@ func (m *mockStore) Get(key string) ([]byte, error) {
 }
 
 func (m *mockStore) Scan(prefix string) (map[string][]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	results := make(map[string][]byte)
 	for k, v := range m.data {
 		if prefix == "" || (len(k) >= len(prefix) && k[:len(prefix)] == prefix) {
@@ -683,3 +697,112 @@ func TestFollowerReplicationState(t *testing.T) {
 		t.Error("Expected IsHealthy to be true")
 	}
 }
+
+// TestScatterGatherFragment tests that every partition's keys are evaluated
+// by exactly one node, locally or through a forwarded FRAGMENT request
+func TestScatterGatherFragment(t *testing.T) {
+	newManager := func(nodeID string) *UnifiedClusterManager {
+		return NewUnifiedClusterManager(ClusterConfig{
+			NodeID:         nodeID,
+			NodeAddr:       "127.0.0.1",
+			PartitionCount: 16,
+			VirtualNodes:   100,
+			SyncTimeout:    5 * time.Second,
+		})
+	}
+	local := newManager("node1")
+	remote := newManager("node2")
+
+	if _, err := local.ScatterGatherFragment([]byte("q")); err == nil {
+		t.Error("Expected an error without a fragment executor")
+	}
+
+	// Each node reports the keys it owns out of a shared key set
+	var keys []string
+	for i := 0; i < 50; i++ {
+		keys = append(keys, fmt.Sprintf("row:t:%d", i))
+	}
+	executor := func(fragment []byte, owns func(key string) bool) ([]byte, error) {
+		if string(fragment) != "q" {
+			return nil, fmt.Errorf("unexpected fragment %q", fragment)
+		}
+		var owned []string
+		for _, key := range keys {
+			if owns(key) {
+				owned = append(owned, key)
+			}
+		}
+		return json.Marshal(owned)
+	}
+	local.SetFragmentExecutor(executor)
+	remote.SetFragmentExecutor(executor)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go remote.handleDataConnection(conn)
+		}
+	}()
+
+	local.nodes["node2"] = &ClusterNode{ID: "node2", Addr: "127.0.0.1", DataPort: ln.Addr().(*net.TCPAddr).Port}
+	for id, p := range local.partitions {
+		if id%2 == 0 {
+			p.Leader = "node2"
+		} else {
+			p.Leader = "node1"
+		}
+	}
+
+	results, err := local.ScatterGatherFragment([]byte("q"))
+	if err != nil {
+		t.Fatalf("ScatterGatherFragment failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 partial results, got %d", len(results))
+	}
+
+	seen := make(map[string]int)
+	for _, result := range results {
+		var owned []string
+		if err := json.Unmarshal(result, &owned); err != nil {
+			t.Fatalf("Invalid partial result: %v", err)
+		}
+		for _, key := range owned {
+			seen[key]++
+		}
+	}
+	for _, key := range keys {
+		if seen[key] != 1 {
+			t.Errorf("Key %s evaluated %d times", key, seen[key])
+		}
+	}
+
+	// A leaderless partition fails the query rather than dropping its rows
+	local.partitions[3].Leader = ""
+	if _, err := local.ScatterGatherFragment([]byte("q")); err == nil || !strings.Contains(err.Error(), "no leader") {
+		t.Errorf("Expected a leaderless partition error, got %v", err)
+	}
+	local.partitions[3].Leader = "node1"
+
+	// A node behind the coordinator's Raft index does not evaluate the fragment
+	if _, err := remote.executeFragmentAt([]byte("q"), []int{0}, 1); err == nil {
+		t.Error("Expected an error for a read index on a node without Raft writes")
+	}
+	writer := NewRaftWriter(DefaultRaftConfig("node2", "127.0.0.1:0"), newMockWriteStore())
+	writer.SetTimeout(50 * time.Millisecond)
+	remote.raftWriter = writer
+	if _, err := remote.executeFragmentAt([]byte("q"), []int{0}, 1); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected a lagging node to time out, got %v", err)
+	}
+	if _, err := remote.executeFragmentAt([]byte("q"), []int{0}, 0); err != nil {
+		t.Errorf("Fragment without a read index failed: %v", err)
+	}
+}
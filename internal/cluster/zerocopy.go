@@ -197,13 +197,21 @@ func (zcm *ZeroCopyManager) SendFile(conn net.Conn, filePath string) (int64, err
 		return 0, fmt.Errorf("failed to stat file: %w", err)
 	}
 	
-	fileSize := fileInfo.Size()
-	
+	return zcm.SendFileRange(conn, file, 0, fileInfo.Size())
+}
+
+// SendFileRange transfers size bytes of an open file, starting at offset,
+// to a network connection using zero-copy. The file's own read position is
+// not used or changed, so one file can serve several ranges concurrently.
+func (zcm *ZeroCopyManager) SendFileRange(conn net.Conn, file *os.File, offset, size int64) (int64, error) {
 	// Try zero-copy sendfile
-	bytesSent, err := zcm.sendFileZeroCopy(conn, file, fileSize)
+	bytesSent, err := zcm.sendFileZeroCopy(conn, file, offset, size)
 	if err != nil {
+		if bytesSent > 0 {
+			return bytesSent, err
+		}
 		// Fallback to regular copy
-		return zcm.sendFileRegular(conn, file, fileSize)
+		return zcm.sendFileRegular(conn, file, offset, size)
 	}
 	
 	zcm.mu.Lock()
@@ -215,7 +223,7 @@ func (zcm *ZeroCopyManager) SendFile(conn net.Conn, filePath string) (int64, err
 }
 
 // sendFileZeroCopy uses platform-specific zero-copy mechanisms
-func (zcm *ZeroCopyManager) sendFileZeroCopy(conn net.Conn, file *os.File, size int64) (int64, error) {
+func (zcm *ZeroCopyManager) sendFileZeroCopy(conn net.Conn, file *os.File, offset, size int64) (int64, error) {
 	// Get the underlying file descriptor
 	tcpConn, ok := conn.(*net.TCPConn)
 	if !ok {
@@ -233,7 +241,7 @@ func (zcm *ZeroCopyManager) sendFileZeroCopy(conn net.Conn, file *os.File, size
 	
 	// Use sendfile syscall
 	err = rawConn.Control(func(fd uintptr) {
-		bytesSent, sendErr = zcm.sendfileImpl(int(fd), int(file.Fd()), size)
+		bytesSent, sendErr = zcm.sendfileImpl(int(fd), int(file.Fd()), offset, size)
 	})
 	
 	if err != nil {
@@ -244,17 +252,20 @@ func (zcm *ZeroCopyManager) sendFileZeroCopy(conn net.Conn, file *os.File, size
 }
 
 // sendFileRegular uses regular io.Copy as fallback
-func (zcm *ZeroCopyManager) sendFileRegular(conn net.Conn, file *os.File, size int64) (int64, error) {
+func (zcm *ZeroCopyManager) sendFileRegular(conn net.Conn, file *os.File, offset, size int64) (int64, error) {
 	// Use buffered copy with pooled buffer
 	bufSize := 1024 * 1024 // 1MB buffer
 	if size < int64(bufSize) {
 		bufSize = int(size)
 	}
+	if bufSize == 0 {
+		return 0, nil
+	}
 
 	buf := zcm.bufferPool.Get(bufSize)
 	defer zcm.bufferPool.Put(buf)
 
-	bytesSent, err := io.CopyBuffer(conn, file, buf)
+	bytesSent, err := io.CopyBuffer(conn, io.NewSectionReader(file, offset, size), buf)
 	if err != nil {
 		return 0, fmt.Errorf("failed to copy file: %w", err)
 	}
@@ -27,8 +27,7 @@ import (
 
 // sendfileImpl implements zero-copy file transfer using macOS sendfile()
 // Note: macOS sendfile has different signature than Linux
-func (zcm *ZeroCopyManager) sendfileImpl(sockfd int, filefd int, size int64) (int64, error) {
-	var offset int64 = 0
+func (zcm *ZeroCopyManager) sendfileImpl(sockfd int, filefd int, offset int64, size int64) (int64, error) {
 	var sent int64 = 0
 	
 	for sent < size {
@@ -24,7 +24,7 @@ import (
 )
 
 // sendfileImpl fallback for platforms without sendfile support
-func (zcm *ZeroCopyManager) sendfileImpl(sockfd int, filefd int, size int64) (int64, error) {
+func (zcm *ZeroCopyManager) sendfileImpl(sockfd int, filefd int, offset int64, size int64) (int64, error) {
 	return 0, fmt.Errorf("sendfile not supported on this platform")
 }
 
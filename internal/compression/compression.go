@@ -120,6 +120,7 @@ type Config struct {
 	BatchSize        int       `json:"batch_size"`        // Number of entries per batch
 	BatchTimeout     int       `json:"batch_timeout_ms"`  // Max wait time for batch (ms)
 	DictionaryEnable bool      `json:"dictionary_enable"` // Use dictionary compression
+	MaxDecodedSize   int       `json:"max_decoded_size"`  // Largest decompressed size accepted, 0 for no limit
 }
 
 // DefaultConfig returns sensible defaults
@@ -140,6 +141,7 @@ var (
 	ErrInvalidHeader    = errors.New("invalid compression header")
 	ErrUnsupportedAlgo  = errors.New("unsupported compression algorithm")
 	ErrDecompressFailed = errors.New("decompression failed")
+	ErrDecodedTooLarge  = errors.New("decompressed data exceeds the size limit")
 )
 
 // Compressor provides compression/decompression operations
@@ -155,7 +157,11 @@ type Compressor struct {
 // NewCompressor creates a new compressor
 func NewCompressor(config Config) *Compressor {
 	zstdEnc, _ := zstd.NewWriter(nil)
-	zstdDec, _ := zstd.NewReader(nil)
+	var zstdOpts []zstd.DOption
+	if config.MaxDecodedSize > 0 {
+		zstdOpts = append(zstdOpts, zstd.WithDecoderMaxMemory(uint64(config.MaxDecodedSize)))
+	}
+	zstdDec, _ := zstd.NewReader(nil, zstdOpts...)
 
 	return &Compressor{
 		config: config,
@@ -201,7 +207,9 @@ func (c *Compressor) Compress(data []byte) ([]byte, error) {
 	}
 }
 
-// Decompress decompresses data
+// Decompress decompresses data. With MaxDecodedSize set, it stops with
+// ErrDecodedTooLarge as soon as the output would exceed it, so a small
+// payload cannot expand into an unbounded allocation.
 func (c *Compressor) Decompress(data []byte, algorithm Algorithm) ([]byte, error) {
 	switch algorithm {
 	case AlgorithmNone:
@@ -249,7 +257,24 @@ func (c *Compressor) decompressGzip(data []byte) ([]byte, error) {
 	}
 	defer r.Close()
 
-	return io.ReadAll(r)
+	return c.readLimited(r)
+}
+
+// readLimited reads a decompressing reader to the end, stopping once the
+// output exceeds MaxDecodedSize.
+func (c *Compressor) readLimited(r io.Reader) ([]byte, error) {
+	limit := c.config.MaxDecodedSize
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > limit {
+		return nil, ErrDecodedTooLarge
+	}
+	return data, nil
 }
 
 // compressLZ4 compresses using LZ4
@@ -279,7 +304,7 @@ func (c *Compressor) compressLZ4(data []byte) ([]byte, error) {
 // decompressLZ4 decompresses LZ4 data
 func (c *Compressor) decompressLZ4(data []byte) ([]byte, error) {
 	zr := lz4.NewReader(bytes.NewReader(data))
-	return io.ReadAll(zr)
+	return c.readLimited(zr)
 }
 
 // compressSnappy compresses using Snappy
@@ -289,6 +314,15 @@ func (c *Compressor) compressSnappy(data []byte) ([]byte, error) {
 
 // decompressSnappy decompresses Snappy data
 func (c *Compressor) decompressSnappy(data []byte) ([]byte, error) {
+	if c.config.MaxDecodedSize > 0 {
+		n, err := snappy.DecodedLen(data)
+		if err != nil {
+			return nil, err
+		}
+		if n > c.config.MaxDecodedSize {
+			return nil, ErrDecodedTooLarge
+		}
+	}
 	return snappy.Decode(nil, data)
 }
 
@@ -299,7 +333,11 @@ func (c *Compressor) compressZstd(data []byte) ([]byte, error) {
 
 // decompressZstd decompresses Zstd data
 func (c *Compressor) decompressZstd(data []byte) ([]byte, error) {
-	return c.zstdDec.DecodeAll(data, nil)
+	out, err := c.zstdDec.DecodeAll(data, nil)
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		return nil, ErrDecodedTooLarge
+	}
+	return out, err
 }
 
 // BatchCompressor handles batch compression for better ratios
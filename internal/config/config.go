@@ -58,11 +58,17 @@ you have two options:
 
 Environment Variables:
   - FLYDB_PORT: Server port for client connections (binary protocol)
+  - FLYDB_POSTGRES_PORT: PostgreSQL wire protocol port (0 disables)
   - FLYDB_REPL_PORT: Replication port
   - FLYDB_ROLE: Server role (standalone, cluster)
   - FLYDB_DB_PATH: Path to database file
   - FLYDB_ENCRYPTION_ENABLED: Enable data-at-rest encryption (true/false, default: true)
   - FLYDB_ENCRYPTION_PASSPHRASE: Passphrase for encryption key derivation (REQUIRED when encryption enabled)
+  - FLYDB_SYNCHRONOUS_COMMIT: WAL durability for commits (always, batch(10ms), off)
+  - FLYDB_AUTO_ANALYZE_FRACTION: Fraction of changed rows that re-analyzes a table (0 disables)
+  - FLYDB_TTL_REAPER_INTERVAL_SEC: Seconds between TTL reaper passes (0 disables)
+  - FLYDB_TTL_REAPER_BATCH_SIZE: Expired rows deleted per batch
+  - FLYDB_CURSOR_MEMORY_LIMIT_MB: Memory for the rows of open cursors in MB (0 = unlimited)
   - FLYDB_LOG_LEVEL: Log level (debug, info, warn, error)
   - FLYDB_LOG_JSON: Enable JSON logging (true/false)
   - FLYDB_ADMIN_PASSWORD: Initial admin password (first-time setup only)
@@ -78,20 +84,29 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+
+	"flydb/internal/storage"
 )
 
 // Environment variable names for configuration.
 const (
 	EnvPort                 = "FLYDB_PORT"
+	EnvPostgresPort         = "FLYDB_POSTGRES_PORT"
 	EnvReplPort             = "FLYDB_REPL_PORT"
 	EnvClusterPort          = "FLYDB_CLUSTER_PORT"
 	EnvRole                 = "FLYDB_ROLE"
 	EnvClusterPeers         = "FLYDB_CLUSTER_PEERS"
+	EnvRaftPeers            = "FLYDB_RAFT_PEERS"
 	EnvDBPath               = "FLYDB_DB_PATH"
 	EnvDataDir              = "FLYDB_DATA_DIR"
 	EnvStorageEngine        = "FLYDB_STORAGE_ENGINE"
 	EnvBufferPoolSize       = "FLYDB_BUFFER_POOL_SIZE"
 	EnvCheckpointSecs       = "FLYDB_CHECKPOINT_SECS"
+	EnvSynchronousCommit    = "FLYDB_SYNCHRONOUS_COMMIT"
+	EnvAutoAnalyzeFraction  = "FLYDB_AUTO_ANALYZE_FRACTION"
+	EnvTTLReaperInterval    = "FLYDB_TTL_REAPER_INTERVAL_SEC"
+	EnvTTLReaperBatchSize   = "FLYDB_TTL_REAPER_BATCH_SIZE"
+	EnvCursorMemoryLimit    = "FLYDB_CURSOR_MEMORY_LIMIT_MB"
 	EnvLogLevel             = "FLYDB_LOG_LEVEL"
 	EnvLogJSON              = "FLYDB_LOG_JSON"
 	EnvAdminPassword        = "FLYDB_ADMIN_PASSWORD"
@@ -179,7 +194,9 @@ type HealthConfig struct {
 	Addr    string `toml:"addr" json:"addr"`       // Health check HTTP server address (e.g., ":9095")
 }
 
-// AdminConfig holds admin API configuration.
+// AdminConfig holds admin API configuration. The admin listener serves the
+// HTTP/JSON query API; with AuthEnabled off, requests without credentials
+// run as the admin user.
 type AdminConfig struct {
 	Enabled     bool   `toml:"enabled" json:"enabled"`           // Enable admin API
 	Addr        string `toml:"addr" json:"addr"`                 // Admin API HTTP server address (e.g., ":9096")
@@ -203,6 +220,10 @@ type Config struct {
 	ClusterPort int    `toml:"cluster_port" json:"cluster_port"`
 	Role        string `toml:"role" json:"role"`
 
+	// PostgresPort is the port for PostgreSQL wire protocol clients such as
+	// psql and pgx. 0 disables the PostgreSQL listener.
+	PostgresPort int `toml:"postgres_port" json:"postgres_port"`
+
 	// Cluster configuration
 	ClusterPeers      []string `toml:"cluster_peers" json:"cluster_peers"`                 // List of peer addresses for cluster mode
 	HeartbeatInterval int      `toml:"heartbeat_interval_ms" json:"heartbeat_interval_ms"` // Heartbeat interval in milliseconds
@@ -224,9 +245,12 @@ type Config struct {
 	MaxReplicationLag int    `toml:"max_replication_lag_ms" json:"max_replication_lag_ms"` // Max acceptable replication lag in ms
 
 	// Raft consensus configuration
-	EnableRaft            bool `toml:"enable_raft" json:"enable_raft"`                               // Enable Raft-based consensus (replaces Bully)
-	RaftElectionTimeout   int  `toml:"raft_election_timeout_ms" json:"raft_election_timeout_ms"`     // Raft election timeout in ms
-	RaftHeartbeatInterval int  `toml:"raft_heartbeat_interval_ms" json:"raft_heartbeat_interval_ms"` // Raft heartbeat interval in ms
+	EnableRaft            bool     `toml:"enable_raft" json:"enable_raft"`                               // Enable Raft-based consensus (replaces Bully)
+	RaftElectionTimeout   int      `toml:"raft_election_timeout_ms" json:"raft_election_timeout_ms"`     // Raft election timeout in ms
+	RaftHeartbeatInterval int      `toml:"raft_heartbeat_interval_ms" json:"raft_heartbeat_interval_ms"` // Raft heartbeat interval in ms
+	RaftPort              int      `toml:"raft_port" json:"raft_port"`                                   // Raft consensus port
+	RaftJoin              bool     `toml:"raft_join" json:"raft_join"`                                   // Wait to be added with ALTER CLUSTER ADD NODE instead of bootstrapping
+	RaftPeers             []string `toml:"raft_peers" json:"raft_peers"`                                 // Raft addresses (host:raft_port) of the other voters
 
 	// Service Discovery configuration
 	DiscoveryEnabled   bool   `toml:"discovery_enabled" json:"discovery_enabled"`       // Enable mDNS-based service discovery
@@ -248,6 +272,26 @@ type Config struct {
 	BufferPoolSize int    `toml:"buffer_pool_size" json:"buffer_pool_size"` // Buffer pool size in pages (0 = auto-size based on available memory)
 	CheckpointSecs int    `toml:"checkpoint_secs" json:"checkpoint_secs"`   // Checkpoint interval in seconds (0 = disabled)
 
+	// SynchronousCommit is the default WAL durability for committed statements:
+	// always, batch(interval) such as batch(10ms), or off. Sessions can override
+	// it with the synchronous_commit option.
+	SynchronousCommit string `toml:"synchronous_commit" json:"synchronous_commit"`
+
+	// AutoAnalyzeFraction is the fraction of a table's rows that must be
+	// inserted, updated or deleted before its planner statistics are
+	// refreshed automatically. 0 disables automatic ANALYZE.
+	AutoAnalyzeFraction float64 `toml:"auto_analyze_fraction" json:"auto_analyze_fraction"`
+
+	// TTLReaperInterval is the number of seconds between passes of the
+	// background reaper that deletes expired rows of tables with a TTL.
+	// 0 disables the reaper. TTLReaperBatchSize caps the rows deleted per batch.
+	TTLReaperInterval  int `toml:"ttl_reaper_interval_sec" json:"ttl_reaper_interval_sec"`
+	TTLReaperBatchSize int `toml:"ttl_reaper_batch_size" json:"ttl_reaper_batch_size"`
+
+	// CursorMemoryLimitMB caps the memory, in megabytes, used by the rows of
+	// all open server-side cursors. 0 removes the limit.
+	CursorMemoryLimitMB int `toml:"cursor_memory_limit_mb" json:"cursor_memory_limit_mb"`
+
 	// Multi-database configuration
 	DefaultDatabase  string `toml:"default_database" json:"default_database"`   // Default database for new connections
 	DefaultEncoding  string `toml:"default_encoding" json:"default_encoding"`   // Default encoding for new databases
@@ -326,6 +370,8 @@ func DefaultConfig() *Config {
 		EnableRaft:            true, // Enable Raft by default for cluster mode
 		RaftElectionTimeout:   1000, // 1s
 		RaftHeartbeatInterval: 150,  // 150ms
+		RaftPort:              9997,
+		RaftPeers:             []string{},
 
 		// Service Discovery
 		DiscoveryEnabled:   false, // Disabled by default
@@ -347,6 +393,12 @@ func DefaultConfig() *Config {
 		BufferPoolSize: 0,      // 0 = auto-size based on available memory
 		CheckpointSecs: 60,     // 1 minute
 
+		SynchronousCommit:   "always", // fsync before acknowledging each commit
+		AutoAnalyzeFraction: 0.2,      // re-analyze after 20% of rows change
+		TTLReaperInterval:   60,       // reap expired rows every minute
+		TTLReaperBatchSize:  1000,     // delete up to 1000 expired rows per batch
+		CursorMemoryLimitMB: 256,      // 256MB for the rows of open cursors
+
 		// Multi-database
 		DefaultDatabase:  "default",
 		DefaultEncoding:  "UTF8",
@@ -473,6 +525,11 @@ func (c *Config) Validate() error {
 	if c.ClusterPort < 1 || c.ClusterPort > 65535 {
 		errs = append(errs, fmt.Sprintf("invalid cluster_port: %d (must be 1-65535)", c.ClusterPort))
 	}
+	if c.PostgresPort < 0 || c.PostgresPort > 65535 {
+		errs = append(errs, fmt.Sprintf("invalid postgres_port: %d (must be 0-65535)", c.PostgresPort))
+	} else if c.PostgresPort != 0 && c.PostgresPort == c.Port {
+		errs = append(errs, "postgres_port must be different from port")
+	}
 
 	// Check for port conflicts
 	if c.Role == "cluster" {
@@ -480,6 +537,23 @@ func (c *Config) Validate() error {
 		if len(ports) < 3 {
 			errs = append(errs, "all ports (port, replication_port, cluster_port) must be different in cluster mode")
 		}
+		if c.EnableRaft {
+			if c.RaftPort < 1 || c.RaftPort > 65535 {
+				errs = append(errs, fmt.Sprintf("invalid raft_port: %d (must be 1-65535)", c.RaftPort))
+			} else if _, ok := ports[c.RaftPort]; ok {
+				errs = append(errs, "raft_port must be different from port, replication_port and cluster_port")
+			}
+			// A bootstrapping node needs the Raft address of every other
+			// voter; they cannot be derived from cluster_peers
+			if len(c.RaftPeers) == 0 && len(c.ClusterPeers) > 0 && !c.RaftJoin {
+				errs = append(errs, "enable_raft requires the Raft address of every other voter in raft_peers")
+			}
+			for _, peer := range c.RaftPeers {
+				if _, port, ok := strings.Cut(peer, ":"); !ok || port == "" {
+					errs = append(errs, fmt.Sprintf("invalid raft_peers entry: %q (must be host:port)", peer))
+				}
+			}
+		}
 	}
 
 	// Validate role
@@ -528,6 +602,29 @@ func (c *Config) Validate() error {
 		errs = append(errs, "db_path cannot be empty")
 	}
 
+	// Validate synchronous commit mode
+	if c.SynchronousCommit != "" {
+		if _, err := storage.ParseSyncPolicy(c.SynchronousCommit); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	// Validate auto-analyze threshold
+	if c.AutoAnalyzeFraction < 0 || c.AutoAnalyzeFraction > 1 {
+		errs = append(errs, fmt.Sprintf("invalid auto_analyze_fraction: %g (must be between 0 and 1)", c.AutoAnalyzeFraction))
+	}
+
+	// Validate TTL reaper settings
+	if c.TTLReaperInterval < 0 {
+		errs = append(errs, fmt.Sprintf("invalid ttl_reaper_interval_sec: %d (must be >= 0)", c.TTLReaperInterval))
+	}
+	if c.TTLReaperBatchSize < 1 {
+		errs = append(errs, fmt.Sprintf("invalid ttl_reaper_batch_size: %d (must be >= 1)", c.TTLReaperBatchSize))
+	}
+	if c.CursorMemoryLimitMB < 0 {
+		errs = append(errs, fmt.Sprintf("invalid cursor_memory_limit_mb: %d (must be >= 0)", c.CursorMemoryLimitMB))
+	}
+
 	// Note: Encryption passphrase validation is intentionally NOT done here.
 	// The passphrase check is done at startup in main.go to provide a more
 	// user-friendly error message with guidance on how to fix the issue.
@@ -579,6 +676,11 @@ func (m *Manager) LoadFromEnv() {
 			cfg.ReplPort = port
 		}
 	}
+	if v := os.Getenv(EnvPostgresPort); v != "" {
+		if port, err := strconv.Atoi(v); err == nil {
+			cfg.PostgresPort = port
+		}
+	}
 	if v := os.Getenv(EnvRole); v != "" {
 		cfg.Role = v
 	}
@@ -601,6 +703,29 @@ func (m *Manager) LoadFromEnv() {
 			cfg.CheckpointSecs = secs
 		}
 	}
+	if v := os.Getenv(EnvSynchronousCommit); v != "" {
+		cfg.SynchronousCommit = v
+	}
+	if v := os.Getenv(EnvAutoAnalyzeFraction); v != "" {
+		if fraction, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.AutoAnalyzeFraction = fraction
+		}
+	}
+	if v := os.Getenv(EnvTTLReaperInterval); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			cfg.TTLReaperInterval = secs
+		}
+	}
+	if v := os.Getenv(EnvTTLReaperBatchSize); v != "" {
+		if size, err := strconv.Atoi(v); err == nil {
+			cfg.TTLReaperBatchSize = size
+		}
+	}
+	if v := os.Getenv(EnvCursorMemoryLimit); v != "" {
+		if mb, err := strconv.Atoi(v); err == nil {
+			cfg.CursorMemoryLimitMB = mb
+		}
+	}
 	if v := os.Getenv(EnvLogLevel); v != "" {
 		cfg.LogLevel = v
 	}
@@ -648,6 +773,16 @@ func (m *Manager) LoadFromEnv() {
 			}
 		}
 	}
+	if v := os.Getenv(EnvRaftPeers); v != "" {
+		peers := strings.Split(v, ",")
+		cfg.RaftPeers = make([]string, 0, len(peers))
+		for _, peer := range peers {
+			peer = strings.TrimSpace(peer)
+			if peer != "" {
+				cfg.RaftPeers = append(cfg.RaftPeers, peer)
+			}
+		}
+	}
 	if v := os.Getenv(EnvReplicationMode); v != "" {
 		cfg.ReplicationMode = strings.ToLower(v)
 	}
@@ -833,9 +968,15 @@ func (c *Config) String() string {
 	sb.WriteString(fmt.Sprintf("  Role:             %s\n", c.Role))
 	sb.WriteString(fmt.Sprintf("  Port:             %d\n", c.Port))
 	sb.WriteString(fmt.Sprintf("  Replication Port: %d\n", c.ReplPort))
+	if c.PostgresPort != 0 {
+		sb.WriteString(fmt.Sprintf("  PostgreSQL Port:  %d\n", c.PostgresPort))
+	}
 	if c.Role == "cluster" {
 		sb.WriteString(fmt.Sprintf("  Cluster Port:     %d\n", c.ClusterPort))
 		sb.WriteString(fmt.Sprintf("  Cluster Peers:    %v\n", c.ClusterPeers))
+		if c.EnableRaft {
+			sb.WriteString(fmt.Sprintf("  Raft Peers:       %v\n", c.RaftPeers))
+		}
 		sb.WriteString(fmt.Sprintf("  Replication Mode: %s\n", c.ReplicationMode))
 	}
 	sb.WriteString(fmt.Sprintf("  DB Path:          %s\n", c.DBPath))
@@ -118,10 +118,43 @@ func TestConfigValidation(t *testing.T) {
 				cfg := validTestConfig()
 				cfg.Role = "cluster"
 				cfg.ClusterPeers = []string{"node2:9998", "node3:9998"}
+				cfg.RaftPeers = []string{"node2:9997", "node3:9997"}
 				return cfg
 			}(),
 			wantErr: false,
 		},
+		{
+			name: "raft cluster without raft peers",
+			cfg: func() *Config {
+				cfg := validTestConfig()
+				cfg.Role = "cluster"
+				cfg.ClusterPeers = []string{"node2:9998", "node3:9998"}
+				return cfg
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "raft node joining without raft peers",
+			cfg: func() *Config {
+				cfg := validTestConfig()
+				cfg.Role = "cluster"
+				cfg.ClusterPeers = []string{"node2:9998", "node3:9998"}
+				cfg.RaftJoin = true
+				return cfg
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "invalid raft peer",
+			cfg: func() *Config {
+				cfg := validTestConfig()
+				cfg.Role = "cluster"
+				cfg.ClusterPeers = []string{"node2:9998"}
+				cfg.RaftPeers = []string{"node2"}
+				return cfg
+			}(),
+			wantErr: true,
+		},
 		{
 			name: "cluster without peers",
 			cfg: func() *Config {
@@ -141,6 +174,69 @@ func TestConfigValidation(t *testing.T) {
 			}(),
 			wantErr: true,
 		},
+		{
+			name: "batch synchronous commit",
+			cfg: func() *Config {
+				cfg := validTestConfig()
+				cfg.SynchronousCommit = "batch(5ms)"
+				return cfg
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "invalid synchronous commit",
+			cfg: func() *Config {
+				cfg := validTestConfig()
+				cfg.SynchronousCommit = "batch(soon)"
+				return cfg
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid auto analyze fraction",
+			cfg: func() *Config {
+				cfg := validTestConfig()
+				cfg.AutoAnalyzeFraction = 1.5
+				return cfg
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid ttl reaper batch size",
+			cfg: func() *Config {
+				cfg := validTestConfig()
+				cfg.TTLReaperBatchSize = 0
+				return cfg
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid cursor memory limit",
+			cfg: func() *Config {
+				cfg := validTestConfig()
+				cfg.CursorMemoryLimitMB = -1
+				return cfg
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "postgres port",
+			cfg: func() *Config {
+				cfg := validTestConfig()
+				cfg.PostgresPort = 5432
+				return cfg
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "postgres port same as port",
+			cfg: func() *Config {
+				cfg := validTestConfig()
+				cfg.PostgresPort = cfg.Port
+				return cfg
+			}(),
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -81,6 +81,7 @@ const (
 	ErrCodeInvalidJSON               ErrorCode = 2025
 	ErrCodeJSONPathError             ErrorCode = 2026
 	ErrCodeParameterMismatch         ErrorCode = 2027
+	ErrCodeQueryCanceled             ErrorCode = 2028
 
 	// Connection errors (3000-3999)
 	ErrCodeConnection        ErrorCode = 3000
@@ -510,6 +511,16 @@ func ParameterMismatch(expected, got int) *FlyDBError {
 	}
 }
 
+// QueryCanceled creates an error for a statement that was canceled before it
+// completed. reason is "user request" or "statement timeout".
+func QueryCanceled(reason string) *FlyDBError {
+	return &FlyDBError{
+		Code:     ErrCodeQueryCanceled,
+		Category: CategoryExecution,
+		Message:  fmt.Sprintf("canceling statement due to %s", reason),
+	}
+}
+
 // ============================================================================
 // Connection Error Constructors
 // ============================================================================
@@ -755,6 +766,17 @@ func TransactionDeadlock() *FlyDBError {
 	}
 }
 
+// TransactionSerializationFailure creates an error for a serializable
+// transaction whose reads changed before it committed.
+func TransactionSerializationFailure() *FlyDBError {
+	return &FlyDBError{
+		Code:     ErrCodeTxSerializationFail,
+		Category: CategoryTransaction,
+		Message:  "could not serialize access due to concurrent update",
+		Hint:     "Retry the transaction",
+	}
+}
+
 // ============================================================================
 // Driver Error Constructors
 // ============================================================================
@@ -34,7 +34,9 @@ Common Classes:
   - 24 = Invalid cursor state
   - 25 = Invalid transaction state
   - 28 = Invalid authorization specification
+  - 40 = Transaction rollback
   - 42 = Syntax error or access rule violation
+  - 57 = Operator intervention
   - HY = CLI-specific condition (ODBC)
 */
 package errors
@@ -98,6 +100,9 @@ const (
 	SQLStateAuthError           SQLSTATE = "28000"
 	SQLStateInvalidAuthSpec     SQLSTATE = "28P01"
 
+	// Transaction Rollback (40xxx)
+	SQLStateSerializationFailure SQLSTATE = "40001"
+
 	// Invalid Catalog Name (3Dxxx)
 	SQLStateInvalidCatalog SQLSTATE = "3D000"
 
@@ -115,6 +120,9 @@ const (
 	SQLStateUndefinedFunction   SQLSTATE = "42883"
 	SQLStateInsufficientPriv    SQLSTATE = "42501"
 
+	// Operator Intervention (57xxx)
+	SQLStateQueryCanceled SQLSTATE = "57014"
+
 	// CLI-specific Condition (HYxxx) - ODBC specific
 	SQLStateCLIError            SQLSTATE = "HY000"
 	SQLStateMemoryAlloc         SQLSTATE = "HY001"
@@ -157,6 +165,7 @@ var sqlstateMap = map[ErrorCode]SQLSTATE{
 	ErrCodeForeignKeyViolation: SQLStateForeignKeyViolation,
 	ErrCodeDivisionByZero:      SQLStateDivisionByZero,
 	ErrCodeOverflow:            SQLStateNumericOutOfRange,
+	ErrCodeQueryCanceled:       SQLStateQueryCanceled,
 
 	// Connection errors (3000-3999) -> 08xxx
 	ErrCodeConnection:        SQLStateConnectionError,
@@ -201,7 +210,7 @@ var sqlstateMap = map[ErrorCode]SQLSTATE{
 	ErrCodeTxAlreadyActive:     SQLStateActiveTx,
 	ErrCodeTxIsolationError:    SQLStateTransactionState,
 	ErrCodeTxDeadlock:          SQLStateTransactionState,
-	ErrCodeTxSerializationFail: SQLStateTransactionState,
+	ErrCodeTxSerializationFail: SQLStateSerializationFailure,
 	ErrCodeTxReadOnly:          SQLStateReadOnlyTx,
 
 	// Driver errors (9000-9999) -> HYxxx
@@ -0,0 +1,377 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package httpapi serves SQL over HTTP/JSON for clients that cannot hold a
+TCP connection open, such as serverless functions and scripts.
+
+ENDPOINTS:
+==========
+
+	POST   /v1/query                   - Run a statement
+	POST   /v1/sessions                - Begin a transaction session
+	POST   /v1/sessions/{id}/commit    - Commit a session's transaction
+	POST   /v1/sessions/{id}/rollback  - Roll a session's transaction back
+	POST   /v1/tokens                  - Issue an API token
+	DELETE /v1/tokens                  - Revoke the presented API token
+
+AUTHENTICATION:
+===============
+Requests authenticate with HTTP basic auth or with an API token:
+
+	Authorization: Bearer flydb_...
+
+Tokens are issued by POST /v1/tokens, which requires basic auth, so that
+clients need not keep the password.
+
+QUERIES:
+========
+The body of POST /v1/query names the statement and its parameters, which
+are bound to the placeholders $1, $2, ...:
+
+	{"sql": "SELECT * FROM users WHERE id = $1", "params": [42],
+	 "database": "default", "timeout": "5s"}
+
+The result is a single JSON document, or newline-delimited JSON with
+"Accept: application/x-ndjson": a {"columns": [...]} line, one array per
+row, and a {"row_count": n} line, so that a truncated stream can be told
+from a complete one. Rows are written and flushed as they are encoded.
+
+TRANSACTIONS:
+=============
+POST /v1/sessions begins a transaction and returns its session ID. Queries
+that carry the ID in the X-FlyDB-Session header run in the transaction
+until it is committed or rolled back. Sessions belong to the user that
+began them and are rolled back after sessionIdleTimeout without requests.
+*/
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"flydb/internal/errors"
+	"flydb/internal/logging"
+	"flydb/internal/protocol"
+)
+
+// Package logger for the HTTP API.
+var log = logging.NewLogger("httpapi")
+
+// maxRequestSize bounds the size of a request body.
+const maxRequestSize = 16 << 20
+
+// Authenticator verifies HTTP clients and manages their API tokens.
+type Authenticator interface {
+	// Authenticate checks a password.
+	Authenticate(username, password string) bool
+	// AuthenticateToken returns the user an API token authenticates as.
+	AuthenticateToken(token string) (username string, ok bool)
+	// CreateToken issues a token for username that expires after ttl, or
+	// never if ttl is 0.
+	CreateToken(username string, ttl time.Duration) (string, error)
+	// RevokeToken deletes a token.
+	RevokeToken(token string) error
+}
+
+// Handler serves the HTTP API.
+type Handler struct {
+	executor      protocol.ContextQueryExecutor
+	auth          Authenticator
+	txMgr         protocol.TransactionManager
+	dbMgr         protocol.DatabaseManager
+	commits       protocol.CommitSyncer
+	anonymousUser string
+	mux           *http.ServeMux
+
+	// Open sessions by ID
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// NewHandler creates a handler that runs statements on executor and
+// authenticates users with auth.
+func NewHandler(executor protocol.ContextQueryExecutor, auth Authenticator) *Handler {
+	h := &Handler{
+		executor: executor,
+		auth:     auth,
+		mux:      http.NewServeMux(),
+		sessions: make(map[string]*session),
+	}
+	h.mux.HandleFunc("POST /v1/query", h.authenticated(h.handleQuery))
+	h.mux.HandleFunc("POST /v1/sessions", h.authenticated(h.handleBegin))
+	h.mux.HandleFunc("POST /v1/sessions/{id}/commit", h.authenticated(h.handleCommit))
+	h.mux.HandleFunc("POST /v1/sessions/{id}/rollback", h.authenticated(h.handleRollback))
+	h.mux.HandleFunc("POST /v1/tokens", h.handleCreateToken)
+	h.mux.HandleFunc("DELETE /v1/tokens", h.handleRevokeToken)
+	return h
+}
+
+// SetTransactionManager sets the manager that runs session transactions.
+// Without one, POST /v1/sessions is rejected.
+func (h *Handler) SetTransactionManager(tm protocol.TransactionManager) {
+	h.txMgr = tm
+}
+
+// SetDatabaseManager sets the manager that checks the databases requests
+// name.
+func (h *Handler) SetDatabaseManager(dm protocol.DatabaseManager) {
+	h.dbMgr = dm
+}
+
+// SetCommitSyncer sets the hook that makes statements outside sessions
+// durable before their response is sent.
+func (h *Handler) SetCommitSyncer(cs protocol.CommitSyncer) {
+	h.commits = cs
+}
+
+// SetAnonymousUser lets requests without credentials run as user. It is
+// meant for trusted networks only; by default credentials are required.
+func (h *Handler) SetAnonymousUser(user string) {
+	h.anonymousUser = user
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+	h.mux.ServeHTTP(w, r)
+}
+
+// Close rolls back the transactions of all open sessions.
+func (h *Handler) Close() {
+	h.mu.Lock()
+	sessions := make([]*session, 0, len(h.sessions))
+	for _, s := range h.sessions {
+		sessions = append(sessions, s)
+	}
+	h.mu.Unlock()
+
+	for _, s := range sessions {
+		h.endSession(s, false)
+	}
+}
+
+// userHandler is an endpoint that needs the authenticated user.
+type userHandler func(w http.ResponseWriter, r *http.Request, user string)
+
+// authenticated wraps an endpoint so that it runs only for authenticated
+// requests.
+func (h *Handler) authenticated(next userHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := h.authenticate(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		next(w, r, user)
+	}
+}
+
+// authenticate returns the user of a request from its basic auth
+// credentials or bearer token.
+func (h *Handler) authenticate(r *http.Request) (string, error) {
+	if username, password, ok := r.BasicAuth(); ok {
+		if h.auth.Authenticate(username, password) {
+			return username, nil
+		}
+		log.Debug("HTTP authentication failed", "user", username, "remote_addr", r.RemoteAddr)
+		return "", errors.AuthenticationFailed()
+	}
+	if token, ok := bearerToken(r); ok {
+		if username, ok := h.auth.AuthenticateToken(token); ok {
+			return username, nil
+		}
+		return "", errors.NewAuthError("invalid or expired token").
+			WithHint("Issue a new token with POST /v1/tokens")
+	}
+	if h.anonymousUser != "" {
+		return h.anonymousUser, nil
+	}
+	return "", errors.NewAuthError("authentication required").
+		WithHint("Use basic auth or an API token")
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// tokenRequest is the body of POST /v1/tokens.
+type tokenRequest struct {
+	TTL string `json:"ttl"` // Lifetime such as "24h"; empty for no expiry
+}
+
+// tokenResponse is the response of POST /v1/tokens.
+type tokenResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// handleCreateToken issues an API token to a user authenticated with a
+// password, so that a token cannot be used to mint tokens that outlive
+// it.
+func (h *Handler) handleCreateToken(w http.ResponseWriter, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+	if !ok || !h.auth.Authenticate(username, password) {
+		writeError(w, errors.AuthenticationFailed().WithDetail("tokens are issued with basic auth"))
+		return
+	}
+
+	var req tokenRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+			writeError(w, errors.InvalidValue("ttl", "expected a positive duration such as \"24h\""))
+			return
+		}
+	}
+
+	token, err := h.auth.CreateToken(username, ttl)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	resp := tokenResponse{Token: token}
+	if ttl > 0 {
+		resp.ExpiresAt = time.Now().Add(ttl).UTC().Format(time.RFC3339)
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// handleRevokeToken revokes the token the request authenticates with.
+func (h *Handler) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(r)
+	if !ok {
+		writeError(w, errors.NewAuthError("authentication required").
+			WithHint("Present the token to revoke as a bearer token"))
+		return
+	}
+	if _, ok := h.auth.AuthenticateToken(token); !ok {
+		writeError(w, errors.NewAuthError("invalid or expired token"))
+		return
+	}
+	if err := h.auth.RevokeToken(token); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeBody decodes a JSON request body into v. An empty body leaves v
+// unchanged.
+func decodeBody(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil && err != io.EOF {
+		return errors.InvalidJSON(err.Error())
+	}
+	return nil
+}
+
+// errorResponse is the body of an error response.
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+// errorBody describes an error like the binary protocol's ErrorMessage,
+// with the SQLSTATE and the detail and hint of the error.
+type errorBody struct {
+	Code     int    `json:"code"`
+	SQLState string `json:"sqlstate"`
+	Message  string `json:"message"`
+	Detail   string `json:"detail,omitempty"`
+	Hint     string `json:"hint,omitempty"`
+}
+
+// toErrorBody converts an error to its response body.
+func toErrorBody(err error) errorBody {
+	var fe *errors.FlyDBError
+	if !stderrors.As(err, &fe) {
+		fe = errors.InternalError(err.Error())
+	}
+	return errorBody{
+		Code:     int(fe.Code),
+		SQLState: string(fe.SQLSTATE()),
+		Message:  fe.Message,
+		Detail:   fe.Detail,
+		Hint:     fe.Hint,
+	}
+}
+
+// httpStatus returns the HTTP status of an error response.
+func httpStatus(err error) int {
+	if stderrors.Is(err, context.DeadlineExceeded) {
+		return http.StatusRequestTimeout
+	}
+	var fe *errors.FlyDBError
+	if !stderrors.As(err, &fe) {
+		return http.StatusInternalServerError
+	}
+	switch fe.Code {
+	case errors.ErrCodePermissionDenied:
+		return http.StatusForbidden
+	case errors.ErrCodeSessionExpired:
+		return http.StatusNotFound
+	case errors.ErrCodeQueryCanceled:
+		return http.StatusRequestTimeout
+	case errors.ErrCodeTxDeadlock, errors.ErrCodeTxSerializationFail:
+		return http.StatusConflict
+	case errors.ErrCodeInternal:
+		return http.StatusInternalServerError
+	}
+	switch fe.Category {
+	case errors.CategoryAuth:
+		return http.StatusUnauthorized
+	case errors.CategoryStorage:
+		return http.StatusInternalServerError
+	case errors.CategoryConnection:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadRequest
+}
+
+// writeError sends an error response.
+func writeError(w http.ResponseWriter, err error) {
+	status := httpStatus(err)
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="flydb", charset="UTF-8"`)
+	}
+	writeJSON(w, status, errorResponse{Error: toErrorBody(err)})
+}
+
+// writeJSON sends a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
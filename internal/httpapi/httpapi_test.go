@@ -0,0 +1,360 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpapi_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"flydb/internal/auth"
+	"flydb/internal/httpapi"
+	"flydb/internal/server"
+	"flydb/internal/storage"
+)
+
+// startServer starts a server with the HTTP API, an admin user and a user
+// "app" with password "secret", and returns the API's base URL.
+func startServer(t *testing.T) string {
+	t.Helper()
+	tmpDir, err := os.MkdirTemp("", "flydb_httpapi_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	store, err := storage.NewStorageEngine(storage.StorageConfig{DataDir: tmpDir, BufferPoolSize: 256})
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		t.Fatalf("Failed to create storage engine: %v", err)
+	}
+	authMgr := auth.NewAuthManager(store)
+	if err := authMgr.InitializeAdmin("admin"); err != nil {
+		store.Close()
+		os.RemoveAll(tmpDir)
+		t.Fatalf("Failed to initialize admin user: %v", err)
+	}
+	if err := authMgr.CreateUser("app", "secret"); err != nil {
+		store.Close()
+		os.RemoveAll(tmpDir)
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	ports := make([]int, 2)
+	for i := range ports {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to find a port: %v", err)
+		}
+		ports[i] = listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+	}
+	httpAddr := fmt.Sprintf("127.0.0.1:%d", ports[1])
+
+	srv := server.NewServerWithStore(fmt.Sprintf(":%d", ports[0]), store)
+	srv.SetHTTPAddr(httpAddr, true)
+	t.Cleanup(func() {
+		srv.Stop()
+		store.Close()
+		time.Sleep(10 * time.Millisecond)
+		os.RemoveAll(tmpDir)
+	})
+	go srv.Start()
+	time.Sleep(150 * time.Millisecond)
+	return "http://" + httpAddr
+}
+
+// client sends API requests with fixed credentials.
+type client struct {
+	t       *testing.T
+	base    string
+	user    string
+	pass    string
+	token   string
+	session string
+}
+
+// response is a decoded API response.
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// json decodes the response body.
+func (r *response) json(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var v map[string]interface{}
+	if err := json.Unmarshal(r.body, &v); err != nil {
+		t.Fatalf("Invalid JSON response %q: %v", r.body, err)
+	}
+	return v
+}
+
+// errorField returns a field of the error of a response.
+func (r *response) errorField(t *testing.T, name string) interface{} {
+	t.Helper()
+	e, ok := r.json(t)["error"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Response %q has no error", r.body)
+	}
+	return e[name]
+}
+
+func (c *client) do(method, path string, body interface{}, accept string) *response {
+	c.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	}
+	req, _ := http.NewRequest(method, c.base+path, reader)
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.pass)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.session != "" {
+		req.Header.Set(httpapi.SessionHeader, c.session)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return &response{status: resp.StatusCode, header: resp.Header, body: data}
+}
+
+// query runs a statement and returns the response.
+func (c *client) query(sql string, params ...interface{}) *response {
+	c.t.Helper()
+	return c.do("POST", "/v1/query", map[string]interface{}{"sql": sql, "params": params}, "")
+}
+
+// mustQuery runs a statement that must succeed and decodes its result.
+func (c *client) mustQuery(sql string, params ...interface{}) map[string]interface{} {
+	c.t.Helper()
+	resp := c.query(sql, params...)
+	if resp.status != http.StatusOK {
+		c.t.Fatalf("Query %q returned %d: %s", sql, resp.status, resp.body)
+	}
+	return resp.json(c.t)
+}
+
+func TestAuthentication(t *testing.T) {
+	base := startServer(t)
+
+	anonymous := &client{t: t, base: base}
+	if resp := anonymous.query("SELECT 1 FROM t"); resp.status != http.StatusUnauthorized || resp.header.Get("WWW-Authenticate") == "" {
+		t.Errorf("Request without credentials returned %d", resp.status)
+	}
+	wrong := &client{t: t, base: base, user: "admin", pass: "wrong"}
+	if resp := wrong.query("SELECT 1 FROM t"); resp.status != http.StatusUnauthorized {
+		t.Errorf("Request with a wrong password returned %d", resp.status)
+	}
+
+	// Tokens are issued with basic auth and then replace it
+	admin := &client{t: t, base: base, user: "admin", pass: "admin"}
+	admin.mustQuery("CREATE TABLE t (id INT)")
+	resp := admin.do("POST", "/v1/tokens", map[string]string{"ttl": "1h"}, "")
+	if resp.status != http.StatusCreated {
+		t.Fatalf("POST /v1/tokens returned %d: %s", resp.status, resp.body)
+	}
+	token, _ := resp.json(t)["token"].(string)
+	if !strings.HasPrefix(token, auth.TokenPrefix) || resp.json(t)["expires_at"] == nil {
+		t.Errorf("Unexpected token response %s", resp.body)
+	}
+
+	bearer := &client{t: t, base: base, token: token}
+	bearer.mustQuery("INSERT INTO t VALUES (1)")
+	if resp := bearer.do("POST", "/v1/tokens", nil, ""); resp.status != http.StatusUnauthorized {
+		t.Errorf("Token issued a token: %d", resp.status)
+	}
+	if resp := bearer.do("DELETE", "/v1/tokens", nil, ""); resp.status != http.StatusNoContent {
+		t.Errorf("DELETE /v1/tokens returned %d: %s", resp.status, resp.body)
+	}
+	if resp := bearer.query("SELECT id FROM t"); resp.status != http.StatusUnauthorized {
+		t.Errorf("Revoked token returned %d", resp.status)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	c := &client{t: t, base: startServer(t), user: "admin", pass: "admin"}
+
+	c.mustQuery("CREATE TABLE people (id INT, name TEXT, score FLOAT, active BOOLEAN)")
+	result := c.mustQuery("INSERT INTO people VALUES ($1, $2, $3, $4)", 1, "O'Brien costs $1", -2.5, true)
+	if result["rows_affected"] != float64(1) {
+		t.Errorf("INSERT returned %v", result)
+	}
+	c.mustQuery("INSERT INTO people VALUES ($1, $2, $3, $4)", 2, nil, 10, false)
+
+	result = c.mustQuery("SELECT id, name, score, active FROM people WHERE id >= $1 ORDER BY id", 1)
+	columns, _ := json.Marshal(result["columns"])
+	if string(columns) != `[{"name":"id","type":"INT"},{"name":"name","type":"TEXT"},{"name":"score","type":"FLOAT"},{"name":"active","type":"BOOLEAN"}]` {
+		t.Errorf("Columns are %s", columns)
+	}
+	rows, _ := json.Marshal(result["rows"])
+	if string(rows) != `[[1,"O'Brien costs $1",-2.5,true],[2,null,10,false]]` || result["row_count"] != float64(2) {
+		t.Errorf("Rows are %s, row_count %v", rows, result["row_count"])
+	}
+	if result := c.mustQuery("SELECT id FROM people WHERE id > $1", 5); fmt.Sprint(result["rows"]) != "[]" {
+		t.Errorf("Empty result has rows %v", result["rows"])
+	}
+
+	// Statement errors carry the code and SQLSTATE
+	resp := c.query("SELECT * FROM missing")
+	if resp.status != http.StatusBadRequest || resp.errorField(t, "sqlstate") != "42S02" {
+		t.Errorf("Missing table returned %d: %s", resp.status, resp.body)
+	}
+	for _, query := range []string{"BEGIN", "-- start\nBEGIN", "/* done */ COMMIT", "rollback;"} {
+		if resp := c.query(query); resp.status != http.StatusBadRequest || resp.errorField(t, "sqlstate") != "25000" {
+			t.Errorf("%q returned %d: %s", query, resp.status, resp.body)
+		}
+	}
+	if resp := c.do("POST", "/v1/query", map[string]string{"sql": "SELECT id FROM people", "bogus": "x"}, ""); resp.status != http.StatusBadRequest {
+		t.Errorf("Unknown request field returned %d", resp.status)
+	}
+	if resp := c.do("POST", "/v1/query", map[string]string{"sql": "SELECT id FROM people", "database": "nodb"}, ""); resp.status != http.StatusBadRequest {
+		t.Errorf("Missing database returned %d", resp.status)
+	}
+}
+
+func TestQueryNDJSON(t *testing.T) {
+	c := &client{t: t, base: startServer(t), user: "admin", pass: "admin"}
+	c.mustQuery("CREATE TABLE nums (n INT)")
+
+	// Tokens skip the password hash that basic auth checks per request
+	resp := c.do("POST", "/v1/tokens", nil, "")
+	c.user, c.token = "", resp.json(t)["token"].(string)
+	resp = c.do("POST", "/v1/sessions", nil, "")
+	id, _ := resp.json(t)["session"].(string)
+	c.session = id
+	for i := 1; i <= 300; i++ {
+		c.mustQuery("INSERT INTO nums VALUES ($1)", i)
+	}
+	c.session = ""
+	if resp := c.do("POST", "/v1/sessions/"+id+"/commit", nil, ""); resp.status != http.StatusOK {
+		t.Fatalf("Commit returned %d: %s", resp.status, resp.body)
+	}
+
+	resp = c.do("POST", "/v1/query", map[string]string{"sql": "SELECT n FROM nums ORDER BY n"}, "application/x-ndjson")
+	if resp.status != http.StatusOK || resp.header.Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("NDJSON query returned %d %q", resp.status, resp.header.Get("Content-Type"))
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(resp.body))
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 302 {
+		t.Fatalf("Got %d lines, want 302", len(lines))
+	}
+	if lines[0] != `{"columns":[{"name":"n","type":"INT"}]}` || lines[1] != "[1]" || lines[300] != "[300]" || lines[301] != `{"row_count":300}` {
+		t.Errorf("Unexpected lines %q %q %q %q", lines[0], lines[1], lines[300], lines[301])
+	}
+
+	// Rows of a plain scan are written as the executor produces them
+	resp = c.do("POST", "/v1/query?format=ndjson", map[string]string{"sql": "SELECT n FROM nums WHERE n > 0 LIMIT 260"}, "")
+	lines = strings.Split(strings.TrimSuffix(string(resp.body), "\n"), "\n")
+	if len(lines) != 262 || lines[0] != `{"columns":[{"name":"n","type":"INT"}]}` || lines[261] != `{"row_count":260}` {
+		t.Errorf("Streamed query returned %d lines, first %q, last %q", len(lines), lines[0], lines[len(lines)-1])
+	}
+
+	if resp := c.do("POST", "/v1/query?format=ndjson", map[string]string{"sql": "DELETE FROM nums WHERE n = 5"}, ""); !strings.Contains(string(resp.body), `"rows_affected":1`) {
+		t.Errorf("NDJSON DELETE returned %s", resp.body)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	base := startServer(t)
+	c := &client{t: t, base: base, user: "admin", pass: "admin"}
+	c.mustQuery("CREATE TABLE t (id INT)")
+
+	count := func() interface{} {
+		t.Helper()
+		outside := &client{t: t, base: base, user: "admin", pass: "admin"}
+		return outside.mustQuery("SELECT COUNT(*) FROM t")["rows"].([]interface{})[0].([]interface{})[0]
+	}
+	begin := func(body interface{}) string {
+		t.Helper()
+		resp := c.do("POST", "/v1/sessions", body, "")
+		if resp.status != http.StatusCreated {
+			t.Fatalf("POST /v1/sessions returned %d: %s", resp.status, resp.body)
+		}
+		return resp.json(t)["session"].(string)
+	}
+
+	// Rolled back writes are discarded
+	id := begin(nil)
+	c.session = id
+	c.mustQuery("INSERT INTO t VALUES ($1)", 1)
+	c.session = ""
+	if n := count(); n != float64(0) {
+		t.Errorf("Uncommitted row visible outside the session: %v rows", n)
+	}
+	other := &client{t: t, base: base, user: "app", pass: "secret"}
+	if resp := other.do("POST", "/v1/sessions/"+id+"/rollback", nil, ""); resp.status != http.StatusNotFound {
+		t.Errorf("Another user ended the session: %d", resp.status)
+	}
+	if resp := c.do("POST", "/v1/sessions/"+id+"/rollback", nil, ""); resp.status != http.StatusOK {
+		t.Fatalf("Rollback returned %d: %s", resp.status, resp.body)
+	}
+	if n := count(); n != float64(0) {
+		t.Errorf("Table has %v rows after rollback, want 0", n)
+	}
+
+	// Committed writes are kept
+	id = begin(map[string]interface{}{"isolation": "serializable"})
+	c.session = id
+	c.mustQuery("INSERT INTO t VALUES ($1)", 2)
+	c.session = ""
+	if resp := c.do("POST", "/v1/sessions/"+id+"/commit", nil, ""); resp.status != http.StatusOK {
+		t.Fatalf("Commit returned %d: %s", resp.status, resp.body)
+	}
+	if n := count(); n != float64(1) {
+		t.Errorf("Table has %v rows after commit, want 1", n)
+	}
+
+	// Ended sessions are gone
+	if resp := c.do("POST", "/v1/sessions/"+id+"/commit", nil, ""); resp.status != http.StatusNotFound {
+		t.Errorf("Second commit returned %d", resp.status)
+	}
+	c.session = id
+	if resp := c.query("SELECT id FROM t"); resp.status != http.StatusNotFound {
+		t.Errorf("Query in an ended session returned %d", resp.status)
+	}
+
+	// Read-only sessions reject writes
+	c.session = begin(map[string]interface{}{"read_only": true})
+	if resp := c.query("INSERT INTO t VALUES (3)"); resp.status != http.StatusBadRequest {
+		t.Errorf("Write in a read-only session returned %d: %s", resp.status, resp.body)
+	}
+	if resp := c.do("POST", "/v1/sessions", map[string]interface{}{"isolation": "chaos"}, ""); resp.status != http.StatusBadRequest {
+		t.Errorf("Unknown isolation level returned %d", resp.status)
+	}
+}
@@ -0,0 +1,371 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"flydb/internal/errors"
+	"flydb/internal/literal"
+	"flydb/internal/protocol"
+	"flydb/internal/sql"
+)
+
+// ndjsonType is the media type of newline-delimited JSON results.
+const ndjsonType = "application/x-ndjson"
+
+// flushRows is how many rows are written between flushes of a result.
+const flushRows = 256
+
+// queryRequest is the body of POST /v1/query.
+type queryRequest struct {
+	SQL      string        `json:"sql"`
+	Params   []interface{} `json:"params"`
+	Database string        `json:"database"`
+	Timeout  string        `json:"timeout"` // Statement timeout such as "5s"
+}
+
+// column describes a result column.
+type column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// handleQuery runs a statement and writes its result.
+func (h *Handler) handleQuery(w http.ResponseWriter, r *http.Request, user string) {
+	var req queryRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if strings.TrimSpace(req.SQL) == "" {
+		writeError(w, errors.MissingRequired("sql"))
+		return
+	}
+	params := make([]interface{}, len(req.Params))
+	for i, p := range req.Params {
+		param, err := paramValue(p)
+		if err != nil {
+			writeError(w, errors.InvalidValue(fmt.Sprintf("params[%d]", i), err.Error()))
+			return
+		}
+		params[i] = param
+	}
+	query := sql.BindParameters(req.SQL, params)
+	if err := checkStatement(query); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx := r.Context()
+	if req.Timeout != "" {
+		timeout, err := time.ParseDuration(req.Timeout)
+		if err != nil || timeout <= 0 {
+			writeError(w, errors.InvalidValue("timeout", "expected a positive duration such as \"5s\""))
+			return
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	rw := newResultWriter(w, r)
+	result, err := h.execute(ctx, r, user, req.Database, query, rw)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = errors.QueryCanceled("statement timeout")
+		}
+		rw.fail(err)
+		return
+	}
+	rw.finish(result)
+}
+
+// execute runs a statement, in the request's session if it names one, and
+// hands the rows of its result to stream.
+func (h *Handler) execute(ctx context.Context, r *http.Request, user, database, query string, stream protocol.RowStream) (*protocol.QueryResult, error) {
+	id := r.Header.Get(SessionHeader)
+	if id == "" {
+		database, err := h.database(database)
+		if err != nil {
+			return nil, err
+		}
+		result, err := h.run(ctx, query, database, user, stream)
+		if err != nil {
+			return nil, err
+		}
+		if h.commits != nil {
+			if err := h.commits.SyncCommit(""); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+
+	s, err := h.lookupSession(id, user)
+	if err != nil {
+		return nil, err
+	}
+	if database != "" && database != s.database {
+		return nil, errors.NewTransactionError("a transaction cannot span databases").
+			WithDetail(fmt.Sprintf("session began in %q", s.database))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return nil, sessionNotFound(id)
+	}
+	return h.run(protocol.ContextWithTransaction(ctx, s.txID), query, s.database, user, stream)
+}
+
+// run executes a query and hands the rows of its result to stream, as the
+// executor produces them if it can stream and once it is done otherwise.
+func (h *Handler) run(ctx context.Context, query, database, user string, stream protocol.RowStream) (*protocol.QueryResult, error) {
+	if executor, ok := h.executor.(protocol.StreamQueryExecutor); ok {
+		return executor.ExecuteStreamContext(ctx, query, database, user, stream)
+	}
+	result, err := h.executor.ExecuteResultContext(ctx, query, database, user)
+	if err != nil || result.Columns == nil {
+		return result, err
+	}
+	if err := stream.Columns(result.Columns, result.ColumnTypes); err != nil {
+		return nil, err
+	}
+	for _, row := range result.Rows {
+		if err := stream.Row(row); err != nil {
+			return nil, err
+		}
+	}
+	result.Rows = nil
+	return result, nil
+}
+
+// database returns the database a request names, checking that it exists.
+func (h *Handler) database(name string) (string, error) {
+	if name == "" {
+		return "default", nil
+	}
+	if h.dbMgr != nil && !h.dbMgr.DatabaseExists(name) {
+		return "", errors.InvalidValue("database", fmt.Sprintf("database %q does not exist", name))
+	}
+	return name, nil
+}
+
+// checkStatement rejects transaction control statements, which would
+// otherwise act on the shared executor; sessions replace them. The query is
+// classified by its parsed statement, so comments cannot hide one; queries
+// that do not parse are left for the executor to report.
+func checkStatement(query string) error {
+	stmt, err := sql.NewParser(sql.NewLexer(query)).Parse()
+	if err != nil {
+		return nil
+	}
+	var name string
+	switch s := stmt.(type) {
+	case *sql.BeginStmt:
+		name = "BEGIN"
+	case *sql.CommitStmt:
+		name = "COMMIT"
+	case *sql.RollbackStmt:
+		if s.ToSavepoint != "" {
+			return nil
+		}
+		name = "ROLLBACK"
+	default:
+		return nil
+	}
+	return errors.NewTransactionError(fmt.Sprintf("%s is not supported over HTTP", name)).
+		WithHint("Use POST /v1/sessions to begin a transaction and the X-FlyDB-Session header to run statements in it")
+}
+
+// paramValue converts a JSON parameter to a value for sql.BindParameters.
+// Numbers the SQL lexer cannot read bare, such as negative numbers, are
+// bound as strings, which numeric columns accept; objects and arrays are
+// bound as their JSON text.
+func paramValue(p interface{}) (interface{}, error) {
+	switch v := p.(type) {
+	case nil, string, bool:
+		return v, nil
+	case json.Number:
+		if literal.IsNumber(string(v)) {
+			return v, nil
+		}
+		return string(v), nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	}
+}
+
+// wantsNDJSON reports whether a request asks for newline-delimited JSON.
+func wantsNDJSON(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "ndjson"
+	}
+	return strings.Contains(r.Header.Get("Accept"), ndjsonType)
+}
+
+// resultWriter writes a statement result as a JSON document or as NDJSON.
+// It is the stream the statement's rows are handed to: the columns and each
+// row are written as the executor produces them and flushed every flushRows
+// rows, so large results reach the client without being held in memory.
+type resultWriter struct {
+	w       http.ResponseWriter
+	r       *http.Request
+	bw      *bufio.Writer
+	rc      *http.ResponseController
+	ndjson  bool
+	started bool // Whether the columns, and so the status, were written
+	rows    int
+}
+
+// newResultWriter returns a writer for the result of the request r.
+func newResultWriter(w http.ResponseWriter, r *http.Request) *resultWriter {
+	return &resultWriter{
+		w:      w,
+		r:      r,
+		bw:     bufio.NewWriter(w),
+		rc:     http.NewResponseController(w),
+		ndjson: wantsNDJSON(r),
+	}
+}
+
+// setContentType sets the media type of the response.
+func (rw *resultWriter) setContentType() {
+	if rw.ndjson {
+		rw.w.Header().Set("Content-Type", ndjsonType)
+	} else {
+		rw.w.Header().Set("Content-Type", "application/json")
+	}
+}
+
+// Columns writes the result columns.
+func (rw *resultWriter) Columns(names, types []string) error {
+	rw.setContentType()
+	rw.started = true
+	columns := make([]column, len(names))
+	for i, name := range names {
+		columns[i] = column{Name: name}
+		if i < len(types) {
+			columns[i].Type = types[i]
+		}
+	}
+	header, _ := json.Marshal(columns)
+	if rw.ndjson {
+		fmt.Fprintf(rw.bw, "{\"columns\":%s}\n", header)
+	} else {
+		fmt.Fprintf(rw.bw, "{\"columns\":%s,\"rows\":[", header)
+	}
+	return nil
+}
+
+// Row writes a result row. It fails once the client is gone, which stops
+// the statement.
+func (rw *resultWriter) Row(values []interface{}) error {
+	data, err := json.Marshal(rowValues(values))
+	if err != nil {
+		return errors.InternalError("failed to encode row: " + err.Error())
+	}
+	if !rw.ndjson && rw.rows > 0 {
+		rw.bw.WriteByte(',')
+	}
+	rw.bw.Write(data)
+	if rw.ndjson {
+		rw.bw.WriteByte('\n')
+	}
+	if rw.rows++; rw.rows%flushRows == 0 {
+		if err := rw.bw.Flush(); err != nil {
+			return err
+		}
+		if err := rw.r.Context().Err(); err != nil {
+			return err
+		}
+		rw.rc.Flush()
+	}
+	return nil
+}
+
+// finish writes the end of a successful result: the row count after the
+// rows of a tabular result, or the summary of any other.
+func (rw *resultWriter) finish(result *protocol.QueryResult) {
+	if !rw.started {
+		rw.setContentType()
+		summary := map[string]interface{}{
+			"message":       result.Message,
+			"rows_affected": result.RowsAffected,
+		}
+		if result.LastInsertID != 0 {
+			summary["last_insert_id"] = result.LastInsertID
+		}
+		data, _ := json.Marshal(summary)
+		rw.bw.Write(data)
+		rw.bw.WriteByte('\n')
+		rw.bw.Flush()
+		return
+	}
+	if rw.ndjson {
+		fmt.Fprintf(rw.bw, "{\"row_count\":%d}\n", rw.rows)
+	} else {
+		fmt.Fprintf(rw.bw, "],\"row_count\":%d}\n", rw.rows)
+	}
+	rw.bw.Flush()
+}
+
+// fail reports a statement error. Before the columns were written it is an
+// error response; after, the status is sent, so the error ends the stream.
+func (rw *resultWriter) fail(err error) {
+	if !rw.started {
+		writeError(rw.w, err)
+		return
+	}
+	log.Warn("Statement failed while streaming its result", "error", err)
+	failure, _ := json.Marshal(errorResponse{Error: toErrorBody(err)})
+	if !rw.ndjson {
+		rw.bw.WriteString("],")
+		failure = failure[1:]
+	}
+	rw.bw.Write(failure)
+	rw.bw.WriteByte('\n')
+	rw.bw.Flush()
+}
+
+// rowValues returns the values of a row as JSON can encode them: floats
+// that are not finite become strings.
+func rowValues(row []interface{}) []interface{} {
+	values := row
+	copied := false
+	for i, v := range row {
+		if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			if !copied {
+				values = append([]interface{}(nil), row...)
+				copied = true
+			}
+			values[i] = fmt.Sprint(f)
+		}
+	}
+	return values
+}
@@ -0,0 +1,215 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"flydb/internal/errors"
+)
+
+// SessionHeader carries the session a query runs in.
+const SessionHeader = "X-FlyDB-Session"
+
+// sessionIdleTimeout is how long a session may go without requests before
+// its transaction is rolled back.
+const sessionIdleTimeout = 5 * time.Minute
+
+// maxSessions bounds the number of open sessions, as each holds a
+// transaction open.
+const maxSessions = 1024
+
+// session is a transaction that requests run in by naming its ID.
+type session struct {
+	id       string
+	user     string
+	database string
+	txID     string
+	timer    *time.Timer // Rolls the session back when idle
+
+	// mu serializes the statements of the session. ended is set, under
+	// mu, once the transaction has been committed or rolled back.
+	mu    sync.Mutex
+	ended bool
+}
+
+// beginRequest is the body of POST /v1/sessions.
+type beginRequest struct {
+	Database  string `json:"database"`
+	Isolation string `json:"isolation"` // e.g. "serializable"; default read committed
+	ReadOnly  bool   `json:"read_only"`
+}
+
+// sessionResponse is the response of the session endpoints.
+type sessionResponse struct {
+	Session string `json:"session"`
+	Status  string `json:"status"`
+}
+
+// isolationLevels maps isolation level names to the levels of
+// protocol.TransactionManager.Begin.
+var isolationLevels = map[string]int{
+	"read uncommitted": 0,
+	"read committed":   1,
+	"repeatable read":  2,
+	"serializable":     3,
+}
+
+// sessionNotFound is the error for an unknown, ended or expired session.
+func sessionNotFound(id string) error {
+	return &errors.FlyDBError{
+		Code:     errors.ErrCodeSessionExpired,
+		Category: errors.CategoryTransaction,
+		Message:  fmt.Sprintf("session %q does not exist", id),
+		Detail:   "it was committed, rolled back or expired after being idle",
+		Hint:     "Begin a new session with POST /v1/sessions",
+	}
+}
+
+// handleBegin begins a transaction session.
+func (h *Handler) handleBegin(w http.ResponseWriter, r *http.Request, user string) {
+	if h.txMgr == nil {
+		writeError(w, errors.NewTransactionError("transactions are not supported"))
+		return
+	}
+	var req beginRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	isolation := 1
+	if req.Isolation != "" {
+		level, ok := isolationLevels[strings.ToLower(strings.Join(strings.Fields(req.Isolation), " "))]
+		if !ok {
+			writeError(w, errors.InvalidValue("isolation", "expected read uncommitted, read committed, repeatable read or serializable"))
+			return
+		}
+		isolation = level
+	}
+	database, err := h.database(req.Database)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.mu.Lock()
+	full := len(h.sessions) >= maxSessions
+	h.mu.Unlock()
+	if full {
+		writeJSON(w, http.StatusTooManyRequests, errorResponse{Error: toErrorBody(
+			errors.NewTransactionError("too many open sessions").WithHint("Commit or roll back open sessions"))})
+		return
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		writeError(w, err)
+		return
+	}
+	txID, err := h.txMgr.Begin(database, isolation, req.ReadOnly)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s := &session{id: hex.EncodeToString(id), user: user, database: database, txID: txID}
+	h.mu.Lock()
+	h.sessions[s.id] = s
+	s.timer = time.AfterFunc(sessionIdleTimeout, func() {
+		log.Debug("Rolling back idle HTTP session", "session", s.id, "user", s.user)
+		h.endSession(s, false)
+	})
+	h.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, sessionResponse{Session: s.id, Status: "active"})
+}
+
+// handleCommit commits a session's transaction and ends the session.
+func (h *Handler) handleCommit(w http.ResponseWriter, r *http.Request, user string) {
+	h.finish(w, r, user, true)
+}
+
+// handleRollback rolls a session's transaction back and ends the session.
+func (h *Handler) handleRollback(w http.ResponseWriter, r *http.Request, user string) {
+	h.finish(w, r, user, false)
+}
+
+// finish commits or rolls back the session named in the request path.
+func (h *Handler) finish(w http.ResponseWriter, r *http.Request, user string, commit bool) {
+	id := r.PathValue("id")
+	s, err := h.lookupSession(id, user)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ended, err := h.endSession(s, commit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if !ended {
+		writeError(w, sessionNotFound(id))
+		return
+	}
+	if commit {
+		writeJSON(w, http.StatusOK, sessionResponse{Session: id, Status: "committed"})
+	} else {
+		writeJSON(w, http.StatusOK, sessionResponse{Session: id, Status: "rolled back"})
+	}
+}
+
+// lookupSession returns an open session of user and keeps it from
+// expiring. Sessions of other users are reported as missing.
+func (h *Handler) lookupSession(id, user string) (*session, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.sessions[id]
+	if !ok || s.user != user {
+		return nil, sessionNotFound(id)
+	}
+	s.timer.Reset(sessionIdleTimeout)
+	return s, nil
+}
+
+// endSession commits or rolls back a session's transaction once any
+// running statement completes, and forgets the session. ended is false if
+// the session had already ended.
+func (h *Handler) endSession(s *session, commit bool) (ended bool, err error) {
+	h.mu.Lock()
+	if h.sessions[s.id] == s {
+		delete(h.sessions, s.id)
+	}
+	s.timer.Stop()
+	h.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return false, nil
+	}
+	s.ended = true
+	if commit {
+		return true, h.txMgr.Commit(s.txID)
+	}
+	return true, h.txMgr.Rollback(s.txID)
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package literal formats values as FlyDB SQL literals.
+
+The drivers and wire protocols bind parameters by substituting literals for
+their placeholders, so they share these rules: strings are quoted with
+embedded quotes doubled, and only numbers the lexer reads as numbers are
+inlined bare. Negative numbers and exponents are not among them; quoted,
+they are still accepted by numeric columns.
+*/
+package literal
+
+import "strings"
+
+// Quote returns s as a string literal.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// IsNumber reports whether s is an unsigned decimal number as the lexer
+// reads it, such as 42 or 3.14.
+func IsNumber(s string) bool {
+	intPart, fracPart, hasPoint := strings.Cut(s, ".")
+	return IsDigits(intPart) && (!hasPoint || IsDigits(fracPart))
+}
+
+// IsDigits reports whether s is a non-empty string of decimal digits.
+func IsDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
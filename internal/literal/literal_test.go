@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package literal
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	if got := Quote("it's"); got != "'it''s'" {
+		t.Errorf("Quote = %s, want 'it''s'", got)
+	}
+}
+
+func TestIsNumber(t *testing.T) {
+	tests := map[string]bool{
+		"42":    true,
+		"3.14":  true,
+		"0":     true,
+		"":      false,
+		".5":    false,
+		"5.":    false,
+		"-1":    false,
+		"1e5":   false,
+		"1.2.3": false,
+		"12a":   false,
+	}
+	for s, want := range tests {
+		if got := IsNumber(s); got != want {
+			t.Errorf("IsNumber(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
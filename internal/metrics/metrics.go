@@ -27,6 +27,7 @@ METRIC CATEGORIES:
 - Storage: database size, WAL size
 - Replication: lag, sync status
 - Cluster: nodes, leader status
+- TTL: reaper runs, expired rows, errors
 
 PROMETHEUS ENDPOINT:
 ====================
@@ -97,6 +98,18 @@ type Metrics struct {
 	ClusterNodes       atomic.Int64
 	IsLeader           atomic.Bool
 
+	// TTL reaper metrics
+	TTLReaperRuns      atomic.Uint64 // Completed reaper passes
+	TTLRowsExpired     atomic.Uint64 // Rows deleted because they expired
+	TTLReaperErrors    atomic.Uint64 // Reaper passes that failed on a database
+	TTLLastRunUnix     atomic.Int64  // Unix time of the last reaper pass
+
+	// Anti-entropy repair metrics
+	RepairRuns           atomic.Uint64 // Finished partition repairs
+	RepairRangesStreamed atomic.Uint64 // Differing key ranges streamed to replicas
+	RepairKeysStreamed   atomic.Uint64 // Keys streamed to replicas
+	RepairsActive        atomic.Int64  // Repairs in progress
+
 	// Per-database metrics
 	dbMetrics sync.Map // database name -> *DatabaseMetrics
 }
@@ -149,6 +162,24 @@ func (m *Metrics) RecordQueryError() {
 	m.QueriesFailed.Add(1)
 }
 
+// RecordTTLRun records a TTL reaper pass that expired the given number of
+// rows and failed on errs databases.
+func (m *Metrics) RecordTTLRun(expired int, errs int) {
+	m.TTLReaperRuns.Add(1)
+	m.TTLRowsExpired.Add(uint64(expired))
+	m.TTLReaperErrors.Add(uint64(errs))
+	m.TTLLastRunUnix.Store(time.Now().Unix())
+}
+
+// SetRepairProgress records the anti-entropy repair totals reported by
+// the cluster manager.
+func (m *Metrics) SetRepairProgress(runs, ranges, keys uint64, active int64) {
+	m.RepairRuns.Store(runs)
+	m.RepairRangesStreamed.Store(ranges)
+	m.RepairKeysStreamed.Store(keys)
+	m.RepairsActive.Store(active)
+}
+
 // ConnectionOpened records a new connection.
 func (m *Metrics) ConnectionOpened() {
 	m.ActiveConnections.Add(1)
@@ -309,5 +340,39 @@ func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "# HELP flydb_is_leader Is this node the cluster leader (1=yes, 0=no)\n")
 	fmt.Fprintf(w, "# TYPE flydb_is_leader gauge\n")
 	fmt.Fprintf(w, "flydb_is_leader %d\n", isLeader)
+
+	// TTL reaper metrics
+	fmt.Fprintf(w, "# HELP flydb_ttl_reaper_runs_total Completed TTL reaper passes\n")
+	fmt.Fprintf(w, "# TYPE flydb_ttl_reaper_runs_total counter\n")
+	fmt.Fprintf(w, "flydb_ttl_reaper_runs_total %d\n", m.TTLReaperRuns.Load())
+
+	fmt.Fprintf(w, "# HELP flydb_ttl_rows_expired_total Rows deleted by the TTL reaper\n")
+	fmt.Fprintf(w, "# TYPE flydb_ttl_rows_expired_total counter\n")
+	fmt.Fprintf(w, "flydb_ttl_rows_expired_total %d\n", m.TTLRowsExpired.Load())
+
+	fmt.Fprintf(w, "# HELP flydb_ttl_reaper_errors_total TTL reaper failures\n")
+	fmt.Fprintf(w, "# TYPE flydb_ttl_reaper_errors_total counter\n")
+	fmt.Fprintf(w, "flydb_ttl_reaper_errors_total %d\n", m.TTLReaperErrors.Load())
+
+	fmt.Fprintf(w, "# HELP flydb_ttl_reaper_last_run_timestamp_seconds Unix time of the last TTL reaper pass\n")
+	fmt.Fprintf(w, "# TYPE flydb_ttl_reaper_last_run_timestamp_seconds gauge\n")
+	fmt.Fprintf(w, "flydb_ttl_reaper_last_run_timestamp_seconds %d\n", m.TTLLastRunUnix.Load())
+
+	// Anti-entropy repair metrics
+	fmt.Fprintf(w, "# HELP flydb_repair_runs_total Finished partition repairs\n")
+	fmt.Fprintf(w, "# TYPE flydb_repair_runs_total counter\n")
+	fmt.Fprintf(w, "flydb_repair_runs_total %d\n", m.RepairRuns.Load())
+
+	fmt.Fprintf(w, "# HELP flydb_repair_ranges_streamed_total Differing key ranges streamed to replicas\n")
+	fmt.Fprintf(w, "# TYPE flydb_repair_ranges_streamed_total counter\n")
+	fmt.Fprintf(w, "flydb_repair_ranges_streamed_total %d\n", m.RepairRangesStreamed.Load())
+
+	fmt.Fprintf(w, "# HELP flydb_repair_keys_streamed_total Keys streamed to replicas by repairs\n")
+	fmt.Fprintf(w, "# TYPE flydb_repair_keys_streamed_total counter\n")
+	fmt.Fprintf(w, "flydb_repair_keys_streamed_total %d\n", m.RepairKeysStreamed.Load())
+
+	fmt.Fprintf(w, "# HELP flydb_repairs_active Partition repairs in progress\n")
+	fmt.Fprintf(w, "# TYPE flydb_repairs_active gauge\n")
+	fmt.Fprintf(w, "flydb_repairs_active %d\n", m.RepairsActive.Load())
 }
 
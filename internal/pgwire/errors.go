@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pgwire
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+
+	"flydb/internal/errors"
+)
+
+// pgError is an error reported to the client with a PostgreSQL SQLSTATE.
+type pgError struct {
+	severity string // ERROR if empty
+	code     string
+	message  string
+	detail   string
+	hint     string
+}
+
+// Error implements the error interface.
+func (e *pgError) Error() string {
+	return e.message
+}
+
+// response returns the ErrorResponse of the error.
+func (e *pgError) response() *message {
+	return e.fields(newMessage(msgErrorResponse))
+}
+
+// fields appends the fields of an ErrorResponse or NoticeResponse to m.
+func (e *pgError) fields(m *message) *message {
+	severity := e.severity
+	if severity == "" {
+		severity = "ERROR"
+	}
+	m.byte('S').string(severity)
+	m.byte('V').string(severity)
+	m.byte('C').string(e.code)
+	m.byte('M').string(e.message)
+	if e.detail != "" {
+		m.byte('D').string(e.detail)
+	}
+	if e.hint != "" {
+		m.byte('H').string(e.hint)
+	}
+	return m.byte(0)
+}
+
+// pgErrorCodes maps FlyDB error codes whose SQLSTATE is not specific
+// enough for PostgreSQL clients, such as those reported as HY000.
+var pgErrorCodes = map[errors.ErrorCode]string{
+	errors.ErrCodeTableAlreadyExists:        "42P07",
+	errors.ErrCodeColumnAlreadyExists:       "42701",
+	errors.ErrCodeConstraintAlreadyExists:   "42710",
+	errors.ErrCodeConstraintNotFound:        "42704",
+	errors.ErrCodeIndexAlreadyExists:        "42P07",
+	errors.ErrCodeIndexNotFound:             "42704",
+	errors.ErrCodeProcedureAlreadyExists:    "42723",
+	errors.ErrCodeProcedureNotFound:         "42883",
+	errors.ErrCodeViewAlreadyExists:         "42P07",
+	errors.ErrCodeViewNotFound:              "42P01",
+	errors.ErrCodeTriggerAlreadyExists:      "42710",
+	errors.ErrCodeTriggerNotFound:           "42704",
+	errors.ErrCodePreparedStatementExists:   "42P05",
+	errors.ErrCodePreparedStatementNotFound: "26000",
+	errors.ErrCodeInvalidJSON:               "22P02",
+	errors.ErrCodeTxDeadlock:                "40P01",
+}
+
+// pgStates maps the ODBC-specific SQLSTATEs FlyDB uses to their
+// PostgreSQL equivalents.
+var pgStates = map[errors.SQLSTATE]string{
+	errors.SQLStateSyntaxError:         "42601",
+	errors.SQLStateTableNotFound:       "42P01",
+	errors.SQLStateColumnNotFound:      "42703",
+	errors.SQLStateTableAlreadyExists:  "42P07",
+	errors.SQLStateColumnAlreadyExists: "42701",
+	errors.SQLStateIndexNotFound:       "42704",
+}
+
+// toPGError converts an error to the error reported to the client.
+func toPGError(err error) *pgError {
+	var pe *pgError
+	if stderrors.As(err, &pe) {
+		return pe
+	}
+	switch {
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return &pgError{code: "57014", message: "canceling statement due to statement timeout"}
+	case stderrors.Is(err, context.Canceled):
+		return &pgError{code: "57014", message: "canceling statement due to user request"}
+	}
+
+	var fe *errors.FlyDBError
+	if !stderrors.As(err, &fe) {
+		return &pgError{code: "XX000", message: err.Error()}
+	}
+	code, ok := pgErrorCodes[fe.Code]
+	if !ok {
+		state := errors.ToSQLSTATE(fe.Code)
+		if code, ok = pgStates[state]; !ok {
+			code = string(state)
+			// CLI-specific (ODBC) classes have no PostgreSQL meaning
+			if strings.HasPrefix(code, "HY") {
+				code = "XX000"
+			}
+		}
+	}
+	return &pgError{code: code, message: fe.Message, detail: fe.Detail, hint: fe.Hint}
+}
+
+// sendError sends an ErrorResponse. An error inside a transaction block
+// aborts the block until it is rolled back.
+func (s *session) sendError(err error) error {
+	if s.txID != "" {
+		s.txFailed = true
+	}
+	return s.send(toPGError(err).response())
+}
+
+// sendNotice sends a NoticeResponse warning.
+func (s *session) sendNotice(code, message string) error {
+	e := &pgError{severity: "WARNING", code: code, message: message}
+	return s.send(e.fields(newMessage(msgNoticeResponse)))
+}
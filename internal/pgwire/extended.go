@@ -0,0 +1,247 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pgwire
+
+import (
+	"fmt"
+)
+
+// errInvalidMessage is reported for extended query messages that do not
+// parse.
+var errInvalidMessage = &pgError{code: "08P01", message: "invalid message format"}
+
+// handleParse creates a prepared statement.
+func (s *session) handleParse(body []byte) error {
+	r := &reader{buf: body}
+	name := r.string()
+	query := r.string()
+	n := int(r.int16())
+	oids := make([]uint32, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		oids = append(oids, uint32(r.int32()))
+	}
+	if r.err != nil {
+		return errInvalidMessage
+	}
+
+	stmts := splitStatements(query)
+	if len(stmts) > 1 {
+		return &pgError{code: "42601", message: "cannot insert multiple commands into a prepared statement"}
+	}
+	query = ""
+	if len(stmts) == 1 {
+		query = stmts[0]
+	}
+	if _, exists := s.statements[name]; exists && name != "" {
+		return &pgError{code: "42P05", message: fmt.Sprintf("prepared statement %q already exists", name)}
+	}
+
+	// Parameters the client left unspecified are inferred from their use
+	nparams := len(oids)
+	for _, p := range placeholders(query) {
+		nparams = max(nparams, p.index)
+	}
+	for len(oids) < nparams {
+		oids = append(oids, oidUnspecified)
+	}
+
+	s.statements[name] = &preparedStatement{query: query, paramOIDs: oids}
+	return s.send(newMessage(msgParseComplete))
+}
+
+// handleBind binds parameters to a prepared statement, creating a portal.
+func (s *session) handleBind(body []byte) error {
+	r := &reader{buf: body}
+	portalName := r.string()
+	stmtName := r.string()
+	paramFormats := make([]int16, max(int(r.int16()), 0))
+	for i := range paramFormats {
+		paramFormats[i] = r.int16()
+	}
+	values := make([][]byte, max(int(r.int16()), 0))
+	for i := range values {
+		if length := r.int32(); length >= 0 {
+			values[i] = r.bytes(int(length))
+			if values[i] == nil {
+				values[i] = []byte{}
+			}
+		}
+	}
+	resultFormats := make([]int16, max(int(r.int16()), 0))
+	for i := range resultFormats {
+		resultFormats[i] = r.int16()
+	}
+	if r.err != nil {
+		return errInvalidMessage
+	}
+
+	stmt, ok := s.statements[stmtName]
+	if !ok {
+		return &pgError{code: "26000", message: fmt.Sprintf("prepared statement %q does not exist", stmtName)}
+	}
+	if len(values) != len(stmt.paramOIDs) {
+		return &pgError{code: "08P01", message: fmt.Sprintf("bind message supplies %d parameters, but prepared statement %q requires %d", len(values), stmtName, len(stmt.paramOIDs))}
+	}
+	if len(paramFormats) > 1 && len(paramFormats) != len(values) {
+		return &pgError{code: "08P01", message: fmt.Sprintf("bind message has %d parameter formats but %d parameters", len(paramFormats), len(values))}
+	}
+	if _, exists := s.portals[portalName]; exists && portalName != "" {
+		return &pgError{code: "42P03", message: fmt.Sprintf("cursor %q already exists", portalName)}
+	}
+
+	literals := make([]string, len(values))
+	for i, value := range values {
+		literal, err := paramLiteral(value, formatCode(paramFormats, i), stmt.paramOIDs[i])
+		if err != nil {
+			return &pgError{code: "22P02", message: fmt.Sprintf("parameter $%d: %v", i+1, err)}
+		}
+		literals[i] = literal
+	}
+	query, err := bindParams(stmt.query, literals)
+	if err != nil {
+		return err
+	}
+
+	s.portals[portalName] = &portal{stmt: stmt, query: query, resultFormats: resultFormats}
+	return s.send(newMessage(msgBindComplete))
+}
+
+// handleDescribe describes a prepared statement or a portal.
+func (s *session) handleDescribe(body []byte) error {
+	r := &reader{buf: body}
+	kind := r.byte()
+	name := r.string()
+	if r.err != nil {
+		return errInvalidMessage
+	}
+
+	switch kind {
+	case 'S':
+		stmt, ok := s.statements[name]
+		if !ok {
+			return &pgError{code: "26000", message: fmt.Sprintf("prepared statement %q does not exist", name)}
+		}
+		m := newMessage(msgParameterDescription).int16(len(stmt.paramOIDs))
+		for _, oid := range stmt.paramOIDs {
+			if oid == oidUnspecified {
+				oid = oidText
+			}
+			m.int32(int(oid))
+		}
+		if err := s.send(m); err != nil {
+			return err
+		}
+		if columns := s.describeStatement(stmt); columns != nil {
+			return s.sendRowDescription(columns, nil)
+		}
+		return s.send(newMessage(msgNoData))
+
+	case 'P':
+		p, ok := s.portals[name]
+		if !ok {
+			return &pgError{code: "34000", message: fmt.Sprintf("portal %q does not exist", name)}
+		}
+		// Reads run now so that their columns are known; Execute then
+		// sends the rows
+		if p.result == nil && isRead(p.query) {
+			result, err := s.execute(p.query)
+			if err != nil {
+				return err
+			}
+			p.result = result
+		}
+		if p.result != nil && p.result.columns != nil {
+			return s.sendRowDescription(p.result.columns, p.resultFormats)
+		}
+		return s.send(newMessage(msgNoData))
+	}
+	return errInvalidMessage
+}
+
+// describeStatement returns the result columns of a prepared statement, or
+// nil if it returns no rows or they cannot be found. Reads are run with
+// all parameters NULL, and then 1 for statements such as LIMIT $1 that
+// reject NULL.
+func (s *session) describeStatement(stmt *preparedStatement) []column {
+	if stmt.described || s.txFailed {
+		return stmt.columns
+	}
+	stmt.described = true
+	if !isRead(stmt.query) {
+		return nil
+	}
+
+	for _, value := range []string{"NULL", "1"} {
+		literals := make([]string, len(stmt.paramOIDs))
+		for i := range literals {
+			literals[i] = value
+		}
+		query, err := bindParams(stmt.query, literals)
+		if err != nil {
+			return nil
+		}
+		if result, err := s.execute(query); err == nil {
+			stmt.columns = result.columns
+			return stmt.columns
+		}
+	}
+	return nil
+}
+
+// handleExecute runs a portal, or sends more of its rows.
+func (s *session) handleExecute(body []byte) error {
+	r := &reader{buf: body}
+	name := r.string()
+	maxRows := int(r.int32())
+	if r.err != nil {
+		return errInvalidMessage
+	}
+
+	p, ok := s.portals[name]
+	if !ok {
+		return &pgError{code: "34000", message: fmt.Sprintf("portal %q does not exist", name)}
+	}
+	if p.result == nil {
+		result, err := s.execute(p.query)
+		if err != nil {
+			return err
+		}
+		p.result = result
+	}
+	return s.sendRows(p.result, p, maxRows)
+}
+
+// handleClose closes a prepared statement or a portal. Closing one that
+// does not exist is not an error.
+func (s *session) handleClose(body []byte) error {
+	r := &reader{buf: body}
+	kind := r.byte()
+	name := r.string()
+	if r.err != nil {
+		return errInvalidMessage
+	}
+
+	switch kind {
+	case 'S':
+		delete(s.statements, name)
+	case 'P':
+		delete(s.portals, name)
+	default:
+		return errInvalidMessage
+	}
+	return s.send(newMessage(msgCloseComplete))
+}
@@ -0,0 +1,240 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pgwire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Startup packet codes. The protocol version is the major version in the
+// high 16 bits and the minor version in the low 16 bits.
+const (
+	protocolVersion3  = 3 << 16
+	cancelRequestCode = 80877102
+	sslRequestCode    = 80877103
+	gssEncRequestCode = 80877104
+)
+
+// Frontend message types.
+const (
+	msgBind      byte = 'B'
+	msgClose     byte = 'C'
+	msgDescribe  byte = 'D'
+	msgExecute   byte = 'E'
+	msgFlush     byte = 'H'
+	msgParse     byte = 'P'
+	msgPassword  byte = 'p' // Also SASLInitialResponse and SASLResponse
+	msgQuery     byte = 'Q'
+	msgSync      byte = 'S'
+	msgTerminate byte = 'X'
+)
+
+// Backend message types.
+const (
+	msgAuthentication       byte = 'R'
+	msgBackendKeyData       byte = 'K'
+	msgBindComplete         byte = '2'
+	msgCloseComplete        byte = '3'
+	msgCommandComplete      byte = 'C'
+	msgDataRow              byte = 'D'
+	msgEmptyQueryResponse   byte = 'I'
+	msgErrorResponse        byte = 'E'
+	msgNegotiateProtocol    byte = 'v'
+	msgNoData               byte = 'n'
+	msgNoticeResponse       byte = 'N'
+	msgParameterDescription byte = 't'
+	msgParameterStatus      byte = 'S'
+	msgParseComplete        byte = '1'
+	msgPortalSuspended      byte = 's'
+	msgReadyForQuery        byte = 'Z'
+	msgRowDescription       byte = 'T'
+)
+
+// Authentication request codes.
+const (
+	authOK           = 0
+	authSASL         = 10
+	authSASLContinue = 11
+	authSASLFinal    = 12
+)
+
+// Transaction status indicators of ReadyForQuery.
+const (
+	txIdle   byte = 'I'
+	txActive byte = 'T'
+	txFailed byte = 'E'
+)
+
+// maxStartupSize caps the startup packet, as PostgreSQL does.
+const maxStartupSize = 10000
+
+// maxMessageSize caps the other frontend messages.
+const maxMessageSize = 16 * 1024 * 1024
+
+// errMalformed is returned for messages that end before their fields do.
+var errMalformed = errors.New("malformed message")
+
+// readStartup reads a startup packet: its code and the rest of its body.
+func readStartup(r io.Reader) (code uint32, body []byte, err error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 8 || length > maxStartupSize {
+		return 0, nil, fmt.Errorf("invalid startup packet length %d", length)
+	}
+	body = make([]byte, length-8)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return binary.BigEndian.Uint32(header[4:]), body, nil
+}
+
+// readMessage reads a frontend message: its type and body.
+func readMessage(r io.Reader) (typ byte, body []byte, err error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length < 4 || length > maxMessageSize {
+		return 0, nil, fmt.Errorf("invalid message length %d", length)
+	}
+	body = make([]byte, length-4)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header[0], body, nil
+}
+
+// reader reads the fields of a message body. The first read past the end
+// of the body sets err; later reads return zero values.
+type reader struct {
+	buf []byte
+	err error
+}
+
+// byte reads a byte.
+func (r *reader) byte() byte {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+// int16 reads a 16-bit integer.
+func (r *reader) int16() int16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+// int32 reads a 32-bit integer.
+func (r *reader) int32() int32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+// string reads a null-terminated string.
+func (r *reader) string() string {
+	i := bytes.IndexByte(r.buf, 0)
+	if i < 0 {
+		r.fail()
+		return ""
+	}
+	s := string(r.buf[:i])
+	r.buf = r.buf[i+1:]
+	return s
+}
+
+// bytes reads n bytes.
+func (r *reader) bytes(n int) []byte {
+	if n < 0 || n > len(r.buf) {
+		r.fail()
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+// fail records a read past the end of the body.
+func (r *reader) fail() {
+	if r.err == nil {
+		r.err = errMalformed
+	}
+	r.buf = nil
+}
+
+// message builds a backend message.
+type message struct {
+	buf []byte
+}
+
+// newMessage starts a message of the given type.
+func newMessage(typ byte) *message {
+	return &message{buf: []byte{typ, 0, 0, 0, 0}}
+}
+
+// byte appends a byte.
+func (m *message) byte(b byte) *message {
+	m.buf = append(m.buf, b)
+	return m
+}
+
+// int16 appends a 16-bit integer.
+func (m *message) int16(v int) *message {
+	m.buf = binary.BigEndian.AppendUint16(m.buf, uint16(v))
+	return m
+}
+
+// int32 appends a 32-bit integer.
+func (m *message) int32(v int) *message {
+	m.buf = binary.BigEndian.AppendUint32(m.buf, uint32(v))
+	return m
+}
+
+// string appends a null-terminated string.
+func (m *message) string(s string) *message {
+	m.buf = append(m.buf, s...)
+	m.buf = append(m.buf, 0)
+	return m
+}
+
+// bytes appends raw bytes.
+func (m *message) bytes(b []byte) *message {
+	m.buf = append(m.buf, b...)
+	return m
+}
+
+// writeTo sets the length of the message and writes it.
+func (m *message) writeTo(w io.Writer) error {
+	binary.BigEndian.PutUint32(m.buf[1:5], uint32(len(m.buf)-1))
+	_, err := w.Write(m.buf)
+	return err
+}
@@ -0,0 +1,305 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pgwire
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"flydb/internal/literal"
+)
+
+// parameter is a session parameter clients can SET and SHOW.
+type parameter struct {
+	name     string
+	def      string // Default value
+	reported bool   // Sent in ParameterStatus at startup and on change
+	readOnly bool
+}
+
+// parameters are the session parameters of a connection. Most exist so
+// that drivers which set them at startup work; statement_timeout,
+// synchronous_commit and the default transaction modes take effect.
+var parameters = []parameter{
+	{name: "application_name", reported: true},
+	{name: "client_encoding", def: "UTF8", reported: true},
+	{name: "client_min_messages", def: "notice"},
+	{name: "DateStyle", def: "ISO, MDY", reported: true},
+	{name: "default_transaction_isolation", def: "read committed"},
+	{name: "default_transaction_read_only", def: "off"},
+	{name: "extra_float_digits", def: "1"},
+	{name: "integer_datetimes", def: "on", reported: true, readOnly: true},
+	{name: "IntervalStyle", def: "postgres", reported: true},
+	{name: "search_path", def: `"$user", public`},
+	{name: "server_encoding", def: "UTF8", reported: true, readOnly: true},
+	{name: "server_version", def: "14.0", reported: true, readOnly: true},
+	{name: "standard_conforming_strings", def: "on", reported: true},
+	{name: "statement_timeout", def: "0"},
+	{name: "synchronous_commit", def: "on"},
+	{name: "TimeZone", def: "UTC", reported: true},
+}
+
+// lookupParameter finds a parameter by its case-insensitive name.
+func lookupParameter(name string) (*parameter, error) {
+	for i := range parameters {
+		if strings.EqualFold(parameters[i].name, name) {
+			return &parameters[i], nil
+		}
+	}
+	return nil, &pgError{code: "42704", message: fmt.Sprintf("unrecognized configuration parameter %q", name)}
+}
+
+// parameter returns the value of a parameter in the session.
+func (s *session) parameter(name string) string {
+	p, err := lookupParameter(name)
+	if err != nil {
+		return ""
+	}
+	if value, ok := s.params[strings.ToLower(p.name)]; ok {
+		return value
+	}
+	return p.def
+}
+
+// setParameter validates and sets a parameter.
+func (s *session) setParameter(name, value string) error {
+	p, err := lookupParameter(name)
+	if err != nil {
+		return err
+	}
+	invalid := &pgError{code: "22023", message: fmt.Sprintf("invalid value for parameter %q: %q", p.name, value)}
+	if p.readOnly {
+		if value == p.def {
+			return nil
+		}
+		return &pgError{code: "55P02", message: fmt.Sprintf("parameter %q cannot be changed", p.name)}
+	}
+
+	switch p.name {
+	case "client_encoding":
+		switch strings.ToUpper(strings.ReplaceAll(value, "-", "")) {
+		case "UTF8", "UNICODE":
+			value = "UTF8"
+		default:
+			return &pgError{code: "0A000", message: fmt.Sprintf("encoding %q is not supported; only UTF8 is", value)}
+		}
+	case "standard_conforming_strings":
+		if !strings.EqualFold(value, "on") {
+			return &pgError{code: "0A000", message: "standard_conforming_strings cannot be turned off"}
+		}
+	case "statement_timeout":
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			return invalid
+		}
+		s.statementTimeout = timeout
+	case "synchronous_commit":
+		policy := strings.ToLower(value)
+		switch policy {
+		case "on", "local", "remote_write", "remote_apply":
+			// PostgreSQL's durable settings select the server default
+			policy = ""
+		default:
+			if s.h.commits != nil {
+				if err := s.h.commits.ValidateSyncPolicy(policy); err != nil {
+					return &pgError{code: "22023", message: err.Error()}
+				}
+			}
+		}
+		s.syncCommit = policy
+	case "default_transaction_isolation":
+		value = strings.ToLower(strings.Join(strings.Fields(value), " "))
+		switch value {
+		case "read uncommitted", "read committed", "repeatable read", "serializable":
+		default:
+			return invalid
+		}
+	case "default_transaction_read_only":
+		switch strings.ToLower(value) {
+		case "on", "true", "yes", "1":
+			value = "on"
+		case "off", "false", "no", "0":
+			value = "off"
+		default:
+			return invalid
+		}
+	}
+
+	s.params[strings.ToLower(p.name)] = value
+	return nil
+}
+
+// parseTimeout parses a statement_timeout value: milliseconds, or a number
+// with a unit such as "30s" or "5min". Zero disables the limit.
+func parseTimeout(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if literal.IsDigits(value) {
+		value += "ms"
+	}
+	value = strings.Replace(value, "min", "m", 1)
+	timeout, err := time.ParseDuration(strings.ReplaceAll(value, " ", ""))
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("invalid timeout %q", value)
+	}
+	return timeout, nil
+}
+
+// setCommand handles SET [SESSION | LOCAL] name { TO | = } value and
+// SET TIME ZONE value. SET LOCAL lasts for the session, not just the
+// transaction. SET TRANSACTION is accepted and ignored; BEGIN takes the
+// transaction modes instead.
+func (s *session) setCommand(stmt string) (*commandResult, error) {
+	rest := strings.TrimSpace(stmt[len("SET"):])
+	if words := keywords(rest, 1); len(words) == 1 && (words[0] == "SESSION" || words[0] == "LOCAL") {
+		rest = strings.TrimSpace(rest[len(words[0]):])
+	}
+	words := keywords(rest, 2)
+	result := &commandResult{tag: "SET"}
+	if len(words) > 0 && words[0] == "TRANSACTION" || len(words) > 1 && words[0] == "CHARACTERISTICS" {
+		return result, nil
+	}
+
+	var name string
+	if len(words) == 2 && words[0] == "TIME" && words[1] == "ZONE" {
+		name = "TimeZone"
+		rest = strings.TrimSpace(rest[len("TIME"):])
+		rest = strings.TrimSpace(rest[len("ZONE"):])
+	} else {
+		end := strings.IndexFunc(rest, func(r rune) bool {
+			return !(r == '_' || r == '.' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		})
+		if end < 0 {
+			end = len(rest)
+		}
+		name, rest = rest[:end], strings.TrimSpace(rest[end:])
+		if strings.HasPrefix(rest, "=") {
+			rest = strings.TrimSpace(rest[1:])
+		} else if words := keywords(rest, 1); len(words) == 1 && words[0] == "TO" {
+			rest = strings.TrimSpace(rest[len("TO"):])
+		} else {
+			return nil, &pgError{code: "42601", message: "syntax error in SET statement"}
+		}
+	}
+
+	value, isDefault, err := parseSetValue(rest)
+	if err != nil {
+		return nil, err
+	}
+	if isDefault {
+		return result, s.resetParameter(name)
+	}
+	if err := s.setParameter(name, value); err != nil {
+		return nil, err
+	}
+	return result, s.reportParameter(name)
+}
+
+// parseSetValue parses the value list of SET. Quoted values are unquoted
+// and several values are joined with ", ", as for DateStyle.
+func parseSetValue(list string) (value string, isDefault bool, err error) {
+	var values []string
+	start := 0
+	for i := 0; i <= len(list); {
+		if i < len(list) {
+			if j := skipQuoted(list, i); j != i {
+				i = j
+				continue
+			}
+			if list[i] != ',' {
+				i++
+				continue
+			}
+		}
+		item := strings.TrimSpace(list[start:i])
+		if len(item) >= 2 && item[0] == '\'' && item[len(item)-1] == '\'' {
+			item = strings.ReplaceAll(item[1:len(item)-1], "''", "'")
+		} else if strings.EqualFold(item, "DEFAULT") {
+			isDefault = true
+		} else if item == "" {
+			return "", false, &pgError{code: "42601", message: "syntax error in SET statement"}
+		}
+		values = append(values, item)
+		start = i + 1
+		i++
+	}
+	return strings.Join(values, ", "), isDefault && len(values) == 1, nil
+}
+
+// resetCommand handles RESET name and RESET ALL.
+func (s *session) resetCommand(stmt string) (*commandResult, error) {
+	name := strings.TrimSpace(stmt[len("RESET"):])
+	result := &commandResult{tag: "RESET"}
+	if !strings.EqualFold(name, "ALL") {
+		return result, s.resetParameter(name)
+	}
+	for _, p := range parameters {
+		if !p.readOnly {
+			if err := s.resetParameter(p.name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// resetParameter restores the default value of a parameter.
+func (s *session) resetParameter(name string) error {
+	p, err := lookupParameter(name)
+	if err != nil {
+		return err
+	}
+	if err := s.setParameter(p.name, p.def); err != nil {
+		return err
+	}
+	delete(s.params, strings.ToLower(p.name))
+	return s.reportParameter(p.name)
+}
+
+// reportParameter sends the new value of a reported parameter.
+func (s *session) reportParameter(name string) error {
+	p, err := lookupParameter(name)
+	if err != nil || !p.reported {
+		return err
+	}
+	return s.sendParameterStatus(p.name)
+}
+
+// showCommand handles SHOW name and SHOW ALL.
+func (s *session) showCommand(stmt string) (*commandResult, error) {
+	name := strings.TrimSpace(stmt[len("SHOW"):])
+	if strings.EqualFold(name, "ALL") {
+		result := &commandResult{
+			columns: []column{{name: "name", oid: oidText}, {name: "setting", oid: oidText}},
+			tag:     "SHOW",
+		}
+		for _, p := range parameters {
+			result.rows = append(result.rows, []interface{}{p.name, s.parameter(p.name)})
+		}
+		return result, nil
+	}
+
+	p, err := lookupParameter(name)
+	if err != nil {
+		return nil, err
+	}
+	return &commandResult{
+		columns: []column{{name: p.name, oid: oidText}},
+		rows:    [][]interface{}{{s.parameter(p.name)}},
+		tag:     "SHOW",
+	}, nil
+}
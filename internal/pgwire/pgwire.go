@@ -0,0 +1,504 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package pgwire serves the PostgreSQL frontend/backend protocol (version 3.0)
+so that PostgreSQL drivers and tools such as psql, pgx, libpq and the
+PostgreSQL JDBC driver can connect to FlyDB.
+
+Supported protocol features:
+
+  - SSL negotiation (SSLRequest) when the server has TLS configured
+  - SCRAM-SHA-256 authentication for every user; unknown users go through
+    a mock exchange, so clients cannot tell them from wrong passwords
+  - The simple query protocol, including several statements per query
+  - The extended query protocol: Parse, Bind, Describe, Execute, Close,
+    Sync and Flush, with text and binary parameters and results
+  - CancelRequest, statement_timeout and transaction blocks
+
+Statements are FlyDB SQL; this package does not translate PostgreSQL SQL.
+Transaction control (BEGIN, COMMIT, ROLLBACK, SAVEPOINT) and session
+parameters (SET, RESET, SHOW) are handled here, and everything else runs on
+the server's executor. Result columns are described with the PostgreSQL
+types that match their FlyDB types, falling back to text.
+*/
+package pgwire
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/binary"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"flydb/internal/logging"
+	"flydb/internal/protocol"
+)
+
+// Package logger for the PostgreSQL listener.
+var log = logging.NewLogger("pgwire")
+
+// startupTimeout bounds the startup and authentication of a connection.
+const startupTimeout = 30 * time.Second
+
+// Authenticator verifies PostgreSQL clients.
+type Authenticator interface {
+	// SCRAMVerifier returns the SCRAM-SHA-256 verifier of a user. ok is
+	// false if the user does not exist or has no verifier; either way the
+	// client goes through a SCRAM exchange that fails.
+	SCRAMVerifier(username string) (verifier string, ok bool)
+}
+
+// Handler serves PostgreSQL protocol connections.
+type Handler struct {
+	executor  protocol.ContextQueryExecutor
+	auth      Authenticator
+	txMgr     protocol.TransactionManager
+	dbMgr     protocol.DatabaseManager
+	commits   protocol.CommitSyncer
+	tlsConfig *tls.Config
+	mockKey   []byte // Derives the mock SCRAM verifiers of unknown users
+
+	// Sessions by process ID, for CancelRequest
+	mu       sync.Mutex
+	sessions map[int32]*session
+	nextPID  int32
+}
+
+// NewHandler creates a handler that runs statements on executor and
+// authenticates users with auth.
+func NewHandler(executor protocol.ContextQueryExecutor, auth Authenticator) *Handler {
+	mockKey := make([]byte, sha256.Size)
+	rand.Read(mockKey)
+	return &Handler{
+		executor: executor,
+		auth:     auth,
+		mockKey:  mockKey,
+		sessions: make(map[int32]*session),
+	}
+}
+
+// SetTransactionManager sets the manager that runs transaction blocks.
+// Without one, BEGIN is rejected.
+func (h *Handler) SetTransactionManager(tm protocol.TransactionManager) {
+	h.txMgr = tm
+}
+
+// SetDatabaseManager sets the manager that checks the database named in
+// the startup packet.
+func (h *Handler) SetDatabaseManager(dm protocol.DatabaseManager) {
+	h.dbMgr = dm
+}
+
+// SetCommitSyncer sets the hook that makes statements durable before they
+// complete, honouring the synchronous_commit parameter.
+func (h *Handler) SetCommitSyncer(cs protocol.CommitSyncer) {
+	h.commits = cs
+}
+
+// SetTLSConfig enables SSL. Clients must then request SSL before startup.
+func (h *Handler) SetTLSConfig(config *tls.Config) {
+	h.tlsConfig = config
+}
+
+// session is the state of a PostgreSQL connection.
+type session struct {
+	h    *Handler
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+
+	pid    int32
+	secret int32
+
+	user     string
+	database string
+	params   map[string]string
+
+	statementTimeout time.Duration
+	syncCommit       string
+
+	txID     string
+	txFailed bool
+
+	statements map[string]*preparedStatement
+	portals    map[string]*portal
+
+	// Cancels the running statement
+	cancelMu sync.Mutex
+	cancel   context.CancelFunc
+}
+
+// HandleConnection serves conn until the client disconnects.
+func (h *Handler) HandleConnection(conn net.Conn) {
+	remoteAddr := conn.RemoteAddr().String()
+	s := &session{
+		h:          h,
+		conn:       conn,
+		params:     make(map[string]string),
+		statements: make(map[string]*preparedStatement),
+		portals:    make(map[string]*portal),
+	}
+	defer func() {
+		s.conn.Close()
+	}()
+
+	conn.SetDeadline(time.Now().Add(startupTimeout))
+	ok, err := s.startup()
+	if err != nil {
+		log.Debug("PostgreSQL startup failed", "remote_addr", remoteAddr, "error", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	s.conn.SetDeadline(time.Time{})
+	defer h.unregister(s)
+	defer s.rollback()
+
+	log.Info("PostgreSQL session started", "remote_addr", remoteAddr, "user", s.user, "database", s.database)
+	if err := s.serve(); err != nil && err != io.EOF && !stderrors.Is(err, net.ErrClosed) {
+		log.Debug("PostgreSQL session ended", "remote_addr", remoteAddr, "error", err)
+	}
+}
+
+// register assigns the session a process ID and secret for CancelRequest.
+func (h *Handler) register(s *session) {
+	var secret [4]byte
+	rand.Read(secret[:])
+	s.secret = int32(binary.BigEndian.Uint32(secret[:]))
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for {
+		h.nextPID++
+		if h.nextPID <= 0 {
+			h.nextPID = 1
+		}
+		if _, taken := h.sessions[h.nextPID]; !taken {
+			break
+		}
+	}
+	s.pid = h.nextPID
+	h.sessions[s.pid] = s
+}
+
+// unregister removes the session from the cancel registry.
+func (h *Handler) unregister(s *session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.sessions, s.pid)
+}
+
+// cancelRequest cancels the running statement of the session with the
+// given process ID if the secret matches.
+func (h *Handler) cancelRequest(pid, secret int32) {
+	h.mu.Lock()
+	s := h.sessions[pid]
+	h.mu.Unlock()
+	if s == nil {
+		return
+	}
+	var want, got [4]byte
+	binary.BigEndian.PutUint32(want[:], uint32(s.secret))
+	binary.BigEndian.PutUint32(got[:], uint32(secret))
+	if subtle.ConstantTimeCompare(want[:], got[:]) != 1 {
+		log.Warn("PostgreSQL cancel request with a wrong secret", "pid", pid)
+		return
+	}
+
+	s.cancelMu.Lock()
+	defer s.cancelMu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// startup negotiates SSL, reads the startup packet and authenticates the
+// client. ok is false if the connection ends without an error, as after a
+// CancelRequest or a rejected login.
+func (s *session) startup() (ok bool, err error) {
+	for {
+		code, body, err := readStartup(s.conn)
+		if err != nil {
+			return false, err
+		}
+
+		switch code {
+		case sslRequestCode:
+			if _, isTLS := s.conn.(*tls.Conn); isTLS || s.h.tlsConfig == nil {
+				if _, err := s.conn.Write([]byte{'N'}); err != nil {
+					return false, err
+				}
+				continue
+			}
+			if _, err := s.conn.Write([]byte{'S'}); err != nil {
+				return false, err
+			}
+			tlsConn := tls.Server(s.conn, s.h.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return false, err
+			}
+			s.conn = tlsConn
+			continue
+
+		case gssEncRequestCode:
+			if _, err := s.conn.Write([]byte{'N'}); err != nil {
+				return false, err
+			}
+			continue
+
+		case cancelRequestCode:
+			r := &reader{buf: body}
+			pid, secret := r.int32(), r.int32()
+			if r.err != nil {
+				return false, r.err
+			}
+			s.h.cancelRequest(pid, secret)
+			return false, nil
+		}
+
+		s.r = bufio.NewReader(s.conn)
+		s.w = bufio.NewWriter(s.conn)
+		return s.login(code, body)
+	}
+}
+
+// login handles the startup packet of protocol version 3.
+func (s *session) login(version uint32, body []byte) (bool, error) {
+	if version>>16 != protocolVersion3>>16 {
+		return false, s.fatal(&pgError{code: "0A000", message: fmt.Sprintf("unsupported frontend protocol %d.%d", version>>16, version&0xFFFF)})
+	}
+	if _, isTLS := s.conn.(*tls.Conn); s.h.tlsConfig != nil && !isTLS {
+		return false, s.fatal(&pgError{code: "28000", message: "SSL connection is required"})
+	}
+
+	// Parameters are name/value pairs ending with an empty name
+	r := &reader{buf: body}
+	startupParams := make(map[string]string)
+	var unsupported []string
+	for {
+		name := r.string()
+		if r.err != nil {
+			return false, s.fatal(&pgError{code: "08P01", message: "invalid startup packet layout"})
+		}
+		if name == "" {
+			break
+		}
+		value := r.string()
+		if strings.HasPrefix(name, "_pq_.") {
+			unsupported = append(unsupported, name)
+			continue
+		}
+		startupParams[name] = value
+	}
+
+	// Newer minor versions and protocol options are declined, not rejected
+	if version&0xFFFF > 0 || len(unsupported) > 0 {
+		m := newMessage(msgNegotiateProtocol).int32(protocolVersion3).int32(len(unsupported))
+		for _, name := range unsupported {
+			m.string(name)
+		}
+		if err := s.send(m); err != nil {
+			return false, err
+		}
+	}
+
+	s.user = startupParams["user"]
+	if s.user == "" {
+		return false, s.fatal(&pgError{code: "28000", message: "no PostgreSQL user name specified in startup packet"})
+	}
+	s.database = startupParams["database"]
+	if s.database == "" {
+		s.database = "default"
+	}
+
+	// Every login is a SCRAM exchange, so the method asked for does not
+	// tell a client whether the user exists
+	verifier, known := s.h.scramVerifier(s.user)
+	authErr := s.authenticateSCRAM(verifier)
+	if authErr != nil && !known {
+		authErr = errNoVerifier
+	}
+	if authErr != nil {
+		log.Warn("PostgreSQL auth failed", "remote_addr", s.conn.RemoteAddr().String(), "user", s.user, "error", authErr)
+		return false, s.fatal(&pgError{code: "28P01", message: fmt.Sprintf("password authentication failed for user %q", s.user)})
+	}
+
+	if s.h.dbMgr != nil && s.database != "default" && !s.h.dbMgr.DatabaseExists(s.database) {
+		return false, s.fatal(&pgError{code: "3D000", message: fmt.Sprintf("database %q does not exist", s.database)})
+	}
+
+	// Session parameters from the startup packet, such as application_name
+	for name, value := range startupParams {
+		switch name {
+		case "user", "database", "options", "replication":
+			continue
+		}
+		if err := s.setParameter(name, value); err != nil {
+			return false, s.fatal(toPGError(err))
+		}
+	}
+
+	if err := s.send(newMessage(msgAuthentication).int32(authOK)); err != nil {
+		return false, err
+	}
+	for _, p := range parameters {
+		if p.reported {
+			if err := s.sendParameterStatus(p.name); err != nil {
+				return false, err
+			}
+		}
+	}
+	s.h.register(s)
+	if err := s.send(newMessage(msgBackendKeyData).int32(int(s.pid)).int32(int(s.secret))); err != nil {
+		return false, err
+	}
+	return true, s.sendReady()
+}
+
+// serve reads and handles messages until the client terminates.
+func (s *session) serve() error {
+	// After an error in the extended protocol, messages up to Sync are
+	// discarded
+	discarding := false
+	for {
+		typ, body, err := readMessage(s.r)
+		if err != nil {
+			return err
+		}
+		if discarding && typ != msgSync && typ != msgTerminate {
+			continue
+		}
+
+		switch typ {
+		case msgQuery:
+			r := &reader{buf: body}
+			query := r.string()
+			if r.err != nil {
+				return s.fatal(&pgError{code: "08P01", message: "invalid Query message"})
+			}
+			if err := s.simpleQuery(query); err != nil {
+				return err
+			}
+			continue
+		case msgSync:
+			discarding = false
+			if err := s.sendReady(); err != nil {
+				return err
+			}
+			continue
+		case msgFlush:
+			if err := s.flush(); err != nil {
+				return err
+			}
+			continue
+		case msgTerminate:
+			return nil
+		}
+
+		var handleErr error
+		switch typ {
+		case msgParse:
+			handleErr = s.handleParse(body)
+		case msgBind:
+			handleErr = s.handleBind(body)
+		case msgDescribe:
+			handleErr = s.handleDescribe(body)
+		case msgExecute:
+			handleErr = s.handleExecute(body)
+		case msgClose:
+			handleErr = s.handleClose(body)
+		default:
+			return s.fatal(&pgError{code: "08P01", message: fmt.Sprintf("invalid frontend message type %q", typ)})
+		}
+		if handleErr != nil {
+			var netErr net.Error
+			if stderrors.As(handleErr, &netErr) || stderrors.Is(handleErr, io.ErrClosedPipe) || stderrors.Is(handleErr, net.ErrClosed) {
+				return handleErr
+			}
+			if err := s.sendError(handleErr); err != nil {
+				return err
+			}
+			discarding = true
+		}
+	}
+}
+
+// send writes a message to the output buffer.
+func (s *session) send(m *message) error {
+	return m.writeTo(s.w)
+}
+
+// flush writes buffered messages to the client.
+func (s *session) flush() error {
+	return s.w.Flush()
+}
+
+// sendReady sends ReadyForQuery with the transaction status and flushes.
+func (s *session) sendReady() error {
+	status := txIdle
+	if s.txFailed {
+		status = txFailed
+	} else if s.txID != "" {
+		status = txActive
+	}
+	if err := s.send(newMessage(msgReadyForQuery).byte(status)); err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+// sendParameterStatus reports the value of a parameter.
+func (s *session) sendParameterStatus(name string) error {
+	return s.send(newMessage(msgParameterStatus).string(name).string(s.parameter(name)))
+}
+
+// fatal sends a FATAL error and flushes. The connection ends afterwards.
+func (s *session) fatal(e *pgError) error {
+	e.severity = "FATAL"
+	if s.w == nil {
+		s.w = bufio.NewWriter(s.conn)
+	}
+	if err := s.send(e.response()); err != nil {
+		return err
+	}
+	if err := s.flush(); err != nil {
+		return err
+	}
+	return stderrors.New(e.message)
+}
+
+// rollback rolls back an open transaction when the session ends.
+func (s *session) rollback() {
+	if s.txID == "" || s.h.txMgr == nil {
+		return
+	}
+	if err := s.h.txMgr.Rollback(s.txID); err != nil {
+		log.Debug("PostgreSQL rollback on disconnect failed", "tx_id", s.txID, "error", err)
+	}
+	s.txID = ""
+	s.txFailed = false
+}
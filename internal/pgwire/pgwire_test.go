@@ -0,0 +1,656 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pgwire_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"flydb/internal/auth"
+	"flydb/internal/server"
+	"flydb/internal/storage"
+)
+
+// startServer starts a server with a PostgreSQL listener, an admin user and
+// a user "app" with password "secret", and returns the listener's address.
+func startServer(t *testing.T) string {
+	t.Helper()
+	addr, _ := startServerWithStore(t)
+	return addr
+}
+
+// startServerWithStore starts a server like startServer and also returns
+// its store.
+func startServerWithStore(t *testing.T) (string, storage.Engine) {
+	t.Helper()
+	tmpDir, err := os.MkdirTemp("", "flydb_pgwire_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	store, err := storage.NewStorageEngine(storage.StorageConfig{DataDir: tmpDir, BufferPoolSize: 256})
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		t.Fatalf("Failed to create storage engine: %v", err)
+	}
+	authMgr := auth.NewAuthManager(store)
+	if err := authMgr.InitializeAdmin("admin"); err != nil {
+		store.Close()
+		os.RemoveAll(tmpDir)
+		t.Fatalf("Failed to initialize admin user: %v", err)
+	}
+	if err := authMgr.CreateUser("app", "secret"); err != nil {
+		store.Close()
+		os.RemoveAll(tmpDir)
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	ports := make([]int, 2)
+	for i := range ports {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to find a port: %v", err)
+		}
+		ports[i] = listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+	}
+	pgAddr := fmt.Sprintf("127.0.0.1:%d", ports[1])
+
+	srv := server.NewServerWithStore(fmt.Sprintf(":%d", ports[0]), store)
+	srv.SetPostgresAddr(pgAddr)
+	t.Cleanup(func() {
+		srv.Stop()
+		store.Close()
+		time.Sleep(10 * time.Millisecond)
+		os.RemoveAll(tmpDir)
+	})
+	go srv.Start()
+	time.Sleep(150 * time.Millisecond)
+	return pgAddr, store
+}
+
+// pgClient is a minimal PostgreSQL protocol client.
+type pgClient struct {
+	t      *testing.T
+	conn   net.Conn
+	r      *bufio.Reader
+	status byte              // Transaction status of the last ReadyForQuery
+	params map[string]string // ParameterStatus values
+	salt   string            // SCRAM salt sent by the server
+}
+
+// pgServerError is an ErrorResponse.
+type pgServerError struct {
+	code, message string
+}
+
+func (e *pgServerError) Error() string {
+	return e.code + ": " + e.message
+}
+
+// result is what a query returned.
+type result struct {
+	columns []string
+	oids    []uint32
+	rows    [][]*string
+	tags    []string
+	err     *pgServerError
+}
+
+// connect opens a session, answering the SCRAM-SHA-256 exchange with
+// password. The client is returned with the error of a failed login.
+func connect(t *testing.T, addr, user, password, database string) (*pgClient, error) {
+	t.Helper()
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	c := &pgClient{t: t, conn: conn, r: bufio.NewReader(conn), params: make(map[string]string)}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	var startup bytes.Buffer
+	binary.Write(&startup, binary.BigEndian, int32(3<<16))
+	for _, kv := range [][2]string{{"user", user}, {"database", database}, {"application_name", "pgwire_test"}} {
+		startup.WriteString(kv[0] + "\x00" + kv[1] + "\x00")
+	}
+	startup.WriteByte(0)
+	packet := binary.BigEndian.AppendUint32(nil, uint32(startup.Len()+4))
+	if _, err := conn.Write(append(packet, startup.Bytes()...)); err != nil {
+		t.Fatalf("Failed to send startup packet: %v", err)
+	}
+
+	var scram *scramClient
+	for {
+		typ, body := c.read()
+		switch typ {
+		case 'R':
+			code := binary.BigEndian.Uint32(body)
+			data := body[4:]
+			switch code {
+			case 0:
+			case 10:
+				if !strings.HasPrefix(string(data), "SCRAM-SHA-256\x00") {
+					t.Fatalf("Unexpected SASL mechanisms %q", data)
+				}
+				scram = newSCRAMClient(password)
+				first := scram.clientFirst()
+				msg := append([]byte("SCRAM-SHA-256\x00"), binary.BigEndian.AppendUint32(nil, uint32(len(first)))...)
+				c.send('p', append(msg, first...))
+			case 11:
+				c.salt = scramAttribute(string(data), "s")
+				c.send('p', []byte(scram.clientFinal(t, string(data))))
+			case 12:
+				if string(data) != scram.serverFinal {
+					t.Fatalf("Server signature %q, want %q", data, scram.serverFinal)
+				}
+			default:
+				t.Fatalf("Unexpected authentication request %d", code)
+			}
+		case 'S', 'K':
+		case 'E':
+			return c, parseError(body)
+		case 'Z':
+			c.status = body[0]
+			return c, nil
+		default:
+			t.Fatalf("Unexpected message %q during startup", typ)
+		}
+	}
+}
+
+// send sends a message.
+func (c *pgClient) send(typ byte, body []byte) {
+	c.t.Helper()
+	msg := append([]byte{typ}, binary.BigEndian.AppendUint32(nil, uint32(len(body)+4))...)
+	if _, err := c.conn.Write(append(msg, body...)); err != nil {
+		c.t.Fatalf("Failed to send %q: %v", typ, err)
+	}
+}
+
+// read reads a message, recording ParameterStatus values.
+func (c *pgClient) read() (byte, []byte) {
+	c.t.Helper()
+	for {
+		var header [5]byte
+		if _, err := io.ReadFull(c.r, header[:]); err != nil {
+			c.t.Fatalf("Failed to read message: %v", err)
+		}
+		body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+		if _, err := io.ReadFull(c.r, body); err != nil {
+			c.t.Fatalf("Failed to read message body: %v", err)
+		}
+		if header[0] == 'S' {
+			fields := strings.Split(string(body), "\x00")
+			c.params[fields[0]] = fields[1]
+			if c.status != 0 {
+				continue
+			}
+		}
+		if header[0] == 'N' {
+			continue
+		}
+		return header[0], body
+	}
+}
+
+// collect reads the responses up to ReadyForQuery.
+func (c *pgClient) collect() *result {
+	c.t.Helper()
+	res := &result{}
+	for {
+		typ, body := c.read()
+		switch typ {
+		case 'T':
+			n := int(binary.BigEndian.Uint16(body))
+			body = body[2:]
+			res.columns, res.oids = nil, nil
+			for i := 0; i < n; i++ {
+				end := bytes.IndexByte(body, 0)
+				res.columns = append(res.columns, string(body[:end]))
+				body = body[end+1:]
+				res.oids = append(res.oids, binary.BigEndian.Uint32(body[6:]))
+				body = body[18:]
+			}
+		case 'D':
+			n := int(binary.BigEndian.Uint16(body))
+			body = body[2:]
+			row := make([]*string, n)
+			for i := range row {
+				length := int32(binary.BigEndian.Uint32(body))
+				body = body[4:]
+				if length >= 0 {
+					value := string(body[:length])
+					row[i] = &value
+					body = body[length:]
+				}
+			}
+			res.rows = append(res.rows, row)
+		case 'C':
+			res.tags = append(res.tags, strings.TrimSuffix(string(body), "\x00"))
+		case 's':
+			res.tags = append(res.tags, "(suspended)")
+		case 'I':
+			res.tags = append(res.tags, "(empty)")
+		case 'E':
+			res.err = parseError(body)
+		case 'Z':
+			c.status = body[0]
+			return res
+		}
+	}
+}
+
+// query runs a simple query.
+func (c *pgClient) query(q string) *result {
+	c.t.Helper()
+	c.send('Q', []byte(q+"\x00"))
+	return c.collect()
+}
+
+// mustQuery runs a simple query that must succeed.
+func (c *pgClient) mustQuery(q string) *result {
+	c.t.Helper()
+	res := c.query(q)
+	if res.err != nil {
+		c.t.Fatalf("Query %q failed: %v", q, res.err)
+	}
+	return res
+}
+
+// parseError parses the fields of an ErrorResponse.
+func parseError(body []byte) *pgServerError {
+	e := &pgServerError{}
+	for _, field := range strings.Split(string(body), "\x00") {
+		if field == "" {
+			continue
+		}
+		switch field[0] {
+		case 'C':
+			e.code = field[1:]
+		case 'M':
+			e.message = field[1:]
+		}
+	}
+	return e
+}
+
+// scramClient is the client side of a SCRAM-SHA-256 exchange.
+type scramClient struct {
+	password        string
+	nonce           string
+	clientFirstBare string
+	serverFinal     string
+}
+
+func newSCRAMClient(password string) *scramClient {
+	nonce := make([]byte, 18)
+	rand.Read(nonce)
+	return &scramClient{password: password, nonce: base64.StdEncoding.EncodeToString(nonce)}
+}
+
+func (c *scramClient) clientFirst() string {
+	c.clientFirstBare = "n=,r=" + c.nonce
+	return "n,," + c.clientFirstBare
+}
+
+func (c *scramClient) clientFinal(t *testing.T, serverFirst string) string {
+	attrs := make(map[string]string)
+	for _, attr := range strings.Split(serverFirst, ",") {
+		attrs[attr[:1]] = attr[2:]
+	}
+	if !strings.HasPrefix(attrs["r"], c.nonce) {
+		t.Fatalf("Server nonce %q does not extend the client nonce", attrs["r"])
+	}
+	salt, _ := base64.StdEncoding.DecodeString(attrs["s"])
+	iterations, _ := strconv.Atoi(attrs["i"])
+
+	salted, err := pbkdf2.Key(sha256.New, c.password, salt, iterations, sha256.Size)
+	if err != nil {
+		t.Fatalf("pbkdf2 failed: %v", err)
+	}
+	clientKey := hmacSHA256(salted, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	withoutProof := "c=biws,r=" + attrs["r"]
+	authMessage := c.clientFirstBare + "," + serverFirst + "," + withoutProof
+	signature := hmacSHA256(storedKey[:], authMessage)
+	proof := make([]byte, len(clientKey))
+	for i := range proof {
+		proof[i] = clientKey[i] ^ signature[i]
+	}
+	c.serverFinal = "v=" + base64.StdEncoding.EncodeToString(hmacSHA256(hmacSHA256(salted, "Server Key"), authMessage))
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof)
+}
+
+// scramAttribute returns an attribute of a SCRAM message.
+func scramAttribute(message, name string) string {
+	for _, attr := range strings.Split(message, ",") {
+		if strings.HasPrefix(attr, name+"=") {
+			return attr[len(name)+1:]
+		}
+	}
+	return ""
+}
+
+func hmacSHA256(key []byte, message string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// value returns a row value for comparison, "NULL" for NULL.
+func value(v *string) string {
+	if v == nil {
+		return "NULL"
+	}
+	return *v
+}
+
+func TestAuthentication(t *testing.T) {
+	addr, store := startServerWithStore(t)
+	authMgr := auth.NewAuthManager(store)
+
+	c, err := connect(t, addr, "admin", "admin", "")
+	if err != nil {
+		t.Fatalf("SCRAM login failed: %v", err)
+	}
+	if c.status != 'I' || c.params["server_version"] == "" || c.params["application_name"] != "pgwire_test" {
+		t.Errorf("Unexpected startup state: status %q, parameters %v", c.status, c.params)
+	}
+
+	if _, err := connect(t, addr, "app", "secret", ""); err != nil {
+		t.Errorf("Login of a new user failed: %v", err)
+	}
+
+	if _, err := connect(t, addr, "admin", "wrong", ""); err == nil || err.(*pgServerError).code != "28P01" {
+		t.Errorf("Login with a wrong password returned %v, want 28P01", err)
+	}
+	// Unknown users go through a SCRAM exchange too, with the same salt
+	// on every attempt, so they look like users with another password
+	first, err := connect(t, addr, "nobody", "x", "")
+	if err == nil || err.(*pgServerError).code != "28P01" {
+		t.Errorf("Login of an unknown user returned %v, want 28P01", err)
+	}
+	second, _ := connect(t, addr, "nobody", "y", "")
+	if first.salt == "" || first.salt != second.salt {
+		t.Errorf("Unknown user got salts %q and %q, want the same one", first.salt, second.salt)
+	}
+
+	// A user without a verifier cannot log in until a password login
+	// stores one
+	if err := authMgr.CreateUser("legacy", "secret"); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	removeSCRAMVerifier(t, store, "legacy")
+	if _, err := connect(t, addr, "legacy", "secret", ""); err == nil || err.(*pgServerError).code != "28P01" {
+		t.Errorf("Login of a user without a verifier returned %v, want 28P01", err)
+	}
+	if !authMgr.Authenticate("legacy", "secret") {
+		t.Fatal("Password login failed")
+	}
+	if _, err := connect(t, addr, "legacy", "secret", ""); err != nil {
+		t.Errorf("Login after a password login failed: %v", err)
+	}
+}
+
+// removeSCRAMVerifier turns a user into one created before verifiers
+// were stored.
+func removeSCRAMVerifier(t *testing.T, store storage.Engine, username string) {
+	t.Helper()
+	key := "_sys_users:" + username
+	data, err := store.Get(key)
+	if err != nil {
+		t.Fatalf("Failed to read user %s: %v", username, err)
+	}
+	var user auth.User
+	if err := json.Unmarshal(data, &user); err != nil {
+		t.Fatalf("Failed to decode user %s: %v", username, err)
+	}
+	user.SCRAMVerifier = ""
+	data, _ = json.Marshal(user)
+	if err := store.Put(key, data); err != nil {
+		t.Fatalf("Failed to write user %s: %v", username, err)
+	}
+}
+
+func TestSimpleQuery(t *testing.T) {
+	c, err := connect(t, startServer(t), "admin", "admin", "")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	res := c.mustQuery("CREATE TABLE t (id INT, name TEXT, score FLOAT, ok BOOLEAN); " +
+		"INSERT INTO t VALUES (1, 'a;b', 1.5, TRUE); INSERT INTO t VALUES (2, NULL, 2.5, FALSE)")
+	if want := []string{"CREATE TABLE", "INSERT 0 1", "INSERT 0 1"}; strings.Join(res.tags, "|") != strings.Join(want, "|") {
+		t.Errorf("Tags %v, want %v", res.tags, want)
+	}
+
+	res = c.mustQuery("SELECT id, name, score, ok FROM t ORDER BY id")
+	if strings.Join(res.columns, ",") != "id,name,score,ok" || len(res.rows) != 2 || res.tags[0] != "SELECT 2" {
+		t.Fatalf("SELECT returned %v %v %v", res.columns, res.rows, res.tags)
+	}
+	if want := []uint32{23, 25, 701, 16}; fmt.Sprint(res.oids) != fmt.Sprint(want) {
+		t.Errorf("Column types %v, want %v", res.oids, want)
+	}
+	if got := strings.Join([]string{value(res.rows[0][0]), value(res.rows[0][1]), value(res.rows[0][3]), value(res.rows[1][1])}, " "); got != "1 a;b t NULL" {
+		t.Errorf("Rows are %s", got)
+	}
+
+	// An error ends the query string
+	res = c.query("UPDATE t SET score = 3.5 WHERE id = 1; SELECT * FROM missing; DELETE FROM t")
+	if res.err == nil || res.err.code != "42P01" || len(res.tags) != 1 || res.tags[0] != "UPDATE 1" {
+		t.Errorf("Query returned tags %v and error %v, want UPDATE 1 then 42P01", res.tags, res.err)
+	}
+	if res := c.query("SELEC 1"); res.err == nil || res.err.code != "42601" {
+		t.Errorf("Syntax error returned %v, want 42601", res.err)
+	}
+	if res := c.mustQuery(" ; "); len(res.tags) != 1 || res.tags[0] != "(empty)" {
+		t.Errorf("Empty query returned %v", res.tags)
+	}
+
+	// Session parameters
+	c.mustQuery("SET statement_timeout = '5s'; SET application_name TO 'other'")
+	if res := c.mustQuery("SHOW statement_timeout"); value(res.rows[0][0]) != "5s" {
+		t.Errorf("statement_timeout is %s", value(res.rows[0][0]))
+	}
+	if c.params["application_name"] != "other" {
+		t.Errorf("application_name reported as %q", c.params["application_name"])
+	}
+	if res := c.query("SET no_such_parameter = 1"); res.err == nil || res.err.code != "42704" {
+		t.Errorf("SET of an unknown parameter returned %v", res.err)
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	addr := startServer(t)
+	c, err := connect(t, addr, "admin", "admin", "")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	other, err := connect(t, addr, "admin", "admin", "")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	c.mustQuery("CREATE TABLE t (id INT)")
+
+	count := func() string {
+		t.Helper()
+		return value(other.mustQuery("SELECT COUNT(*) FROM t").rows[0][0])
+	}
+
+	c.mustQuery("BEGIN ISOLATION LEVEL SERIALIZABLE; INSERT INTO t VALUES (1)")
+	if c.status != 'T' {
+		t.Errorf("Status in a transaction is %q", c.status)
+	}
+	if n := count(); n != "0" {
+		t.Errorf("Uncommitted row visible to another session: %s rows", n)
+	}
+	if res := c.mustQuery("COMMIT"); res.tags[0] != "COMMIT" || c.status != 'I' {
+		t.Errorf("COMMIT returned %v with status %q", res.tags, c.status)
+	}
+	if n := count(); n != "1" {
+		t.Errorf("Table has %s rows after commit, want 1", n)
+	}
+
+	// A failed transaction ignores statements until it ends
+	c.mustQuery("BEGIN")
+	c.mustQuery("INSERT INTO t VALUES (2)")
+	if res := c.query("SELECT * FROM missing"); res.err == nil || c.status != 'E' {
+		t.Fatalf("Failing statement returned %v with status %q", res.err, c.status)
+	}
+	if res := c.query("INSERT INTO t VALUES (3)"); res.err == nil || res.err.code != "25P02" {
+		t.Errorf("Statement in a failed transaction returned %v, want 25P02", res.err)
+	}
+	if res := c.mustQuery("COMMIT"); res.tags[0] != "ROLLBACK" || c.status != 'I' {
+		t.Errorf("COMMIT of a failed transaction returned %v with status %q", res.tags, c.status)
+	}
+	if n := count(); n != "1" {
+		t.Errorf("Table has %s rows after rollback, want 1", n)
+	}
+}
+
+// extended builds the messages of an extended query.
+type extended struct {
+	buf bytes.Buffer
+}
+
+func (e *extended) msg(typ byte, body []byte) *extended {
+	e.buf.WriteByte(typ)
+	binary.Write(&e.buf, binary.BigEndian, int32(len(body)+4))
+	e.buf.Write(body)
+	return e
+}
+
+func (e *extended) parse(query string, oids ...uint32) *extended {
+	body := []byte("\x00" + query + "\x00")
+	body = binary.BigEndian.AppendUint16(body, uint16(len(oids)))
+	for _, oid := range oids {
+		body = binary.BigEndian.AppendUint32(body, oid)
+	}
+	return e.msg('P', body)
+}
+
+// bind binds params, given as text unless they are []byte, which is sent
+// in binary format. Results are requested in resultFormat.
+func (e *extended) bind(resultFormat int16, params ...interface{}) *extended {
+	body := []byte("\x00\x00")
+	body = binary.BigEndian.AppendUint16(body, uint16(len(params)))
+	for _, p := range params {
+		format := uint16(0)
+		if _, ok := p.([]byte); ok {
+			format = 1
+		}
+		body = binary.BigEndian.AppendUint16(body, format)
+	}
+	body = binary.BigEndian.AppendUint16(body, uint16(len(params)))
+	for _, p := range params {
+		switch p := p.(type) {
+		case nil:
+			body = binary.BigEndian.AppendUint32(body, 0xFFFFFFFF)
+		case []byte:
+			body = binary.BigEndian.AppendUint32(body, uint32(len(p)))
+			body = append(body, p...)
+		default:
+			s := fmt.Sprint(p)
+			body = binary.BigEndian.AppendUint32(body, uint32(len(s)))
+			body = append(body, s...)
+		}
+	}
+	body = binary.BigEndian.AppendUint16(body, 1)
+	body = binary.BigEndian.AppendUint16(body, uint16(resultFormat))
+	return e.msg('B', body)
+}
+
+func (e *extended) describe(kind byte) *extended {
+	return e.msg('D', []byte{kind, 0})
+}
+
+func (e *extended) execute(maxRows int) *extended {
+	return e.msg('E', binary.BigEndian.AppendUint32([]byte{0}, uint32(maxRows)))
+}
+
+func (e *extended) sync(c *pgClient) *result {
+	c.t.Helper()
+	e.msg('S', nil)
+	if _, err := c.conn.Write(e.buf.Bytes()); err != nil {
+		c.t.Fatalf("Failed to send extended query: %v", err)
+	}
+	return c.collect()
+}
+
+func TestExtendedQuery(t *testing.T) {
+	c, err := connect(t, startServer(t), "admin", "admin", "")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	c.mustQuery("CREATE TABLE t (id INT, name TEXT, amount BIGINT)")
+
+	// Text parameters of unspecified type, including quotes and NULL
+	ins := new(extended).parse("INSERT INTO t VALUES ($1, $2, $3)")
+	ins.bind(0, 1, "O'Brien", 100).execute(0)
+	ins.bind(0, 2, nil, 200).execute(0)
+	ins.bind(0, 3, "c", 300).execute(0)
+	if res := ins.sync(c); res.err != nil || strings.Join(res.tags, "|") != "INSERT 0 1|INSERT 0 1|INSERT 0 1" {
+		t.Fatalf("Extended INSERT returned %v %v", res.tags, res.err)
+	}
+
+	// A binary int4 parameter, described statement and text results
+	id := binary.BigEndian.AppendUint32(nil, 1)
+	res := new(extended).parse("SELECT id, name FROM t WHERE id = $1", 23).describe('S').bind(0, id).execute(0).sync(c)
+	if res.err != nil || len(res.rows) != 1 || value(res.rows[0][1]) != "O'Brien" {
+		t.Fatalf("SELECT returned %v %v", res.rows, res.err)
+	}
+	if fmt.Sprint(res.oids) != "[23 25]" {
+		t.Errorf("Described column types %v, want [23 25]", res.oids)
+	}
+
+	// Binary results, fetched a row at a time
+	res = new(extended).parse("SELECT amount FROM t WHERE id > $1 ORDER BY id LIMIT $2").
+		bind(1, 0, 2).describe('P').execute(1).execute(1).sync(c)
+	if res.err != nil || len(res.rows) != 2 {
+		t.Fatalf("Portal returned %v %v", res.rows, res.err)
+	}
+	if got := binary.BigEndian.Uint64([]byte(*res.rows[1][0])); got != 200 {
+		t.Errorf("Second amount is %d, want 200", got)
+	}
+	if want := "(suspended)|SELECT 2"; strings.Join(res.tags, "|") != want {
+		t.Errorf("Tags %v, want %s", res.tags, want)
+	}
+
+	// Errors discard messages up to Sync
+	res = new(extended).parse("SELECT * FROM missing").bind(0).execute(0).parse("SELECT id FROM t").bind(0).execute(0).sync(c)
+	if res.err == nil || res.err.code != "42P01" || len(res.rows) != 0 {
+		t.Errorf("Failed portal returned %v %v", res.rows, res.err)
+	}
+	if res := new(extended).parse("SELECT id FROM t; SELECT id FROM t").sync(c); res.err == nil || res.err.code != "42601" {
+		t.Errorf("Parse of two statements returned %v, want 42601", res.err)
+	}
+}
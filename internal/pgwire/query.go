@@ -0,0 +1,556 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pgwire
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"flydb/internal/protocol"
+)
+
+// column describes a result column.
+type column struct {
+	name string
+	oid  uint32
+}
+
+// commandResult is the outcome of a statement.
+type commandResult struct {
+	columns []column // nil for statements that return no rows
+	rows    [][]interface{}
+	tag     string // CommandComplete tag; empty for an empty query
+}
+
+// preparedStatement is a statement created by Parse.
+type preparedStatement struct {
+	query     string
+	paramOIDs []uint32
+
+	// Result columns found by Describe, if it could find them
+	described bool
+	columns   []column
+}
+
+// portal is a bound statement created by Bind.
+type portal struct {
+	stmt          *preparedStatement
+	query         string
+	resultFormats []int16
+
+	// The result once the portal has run, and the next row to send
+	result *commandResult
+	next   int
+}
+
+// skipQuoted returns the index after the string literal, quoted
+// identifier or comment that starts at query[i], or i if none does.
+func skipQuoted(query string, i int) int {
+	switch c := query[i]; {
+	case c == '\'' || c == '"':
+		// Doubled quotes inside a literal end it and start it again
+		end := strings.IndexByte(query[i+1:], c)
+		if end < 0 {
+			return len(query)
+		}
+		return i + end + 2
+	case c == '-' && strings.HasPrefix(query[i:], "--"):
+		end := strings.IndexByte(query[i:], '\n')
+		if end < 0 {
+			return len(query)
+		}
+		return i + end + 1
+	case c == '/' && strings.HasPrefix(query[i:], "/*"):
+		end := strings.Index(query[i+2:], "*/")
+		if end < 0 {
+			return len(query)
+		}
+		return i + end + 4
+	}
+	return i
+}
+
+// splitStatements splits a query string into its statements at semicolons
+// outside literals and comments. Empty statements are dropped.
+func splitStatements(query string) []string {
+	var stmts []string
+	start := 0
+	for i := 0; i < len(query); {
+		if j := skipQuoted(query, i); j != i {
+			i = j
+			continue
+		}
+		if query[i] == ';' {
+			if stmt := strings.TrimSpace(query[start:i]); stmt != "" {
+				stmts = append(stmts, stmt)
+			}
+			start = i + 1
+		}
+		i++
+	}
+	if stmt := strings.TrimSpace(query[start:]); stmt != "" {
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+// placeholder is a $n placeholder found in a query.
+type placeholder struct {
+	start, end int // Byte offsets of the placeholder
+	index      int // n
+}
+
+// placeholders returns the placeholders of query, skipping literals and
+// comments.
+func placeholders(query string) []placeholder {
+	var found []placeholder
+	for i := 0; i < len(query); {
+		if j := skipQuoted(query, i); j != i {
+			i = j
+			continue
+		}
+		if query[i] != '$' {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+			j++
+		}
+		if index, err := strconv.Atoi(query[i+1 : j]); err == nil {
+			found = append(found, placeholder{start: i, end: j, index: index})
+		}
+		i = j
+	}
+	return found
+}
+
+// bindParams substitutes literals for the placeholders of query.
+func bindParams(query string, literals []string) (string, error) {
+	var b strings.Builder
+	last := 0
+	for _, p := range placeholders(query) {
+		if p.index < 1 || p.index > len(literals) {
+			return "", &pgError{code: "42P02", message: fmt.Sprintf("there is no parameter $%d", p.index)}
+		}
+		b.WriteString(query[last:p.start])
+		b.WriteString(literals[p.index-1])
+		last = p.end
+	}
+	b.WriteString(query[last:])
+	return b.String(), nil
+}
+
+// keywords returns the leading words of a statement in upper case, with
+// commas treated as spaces.
+func keywords(stmt string, n int) []string {
+	words := strings.Fields(strings.ToUpper(strings.ReplaceAll(stmt, ",", " ")))
+	if len(words) > n {
+		words = words[:n]
+	}
+	return words
+}
+
+// isRead reports whether a statement only reads, so that it can be run
+// early to describe its result.
+func isRead(stmt string) bool {
+	words := keywords(stmt, 1)
+	if len(words) == 0 {
+		return false
+	}
+	switch words[0] {
+	case "SELECT", "WITH", "VALUES", "INSPECT", "SHOW", "EXPLAIN":
+		return true
+	}
+	return false
+}
+
+// simpleQuery runs a Query message. Statements run one after another until
+// one fails.
+func (s *session) simpleQuery(query string) error {
+	stmts := splitStatements(query)
+	if len(stmts) == 0 {
+		if err := s.send(newMessage(msgEmptyQueryResponse)); err != nil {
+			return err
+		}
+		return s.sendReady()
+	}
+
+	for _, stmt := range stmts {
+		result, err := s.execute(stmt)
+		if err != nil {
+			if err := s.sendError(err); err != nil {
+				return err
+			}
+			break
+		}
+		if result.columns != nil {
+			if err := s.sendRowDescription(result.columns, nil); err != nil {
+				return err
+			}
+		}
+		if err := s.sendRows(result, nil, 0); err != nil {
+			return err
+		}
+	}
+	return s.sendReady()
+}
+
+// execute runs a statement. Transaction control and session parameters
+// are handled here; other statements run on the executor.
+func (s *session) execute(stmt string) (*commandResult, error) {
+	words := keywords(stmt, 4)
+	if len(words) == 0 {
+		return &commandResult{}, nil
+	}
+
+	switch words[0] {
+	case "BEGIN", "START":
+		return s.begin(stmt)
+	case "COMMIT", "END":
+		return s.commit()
+	case "ROLLBACK", "ABORT":
+		if len(words) > 1 && (words[1] == "TO" || len(words) > 2 && words[2] == "TO") {
+			return s.rollbackTo(stmt)
+		}
+		return s.rollbackCommand()
+	}
+
+	if s.txFailed {
+		return nil, &pgError{code: "25P02", message: "current transaction is aborted, commands ignored until end of transaction block"}
+	}
+
+	switch words[0] {
+	case "SAVEPOINT", "RELEASE":
+		return s.savepoint(stmt)
+	case "SET":
+		return s.setCommand(stmt)
+	case "RESET":
+		return s.resetCommand(stmt)
+	case "SHOW":
+		return s.showCommand(stmt)
+	}
+	return s.run(stmt)
+}
+
+// statementContext returns the context of a statement, which carries the
+// session's transaction and ends at statement_timeout or on cancel.
+func (s *session) statementContext() (context.Context, context.CancelFunc) {
+	ctx := context.Background()
+	if s.txID != "" {
+		ctx = protocol.ContextWithTransaction(ctx, s.txID)
+	}
+	var cancel context.CancelFunc
+	if s.statementTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.statementTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	s.cancelMu.Lock()
+	s.cancel = cancel
+	s.cancelMu.Unlock()
+	return ctx, func() {
+		s.cancelMu.Lock()
+		s.cancel = nil
+		s.cancelMu.Unlock()
+		cancel()
+	}
+}
+
+// run executes a statement on the executor.
+func (s *session) run(stmt string) (*commandResult, error) {
+	ctx, done := s.statementContext()
+	defer done()
+
+	res, err := s.h.executor.ExecuteResultContext(ctx, stmt, s.database, s.user)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	if s.txID == "" {
+		if err := s.syncCommitted(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Track database switches, as the binary protocol does
+	if strings.HasPrefix(res.Message, "USE ") && strings.HasSuffix(res.Message, " OK") {
+		s.database = strings.TrimSuffix(strings.TrimPrefix(res.Message, "USE "), " OK")
+	} else if idx := strings.Index(res.Message, "(switched to "); strings.HasPrefix(res.Message, "DROP DATABASE OK") && idx >= 0 {
+		s.database = strings.TrimSuffix(res.Message[idx+len("(switched to "):], ")")
+	}
+
+	result := &commandResult{rows: res.Rows, tag: commandTag(stmt, res)}
+	if len(res.Columns) > 0 {
+		result.columns = make([]column, len(res.Columns))
+		for i, name := range res.Columns {
+			oid := oidText
+			if i < len(res.ColumnTypes) {
+				oid = typeOID(res.ColumnTypes[i])
+			}
+			result.columns[i] = column{name: name, oid: oid}
+		}
+	}
+	return result, nil
+}
+
+// syncCommitted makes completed writes durable under the session's
+// synchronous_commit setting.
+func (s *session) syncCommitted() error {
+	if s.h.commits == nil {
+		return nil
+	}
+	return s.h.commits.SyncCommit(s.syncCommit)
+}
+
+// commandTag returns the CommandComplete tag of a statement.
+func commandTag(stmt string, res *protocol.QueryResult) string {
+	if len(res.Columns) > 0 {
+		return fmt.Sprintf("SELECT %d", len(res.Rows))
+	}
+	words := keywords(stmt, 5)
+	switch words[0] {
+	case "INSERT":
+		return fmt.Sprintf("INSERT 0 %d", res.RowsAffected)
+	case "UPDATE", "DELETE":
+		return fmt.Sprintf("%s %d", words[0], res.RowsAffected)
+	case "CREATE", "DROP", "ALTER":
+		// The object type, skipping modifiers such as UNIQUE
+		for _, word := range words[1:] {
+			switch word {
+			case "OR", "REPLACE", "UNIQUE", "TEMP", "TEMPORARY", "IF":
+				continue
+			}
+			return words[0] + " " + word
+		}
+	case "TRUNCATE":
+		return "TRUNCATE TABLE"
+	}
+	return words[0]
+}
+
+// begin starts a transaction block.
+func (s *session) begin(stmt string) (*commandResult, error) {
+	result := &commandResult{tag: "BEGIN"}
+	if s.txID != "" {
+		return result, s.sendNotice("25001", "there is already a transaction in progress")
+	}
+	if s.h.txMgr == nil {
+		return nil, &pgError{code: "0A000", message: "transactions are not supported"}
+	}
+
+	isolation := isolationLevel(s.parameter("default_transaction_isolation"))
+	readOnly := s.parameter("default_transaction_read_only") == "on"
+	modes := " " + strings.Join(keywords(stmt, 16), " ") + " "
+	if i := strings.Index(modes, " ISOLATION LEVEL "); i >= 0 {
+		isolation = isolationLevel(modes[i+len(" ISOLATION LEVEL "):])
+	}
+	if strings.Contains(modes, " READ ONLY ") {
+		readOnly = true
+	} else if strings.Contains(modes, " READ WRITE ") {
+		readOnly = false
+	}
+
+	txID, err := s.h.txMgr.Begin(s.database, isolation, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	s.txID = txID
+	s.txFailed = false
+	return result, nil
+}
+
+// isolationLevel returns the transaction manager's isolation level (0-3)
+// of a PostgreSQL isolation level name. Unknown names select READ
+// COMMITTED, the PostgreSQL default.
+func isolationLevel(name string) int {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	switch {
+	case strings.HasPrefix(name, "SERIALIZABLE"):
+		return 3
+	case strings.HasPrefix(name, "REPEATABLE READ"):
+		return 2
+	case strings.HasPrefix(name, "READ UNCOMMITTED"):
+		return 0
+	}
+	return 1
+}
+
+// commit ends a transaction block. A failed block is rolled back instead.
+func (s *session) commit() (*commandResult, error) {
+	if s.txID == "" {
+		return &commandResult{tag: "COMMIT"}, s.sendNotice("25P01", "there is no transaction in progress")
+	}
+	if s.txFailed {
+		return s.rollbackCommand()
+	}
+
+	txID := s.txID
+	s.txID, s.txFailed = "", false
+	if err := s.h.txMgr.Commit(txID); err != nil {
+		return nil, err
+	}
+	if err := s.syncCommitted(); err != nil {
+		return nil, err
+	}
+	return &commandResult{tag: "COMMIT"}, nil
+}
+
+// rollbackCommand rolls back a transaction block.
+func (s *session) rollbackCommand() (*commandResult, error) {
+	if s.txID == "" {
+		return &commandResult{tag: "ROLLBACK"}, s.sendNotice("25P01", "there is no transaction in progress")
+	}
+	txID := s.txID
+	s.txID, s.txFailed = "", false
+	if err := s.h.txMgr.Rollback(txID); err != nil {
+		return nil, err
+	}
+	return &commandResult{tag: "ROLLBACK"}, nil
+}
+
+// savepointName returns the savepoint named by the last word of stmt.
+// Unquoted names are case-insensitive.
+func savepointName(stmt string) string {
+	words := strings.Fields(stmt)
+	name := words[len(words)-1]
+	if len(name) >= 2 && name[0] == '"' && name[len(name)-1] == '"' {
+		return name[1 : len(name)-1]
+	}
+	return strings.ToLower(name)
+}
+
+// savepoint handles SAVEPOINT and RELEASE [SAVEPOINT].
+func (s *session) savepoint(stmt string) (*commandResult, error) {
+	words := keywords(stmt, 3)
+	if s.txID == "" {
+		return nil, &pgError{code: "25P01", message: fmt.Sprintf("%s can only be used in transaction blocks", words[0])}
+	}
+	if len(words) < 2 || len(words) == 2 && words[1] == "SAVEPOINT" {
+		return nil, &pgError{code: "42601", message: "savepoint name missing"}
+	}
+	name := savepointName(stmt)
+	if words[0] == "SAVEPOINT" {
+		if err := s.h.txMgr.CreateSavepoint(s.txID, name); err != nil {
+			return nil, err
+		}
+		return &commandResult{tag: "SAVEPOINT"}, nil
+	}
+	if err := s.h.txMgr.ReleaseSavepoint(s.txID, name); err != nil {
+		return nil, err
+	}
+	return &commandResult{tag: "RELEASE"}, nil
+}
+
+// rollbackTo handles ROLLBACK [WORK | TRANSACTION] TO [SAVEPOINT] name,
+// which also clears a failed transaction block.
+func (s *session) rollbackTo(stmt string) (*commandResult, error) {
+	if s.txID == "" {
+		return nil, &pgError{code: "25P01", message: "ROLLBACK TO SAVEPOINT can only be used in transaction blocks"}
+	}
+	if err := s.h.txMgr.RollbackToSavepoint(s.txID, savepointName(stmt)); err != nil {
+		return nil, err
+	}
+	s.txFailed = false
+	return &commandResult{tag: "ROLLBACK"}, nil
+}
+
+// sendRowDescription describes result columns. formats are the result
+// format codes as in Bind.
+func (s *session) sendRowDescription(columns []column, formats []int16) error {
+	m := newMessage(msgRowDescription).int16(len(columns))
+	for i, col := range columns {
+		m.string(col.name)
+		m.int32(0) // Table OID
+		m.int16(0) // Column number
+		m.int32(int(col.oid))
+		m.int16(typeSize(col.oid))
+		m.int32(-1) // Type modifier
+		m.int16(int(formatCode(formats, i)))
+	}
+	return s.send(m)
+}
+
+// resultFormat returns the format code of result column i.
+func formatCode(formats []int16, i int) int16 {
+	switch {
+	case len(formats) == 0:
+		return formatText
+	case len(formats) == 1:
+		return formats[0]
+	case i < len(formats):
+		return formats[i]
+	}
+	return formatText
+}
+
+// sendRows sends the rows of a result from its next row on, followed by
+// CommandComplete. With maxRows > 0, at most maxRows rows are sent and
+// PortalSuspended ends a result that has more.
+func (s *session) sendRows(result *commandResult, p *portal, maxRows int) error {
+	if result.tag == "" {
+		return s.send(newMessage(msgEmptyQueryResponse))
+	}
+
+	var formats []int16
+	next := 0
+	if p != nil {
+		formats, next = p.resultFormats, p.next
+	}
+	end := len(result.rows)
+	if maxRows > 0 && next+maxRows < end {
+		end = next + maxRows
+	}
+
+	for _, row := range result.rows[next:end] {
+		m := newMessage(msgDataRow).int16(len(row))
+		for i, v := range row {
+			if v == nil {
+				m.int32(-1)
+				continue
+			}
+			oid := oidText
+			if i < len(result.columns) {
+				oid = result.columns[i].oid
+			}
+			b := encodeText(v, oid)
+			if formatCode(formats, i) == formatBinary {
+				var err error
+				if b, err = encodeBinary(v, oid); err != nil {
+					return &pgError{code: "22P03", message: fmt.Sprintf("cannot send column %d in binary format: %v", i+1, err)}
+				}
+			}
+			m.int32(len(b)).bytes(b)
+		}
+		if err := s.send(m); err != nil {
+			return err
+		}
+	}
+
+	if p != nil {
+		p.next = end
+		if end < len(result.rows) {
+			return s.send(newMessage(msgPortalSuspended))
+		}
+	}
+	return s.send(newMessage(msgCommandComplete).string(result.tag))
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pgwire
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// scramMechanism is the only SASL mechanism offered.
+const scramMechanism = "SCRAM-SHA-256"
+
+// scramMockIterations is the iteration count of mock verifiers, that of
+// the verifiers FlyDB stores.
+const scramMockIterations = 4096
+
+var (
+	// errAuthFailed is returned when the client's proof is wrong.
+	errAuthFailed = errors.New("authentication failed")
+
+	// errNoVerifier is returned for a user without a SCRAM verifier. Users
+	// created by earlier versions get one when their password is next
+	// checked over the binary protocol or HTTP API, or set again.
+	errNoVerifier = errors.New("user does not exist or has no SCRAM verifier")
+)
+
+// scramVerifier is a parsed SCRAM-SHA-256 verifier.
+type scramVerifier struct {
+	iterations int
+	salt       []byte
+	storedKey  []byte
+	serverKey  []byte
+}
+
+// parseSCRAMVerifier parses a verifier in the format of
+// auth.NewSCRAMVerifier: SCRAM-SHA-256$<iterations>:<salt>$<StoredKey>:<ServerKey>.
+func parseSCRAMVerifier(s string) (*scramVerifier, error) {
+	parts := strings.Split(s, "$")
+	if len(parts) != 3 || parts[0] != scramMechanism {
+		return nil, errors.New("invalid SCRAM verifier")
+	}
+	iterations, salt, ok1 := strings.Cut(parts[1], ":")
+	storedKey, serverKey, ok2 := strings.Cut(parts[2], ":")
+	if !ok1 || !ok2 {
+		return nil, errors.New("invalid SCRAM verifier")
+	}
+
+	v := &scramVerifier{}
+	var err error
+	if v.iterations, err = strconv.Atoi(iterations); err != nil {
+		return nil, fmt.Errorf("invalid SCRAM verifier: %w", err)
+	}
+	enc := base64.StdEncoding
+	if v.salt, err = enc.DecodeString(salt); err != nil {
+		return nil, fmt.Errorf("invalid SCRAM verifier: %w", err)
+	}
+	if v.storedKey, err = enc.DecodeString(storedKey); err != nil {
+		return nil, fmt.Errorf("invalid SCRAM verifier: %w", err)
+	}
+	if v.serverKey, err = enc.DecodeString(serverKey); err != nil {
+		return nil, fmt.Errorf("invalid SCRAM verifier: %w", err)
+	}
+	return v, nil
+}
+
+// scramVerifier returns the verifier a user authenticates against. known
+// is false for users without a valid verifier, who get a mock verifier
+// instead: its salt is the same on every attempt and no proof matches it,
+// so the exchange looks like that of a real user with another password.
+func (h *Handler) scramVerifier(user string) (v *scramVerifier, known bool) {
+	if verifier, ok := h.auth.SCRAMVerifier(user); ok {
+		if v, err := parseSCRAMVerifier(verifier); err == nil {
+			return v, true
+		}
+	}
+	return &scramVerifier{
+		iterations: scramMockIterations,
+		salt:       scramHMAC(h.mockKey, "salt:"+user)[:16],
+		storedKey:  scramHMAC(h.mockKey, "stored key:"+user),
+		serverKey:  scramHMAC(h.mockKey, "server key:"+user),
+	}, false
+}
+
+// scramHMAC returns HMAC-SHA-256(key, message).
+func scramHMAC(key []byte, message string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// scramAttributes parses the comma-separated attributes of a SCRAM
+// message into a map keyed by attribute name.
+func scramAttributes(message string) map[byte]string {
+	attrs := make(map[byte]string)
+	for _, attr := range strings.Split(message, ",") {
+		if len(attr) >= 2 && attr[1] == '=' {
+			attrs[attr[0]] = attr[2:]
+		}
+	}
+	return attrs
+}
+
+// authenticateSCRAM runs a SCRAM-SHA-256 exchange (RFC 5802, RFC 7677)
+// against v. The password never crosses the wire; the client proves it
+// knows it and the server proves it holds the verifier.
+func (s *session) authenticateSCRAM(v *scramVerifier) error {
+	// AuthenticationSASL lists the mechanisms, ending with an empty one
+	if err := s.send(newMessage(msgAuthentication).int32(authSASL).string(scramMechanism).byte(0)); err != nil {
+		return err
+	}
+	if err := s.flush(); err != nil {
+		return err
+	}
+
+	// SASLInitialResponse: mechanism, then client-first-message
+	body, err := s.readAuthMessage()
+	if err != nil {
+		return err
+	}
+	r := &reader{buf: body}
+	mechanism := r.string()
+	clientFirst := string(r.bytes(int(r.int32())))
+	if r.err != nil {
+		return r.err
+	}
+	if mechanism != scramMechanism {
+		return fmt.Errorf("unsupported SASL mechanism %q", mechanism)
+	}
+
+	// Channel binding is not offered, so the GS2 header is "n,," or "y,,"
+	if !strings.HasPrefix(clientFirst, "n,,") && !strings.HasPrefix(clientFirst, "y,,") {
+		return errors.New("unsupported SCRAM channel binding")
+	}
+	gs2Header := clientFirst[:3]
+	clientFirstBare := clientFirst[3:]
+	clientNonce := scramAttributes(clientFirstBare)['r']
+	if clientNonce == "" {
+		return errors.New("SCRAM client nonce missing")
+	}
+
+	nonce := make([]byte, 18)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	combinedNonce := clientNonce + base64.StdEncoding.EncodeToString(nonce)
+	serverFirst := fmt.Sprintf("r=%s,s=%s,i=%d", combinedNonce, base64.StdEncoding.EncodeToString(v.salt), v.iterations)
+	if err := s.send(newMessage(msgAuthentication).int32(authSASLContinue).bytes([]byte(serverFirst))); err != nil {
+		return err
+	}
+	if err := s.flush(); err != nil {
+		return err
+	}
+
+	// SASLResponse: client-final-message
+	body, err = s.readAuthMessage()
+	if err != nil {
+		return err
+	}
+	clientFinal := string(body)
+	i := strings.LastIndex(clientFinal, ",p=")
+	if i < 0 {
+		return errors.New("SCRAM client proof missing")
+	}
+	clientFinalWithoutProof := clientFinal[:i]
+	attrs := scramAttributes(clientFinalWithoutProof)
+	if attrs['c'] != base64.StdEncoding.EncodeToString([]byte(gs2Header)) || attrs['r'] != combinedNonce {
+		return errAuthFailed
+	}
+	proof, err := base64.StdEncoding.DecodeString(clientFinal[i+3:])
+	if err != nil || len(proof) != sha256.Size {
+		return errAuthFailed
+	}
+
+	// The proof is ClientKey XOR ClientSignature; H(ClientKey) must be StoredKey
+	authMessage := clientFirstBare + "," + serverFirst + "," + clientFinalWithoutProof
+	clientSignature := scramHMAC(v.storedKey, authMessage)
+	clientKey := make([]byte, len(proof))
+	for i := range proof {
+		clientKey[i] = proof[i] ^ clientSignature[i]
+	}
+	storedKey := sha256.Sum256(clientKey)
+	if subtle.ConstantTimeCompare(storedKey[:], v.storedKey) != 1 {
+		return errAuthFailed
+	}
+
+	serverSignature := scramHMAC(v.serverKey, authMessage)
+	serverFinal := "v=" + base64.StdEncoding.EncodeToString(serverSignature)
+	return s.send(newMessage(msgAuthentication).int32(authSASLFinal).bytes([]byte(serverFinal)))
+}
+
+// readAuthMessage reads a SASLInitialResponse or SASLResponse.
+func (s *session) readAuthMessage() ([]byte, error) {
+	typ, body, err := readMessage(s.r)
+	if err != nil {
+		return nil, err
+	}
+	if typ != msgPassword {
+		return nil, fmt.Errorf("expected password response, got message type %q", typ)
+	}
+	return body, nil
+}
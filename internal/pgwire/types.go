@@ -0,0 +1,512 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pgwire
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"flydb/internal/literal"
+)
+
+// PostgreSQL type OIDs of the types FlyDB values are described as.
+const (
+	oidUnspecified uint32 = 0
+	oidBool        uint32 = 16
+	oidBytea       uint32 = 17
+	oidInt8        uint32 = 20
+	oidInt2        uint32 = 21
+	oidInt4        uint32 = 23
+	oidText        uint32 = 25
+	oidJSON        uint32 = 114
+	oidFloat4      uint32 = 700
+	oidFloat8      uint32 = 701
+	oidUnknown     uint32 = 705
+	oidVarchar     uint32 = 1043
+	oidDate        uint32 = 1082
+	oidTime        uint32 = 1083
+	oidTimestamp   uint32 = 1114
+	oidTimestampTZ uint32 = 1184
+	oidNumeric     uint32 = 1700
+	oidUUID        uint32 = 2950
+	oidJSONB       uint32 = 3802
+)
+
+// Format codes of parameters and result columns.
+const (
+	formatText   int16 = 0
+	formatBinary int16 = 1
+)
+
+// pgEpoch is the epoch of binary dates and timestamps.
+var pgEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// typeOID returns the OID of the PostgreSQL type a FlyDB column type is
+// described as. Types without a counterpart are described as text.
+func typeOID(columnType string) uint32 {
+	switch columnType {
+	case "BOOLEAN":
+		return oidBool
+	case "SMALLINT":
+		return oidInt2
+	case "INT":
+		return oidInt4
+	case "BIGINT", "SERIAL":
+		return oidInt8
+	case "REAL":
+		return oidFloat4
+	case "FLOAT", "DOUBLE":
+		return oidFloat8
+	case "DECIMAL", "MONEY":
+		return oidNumeric
+	case "TIMESTAMP", "DATETIME":
+		return oidTimestampTZ
+	case "DATE":
+		return oidDate
+	case "TIME":
+		return oidTime
+	case "UUID":
+		return oidUUID
+	case "JSONB":
+		return oidJSONB
+	case "BLOB", "BYTEA", "BINARY", "VARBINARY":
+		return oidBytea
+	case "VARCHAR", "NVARCHAR":
+		return oidVarchar
+	}
+	return oidText
+}
+
+// typeSize returns the size of a type in RowDescription; -1 means variable.
+func typeSize(oid uint32) int {
+	switch oid {
+	case oidBool:
+		return 1
+	case oidInt2:
+		return 2
+	case oidInt4, oidFloat4, oidDate:
+		return 4
+	case oidInt8, oidFloat8, oidTime, oidTimestamp, oidTimestampTZ:
+		return 8
+	case oidUUID:
+		return 16
+	}
+	return -1
+}
+
+// encodeText returns the text format of a result value.
+func encodeText(v interface{}, oid uint32) []byte {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return []byte("t")
+		}
+		return []byte("f")
+	case int64:
+		return strconv.AppendInt(nil, v, 10)
+	case float64:
+		return []byte(formatFloat(v, 64))
+	case string:
+		switch oid {
+		case oidTimestampTZ:
+			if t, err := parseTimestamp(v); err == nil {
+				return []byte(formatTimestamp(t))
+			}
+		case oidBytea:
+			if b, err := base64.StdEncoding.DecodeString(v); err == nil {
+				return []byte(`\x` + hex.EncodeToString(b))
+			}
+		case oidBool:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return encodeText(b, oid)
+			}
+		}
+		return []byte(v)
+	}
+	return []byte(fmt.Sprint(v))
+}
+
+// encodeBinary returns the binary format of a result value.
+func encodeBinary(v interface{}, oid uint32) ([]byte, error) {
+	switch oid {
+	case oidBool:
+		b, ok := v.(bool)
+		if !ok {
+			var err error
+			if b, err = strconv.ParseBool(fmt.Sprint(v)); err != nil {
+				return nil, err
+			}
+		}
+		if b {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case oidInt2, oidInt4, oidInt8:
+		n, err := toInt64(v)
+		if err != nil {
+			return nil, err
+		}
+		switch oid {
+		case oidInt2:
+			return binary.BigEndian.AppendUint16(nil, uint16(n)), nil
+		case oidInt4:
+			return binary.BigEndian.AppendUint32(nil, uint32(n)), nil
+		}
+		return binary.BigEndian.AppendUint64(nil, uint64(n)), nil
+	case oidFloat4, oidFloat8:
+		f, err := toFloat64(v)
+		if err != nil {
+			return nil, err
+		}
+		if oid == oidFloat4 {
+			return binary.BigEndian.AppendUint32(nil, math.Float32bits(float32(f))), nil
+		}
+		return binary.BigEndian.AppendUint64(nil, math.Float64bits(f)), nil
+	case oidNumeric:
+		return encodeNumeric(string(encodeText(v, oid)))
+	case oidTimestampTZ, oidTimestamp:
+		t, err := parseTimestamp(fmt.Sprint(v))
+		if err != nil {
+			return nil, err
+		}
+		return binary.BigEndian.AppendUint64(nil, uint64(t.Sub(pgEpoch).Microseconds())), nil
+	case oidDate:
+		t, err := time.Parse("2006-01-02", fmt.Sprint(v))
+		if err != nil {
+			return nil, err
+		}
+		days := int32(t.Sub(pgEpoch).Hours() / 24)
+		return binary.BigEndian.AppendUint32(nil, uint32(days)), nil
+	case oidTime:
+		t, err := time.Parse("15:04:05.999999", fmt.Sprint(v))
+		if err != nil {
+			return nil, err
+		}
+		midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return binary.BigEndian.AppendUint64(nil, uint64(t.Sub(midnight).Microseconds())), nil
+	case oidUUID:
+		b, err := hex.DecodeString(strings.ReplaceAll(fmt.Sprint(v), "-", ""))
+		if err != nil || len(b) != 16 {
+			return nil, fmt.Errorf("invalid UUID %q", v)
+		}
+		return b, nil
+	case oidJSONB:
+		return append([]byte{1}, encodeText(v, oid)...), nil
+	case oidBytea:
+		if s, ok := v.(string); ok {
+			if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+				return b, nil
+			}
+		}
+	}
+	return encodeText(v, oid), nil
+}
+
+// toInt64 converts a result value to an integer.
+func toInt64(v interface{}) (int64, error) {
+	switch v := v.(type) {
+	case int64:
+		return v, nil
+	case float64:
+		return int64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return strconv.ParseInt(fmt.Sprint(v), 10, 64)
+}
+
+// toFloat64 converts a result value to a float.
+func toFloat64(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	}
+	return strconv.ParseFloat(fmt.Sprint(v), 64)
+}
+
+// formatFloat formats a float as PostgreSQL does.
+func formatFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
+
+// parseTimestamp parses a stored timestamp.
+func parseTimestamp(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+// formatTimestamp formats a timestamp with time zone as PostgreSQL does,
+// such as "2026-01-02 03:04:05.5+00".
+func formatTimestamp(t time.Time) string {
+	s := t.Format("2006-01-02 15:04:05.999999")
+	_, offset := t.Zone()
+	sign := byte('+')
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	s += fmt.Sprintf("%c%02d", sign, offset/3600)
+	if minutes := offset % 3600 / 60; minutes != 0 {
+		s += fmt.Sprintf(":%02d", minutes)
+	}
+	return s
+}
+
+// encodeNumeric returns the binary format of a decimal number: base-10000
+// digits with a weight, a sign and a display scale.
+func encodeNumeric(s string) ([]byte, error) {
+	const (
+		numericPos = 0x0000
+		numericNeg = 0x4000
+		numericNaN = 0xC000
+	)
+	if s == "NaN" {
+		return binary.BigEndian.AppendUint64(nil, numericNaN<<16), nil
+	}
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	sign := numericPos
+	if strings.HasPrefix(s, "-") {
+		sign = numericNeg
+		s = s[1:]
+	} else {
+		s = strings.TrimPrefix(s, "+")
+	}
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" || strings.Trim(intPart+fracPart, "0123456789") != "" {
+		return nil, fmt.Errorf("invalid numeric %q", s)
+	}
+	dscale := len(fracPart)
+
+	// Align both parts to groups of four decimal digits
+	intPart = strings.Repeat("0", (4-len(intPart)%4)%4) + intPart
+	fracPart += strings.Repeat("0", (4-len(fracPart)%4)%4)
+	var digits []int
+	for i := 0; i < len(intPart+fracPart); i += 4 {
+		d, _ := strconv.Atoi((intPart + fracPart)[i : i+4])
+		digits = append(digits, d)
+	}
+	weight := len(intPart)/4 - 1
+	for len(digits) > 0 && digits[0] == 0 {
+		digits = digits[1:]
+		weight--
+	}
+	for len(digits) > 0 && digits[len(digits)-1] == 0 {
+		digits = digits[:len(digits)-1]
+	}
+	if len(digits) == 0 {
+		weight, sign = 0, numericPos
+	}
+
+	b := make([]byte, 0, 8+2*len(digits))
+	b = binary.BigEndian.AppendUint16(b, uint16(len(digits)))
+	b = binary.BigEndian.AppendUint16(b, uint16(int16(weight)))
+	b = binary.BigEndian.AppendUint16(b, uint16(sign))
+	b = binary.BigEndian.AppendUint16(b, uint16(dscale))
+	for _, d := range digits {
+		b = binary.BigEndian.AppendUint16(b, uint16(d))
+	}
+	return b, nil
+}
+
+// decodeNumeric returns the text format of a binary decimal number.
+func decodeNumeric(b []byte) (string, error) {
+	r := &reader{buf: b}
+	ndigits := int(r.int16())
+	weight := int(r.int16())
+	sign := uint16(r.int16())
+	dscale := int(r.int16())
+	if r.err != nil || ndigits < 0 || dscale < 0 || len(r.buf) != 2*ndigits {
+		return "", errMalformed
+	}
+	if sign == 0xC000 {
+		return "NaN", nil
+	}
+
+	var intPart, fracPart strings.Builder
+	for i := 0; i <= weight || i < ndigits; i++ {
+		var d int16
+		if i < ndigits {
+			d = r.int16()
+		}
+		if i <= weight {
+			fmt.Fprintf(&intPart, "%04d", d)
+		} else {
+			fmt.Fprintf(&fracPart, "%04d", d)
+		}
+	}
+	// Groups between the point and the first digit are zero
+	frac := strings.Repeat("0000", max(-weight-1, 0)) + fracPart.String()
+	frac = (frac + strings.Repeat("0", dscale))[:dscale]
+
+	s := strings.TrimLeft(intPart.String(), "0")
+	if s == "" {
+		s = "0"
+	}
+	if dscale > 0 {
+		s += "." + frac
+	}
+	if sign == 0x4000 {
+		s = "-" + s
+	}
+	return s, nil
+}
+
+// decodeBinaryParam returns the text format of a binary parameter value.
+func decodeBinaryParam(b []byte, oid uint32) (string, error) {
+	switch oid {
+	case oidBool:
+		if len(b) != 1 {
+			return "", errMalformed
+		}
+		return strconv.FormatBool(b[0] != 0), nil
+	case oidInt2:
+		if len(b) != 2 {
+			return "", errMalformed
+		}
+		return strconv.Itoa(int(int16(binary.BigEndian.Uint16(b)))), nil
+	case oidInt4:
+		if len(b) != 4 {
+			return "", errMalformed
+		}
+		return strconv.Itoa(int(int32(binary.BigEndian.Uint32(b)))), nil
+	case oidInt8:
+		if len(b) != 8 {
+			return "", errMalformed
+		}
+		return strconv.FormatInt(int64(binary.BigEndian.Uint64(b)), 10), nil
+	case oidFloat4:
+		if len(b) != 4 {
+			return "", errMalformed
+		}
+		return formatFloat(float64(math.Float32frombits(binary.BigEndian.Uint32(b))), 32), nil
+	case oidFloat8:
+		if len(b) != 8 {
+			return "", errMalformed
+		}
+		return formatFloat(math.Float64frombits(binary.BigEndian.Uint64(b)), 64), nil
+	case oidNumeric:
+		return decodeNumeric(b)
+	case oidTimestamp, oidTimestampTZ:
+		if len(b) != 8 {
+			return "", errMalformed
+		}
+		t := pgEpoch.Add(time.Duration(int64(binary.BigEndian.Uint64(b))) * time.Microsecond)
+		return t.Format(time.RFC3339Nano), nil
+	case oidDate:
+		if len(b) != 4 {
+			return "", errMalformed
+		}
+		return pgEpoch.AddDate(0, 0, int(int32(binary.BigEndian.Uint32(b)))).Format("2006-01-02"), nil
+	case oidTime:
+		if len(b) != 8 {
+			return "", errMalformed
+		}
+		t := pgEpoch.Add(time.Duration(int64(binary.BigEndian.Uint64(b))) * time.Microsecond)
+		return t.Format("15:04:05.999999"), nil
+	case oidUUID:
+		if len(b) != 16 {
+			return "", errMalformed
+		}
+		h := hex.EncodeToString(b)
+		return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+	case oidJSONB:
+		if len(b) == 0 || b[0] != 1 {
+			return "", errMalformed
+		}
+		return string(b[1:]), nil
+	case oidText, oidVarchar, oidJSON, oidUnknown, oidUnspecified, oidBytea:
+		return string(b), nil
+	}
+	return "", fmt.Errorf("binary format of type %d is not supported", oid)
+}
+
+// paramLiteral returns the SQL literal a bound parameter is substituted
+// as. Numbers are inlined bare so they work where FlyDB requires a number,
+// such as LIMIT; everything else is quoted.
+func paramLiteral(value []byte, format int16, oid uint32) (string, error) {
+	if value == nil {
+		return "NULL", nil
+	}
+	s := string(value)
+	if format == formatBinary {
+		var err error
+		if s, err = decodeBinaryParam(value, oid); err != nil {
+			return "", err
+		}
+	}
+
+	switch oid {
+	case oidInt2, oidInt4, oidInt8, oidFloat4, oidFloat8, oidNumeric:
+		s = strings.TrimSpace(s)
+		if !literal.IsNumber(s) {
+			return literal.Quote(s), nil
+		}
+		return s, nil
+	case oidBool:
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "t", "true", "y", "yes", "on", "1":
+			return "TRUE", nil
+		case "f", "false", "n", "no", "off", "0":
+			return "FALSE", nil
+		}
+		return "", fmt.Errorf("invalid input syntax for type boolean: %q", s)
+	case oidBytea:
+		b := value
+		if format == formatText && strings.HasPrefix(s, `\x`) {
+			var err error
+			if b, err = hex.DecodeString(s[2:]); err != nil {
+				return "", fmt.Errorf("invalid input syntax for type bytea: %v", err)
+			}
+		}
+		return literal.Quote(base64.StdEncoding.EncodeToString(b)), nil
+	case oidUnspecified, oidUnknown:
+		if literal.IsNumber(s) {
+			return s, nil
+		}
+	}
+	return literal.Quote(s), nil
+}
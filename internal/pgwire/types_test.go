@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pgwire
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNumericRoundTrip(t *testing.T) {
+	for _, s := range []string{"0", "1", "-1", "123.45", "10000", "0.0001", "-98765.4321", "12345678901234567890.5"} {
+		b, err := encodeNumeric(s)
+		if err != nil {
+			t.Errorf("encodeNumeric(%q) failed: %v", s, err)
+			continue
+		}
+		got, err := decodeNumeric(b)
+		if err != nil {
+			t.Errorf("decodeNumeric(encodeNumeric(%q)) failed: %v", s, err)
+			continue
+		}
+		if got != s {
+			t.Errorf("Numeric %q round-tripped to %q", s, got)
+		}
+	}
+	if _, err := encodeNumeric("abc"); err == nil {
+		t.Error("encodeNumeric accepted a non-number")
+	}
+}
+
+func TestParamLiteral(t *testing.T) {
+	int4 := binary.BigEndian.AppendUint32(nil, 42)
+	tests := []struct {
+		value  []byte
+		format int16
+		oid    uint32
+		want   string
+	}{
+		{nil, formatText, oidInt4, "NULL"},
+		{[]byte("42"), formatText, oidUnspecified, "42"},
+		{[]byte("1.5"), formatText, oidFloat8, "1.5"},
+		{[]byte("-7"), formatText, oidInt8, "'-7'"},
+		{[]byte("O'Brien"), formatText, oidText, "'O''Brien'"},
+		{[]byte("7"), formatText, oidText, "'7'"},
+		{[]byte("on"), formatText, oidBool, "TRUE"},
+		{[]byte(`\x6869`), formatText, oidBytea, "'aGk='"},
+		{int4, formatBinary, oidInt4, "42"},
+		{[]byte{1}, formatBinary, oidBool, "TRUE"},
+	}
+	for _, tt := range tests {
+		got, err := paramLiteral(tt.value, tt.format, tt.oid)
+		if err != nil {
+			t.Errorf("paramLiteral(%q, %d, %d) failed: %v", tt.value, tt.format, tt.oid, err)
+		} else if got != tt.want {
+			t.Errorf("paramLiteral(%q, %d, %d) = %s, want %s", tt.value, tt.format, tt.oid, got, tt.want)
+		}
+	}
+
+	if _, err := paramLiteral([]byte("maybe"), formatText, oidBool); err == nil {
+		t.Error("paramLiteral accepted an invalid boolean")
+	}
+	if _, err := paramLiteral([]byte{0, 1}, formatBinary, oidInt4); err == nil {
+		t.Error("paramLiteral accepted a short binary int4")
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	ts := time.Date(2026, 3, 4, 5, 6, 7, 500000000, time.UTC)
+	if got := formatTimestamp(ts); got != "2026-03-04 05:06:07.5+00" {
+		t.Errorf("formatTimestamp = %s", got)
+	}
+	ts = time.Date(2026, 3, 4, 5, 6, 7, 0, time.FixedZone("", 5*3600+30*60))
+	if got := formatTimestamp(ts); got != "2026-03-04 05:06:07+05:30" {
+		t.Errorf("formatTimestamp = %s", got)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	query := `INSERT INTO t VALUES ('a;b'); -- c;d
+SELECT "x;y" FROM t /* e;f */;; SELECT $1`
+	stmts := splitStatements(query)
+	if len(stmts) != 3 || stmts[0] != "INSERT INTO t VALUES ('a;b')" || stmts[2] != "SELECT $1" {
+		t.Fatalf("splitStatements = %q", stmts)
+	}
+
+	bound, err := bindParams("SELECT '$1', $2 FROM t WHERE a = $1 -- $3", []string{"1", "'x'"})
+	if err != nil || bound != "SELECT '$1', 'x' FROM t WHERE a = 1 -- $3" {
+		t.Errorf("bindParams = %q, %v", bound, err)
+	}
+	if _, err := bindParams("SELECT $3", []string{"1"}); err == nil || !strings.Contains(err.Error(), "$3") {
+		t.Errorf("bindParams of a missing parameter returned %v", err)
+	}
+}
@@ -0,0 +1,461 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Binary Result Encoding
+======================
+
+Under protocol version 2 query and cursor results are encoded in binary
+rather than JSON. Integers use varints (zig-zag for signed values) and
+strings a varint length prefix.
+
+Each row is written as its value count n (uvarint), a null bitmap of
+(n+7)/8 bytes in which bit i%8 of byte i/8 is set if value i is NULL, and
+each non-NULL value as a type tag byte followed by its data:
+
+	0x01 int     varint
+	0x02 float   8 bytes, IEEE 754 big-endian
+	0x03 bool    1 byte
+	0x04 string  uvarint length + UTF-8 bytes
+	0x05 bytes   uvarint length + raw bytes
+	0x06 time    varint nanoseconds since the Unix epoch
+
+A row list is the row count (uvarint) followed by the rows. The messages
+are laid out as follows.
+
+QueryResultMessage:
+  - flags byte (0x01 = success)
+  - message (string), columns (uvarint count + strings),
+    column types (uvarint count + strings)
+  - row list
+  - row count (uvarint), rows affected (varint), last insert ID (varint)
+
+CursorResultMessage:
+  - flags byte (0x01 = success, 0x02 = has more rows)
+  - cursor ID (string), message (string)
+  - columns (uvarint count + column metadata)
+  - row list
+  - row count (uvarint), position (varint), total rows (varint)
+
+Decoded integers are int64, so callers see the same Go types the server
+produced instead of the float64 that JSON numbers decode to.
+*/
+package protocol
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Type tags of values in binary rows.
+const (
+	binaryValueInt    byte = 0x01
+	binaryValueFloat  byte = 0x02
+	binaryValueBool   byte = 0x03
+	binaryValueString byte = 0x04
+	binaryValueBytes  byte = 0x05
+	binaryValueTime   byte = 0x06
+)
+
+// Flags of binary result messages.
+const (
+	binaryFlagSuccess byte = 0x01
+	binaryFlagHasMore byte = 0x02
+)
+
+// EncodeVersion encodes the message for the given protocol version.
+func (m *QueryResultMessage) EncodeVersion(version byte) ([]byte, error) {
+	if version < ProtocolVersionBinary {
+		return m.Encode()
+	}
+
+	e := NewBinaryEncoder()
+	var flags byte
+	if m.Success {
+		flags |= binaryFlagSuccess
+	}
+	e.buf.WriteByte(flags)
+	e.WriteVarString(m.Message)
+	e.writeStrings(m.Columns)
+	e.writeStrings(m.ColumnTypes)
+	if err := e.WriteRows(m.Rows); err != nil {
+		return nil, err
+	}
+	e.WriteUvarint(uint64(m.RowCount))
+	e.WriteVarint(m.RowsAffected)
+	e.WriteVarint(m.LastInsertID)
+	return e.Bytes(), nil
+}
+
+// DecodeQueryResult decodes a query result message in the encoding given
+// by the protocol version of its header.
+func DecodeQueryResult(msg *Message) (*QueryResultMessage, error) {
+	if msg.Header.Version < ProtocolVersionBinary {
+		return DecodeQueryResultMessage(msg.Payload)
+	}
+
+	d := NewBinaryDecoder(msg.Payload)
+	flags, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	m := &QueryResultMessage{Success: flags&binaryFlagSuccess != 0}
+	if m.Message, err = d.ReadVarString(); err != nil {
+		return nil, err
+	}
+	if m.Columns, err = d.readStrings(); err != nil {
+		return nil, err
+	}
+	if m.ColumnTypes, err = d.readStrings(); err != nil {
+		return nil, err
+	}
+	if m.Rows, err = d.ReadRows(); err != nil {
+		return nil, err
+	}
+	rowCount, err := d.ReadUvarint()
+	if err != nil {
+		return nil, err
+	}
+	m.RowCount = int(rowCount)
+	if m.RowsAffected, err = d.ReadVarint(); err != nil {
+		return nil, err
+	}
+	if m.LastInsertID, err = d.ReadVarint(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EncodeVersion encodes the message for the given protocol version.
+func (m *CursorResultMessage) EncodeVersion(version byte) ([]byte, error) {
+	if version < ProtocolVersionBinary {
+		return m.Encode()
+	}
+
+	e := NewBinaryEncoder()
+	var flags byte
+	if m.Success {
+		flags |= binaryFlagSuccess
+	}
+	if m.HasMoreRows {
+		flags |= binaryFlagHasMore
+	}
+	e.buf.WriteByte(flags)
+	e.WriteVarString(m.CursorID)
+	e.WriteVarString(m.Message)
+	e.WriteUvarint(uint64(len(m.Columns)))
+	for _, col := range m.Columns {
+		e.writeColumnMetadata(col)
+	}
+	if err := e.WriteRows(m.Rows); err != nil {
+		return nil, err
+	}
+	e.WriteUvarint(uint64(m.RowCount))
+	e.WriteVarint(m.Position)
+	e.WriteVarint(m.TotalRows)
+	return e.Bytes(), nil
+}
+
+// DecodeCursorResult decodes a cursor result message in the encoding given
+// by the protocol version of its header.
+func DecodeCursorResult(msg *Message) (*CursorResultMessage, error) {
+	if msg.Header.Version < ProtocolVersionBinary {
+		return DecodeCursorResultMessage(msg.Payload)
+	}
+
+	d := NewBinaryDecoder(msg.Payload)
+	flags, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	m := &CursorResultMessage{
+		Success:     flags&binaryFlagSuccess != 0,
+		HasMoreRows: flags&binaryFlagHasMore != 0,
+	}
+	if m.CursorID, err = d.ReadVarString(); err != nil {
+		return nil, err
+	}
+	if m.Message, err = d.ReadVarString(); err != nil {
+		return nil, err
+	}
+	count, err := d.readCount()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < count; i++ {
+		col, err := d.readColumnMetadata()
+		if err != nil {
+			return nil, err
+		}
+		m.Columns = append(m.Columns, col)
+	}
+	if m.Rows, err = d.ReadRows(); err != nil {
+		return nil, err
+	}
+	rowCount, err := d.ReadUvarint()
+	if err != nil {
+		return nil, err
+	}
+	m.RowCount = int(rowCount)
+	if m.Position, err = d.ReadVarint(); err != nil {
+		return nil, err
+	}
+	if m.TotalRows, err = d.ReadVarint(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// WriteRows writes rows with a null bitmap and typed values.
+func (e *BinaryEncoder) WriteRows(rows [][]interface{}) error {
+	e.WriteUvarint(uint64(len(rows)))
+	for _, row := range rows {
+		e.WriteUvarint(uint64(len(row)))
+		bitmap := make([]byte, (len(row)+7)/8)
+		for i, v := range row {
+			if v == nil {
+				bitmap[i/8] |= 1 << (i % 8)
+			}
+		}
+		e.buf.Write(bitmap)
+		for _, v := range row {
+			if v == nil {
+				continue
+			}
+			if err := e.WriteValue(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteValue writes a non-NULL value as a type tag and its data. Values of
+// other types are written as their string form.
+func (e *BinaryEncoder) WriteValue(v interface{}) error {
+	switch val := v.(type) {
+	case int:
+		return e.writeInt(int64(val))
+	case int8:
+		return e.writeInt(int64(val))
+	case int16:
+		return e.writeInt(int64(val))
+	case int32:
+		return e.writeInt(int64(val))
+	case int64:
+		return e.writeInt(val)
+	case uint8:
+		return e.writeInt(int64(val))
+	case uint16:
+		return e.writeInt(int64(val))
+	case uint32:
+		return e.writeInt(int64(val))
+	case float32:
+		return e.writeFloat(float64(val))
+	case float64:
+		return e.writeFloat(val)
+	case bool:
+		e.buf.WriteByte(binaryValueBool)
+		return e.WriteBool(val)
+	case string:
+		e.buf.WriteByte(binaryValueString)
+		return e.WriteVarString(val)
+	case []byte:
+		e.buf.WriteByte(binaryValueBytes)
+		if err := e.WriteUvarint(uint64(len(val))); err != nil {
+			return err
+		}
+		_, err := e.buf.Write(val)
+		return err
+	case time.Time:
+		e.buf.WriteByte(binaryValueTime)
+		return e.WriteVarint(val.UnixNano())
+	default:
+		e.buf.WriteByte(binaryValueString)
+		return e.WriteVarString(fmt.Sprintf("%v", val))
+	}
+}
+
+func (e *BinaryEncoder) writeInt(v int64) error {
+	e.buf.WriteByte(binaryValueInt)
+	return e.WriteVarint(v)
+}
+
+func (e *BinaryEncoder) writeFloat(v float64) error {
+	e.buf.WriteByte(binaryValueFloat)
+	return e.WriteFloat64(v)
+}
+
+// writeStrings writes a count-prefixed list of strings.
+func (e *BinaryEncoder) writeStrings(values []string) {
+	e.WriteUvarint(uint64(len(values)))
+	for _, s := range values {
+		e.WriteVarString(s)
+	}
+}
+
+// writeColumnMetadata writes the metadata of a cursor column.
+func (e *BinaryEncoder) writeColumnMetadata(col ColumnMetadata) {
+	e.WriteVarint(int64(col.Index))
+	e.WriteVarString(col.Name)
+	e.WriteVarString(col.Label)
+	e.WriteVarString(col.Type)
+	e.WriteVarint(int64(col.TypeCode))
+	e.WriteVarint(int64(col.Precision))
+	e.WriteVarint(int64(col.Scale))
+	e.WriteVarint(int64(col.DisplaySize))
+	var flags byte
+	if col.Nullable {
+		flags |= 0x01
+	}
+	if col.AutoIncrement {
+		flags |= 0x02
+	}
+	if col.ReadOnly {
+		flags |= 0x04
+	}
+	e.buf.WriteByte(flags)
+	e.WriteVarString(col.TableName)
+	e.WriteVarString(col.SchemaName)
+}
+
+// ReadRows reads rows written by WriteRows.
+func (d *BinaryDecoder) ReadRows() ([][]interface{}, error) {
+	count, err := d.readCount()
+	if err != nil || count == 0 {
+		return nil, err
+	}
+	rows := make([][]interface{}, count)
+	for r := range rows {
+		n, err := d.readCount()
+		if err != nil {
+			return nil, err
+		}
+		bitmap := make([]byte, (n+7)/8)
+		if _, err := io.ReadFull(d.r, bitmap); err != nil {
+			return nil, err
+		}
+		row := make([]interface{}, n)
+		for i := range row {
+			if bitmap[i/8]&(1<<(i%8)) != 0 {
+				continue
+			}
+			if row[i], err = d.ReadValue(); err != nil {
+				return nil, err
+			}
+		}
+		rows[r] = row
+	}
+	return rows, nil
+}
+
+// ReadValue reads a value written by WriteValue.
+func (d *BinaryDecoder) ReadValue() (interface{}, error) {
+	tag, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch tag {
+	case binaryValueInt:
+		return d.ReadVarint()
+	case binaryValueFloat:
+		return d.ReadFloat64()
+	case binaryValueBool:
+		return d.ReadBool()
+	case binaryValueString:
+		return d.ReadVarString()
+	case binaryValueBytes:
+		s, err := d.ReadVarString()
+		return []byte(s), err
+	case binaryValueTime:
+		nanos, err := d.ReadVarint()
+		return time.Unix(0, nanos).UTC(), err
+	default:
+		return nil, fmt.Errorf("%w: unknown value type 0x%02x", ErrInvalidMessage, tag)
+	}
+}
+
+// readCount reads a uvarint element count, rejecting counts that cannot fit
+// in the remaining payload. Every element takes at least one bit.
+func (d *BinaryDecoder) readCount() (int, error) {
+	n, err := d.ReadUvarint()
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(d.r.Len())*8 {
+		return 0, ErrInvalidMessage
+	}
+	return int(n), nil
+}
+
+// readStrings reads a list written by writeStrings.
+func (d *BinaryDecoder) readStrings() ([]string, error) {
+	count, err := d.readCount()
+	if err != nil || count == 0 {
+		return nil, err
+	}
+	values := make([]string, count)
+	for i := range values {
+		if values[i], err = d.ReadVarString(); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// readColumnMetadata reads metadata written by writeColumnMetadata.
+func (d *BinaryDecoder) readColumnMetadata() (ColumnMetadata, error) {
+	var col ColumnMetadata
+	var ints [5]int64
+	var err error
+	if ints[0], err = d.ReadVarint(); err != nil {
+		return col, err
+	}
+	if col.Name, err = d.ReadVarString(); err != nil {
+		return col, err
+	}
+	if col.Label, err = d.ReadVarString(); err != nil {
+		return col, err
+	}
+	if col.Type, err = d.ReadVarString(); err != nil {
+		return col, err
+	}
+	for i := 1; i < len(ints); i++ {
+		if ints[i], err = d.ReadVarint(); err != nil {
+			return col, err
+		}
+	}
+	flags, err := d.r.ReadByte()
+	if err != nil {
+		return col, err
+	}
+	if col.TableName, err = d.ReadVarString(); err != nil {
+		return col, err
+	}
+	if col.SchemaName, err = d.ReadVarString(); err != nil {
+		return col, err
+	}
+	col.Index = int(ints[0])
+	col.TypeCode = int(ints[1])
+	col.Precision = int(ints[2])
+	col.Scale = int(ints[3])
+	col.DisplaySize = int(ints[4])
+	col.Nullable = flags&0x01 != 0
+	col.AutoIncrement = flags&0x02 != 0
+	col.ReadOnly = flags&0x04 != 0
+	return col, nil
+}
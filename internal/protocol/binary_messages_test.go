@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// roundTrip writes a payload with the given version and reads it back.
+func roundTrip(t *testing.T, version byte, msgType MessageType, payload []byte) *Message {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	if err := WriteMessageVersion(buf, version, msgType, payload); err != nil {
+		t.Fatalf("WriteMessageVersion failed: %v", err)
+	}
+	msg, err := ReadMessage(buf)
+	if err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+	return msg
+}
+
+func TestBinaryQueryResultMessage(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+	original := &QueryResultMessage{
+		Success:      true,
+		Message:      "id, name, score, active, data, at\n(2 rows)",
+		Columns:      []string{"id", "name", "score", "active", "data", "at"},
+		ColumnTypes:  []string{"BIGINT", "TEXT", "DOUBLE", "BOOLEAN", "BLOB", "TIMESTAMP"},
+		Rows:         [][]interface{}{{int64(-7), "Smith, Jane", 1.5, true, []byte{0, 1}, ts}, {int64(1 << 40), nil, nil, false, nil, nil}},
+		RowCount:     2,
+		RowsAffected: 0,
+		LastInsertID: 42,
+	}
+
+	payload, err := original.EncodeVersion(ProtocolVersionBinary)
+	if err != nil {
+		t.Fatalf("EncodeVersion failed: %v", err)
+	}
+	decoded, err := DecodeQueryResult(roundTrip(t, ProtocolVersionBinary, MsgQueryResult, payload))
+	if err != nil {
+		t.Fatalf("DecodeQueryResult failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, original) {
+		t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", decoded, original)
+	}
+
+	// The JSON fallback is chosen by the header version
+	payload, _ = original.EncodeVersion(ProtocolVersion)
+	decoded, err = DecodeQueryResult(roundTrip(t, ProtocolVersion, MsgQueryResult, payload))
+	if err != nil || decoded.Rows[0][0] != float64(-7) || decoded.LastInsertID != 42 {
+		t.Errorf("Unexpected JSON result %+v, %v", decoded, err)
+	}
+}
+
+func TestBinaryRowsSmallerThanJSON(t *testing.T) {
+	m := &QueryResultMessage{Success: true, Columns: []string{"id", "name", "active", "manager"}}
+	for i := 0; i < 1000; i++ {
+		m.Rows = append(m.Rows, []interface{}{int64(1_000_000 + i), "user", i%2 == 0, nil})
+	}
+	m.RowCount = len(m.Rows)
+
+	binaryPayload, _ := m.EncodeVersion(ProtocolVersionBinary)
+	jsonPayload, _ := m.Encode()
+	if len(binaryPayload) >= len(jsonPayload) {
+		t.Errorf("Binary payload (%d bytes) not smaller than JSON (%d bytes)", len(binaryPayload), len(jsonPayload))
+	}
+}
+
+func TestBinaryCursorResultMessage(t *testing.T) {
+	original := &CursorResultMessage{
+		Success:  true,
+		CursorID: "cur_1",
+		Columns: []ColumnMetadata{
+			{Index: 0, Name: "id", Label: "id", Type: "INT", TypeCode: 4, Nullable: false, AutoIncrement: true, TableName: "users"},
+			{Index: 1, Name: "name", Label: "name", Type: "TEXT", Precision: 255, Nullable: true},
+		},
+		Rows:        [][]interface{}{{int64(1), "Alice"}, {int64(2), nil}},
+		RowCount:    2,
+		HasMoreRows: true,
+		Position:    2,
+		TotalRows:   -1,
+	}
+
+	payload, err := original.EncodeVersion(ProtocolVersionBinary)
+	if err != nil {
+		t.Fatalf("EncodeVersion failed: %v", err)
+	}
+	decoded, err := DecodeCursorResult(roundTrip(t, ProtocolVersionBinary, MsgCursorResult, payload))
+	if err != nil {
+		t.Fatalf("DecodeCursorResult failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, original) {
+		t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", decoded, original)
+	}
+}
+
+func TestBinaryQueryResultTruncated(t *testing.T) {
+	m := &QueryResultMessage{Success: true, Columns: []string{"name"}, Rows: [][]interface{}{{"Alice"}}, RowCount: 1}
+	payload, _ := m.EncodeVersion(ProtocolVersionBinary)
+	for i := 0; i < len(payload); i++ {
+		msg := &Message{Header: Header{Version: ProtocolVersionBinary}, Payload: payload[:i]}
+		if _, err := DecodeQueryResult(msg); err == nil {
+			t.Errorf("Expected an error decoding %d of %d bytes", i, len(payload))
+		}
+	}
+}
@@ -0,0 +1,232 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Payload Compression
+===================
+
+A client lists the compression algorithms it accepts, in order of
+preference, in the Compression field of its Auth message. The server picks
+the first one it supports and names it in the AuthResult. From then on both
+sides compress every payload of at least CompressionThreshold bytes with
+that algorithm and set FlagCompressed in the frame header. Payloads that
+do not shrink are sent as they are. The header Length is the length of the
+compressed payload.
+
+FrameWriter applies compression below a bufio.Writer, so handlers keep
+writing plain frames. Compression.ReadMessage undoes it on the read side.
+*/
+package protocol
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	"flydb/internal/compression"
+)
+
+// CompressionThreshold is the payload size from which frames are compressed.
+const CompressionThreshold = 512
+
+// ErrCompressionNotNegotiated is returned for a compressed frame on a
+// connection that did not negotiate compression.
+var ErrCompressionNotNegotiated = errors.New("compressed message without negotiated compression")
+
+// compressionAlgorithms are the algorithms the protocol supports. Their
+// order does not matter: the client's order of preference decides.
+var compressionAlgorithms = []compression.Algorithm{
+	compression.AlgorithmLZ4,
+	compression.AlgorithmSnappy,
+	compression.AlgorithmZstd,
+	compression.AlgorithmGzip,
+}
+
+var (
+	compressorsMu sync.Mutex
+	compressors   = make(map[compression.Algorithm]*compression.Compressor)
+)
+
+// sharedCompressor returns the process-wide compressor for an algorithm.
+// Compressors are safe for concurrent use and costly to create.
+func sharedCompressor(algorithm compression.Algorithm) *compression.Compressor {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	c, ok := compressors[algorithm]
+	if !ok {
+		c = compression.NewCompressor(compression.Config{
+			Algorithm:      algorithm,
+			Level:          compression.LevelDefault,
+			MaxDecodedSize: MaxMessageSize,
+		})
+		compressors[algorithm] = c
+	}
+	return c
+}
+
+// NegotiateCompression returns the first algorithm in offered that the
+// protocol supports, or AlgorithmNone.
+func NegotiateCompression(offered []string) compression.Algorithm {
+	for _, name := range offered {
+		algorithm, err := compression.ParseAlgorithm(name)
+		if err != nil {
+			continue
+		}
+		for _, supported := range compressionAlgorithms {
+			if algorithm == supported {
+				return algorithm
+			}
+		}
+	}
+	return compression.AlgorithmNone
+}
+
+// Compression compresses and decompresses the payloads of one connection.
+// A nil *Compression means no compression was negotiated.
+type Compression struct {
+	algorithm  compression.Algorithm
+	compressor *compression.Compressor
+}
+
+// NewCompression returns the payload compression for an algorithm, or nil
+// for AlgorithmNone.
+func NewCompression(algorithm compression.Algorithm) *Compression {
+	if algorithm == compression.AlgorithmNone {
+		return nil
+	}
+	return &Compression{algorithm: algorithm, compressor: sharedCompressor(algorithm)}
+}
+
+// Algorithm returns the negotiated algorithm.
+func (c *Compression) Algorithm() compression.Algorithm {
+	if c == nil {
+		return compression.AlgorithmNone
+	}
+	return c.algorithm
+}
+
+// DecompressPayload returns the payload of a frame with the given flags,
+// decompressing it if FlagCompressed is set. Decompression stops as soon
+// as the output exceeds MaxMessageSize.
+func (c *Compression) DecompressPayload(flags MessageFlag, payload []byte) ([]byte, error) {
+	if flags&FlagCompressed == 0 {
+		return payload, nil
+	}
+	if c == nil {
+		return nil, ErrCompressionNotNegotiated
+	}
+	data, err := c.compressor.Decompress(payload, c.algorithm)
+	if errors.Is(err, compression.ErrDecodedTooLarge) {
+		return nil, ErrMessageTooLarge
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ReadMessage reads a message and decompresses its payload. The returned
+// header has FlagCompressed cleared and the decompressed length.
+func (c *Compression) ReadMessage(r io.Reader) (*Message, error) {
+	msg, err := ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Header.Flags&FlagCompressed == 0 {
+		return msg, nil
+	}
+	if msg.Payload, err = c.DecompressPayload(msg.Header.Flags, msg.Payload); err != nil {
+		return nil, err
+	}
+	msg.Header.Flags &^= FlagCompressed
+	msg.Header.Length = uint32(len(msg.Payload))
+	return msg, nil
+}
+
+// compressFrame compresses the payload of a complete frame if it is large
+// enough and shrinks, returning the frame to send.
+func (c *Compression) compressFrame(frame []byte) []byte {
+	payload := frame[HeaderSize:]
+	if len(payload) < CompressionThreshold || MessageFlag(frame[3])&FlagCompressed != 0 {
+		return frame
+	}
+	compressed, err := c.compressor.Compress(payload)
+	if err != nil || len(compressed) >= len(payload) {
+		return frame
+	}
+	out := make([]byte, HeaderSize+len(compressed))
+	copy(out, frame[:HeaderSize])
+	out[3] |= byte(FlagCompressed)
+	binary.BigEndian.PutUint32(out[4:HeaderSize], uint32(len(compressed)))
+	copy(out[HeaderSize:], compressed)
+	return out
+}
+
+// FrameWriter is an io.Writer for protocol frames that compresses their
+// payloads once compression is enabled. It buffers partial frames, so it
+// is meant to sit below a bufio.Writer that is flushed after each message.
+type FrameWriter struct {
+	w           io.Writer
+	buf         []byte
+	compression *Compression
+}
+
+// NewFrameWriter returns a FrameWriter that writes frames to w.
+func NewFrameWriter(w io.Writer) *FrameWriter {
+	return &FrameWriter{w: w}
+}
+
+// NewCompressedWriter returns a bufio.Writer over a FrameWriter, and the
+// FrameWriter so compression can be enabled after authentication.
+func NewCompressedWriter(w io.Writer) (*bufio.Writer, *FrameWriter) {
+	fw := NewFrameWriter(w)
+	return bufio.NewWriter(fw), fw
+}
+
+// SetCompression enables compression for the frames written from now on.
+// It must be called between messages.
+func (fw *FrameWriter) SetCompression(c *Compression) {
+	fw.compression = c
+}
+
+// Write implements io.Writer.
+func (fw *FrameWriter) Write(p []byte) (int, error) {
+	if fw.compression == nil && len(fw.buf) == 0 {
+		return fw.w.Write(p)
+	}
+
+	fw.buf = append(fw.buf, p...)
+	for len(fw.buf) >= HeaderSize {
+		size := HeaderSize + int(binary.BigEndian.Uint32(fw.buf[4:HeaderSize]))
+		if len(fw.buf) < size {
+			break
+		}
+		frame := fw.buf[:size]
+		if fw.compression != nil {
+			frame = fw.compression.compressFrame(frame)
+		}
+		if _, err := fw.w.Write(frame); err != nil {
+			return 0, err
+		}
+		fw.buf = fw.buf[size:]
+	}
+	if len(fw.buf) == 0 {
+		fw.buf = nil
+	}
+	return len(p), nil
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"flydb/internal/compression"
+)
+
+func TestNegotiateCompression(t *testing.T) {
+	tests := []struct {
+		offered []string
+		want    compression.Algorithm
+	}{
+		{nil, compression.AlgorithmNone},
+		{[]string{"zstd", "lz4"}, compression.AlgorithmZstd},
+		{[]string{"brotli", "snappy"}, compression.AlgorithmSnappy},
+		{[]string{"none"}, compression.AlgorithmNone},
+	}
+	for _, tt := range tests {
+		if got := NegotiateCompression(tt.offered); got != tt.want {
+			t.Errorf("NegotiateCompression(%v) = %v, want %v", tt.offered, got, tt.want)
+		}
+	}
+}
+
+func TestFrameWriterCompression(t *testing.T) {
+	for _, algorithm := range compressionAlgorithms {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			var conn bytes.Buffer
+			w, frames := NewCompressedWriter(&conn)
+			c := NewCompression(algorithm)
+
+			// Frames written before compression is enabled pass through
+			WriteMessage(w, MsgAuthResult, []byte(`{"success":true}`))
+			w.Flush()
+			frames.SetCompression(c)
+
+			large := []byte(strings.Repeat("Alice, 30, alice@example.com\n", 200))
+			WriteMessageVersion(w, ProtocolVersionBinary, MsgQueryResult, large)
+			WriteMessage(w, MsgPong, []byte("small"))
+			w.Flush()
+
+			raw := conn.Bytes()
+			msg, err := c.ReadMessage(&conn)
+			if err != nil || msg.Header.Type != MsgAuthResult {
+				t.Fatalf("Unexpected first message %+v, %v", msg, err)
+			}
+			if int(raw[HeaderSize+16+3])&int(FlagCompressed) == 0 {
+				t.Error("Large payload was not flagged compressed")
+			}
+			if len(raw) >= 2*HeaderSize+16+len(large) {
+				t.Errorf("Large payload was not compressed: %d bytes on the wire", len(raw))
+			}
+
+			msg, err = c.ReadMessage(&conn)
+			if err != nil {
+				t.Fatalf("ReadMessage failed: %v", err)
+			}
+			if !bytes.Equal(msg.Payload, large) || msg.Header.Flags != FlagNone || msg.Header.Version != ProtocolVersionBinary {
+				t.Errorf("Compressed message did not round trip: header %+v", msg.Header)
+			}
+
+			msg, err = c.ReadMessage(&conn)
+			if err != nil || string(msg.Payload) != "small" {
+				t.Errorf("Unexpected small message %+v, %v", msg, err)
+			}
+		})
+	}
+}
+
+func TestCompressedFrameWithoutNegotiation(t *testing.T) {
+	var conn bytes.Buffer
+	w, frames := NewCompressedWriter(&conn)
+	frames.SetCompression(NewCompression(compression.AlgorithmLZ4))
+	WriteMessage(w, MsgQuery, bytes.Repeat([]byte("x"), 4096))
+	w.Flush()
+
+	var none *Compression
+	if _, err := none.ReadMessage(&conn); err != ErrCompressionNotNegotiated {
+		t.Errorf("Expected ErrCompressionNotNegotiated, got %v", err)
+	}
+}
+
+func TestDecompressionBomb(t *testing.T) {
+	bomb := make([]byte, MaxMessageSize+1)
+	for _, algorithm := range compressionAlgorithms {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			payload, err := compression.NewCompressor(compression.Config{Algorithm: algorithm, Level: compression.LevelDefault}).Compress(bomb)
+			if err != nil {
+				t.Fatalf("Compress failed: %v", err)
+			}
+			if len(payload) > MaxMessageSize/16 {
+				t.Fatalf("Bomb payload is %d bytes", len(payload))
+			}
+			if _, err := NewCompression(algorithm).DecompressPayload(FlagCompressed, payload); err != ErrMessageTooLarge {
+				t.Errorf("Expected ErrMessageTooLarge, got %v", err)
+			}
+		})
+	}
+}
@@ -81,6 +81,10 @@ Connection Lifecycle:
     d. Send response
  5. Connection closes (client disconnect or error)
 
+A client may instead send a Multiplex message first. The connection then
+carries many streams (see multiplex.go), and each stream goes through this
+lifecycle as a connection of its own.
+
 Thread Safety:
 ==============
 
@@ -111,6 +115,9 @@ package protocol
 
 import (
 	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net"
@@ -138,6 +145,68 @@ type DatabaseAwareQueryExecutor interface {
 	ExecuteInDatabase(query, database string, user string) (string, error)
 }
 
+// QueryResult is the structured result of a query.
+type QueryResult struct {
+	Message      string          // Formatted result text
+	Columns      []string        // Column names of a tabular result
+	ColumnTypes  []string        // FlyDB type of each column
+	Rows         [][]interface{} // Typed row values
+	RowsAffected int64           // Rows changed by INSERT, UPDATE or DELETE
+	LastInsertID int64           // Auto-increment value of the last inserted row
+}
+
+// ResultQueryExecutor extends DatabaseAwareQueryExecutor with structured
+// results, so clients need not parse the formatted text.
+type ResultQueryExecutor interface {
+	DatabaseAwareQueryExecutor
+	// ExecuteResultInDatabase executes a query in the context of a specific
+	// database and returns its structured result.
+	ExecuteResultInDatabase(query, database string, user string) (*QueryResult, error)
+}
+
+// ContextQueryExecutor extends ResultQueryExecutor with cancellation. The
+// query stops when ctx is done, because the client canceled it or its
+// statement_timeout expired.
+type ContextQueryExecutor interface {
+	ResultQueryExecutor
+	// ExecuteResultContext executes a query like ExecuteResultInDatabase
+	// and stops it when ctx is done.
+	ExecuteResultContext(ctx context.Context, query, database string, user string) (*QueryResult, error)
+}
+
+// RowStream receives the rows of a tabular query result as they are
+// produced. Columns is called once before the first row.
+type RowStream interface {
+	Columns(names, types []string) error
+	Row(values []interface{}) error
+}
+
+// StreamQueryExecutor extends ContextQueryExecutor with streamed results,
+// so large results need not be held in memory.
+type StreamQueryExecutor interface {
+	ContextQueryExecutor
+	// ExecuteStreamContext executes a query like ExecuteResultContext and
+	// hands the rows of its result to stream as they are produced. The
+	// returned result has no rows. An error from stream stops the query.
+	ExecuteStreamContext(ctx context.Context, query, database string, user string, stream RowStream) (*QueryResult, error)
+}
+
+// txContextKey is the context key of the transaction a statement runs in.
+type txContextKey struct{}
+
+// ContextWithTransaction returns a copy of ctx for a statement run in the
+// transaction txID.
+func ContextWithTransaction(ctx context.Context, txID string) context.Context {
+	return context.WithValue(ctx, txContextKey{}, txID)
+}
+
+// TransactionFromContext returns the ID of the transaction a statement
+// runs in, or "" if it runs outside one.
+func TransactionFromContext(ctx context.Context) string {
+	txID, _ := ctx.Value(txContextKey{}).(string)
+	return txID
+}
+
 // PreparedStatementManager is the interface for managing prepared statements.
 type PreparedStatementManager interface {
 	Prepare(name, query string) error
@@ -145,6 +214,16 @@ type PreparedStatementManager interface {
 	Deallocate(name string) error
 }
 
+// ContextPreparedStatementManager extends PreparedStatementManager with the
+// connection's context, so prepared statements can be canceled and run in
+// the connection's transaction.
+type ContextPreparedStatementManager interface {
+	PreparedStatementManager
+	// ExecuteContext runs a prepared statement as user in database and
+	// stops it when ctx is done.
+	ExecuteContext(ctx context.Context, name string, params []interface{}, database, user string) (string, error)
+}
+
 // Authenticator is the interface for authentication.
 type Authenticator interface {
 	Authenticate(username, password string) bool
@@ -169,14 +248,25 @@ type SessionManager interface {
 
 // CursorManager manages server-side cursors.
 type CursorManager interface {
-	OpenCursor(query string, cursorType, concurrency, fetchSize int, params []interface{}) (string, []ColumnMetadata, error)
+	// OpenCursor runs query as user in database and opens a cursor over its
+	// rows. The query stops when ctx is done.
+	OpenCursor(ctx context.Context, query, database, user string, cursorType, concurrency, fetchSize int, params []interface{}) (string, []ColumnMetadata, error)
+	// FetchRows returns up to count rows in the given direction (a Fetch*
+	// constant), whether rows follow them and the position of the next row.
 	FetchRows(cursorID string, direction int, offset int64, count int) ([][]interface{}, bool, int64, error)
+	// ScrollCursor moves the cursor without returning rows and returns the
+	// position of the next row.
+	ScrollCursor(cursorID string, direction int, offset int64) (int64, error)
 	CloseCursor(cursorID string) error
 }
 
-// TransactionManager manages transactions.
+// TransactionManager manages transactions. The statements a connection
+// runs while it has a transaction open carry the transaction ID in their
+// context (see TransactionFromContext).
 type TransactionManager interface {
-	Begin(isolationLevel int, readOnly bool) (string, error)
+	// Begin starts a transaction on database with the given isolation
+	// level (0-3, as in BeginTxMessage) and returns its ID.
+	Begin(database string, isolationLevel int, readOnly bool) (string, error)
 	Commit(txID string) error
 	Rollback(txID string) error
 	CreateSavepoint(txID, name string) error
@@ -195,6 +285,27 @@ type DatabaseManager interface {
 	ListDatabases() []string
 }
 
+// CommitSyncer makes completed statements durable before they are
+// acknowledged, honouring the session's synchronous_commit option.
+type CommitSyncer interface {
+	// SyncCommit waits until all writes made so far are durable under policy
+	// (always, batch(interval) or off). An empty policy selects the server default.
+	SyncCommit(policy string) error
+	// ValidateSyncPolicy returns an error if policy is not a valid setting.
+	ValidateSyncPolicy(policy string) error
+}
+
+// ReadBarrier holds statements back until the node's data is fresh enough
+// for the session's read_consistency option.
+type ReadBarrier interface {
+	// WaitReadable waits until this node may serve reads under policy
+	// (local, linearizable, lease or bounded_staleness(ms)). An empty policy
+	// reads local data without waiting.
+	WaitReadable(policy string) error
+	// ValidateReadConsistency returns an error if policy is not a valid setting.
+	ValidateReadConsistency(policy string) error
+}
+
 // connectionState holds per-connection state.
 type connectionState struct {
 	authenticated   bool
@@ -205,6 +316,26 @@ type connectionState struct {
 	isolationLevel  int
 	readOnly        bool
 	currentDatabase string // Current database for this connection
+	syncCommit      string // synchronous_commit override ("" = server default)
+	readConsistency string // read_consistency setting ("" = local)
+	version         byte   // Protocol version negotiated at authentication
+
+	// compression is the payload compression negotiated at authentication
+	// (nil for none); frames applies it to the frames written.
+	compression *Compression
+	frames      *FrameWriter
+
+	// cancelKey lets another connection cancel this session's statements.
+	// statementTimeout bounds each statement (0 = no limit). cancel stops
+	// the statement running now; it is nil between statements.
+	cancelKey        string
+	statementTimeout time.Duration
+	cancelMu         sync.Mutex
+	cancel           context.CancelFunc
+
+	// cursors holds the IDs of the cursors this connection opened; they
+	// are closed when it disconnects.
+	cursors map[string]bool
 }
 
 // BinaryHandler handles binary protocol connections.
@@ -217,8 +348,11 @@ type BinaryHandler struct {
 	cursors     CursorManager
 	txMgr       TransactionManager
 	dbMgr       DatabaseManager
+	commits     CommitSyncer
+	reads       ReadBarrier
 	mu          sync.RWMutex
 	connections map[net.Conn]*connectionState
+	cancelKeys  map[string]*connectionState // Sessions by cancel key, guarded by mu
 
 	// Zero-copy buffer pool for efficient memory management
 	bufferPool  *BufferPool
@@ -232,6 +366,7 @@ func NewBinaryHandler(executor QueryExecutor, prepMgr PreparedStatementManager,
 		prepMgr:     prepMgr,
 		auth:        auth,
 		connections: make(map[net.Conn]*connectionState),
+		cancelKeys:  make(map[string]*connectionState),
 		bufferPool:  NewBufferPool(),
 		useZeroCopy: true, // Enable zero-copy by default
 	}
@@ -272,6 +407,34 @@ func (h *BinaryHandler) SetTransactionManager(tm TransactionManager) {
 	h.txMgr = tm
 }
 
+// SetCommitSyncer sets the hook that enforces synchronous_commit.
+func (h *BinaryHandler) SetCommitSyncer(cs CommitSyncer) {
+	h.commits = cs
+}
+
+// SetReadBarrier sets the hook that enforces read_consistency.
+func (h *BinaryHandler) SetReadBarrier(rb ReadBarrier) {
+	h.reads = rb
+}
+
+// waitReadable holds the next statement back until it may read under the
+// session's read_consistency setting.
+func (h *BinaryHandler) waitReadable(state *connectionState) error {
+	if h.reads == nil || state.readConsistency == "" {
+		return nil
+	}
+	return h.reads.WaitReadable(state.readConsistency)
+}
+
+// syncCommit makes the statement just executed durable under the session's
+// synchronous_commit setting.
+func (h *BinaryHandler) syncCommit(state *connectionState) error {
+	if h.commits == nil {
+		return nil
+	}
+	return h.commits.SyncCommit(state.syncCommit)
+}
+
 // SetDatabaseManager sets the database manager for multi-database support.
 func (h *BinaryHandler) SetDatabaseManager(dm DatabaseManager) {
 	h.dbMgr = dm
@@ -292,6 +455,8 @@ func (h *BinaryHandler) HandleConnection(conn net.Conn) {
 		authenticated:  false,
 		autoCommit:     true,
 		isolationLevel: 1, // READ COMMITTED
+		version:        ProtocolVersion,
+		cursors:        make(map[string]bool),
 	}
 
 	h.mu.Lock()
@@ -306,9 +471,14 @@ func (h *BinaryHandler) HandleConnection(conn net.Conn) {
 
 		h.mu.Lock()
 		delete(h.connections, conn)
+		if connState.cancelKey != "" {
+			delete(h.cancelKeys, connState.cancelKey)
+		}
 		remainingConns := len(h.connections)
 		h.mu.Unlock()
 
+		h.closeCursors(connState)
+		h.rollbackTransaction(connState)
 		conn.Close()
 		log.Info("Binary connection terminated",
 			"remote_addr", remoteAddr,
@@ -318,7 +488,8 @@ func (h *BinaryHandler) HandleConnection(conn net.Conn) {
 	}()
 
 	reader := bufio.NewReader(conn)
-	writer := bufio.NewWriter(conn)
+	writer, frames := NewCompressedWriter(conn)
+	connState.frames = frames
 
 	// Create zero-copy reader if enabled
 	var zcReader *ZeroCopyReader
@@ -328,6 +499,8 @@ func (h *BinaryHandler) HandleConnection(conn net.Conn) {
 	}
 
 	authenticated := false
+	_, isStream := conn.(*Stream)
+	first := true
 
 	for {
 		var header *Header
@@ -346,6 +519,10 @@ func (h *BinaryHandler) HandleConnection(conn net.Conn) {
 			}
 		}
 
+		if err == nil && header.Flags&FlagCompressed != 0 {
+			payload, err = connState.compression.DecompressPayload(header.Flags, payload)
+		}
+
 		if err != nil {
 			if err != io.EOF {
 				log.Debug("Binary read error", "remote_addr", remoteAddr, "error", err)
@@ -360,8 +537,22 @@ func (h *BinaryHandler) HandleConnection(conn net.Conn) {
 		// Create request context for logging
 		reqCtx := logging.NewRequestContext(remoteAddr, cmdName)
 
-		// Handle authentication first
-		if !authenticated && header.Type != MsgAuth && header.Type != MsgPing {
+		// The first message of a connection may switch it to multiplexed
+		// mode; each stream is then served as a connection of its own.
+		if header.Type == MsgMultiplex {
+			if !first || isStream {
+				reqCtx.LogError(log, "multiplexing not at connection start")
+				h.sendError(writer, 400, "multiplexing must be negotiated with the first message of a connection")
+				continue
+			}
+			h.serveMultiplexed(conn, reader, writer, payload)
+			return
+		}
+		first = false
+
+		// Handle authentication first. A cancel request is authorized by its
+		// cancel key, so it may be sent on a fresh connection.
+		if !authenticated && header.Type != MsgAuth && header.Type != MsgPing && header.Type != MsgCancel {
 			reqCtx.LogError(log, "authentication required")
 			h.sendError(writer, 401, "authentication required")
 			continue
@@ -372,6 +563,40 @@ func (h *BinaryHandler) HandleConnection(conn net.Conn) {
 	}
 }
 
+// serveMultiplexed answers a Multiplex request and serves each stream the
+// client opens until the connection closes.
+func (h *BinaryHandler) serveMultiplexed(conn net.Conn, r *bufio.Reader, w *bufio.Writer, payload []byte) {
+	remoteAddr := conn.RemoteAddr().String()
+
+	req, err := DecodeMultiplexMessage(payload)
+	if err != nil {
+		h.sendError(w, 400, "invalid multiplex message format")
+		return
+	}
+	data, err := (&MultiplexMessage{Window: DefaultStreamWindow, MaxStreams: MaxStreams}).Encode()
+	if err != nil {
+		h.sendError(w, 500, "failed to encode multiplex response")
+		return
+	}
+	WriteMessage(w, MsgMultiplex, data)
+	if err := w.Flush(); err != nil {
+		return
+	}
+
+	// The reader may already hold the first frames
+	mc := newMultiplexConn(bufferedConn{Conn: conn, r: r}, false, req.Window)
+	defer mc.Close()
+	log.Info("Binary connection multiplexed", "remote_addr", remoteAddr)
+
+	for {
+		stream, err := mc.Accept()
+		if err != nil {
+			return
+		}
+		go h.HandleConnection(stream)
+	}
+}
+
 // handleRequestWithRecovery handles a single request and recovers from any panics.
 func (h *BinaryHandler) handleRequestWithRecovery(
 	w *bufio.Writer,
@@ -392,6 +617,11 @@ func (h *BinaryHandler) handleRequestWithRecovery(
 	var success bool
 	switch header.Type {
 	case MsgAuth:
+		// The Auth header carries the protocol version the client asks for
+		state.version = ProtocolVersion
+		if header.Version >= ProtocolVersionBinary {
+			state.version = ProtocolVersionBinary
+		}
 		*authenticated = h.handleAuth(w, payload, remoteAddr, state)
 		success = *authenticated
 
@@ -402,7 +632,7 @@ func (h *BinaryHandler) handleRequestWithRecovery(
 		success = h.handlePrepare(w, payload, remoteAddr)
 
 	case MsgExecute:
-		success = h.handleExecute(w, payload, remoteAddr)
+		success = h.handleExecute(w, payload, remoteAddr, state)
 
 	case MsgDeallocate:
 		success = h.handleDeallocate(w, payload, remoteAddr)
@@ -411,15 +641,21 @@ func (h *BinaryHandler) handleRequestWithRecovery(
 		h.handlePing(w)
 		success = true
 
+	case MsgCancel:
+		success = h.handleCancel(w, payload, remoteAddr)
+
 	// Cursor operations for ODBC/JDBC driver support
 	case MsgCursorOpen:
-		success = h.handleCursorOpen(w, payload, remoteAddr)
+		success = h.handleCursorOpen(w, payload, remoteAddr, state)
 
 	case MsgCursorFetch:
-		success = h.handleCursorFetch(w, payload, remoteAddr)
+		success = h.handleCursorFetch(w, payload, remoteAddr, state)
+
+	case MsgCursorScroll:
+		success = h.handleCursorScroll(w, payload, remoteAddr, state)
 
 	case MsgCursorClose:
-		success = h.handleCursorClose(w, payload, remoteAddr)
+		success = h.handleCursorClose(w, payload, remoteAddr, state)
 
 	// Metadata operations for ODBC/JDBC driver support
 	case MsgGetTables:
@@ -450,6 +686,9 @@ func (h *BinaryHandler) handleRequestWithRecovery(
 	case MsgRollbackTx:
 		success = h.handleRollbackTx(w, remoteAddr, state)
 
+	case MsgSavepoint:
+		success = h.handleSavepoint(w, payload, remoteAddr, state)
+
 	// Session operations
 	case MsgSetOption:
 		success = h.handleSetOption(w, payload, remoteAddr, state)
@@ -506,10 +745,14 @@ func msgTypeToString(t MessageType) string {
 		return "PING"
 	case MsgPong:
 		return "PONG"
+	case MsgCancel:
+		return "CANCEL"
 	case MsgCursorOpen:
 		return "CURSOR_OPEN"
 	case MsgCursorFetch:
 		return "CURSOR_FETCH"
+	case MsgCursorScroll:
+		return "CURSOR_SCROLL"
 	case MsgCursorClose:
 		return "CURSOR_CLOSE"
 	case MsgGetTables:
@@ -530,6 +773,8 @@ func msgTypeToString(t MessageType) string {
 		return "COMMIT_TX"
 	case MsgRollbackTx:
 		return "ROLLBACK_TX"
+	case MsgSavepoint:
+		return "SAVEPOINT"
 	case MsgSetOption:
 		return "SET_OPTION"
 	case MsgGetOption:
@@ -542,6 +787,8 @@ func msgTypeToString(t MessageType) string {
 		return "GET_DATABASES"
 	case MsgDatabaseResult:
 		return "DATABASE_RESULT"
+	case MsgMultiplex:
+		return "MULTIPLEX"
 	default:
 		return fmt.Sprintf("UNKNOWN(%d)", t)
 	}
@@ -584,7 +831,7 @@ func (h *BinaryHandler) handleAuth(w *bufio.Writer, payload []byte, remoteAddr s
 					"user", authMsg.Username,
 					"database", requestedDB)
 				data, _ := result.Encode()
-				WriteMessage(w, MsgAuthResult, data)
+				WriteMessageVersion(w, state.version, MsgAuthResult, data)
 				w.Flush()
 				return false
 			}
@@ -592,6 +839,11 @@ func (h *BinaryHandler) handleAuth(w *bufio.Writer, payload []byte, remoteAddr s
 
 		state.currentDatabase = requestedDB
 		result.Database = requestedDB
+		result.CancelKey = h.registerCancelKey(state)
+		state.compression = NewCompression(NegotiateCompression(authMsg.Compression))
+		if state.compression != nil {
+			result.Compression = state.compression.Algorithm().String()
+		}
 		log.Info("Binary auth success",
 			"remote_addr", remoteAddr,
 			"user", authMsg.Username,
@@ -599,8 +851,13 @@ func (h *BinaryHandler) handleAuth(w *bufio.Writer, payload []byte, remoteAddr s
 	}
 
 	data, _ := result.Encode()
-	WriteMessage(w, MsgAuthResult, data)
+	WriteMessageVersion(w, state.version, MsgAuthResult, data)
 	w.Flush()
+
+	// Compress the frames after the AuthResult, which is sent uncompressed
+	if success && state.frames != nil {
+		state.frames.SetCompression(state.compression)
+	}
 	return success
 }
 
@@ -615,9 +872,37 @@ func (h *BinaryHandler) handleQuery(w *bufio.Writer, payload []byte, remoteAddr
 
 	log.Debug("Executing binary query", "remote_addr", remoteAddr, "database", state.currentDatabase)
 
-	// Use database-aware executor if available, otherwise fall back to default
+	if err := h.waitReadable(state); err != nil {
+		log.Debug("Read barrier failed", "remote_addr", remoteAddr, "error", err)
+		h.sendError(w, 503, err.Error())
+		return true
+	}
+
+	endTx, err := h.implicitTransaction(state)
+	if err != nil {
+		h.sendError(w, 500, err.Error())
+		return true
+	}
+	defer endTx()
+
+	ctx, done := h.startStatement(state)
+	defer done()
+
+	// Prefer cancelable structured results, then structured results, then
+	// the database-aware executor, then the default
 	var result string
-	if dbExec, ok := h.executor.(DatabaseAwareQueryExecutor); ok {
+	var structured *QueryResult
+	if ctxExec, ok := h.executor.(ContextQueryExecutor); ok {
+		structured, err = ctxExec.ExecuteResultContext(ctx, queryMsg.Query, state.currentDatabase, state.username)
+		if err == nil {
+			result = structured.Message
+		}
+	} else if resExec, ok := h.executor.(ResultQueryExecutor); ok {
+		structured, err = resExec.ExecuteResultInDatabase(queryMsg.Query, state.currentDatabase, state.username)
+		if err == nil {
+			result = structured.Message
+		}
+	} else if dbExec, ok := h.executor.(DatabaseAwareQueryExecutor); ok {
 		result, err = dbExec.ExecuteInDatabase(queryMsg.Query, state.currentDatabase, state.username)
 	} else {
 		result, err = h.executor.Execute(queryMsg.Query, state.username)
@@ -629,6 +914,12 @@ func (h *BinaryHandler) handleQuery(w *bufio.Writer, payload []byte, remoteAddr
 		return true // Keep connection alive on query errors
 	}
 
+	if err := h.syncCommit(state); err != nil {
+		log.Error("Commit sync failed", "remote_addr", remoteAddr, "error", err)
+		h.sendError(w, 500, err.Error())
+		return true
+	}
+
 	// Check if this was a USE statement and update connection state
 	// Result format: "USE <database> OK"
 	if strings.HasPrefix(result, "USE ") && strings.HasSuffix(result, " OK") {
@@ -653,8 +944,16 @@ func (h *BinaryHandler) handleQuery(w *bufio.Writer, payload []byte, remoteAddr
 		Success: true,
 		Message: result,
 	}
-	data, _ := resultMsg.Encode()
-	WriteMessage(w, MsgQueryResult, data)
+	if structured != nil {
+		resultMsg.Columns = structured.Columns
+		resultMsg.ColumnTypes = structured.ColumnTypes
+		resultMsg.Rows = structured.Rows
+		resultMsg.RowCount = len(structured.Rows)
+		resultMsg.RowsAffected = structured.RowsAffected
+		resultMsg.LastInsertID = structured.LastInsertID
+	}
+	data, _ := resultMsg.EncodeVersion(state.version)
+	WriteMessageVersion(w, state.version, MsgQueryResult, data)
 	w.Flush()
 	return true
 }
@@ -692,7 +991,7 @@ func (h *BinaryHandler) handlePrepare(w *bufio.Writer, payload []byte, remoteAdd
 }
 
 // handleExecute handles execute prepared statement messages.
-func (h *BinaryHandler) handleExecute(w *bufio.Writer, payload []byte, remoteAddr string) bool {
+func (h *BinaryHandler) handleExecute(w *bufio.Writer, payload []byte, remoteAddr string, state *connectionState) bool {
 	execMsg, err := DecodeExecuteMessage(payload)
 	if err != nil {
 		log.Debug("Invalid execute message", "remote_addr", remoteAddr, "error", err)
@@ -706,19 +1005,46 @@ func (h *BinaryHandler) handleExecute(w *bufio.Writer, payload []byte, remoteAdd
 	}
 
 	log.Debug("Executing prepared statement", "remote_addr", remoteAddr, "name", execMsg.Name)
-	result, err := h.prepMgr.Execute(execMsg.Name, execMsg.Params)
+	if err := h.waitReadable(state); err != nil {
+		log.Debug("Read barrier failed", "remote_addr", remoteAddr, "error", err)
+		h.sendError(w, 503, err.Error())
+		return true
+	}
+	var result string
+	if ctxPrep, ok := h.prepMgr.(ContextPreparedStatementManager); ok {
+		endTx, txErr := h.implicitTransaction(state)
+		if txErr != nil {
+			h.sendError(w, 500, txErr.Error())
+			return true
+		}
+		ctx, done := h.startStatement(state)
+		result, err = ctxPrep.ExecuteContext(ctx, execMsg.Name, execMsg.Params, state.currentDatabase, state.username)
+		done()
+		endTx()
+	} else if state.transactionID != "" {
+		h.sendError(w, 501, "prepared statements cannot run in a transaction")
+		return true
+	} else {
+		result, err = h.prepMgr.Execute(execMsg.Name, execMsg.Params)
+	}
 	if err != nil {
 		log.Debug("Execute error", "remote_addr", remoteAddr, "error", err)
 		h.sendError(w, 500, err.Error())
 		return true // Keep connection alive
 	}
 
+	if err := h.syncCommit(state); err != nil {
+		log.Error("Commit sync failed", "remote_addr", remoteAddr, "error", err)
+		h.sendError(w, 500, err.Error())
+		return true
+	}
+
 	resultMsg := &QueryResultMessage{
 		Success: true,
 		Message: result,
 	}
-	data, _ := resultMsg.Encode()
-	WriteMessage(w, MsgQueryResult, data)
+	data, _ := resultMsg.EncodeVersion(state.version)
+	WriteMessageVersion(w, state.version, MsgQueryResult, data)
 	w.Flush()
 	return true
 }
@@ -755,6 +1081,84 @@ func (h *BinaryHandler) handleDeallocate(w *bufio.Writer, payload []byte, remote
 	return true
 }
 
+// registerCancelKey issues a new cancel key for an authenticated session.
+func (h *BinaryHandler) registerCancelKey(state *connectionState) string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	key := hex.EncodeToString(buf)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if state.cancelKey != "" {
+		delete(h.cancelKeys, state.cancelKey)
+	}
+	state.cancelKey = key
+	h.cancelKeys[key] = state
+	return key
+}
+
+// startStatement returns the context of the statement about to run, bounded
+// by the session's statement_timeout and canceled by a Cancel message. done
+// must be called when the statement finishes.
+func (h *BinaryHandler) startStatement(state *connectionState) (ctx context.Context, done func()) {
+	var cancel context.CancelFunc
+	if state.statementTimeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), state.statementTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+
+	state.cancelMu.Lock()
+	state.cancel = cancel
+	state.cancelMu.Unlock()
+
+	if state.transactionID != "" {
+		ctx = ContextWithTransaction(ctx, state.transactionID)
+	}
+	return ctx, func() {
+		state.cancelMu.Lock()
+		state.cancel = nil
+		state.cancelMu.Unlock()
+		cancel()
+	}
+}
+
+// handleCancel handles cancel messages. Like the reply to an unknown key,
+// the reply does not say whether a statement was running, so cancel keys
+// cannot be probed.
+func (h *BinaryHandler) handleCancel(w *bufio.Writer, payload []byte, remoteAddr string) bool {
+	msg, err := DecodeCancelMessage(payload)
+	if err != nil {
+		log.Debug("Invalid cancel message", "remote_addr", remoteAddr, "error", err)
+		h.sendError(w, 400, "invalid cancel message")
+		return false
+	}
+
+	h.mu.RLock()
+	target := h.cancelKeys[msg.CancelKey]
+	h.mu.RUnlock()
+
+	if target != nil {
+		target.cancelMu.Lock()
+		if target.cancel != nil {
+			target.cancel()
+			log.Info("Statement canceled", "remote_addr", remoteAddr, "user", target.username)
+		}
+		target.cancelMu.Unlock()
+	}
+
+	result := &SessionResultMessage{
+		Success: true,
+		Message: "cancel request sent",
+	}
+	data, _ := result.Encode()
+	WriteMessage(w, MsgSessionResult, data)
+	w.Flush()
+	return true
+}
+
 // handlePing handles ping messages.
 func (h *BinaryHandler) handlePing(w *bufio.Writer) {
 	WriteMessage(w, MsgPong, nil)
@@ -786,7 +1190,7 @@ func (h *BinaryHandler) Close() {
 // ============================================================================
 
 // handleCursorOpen handles cursor open messages.
-func (h *BinaryHandler) handleCursorOpen(w *bufio.Writer, payload []byte, remoteAddr string) bool {
+func (h *BinaryHandler) handleCursorOpen(w *bufio.Writer, payload []byte, remoteAddr string, state *connectionState) bool {
 	if h.cursors == nil {
 		h.sendError(w, 501, "cursors not supported")
 		return false
@@ -799,26 +1203,41 @@ func (h *BinaryHandler) handleCursorOpen(w *bufio.Writer, payload []byte, remote
 		return false
 	}
 
-	cursorID, columns, err := h.cursors.OpenCursor(msg.Query, msg.CursorType, msg.Concurrency, msg.FetchSize, msg.Parameters)
+	if err := h.waitReadable(state); err != nil {
+		log.Debug("Read barrier failed", "remote_addr", remoteAddr, "error", err)
+		h.sendError(w, 503, err.Error())
+		return true
+	}
+
+	endTx, err := h.implicitTransaction(state)
+	if err != nil {
+		h.sendError(w, 500, err.Error())
+		return true
+	}
+	ctx, done := h.startStatement(state)
+	cursorID, columns, err := h.cursors.OpenCursor(ctx, msg.Query, state.currentDatabase, state.username, msg.CursorType, msg.Concurrency, msg.FetchSize, msg.Parameters)
+	done()
+	endTx()
 	if err != nil {
 		log.Debug("Cursor open error", "remote_addr", remoteAddr, "error", err)
 		h.sendError(w, 500, err.Error())
 		return false
 	}
+	state.cursors[cursorID] = true
 
 	result := &CursorResultMessage{
 		Success:  true,
 		CursorID: cursorID,
 		Columns:  columns,
 	}
-	data, _ := result.Encode()
-	WriteMessage(w, MsgCursorResult, data)
+	data, _ := result.EncodeVersion(state.version)
+	WriteMessageVersion(w, state.version, MsgCursorResult, data)
 	w.Flush()
 	return true
 }
 
 // handleCursorFetch handles cursor fetch messages.
-func (h *BinaryHandler) handleCursorFetch(w *bufio.Writer, payload []byte, remoteAddr string) bool {
+func (h *BinaryHandler) handleCursorFetch(w *bufio.Writer, payload []byte, remoteAddr string, state *connectionState) bool {
 	if h.cursors == nil {
 		h.sendError(w, 501, "cursors not supported")
 		return false
@@ -830,6 +1249,10 @@ func (h *BinaryHandler) handleCursorFetch(w *bufio.Writer, payload []byte, remot
 		h.sendError(w, 400, "invalid cursor fetch message")
 		return false
 	}
+	if !state.cursors[msg.CursorID] {
+		h.sendError(w, 404, "cursor not found")
+		return false
+	}
 
 	rows, hasMore, position, err := h.cursors.FetchRows(msg.CursorID, msg.Direction, msg.Offset, msg.Count)
 	if err != nil {
@@ -846,14 +1269,50 @@ func (h *BinaryHandler) handleCursorFetch(w *bufio.Writer, payload []byte, remot
 		HasMoreRows: hasMore,
 		Position:    position,
 	}
-	data, _ := result.Encode()
-	WriteMessage(w, MsgCursorResult, data)
+	data, _ := result.EncodeVersion(state.version)
+	WriteMessageVersion(w, state.version, MsgCursorResult, data)
+	w.Flush()
+	return true
+}
+
+// handleCursorScroll handles cursor scroll messages.
+func (h *BinaryHandler) handleCursorScroll(w *bufio.Writer, payload []byte, remoteAddr string, state *connectionState) bool {
+	if h.cursors == nil {
+		h.sendError(w, 501, "cursors not supported")
+		return false
+	}
+
+	msg, err := DecodeCursorScrollMessage(payload)
+	if err != nil {
+		log.Debug("Invalid cursor scroll message", "remote_addr", remoteAddr, "error", err)
+		h.sendError(w, 400, "invalid cursor scroll message")
+		return false
+	}
+	if !state.cursors[msg.CursorID] {
+		h.sendError(w, 404, "cursor not found")
+		return false
+	}
+
+	position, err := h.cursors.ScrollCursor(msg.CursorID, msg.Direction, msg.Offset)
+	if err != nil {
+		log.Debug("Cursor scroll error", "remote_addr", remoteAddr, "error", err)
+		h.sendError(w, 500, err.Error())
+		return false
+	}
+
+	result := &CursorResultMessage{
+		Success:  true,
+		CursorID: msg.CursorID,
+		Position: position,
+	}
+	data, _ := result.EncodeVersion(state.version)
+	WriteMessageVersion(w, state.version, MsgCursorResult, data)
 	w.Flush()
 	return true
 }
 
 // handleCursorClose handles cursor close messages.
-func (h *BinaryHandler) handleCursorClose(w *bufio.Writer, payload []byte, remoteAddr string) bool {
+func (h *BinaryHandler) handleCursorClose(w *bufio.Writer, payload []byte, remoteAddr string, state *connectionState) bool {
 	if h.cursors == nil {
 		h.sendError(w, 501, "cursors not supported")
 		return false
@@ -865,6 +1324,11 @@ func (h *BinaryHandler) handleCursorClose(w *bufio.Writer, payload []byte, remot
 		h.sendError(w, 400, "invalid cursor close message")
 		return false
 	}
+	if !state.cursors[msg.CursorID] {
+		h.sendError(w, 404, "cursor not found")
+		return false
+	}
+	delete(state.cursors, msg.CursorID)
 
 	err = h.cursors.CloseCursor(msg.CursorID)
 	if err != nil {
@@ -884,6 +1348,16 @@ func (h *BinaryHandler) handleCursorClose(w *bufio.Writer, payload []byte, remot
 	return true
 }
 
+// closeCursors closes the cursors a connection left open.
+func (h *BinaryHandler) closeCursors(state *connectionState) {
+	if h.cursors == nil {
+		return
+	}
+	for cursorID := range state.cursors {
+		h.cursors.CloseCursor(cursorID)
+	}
+}
+
 // ============================================================================
 // Metadata handlers for ODBC/JDBC driver support
 // ============================================================================
@@ -1091,7 +1565,13 @@ func (h *BinaryHandler) handleBeginTx(w *bufio.Writer, payload []byte, remoteAdd
 		return false
 	}
 
-	txID, err := h.txMgr.Begin(msg.IsolationLevel, msg.ReadOnly)
+	if state.transactionID != "" {
+		h.sendError(w, 400, "transaction already active")
+		return false
+	}
+
+	// A read-only session only starts read-only transactions
+	txID, err := h.txMgr.Begin(state.currentDatabase, msg.IsolationLevel, msg.ReadOnly || state.readOnly)
 	if err != nil {
 		log.Debug("Begin tx error", "remote_addr", remoteAddr, "error", err)
 		h.sendError(w, 500, err.Error())
@@ -1122,15 +1602,19 @@ func (h *BinaryHandler) handleCommitTx(w *bufio.Writer, remoteAddr string, state
 		return false
 	}
 
-	err := h.txMgr.Commit(state.transactionID)
+	// The transaction ends even if the commit fails
+	txID := state.transactionID
+	state.transactionID = ""
+	err := h.txMgr.Commit(txID)
+	if err == nil {
+		err = h.syncCommit(state)
+	}
 	if err != nil {
 		log.Debug("Commit tx error", "remote_addr", remoteAddr, "error", err)
 		h.sendError(w, 500, err.Error())
 		return false
 	}
 
-	txID := state.transactionID
-	state.transactionID = ""
 	result := &TxResultMessage{
 		Success:       true,
 		TransactionID: txID,
@@ -1154,15 +1638,15 @@ func (h *BinaryHandler) handleRollbackTx(w *bufio.Writer, remoteAddr string, sta
 		return false
 	}
 
-	err := h.txMgr.Rollback(state.transactionID)
+	txID := state.transactionID
+	state.transactionID = ""
+	err := h.txMgr.Rollback(txID)
 	if err != nil {
 		log.Debug("Rollback tx error", "remote_addr", remoteAddr, "error", err)
 		h.sendError(w, 500, err.Error())
 		return false
 	}
 
-	txID := state.transactionID
-	state.transactionID = ""
 	result := &TxResultMessage{
 		Success:       true,
 		TransactionID: txID,
@@ -1174,6 +1658,88 @@ func (h *BinaryHandler) handleRollbackTx(w *bufio.Writer, remoteAddr string, sta
 	return true
 }
 
+// handleSavepoint handles savepoint messages.
+func (h *BinaryHandler) handleSavepoint(w *bufio.Writer, payload []byte, remoteAddr string, state *connectionState) bool {
+	if h.txMgr == nil {
+		h.sendError(w, 501, "transactions not supported")
+		return false
+	}
+
+	msg, err := DecodeSavepointMessage(payload)
+	if err != nil || msg.Name == "" {
+		log.Debug("Invalid savepoint message", "remote_addr", remoteAddr, "error", err)
+		h.sendError(w, 400, "invalid savepoint message")
+		return false
+	}
+
+	if state.transactionID == "" {
+		h.sendError(w, 400, "no active transaction")
+		return false
+	}
+
+	var message string
+	switch {
+	case msg.Rollback:
+		err = h.txMgr.RollbackToSavepoint(state.transactionID, msg.Name)
+		message = "rolled back to savepoint " + msg.Name
+	case msg.Release:
+		err = h.txMgr.ReleaseSavepoint(state.transactionID, msg.Name)
+		message = "savepoint " + msg.Name + " released"
+	default:
+		err = h.txMgr.CreateSavepoint(state.transactionID, msg.Name)
+		message = "savepoint " + msg.Name + " created"
+	}
+	if err != nil {
+		log.Debug("Savepoint error", "remote_addr", remoteAddr, "error", err)
+		h.sendError(w, 500, err.Error())
+		return false
+	}
+
+	result := &TxResultMessage{
+		Success:       true,
+		TransactionID: state.transactionID,
+		Message:       message,
+	}
+	data, _ := result.Encode()
+	WriteMessage(w, MsgTxResult, data)
+	w.Flush()
+	return true
+}
+
+// implicitTransaction starts the transaction a statement runs in when the
+// connection has none open: with auto_commit off it stays open until the
+// client commits or rolls back, and a read-only session runs each statement
+// in a read-only transaction of its own. The returned function ends a
+// transaction of the statement's own.
+func (h *BinaryHandler) implicitTransaction(state *connectionState) (func(), error) {
+	if h.txMgr == nil || state.transactionID != "" || (state.autoCommit && !state.readOnly) {
+		return func() {}, nil
+	}
+
+	txID, err := h.txMgr.Begin(state.currentDatabase, state.isolationLevel, state.readOnly)
+	if err != nil {
+		return func() {}, err
+	}
+	state.transactionID = txID
+	if !state.autoCommit {
+		return func() {}, nil
+	}
+	return func() {
+		// Nothing was written, so there is nothing to commit
+		state.transactionID = ""
+		h.txMgr.Rollback(txID)
+	}, nil
+}
+
+// rollbackTransaction rolls back the transaction a connection left open.
+func (h *BinaryHandler) rollbackTransaction(state *connectionState) {
+	if h.txMgr == nil || state.transactionID == "" {
+		return
+	}
+	h.txMgr.Rollback(state.transactionID)
+	state.transactionID = ""
+}
+
 // ============================================================================
 // Session handlers
 // ============================================================================
@@ -1191,6 +1757,19 @@ func (h *BinaryHandler) handleSetOption(w *bufio.Writer, payload []byte, remoteA
 	switch msg.Option {
 	case "auto_commit":
 		if v, ok := msg.Value.(bool); ok {
+			// Turning auto_commit on commits the open transaction
+			if v && !state.autoCommit && state.transactionID != "" && h.txMgr != nil {
+				txID := state.transactionID
+				state.transactionID = ""
+				err := h.txMgr.Commit(txID)
+				if err == nil {
+					err = h.syncCommit(state)
+				}
+				if err != nil {
+					h.sendError(w, 500, err.Error())
+					return true
+				}
+			}
 			state.autoCommit = v
 		}
 	case "isolation_level":
@@ -1201,6 +1780,39 @@ func (h *BinaryHandler) handleSetOption(w *bufio.Writer, payload []byte, remoteA
 		if v, ok := msg.Value.(bool); ok {
 			state.readOnly = v
 		}
+	case "synchronous_commit":
+		v, ok := msg.Value.(string)
+		if !ok {
+			h.sendError(w, 400, "synchronous_commit must be a string")
+			return true
+		}
+		if v != "" && h.commits != nil {
+			if err := h.commits.ValidateSyncPolicy(v); err != nil {
+				h.sendError(w, 400, err.Error())
+				return true
+			}
+		}
+		state.syncCommit = v
+	case "read_consistency":
+		v, ok := msg.Value.(string)
+		if !ok {
+			h.sendError(w, 400, "read_consistency must be a string")
+			return true
+		}
+		if v != "" && h.reads != nil {
+			if err := h.reads.ValidateReadConsistency(v); err != nil {
+				h.sendError(w, 400, err.Error())
+				return true
+			}
+		}
+		state.readConsistency = v
+	case "statement_timeout":
+		timeout, err := parseStatementTimeout(msg.Value)
+		if err != nil {
+			h.sendError(w, 400, err.Error())
+			return true
+		}
+		state.statementTimeout = timeout
 	default:
 		// Delegate to session manager if available
 		if h.sessions != nil {
@@ -1224,6 +1836,28 @@ func (h *BinaryHandler) handleSetOption(w *bufio.Writer, payload []byte, remoteA
 	return true
 }
 
+// parseStatementTimeout parses a statement_timeout value: a number of
+// milliseconds or a duration string such as "30s". Zero disables the limit.
+func parseStatementTimeout(value interface{}) (time.Duration, error) {
+	var timeout time.Duration
+	switch v := value.(type) {
+	case float64:
+		timeout = time.Duration(v * float64(time.Millisecond))
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid statement_timeout %q", v)
+		}
+		timeout = d
+	default:
+		return 0, fmt.Errorf("statement_timeout must be milliseconds or a duration string")
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("statement_timeout must not be negative")
+	}
+	return timeout, nil
+}
+
 // handleGetOption handles get session option messages.
 func (h *BinaryHandler) handleGetOption(w *bufio.Writer, payload []byte, remoteAddr string, state *connectionState) bool {
 	msg, err := DecodeGetOptionMessage(payload)
@@ -1243,6 +1877,12 @@ func (h *BinaryHandler) handleGetOption(w *bufio.Writer, payload []byte, remoteA
 		value = state.readOnly
 	case "username":
 		value = state.username
+	case "synchronous_commit":
+		value = state.syncCommit
+	case "read_consistency":
+		value = state.readConsistency
+	case "statement_timeout":
+		value = state.statementTimeout.Milliseconds()
 	default:
 		if h.sessions != nil {
 			value, err = h.sessions.GetOption(state.sessionID, msg.Option)
@@ -1269,8 +1909,8 @@ func (h *BinaryHandler) handleGetServerInfo(w *bufio.Writer, remoteAddr string)
 	result := &SessionResultMessage{
 		Success:         true,
 		ServerVersion:   "1.0.0",
-		ProtocolVersion: int(ProtocolVersion),
-		Capabilities:    []string{"sql", "prepared_statements", "transactions", "cursors"},
+		ProtocolVersion: int(ProtocolVersionBinary),
+		Capabilities:    []string{"sql", "prepared_statements", "transactions", "cursors", "binary_rows"},
 		MaxStatementLen: MaxMessageSize,
 	}
 
@@ -1295,6 +1935,10 @@ func (h *BinaryHandler) handleUseDatabase(w *bufio.Writer, payload []byte, remot
 		h.sendError(w, 400, "invalid use database message")
 		return false
 	}
+	if state.transactionID != "" {
+		h.sendError(w, 400, "cannot change database inside a transaction")
+		return false
+	}
 
 	result := &DatabaseResultMessage{
 		Success: true,
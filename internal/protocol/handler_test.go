@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingExecutor runs every query until its context is done.
+type blockingExecutor struct {
+	started chan struct{}
+}
+
+func (e *blockingExecutor) Execute(query string, user string) (string, error) {
+	return e.ExecuteInDatabase(query, "", user)
+}
+
+func (e *blockingExecutor) ExecuteInDatabase(query, database string, user string) (string, error) {
+	result, err := e.ExecuteResultInDatabase(query, database, user)
+	if err != nil {
+		return "", err
+	}
+	return result.Message, nil
+}
+
+func (e *blockingExecutor) ExecuteResultInDatabase(query, database string, user string) (*QueryResult, error) {
+	return e.ExecuteResultContext(context.Background(), query, database, user)
+}
+
+func (e *blockingExecutor) ExecuteResultContext(ctx context.Context, query, database string, user string) (*QueryResult, error) {
+	e.started <- struct{}{}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+type allowAll struct{}
+
+func (allowAll) Authenticate(username, password string) bool { return true }
+
+// dialHandler connects a client to a handler over an in-memory pipe.
+func dialHandler(t *testing.T, h *BinaryHandler) net.Conn {
+	t.Helper()
+	client, server := net.Pipe()
+	go h.HandleConnection(server)
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestCancelRunningQuery(t *testing.T) {
+	exec := &blockingExecutor{started: make(chan struct{}, 1)}
+	h := NewBinaryHandler(exec, nil, allowAll{})
+
+	conn := dialHandler(t, h)
+	payload, _ := (&AuthMessage{Username: "alice"}).Encode()
+	WriteMessage(conn, MsgAuth, payload)
+	msg, err := ReadMessage(conn)
+	if err != nil {
+		t.Fatalf("Failed to read auth response: %v", err)
+	}
+	auth, _ := DecodeAuthResultMessage(msg.Payload)
+
+	payload, _ = (&QueryMessage{Query: "SELECT * FROM big"}).Encode()
+	WriteMessage(conn, MsgQuery, payload)
+	<-exec.started
+
+	// A wrong key is answered the same way but cancels nothing
+	cancelConn := dialHandler(t, h)
+	for _, key := range []string{"wrong", auth.CancelKey} {
+		payload, _ = (&CancelMessage{CancelKey: key}).Encode()
+		WriteMessage(cancelConn, MsgCancel, payload)
+		if msg, err := ReadMessage(cancelConn); err != nil || msg.Header.Type != MsgSessionResult {
+			t.Fatalf("Unexpected cancel response %+v, %v", msg, err)
+		}
+	}
+
+	msg, err = ReadMessage(conn)
+	if err != nil {
+		t.Fatalf("Failed to read query response: %v", err)
+	}
+	errMsg, _ := DecodeErrorMessage(msg.Payload)
+	if msg.Header.Type != MsgError || !strings.Contains(errMsg.Message, "canceled") {
+		t.Errorf("Expected the query to be canceled, got %s", msg.Payload)
+	}
+}
+
+func TestParseStatementTimeout(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  time.Duration
+		ok    bool
+	}{
+		{float64(1500), 1500 * time.Millisecond, true},
+		{"30s", 30 * time.Second, true},
+		{float64(0), 0, true},
+		{"soon", 0, false},
+		{float64(-1), 0, false},
+		{true, 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseStatementTimeout(tt.value)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseStatementTimeout(%v) = %v, %v", tt.value, got, err)
+		}
+	}
+}
@@ -50,7 +50,8 @@ Message Encoding:
 
 All messages are encoded as JSON for simplicity and debuggability.
 The JSON payload is wrapped in the binary message frame defined in
-protocol.go.
+protocol.go. Clients that negotiate protocol version 2 receive query and
+cursor results in the binary encoding of binary_messages.go instead.
 
 Example QueryMessage:
   {"query": "SELECT * FROM users WHERE id = 1"}
@@ -113,12 +114,18 @@ func DecodeQueryMessage(data []byte) (*QueryMessage, error) {
 }
 
 // QueryResultMessage represents a query response.
+// Message carries the formatted result text. Tabular results also carry
+// their column names, FlyDB column types and typed row values; DML carries
+// the affected row count and, for INSERT, the last auto-increment value.
 type QueryResultMessage struct {
-	Success  bool            `json:"success"`
-	Message  string          `json:"message,omitempty"`
-	Columns  []string        `json:"columns,omitempty"`
-	Rows     [][]interface{} `json:"rows,omitempty"`
-	RowCount int             `json:"row_count"`
+	Success      bool            `json:"success"`
+	Message      string          `json:"message,omitempty"`
+	Columns      []string        `json:"columns,omitempty"`
+	ColumnTypes  []string        `json:"column_types,omitempty"`
+	Rows         [][]interface{} `json:"rows,omitempty"`
+	RowCount     int             `json:"row_count"`
+	RowsAffected int64           `json:"rows_affected,omitempty"`
+	LastInsertID int64           `json:"last_insert_id,omitempty"`
 }
 
 // Encode encodes the query result message to bytes.
@@ -238,9 +245,10 @@ func DecodeDeallocateMessage(data []byte) (*DeallocateMessage, error) {
 
 // AuthMessage represents an authentication request.
 type AuthMessage struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-	Database string `json:"database,omitempty"` // Optional: database to connect to (default: "default")
+	Username    string   `json:"username"`
+	Password    string   `json:"password"`
+	Database    string   `json:"database,omitempty"`    // Optional: database to connect to (default: "default")
+	Compression []string `json:"compression,omitempty"` // Optional: accepted compression algorithms, preferred first
 }
 
 // Encode encodes the auth message to bytes.
@@ -259,9 +267,11 @@ func DecodeAuthMessage(data []byte) (*AuthMessage, error) {
 
 // AuthResultMessage represents an authentication response.
 type AuthResultMessage struct {
-	Success  bool   `json:"success"`
-	Message  string `json:"message,omitempty"`
-	Database string `json:"database,omitempty"` // Current database after authentication
+	Success     bool   `json:"success"`
+	Message     string `json:"message,omitempty"`
+	Database    string `json:"database,omitempty"`    // Current database after authentication
+	Compression string `json:"compression,omitempty"` // Compression algorithm in use, empty for none
+	CancelKey   string `json:"cancel_key,omitempty"`  // Key that cancels this session's statements
 }
 
 // Encode encodes the auth result message to bytes.
@@ -278,6 +288,48 @@ func DecodeAuthResultMessage(data []byte) (*AuthResultMessage, error) {
 	return &m, nil
 }
 
+// MultiplexMessage asks the server to switch a new connection to
+// multiplexed mode, and is its answer. Each side announces the receive
+// window of its streams.
+type MultiplexMessage struct {
+	Window     uint32 `json:"window"`                // Receive window of each stream in bytes
+	MaxStreams uint32 `json:"max_streams,omitempty"` // Maximum number of open streams
+}
+
+// Encode encodes the multiplex message to bytes.
+func (m *MultiplexMessage) Encode() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// DecodeMultiplexMessage decodes a multiplex message from bytes.
+func DecodeMultiplexMessage(data []byte) (*MultiplexMessage, error) {
+	var m MultiplexMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// CancelMessage asks the server to cancel the statement running in the
+// session the cancel key was issued to.
+type CancelMessage struct {
+	CancelKey string `json:"cancel_key"`
+}
+
+// Encode encodes the cancel message to bytes.
+func (m *CancelMessage) Encode() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// DecodeCancelMessage decodes a cancel message from bytes.
+func DecodeCancelMessage(data []byte) (*CancelMessage, error) {
+	var m CancelMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
 // BinaryEncoder provides efficient binary encoding for row data.
 type BinaryEncoder struct {
 	buf *bytes.Buffer
@@ -334,9 +386,32 @@ func (e *BinaryEncoder) Reset() {
 	e.buf.Reset()
 }
 
+// WriteUvarint writes an unsigned varint.
+func (e *BinaryEncoder) WriteUvarint(v uint64) error {
+	var buf [binary.MaxVarintLen64]byte
+	_, err := e.buf.Write(buf[:binary.PutUvarint(buf[:], v)])
+	return err
+}
+
+// WriteVarint writes a zig-zag encoded signed varint.
+func (e *BinaryEncoder) WriteVarint(v int64) error {
+	var buf [binary.MaxVarintLen64]byte
+	_, err := e.buf.Write(buf[:binary.PutVarint(buf[:], v)])
+	return err
+}
+
+// WriteVarString writes a string prefixed with its varint length.
+func (e *BinaryEncoder) WriteVarString(s string) error {
+	if err := e.WriteUvarint(uint64(len(s))); err != nil {
+		return err
+	}
+	_, err := e.buf.WriteString(s)
+	return err
+}
+
 // BinaryDecoder provides efficient binary decoding for row data.
 type BinaryDecoder struct {
-	r io.Reader
+	r *bytes.Reader
 }
 
 // NewBinaryDecoder creates a new binary decoder.
@@ -392,3 +467,29 @@ func (d *BinaryDecoder) ReadBytes() ([]byte, error) {
 	}
 	return buf, nil
 }
+
+// ReadUvarint reads an unsigned varint.
+func (d *BinaryDecoder) ReadUvarint() (uint64, error) {
+	return binary.ReadUvarint(d.r)
+}
+
+// ReadVarint reads a zig-zag encoded signed varint.
+func (d *BinaryDecoder) ReadVarint() (int64, error) {
+	return binary.ReadVarint(d.r)
+}
+
+// ReadVarString reads a string prefixed with its varint length.
+func (d *BinaryDecoder) ReadVarString() (string, error) {
+	length, err := d.ReadUvarint()
+	if err != nil {
+		return "", err
+	}
+	if length > uint64(d.r.Len()) {
+		return "", ErrInvalidMessage
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
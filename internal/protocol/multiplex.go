@@ -26,7 +26,17 @@ connections (streams) over a single TCP connection:
 - Reduces connection overhead
 - Enables concurrent requests on one connection
 - Supports flow control per stream
-- Handles stream prioritization
+
+Each stream is a session of its own: the server serves it exactly like a
+separate connection, with its own authentication, transaction and cursors.
+
+Negotiation:
+============
+
+A client opts in by sending a Multiplex message as the first message on a
+new connection. The server answers with a Multiplex message, and from then
+on both sides send multiplexed frames only. Multiplex reports the refusal
+of a server that answers with an Error instead.
 
 Frame Format:
 =============
@@ -37,28 +47,53 @@ Multiplexed frames add a stream ID to the standard protocol:
   | Magic  | Version| MsgType| Flags  | StreamID (4B)   |    Length (4B)   | Payload...
   +--------+--------+--------+--------+--------+--------+--------+--------+...
 
+A frame carries one standard message of its stream, with the Version, Type
+and Flags of that message, or one of the stream control messages
+StreamWindow and StreamClose.
+
 Stream Lifecycle:
 =================
 
-1. Client opens stream with unique ID
+1. Client opens stream with unique ID (odd IDs)
 2. Messages are tagged with stream ID
 3. Server routes responses to correct stream
-4. Either side can close stream
+4. Each side closes the stream with StreamClose once, and discards the
+   frames that arrive for it afterwards; the stream ID is free again
+   once both sides have closed it
+
+Flow Control:
+=============
+
+Each side announces the receive window of its streams when multiplexing is
+negotiated. The payload bytes sent on a stream use up its send window, and
+the receiver returns them with a StreamWindow frame (a 4-byte big-endian
+increment) once the application has consumed half a window. A frame may be
+sent when its payload fits the window, or when at least half the window is
+available, so messages larger than the window still get through. A stream
+whose reader stops reading thus blocks only its own writers.
 */
 package protocol
 
 import (
+	"bufio"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
+	"net"
+	"os"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // Multiplexing constants
 const (
 	MultiplexHeaderSize = 12 // Magic + Version + Type + Flags + StreamID + Length
 	MaxStreams          = 65536
+
+	// DefaultStreamWindow is the receive window of each stream in bytes.
+	DefaultStreamWindow = 256 * 1024
 )
 
 // Stream states
@@ -74,6 +109,7 @@ var (
 	ErrTooManyStreams  = errors.New("too many streams")
 	ErrStreamNotFound  = errors.New("stream not found")
 	ErrInvalidStreamID = errors.New("invalid stream ID")
+	ErrFlowControl     = errors.New("stream flow control window exceeded")
 )
 
 // MultiplexFrame represents a multiplexed message frame
@@ -83,14 +119,38 @@ type MultiplexFrame struct {
 	Payload  []byte
 }
 
-// Stream represents a logical stream within a multiplexed connection
+// Stream represents a logical stream within a multiplexed connection. It
+// implements net.Conn: the bytes read and written are standard protocol
+// messages, so a stream can be used wherever a connection is.
 type Stream struct {
-	ID       uint32
-	state    uint32
-	recvChan chan *MultiplexFrame
-	sendChan chan *MultiplexFrame
-	mu       sync.Mutex
-	conn     *MultiplexConn
+	ID   uint32
+	conn *MultiplexConn
+
+	mu         sync.Mutex
+	cond       *sync.Cond // Signalled when frames, window or state change
+	state      uint32
+	peerClosed bool // The peer sent StreamClose or the connection closed
+
+	// queue holds the frames received and not yet read; rbuf is the rest
+	// of the message Read is returning.
+	queue []*MultiplexFrame
+	rbuf  []byte
+
+	// recvAvail is the window the peer believes it has, and consumed the
+	// bytes read since the last StreamWindow frame. sendWindow is the
+	// window left for sending.
+	recvAvail  int64
+	consumed   int64
+	sendWindow int64
+
+	readDeadline  time.Time
+	writeDeadline time.Time
+	readTimer     *time.Timer
+	writeTimer    *time.Timer
+
+	// wmu serializes writers; wbuf holds a partially written message.
+	wmu  sync.Mutex
+	wbuf []byte
 }
 
 // MultiplexConn manages a multiplexed connection
@@ -102,20 +162,38 @@ type MultiplexConn struct {
 	isClient   bool
 	closed     atomic.Bool
 	closeChan  chan struct{}
+	acceptChan chan *Stream
 	writeMu    sync.Mutex
 	headerBuf  []byte
 	bufferPool *BufferPool
+
+	// window is the receive window of each stream, peerWindow the peer's.
+	window     int64
+	peerWindow int64
 }
 
-// NewMultiplexConn creates a new multiplexed connection
+// NewMultiplexConn creates a new multiplexed connection whose streams use
+// DefaultStreamWindow on both sides.
 func NewMultiplexConn(conn io.ReadWriteCloser, isClient bool) *MultiplexConn {
+	return newMultiplexConn(conn, isClient, DefaultStreamWindow)
+}
+
+// newMultiplexConn creates a multiplexed connection to a peer with the
+// given receive window.
+func newMultiplexConn(conn io.ReadWriteCloser, isClient bool, peerWindow uint32) *MultiplexConn {
+	if peerWindow == 0 {
+		peerWindow = DefaultStreamWindow
+	}
 	mc := &MultiplexConn{
 		conn:       conn,
 		streams:    make(map[uint32]*Stream),
 		isClient:   isClient,
 		closeChan:  make(chan struct{}),
+		acceptChan: make(chan *Stream, 16),
 		headerBuf:  make([]byte, MultiplexHeaderSize),
 		bufferPool: DefaultBufferPool,
+		window:     DefaultStreamWindow,
+		peerWindow: int64(peerWindow),
 	}
 
 	// Client uses odd stream IDs, server uses even
@@ -131,6 +209,51 @@ func NewMultiplexConn(conn io.ReadWriteCloser, isClient bool) *MultiplexConn {
 	return mc
 }
 
+// Multiplex negotiates multiplexed mode on a new client connection. It
+// must be called before any other message is sent on conn; afterwards conn
+// must only be used through the returned MultiplexConn.
+func Multiplex(conn io.ReadWriteCloser) (*MultiplexConn, error) {
+	req, err := (&MultiplexMessage{Window: DefaultStreamWindow, MaxStreams: MaxStreams}).Encode()
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteMessage(conn, MsgMultiplex, req); err != nil {
+		return nil, err
+	}
+
+	msg, err := ReadMessage(conn)
+	if err != nil {
+		return nil, err
+	}
+	switch msg.Header.Type {
+	case MsgMultiplex:
+		resp, err := DecodeMultiplexMessage(msg.Payload)
+		if err != nil {
+			return nil, err
+		}
+		return newMultiplexConn(conn, true, resp.Window), nil
+	case MsgError:
+		if errMsg, err := DecodeErrorMessage(msg.Payload); err == nil {
+			return nil, fmt.Errorf("multiplexing refused: %s", errMsg.Message)
+		}
+	}
+	return nil, ErrInvalidMessage
+}
+
+// newStream creates a stream and registers it. The caller holds mc.mu.
+func (mc *MultiplexConn) newStream(streamID uint32) *Stream {
+	stream := &Stream{
+		ID:         streamID,
+		conn:       mc,
+		state:      StreamOpen,
+		recvAvail:  mc.window,
+		sendWindow: mc.peerWindow,
+	}
+	stream.cond = sync.NewCond(&stream.mu)
+	mc.streams[streamID] = stream
+	return stream
+}
+
 // OpenStream opens a new stream
 func (mc *MultiplexConn) OpenStream() (*Stream, error) {
 	if mc.closed.Load() {
@@ -147,37 +270,18 @@ func (mc *MultiplexConn) OpenStream() (*Stream, error) {
 	streamID := mc.nextID
 	mc.nextID += 2 // Increment by 2 to maintain odd/even
 
-	stream := &Stream{
-		ID:       streamID,
-		state:    StreamOpen,
-		recvChan: make(chan *MultiplexFrame, 64),
-		sendChan: make(chan *MultiplexFrame, 64),
-		conn:     mc,
-	}
-
-	mc.streams[streamID] = stream
-	return stream, nil
+	return mc.newStream(streamID), nil
 }
 
-// AcceptStream accepts an incoming stream (for server side)
-func (mc *MultiplexConn) AcceptStream(streamID uint32) (*Stream, error) {
-	mc.mu.Lock()
-	defer mc.mu.Unlock()
-
-	if _, exists := mc.streams[streamID]; exists {
-		return nil, ErrInvalidStreamID
-	}
-
-	stream := &Stream{
-		ID:       streamID,
-		state:    StreamOpen,
-		recvChan: make(chan *MultiplexFrame, 64),
-		sendChan: make(chan *MultiplexFrame, 64),
-		conn:     mc,
+// Accept waits for the peer to open a stream. Only the server side accepts
+// streams.
+func (mc *MultiplexConn) Accept() (*Stream, error) {
+	select {
+	case stream := <-mc.acceptChan:
+		return stream, nil
+	case <-mc.closeChan:
+		return nil, ErrStreamClosed
 	}
-
-	mc.streams[streamID] = stream
-	return stream, nil
 }
 
 // GetStream returns an existing stream
@@ -194,21 +298,28 @@ func (mc *MultiplexConn) GetStream(streamID uint32) (*Stream, error) {
 
 // CloseStream closes a stream
 func (mc *MultiplexConn) CloseStream(streamID uint32) error {
-	mc.mu.Lock()
-	stream, ok := mc.streams[streamID]
-	if !ok {
-		mc.mu.Unlock()
-		return ErrStreamNotFound
+	stream, err := mc.GetStream(streamID)
+	if err != nil {
+		return err
 	}
+	return stream.Close()
+}
+
+// forget unregisters a stream both sides have closed.
+func (mc *MultiplexConn) forget(streamID uint32) {
+	mc.mu.Lock()
 	delete(mc.streams, streamID)
 	mc.mu.Unlock()
+}
 
-	atomic.StoreUint32(&stream.state, StreamClosed)
-	close(stream.recvChan)
-	return nil
+// NumStreams returns the number of open streams.
+func (mc *MultiplexConn) NumStreams() int {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return len(mc.streams)
 }
 
-// Close closes the multiplexed connection
+// Close closes the multiplexed connection and all its streams
 func (mc *MultiplexConn) Close() error {
 	if mc.closed.Swap(true) {
 		return nil
@@ -217,59 +328,95 @@ func (mc *MultiplexConn) Close() error {
 	close(mc.closeChan)
 
 	mc.mu.Lock()
-	for id, stream := range mc.streams {
-		atomic.StoreUint32(&stream.state, StreamClosed)
-		close(stream.recvChan)
-		delete(mc.streams, id)
-	}
+	streams := mc.streams
+	mc.streams = make(map[uint32]*Stream)
 	mc.mu.Unlock()
 
+	for _, stream := range streams {
+		stream.mu.Lock()
+		stream.peerClosed = true
+		if stream.state == StreamOpen {
+			stream.state = StreamHalfClosed
+		}
+		stream.cond.Broadcast()
+		stream.mu.Unlock()
+	}
+
 	return mc.conn.Close()
 }
 
 // readLoop reads frames and dispatches to streams
 func (mc *MultiplexConn) readLoop() {
-	for {
-		select {
-		case <-mc.closeChan:
-			return
-		default:
-		}
+	defer mc.Close()
 
+	for {
 		frame, err := mc.readFrame()
 		if err != nil {
-			if err != io.EOF {
-				// Log error
+			if err != io.EOF && !mc.closed.Load() {
+				log.Debug("Multiplexed read error", "error", err)
 			}
-			mc.Close()
 			return
 		}
 
-		mc.mu.RLock()
-		stream, ok := mc.streams[frame.StreamID]
-		mc.mu.RUnlock()
-
-		if !ok {
-			// Unknown stream - could be new stream from peer
-			if !mc.isClient {
-				// Server auto-accepts new streams
-				stream, err = mc.AcceptStream(frame.StreamID)
-				if err != nil {
-					continue
-				}
-			} else {
-				continue
-			}
+		stream := mc.route(frame)
+		if stream == nil {
+			mc.bufferPool.Put(frame.Payload)
+			continue
 		}
 
-		select {
-		case stream.recvChan <- frame:
+		switch frame.Header.Type {
+		case MsgStreamWindow:
+			if len(frame.Payload) == 4 {
+				stream.addWindow(int64(binary.BigEndian.Uint32(frame.Payload)))
+			}
+			mc.bufferPool.Put(frame.Payload)
+		case MsgStreamClose:
+			mc.bufferPool.Put(frame.Payload)
+			stream.remoteClose()
 		default:
-			// Channel full, drop frame (flow control would handle this)
+			if err := stream.push(frame); err != nil {
+				log.Debug("Multiplexed protocol error", "stream_id", frame.StreamID, "error", err)
+				return
+			}
 		}
 	}
 }
 
+// route returns the stream of a frame, accepting the streams the peer opens.
+// It returns nil for frames that must be discarded.
+func (mc *MultiplexConn) route(frame *MultiplexFrame) *Stream {
+	mc.mu.Lock()
+	stream, ok := mc.streams[frame.StreamID]
+	if ok {
+		mc.mu.Unlock()
+		return stream
+	}
+
+	// Closed streams stay registered until both sides closed them, so only
+	// a data frame with an odd ID opens a stream on the server.
+	if mc.isClient || frame.StreamID%2 != 1 ||
+		frame.Header.Type == MsgStreamWindow || frame.Header.Type == MsgStreamClose {
+		mc.mu.Unlock()
+		return nil
+	}
+
+	if len(mc.streams) >= MaxStreams {
+		mc.mu.Unlock()
+		// Refuse the stream without blocking the read loop on the write
+		go mc.writeFrame(frame.StreamID, controlHeader(MsgStreamClose), nil)
+		return nil
+	}
+	stream = mc.newStream(frame.StreamID)
+	mc.mu.Unlock()
+
+	select {
+	case mc.acceptChan <- stream:
+		return stream
+	case <-mc.closeChan:
+		return nil
+	}
+}
+
 // readFrame reads a single multiplexed frame
 func (mc *MultiplexConn) readFrame() (*MultiplexFrame, error) {
 	if _, err := io.ReadFull(mc.conn, mc.headerBuf); err != nil {
@@ -303,69 +450,346 @@ func (mc *MultiplexConn) readFrame() (*MultiplexFrame, error) {
 	return frame, nil
 }
 
-// WriteFrame writes a frame to a stream
-func (mc *MultiplexConn) WriteFrame(streamID uint32, msgType MessageType, flags MessageFlag, payload []byte) error {
+// writeFrame writes a frame of a stream. It bypasses flow control, which
+// the stream applies before calling it.
+func (mc *MultiplexConn) writeFrame(streamID uint32, h Header, payload []byte) error {
 	if mc.closed.Load() {
 		return ErrStreamClosed
 	}
 
-	mc.writeMu.Lock()
-	defer mc.writeMu.Unlock()
-
-	// Build header
 	header := make([]byte, MultiplexHeaderSize)
 	header[0] = MagicByte
-	header[1] = ProtocolVersion
-	header[2] = byte(msgType)
-	header[3] = byte(flags)
+	header[1] = h.Version
+	header[2] = byte(h.Type)
+	header[3] = byte(h.Flags)
 	binary.BigEndian.PutUint32(header[4:8], streamID)
 	binary.BigEndian.PutUint32(header[8:12], uint32(len(payload)))
 
-	// Write header
-	if _, err := mc.conn.Write(header); err != nil {
-		return err
-	}
+	mc.writeMu.Lock()
+	defer mc.writeMu.Unlock()
 
-	// Write payload
+	buffers := net.Buffers{header}
 	if len(payload) > 0 {
-		if _, err := mc.conn.Write(payload); err != nil {
-			return err
-		}
+		buffers = append(buffers, payload)
 	}
+	_, err := buffers.WriteTo(mc.conn)
+	return err
+}
 
-	return nil
+// controlHeader returns the header of a stream control frame.
+func controlHeader(msgType MessageType) Header {
+	return Header{Magic: MagicByte, Version: ProtocolVersion, Type: msgType}
 }
 
 // Stream methods
 
-// Send sends a message on the stream
-func (s *Stream) Send(msgType MessageType, payload []byte) error {
-	if atomic.LoadUint32(&s.state) != StreamOpen {
+// push queues a frame received for the stream.
+func (s *Stream) push(frame *MultiplexFrame) error {
+	cost := int64(len(frame.Payload))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state == StreamClosed {
+		s.conn.bufferPool.Put(frame.Payload)
+		return nil
+	}
+	if s.recvAvail < min(cost, s.conn.window/2) {
+		return ErrFlowControl
+	}
+	s.recvAvail -= cost
+	s.queue = append(s.queue, frame)
+	s.cond.Broadcast()
+	return nil
+}
+
+// next returns the next frame received, waiting for one to arrive.
+func (s *Stream) next() (*MultiplexFrame, error) {
+	s.mu.Lock()
+	for len(s.queue) == 0 {
+		switch {
+		case s.state == StreamClosed:
+			s.mu.Unlock()
+			return nil, ErrStreamClosed
+		case s.state == StreamHalfClosed:
+			s.mu.Unlock()
+			return nil, io.EOF
+		case expired(s.readDeadline):
+			s.mu.Unlock()
+			return nil, os.ErrDeadlineExceeded
+		}
+		s.cond.Wait()
+	}
+
+	frame := s.queue[0]
+	s.queue[0] = nil
+	s.queue = s.queue[1:]
+
+	// Return the window once half of it has been consumed
+	var increment int64
+	s.consumed += int64(len(frame.Payload))
+	if s.consumed >= s.conn.window/2 && s.state == StreamOpen {
+		increment = s.consumed
+		s.consumed = 0
+		s.recvAvail += increment
+	}
+	s.mu.Unlock()
+
+	if increment > 0 {
+		payload := make([]byte, 4)
+		binary.BigEndian.PutUint32(payload, uint32(increment))
+		s.conn.writeFrame(s.ID, controlHeader(MsgStreamWindow), payload)
+	}
+	return frame, nil
+}
+
+// send sends a message on the stream once the window allows it.
+func (s *Stream) send(h Header, payload []byte) error {
+	cost := int64(len(payload))
+
+	s.mu.Lock()
+	for s.sendWindow < min(cost, s.conn.peerWindow/2) {
+		if s.state != StreamOpen {
+			s.mu.Unlock()
+			return ErrStreamClosed
+		}
+		if expired(s.writeDeadline) {
+			s.mu.Unlock()
+			return os.ErrDeadlineExceeded
+		}
+		s.cond.Wait()
+	}
+	if s.state != StreamOpen {
+		s.mu.Unlock()
 		return ErrStreamClosed
 	}
-	return s.conn.WriteFrame(s.ID, msgType, FlagNone, payload)
+	s.sendWindow -= cost
+	s.mu.Unlock()
+
+	return s.conn.writeFrame(s.ID, h, payload)
+}
+
+// addWindow returns window the peer has consumed.
+func (s *Stream) addWindow(increment int64) {
+	s.mu.Lock()
+	s.sendWindow += increment
+	s.cond.Broadcast()
+	s.mu.Unlock()
+}
+
+// remoteClose marks the stream closed by the peer. The frames already
+// received can still be read.
+func (s *Stream) remoteClose() {
+	s.mu.Lock()
+	s.peerClosed = true
+	forget := s.state == StreamClosed
+	if s.state == StreamOpen {
+		s.state = StreamHalfClosed
+	}
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	if forget {
+		s.conn.forget(s.ID)
+	}
 }
 
-// Recv receives a message from the stream
+// Send sends a message on the stream
+func (s *Stream) Send(msgType MessageType, payload []byte) error {
+	return s.send(Header{Magic: MagicByte, Version: ProtocolVersion, Type: msgType}, payload)
+}
+
+// Recv receives a message from the stream. It must not be mixed with Read.
 func (s *Stream) Recv() (*MultiplexFrame, error) {
-	if atomic.LoadUint32(&s.state) == StreamClosed {
+	frame, err := s.next()
+	if err == io.EOF {
 		return nil, ErrStreamClosed
 	}
+	return frame, err
+}
 
-	frame, ok := <-s.recvChan
-	if !ok {
-		return nil, ErrStreamClosed
+// Read reads the messages received on the stream, each with its standard
+// 8-byte header.
+func (s *Stream) Read(p []byte) (int, error) {
+	if len(s.rbuf) == 0 {
+		frame, err := s.next()
+		if err != nil {
+			return 0, err
+		}
+		buf := make([]byte, HeaderSize+len(frame.Payload))
+		buf[0] = frame.Header.Magic
+		buf[1] = frame.Header.Version
+		buf[2] = byte(frame.Header.Type)
+		buf[3] = byte(frame.Header.Flags)
+		binary.BigEndian.PutUint32(buf[4:HeaderSize], uint32(len(frame.Payload)))
+		copy(buf[HeaderSize:], frame.Payload)
+		s.conn.bufferPool.Put(frame.Payload)
+		s.rbuf = buf
 	}
-	return frame, nil
+
+	n := copy(p, s.rbuf)
+	s.rbuf = s.rbuf[n:]
+	return n, nil
+}
+
+// Write writes standard messages to the stream. Each complete message is
+// sent as one frame; a partial message is held until the rest is written.
+func (s *Stream) Write(p []byte) (int, error) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+
+	s.wbuf = append(s.wbuf, p...)
+	for len(s.wbuf) >= HeaderSize {
+		if s.wbuf[0] != MagicByte {
+			return 0, ErrInvalidMagic
+		}
+		length := binary.BigEndian.Uint32(s.wbuf[4:HeaderSize])
+		if length > MaxMessageSize {
+			return 0, ErrMessageTooLarge
+		}
+		size := HeaderSize + int(length)
+		if len(s.wbuf) < size {
+			break
+		}
+		h := Header{
+			Magic:   s.wbuf[0],
+			Version: s.wbuf[1],
+			Type:    MessageType(s.wbuf[2]),
+			Flags:   MessageFlag(s.wbuf[3]),
+		}
+		if err := s.send(h, s.wbuf[HeaderSize:size]); err != nil {
+			return 0, err
+		}
+		s.wbuf = s.wbuf[size:]
+	}
+	if len(s.wbuf) == 0 {
+		s.wbuf = nil
+	}
+	return len(p), nil
 }
 
 // Close closes the stream
 func (s *Stream) Close() error {
-	return s.conn.CloseStream(s.ID)
+	s.mu.Lock()
+	if s.state == StreamClosed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.state = StreamClosed
+	peerClosed := s.peerClosed
+	for _, frame := range s.queue {
+		s.conn.bufferPool.Put(frame.Payload)
+	}
+	s.queue = nil
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	if peerClosed {
+		s.conn.forget(s.ID)
+		return nil
+	}
+	return s.conn.writeFrame(s.ID, controlHeader(MsgStreamClose), nil)
 }
 
-// ID returns the stream ID
+// StreamID returns the stream ID
 func (s *Stream) StreamID() uint32 {
 	return s.ID
 }
 
+// LocalAddr returns the local address of the connection.
+func (s *Stream) LocalAddr() net.Addr {
+	if c, ok := s.conn.conn.(net.Conn); ok {
+		return streamAddr{c.LocalAddr(), s.ID}
+	}
+	return streamAddr{id: s.ID}
+}
+
+// RemoteAddr returns the remote address of the connection, tagged with the
+// stream ID.
+func (s *Stream) RemoteAddr() net.Addr {
+	if c, ok := s.conn.conn.(net.Conn); ok {
+		return streamAddr{c.RemoteAddr(), s.ID}
+	}
+	return streamAddr{id: s.ID}
+}
+
+// SetDeadline sets the read and write deadlines of the stream.
+func (s *Stream) SetDeadline(t time.Time) error {
+	s.SetReadDeadline(t)
+	return s.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for Read and Recv.
+func (s *Stream) SetReadDeadline(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readDeadline = t
+	s.readTimer = s.resetTimer(s.readTimer, t)
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for Write and Send waiting for window.
+func (s *Stream) SetWriteDeadline(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writeDeadline = t
+	s.writeTimer = s.resetTimer(s.writeTimer, t)
+	return nil
+}
+
+// resetTimer replaces timer with one that wakes the waiters of the stream
+// at deadline t. The caller holds s.mu.
+func (s *Stream) resetTimer(timer *time.Timer, t time.Time) *time.Timer {
+	if timer != nil {
+		timer.Stop()
+	}
+	s.cond.Broadcast()
+	if t.IsZero() {
+		return nil
+	}
+	return time.AfterFunc(time.Until(t), func() {
+		s.mu.Lock()
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	})
+}
+
+// expired reports whether deadline is set and has passed.
+func expired(deadline time.Time) bool {
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
+
+// streamAddr is the address of a stream: the address of its connection and
+// the stream ID.
+type streamAddr struct {
+	net.Addr
+	id uint32
+}
+
+// Network returns the network of the connection.
+func (a streamAddr) Network() string {
+	if a.Addr == nil {
+		return "multiplex"
+	}
+	return a.Addr.Network()
+}
+
+// String returns the connection address followed by the stream ID.
+func (a streamAddr) String() string {
+	if a.Addr == nil {
+		return fmt.Sprintf("stream#%d", a.id)
+	}
+	return fmt.Sprintf("%s#%d", a.Addr.String(), a.id)
+}
+
+// bufferedConn is a connection whose reads go through a reader that may
+// already hold data read from it.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// Read reads from the buffered reader.
+func (c bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
@@ -0,0 +1,199 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// multiplexPair negotiates multiplexing over a pipe and returns both ends.
+func multiplexPair(t *testing.T) (client, server *MultiplexConn) {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+
+	done := make(chan *MultiplexConn)
+	go func() {
+		msg, err := ReadMessage(serverConn)
+		if err != nil || msg.Header.Type != MsgMultiplex {
+			t.Errorf("Expected a Multiplex message, got %v (%v)", msg, err)
+			close(done)
+			return
+		}
+		req, _ := DecodeMultiplexMessage(msg.Payload)
+		resp, _ := (&MultiplexMessage{Window: DefaultStreamWindow}).Encode()
+		WriteMessage(serverConn, MsgMultiplex, resp)
+		done <- newMultiplexConn(serverConn, false, req.Window)
+	}()
+
+	client, err := Multiplex(clientConn)
+	if err != nil {
+		t.Fatalf("Multiplex failed: %v", err)
+	}
+	server = <-done
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
+
+// echo answers every message of the streams server accepts with a Pong
+// carrying the same payload.
+func echo(server *MultiplexConn) {
+	for {
+		stream, err := server.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer stream.Close()
+			for {
+				msg, err := ReadMessage(stream)
+				if err != nil {
+					return
+				}
+				WriteMessage(stream, MsgPong, msg.Payload)
+			}
+		}()
+	}
+}
+
+func TestMultiplexStreams(t *testing.T) {
+	client, server := multiplexPair(t)
+	go echo(server)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		stream, err := client.OpenStream()
+		if err != nil {
+			t.Fatalf("OpenStream failed: %v", err)
+		}
+		if stream.ID%2 != 1 {
+			t.Errorf("Client stream has even ID %d", stream.ID)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer stream.Close()
+			for j := 0; j < 20; j++ {
+				payload := []byte(fmt.Sprintf("stream %d message %d", stream.ID, j))
+				if err := WriteMessage(stream, MsgPing, payload); err != nil {
+					t.Errorf("Write failed: %v", err)
+					return
+				}
+				msg, err := ReadMessage(stream)
+				if err != nil {
+					t.Errorf("Read failed: %v", err)
+					return
+				}
+				if msg.Header.Type != MsgPong || !bytes.Equal(msg.Payload, payload) {
+					t.Errorf("Stream %d got %v %q, want Pong %q", stream.ID, msg.Header.Type, msg.Payload, payload)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMultiplexFlowControl(t *testing.T) {
+	client, server := multiplexPair(t)
+
+	blocked, err := client.OpenStream()
+	if err != nil {
+		t.Fatalf("OpenStream failed: %v", err)
+	}
+	large := bytes.Repeat([]byte("x"), DefaultStreamWindow/4)
+	for i := 0; i < 4; i++ {
+		if err := WriteMessage(blocked, MsgPing, large); err != nil {
+			t.Fatalf("Write within the window failed: %v", err)
+		}
+	}
+	accepted, err := server.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+
+	// The window is used up until the server reads
+	blocked.SetWriteDeadline(time.Now().Add(100 * time.Millisecond))
+	if err := WriteMessage(blocked, MsgPing, large); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Write beyond the window returned %v, want a deadline error", err)
+	}
+	blocked.SetWriteDeadline(time.Time{})
+
+	// Other streams are not held up
+	go echo(server)
+	other, _ := client.OpenStream()
+	other.SetDeadline(time.Now().Add(5 * time.Second))
+	WriteMessage(other, MsgPing, []byte("hello"))
+	if msg, err := ReadMessage(other); err != nil || string(msg.Payload) != "hello" {
+		t.Fatalf("Other stream got %v, %v", msg, err)
+	}
+
+	// Reading half the window returns it to the writer
+	for i := 0; i < 2; i++ {
+		if _, err := ReadMessage(accepted); err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+	}
+	blocked.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if err := WriteMessage(blocked, MsgPing, large); err != nil {
+		t.Fatalf("Write after the window was returned failed: %v", err)
+	}
+}
+
+func TestMultiplexClose(t *testing.T) {
+	client, server := multiplexPair(t)
+
+	stream, _ := client.OpenStream()
+	WriteMessage(stream, MsgPing, []byte("last"))
+	accepted, err := server.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	stream.Close()
+
+	// Messages sent before the close are still delivered
+	accepted.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if msg, err := ReadMessage(accepted); err != nil || string(msg.Payload) != "last" {
+		t.Fatalf("Read got %v, %v", msg, err)
+	}
+	if _, err := ReadMessage(accepted); err != io.EOF {
+		t.Fatalf("Read after close returned %v, want EOF", err)
+	}
+	if err := WriteMessage(accepted, MsgPong, nil); err != ErrStreamClosed {
+		t.Errorf("Write after close returned %v, want ErrStreamClosed", err)
+	}
+	accepted.Close()
+
+	// Closing the connection ends every stream
+	open, _ := client.OpenStream()
+	client.Close()
+	if _, err := ReadMessage(open); err != io.EOF {
+		t.Errorf("Read after the connection closed returned %v, want EOF", err)
+	}
+	if _, err := server.Accept(); err != ErrStreamClosed {
+		t.Errorf("Accept after the connection closed returned %v", err)
+	}
+}
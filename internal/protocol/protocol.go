@@ -32,7 +32,7 @@ Message Format:
 	+--------+--------+--------+--------+--------+--------+...
 
 	- Magic (1 byte): Protocol magic number (0xFD for FlyDB)
-	- Version (1 byte): Protocol version (currently 0x01)
+	- Version (1 byte): Protocol version (0x01 or 0x02, see below)
 	- MsgType (1 byte): Message type identifier
 	- Flags (1 byte): Message flags (compression, etc.)
 	- Length (4 bytes): Payload length in big-endian
@@ -52,6 +52,18 @@ Message Types:
 	- 0x09: AuthResult - Authentication response
 	- 0x0A: Ping - Keep-alive ping
 	- 0x0B: Pong - Keep-alive pong
+	- 0x0C: Cancel - Cancel the statement running in another session
+
+Protocol Versions:
+==================
+
+Version 0x01 encodes every payload as JSON. A client asks for version 0x02
+by sending its Auth message with that Version byte; the server answers with
+the version it accepted in the AuthResult header. Under version 0x02,
+QueryResult and CursorResult payloads use a compact binary encoding with
+varint integers, null bitmaps and length-prefixed typed values, and are
+sent with Version 0x02 in their header. All other payloads remain JSON, so
+the Version byte of a message always tells how its payload is encoded.
 */
 package protocol
 
@@ -63,8 +75,9 @@ import (
 
 // Protocol constants.
 const (
-	MagicByte      byte = 0xFD // FlyDB magic byte
-	ProtocolVersion byte = 0x01
+	MagicByte             byte = 0xFD // FlyDB magic byte
+	ProtocolVersion       byte = 0x01 // JSON payloads
+	ProtocolVersionBinary byte = 0x02 // Binary result payloads
 
 	// Maximum message size (16 MB)
 	MaxMessageSize = 16 * 1024 * 1024
@@ -90,6 +103,7 @@ const (
 	MsgAuthResult    MessageType = 0x09
 	MsgPing          MessageType = 0x0A
 	MsgPong          MessageType = 0x0B
+	MsgCancel        MessageType = 0x0C
 
 	// Cursor operations (0x10-0x1F)
 	MsgCursorOpen   MessageType = 0x10
@@ -124,6 +138,11 @@ const (
 	MsgUseDatabase       MessageType = 0x50
 	MsgGetDatabases      MessageType = 0x51
 	MsgDatabaseResult    MessageType = 0x52
+
+	// Multiplex operations (0x60-0x6F)
+	MsgMultiplex    MessageType = 0x60
+	MsgStreamWindow MessageType = 0x61
+	MsgStreamClose  MessageType = 0x62
 )
 
 // MessageFlag represents message flags.
@@ -189,7 +208,7 @@ func ReadHeader(r io.Reader) (Header, error) {
 	if h.Magic != MagicByte {
 		return Header{}, ErrInvalidMagic
 	}
-	if h.Version != ProtocolVersion {
+	if h.Version < ProtocolVersion || h.Version > ProtocolVersionBinary {
 		return Header{}, ErrInvalidVersion
 	}
 	if h.Length > MaxMessageSize {
@@ -201,9 +220,15 @@ func ReadHeader(r io.Reader) (Header, error) {
 
 // WriteMessage writes a complete message to the writer.
 func WriteMessage(w io.Writer, msgType MessageType, payload []byte) error {
+	return WriteMessageVersion(w, ProtocolVersion, msgType, payload)
+}
+
+// WriteMessageVersion writes a complete message with the given protocol
+// version in its header.
+func WriteMessageVersion(w io.Writer, version byte, msgType MessageType, payload []byte) error {
 	h := Header{
 		Magic:   MagicByte,
-		Version: ProtocolVersion,
+		Version: version,
 		Type:    msgType,
 		Flags:   FlagNone,
 		Length:  uint32(len(payload)),
@@ -31,6 +31,24 @@ import (
 // Cursor Messages
 // ============================================================================
 
+// Cursor types of CursorOpenMessage.
+const (
+	CursorForwardOnly = 0
+	CursorStatic      = 1
+	CursorKeyset      = 2
+	CursorDynamic     = 3
+)
+
+// Fetch directions of CursorFetchMessage and CursorScrollMessage.
+const (
+	FetchNext     = 0
+	FetchPrior    = 1
+	FetchFirst    = 2
+	FetchLast     = 3
+	FetchAbsolute = 4
+	FetchRelative = 5
+)
+
 // CursorOpenMessage requests opening a cursor for a query.
 type CursorOpenMessage struct {
 	Query       string        `json:"query"`
@@ -76,6 +94,27 @@ func DecodeCursorFetchMessage(data []byte) (*CursorFetchMessage, error) {
 	return &m, nil
 }
 
+// CursorScrollMessage requests moving a cursor without fetching rows.
+type CursorScrollMessage struct {
+	CursorID  string `json:"cursor_id"`
+	Direction int    `json:"direction"` // Same codes as CursorFetchMessage
+	Offset    int64  `json:"offset"`    // For absolute/relative positioning
+}
+
+// Encode encodes the message to bytes.
+func (m *CursorScrollMessage) Encode() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// DecodeCursorScrollMessage decodes a cursor scroll message.
+func DecodeCursorScrollMessage(data []byte) (*CursorScrollMessage, error) {
+	var m CursorScrollMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
 // CursorCloseMessage requests closing a cursor.
 type CursorCloseMessage struct {
 	CursorID string `json:"cursor_id"`
@@ -318,10 +357,12 @@ func DecodeBeginTxMessage(data []byte) (*BeginTxMessage, error) {
 	return &m, nil
 }
 
-// SavepointMessage requests creating or releasing a savepoint.
+// SavepointMessage requests creating, releasing or rolling back to a
+// savepoint.
 type SavepointMessage struct {
-	Name    string `json:"name"`
-	Release bool   `json:"release"` // true = release, false = create
+	Name     string `json:"name"`
+	Release  bool   `json:"release"`            // true = release, false = create
+	Rollback bool   `json:"rollback,omitempty"` // true = roll back to the savepoint
 }
 
 // Encode encodes the message to bytes.
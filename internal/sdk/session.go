@@ -387,6 +387,10 @@ type ConnectionConfig struct {
 	ApplicationName string
 	// ClientInfo provides additional client information.
 	ClientInfo map[string]string
+	// TLS enables TLS on the connection.
+	TLS bool
+	// Multiplex shares one connection between sessions.
+	Multiplex bool
 }
 
 // NewConnectionConfig creates a new connection configuration with defaults.
@@ -455,6 +459,10 @@ func parseODBCConnectionString(connStr string, config *ConnectionConfig) (*Conne
 			config.ReadOnly = parseBool(value)
 		case "autocommit", "auto commit":
 			config.AutoCommit = parseBool(value)
+		case "tls", "ssl", "encrypt":
+			config.TLS = parseBool(value)
+		case "multiplex":
+			config.Multiplex = parseBool(value)
 		}
 	}
 	return config, nil
@@ -528,6 +536,10 @@ func parseJDBCConnectionString(connStr string, config *ConnectionConfig) (*Conne
 				config.ReadOnly = parseBool(value)
 			case "autocommit":
 				config.AutoCommit = parseBool(value)
+			case "tls", "ssl":
+				config.TLS = parseBool(value)
+			case "multiplex":
+				config.Multiplex = parseBool(value)
 			}
 		}
 	}
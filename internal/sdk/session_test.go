@@ -194,6 +194,19 @@ func TestParseJDBCConnectionString(t *testing.T) {
 				AutoCommit: false,
 			},
 		},
+		{
+			name:    "JDBC with connection options",
+			connStr: "jdbc:flydb://host:8889/db?user=u&password=p&tls=true&multiplex=true",
+			expected: &ConnectionConfig{
+				Host:      "host",
+				Port:      8889,
+				Database:  "db",
+				Username:  "u",
+				Password:  "p",
+				TLS:       true,
+				Multiplex: true,
+			},
+		},
 		{
 			name:    "JDBC minimal",
 			connStr: "jdbc:flydb://server/database",
@@ -230,6 +243,12 @@ func TestParseJDBCConnectionString(t *testing.T) {
 			if config.ReadOnly != tt.expected.ReadOnly {
 				t.Errorf("ReadOnly mismatch: expected %v, got %v", tt.expected.ReadOnly, config.ReadOnly)
 			}
+			if config.TLS != tt.expected.TLS {
+				t.Errorf("TLS mismatch: expected %v, got %v", tt.expected.TLS, config.TLS)
+			}
+			if config.Multiplex != tt.expected.Multiplex {
+				t.Errorf("Multiplex mismatch: expected %v, got %v", tt.expected.Multiplex, config.Multiplex)
+			}
 		})
 	}
 }
@@ -0,0 +1,545 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Cursor Manager Implementation
+=============================
+
+This file implements the CursorManager interface for the binary protocol.
+A cursor runs its query through the same executor as MsgQuery, with the
+connection's database and user, and its typed rows are sent to the client
+in batches of the fetch size.
+
+Static, keyset and dynamic cursors all run their query when they are opened
+and keep a snapshot of its result on the server, so they can be scrolled.
+
+Forward-only cursors stream their query instead. It runs in the background
+from the time the cursor opens, handing over rows as the executor projects
+them, and waits whenever a fetch size of rows is buffered that the client
+has not fetched. Rows are released once returned, so a forward-only cursor
+holds about one fetch of rows whatever the size of its result. Queries the
+executor cannot stream, such as those with ORDER BY or aggregates, are run
+to completion first and their rows are then handed over the same way. The
+query outlives the statement that opened the cursor, so statement timeouts
+do not apply to it; closing the cursor stops it. If it fails, the fetch that
+reaches the failure returns the error.
+
+Positions count rows from 0. The position reported after a fetch or scroll
+is that of the row the next FETCH NEXT returns; it equals the total row
+count once the cursor is past the last row.
+
+The rows of all open cursors share a memory limit. Rows are charged to it
+as the executor projects them, before sorting and LIMIT, so a query whose
+rows do not fit in what is left of it stops early and its cursor fails to
+open or to fetch.
+*/
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sync"
+
+	"flydb/internal/errors"
+	"flydb/internal/protocol"
+	"flydb/internal/sql"
+)
+
+// DefaultCursorMemoryLimit is the default memory limit for the rows of all
+// open cursors.
+const DefaultCursorMemoryLimit = 256 << 20
+
+// defaultCursorFetchSize is the number of rows fetched when neither the
+// fetch nor the cursor names one.
+const defaultCursorFetchSize = 100
+
+// serverCursor is an open cursor.
+type serverCursor struct {
+	forwardOnly bool
+	fetchSize   int
+	rows        [][]interface{} // Rows from position base on
+	sizes       []int64         // Estimated memory of each row in rows
+	base        int64           // Position of rows[0]
+	total       int64           // Total rows in the result
+	start       int64           // Position of the first row of the last fetch
+	next        int64           // Position of the row the next FETCH NEXT returns
+
+	// A streaming cursor receives its rows from a query that is still
+	// running; total counts the rows received so far until it is done.
+	streaming bool
+	ready     bool // Whether the columns are known or the query is done
+	names     []string
+	types     []string
+	want      int64 // Position up to which a fetch waits for rows
+	done      bool  // Whether the query has handed over every row
+	err       error // Error that stopped the query
+	closed    bool
+	cancel    context.CancelFunc
+}
+
+// serverCursorManager implements the protocol.CursorManager interface.
+type serverCursorManager struct {
+	srv *Server
+
+	mu      sync.Mutex
+	cond    *sync.Cond // Signals rows handed to or released by streaming cursors
+	cursors map[string]*serverCursor
+	limit   int64 // Memory limit for the rows of all cursors, 0 = none
+	used    int64 // Estimated memory of the rows held
+}
+
+// newServerCursorManager creates a cursor manager with the default memory limit.
+func newServerCursorManager(srv *Server) *serverCursorManager {
+	m := &serverCursorManager{
+		srv:     srv,
+		cursors: make(map[string]*serverCursor),
+		limit:   DefaultCursorMemoryLimit,
+	}
+	m.cond = sync.NewCond(&m.mu)
+	return m
+}
+
+// SetCursorMemoryLimit sets the memory limit in bytes for the rows held by
+// open cursors; 0 removes the limit.
+func (s *Server) SetCursorMemoryLimit(limit int64) {
+	s.cursors.mu.Lock()
+	defer s.cursors.mu.Unlock()
+	s.cursors.limit = limit
+}
+
+// OpenCursor runs query as user in database and opens a cursor over its rows.
+func (m *serverCursorManager) OpenCursor(ctx context.Context, query, database, user string, cursorType, concurrency, fetchSize int, params []interface{}) (string, []protocol.ColumnMetadata, error) {
+	if cursorType < protocol.CursorForwardOnly || cursorType > protocol.CursorDynamic {
+		return "", nil, errors.NewCursorError(fmt.Sprintf("unknown cursor type %d", cursorType))
+	}
+	if concurrency != 0 {
+		return "", nil, errors.NewCursorError("updatable cursors are not supported")
+	}
+
+	// Only queries are run, so a statement with side effects is not
+	// executed just to find it returns no rows
+	query = sql.BindParameters(query, params)
+	stmt, err := sql.NewParser(sql.NewLexer(query)).Parse()
+	if err != nil {
+		return "", nil, err
+	}
+	switch stmt.(type) {
+	case *sql.SelectStmt, *sql.UnionStmt, *sql.IntersectStmt, *sql.ExceptStmt, *sql.InspectStmt:
+	default:
+		return "", nil, errors.NewCursorError("cursor query does not return rows")
+	}
+
+	if fetchSize <= 0 {
+		fetchSize = defaultCursorFetchSize
+	}
+	if cursorType == protocol.CursorForwardOnly {
+		return m.openStream(ctx, query, database, user, fetchSize)
+	}
+
+	// The reservation is swapped for the size of the final rows once the
+	// query is done.
+	budget, release := m.reservation()
+	executor := &serverQueryExecutor{srv: m.srv}
+	result, err := executor.executeResult(ctx, query, database, user, budget, nil)
+	release()
+	if err != nil {
+		return "", nil, err
+	}
+
+	cursor := &serverCursor{
+		fetchSize: fetchSize,
+		rows:      result.Rows,
+		sizes:     make([]int64, len(result.Rows)),
+		total:     int64(len(result.Rows)),
+	}
+	var size int64
+	for i, row := range result.Rows {
+		cursor.sizes[i] = rowMemory(row)
+		size += cursor.sizes[i]
+	}
+
+	id, err := newRandomID("cur_")
+	if err != nil {
+		return "", nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.limit > 0 && m.used+size > m.limit {
+		return "", nil, m.limitError(size)
+	}
+	m.used += size
+	m.cursors[id] = cursor
+	return id, cursorColumns(result.Columns, result.ColumnTypes), nil
+}
+
+// openStream opens a forward-only cursor whose query hands over its rows
+// as they are fetched. It returns once the query's columns are known.
+func (m *serverCursorManager) openStream(ctx context.Context, query, database, user string, fetchSize int) (string, []protocol.ColumnMetadata, error) {
+	id, err := newRandomID("cur_")
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The query keeps running after the statement that opened the cursor
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	cursor := &serverCursor{
+		forwardOnly: true,
+		fetchSize:   fetchSize,
+		streaming:   true,
+		cancel:      cancel,
+	}
+	go m.produce(ctx, cursor, query, database, user)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for !cursor.ready {
+		m.cond.Wait()
+	}
+	if cursor.err != nil && cursor.names == nil {
+		return "", nil, cursor.err
+	}
+	m.cursors[id] = cursor
+	return id, cursorColumns(cursor.names, cursor.types), nil
+}
+
+// produce runs the query of a streaming cursor, handing its rows to the
+// cursor until it is done, fails or the cursor is closed.
+func (m *serverCursorManager) produce(ctx context.Context, cursor *serverCursor, query, database, user string) {
+	defer cursor.cancel()
+
+	// Rows a query collects before it can hand them over are charged like
+	// those of the other cursor types
+	budget, release := m.reservation()
+	executor := &serverQueryExecutor{srv: m.srv}
+	_, err := executor.executeResult(ctx, query, database, user, budget, &cursorStream{m: m, cursor: cursor})
+	release()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cursor.ready, cursor.done, cursor.err = true, true, err
+	m.cond.Broadcast()
+}
+
+// cursorStream hands the rows of a streaming cursor's query to the cursor.
+type cursorStream struct {
+	m      *serverCursorManager
+	cursor *serverCursor
+}
+
+// Columns records the result columns, which lets the cursor open.
+func (s *cursorStream) Columns(names, types []string) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	s.cursor.names, s.cursor.types, s.cursor.ready = names, types, true
+	s.m.cond.Broadcast()
+	return nil
+}
+
+// Row adds a row to the cursor, first waiting while it holds a fetch of
+// rows the client has not asked for. The row is charged to the memory
+// limit.
+func (s *cursorStream) Row(values []interface{}) error {
+	m, cursor := s.m, s.cursor
+	size := rowMemory(values)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for !cursor.closed && len(cursor.rows) >= cursor.fetchSize && cursor.total >= cursor.want {
+		m.cond.Wait()
+	}
+	if cursor.closed {
+		return errors.QueryCanceled("cursor closed")
+	}
+	if m.limit > 0 && m.used+size > m.limit {
+		return m.limitError(size)
+	}
+	m.used += size
+	cursor.rows = append(cursor.rows, values)
+	cursor.sizes = append(cursor.sizes, size)
+	cursor.total++
+	m.cond.Broadcast()
+	return nil
+}
+
+// reservation returns a row budget that charges the rows of a running
+// query to the memory limit, so a query whose rows do not fit stops once
+// they exceed it rather than after its whole result was built, and a
+// function that releases the charge.
+func (m *serverCursorManager) reservation() (func([]interface{}) error, func()) {
+	var reserved int64
+	budget := func(row []interface{}) error {
+		size := rowMemory(row)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.limit > 0 && m.used+size > m.limit {
+			return m.limitError(reserved + size)
+		}
+		m.used += size
+		reserved += size
+		return nil
+	}
+	release := func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.used -= reserved
+		reserved = 0
+	}
+	return budget, release
+}
+
+// cursorColumns returns the metadata of a cursor's result columns.
+func cursorColumns(names, types []string) []protocol.ColumnMetadata {
+	columns := make([]protocol.ColumnMetadata, len(names))
+	for i, name := range names {
+		typeName := types[i]
+		columns[i] = protocol.ColumnMetadata{
+			Index:       i,
+			Name:        name,
+			Label:       name,
+			Type:        typeName,
+			TypeCode:    getSQLType(typeName),
+			DisplaySize: getColumnSize(typeName),
+			Nullable:    true,
+			ReadOnly:    true,
+		}
+	}
+	return columns
+}
+
+// FetchRows returns up to count rows in the given direction.
+func (m *serverCursorManager) FetchRows(cursorID string, direction int, offset int64, count int) ([][]interface{}, bool, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cursor, ok := m.cursors[cursorID]
+	if !ok {
+		return nil, false, 0, errors.CursorNotOpen(cursorID)
+	}
+	if cursor.forwardOnly && direction != protocol.FetchNext {
+		return nil, false, 0, errors.NewCursorError("cursor is forward-only")
+	}
+	n := int64(count)
+	if n <= 0 {
+		n = int64(cursor.fetchSize)
+	}
+
+	if !m.fill(cursor, endPosition(direction, offset)) {
+		return nil, false, 0, errors.CursorNotOpen(cursorID)
+	}
+	var start int64
+	switch direction {
+	case protocol.FetchNext:
+		start = cursor.next
+	case protocol.FetchPrior:
+		start = cursor.start - n
+	case protocol.FetchFirst:
+		start = 0
+	case protocol.FetchLast:
+		start = cursor.total - n
+	case protocol.FetchAbsolute:
+		start = cursor.absolute(offset)
+	case protocol.FetchRelative:
+		start = cursor.start + offset
+	default:
+		return nil, false, 0, errors.NewCursorError(fmt.Sprintf("unknown fetch direction %d", direction))
+	}
+
+	// One row more than the fetch tells whether rows are left
+	if !m.fill(cursor, start+n+1) {
+		return nil, false, 0, errors.CursorNotOpen(cursorID)
+	}
+	start = cursor.clamp(start)
+	end := cursor.clamp(start + n)
+	if cursor.err != nil && end == cursor.total {
+		return nil, false, 0, cursor.err
+	}
+
+	rows := make([][]interface{}, end-start)
+	copy(rows, cursor.rows[start-cursor.base:end-cursor.base])
+	cursor.start, cursor.next = start, end
+	m.release(cursor)
+	return rows, end < cursor.total, end, nil
+}
+
+// ScrollCursor moves the cursor without returning rows.
+func (m *serverCursorManager) ScrollCursor(cursorID string, direction int, offset int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cursor, ok := m.cursors[cursorID]
+	if !ok {
+		return 0, errors.CursorNotOpen(cursorID)
+	}
+
+	if !m.fill(cursor, endPosition(direction, offset)) {
+		return 0, errors.CursorNotOpen(cursorID)
+	}
+	var next int64
+	switch direction {
+	case protocol.FetchNext:
+		next = cursor.next + 1
+	case protocol.FetchPrior:
+		next = cursor.next - 1
+	case protocol.FetchFirst:
+		next = 0
+	case protocol.FetchLast:
+		next = cursor.total - 1
+	case protocol.FetchAbsolute:
+		next = cursor.absolute(offset)
+	case protocol.FetchRelative:
+		next = cursor.next + offset
+	default:
+		return 0, errors.NewCursorError(fmt.Sprintf("unknown fetch direction %d", direction))
+	}
+	if cursor.forwardOnly && next < cursor.next {
+		return 0, errors.NewCursorError("cursor is forward-only")
+	}
+	if !m.fill(cursor, next+1) {
+		return 0, errors.CursorNotOpen(cursorID)
+	}
+	next = cursor.clamp(next)
+	if cursor.err != nil && next == cursor.total {
+		return 0, cursor.err
+	}
+
+	cursor.start, cursor.next = next, next
+	m.release(cursor)
+	return next, nil
+}
+
+// CloseCursor closes a cursor and frees its rows.
+func (m *serverCursorManager) CloseCursor(cursorID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cursor, ok := m.cursors[cursorID]
+	if !ok {
+		return errors.CursorNotOpen(cursorID)
+	}
+	for _, size := range cursor.sizes {
+		m.used -= size
+	}
+	delete(m.cursors, cursorID)
+	if cursor.streaming {
+		cursor.closed = true
+		cursor.cancel()
+		m.cond.Broadcast()
+	}
+	return nil
+}
+
+// fill waits until a streaming cursor has received the rows before
+// position, or its query is done. It reports false if the cursor was
+// closed meanwhile. The caller must hold m.mu.
+func (m *serverCursorManager) fill(cursor *serverCursor, position int64) bool {
+	if !cursor.streaming {
+		return true
+	}
+	if position > cursor.want {
+		cursor.want = position
+		m.cond.Broadcast()
+	}
+	for !cursor.closed && !cursor.done && cursor.total < position {
+		m.cond.Wait()
+	}
+	return !cursor.closed
+}
+
+// endPosition returns the position a fetch or scroll in direction must
+// wait for before it can be placed: the end of the result for those
+// counted from it, and 0 for the others.
+func endPosition(direction int, offset int64) int64 {
+	if direction == protocol.FetchLast || (direction == protocol.FetchAbsolute && offset < 0) {
+		return math.MaxInt64
+	}
+	return 0
+}
+
+// limitError returns the error of a result needing size bytes more than
+// the memory limit leaves. The caller must hold m.mu.
+func (m *serverCursorManager) limitError(size int64) error {
+	return errors.NewCursorError("cursor memory limit exceeded").
+		WithDetail(fmt.Sprintf("result needs %d bytes, %d of %d in use", size, m.used, m.limit)).
+		WithHint("Fetch and close open cursors, or narrow the query")
+}
+
+// release frees the rows a forward-only cursor has moved past. The caller
+// must hold m.mu.
+func (m *serverCursorManager) release(cursor *serverCursor) {
+	if !cursor.forwardOnly {
+		return
+	}
+	done := int(cursor.next - cursor.base)
+	for i := 0; i < done; i++ {
+		m.used -= cursor.sizes[i]
+		cursor.rows[i] = nil
+	}
+	cursor.rows = cursor.rows[done:]
+	cursor.sizes = cursor.sizes[done:]
+	cursor.base = cursor.next
+	if cursor.streaming {
+		m.cond.Broadcast()
+	}
+}
+
+// absolute returns the position of an absolute offset; negative offsets
+// count back from the end, -1 being the last row.
+func (c *serverCursor) absolute(offset int64) int64 {
+	if offset < 0 {
+		return c.total + offset
+	}
+	return offset
+}
+
+// clamp limits a position to the rows the cursor still holds.
+func (c *serverCursor) clamp(position int64) int64 {
+	if position < c.base {
+		return c.base
+	}
+	if position > c.total {
+		return c.total
+	}
+	return position
+}
+
+// rowMemory estimates the memory held by a row of typed values.
+func rowMemory(row []interface{}) int64 {
+	size := int64(24 + 16*len(row))
+	for _, value := range row {
+		switch v := value.(type) {
+		case string:
+			size += int64(len(v))
+		case []byte:
+			size += int64(len(v))
+		case nil, bool:
+		default:
+			size += 8
+		}
+	}
+	return size
+}
+
+// newRandomID returns a random cursor or transaction ID with the given prefix.
+func newRandomID(prefix string) (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,303 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"flydb/internal/protocol"
+)
+
+// openTestCursor creates a table of n rows and opens a cursor over it.
+func openTestCursor(t *testing.T, srv *Server, n, cursorType int) string {
+	t.Helper()
+	executor := &serverQueryExecutor{srv: srv}
+	if _, err := executor.ExecuteResultContext(context.Background(), "CREATE TABLE nums (n INT)", "", "admin"); err != nil {
+		t.Fatalf("CREATE TABLE failed: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		query := fmt.Sprintf("INSERT INTO nums VALUES (%d)", i)
+		if _, err := executor.ExecuteResultContext(context.Background(), query, "", "admin"); err != nil {
+			t.Fatalf("INSERT failed: %v", err)
+		}
+	}
+	id, columns, err := srv.cursors.OpenCursor(context.Background(), "SELECT n FROM nums ORDER BY n", "", "admin", cursorType, 0, 2, nil)
+	if err != nil {
+		t.Fatalf("OpenCursor failed: %v", err)
+	}
+	if len(columns) != 1 || columns[0].Name != "n" {
+		t.Fatalf("Unexpected columns %+v", columns)
+	}
+	return id
+}
+
+func TestCursorFetchAndScroll(t *testing.T) {
+	srv, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	id := openTestCursor(t, srv, 5, protocol.CursorStatic)
+	m := srv.cursors
+
+	fetch := func(direction int, offset int64, count int, want string, wantPosition int64) {
+		t.Helper()
+		rows, _, position, err := m.FetchRows(id, direction, offset, count)
+		if err != nil {
+			t.Fatalf("FetchRows(%d, %d) failed: %v", direction, offset, err)
+		}
+		var got []string
+		for _, row := range rows {
+			got = append(got, fmt.Sprint(row[0]))
+		}
+		if strings.Join(got, ",") != want || position != wantPosition {
+			t.Errorf("FetchRows(%d, %d) = %v at %d, want %s at %d", direction, offset, got, position, want, wantPosition)
+		}
+	}
+
+	fetch(protocol.FetchNext, 0, 0, "0,1", 2)
+	fetch(protocol.FetchNext, 0, 0, "2,3", 4)
+	fetch(protocol.FetchPrior, 0, 0, "0,1", 2)
+	fetch(protocol.FetchLast, 0, 0, "3,4", 5)
+	fetch(protocol.FetchAbsolute, -1, 1, "4", 5)
+	fetch(protocol.FetchFirst, 0, 3, "0,1,2", 3)
+	fetch(protocol.FetchRelative, 1, 1, "1", 2)
+
+	if position, err := m.ScrollCursor(id, protocol.FetchAbsolute, 3); err != nil || position != 3 {
+		t.Fatalf("ScrollCursor = %d, %v; want 3", position, err)
+	}
+	fetch(protocol.FetchNext, 0, 0, "3,4", 5)
+
+	// Fetching past the end returns no rows
+	rows, hasMore, _, err := m.FetchRows(id, protocol.FetchNext, 0, 0)
+	if err != nil || len(rows) != 0 || hasMore {
+		t.Errorf("Fetch past the end = %v, %v, %v", rows, hasMore, err)
+	}
+
+	if err := m.CloseCursor(id); err != nil {
+		t.Fatalf("CloseCursor failed: %v", err)
+	}
+	if m.used != 0 {
+		t.Errorf("Memory in use after close = %d, want 0", m.used)
+	}
+	if _, _, _, err := m.FetchRows(id, protocol.FetchNext, 0, 0); err == nil {
+		t.Error("Expected an error fetching from a closed cursor")
+	}
+}
+
+func TestCursorForwardOnly(t *testing.T) {
+	srv, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	id := openTestCursor(t, srv, 4, protocol.CursorForwardOnly)
+	m := srv.cursors
+
+	rows, hasMore, position, err := m.FetchRows(id, protocol.FetchNext, 0, 0)
+	if err != nil || len(rows) != 2 || !hasMore || position != 2 {
+		t.Fatalf("FetchRows = %v, %v, %d, %v", rows, hasMore, position, err)
+	}
+	if _, _, _, err := m.FetchRows(id, protocol.FetchFirst, 0, 0); err == nil {
+		t.Error("Expected FETCH FIRST to fail on a forward-only cursor")
+	}
+	if _, err := m.ScrollCursor(id, protocol.FetchPrior, 0); err == nil {
+		t.Error("Expected scrolling back to fail on a forward-only cursor")
+	}
+	if position, err := m.ScrollCursor(id, protocol.FetchNext, 0); err != nil || position != 3 {
+		t.Errorf("ScrollCursor(NEXT) = %d, %v; want 3", position, err)
+	}
+	m.CloseCursor(id)
+}
+
+func TestCursorStreaming(t *testing.T) {
+	srv, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	executor := &serverQueryExecutor{srv: srv}
+	executor.ExecuteResultContext(context.Background(), "CREATE TABLE nums (n INT)", "", "admin")
+	for i := 0; i < 50; i++ {
+		executor.ExecuteResultContext(context.Background(), fmt.Sprintf("INSERT INTO nums VALUES (%d)", i), "", "admin")
+	}
+	m := srv.cursors
+
+	// Three rows fit in the limit, so a static cursor cannot hold the result
+	// but a forward-only cursor streams it
+	srv.SetCursorMemoryLimit(3 * rowMemory([]interface{}{int64(0)}))
+	if _, _, err := m.OpenCursor(context.Background(), "SELECT n FROM nums", "", "admin", protocol.CursorStatic, 0, 2, nil); err == nil {
+		t.Fatal("Expected a static cursor to exceed the memory limit")
+	}
+	id, _, err := m.OpenCursor(context.Background(), "SELECT n FROM nums", "", "admin", protocol.CursorForwardOnly, 0, 2, nil)
+	if err != nil {
+		t.Fatalf("OpenCursor failed: %v", err)
+	}
+	seen := make(map[interface{}]bool)
+	for {
+		rows, hasMore, _, err := m.FetchRows(id, protocol.FetchNext, 0, 0)
+		if err != nil {
+			t.Fatalf("FetchRows failed after %d rows: %v", len(seen), err)
+		}
+		for _, row := range rows {
+			seen[row[0]] = true
+		}
+		m.mu.Lock()
+		held := len(m.cursors[id].rows)
+		m.mu.Unlock()
+		if held > 2 {
+			t.Errorf("Cursor holds %d rows, want at most a fetch", held)
+		}
+		if !hasMore {
+			break
+		}
+	}
+	if len(seen) != 50 {
+		t.Errorf("Fetched %d distinct rows, want 50", len(seen))
+	}
+	m.CloseCursor(id)
+
+	// A query that fails once the cursor is open fails the fetch that
+	// reaches the failure
+	id, _, err = m.OpenCursor(context.Background(), "SELECT n FROM nums", "", "admin", protocol.CursorForwardOnly, 0, 10, nil)
+	if err != nil {
+		t.Fatalf("OpenCursor failed: %v", err)
+	}
+	if _, _, _, err := m.FetchRows(id, protocol.FetchNext, 0, 0); err == nil || !strings.Contains(err.Error(), "memory limit") {
+		t.Errorf("Expected the memory limit to be exceeded, got %v", err)
+	}
+
+	// Closing the cursor stops its query and frees its rows
+	srv.SetCursorMemoryLimit(0)
+	id2, _, err := m.OpenCursor(context.Background(), "SELECT n FROM nums", "", "admin", protocol.CursorForwardOnly, 0, 2, nil)
+	if err != nil {
+		t.Fatalf("OpenCursor failed: %v", err)
+	}
+	m.CloseCursor(id)
+	m.CloseCursor(id2)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.used != 0 {
+		t.Errorf("Memory in use after close = %d, want 0", m.used)
+	}
+}
+
+func TestCursorMemoryLimit(t *testing.T) {
+	srv, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	srv.SetCursorMemoryLimit(1)
+
+	executor := &serverQueryExecutor{srv: srv}
+	executor.ExecuteResultContext(context.Background(), "CREATE TABLE nums (n INT)", "", "admin")
+	executor.ExecuteResultContext(context.Background(), "INSERT INTO nums VALUES (1)", "", "admin")
+
+	_, _, err := srv.cursors.OpenCursor(context.Background(), "SELECT n FROM nums", "", "admin", protocol.CursorStatic, 0, 0, nil)
+	if err == nil || !strings.Contains(err.Error(), "memory limit") {
+		t.Fatalf("Expected the memory limit to be exceeded, got %v", err)
+	}
+
+	// Rows charged while the query ran are released when it fails
+	srv.SetCursorMemoryLimit(1000)
+	for i := 0; i < 100; i++ {
+		executor.ExecuteResultContext(context.Background(), fmt.Sprintf("INSERT INTO nums VALUES (%d)", i), "", "admin")
+	}
+	_, _, err = srv.cursors.OpenCursor(context.Background(), "SELECT n FROM nums ORDER BY n LIMIT 1", "", "admin", protocol.CursorStatic, 0, 0, nil)
+	if err == nil || !strings.Contains(err.Error(), "memory limit") {
+		t.Fatalf("Expected the memory limit to be exceeded while rows arrive, got %v", err)
+	}
+	if srv.cursors.used != 0 {
+		t.Errorf("Memory in use after a failed open = %d, want 0", srv.cursors.used)
+	}
+
+	_, _, err = srv.cursors.OpenCursor(context.Background(), "DELETE FROM nums", "", "admin", protocol.CursorStatic, 0, 0, nil)
+	if err == nil {
+		t.Fatal("Expected a cursor over DELETE to fail")
+	}
+	result, _ := executor.ExecuteResultContext(context.Background(), "SELECT n FROM nums", "", "admin")
+	if len(result.Rows) != 101 {
+		t.Errorf("Cursor open ran DELETE: %d rows left", len(result.Rows))
+	}
+}
+
+func TestServerCursors(t *testing.T) {
+	srv, addr, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	go srv.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	send := func(msgType protocol.MessageType, m interface{ Encode() ([]byte, error) }) *protocol.Message {
+		t.Helper()
+		payload, _ := m.Encode()
+		sendBinaryMessage(conn, msgType, payload)
+		msg, err := readBinaryMessage(conn)
+		if err != nil {
+			t.Fatalf("Failed to read response: %v", err)
+		}
+		return msg
+	}
+	cursorResult := func(msg *protocol.Message) *protocol.CursorResultMessage {
+		t.Helper()
+		if msg.Header.Type != protocol.MsgCursorResult {
+			t.Fatalf("Expected a cursor result, got %s", msg.Payload)
+		}
+		result, err := protocol.DecodeCursorResultMessage(msg.Payload)
+		if err != nil {
+			t.Fatalf("Failed to decode cursor result: %v", err)
+		}
+		return result
+	}
+
+	send(protocol.MsgAuth, &protocol.AuthMessage{Username: "admin", Password: testAdminPassword})
+	send(protocol.MsgQuery, &protocol.QueryMessage{Query: "CREATE TABLE items (id INT)"})
+	send(protocol.MsgQuery, &protocol.QueryMessage{Query: "INSERT INTO items VALUES (1)"})
+	send(protocol.MsgQuery, &protocol.QueryMessage{Query: "INSERT INTO items VALUES (2)"})
+	send(protocol.MsgQuery, &protocol.QueryMessage{Query: "INSERT INTO items VALUES (3)"})
+
+	open := cursorResult(send(protocol.MsgCursorOpen, &protocol.CursorOpenMessage{
+		Query:      "SELECT id FROM items WHERE id > $1 ORDER BY id",
+		CursorType: protocol.CursorStatic,
+		FetchSize:  1,
+		Parameters: []interface{}{float64(1)},
+	}))
+	if open.CursorID == "" || len(open.Columns) != 1 {
+		t.Fatalf("Unexpected open result %+v", open)
+	}
+
+	fetch := cursorResult(send(protocol.MsgCursorFetch, &protocol.CursorFetchMessage{CursorID: open.CursorID}))
+	if fetch.RowCount != 1 || !fetch.HasMoreRows || fetch.Position != 1 {
+		t.Errorf("Unexpected fetch result %+v", fetch)
+	}
+
+	scroll := cursorResult(send(protocol.MsgCursorScroll, &protocol.CursorScrollMessage{CursorID: open.CursorID, Direction: protocol.FetchFirst}))
+	if scroll.Position != 0 {
+		t.Errorf("Scroll position = %d, want 0", scroll.Position)
+	}
+
+	fetch = cursorResult(send(protocol.MsgCursorFetch, &protocol.CursorFetchMessage{CursorID: open.CursorID, Count: 5}))
+	if fetch.RowCount != 2 || fetch.HasMoreRows {
+		t.Errorf("Unexpected fetch result %+v", fetch)
+	}
+
+	cursorResult(send(protocol.MsgCursorClose, &protocol.CursorCloseMessage{CursorID: open.CursorID}))
+	if msg := send(protocol.MsgCursorFetch, &protocol.CursorFetchMessage{CursorID: open.CursorID}); msg.Header.Type != protocol.MsgError {
+		t.Errorf("Expected fetching a closed cursor to fail, got %s", msg.Payload)
+	}
+}
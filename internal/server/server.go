@@ -63,10 +63,12 @@ This ensures safe concurrent access from multiple goroutines.
 package server
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
 	"sort"
 	"strings"
 	"sync"
@@ -74,7 +76,9 @@ import (
 
 	"flydb/internal/auth"
 	"flydb/internal/errors"
+	"flydb/internal/httpapi"
 	"flydb/internal/logging"
+	"flydb/internal/pgwire"
 	"flydb/internal/protocol"
 	"flydb/internal/sql"
 	"flydb/internal/storage"
@@ -145,6 +149,12 @@ type Server struct {
 	// binaryHandler handles binary protocol connections.
 	binaryHandler *protocol.BinaryHandler
 
+	// pgHandler handles PostgreSQL wire protocol connections.
+	pgHandler *pgwire.Handler
+
+	// httpHandler serves the HTTP/JSON query API.
+	httpHandler *httpapi.Handler
+
 	// preparedStmts manages prepared statements for the server.
 	preparedStmts *sql.PreparedStatementManager
 
@@ -155,6 +165,14 @@ type Server struct {
 	// tlsAddr is the TCP address for TLS connections (e.g., ":8889").
 	tlsAddr string
 
+	// pgAddr is the TCP address for PostgreSQL connections (e.g., ":5432").
+	// If empty, the PostgreSQL listener is disabled.
+	pgAddr string
+
+	// httpAddr is the TCP address of the HTTP/JSON query API (e.g., ":9196").
+	// If empty, the HTTP API is disabled.
+	httpAddr string
+
 	// listeners holds all active listeners for graceful shutdown.
 	listeners []net.Listener
 
@@ -166,6 +184,37 @@ type Server struct {
 
 	// stopped indicates if the server has been stopped.
 	stopped bool
+
+	// autoAnalyzeFraction is applied to every executor the server creates.
+	autoAnalyzeFraction float64
+
+	// clusterAdmin runs ALTER CLUSTER membership changes; nil outside a
+	// Raft cluster. It is applied to every executor the server creates.
+	clusterAdmin sql.ClusterAdmin
+
+	// clusterInspector serves INSPECT CLUSTER; nil outside cluster mode.
+	// It is applied to every executor the server creates.
+	clusterInspector sql.ClusterInspector
+
+	// clusterRepairer runs REPAIR PARTITION; nil outside cluster mode.
+	// It is applied to every executor the server creates.
+	clusterRepairer sql.ClusterRepairer
+
+	// distributed pushes SELECTs down to the partition owners of a
+	// cluster; nil outside cluster mode. It is applied to every executor
+	// the server creates.
+	distributed sql.DistributedScanner
+
+	// ttlReaperInterval is the time between passes of the reaper that
+	// deletes expired rows; 0 disables it. ttlBatchSize caps each batch.
+	ttlReaperInterval time.Duration
+	ttlBatchSize      int
+
+	// cursors holds the server-side cursors of binary protocol connections.
+	cursors *serverCursorManager
+
+	// txns holds the transactions of binary protocol connections.
+	txns *serverTransactionManager
 }
 
 // NewServerWithStore creates a new Server using an existing storage engine.
@@ -213,18 +262,38 @@ func NewServerWithStore(addr string, store storage.Engine) *Server {
 		preparedStmts:     prepMgr,
 		listeners:         make([]net.Listener, 0),
 		stopCh:            make(chan struct{}),
+
+		autoAnalyzeFraction: sql.DefaultAutoAnalyzeFraction,
+		ttlBatchSize:        sql.DefaultTTLBatchSize,
 	}
 
 	// Create the binary protocol handler.
 	srv.binaryHandler = protocol.NewBinaryHandler(
 		&serverQueryExecutor{srv: srv},
-		prepMgr,
+		&serverPreparedStatements{PreparedStatementManager: prepMgr, srv: srv},
 		&serverAuthenticator{auth: authMgr},
 	)
 
 	// Wire up optional dependencies for ODBC/JDBC driver support
 	srv.binaryHandler.SetMetadataProvider(&serverMetadataProvider{srv: srv})
 	srv.binaryHandler.SetDatabaseManager(&serverDatabaseManager{srv: srv})
+	srv.binaryHandler.SetCommitSyncer(&serverCommitSyncer{srv: srv})
+	srv.cursors = newServerCursorManager(srv)
+	srv.binaryHandler.SetCursorManager(srv.cursors)
+	srv.txns = newServerTransactionManager(srv)
+	srv.binaryHandler.SetTransactionManager(srv.txns)
+
+	// Create the PostgreSQL wire protocol handler on the same executor.
+	srv.pgHandler = pgwire.NewHandler(&serverQueryExecutor{srv: srv}, &serverAuthenticator{auth: authMgr})
+	srv.pgHandler.SetTransactionManager(srv.txns)
+	srv.pgHandler.SetDatabaseManager(&serverDatabaseManager{srv: srv})
+	srv.pgHandler.SetCommitSyncer(&serverCommitSyncer{srv: srv})
+
+	// Create the HTTP/JSON query API handler on the same executor.
+	srv.httpHandler = httpapi.NewHandler(&serverQueryExecutor{srv: srv}, &serverAuthenticator{auth: authMgr})
+	srv.httpHandler.SetTransactionManager(srv.txns)
+	srv.httpHandler.SetDatabaseManager(&serverDatabaseManager{srv: srv})
+	srv.httpHandler.SetCommitSyncer(&serverCommitSyncer{srv: srv})
 
 	// Wire up the OnInsert callback for reactive WATCH functionality.
 	// When the executor inserts a row, it calls this callback,
@@ -316,18 +385,38 @@ func NewServerWithDatabaseManager(addr string, binaryAddr string, dbManager *sto
 		preparedStmts:     prepMgr,
 		listeners:         make([]net.Listener, 0),
 		stopCh:            make(chan struct{}),
+
+		autoAnalyzeFraction: sql.DefaultAutoAnalyzeFraction,
+		ttlBatchSize:        sql.DefaultTTLBatchSize,
 	}
 
 	// Create the binary protocol handler.
 	srv.binaryHandler = protocol.NewBinaryHandler(
 		&serverQueryExecutor{srv: srv},
-		prepMgr,
+		&serverPreparedStatements{PreparedStatementManager: prepMgr, srv: srv},
 		&serverAuthenticator{auth: authMgr},
 	)
 
 	// Wire up optional dependencies for ODBC/JDBC driver support
 	srv.binaryHandler.SetMetadataProvider(&serverMetadataProvider{srv: srv})
 	srv.binaryHandler.SetDatabaseManager(&serverDatabaseManager{srv: srv})
+	srv.binaryHandler.SetCommitSyncer(&serverCommitSyncer{srv: srv})
+	srv.cursors = newServerCursorManager(srv)
+	srv.binaryHandler.SetCursorManager(srv.cursors)
+	srv.txns = newServerTransactionManager(srv)
+	srv.binaryHandler.SetTransactionManager(srv.txns)
+
+	// Create the PostgreSQL wire protocol handler on the same executor.
+	srv.pgHandler = pgwire.NewHandler(&serverQueryExecutor{srv: srv}, &serverAuthenticator{auth: authMgr})
+	srv.pgHandler.SetTransactionManager(srv.txns)
+	srv.pgHandler.SetDatabaseManager(&serverDatabaseManager{srv: srv})
+	srv.pgHandler.SetCommitSyncer(&serverCommitSyncer{srv: srv})
+
+	// Create the HTTP/JSON query API handler on the same executor.
+	srv.httpHandler = httpapi.NewHandler(&serverQueryExecutor{srv: srv}, &serverAuthenticator{auth: authMgr})
+	srv.httpHandler.SetTransactionManager(srv.txns)
+	srv.httpHandler.SetDatabaseManager(&serverDatabaseManager{srv: srv})
+	srv.httpHandler.SetCommitSyncer(&serverCommitSyncer{srv: srv})
 
 	// Wire up the OnInsert callback for reactive WATCH functionality.
 	// When the executor inserts a row, it calls this callback,
@@ -381,8 +470,43 @@ func (m *serverDatabaseManager) ListDatabases() []string {
 	return m.srv.dbManager.ListDatabases()
 }
 
+// serverCommitSyncer implements the protocol.CommitSyncer interface.
+type serverCommitSyncer struct {
+	srv *Server
+}
+
+// ValidateSyncPolicy checks a synchronous_commit value.
+func (c *serverCommitSyncer) ValidateSyncPolicy(policy string) error {
+	_, err := storage.ParseSyncPolicy(policy)
+	return err
+}
+
+// SyncCommit waits until the writes of the statement just executed are
+// durable under policy, or under the server default when policy is empty.
+func (c *serverCommitSyncer) SyncCommit(policy string) error {
+	var p storage.SyncPolicy
+	if c.srv.dbManager != nil {
+		p = c.srv.dbManager.CommitPolicy()
+	}
+	if policy != "" {
+		var err error
+		if p, err = storage.ParseSyncPolicy(policy); err != nil {
+			return err
+		}
+	}
+
+	if c.srv.dbManager != nil {
+		return c.srv.dbManager.Commit(p)
+	}
+	if committer, ok := c.srv.store.(storage.Committer); ok {
+		return committer.Commit(p)
+	}
+	return nil
+}
+
 // serverQueryExecutor adapts the server for the QueryExecutor interface.
-// It implements both QueryExecutor and DatabaseAwareQueryExecutor.
+// It implements QueryExecutor, DatabaseAwareQueryExecutor,
+// ResultQueryExecutor and ContextQueryExecutor.
 type serverQueryExecutor struct {
 	srv *Server
 }
@@ -395,11 +519,125 @@ func (e *serverQueryExecutor) Execute(query string, user string) (string, error)
 // ExecuteInDatabase executes a query in the context of a specific database.
 // If database is empty, the default database is used.
 func (e *serverQueryExecutor) ExecuteInDatabase(query, database string, user string) (string, error) {
+	stmt, result, handled, err := e.executeServerStatement(query)
+	if err != nil || handled {
+		return result, err
+	}
+
+	// Get the executor for the specified database
+	executor := e.getExecutorForDatabase(database)
+
+	// Thread-safe: sql.Executor.Execute now handles user context if modified,
+	// but wait, sql.Executor.Execute doesn't take a user parameter yet.
+	// We need to set it on a cloned or thread-safe way.
+	// For now, let's use a mutex or a new approach.
+	// Actually, the best way is to pass user to executor.Execute.
+	return executor.ExecuteWithUser(stmt, user)
+}
+
+// ExecuteResultInDatabase executes a query like ExecuteInDatabase and
+// returns its structured result for the binary protocol.
+func (e *serverQueryExecutor) ExecuteResultInDatabase(query, database string, user string) (*protocol.QueryResult, error) {
+	return e.ExecuteResultContext(context.Background(), query, database, user)
+}
+
+// ExecuteResultContext executes a query like ExecuteResultInDatabase and
+// cancels it when ctx is done.
+func (e *serverQueryExecutor) ExecuteResultContext(ctx context.Context, query, database string, user string) (*protocol.QueryResult, error) {
+	return e.executeResult(ctx, query, database, user, nil, nil)
+}
+
+// ExecuteStreamContext executes a query like ExecuteResultContext and
+// hands its rows to stream as the executor produces them.
+func (e *serverQueryExecutor) ExecuteStreamContext(ctx context.Context, query, database string, user string, stream protocol.RowStream) (*protocol.QueryResult, error) {
+	return e.executeResult(ctx, query, database, user, nil, stream)
+}
+
+// executeResult executes a query like ExecuteResultContext, charging the
+// rows of a SELECT to budget, if not nil, as they are projected. With a
+// stream, the rows are handed to it instead of returned.
+func (e *serverQueryExecutor) executeResult(ctx context.Context, query, database, user string, budget func([]interface{}) error, stream protocol.RowStream) (*protocol.QueryResult, error) {
+	// Statements of a protocol transaction run through it; those that
+	// cannot are rejected before the server runs them
+	executor := e.getExecutorForDatabase(database)
+	if txID := protocol.TransactionFromContext(ctx); txID != "" {
+		stmt, err := sql.NewParser(sql.NewLexer(query)).Parse()
+		if err != nil {
+			return nil, err
+		}
+		if executor, err = e.srv.txns.executor(txID, database, stmt); err != nil {
+			return nil, err
+		}
+	}
+
+	stmt, text, handled, err := e.executeServerStatement(query)
+	if err != nil {
+		return nil, err
+	}
+
+	executor = executor.WithContext(ctx)
+	if budget != nil {
+		executor = executor.WithRowBudget(budget)
+	}
+
+	var result *sql.Result
+	switch {
+	case handled:
+		result = sql.NewTextResult(text)
+		if stream != nil {
+			if err := sql.StreamResult(result, resultStream{stream}); err != nil {
+				return nil, err
+			}
+		}
+	case stream != nil:
+		if result, err = executor.ExecuteStream(stmt, user, resultStream{stream}); err != nil {
+			return nil, transactionError(err)
+		}
+	default:
+		if result, err = executor.ExecuteResult(stmt, user); err != nil {
+			return nil, transactionError(err)
+		}
+	}
+
+	queryResult := &protocol.QueryResult{
+		Message:      result.Message,
+		Rows:         result.Rows,
+		RowsAffected: result.RowsAffected,
+		LastInsertID: result.LastInsertID,
+	}
+	queryResult.Columns, queryResult.ColumnTypes = resultColumns(result.Columns)
+	return queryResult, nil
+}
+
+// resultColumns returns the names and types of result columns.
+func resultColumns(columns []sql.ResultColumn) (names, types []string) {
+	for _, col := range columns {
+		names = append(names, col.Name)
+		types = append(types, col.Type)
+	}
+	return names, types
+}
+
+// resultStream hands the rows of an executor result to a protocol stream.
+type resultStream struct {
+	protocol.RowStream
+}
+
+// Columns sends the names and types of the result columns.
+func (s resultStream) Columns(columns []sql.ResultColumn) error {
+	names, types := resultColumns(columns)
+	return s.RowStream.Columns(names, types)
+}
+
+// executeServerStatement parses a query and executes it if it is one of the
+// statements handled at the server level. handled reports whether it was;
+// otherwise the parsed statement is returned for the database executor.
+func (e *serverQueryExecutor) executeServerStatement(query string) (stmt sql.Statement, result string, handled bool, err error) {
 	lexer := sql.NewLexer(query)
 	parser := sql.NewParser(lexer)
-	stmt, err := parser.Parse()
+	stmt, err = parser.Parse()
 	if err != nil {
-		return "", err
+		return nil, "", false, err
 	}
 
 	// Handle database management statements at the server level
@@ -407,36 +645,34 @@ func (e *serverQueryExecutor) ExecuteInDatabase(query, database string, user str
 	switch dbStmt := stmt.(type) {
 	case *sql.InspectStmt:
 		if dbStmt.Target == "DATABASES" || dbStmt.Target == "DATABASE" {
-			return e.handleInspectDatabase(dbStmt)
+			result, err = e.handleInspectDatabase(dbStmt)
+			return stmt, result, true, err
 		}
 		if dbStmt.Target == "USERS" {
-			return e.handleInspectUsers()
+			result, err = e.handleInspectUsers()
+			return stmt, result, true, err
 		}
 		// Handle user-related inspection using system database
 		if dbStmt.Target == "USER" || dbStmt.Target == "USER_ROLES" || dbStmt.Target == "USER_PRIVILEGES" {
-			return e.handleInspectUserInfo(dbStmt)
+			result, err = e.handleInspectUserInfo(dbStmt)
+			return stmt, result, true, err
 		}
 		// Handle role-related inspection using system database
 		if dbStmt.Target == "ROLES" || dbStmt.Target == "ROLE" || dbStmt.Target == "PRIVILEGES" {
-			return e.handleInspectRoleInfo(dbStmt)
+			result, err = e.handleInspectRoleInfo(dbStmt)
+			return stmt, result, true, err
 		}
 	case *sql.CreateDatabaseStmt:
-		return e.handleCreateDatabase(dbStmt)
+		result, err = e.handleCreateDatabase(dbStmt)
+		return stmt, result, true, err
 	case *sql.DropDatabaseStmt:
-		return e.handleDropDatabase(dbStmt)
+		result, err = e.handleDropDatabase(dbStmt)
+		return stmt, result, true, err
 	case *sql.UseDatabaseStmt:
-		return e.handleUseDatabase(dbStmt)
+		result, err = e.handleUseDatabase(dbStmt)
+		return stmt, result, true, err
 	}
-
-	// Get the executor for the specified database
-	executor := e.getExecutorForDatabase(database)
-
-	// Thread-safe: sql.Executor.Execute now handles user context if modified,
-	// but wait, sql.Executor.Execute doesn't take a user parameter yet.
-	// We need to set it on a cloned or thread-safe way.
-	// For now, let's use a mutex or a new approach.
-	// Actually, the best way is to pass user to executor.Execute.
-	return executor.ExecuteWithUser(stmt, user)
+	return stmt, "", false, nil
 }
 
 // getExecutorForDatabase returns the executor for the specified database.
@@ -456,10 +692,7 @@ func (e *serverQueryExecutor) getExecutorForDatabase(database string) *sql.Execu
 		return e.srv.executor
 	}
 
-	// Create an executor for this database using the global auth manager
-	// The auth manager is backed by the system database for global user management
-	executor := sql.NewExecutor(db.Store, e.srv.auth)
-	return executor
+	return e.srv.newDatabaseExecutor(db)
 }
 
 // handleInspectDatabase handles INSPECT DATABASES and INSPECT DATABASE <name>
@@ -738,6 +971,26 @@ func (e *serverQueryExecutor) handleUseDatabase(stmt *sql.UseDatabaseStmt) (stri
 	return fmt.Sprintf("USE %s OK", stmt.DatabaseName), nil
 }
 
+// serverPreparedStatements adapts the prepared statement manager for the
+// ContextPreparedStatementManager interface.
+type serverPreparedStatements struct {
+	*sql.PreparedStatementManager
+	srv *Server
+}
+
+// ExecuteContext runs a prepared statement like a query of the connection.
+func (p *serverPreparedStatements) ExecuteContext(ctx context.Context, name string, params []interface{}, database, user string) (string, error) {
+	query, err := p.Bind(name, params)
+	if err != nil {
+		return "", err
+	}
+	result, err := (&serverQueryExecutor{srv: p.srv}).ExecuteResultContext(ctx, query, database, user)
+	if err != nil {
+		return "", err
+	}
+	return result.Message, nil
+}
+
 // serverAuthenticator adapts the auth manager for the Authenticator interface.
 type serverAuthenticator struct {
 	auth *auth.AuthManager
@@ -750,6 +1003,28 @@ func (a *serverAuthenticator) Authenticate(username, password string) bool {
 	return a.auth.Authenticate(username, password)
 }
 
+// SCRAMVerifier returns the SCRAM-SHA-256 verifier of a user for
+// PostgreSQL clients.
+func (a *serverAuthenticator) SCRAMVerifier(username string) (string, bool) {
+	return a.auth.SCRAMVerifier(username)
+}
+
+// AuthenticateToken returns the user an API token of an HTTP client
+// authenticates as.
+func (a *serverAuthenticator) AuthenticateToken(token string) (string, bool) {
+	return a.auth.AuthenticateToken(token)
+}
+
+// CreateToken issues an API token for HTTP clients.
+func (a *serverAuthenticator) CreateToken(username string, ttl time.Duration) (string, error) {
+	return a.auth.CreateToken(username, ttl)
+}
+
+// RevokeToken revokes an API token.
+func (a *serverAuthenticator) RevokeToken(token string) error {
+	return a.auth.RevokeToken(token)
+}
+
 // NewServer creates a new Server and initializes a new storage engine at dataDir.
 // This is a convenience constructor for standalone server usage.
 //
@@ -791,6 +1066,64 @@ type TLSConfig struct {
 	ClientCAs string
 }
 
+// SetAutoAnalyzeFraction sets the fraction of a table's rows that must change
+// before its statistics are refreshed automatically (0 disables it).
+// Call this before Start().
+func (s *Server) SetAutoAnalyzeFraction(fraction float64) {
+	s.autoAnalyzeFraction = fraction
+	s.executor.SetAutoAnalyzeFraction(fraction)
+}
+
+// SetReadBarrier enforces the read_consistency session option of binary
+// protocol clients through rb. Without one, every read is served locally.
+func (s *Server) SetReadBarrier(rb protocol.ReadBarrier) {
+	s.binaryHandler.SetReadBarrier(rb)
+}
+
+// SetClusterAdmin enables ALTER CLUSTER, which changes the Raft cluster
+// membership through admin. Call this before Start().
+func (s *Server) SetClusterAdmin(admin sql.ClusterAdmin) {
+	s.clusterAdmin = admin
+	s.executor.SetClusterAdmin(admin)
+}
+
+// SetClusterInspector enables INSPECT CLUSTER, which shows the rebalance
+// plan of the cluster balancer. Call this before Start().
+func (s *Server) SetClusterInspector(inspector sql.ClusterInspector) {
+	s.clusterInspector = inspector
+	s.executor.SetClusterInspector(inspector)
+}
+
+// SetClusterRepairer enables REPAIR PARTITION, which repairs the replicas
+// of a partition through repairer. Call this before Start().
+func (s *Server) SetClusterRepairer(repairer sql.ClusterRepairer) {
+	s.clusterRepairer = repairer
+	s.executor.SetClusterRepairer(repairer)
+}
+
+// SetDistributedScanner pushes SELECTs down to the partition owners of a
+// cluster through scanner. Call this before Start().
+func (s *Server) SetDistributedScanner(scanner sql.DistributedScanner) {
+	s.distributed = scanner
+	s.executor.SetDistributedScanner(scanner, storage.DefaultDatabaseName)
+}
+
+// SetTransactionCoordinator commits binary protocol transactions through
+// coordinator. Call this before Start().
+func (s *Server) SetTransactionCoordinator(coordinator TransactionCoordinator) {
+	s.txns.coordinator = coordinator
+}
+
+// ExecuteFragment evaluates a query fragment pushed down by another node
+// with the executor of the database it reads from.
+func (s *Server) ExecuteFragment(fragment []byte, owns func(key string) bool) ([]byte, error) {
+	database, err := sql.FragmentDatabase(fragment)
+	if err != nil {
+		return nil, err
+	}
+	return s.getExecutorForDatabase(database).ExecuteFragment(fragment, owns)
+}
+
 // EnableTLS configures TLS for the server.
 // Call this before Start() to enable encrypted connections.
 func (s *Server) EnableTLS(config TLSConfig) error {
@@ -854,6 +1187,106 @@ func (s *Server) startTLSListener(ready chan<- struct{}) {
 	}
 }
 
+// SetPostgresAddr enables the PostgreSQL wire protocol listener on addr.
+// Call this before Start(). If TLS is enabled, PostgreSQL clients must
+// negotiate SSL.
+func (s *Server) SetPostgresAddr(addr string) {
+	s.pgAddr = addr
+}
+
+// startPostgresListener starts the PostgreSQL wire protocol listener if it
+// is enabled. The accept loop runs in its own goroutine.
+func (s *Server) startPostgresListener() error {
+	if s.pgAddr == "" {
+		return nil
+	}
+	if s.tlsConfig != nil {
+		s.pgHandler.SetTLSConfig(s.tlsConfig)
+	}
+
+	ln, err := net.Listen("tcp", s.pgAddr)
+	if err != nil {
+		log.Error("Failed to start PostgreSQL listener", "address", s.pgAddr, "error", err)
+		return err
+	}
+
+	// Track the listener for graceful shutdown
+	s.listenersMu.Lock()
+	s.listeners = append(s.listeners, ln)
+	s.listenersMu.Unlock()
+
+	log.Info("PostgreSQL protocol listening", "address", s.pgAddr)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				select {
+				case <-s.stopCh:
+					return
+				default:
+				}
+				log.Warn("PostgreSQL accept error", "error", err)
+				continue
+			}
+			log.Debug("New PostgreSQL connection accepted", "remote_addr", conn.RemoteAddr().String())
+			go s.pgHandler.HandleConnection(conn)
+		}
+	}()
+	return nil
+}
+
+// SetHTTPAddr enables the HTTP/JSON query API on addr. Call this before
+// Start(). If requireAuth is false, requests without credentials run as the
+// admin user, which is only safe on trusted networks. If TLS is enabled,
+// the API is served over HTTPS.
+func (s *Server) SetHTTPAddr(addr string, requireAuth bool) {
+	s.httpAddr = addr
+	if requireAuth {
+		s.httpHandler.SetAnonymousUser("")
+	} else {
+		s.httpHandler.SetAnonymousUser(auth.AdminUsername)
+	}
+}
+
+// startHTTPListener starts the HTTP/JSON query API if it is enabled. The
+// HTTP server runs in its own goroutine.
+func (s *Server) startHTTPListener() error {
+	if s.httpAddr == "" {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", s.httpAddr)
+	if err != nil {
+		log.Error("Failed to start HTTP API listener", "address", s.httpAddr, "error", err)
+		return err
+	}
+	if s.tlsConfig != nil {
+		ln = tls.NewListener(ln, s.tlsConfig)
+	}
+
+	// Track the listener for graceful shutdown
+	s.listenersMu.Lock()
+	s.listeners = append(s.listeners, ln)
+	s.listenersMu.Unlock()
+
+	log.Info("HTTP API listening", "address", s.httpAddr, "tls", s.tlsConfig != nil)
+
+	httpServer := &http.Server{
+		Handler:           s.httpHandler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		err := httpServer.Serve(ln)
+		select {
+		case <-s.stopCh:
+		default:
+			log.Error("HTTP API server error", "error", err)
+		}
+	}()
+	return nil
+}
+
 // Start begins listening for TCP connections using the binary protocol.
 // This method blocks indefinitely, accepting and handling client connections.
 // Each connection is handled in a separate goroutine for concurrency.
@@ -863,6 +1296,18 @@ func (s *Server) startTLSListener(ready chan<- struct{}) {
 //
 // Returns an error only if the initial Listen call fails.
 func (s *Server) Start() error {
+	// Start the background reaper for tables with a TTL
+	if s.ttlReaperInterval > 0 {
+		go s.runTTLReaper()
+	}
+
+	if err := s.startPostgresListener(); err != nil {
+		return err
+	}
+	if err := s.startHTTPListener(); err != nil {
+		return err
+	}
+
 	// If TLS is configured, use TLS listener exclusively
 	if s.tlsConfig != nil && s.tlsAddr != "" {
 		// Use a channel to signal when TLS listener is ready
@@ -939,6 +1384,9 @@ func (s *Server) Stop() error {
 		}
 	}
 
+	// Roll back the transactions of open HTTP sessions
+	s.httpHandler.Close()
+
 	log.Info("Server stopped")
 	return lastErr
 }
@@ -1059,7 +1507,14 @@ func (s *Server) getExecutorForConnection(conn net.Conn) *sql.Executor {
 	dbName := s.connDatabases[conn]
 	s.connDbMu.Unlock()
 
-	if dbName == "" || dbName == storage.DefaultDatabaseName {
+	return s.getExecutorForDatabase(dbName)
+}
+
+// getExecutorForDatabase returns the executor for the named database.
+// If the database is empty, the default or not found, it returns the
+// default executor.
+func (s *Server) getExecutorForDatabase(dbName string) *sql.Executor {
+	if s.dbManager == nil || dbName == "" || dbName == storage.DefaultDatabaseName {
 		return s.executor
 	}
 
@@ -1069,9 +1524,20 @@ func (s *Server) getExecutorForConnection(conn net.Conn) *sql.Executor {
 		return s.executor
 	}
 
+	return s.newDatabaseExecutor(db)
+}
+
+// newDatabaseExecutor creates an executor for a non-default database,
+// configured like the default executor.
+func (s *Server) newDatabaseExecutor(db *storage.Database) *sql.Executor {
 	// Create an executor for this database using the global auth manager
 	// The auth manager is backed by the system database for global user management
 	exec := sql.NewExecutor(db.Store, s.auth)
+	exec.SetAutoAnalyzeFraction(s.autoAnalyzeFraction)
+	exec.SetClusterAdmin(s.clusterAdmin)
+	exec.SetClusterInspector(s.clusterInspector)
+	exec.SetClusterRepairer(s.clusterRepairer)
+	exec.SetDistributedScanner(s.distributed, db.Name)
 
 	// Set collation and encoding from database metadata
 	if db.Metadata != nil {
@@ -21,6 +21,7 @@ import (
 	"net"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -309,6 +310,9 @@ func TestServerSQL(t *testing.T) {
 		errMsg, _ := protocol.DecodeErrorMessage(msg.Payload)
 		t.Fatalf("INSERT failed: %s", errMsg.Message)
 	}
+	if inserted, err := protocol.DecodeQueryResultMessage(msg.Payload); err != nil || inserted.RowsAffected != 1 {
+		t.Errorf("Expected 1 affected row, got %+v, %v", inserted, err)
+	}
 
 	// Select the row
 	queryMsg = &protocol.QueryMessage{Query: "SELECT name FROM users WHERE id = 1"}
@@ -349,5 +353,292 @@ func TestServerSQL(t *testing.T) {
 	if !strings.Contains(result.Message, "Alice") {
 		t.Errorf("Expected 'Alice' in response, got '%s'", result.Message)
 	}
+
+	// The result also carries typed columns and rows
+	if len(result.Columns) != 1 || result.Columns[0] != "name" || len(result.ColumnTypes) != 1 || result.ColumnTypes[0] != "TEXT" {
+		t.Errorf("Expected column name TEXT, got %v %v", result.Columns, result.ColumnTypes)
+	}
+	if result.RowCount != 1 || len(result.Rows) != 1 || result.Rows[0][0] != "Alice" {
+		t.Errorf("Expected row [Alice], got %v", result.Rows)
+	}
 }
 
+
+func TestServerBinaryRows(t *testing.T) {
+	srv, addr, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	go srv.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// Ask for binary results by authenticating with protocol version 2
+	payload, _ := (&protocol.AuthMessage{Username: "admin", Password: testAdminPassword}).Encode()
+	if err := protocol.WriteMessageVersion(conn, protocol.ProtocolVersionBinary, protocol.MsgAuth, payload); err != nil {
+		t.Fatalf("Failed to send AUTH: %v", err)
+	}
+	msg, err := readBinaryMessage(conn)
+	if err != nil {
+		t.Fatalf("Failed to read auth response: %v", err)
+	}
+	if msg.Header.Version != protocol.ProtocolVersionBinary {
+		t.Fatalf("Expected protocol version %d, got %d", protocol.ProtocolVersionBinary, msg.Header.Version)
+	}
+
+	query := func(sql string) *protocol.QueryResultMessage {
+		t.Helper()
+		payload, _ := (&protocol.QueryMessage{Query: sql}).Encode()
+		if err := sendBinaryMessage(conn, protocol.MsgQuery, payload); err != nil {
+			t.Fatalf("Failed to send %q: %v", sql, err)
+		}
+		msg, err := readBinaryMessage(conn)
+		if err != nil {
+			t.Fatalf("Failed to read %q response: %v", sql, err)
+		}
+		if msg.Header.Type == protocol.MsgError {
+			errMsg, _ := protocol.DecodeErrorMessage(msg.Payload)
+			t.Fatalf("%q failed: %s", sql, errMsg.Message)
+		}
+		result, err := protocol.DecodeQueryResult(msg)
+		if err != nil {
+			t.Fatalf("Failed to decode %q result: %v", sql, err)
+		}
+		return result
+	}
+
+	query("CREATE TABLE items (id INT, name TEXT, price FLOAT)")
+	query("INSERT INTO items VALUES (1, 'pen', 1.5)")
+	query("INSERT INTO items (id, name) VALUES (2, 'cap')")
+
+	result := query("SELECT id, name, price FROM items ORDER BY id")
+	want := [][]interface{}{{int64(1), "pen", 1.5}, {int64(2), "cap", nil}}
+	if result.RowCount != 2 || fmt.Sprint(result.Rows) != fmt.Sprint(want) {
+		t.Errorf("Expected rows %v, got %v", want, result.Rows)
+	}
+	if _, ok := result.Rows[0][0].(int64); !ok {
+		t.Errorf("Expected int64 values from binary rows, got %T", result.Rows[0][0])
+	}
+}
+
+func TestServerCompression(t *testing.T) {
+	srv, addr, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	go srv.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	payload, _ := (&protocol.AuthMessage{Username: "admin", Password: testAdminPassword, Compression: []string{"zstd"}}).Encode()
+	if err := sendBinaryMessage(conn, protocol.MsgAuth, payload); err != nil {
+		t.Fatalf("Failed to send AUTH: %v", err)
+	}
+	msg, err := readBinaryMessage(conn)
+	if err != nil {
+		t.Fatalf("Failed to read auth response: %v", err)
+	}
+	authResult, _ := protocol.DecodeAuthResultMessage(msg.Payload)
+	if authResult == nil || !authResult.Success || authResult.Compression != "zstd" {
+		t.Fatalf("Expected zstd compression, got %+v", authResult)
+	}
+
+	// From here on both directions compress large payloads
+	comp := protocol.NewCompression(protocol.NegotiateCompression([]string{authResult.Compression}))
+	writer, frames := protocol.NewCompressedWriter(conn)
+	frames.SetCompression(comp)
+	query := func(sql string) (*protocol.QueryResultMessage, protocol.MessageFlag) {
+		t.Helper()
+		payload, _ := (&protocol.QueryMessage{Query: sql}).Encode()
+		protocol.WriteMessage(writer, protocol.MsgQuery, payload)
+		if err := writer.Flush(); err != nil {
+			t.Fatalf("Failed to send query: %v", err)
+		}
+		msg, err := readBinaryMessage(conn)
+		if err != nil {
+			t.Fatalf("Failed to read %q response: %v", sql, err)
+		}
+		flags := msg.Header.Flags
+		if msg.Payload, err = comp.DecompressPayload(flags, msg.Payload); err != nil {
+			t.Fatalf("Failed to decompress %q response: %v", sql, err)
+		}
+		if msg.Header.Type == protocol.MsgError {
+			errMsg, _ := protocol.DecodeErrorMessage(msg.Payload)
+			t.Fatalf("%q failed: %s", sql, errMsg.Message)
+		}
+		result, err := protocol.DecodeQueryResultMessage(msg.Payload)
+		if err != nil {
+			t.Fatalf("Failed to decode %q result: %v", sql, err)
+		}
+		return result, flags
+	}
+
+	query("CREATE TABLE notes (id INT, body TEXT)")
+	body := strings.Repeat("compressible ", 100)
+	if _, flags := query(fmt.Sprintf("INSERT INTO notes VALUES (1, '%s')", body)); flags&protocol.FlagCompressed != 0 {
+		t.Error("Small INSERT result was compressed")
+	}
+	result, flags := query("SELECT body FROM notes")
+	if flags&protocol.FlagCompressed == 0 {
+		t.Error("Large SELECT result was not compressed")
+	}
+	if len(result.Rows) != 1 || result.Rows[0][0] != body {
+		t.Errorf("Unexpected rows %v", result.Rows)
+	}
+}
+
+func TestServerStatementTimeout(t *testing.T) {
+	srv, addr, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	go srv.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	payload, _ := (&protocol.AuthMessage{Username: "admin", Password: testAdminPassword}).Encode()
+	sendBinaryMessage(conn, protocol.MsgAuth, payload)
+	msg, err := readBinaryMessage(conn)
+	if err != nil {
+		t.Fatalf("Failed to read auth response: %v", err)
+	}
+	authResult, _ := protocol.DecodeAuthResultMessage(msg.Payload)
+	if authResult == nil || !authResult.Success || authResult.CancelKey == "" {
+		t.Fatalf("Expected a cancel key, got %+v", authResult)
+	}
+
+	setTimeout := func(value interface{}) {
+		t.Helper()
+		payload, _ := (&protocol.SetOptionMessage{Option: "statement_timeout", Value: value}).Encode()
+		sendBinaryMessage(conn, protocol.MsgSetOption, payload)
+		if msg, err := readBinaryMessage(conn); err != nil || msg.Header.Type != protocol.MsgSessionResult {
+			t.Fatalf("Failed to set statement_timeout: %+v, %v", msg, err)
+		}
+	}
+	query := func(sql string) *protocol.Message {
+		t.Helper()
+		payload, _ := (&protocol.QueryMessage{Query: sql}).Encode()
+		sendBinaryMessage(conn, protocol.MsgQuery, payload)
+		msg, err := readBinaryMessage(conn)
+		if err != nil {
+			t.Fatalf("Failed to read %q response: %v", sql, err)
+		}
+		return msg
+	}
+
+	query("CREATE TABLE items (id INT)")
+	query("INSERT INTO items VALUES (1)")
+
+	// A timeout that has expired before the statement starts cancels it
+	setTimeout("1ns")
+	msg = query("SELECT id FROM items")
+	errMsg, _ := protocol.DecodeErrorMessage(msg.Payload)
+	if msg.Header.Type != protocol.MsgError || !strings.Contains(errMsg.Message, "statement timeout") {
+		t.Fatalf("Expected a statement timeout, got %s", msg.Payload)
+	}
+
+	setTimeout(float64(0))
+	if msg = query("SELECT id FROM items"); msg.Header.Type != protocol.MsgQueryResult {
+		t.Fatalf("Query failed without a timeout: %s", msg.Payload)
+	}
+
+	// A cancel request is accepted on a connection that did not authenticate
+	cancelConn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cancelConn.Close()
+	cancelConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	payload, _ = (&protocol.CancelMessage{CancelKey: authResult.CancelKey}).Encode()
+	sendBinaryMessage(cancelConn, protocol.MsgCancel, payload)
+	if msg, err := readBinaryMessage(cancelConn); err != nil || msg.Header.Type != protocol.MsgSessionResult {
+		t.Fatalf("Unexpected cancel response %+v, %v", msg, err)
+	}
+}
+
+func TestServerMultiplexing(t *testing.T) {
+	srv, addr, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	go srv.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	mc, err := protocol.Multiplex(conn)
+	if err != nil {
+		t.Fatalf("Failed to negotiate multiplexing: %v", err)
+	}
+	defer mc.Close()
+
+	open := func() *testClient {
+		t.Helper()
+		stream, err := mc.OpenStream()
+		if err != nil {
+			t.Fatalf("Failed to open a stream: %v", err)
+		}
+		return newTestClient(t, stream)
+	}
+
+	// Each stream is a session with its own transaction
+	a, b := open(), open()
+	a.query("CREATE TABLE items (id INT)")
+	a.ok(protocol.MsgBeginTx, &protocol.BeginTxMessage{IsolationLevel: 1})
+	a.query("INSERT INTO items VALUES (1)")
+	if n := b.count("items"); n != 0 {
+		t.Errorf("Other stream sees %d uncommitted rows", n)
+	}
+	a.ok(protocol.MsgCommitTx, emptyPayload{})
+	if n := b.count("items"); n != 1 {
+		t.Errorf("Other stream sees %d rows after commit, want 1", n)
+	}
+	a.fails(protocol.MsgMultiplex, &protocol.MultiplexMessage{}, "first message")
+
+	// Streams run their statements concurrently
+	clients := make([]*testClient, 3)
+	for i := range clients {
+		clients[i] = open()
+	}
+	var wg sync.WaitGroup
+	for i, c := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			payload, _ := (&protocol.QueryMessage{Query: fmt.Sprintf("INSERT INTO items VALUES (%d)", i+2)}).Encode()
+			sendBinaryMessage(c.conn, protocol.MsgQuery, payload)
+			if msg, err := readBinaryMessage(c.conn); err != nil || msg.Header.Type != protocol.MsgQueryResult {
+				t.Errorf("INSERT on stream %d failed: %+v, %v", i, msg, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := a.count("items"); n != 1+len(clients) {
+		t.Errorf("Table has %d rows, want %d", n, 1+len(clients))
+	}
+
+	// Closing a stream ends its session and rolls its transaction back
+	b.ok(protocol.MsgBeginTx, &protocol.BeginTxMessage{IsolationLevel: 1})
+	b.query("DELETE FROM items")
+	b.conn.Close()
+	time.Sleep(100 * time.Millisecond)
+	if n := a.count("items"); n != 1+len(clients) {
+		t.Errorf("Table has %d rows after the stream closed, want %d", n, 1+len(clients))
+	}
+}
@@ -0,0 +1,238 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Transaction Manager Implementation
+==================================
+
+This file implements the TransactionManager interface for the binary
+protocol. Each transaction is a storage.Transaction on the store of the
+database the connection was using when it began. The handler tags the
+context of every statement the connection runs with the transaction ID, and
+the query executor runs those statements through an executor whose reads
+and writes go through the transaction.
+
+In cluster mode COMMIT goes through the cluster's transaction coordinator,
+which applies the writes atomically on every node that holds them.
+
+Inside a transaction only queries, INSERT, UPDATE, DELETE and savepoints
+are allowed: schema changes and database switches are not transactional, so
+they must run outside one.
+*/
+package server
+
+import (
+	stderrors "errors"
+	"fmt"
+	"sync"
+
+	"flydb/internal/errors"
+	"flydb/internal/sql"
+	"flydb/internal/storage"
+)
+
+// serverTransaction is an open protocol transaction.
+type serverTransaction struct {
+	tx       *storage.Transaction
+	database string
+}
+
+// TransactionCoordinator commits the writes of a transaction atomically
+// across a cluster.
+type TransactionCoordinator interface {
+	// CommitTransaction applies ops to database, in order, all or none.
+	CommitTransaction(database string, ops []storage.TxOperation) error
+}
+
+// serverTransactionManager implements the protocol.TransactionManager interface.
+type serverTransactionManager struct {
+	srv         *Server
+	coordinator TransactionCoordinator // Commits transactions; nil writes them to the store
+
+	mu  sync.Mutex
+	txs map[string]*serverTransaction
+}
+
+// newServerTransactionManager creates a transaction manager.
+func newServerTransactionManager(srv *Server) *serverTransactionManager {
+	return &serverTransactionManager{
+		srv: srv,
+		txs: make(map[string]*serverTransaction),
+	}
+}
+
+// Begin starts a transaction on database.
+func (m *serverTransactionManager) Begin(database string, isolationLevel int, readOnly bool) (string, error) {
+	isolation := storage.IsolationLevel(isolationLevel)
+	if isolation < storage.IsolationReadUncommitted || isolation > storage.IsolationSerializable {
+		return "", errors.NewTransactionError(fmt.Sprintf("unknown isolation level %d", isolationLevel))
+	}
+
+	store := m.srv.store
+	if m.srv.dbManager != nil && database != "" && database != storage.DefaultDatabaseName {
+		db, err := m.srv.dbManager.GetDatabase(database)
+		if err != nil {
+			return "", err
+		}
+		store = db.Store
+	}
+
+	id, err := newRandomID("tx_")
+	if err != nil {
+		return "", err
+	}
+
+	opts := storage.TxOptions{Isolation: isolation, ReadOnly: readOnly}
+	if m.coordinator != nil {
+		name := database
+		if name == "" {
+			name = storage.DefaultDatabaseName
+		}
+		opts.Commit = func(ops []storage.TxOperation) error {
+			return m.coordinator.CommitTransaction(name, ops)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.txs[id] = &serverTransaction{
+		tx:       storage.NewTransactionWithOptions(store, opts),
+		database: database,
+	}
+	return id, nil
+}
+
+// Commit commits a transaction.
+func (m *serverTransactionManager) Commit(txID string) error {
+	t, err := m.remove(txID)
+	if err != nil {
+		return err
+	}
+	if err := t.tx.Commit(); err != nil {
+		return transactionError(err)
+	}
+	// Cached results were computed without the committed writes
+	m.srv.getExecutorForDatabase(t.database).InvalidateAllCache()
+	return nil
+}
+
+// Rollback rolls a transaction back.
+func (m *serverTransactionManager) Rollback(txID string) error {
+	t, err := m.remove(txID)
+	if err != nil {
+		return err
+	}
+	return transactionError(t.tx.Rollback())
+}
+
+// CreateSavepoint creates a savepoint in a transaction.
+func (m *serverTransactionManager) CreateSavepoint(txID, name string) error {
+	t, err := m.get(txID)
+	if err != nil {
+		return err
+	}
+	return transactionError(t.tx.CreateSavepoint(name))
+}
+
+// ReleaseSavepoint releases a savepoint of a transaction.
+func (m *serverTransactionManager) ReleaseSavepoint(txID, name string) error {
+	t, err := m.get(txID)
+	if err != nil {
+		return err
+	}
+	return transactionError(t.tx.ReleaseSavepoint(name))
+}
+
+// RollbackToSavepoint discards the writes made since a savepoint.
+func (m *serverTransactionManager) RollbackToSavepoint(txID, name string) error {
+	t, err := m.get(txID)
+	if err != nil {
+		return err
+	}
+	return transactionError(t.tx.RollbackToSavepoint(name))
+}
+
+// executor returns the executor for a statement of a transaction run in
+// database.
+func (m *serverTransactionManager) executor(txID, database string, stmt sql.Statement) (*sql.Executor, error) {
+	t, err := m.get(txID)
+	if err != nil {
+		return nil, err
+	}
+	if database != t.database {
+		return nil, errors.NewTransactionError("a transaction cannot span databases").
+			WithDetail(fmt.Sprintf("transaction began in %q", t.database))
+	}
+	switch s := stmt.(type) {
+	case *sql.SelectStmt, *sql.UnionStmt, *sql.IntersectStmt, *sql.ExceptStmt, *sql.InspectStmt,
+		*sql.SavepointStmt, *sql.ReleaseSavepointStmt:
+	case *sql.InsertStmt, *sql.UpdateStmt, *sql.DeleteStmt:
+		if t.tx.ReadOnly() {
+			return nil, errors.TransactionReadOnly()
+		}
+	case *sql.RollbackStmt:
+		if s.ToSavepoint == "" {
+			return nil, statementNotInTransaction()
+		}
+	default:
+		return nil, statementNotInTransaction()
+	}
+	return m.srv.getExecutorForDatabase(database).WithTransaction(t.tx), nil
+}
+
+// get returns an open transaction.
+func (m *serverTransactionManager) get(txID string) (*serverTransaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.txs[txID]
+	if !ok {
+		return nil, errors.TransactionNotActive()
+	}
+	return t, nil
+}
+
+// remove returns an open transaction and forgets it.
+func (m *serverTransactionManager) remove(txID string) (*serverTransaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.txs[txID]
+	if !ok {
+		return nil, errors.TransactionNotActive()
+	}
+	delete(m.txs, txID)
+	return t, nil
+}
+
+// statementNotInTransaction returns the error for a statement that cannot
+// run inside a transaction.
+func statementNotInTransaction() error {
+	return errors.NewTransactionError("statement cannot run inside a transaction").
+		WithHint("Only queries, INSERT, UPDATE, DELETE and savepoints run in a transaction; COMMIT or ROLLBACK first")
+}
+
+// transactionError converts the errors of a storage transaction to FlyDB
+// errors and returns other errors unchanged.
+func transactionError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case stderrors.Is(err, storage.ErrReadOnlyTransaction):
+		return errors.TransactionReadOnly()
+	case stderrors.Is(err, storage.ErrSerializationFailure):
+		return errors.TransactionSerializationFailure()
+	}
+	return err
+}
@@ -0,0 +1,269 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"flydb/internal/protocol"
+	"flydb/internal/storage"
+)
+
+// emptyPayload is the payload of messages that carry none.
+type emptyPayload struct{}
+
+// Encode returns an empty payload.
+func (emptyPayload) Encode() ([]byte, error) { return nil, nil }
+
+// testClient is an authenticated binary protocol connection.
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+}
+
+// dialTestClient connects to addr and authenticates as admin.
+func dialTestClient(t *testing.T, addr string) *testClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	return newTestClient(t, conn)
+}
+
+// newTestClient authenticates as admin on conn.
+func newTestClient(t *testing.T, conn net.Conn) *testClient {
+	t.Helper()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	c := &testClient{t: t, conn: conn}
+	if msg := c.send(protocol.MsgAuth, &protocol.AuthMessage{Username: "admin", Password: testAdminPassword}); msg.Header.Type != protocol.MsgAuthResult {
+		t.Fatalf("Authentication failed: %s", msg.Payload)
+	}
+	return c
+}
+
+// send sends a message and returns the reply.
+func (c *testClient) send(msgType protocol.MessageType, m interface{ Encode() ([]byte, error) }) *protocol.Message {
+	c.t.Helper()
+	payload, _ := m.Encode()
+	sendBinaryMessage(c.conn, msgType, payload)
+	msg, err := readBinaryMessage(c.conn)
+	if err != nil {
+		c.t.Fatalf("Failed to read response: %v", err)
+	}
+	return msg
+}
+
+// ok sends a message and fails the test if the reply is an error.
+func (c *testClient) ok(msgType protocol.MessageType, m interface{ Encode() ([]byte, error) }) {
+	c.t.Helper()
+	if msg := c.send(msgType, m); msg.Header.Type == protocol.MsgError {
+		c.t.Fatalf("Unexpected error: %s", msg.Payload)
+	}
+}
+
+// fails sends a message and fails the test unless the reply is an error
+// containing want.
+func (c *testClient) fails(msgType protocol.MessageType, m interface{ Encode() ([]byte, error) }, want string) {
+	c.t.Helper()
+	msg := c.send(msgType, m)
+	if msg.Header.Type != protocol.MsgError || !strings.Contains(string(msg.Payload), want) {
+		c.t.Fatalf("Expected an error containing %q, got %s", want, msg.Payload)
+	}
+}
+
+// query runs sql and fails the test on an error.
+func (c *testClient) query(sql string) {
+	c.t.Helper()
+	c.ok(protocol.MsgQuery, &protocol.QueryMessage{Query: sql})
+}
+
+// count returns the number of rows in table.
+func (c *testClient) count(table string) int {
+	c.t.Helper()
+	msg := c.send(protocol.MsgQuery, &protocol.QueryMessage{Query: "SELECT id FROM " + table})
+	result, err := protocol.DecodeQueryResultMessage(msg.Payload)
+	if msg.Header.Type != protocol.MsgQueryResult || err != nil {
+		c.t.Fatalf("SELECT failed: %s", msg.Payload)
+	}
+	return result.RowCount
+}
+
+func TestServerTransactions(t *testing.T) {
+	srv, addr, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	go srv.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	a := dialTestClient(t, addr)
+	defer a.conn.Close()
+	b := dialTestClient(t, addr)
+	defer b.conn.Close()
+
+	a.query("CREATE TABLE items (id INT)")
+
+	// Writes are only visible to other connections once committed
+	a.ok(protocol.MsgBeginTx, &protocol.BeginTxMessage{IsolationLevel: 1})
+	a.query("INSERT INTO items VALUES (1)")
+	if n := a.count("items"); n != 1 {
+		t.Errorf("Transaction sees %d rows, want 1", n)
+	}
+	if n := b.count("items"); n != 0 {
+		t.Errorf("Other connection sees %d uncommitted rows", n)
+	}
+	a.fails(protocol.MsgBeginTx, &protocol.BeginTxMessage{}, "already active")
+	a.fails(protocol.MsgQuery, &protocol.QueryMessage{Query: "CREATE TABLE other (id INT)"}, "cannot run inside a transaction")
+	a.ok(protocol.MsgCommitTx, emptyPayload{})
+	if n := b.count("items"); n != 1 {
+		t.Errorf("Other connection sees %d rows after commit, want 1", n)
+	}
+
+	// Rollback discards the writes
+	a.ok(protocol.MsgBeginTx, &protocol.BeginTxMessage{IsolationLevel: 1})
+	a.query("DELETE FROM items WHERE id = 1")
+	a.ok(protocol.MsgRollbackTx, emptyPayload{})
+	if n := b.count("items"); n != 1 {
+		t.Errorf("Rolled back delete left %d rows, want 1", n)
+	}
+
+	// Rolling back to a savepoint discards only the later writes
+	a.ok(protocol.MsgBeginTx, &protocol.BeginTxMessage{IsolationLevel: 1})
+	a.query("INSERT INTO items VALUES (2)")
+	a.ok(protocol.MsgSavepoint, &protocol.SavepointMessage{Name: "sp"})
+	a.query("INSERT INTO items VALUES (3)")
+	a.ok(protocol.MsgSavepoint, &protocol.SavepointMessage{Name: "sp", Rollback: true})
+	a.ok(protocol.MsgCommitTx, emptyPayload{})
+	if n := b.count("items"); n != 2 {
+		t.Errorf("Savepoint rollback left %d rows, want 2", n)
+	}
+
+	// Read-only transactions reject writes
+	a.ok(protocol.MsgBeginTx, &protocol.BeginTxMessage{IsolationLevel: 1, ReadOnly: true})
+	a.fails(protocol.MsgQuery, &protocol.QueryMessage{Query: "INSERT INTO items VALUES (4)"}, "read-only")
+	a.ok(protocol.MsgRollbackTx, emptyPayload{})
+
+	// With auto_commit off statements run in a transaction that turning it
+	// back on commits
+	a.ok(protocol.MsgSetOption, &protocol.SetOptionMessage{Option: "auto_commit", Value: false})
+	a.query("INSERT INTO items VALUES (5)")
+	if n := b.count("items"); n != 2 {
+		t.Errorf("Other connection sees %d rows before commit, want 2", n)
+	}
+	a.ok(protocol.MsgSetOption, &protocol.SetOptionMessage{Option: "auto_commit", Value: true})
+	if n := b.count("items"); n != 3 {
+		t.Errorf("Other connection sees %d rows after commit, want 3", n)
+	}
+
+	// Prepared statements run in the transaction
+	a.ok(protocol.MsgPrepare, &protocol.PrepareMessage{Name: "add", Query: "INSERT INTO items VALUES ($1)"})
+	a.ok(protocol.MsgBeginTx, &protocol.BeginTxMessage{IsolationLevel: 1})
+	a.ok(protocol.MsgExecute, &protocol.ExecuteMessage{Name: "add", Params: []interface{}{float64(6)}})
+	a.ok(protocol.MsgRollbackTx, emptyPayload{})
+	if n := b.count("items"); n != 3 {
+		t.Errorf("Rolled back prepared insert left %d rows, want 3", n)
+	}
+
+	// Indexes only change when a transaction commits, so other
+	// connections keep finding committed rows through them and never find
+	// uncommitted ones, while the transaction sees its own writes
+	a.query("CREATE TABLE named (id INT, name TEXT)")
+	a.query("CREATE INDEX idx_named_name ON named (name)")
+	a.query("INSERT INTO named VALUES (1, 'a')")
+	a.ok(protocol.MsgBeginTx, &protocol.BeginTxMessage{IsolationLevel: 1})
+	a.query("DELETE FROM named WHERE name = 'a'")
+	a.query("INSERT INTO named VALUES (2, 'b')")
+	if n := a.count("named WHERE name = 'b'"); n != 1 {
+		t.Errorf("Transaction finds %d of its rows by index, want 1", n)
+	}
+	if n := b.count("named WHERE name = 'a'"); n != 1 {
+		t.Errorf("Other connection finds %d committed rows by index, want 1", n)
+	}
+	if n := b.count("named WHERE name = 'b'"); n != 0 {
+		t.Errorf("Other connection finds %d uncommitted rows by index", n)
+	}
+	a.ok(protocol.MsgRollbackTx, emptyPayload{})
+	if n := b.count("named WHERE name = 'a'"); n != 1 {
+		t.Errorf("Rolled back delete left %d rows by index, want 1", n)
+	}
+	a.ok(protocol.MsgBeginTx, &protocol.BeginTxMessage{IsolationLevel: 1})
+	a.query("INSERT INTO named VALUES (2, 'b')")
+	a.ok(protocol.MsgCommitTx, emptyPayload{})
+	if n := b.count("named WHERE name = 'b'"); n != 1 {
+		t.Errorf("Other connection finds %d committed rows by index, want 1", n)
+	}
+
+	// A read-only session cannot write outside a transaction either
+	a.ok(protocol.MsgSetOption, &protocol.SetOptionMessage{Option: "read_only", Value: true})
+	a.fails(protocol.MsgQuery, &protocol.QueryMessage{Query: "INSERT INTO items VALUES (7)"}, "read-only")
+	if n := a.count("items"); n != 3 {
+		t.Errorf("Read-only session sees %d rows, want 3", n)
+	}
+}
+
+// recordingCoordinator commits transactions to a store and records them.
+type recordingCoordinator struct {
+	store     storage.Engine
+	databases []string
+	fail      bool
+}
+
+func (c *recordingCoordinator) CommitTransaction(database string, ops []storage.TxOperation) error {
+	if c.fail {
+		return fmt.Errorf("participant unreachable")
+	}
+	c.databases = append(c.databases, database)
+	return storage.WriteBatch(c.store, ops)
+}
+
+func TestServerTransactionCoordinator(t *testing.T) {
+	srv, addr, cleanup := setupTestServer(t)
+	defer cleanup()
+	coordinator := &recordingCoordinator{store: srv.store}
+	srv.SetTransactionCoordinator(coordinator)
+
+	go srv.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	c := dialTestClient(t, addr)
+	defer c.conn.Close()
+	c.query("CREATE TABLE items (id INT)")
+
+	// COMMIT goes through the coordinator
+	c.ok(protocol.MsgBeginTx, &protocol.BeginTxMessage{IsolationLevel: 1})
+	c.query("INSERT INTO items VALUES (1)")
+	c.ok(protocol.MsgCommitTx, emptyPayload{})
+	if len(coordinator.databases) != 1 || coordinator.databases[0] != storage.DefaultDatabaseName {
+		t.Errorf("Expected one commit to %s, got %v", storage.DefaultDatabaseName, coordinator.databases)
+	}
+	if n := c.count("items"); n != 1 {
+		t.Errorf("Committed transaction left %d rows, want 1", n)
+	}
+
+	// A commit the coordinator refuses writes nothing
+	coordinator.fail = true
+	c.ok(protocol.MsgBeginTx, &protocol.BeginTxMessage{IsolationLevel: 1})
+	c.query("INSERT INTO items VALUES (2)")
+	c.fails(protocol.MsgCommitTx, emptyPayload{}, "participant unreachable")
+	if n := c.count("items"); n != 1 {
+		t.Errorf("Failed commit left %d rows, want 1", n)
+	}
+}
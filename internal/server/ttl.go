@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"time"
+
+	"flydb/internal/metrics"
+	"flydb/internal/sql"
+	"flydb/internal/storage"
+)
+
+// SetTTLReaper configures the background reaper that deletes expired rows
+// of tables with a TTL. An interval of 0 disables it.
+// Call this before Start().
+func (s *Server) SetTTLReaper(interval time.Duration, batchSize int) {
+	s.ttlReaperInterval = interval
+	s.ttlBatchSize = batchSize
+}
+
+// runTTLReaper reaps expired rows every ttlReaperInterval until the server
+// is stopped.
+func (s *Server) runTTLReaper() {
+	ticker := time.NewTicker(s.ttlReaperInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.reapExpiredRows()
+		}
+	}
+}
+
+// reapExpiredRows runs one reaper pass over every database and records it
+// in the metrics.
+func (s *Server) reapExpiredRows() {
+	expired, errs := 0, 0
+	reap := func(dbName string, exec *sql.Executor) {
+		n, err := exec.ReapExpiredRows(s.ttlBatchSize)
+		expired += n
+		if err != nil {
+			errs++
+			log.Warn("TTL reaper failed", "database", dbName, "error", err)
+		}
+	}
+
+	if s.dbManager == nil {
+		reap(storage.DefaultDatabaseName, s.executor)
+	} else {
+		for _, dbName := range s.dbManager.ListDatabases() {
+			if dbName == storage.SystemDatabaseName {
+				continue
+			}
+			if dbName == storage.DefaultDatabaseName {
+				reap(dbName, s.executor)
+				continue
+			}
+			db, err := s.dbManager.GetDatabase(dbName)
+			if err != nil {
+				continue
+			}
+			reap(dbName, s.newDatabaseExecutor(db))
+		}
+	}
+
+	if expired > 0 {
+		log.Debug("TTL reaper expired rows", "rows", expired)
+	}
+	metrics.Get().RecordTTLRun(expired, errs)
+}
@@ -329,16 +329,18 @@ func (s AlterUserStmt) statementNode() {}
 //
 // SQL Syntax:
 //
-//	CREATE TABLE [IF NOT EXISTS] <name> (
+//	CREATE [REFERENCE] TABLE [IF NOT EXISTS] <name> (
 //	    <col1> <type1> [constraints],
 //	    <col2> <type2> [constraints],
 //	    [table_constraints]
-//	)
+//	) [SHARD BY (<column>)] [PARTITION BY ...] [WITH (ttl = '<interval>', ttl_column = <column>)]
 //
 // Examples:
 //
 //	CREATE TABLE users (id INT PRIMARY KEY, name TEXT NOT NULL, email TEXT UNIQUE)
 //	CREATE TABLE IF NOT EXISTS orders (id SERIAL PRIMARY KEY, user_id INT REFERENCES users(id), amount DECIMAL)
+//	CREATE TABLE invoices (id INT PRIMARY KEY, tenant_id INT, total INT) SHARD BY (tenant_id)
+//	CREATE REFERENCE TABLE countries (code TEXT PRIMARY KEY, name TEXT)
 //
 // Supported column types: INT, TEXT, SERIAL, and others defined in types.go
 // Supported constraints: PRIMARY KEY, FOREIGN KEY/REFERENCES, NOT NULL, UNIQUE, AUTO_INCREMENT, DEFAULT
@@ -348,11 +350,80 @@ type CreateTableStmt struct {
 	IfNotExists  bool              // If true, don't error if table already exists
 	Columns      []ColumnDef       // Column definitions (name, type, and constraints)
 	Constraints  []TableConstraint // Table-level constraints (composite keys, etc.)
+	PartitionBy  *PartitionSpec    // Optional PARTITION BY clause
+	TTL          *TTLSpec          // Optional WITH (ttl = ..., ttl_column = ...)
+	ShardBy      string            // Optional SHARD BY (<column>) clause
+	Reference    bool              // CREATE REFERENCE TABLE: replicated to every node
 }
 
 // statementNode implements the Statement interface.
 func (s CreateTableStmt) statementNode() {}
 
+// TTLSpec describes automatic expiry of a table's rows.
+//
+// SQL Syntax:
+//
+//	WITH (ttl = '<interval>', ttl_column = <column>)   -- expire rows <interval> after <column>
+//	WITH (ttl_column = <column>)                        -- <column> holds each row's expiry time
+//
+// Examples:
+//
+//	CREATE TABLE sessions (id INT PRIMARY KEY, created_at TIMESTAMP) WITH (ttl = '30 days', ttl_column = created_at)
+//	CREATE TABLE tokens (token TEXT PRIMARY KEY, expires_at TIMESTAMP) WITH (ttl_column = expires_at)
+type TTLSpec struct {
+	Column   string `json:"column"`             // Timestamp column the expiry is based on
+	Interval string `json:"interval,omitempty"` // Lifetime after Column, such as '30 days'; empty if Column is the expiry time
+}
+
+// PartitionStrategy represents how rows are assigned to partitions.
+type PartitionStrategy string
+
+// Partition strategy constants.
+const (
+	PartitionByRange PartitionStrategy = "RANGE" // Contiguous value ranges
+	PartitionByList  PartitionStrategy = "LIST"  // Explicit value lists
+	PartitionByHash  PartitionStrategy = "HASH"  // Hash of the value modulo a modulus
+)
+
+// PartitionSpec describes how a table is partitioned.
+//
+// SQL Syntax:
+//
+//	CREATE TABLE <name> (...) PARTITION BY RANGE|LIST|HASH (<column>)
+//	  [(PARTITION <name> <bound>, ...)]
+//
+// Example:
+//
+//	CREATE TABLE events (id INT, created_at DATE) PARTITION BY RANGE (created_at) (
+//	  PARTITION events_2025 FOR VALUES FROM ('2025-01-01') TO ('2026-01-01'),
+//	  PARTITION events_old DEFAULT
+//	)
+type PartitionSpec struct {
+	Strategy   PartitionStrategy `json:"strategy"`             // RANGE, LIST, or HASH
+	Column     string            `json:"column"`               // The partition key column
+	Partitions []PartitionDef    `json:"partitions,omitempty"` // Partitions in definition order
+}
+
+// PartitionDef describes one partition and the values it holds.
+//
+// SQL Syntax:
+//
+//	PARTITION <name> FOR VALUES FROM (<low>) TO (<high>)
+//	PARTITION <name> FOR VALUES IN (<value>, ...)
+//	PARTITION <name> FOR VALUES WITH (MODULUS <m>, REMAINDER <r>)
+//	PARTITION <name> DEFAULT
+//
+// MINVALUE and MAXVALUE leave a range bound open.
+type PartitionDef struct {
+	Name      string   `json:"name"`                 // The partition name
+	IsDefault bool     `json:"is_default,omitempty"` // Holds rows no other partition accepts
+	From      string   `json:"from,omitempty"`       // RANGE lower bound, inclusive ("" = MINVALUE)
+	To        string   `json:"to,omitempty"`         // RANGE upper bound, exclusive ("" = MAXVALUE)
+	Values    []string `json:"values,omitempty"`     // LIST values
+	Modulus   int      `json:"modulus,omitempty"`    // HASH modulus
+	Remainder int      `json:"remainder,omitempty"`  // HASH remainder
+}
+
 // ConstraintType represents the type of column constraint.
 type ConstraintType string
 
@@ -1019,6 +1090,7 @@ func (s DeallocateStmt) statementNode() {}
 //	INSPECT TABLES             - List all tables with their schemas
 //	INSPECT TABLE <name>       - Detailed info for a specific table
 //	INSPECT INDEXES            - List all indexes
+//	INSPECT CLUSTER            - Show the planned partition rebalance
 //
 // Examples:
 //
@@ -1029,7 +1101,7 @@ func (s DeallocateStmt) statementNode() {}
 //	INSPECT AUDIT [WHERE ...] [LIMIT n]
 //	INSPECT AUDIT STATS
 type InspectStmt struct {
-	Target       string     // The target to inspect: USERS, DATABASES, DATABASE, TABLES, TABLE, INDEXES, AUDIT, AUDIT_STATS
+	Target       string     // The target to inspect: USERS, DATABASES, DATABASE, TABLES, TABLE, INDEXES, AUDIT, AUDIT_STATS, CLUSTER
 	DatabaseName string     // Optional: database containing the table/object
 	ObjectName   string     // Optional: specific object name for TABLE or DATABASE targets
 	Where        *Condition // Optional: WHERE clause for AUDIT queries
@@ -1274,12 +1346,16 @@ type AlterTableAction string
 
 // ALTER TABLE action constants.
 const (
-	AlterActionAddColumn      AlterTableAction = "ADD COLUMN"
-	AlterActionDropColumn     AlterTableAction = "DROP COLUMN"
-	AlterActionRenameColumn   AlterTableAction = "RENAME COLUMN"
-	AlterActionModifyColumn   AlterTableAction = "MODIFY COLUMN"
-	AlterActionAddConstraint  AlterTableAction = "ADD CONSTRAINT"
-	AlterActionDropConstraint AlterTableAction = "DROP CONSTRAINT"
+	AlterActionAddColumn       AlterTableAction = "ADD COLUMN"
+	AlterActionDropColumn      AlterTableAction = "DROP COLUMN"
+	AlterActionRenameColumn    AlterTableAction = "RENAME COLUMN"
+	AlterActionModifyColumn    AlterTableAction = "MODIFY COLUMN"
+	AlterActionAddConstraint   AlterTableAction = "ADD CONSTRAINT"
+	AlterActionDropConstraint  AlterTableAction = "DROP CONSTRAINT"
+	AlterActionAddPartition    AlterTableAction = "ADD PARTITION"
+	AlterActionAttachPartition AlterTableAction = "ATTACH PARTITION"
+	AlterActionDetachPartition AlterTableAction = "DETACH PARTITION"
+	AlterActionDropPartition   AlterTableAction = "DROP PARTITION"
 )
 
 // AlterTableStmt represents an ALTER TABLE statement.
@@ -1293,6 +1369,10 @@ const (
 //	ALTER TABLE <table_name> MODIFY COLUMN <column_name> <new_type>
 //	ALTER TABLE <table_name> ADD CONSTRAINT <constraint_def>
 //	ALTER TABLE <table_name> DROP CONSTRAINT <constraint_name>
+//	ALTER TABLE <table_name> ADD PARTITION <partition_name> <bound>
+//	ALTER TABLE <table_name> ATTACH PARTITION <table_name> <bound>
+//	ALTER TABLE <table_name> DETACH PARTITION <partition_name>
+//	ALTER TABLE <table_name> DROP PARTITION <partition_name>
 //
 // Examples:
 //
@@ -1310,11 +1390,60 @@ type AlterTableStmt struct {
 	NewColumnType  string           // For MODIFY COLUMN
 	Constraint     *TableConstraint // For ADD CONSTRAINT
 	ConstraintName string           // For DROP CONSTRAINT
+	Partition      *PartitionDef    // For ADD/ATTACH/DETACH/DROP PARTITION
 }
 
 // statementNode implements the Statement interface.
 func (s AlterTableStmt) statementNode() {}
 
+// AlterClusterAction represents the type of ALTER CLUSTER operation.
+type AlterClusterAction string
+
+// ALTER CLUSTER action constants.
+const (
+	AlterClusterAddNode            AlterClusterAction = "ADD NODE"
+	AlterClusterRemoveNode         AlterClusterAction = "REMOVE NODE"
+	AlterClusterTransferLeadership AlterClusterAction = "TRANSFER LEADERSHIP"
+)
+
+// AlterClusterStmt represents an ALTER CLUSTER statement.
+// It changes the membership of the Raft cluster. Nodes are identified by
+// their Raft address.
+//
+// SQL Syntax:
+//
+//	ALTER CLUSTER ADD NODE '<addr>' [AS LEARNER]
+//	ALTER CLUSTER REMOVE NODE '<addr>'
+//	ALTER CLUSTER TRANSFER LEADERSHIP [TO '<addr>']
+//
+// Examples:
+//
+//	ALTER CLUSTER ADD NODE 'node4:9997'
+//	ALTER CLUSTER ADD NODE 'node5:9997' AS LEARNER
+//	ALTER CLUSTER REMOVE NODE 'node1:9997'
+type AlterClusterStmt struct {
+	Action  AlterClusterAction // The type of change
+	Address string             // Raft address of the node; empty lets TRANSFER LEADERSHIP choose
+	Learner bool               // For ADD NODE: keep the node as a non-voting learner
+}
+
+// statementNode implements the Statement interface.
+func (s AlterClusterStmt) statementNode() {}
+
+// RepairPartitionStmt represents a REPAIR PARTITION statement.
+// It compares the replicas of a cluster partition with its leader and
+// streams the key ranges that differ.
+//
+// SQL Syntax:
+//
+//	REPAIR PARTITION <n>
+type RepairPartitionStmt struct {
+	PartitionID int // The partition to repair
+}
+
+// statementNode implements the Statement interface.
+func (s RepairPartitionStmt) statementNode() {}
+
 // TriggerEvent represents the type of event that fires a trigger.
 type TriggerEvent string
 
@@ -1434,6 +1563,26 @@ type TruncateTableStmt struct {
 // statementNode implements the Statement interface.
 func (s TruncateTableStmt) statementNode() {}
 
+// AnalyzeStmt represents an ANALYZE statement.
+// It samples a table's rows and stores per-column statistics
+// (null fraction, distinct values, histogram) for the planner.
+//
+// SQL Syntax:
+//
+//	ANALYZE
+//	ANALYZE <table_name>
+//
+// Example:
+//
+//	ANALYZE orders
+type AnalyzeStmt struct {
+	DatabaseName string // The database containing the table
+	TableName    string // The table to analyze (empty = all tables)
+}
+
+// statementNode implements the Statement interface.
+func (s AnalyzeStmt) statementNode() {}
+
 // =============================================================================
 // Database Management Statements
 // =============================================================================
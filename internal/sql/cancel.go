@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Statement Cancellation:
+=======================
+
+WithContext returns an executor whose statements stop when the context is
+done, because the client canceled the query or its statement_timeout
+expired. The context is checked before a statement starts, by the table
+scans of SELECT, UPDATE and DELETE and their constraint checks, and between
+the rows a SELECT processes. A statement stopped this way fails with a
+QueryCanceled error.
+
+WithRowBudget stops a SELECT the same way when too many rows arrive: the
+budget is charged with every row as it is projected, and the error it
+returns fails the statement at the next check.
+
+UPDATE and DELETE are only stopped while they scan: once they have started
+writing rows they run to completion, so a canceled statement never leaves
+half of its changes behind.
+*/
+package sql
+
+import (
+	"context"
+	"errors"
+
+	ferrors "flydb/internal/errors"
+	"flydb/internal/storage"
+)
+
+// canceledCheckInterval is the number of rows a SELECT processes between
+// checks of its context.
+const canceledCheckInterval = 256
+
+// WithContext returns a copy of the executor whose statements are canceled
+// when ctx is done.
+func (e *Executor) WithContext(ctx context.Context) *Executor {
+	ephemeral := *e
+	ephemeral.ctx = ctx
+	return &ephemeral
+}
+
+// WithRowBudget returns a copy of the executor that calls budget with the
+// values of each result row as it is projected. Once budget returns an
+// error the statement stops and fails with it.
+func (e *Executor) WithRowBudget(budget func(values []interface{}) error) *Executor {
+	ephemeral := *e
+	ephemeral.rowBudget = budget
+	ephemeral.rowErr = nil
+	return &ephemeral
+}
+
+// statementContext returns the context of the statement being executed.
+func (e *Executor) statementContext() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// checkCanceled returns a QueryCanceled error if the statement's context
+// is done, or the error of its row budget if that was exceeded.
+func (e *Executor) checkCanceled() error {
+	if e.rowErr != nil {
+		return e.rowErr
+	}
+	return canceledError(e.statementContext().Err())
+}
+
+// scan reads the keys matching prefix from store, stopping early if the
+// statement is canceled.
+func (e *Executor) scan(store storage.Engine, prefix string) (map[string][]byte, error) {
+	rows, err := storage.ScanContext(e.statementContext(), store, prefix)
+	if err != nil {
+		return nil, canceledError(err)
+	}
+	return rows, nil
+}
+
+// canceledError converts the error of a done context to a QueryCanceled
+// error and returns other errors unchanged.
+func canceledError(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ferrors.QueryCanceled("statement timeout")
+	case errors.Is(err, context.Canceled):
+		return ferrors.QueryCanceled("user request")
+	}
+	return err
+}
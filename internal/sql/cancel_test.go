@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	ferrors "flydb/internal/errors"
+)
+
+func TestExecuteWithContext(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	run := func(e *Executor, query string) (string, error) {
+		t.Helper()
+		stmt, err := NewParser(NewLexer(query)).Parse()
+		if err != nil {
+			t.Fatalf("Parse %q failed: %v", query, err)
+		}
+		return e.Execute(stmt)
+	}
+
+	run(exec, "CREATE TABLE items (id INT, name TEXT)")
+	run(exec, "INSERT INTO items VALUES (1, 'a')")
+
+	// A live context does not change the result
+	result, err := run(exec.WithContext(context.Background()), "SELECT name FROM items")
+	if err != nil || !strings.Contains(result, "a") {
+		t.Fatalf("Unexpected result %q, %v", result, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = run(exec.WithContext(ctx), "SELECT name FROM items")
+	if ferrors.GetCode(err) != ferrors.ErrCodeQueryCanceled || !strings.Contains(err.Error(), "user request") {
+		t.Errorf("Expected a canceled query, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	_, err = run(exec.WithContext(ctx), "DELETE FROM items WHERE id = 1")
+	if ferrors.GetCode(err) != ferrors.ErrCodeQueryCanceled || !strings.Contains(err.Error(), "statement timeout") {
+		t.Errorf("Expected a statement timeout, got %v", err)
+	}
+
+	// The canceled DELETE left the row in place
+	result, _ = run(exec, "SELECT name FROM items")
+	if !strings.Contains(result, "(1 row") {
+		t.Errorf("Row deleted by a canceled statement: %q", result)
+	}
+}
+
+func TestExecuteWithRowBudget(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	run := func(e *Executor, query string) error {
+		t.Helper()
+		stmt, err := NewParser(NewLexer(query)).Parse()
+		if err != nil {
+			t.Fatalf("Parse %q failed: %v", query, err)
+		}
+		_, err = e.ExecuteResult(stmt, "")
+		return err
+	}
+
+	run(exec, "CREATE TABLE items (id INT)")
+	for i := 0; i < 1000; i++ {
+		run(exec, fmt.Sprintf("INSERT INTO items VALUES (%d)", i))
+	}
+
+	// The statement stops at the first row the budget refuses
+	errFull := errors.New("budget exceeded")
+	rows := 0
+	budget := func(values []interface{}) error {
+		if rows++; rows > 10 {
+			return errFull
+		}
+		return nil
+	}
+	if err := run(exec.WithRowBudget(budget), "SELECT id FROM items ORDER BY id LIMIT 5"); err != errFull {
+		t.Fatalf("Expected the budget error, got %v", err)
+	}
+	if rows != 11 {
+		t.Errorf("Budget charged with %d rows, want 11", rows)
+	}
+
+	rows = -1000
+	if err := run(exec.WithRowBudget(budget), "SELECT id FROM items"); err != nil {
+		t.Errorf("Query within the budget failed: %v", err)
+	}
+}
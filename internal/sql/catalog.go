@@ -114,6 +114,9 @@ type Catalog struct {
 
 	// TriggerMgr manages database triggers.
 	TriggerMgr *TriggerManager
+
+	// StatsMgr holds the statistics gathered by ANALYZE.
+	StatsMgr *StatsManager
 }
 
 // TableSchema defines the structure of a database table.
@@ -129,6 +132,10 @@ type TableSchema struct {
 	CreatedAt   time.Time         `json:"created_at,omitempty"`  // When the table was created
 	ModifiedAt  time.Time         `json:"modified_at,omitempty"` // When the table was last modified
 	Owner       string            `json:"owner,omitempty"`       // User who created the table
+	Partition   *PartitionSpec    `json:"partition,omitempty"`   // Partitioning, nil if unpartitioned
+	TTL         *TTLSpec          `json:"ttl,omitempty"`         // Row expiry, nil if rows never expire
+	ShardKey    string            `json:"shard_key,omitempty"`   // Column whose value places rows in a cluster
+	Reference   bool              `json:"reference,omitempty"`   // Rows are replicated to every node
 }
 
 // GetPrimaryKeyColumns returns the names of all primary key columns.
@@ -237,6 +244,7 @@ func NewCatalog(store storage.Engine) *Catalog {
 		store:      store,
 		IndexMgr:   storage.NewIndexManager(store),
 		TriggerMgr: NewTriggerManager(store),
+		StatsMgr:   NewStatsManager(store),
 	}
 	// Load existing schemas from storage into memory.
 	c.load()
@@ -589,6 +597,18 @@ func (c *Catalog) DropColumn(tableName, columnName string) error {
 			if col.IsPrimaryKey() {
 				return ferrors.ConstraintViolation("PRIMARY KEY", "cannot drop primary key column: "+columnName)
 			}
+			// Rows are placed by the partition key
+			if schema.Partition != nil && schema.Partition.Column == columnName {
+				return ferrors.ConstraintViolation("PARTITION", "cannot drop partition key column: "+columnName)
+			}
+			// Rows are placed by the shard key
+			if schema.ShardKey == columnName {
+				return ferrors.ConstraintViolation("SHARD", "cannot drop shard key column: "+columnName)
+			}
+			// Rows expire by the TTL column
+			if schema.TTL != nil && schema.TTL.Column == columnName {
+				return ferrors.ConstraintViolation("TTL", "cannot drop TTL column: "+columnName)
+			}
 			found = true
 			continue
 		}
@@ -667,6 +687,25 @@ func (c *Catalog) RenameColumn(tableName, oldName, newName string) error {
 		}
 	}
 
+	// Update the partition key if applicable
+	if schema.Partition != nil && schema.Partition.Column == oldName {
+		partition := *schema.Partition
+		partition.Column = newName
+		schema.Partition = &partition
+	}
+
+	// Update the shard key if applicable
+	if schema.ShardKey == oldName {
+		schema.ShardKey = newName
+	}
+
+	// Update the TTL column if applicable
+	if schema.TTL != nil && schema.TTL.Column == oldName {
+		ttl := *schema.TTL
+		ttl.Column = newName
+		schema.TTL = &ttl
+	}
+
 	// Update modification time
 	schema.ModifiedAt = time.Now()
 
@@ -839,6 +878,99 @@ func (c *Catalog) DropConstraint(tableName, constraintName string) error {
 	return c.store.Put(schemaKeyPrefix+tableName, data)
 }
 
+// SetPartitionSpec replaces the partitioning of an existing table.
+// A nil spec makes the table unpartitioned.
+//
+// Parameters:
+//   - tableName: The name of the table to modify
+//   - spec: The new partition specification
+//
+// Returns an error if:
+//   - The table does not exist
+//   - The schema cannot be persisted
+func (c *Catalog) SetPartitionSpec(tableName string, spec *PartitionSpec) error {
+	schema, ok := c.Tables[tableName]
+	if !ok {
+		return ferrors.TableNotFound(tableName)
+	}
+
+	schema.Partition = spec
+	schema.ModifiedAt = time.Now()
+
+	// Update the cache
+	c.Tables[tableName] = schema
+
+	// Persist the updated schema
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	return c.store.Put(schemaKeyPrefix+tableName, data)
+}
+
+// SetDistribution sets how the rows of an existing table are placed in a
+// cluster: by the value of a shard key column, on every node for a
+// reference table, or by row id if neither is set.
+//
+// Parameters:
+//   - tableName: The name of the table to modify
+//   - shardKey: The shard key column, or "" for none
+//   - reference: Whether the table is a reference table
+//
+// Returns an error if:
+//   - The table does not exist
+//   - The schema cannot be persisted
+func (c *Catalog) SetDistribution(tableName, shardKey string, reference bool) error {
+	schema, ok := c.Tables[tableName]
+	if !ok {
+		return ferrors.TableNotFound(tableName)
+	}
+
+	schema.ShardKey = shardKey
+	schema.Reference = reference
+	schema.ModifiedAt = time.Now()
+
+	// Update the cache
+	c.Tables[tableName] = schema
+
+	// Persist the updated schema
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	return c.store.Put(schemaKeyPrefix+tableName, data)
+}
+
+// SetTTL replaces the row expiry settings of an existing table.
+// A nil spec disables expiry.
+//
+// Parameters:
+//   - tableName: The name of the table to modify
+//   - spec: The new TTL specification
+//
+// Returns an error if:
+//   - The table does not exist
+//   - The schema cannot be persisted
+func (c *Catalog) SetTTL(tableName string, spec *TTLSpec) error {
+	schema, ok := c.Tables[tableName]
+	if !ok {
+		return ferrors.TableNotFound(tableName)
+	}
+
+	schema.TTL = spec
+	schema.ModifiedAt = time.Now()
+
+	// Update the cache
+	c.Tables[tableName] = schema
+
+	// Persist the updated schema
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	return c.store.Put(schemaKeyPrefix+tableName, data)
+}
+
 // DropTable removes a table from the catalog.
 //
 // Parameters:
@@ -0,0 +1,322 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Distributed Query Execution:
+============================
+
+In a cluster, a single-table SELECT is split into a fragment that runs on
+the owner of every partition and a merge step on the node that received
+the query (the coordinator):
+
+	Coordinator                      Partition owners
+	-----------                      ----------------
+	plan fragment  ── fragment ──>   scan owned rows
+	                                 WHERE + RLS filter
+	                                 projection / partial aggregates
+	merge          <── partials ──   local ORDER BY, DISTINCT, LIMIT
+	ORDER BY, DISTINCT, OFFSET, LIMIT, HAVING
+
+Rows never leave their node. A plain query ships only the projected rows
+that survive the filter, at most OFFSET+LIMIT of them per node, and an
+aggregate query ships one partial state per group: SUM, COUNT, MIN and
+MAX combine directly and AVG travels as its running sum and count.
+
+An INNER or LEFT join runs on the partition owners too when every row it
+matches is on the same node as the primary row: the joined table is a
+reference table, or both tables are sharded and joined on their shard
+keys (see sharding.go). Other joins, subqueries, an open transaction or
+an index lookup run on the coordinator as before.
+*/
+package sql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	ferrors "flydb/internal/errors"
+)
+
+// DistributedScanner runs a query fragment on the owner of every partition
+// of a cluster and returns the partial results, one per node.
+type DistributedScanner interface {
+	ScatterGatherFragment(fragment []byte) ([][]byte, error)
+}
+
+// queryFragment is the part of a SELECT that partition owners evaluate.
+type queryFragment struct {
+	Database string      `json:"database"`
+	Stmt     *SelectStmt `json:"stmt"`
+	RLS      *Condition  `json:"rls,omitempty"`
+}
+
+// fragmentResult is the partial result of a fragment on one node: the
+// formatted rows of a plain query or the partial groups of an aggregate.
+type fragmentResult struct {
+	Rows   []string        `json:"rows,omitempty"`
+	Values [][]interface{} `json:"values,omitempty"` // Values of each row, for structured results
+	Groups []partialGroup  `json:"groups,omitempty"`
+}
+
+// partialGroup carries the aggregate states of one group between nodes.
+type partialGroup struct {
+	Key    []string           `json:"key,omitempty"`
+	States []partialAggregate `json:"states"`
+}
+
+// partialAggregate is the wire form of an aggState.
+type partialAggregate struct {
+	Count  int      `json:"count,omitempty"`
+	Sum    float64  `json:"sum,omitempty"`
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+	MinStr *string  `json:"min_str,omitempty"`
+	MaxStr *string  `json:"max_str,omitempty"`
+	Concat []string `json:"concat,omitempty"`
+}
+
+// FragmentDatabase returns the database a query fragment reads from, so
+// that the receiving node can pick the executor for it.
+func FragmentDatabase(fragment []byte) (string, error) {
+	var frag queryFragment
+	if err := json.Unmarshal(fragment, &frag); err != nil {
+		return "", ferrors.InternalError(fmt.Sprintf("invalid query fragment: %v", err))
+	}
+	return frag.Database, nil
+}
+
+// canDistribute reports whether a SELECT can be pushed down to the
+// partition owners of the cluster.
+func (e *Executor) canDistribute(cat *Catalog, stmt *SelectStmt) bool {
+	if e.distributed == nil || e.tx != nil {
+		return false
+	}
+	if stmt.Subquery != nil || whereHasSubquery(stmt.WhereExt) {
+		return false
+	}
+	if stmt.Join != nil && !canDistributeJoin(cat, stmt) {
+		return false
+	}
+	return !e.canUseIndex(cat, stmt)
+}
+
+// canDistributeJoin reports whether the join of a SELECT only matches rows
+// on the node of the primary row, so that partition owners can evaluate it.
+// Aggregates over joins and joins that must emit unmatched rows of the
+// joined table stay on the coordinator.
+func canDistributeJoin(cat *Catalog, stmt *SelectStmt) bool {
+	join := stmt.Join
+	if len(stmt.Aggregates) > 0 || (join.JoinType != JoinTypeInner && join.JoinType != JoinTypeLeft) {
+		return false
+	}
+	if join.DatabaseName != "" && join.DatabaseName != stmt.DatabaseName {
+		return false
+	}
+	table, ok := cat.GetTable(stmt.TableName)
+	if !ok {
+		return false
+	}
+	joined, ok := cat.GetTable(join.TableName)
+	return ok && colocatedJoin(table, joined, join.On)
+}
+
+// whereHasSubquery reports whether any condition of a WHERE clause uses a
+// subquery.
+func whereHasSubquery(where *WhereClause) bool {
+	if where == nil {
+		return false
+	}
+	return where.IsSubquery || whereHasSubquery(where.And) || whereHasSubquery(where.Or)
+}
+
+// executeDistributedSelect runs a SELECT on the partition owners and merges
+// their partial results.
+func (e *Executor) executeDistributedSelect(stmt *SelectStmt, rls *Condition) (string, error) {
+	// The receiving node runs the fragment with the executor of its
+	// database, so the statement itself no longer names one
+	fragStmt := *stmt
+	fragStmt.DatabaseName = ""
+	frag := queryFragment{Database: stmt.DatabaseName, Stmt: &fragStmt, RLS: rls}
+	if frag.Database == "" {
+		frag.Database = e.distributedDB
+	}
+	data, err := json.Marshal(frag)
+	if err != nil {
+		return "", ferrors.InternalError(fmt.Sprintf("failed to encode query fragment: %v", err))
+	}
+
+	partials, err := e.distributed.ScatterGatherFragment(data)
+	if err != nil {
+		return "", ferrors.NewExecutionError(fmt.Sprintf("distributed query failed: %v", err))
+	}
+
+	var rows []string
+	groups := make(map[string]*aggGroup)
+	for _, partial := range partials {
+		var res fragmentResult
+		if err := json.Unmarshal(partial, &res); err != nil {
+			return "", ferrors.InternalError(fmt.Sprintf("invalid fragment result: %v", err))
+		}
+		rows = append(rows, res.Rows...)
+		if len(res.Values) == len(res.Rows) {
+			for i, row := range res.Rows {
+				e.recordRowValues(row, res.Values[i])
+			}
+		}
+
+		for _, pg := range res.Groups {
+			if len(pg.States) != len(stmt.Aggregates) {
+				return "", ferrors.InternalError("fragment result does not match the query's aggregates")
+			}
+			key := strings.Join(pg.Key, "|")
+			group := groups[key]
+			if group == nil {
+				group = newAggGroup(pg.Key, len(stmt.Aggregates))
+				groups[key] = group
+			}
+			for i, pa := range pg.States {
+				group.states[i].merge(&aggState{
+					count:        pa.Count,
+					sum:          pa.Sum,
+					min:          pa.Min,
+					max:          pa.Max,
+					minStr:       pa.MinStr,
+					maxStr:       pa.MaxStr,
+					concatValues: pa.Concat,
+				})
+			}
+		}
+	}
+
+	if len(stmt.Aggregates) > 0 {
+		return e.formatAggregates(stmt, groups), nil
+	}
+	return e.formatSelectResult(stmt, e.sortDistinctRows(stmt, rows)), nil
+}
+
+// ExecuteFragment evaluates a query fragment pushed down by a coordinator
+// over the local rows for which owns returns true, and returns the
+// encoded partial result.
+func (e *Executor) ExecuteFragment(fragment []byte, owns func(key string) bool) ([]byte, error) {
+	var frag queryFragment
+	if err := json.Unmarshal(fragment, &frag); err != nil || frag.Stmt == nil {
+		return nil, ferrors.InternalError("invalid query fragment")
+	}
+	stmt := frag.Stmt
+
+	cat, err := e.getCatalog(stmt.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := cat.GetTable(stmt.TableName); !ok {
+		return nil, ferrors.TableNotFound(stmt.TableName)
+	}
+
+	// Partitions can only be pruned when the WHERE clause refers to this
+	// table alone
+	var where *WhereClause
+	if stmt.Join == nil {
+		where = stmt.WhereExt
+		if where == nil {
+			where = conditionWhere(stmt.Where)
+		}
+	}
+	rows, err := e.scanTableRows(cat, stmt.TableName, where)
+	if err != nil {
+		return nil, err
+	}
+	for key := range rows {
+		if !owns(key) {
+			delete(rows, key)
+		}
+	}
+
+	// The rows a co-located join matches are on this node: all of a
+	// reference table, or the owned rows of a table sharded the same way
+	var joinRows map[string][]byte
+	if stmt.Join != nil {
+		joined, ok := cat.GetTable(stmt.Join.TableName)
+		if !ok {
+			return nil, ferrors.TableNotFound(stmt.Join.TableName)
+		}
+		joinRows, err = e.scan(cat.store, "row:"+stmt.Join.TableName+":")
+		if err != nil {
+			return nil, err
+		}
+		if !joined.Reference {
+			for key := range joinRows {
+				if !owns(key) {
+					delete(joinRows, key)
+				}
+			}
+		}
+	}
+
+	var res fragmentResult
+	if len(stmt.Aggregates) > 0 {
+		for _, group := range e.accumulateAggregates(stmt, rows, frag.RLS) {
+			pg := partialGroup{Key: group.keyValues}
+			for i := range stmt.Aggregates {
+				state := group.states[i]
+				pg.States = append(pg.States, partialAggregate{
+					Count:  state.count,
+					Sum:    state.sum,
+					Min:    state.min,
+					Max:    state.max,
+					MinStr: state.minStr,
+					MaxStr: state.maxStr,
+					Concat: state.concatValues,
+				})
+			}
+			res.Groups = append(res.Groups, pg)
+		}
+		return json.Marshal(res)
+	}
+
+	// Record the values of each row on a copy of the executor, which
+	// serves other fragments concurrently
+	fe := *e
+	fe.rowValues = make(map[string][][]interface{})
+	fe.rowBudget, fe.rowErr = nil, nil
+	for _, val := range rows {
+		var row map[string]interface{}
+		json.Unmarshal(val, &row)
+
+		flatRow := make(map[string]interface{})
+		for k, v := range row {
+			flatRow[k] = v
+			flatRow[stmt.TableName+"."+k] = v
+		}
+		if stmt.Join != nil {
+			fe.joinRow(cat, stmt, flatRow, joinRows, &res.Rows, frag.RLS)
+		} else {
+			fe.processRow(flatRow, stmt, &res.Rows, frag.RLS)
+		}
+	}
+
+	// Only the first OFFSET+LIMIT rows in the final order can be part of
+	// the merged result
+	res.Rows = e.sortDistinctRows(stmt, res.Rows)
+	if stmt.Limit > 0 && len(res.Rows) > stmt.Offset+stmt.Limit {
+		res.Rows = res.Rows[:stmt.Offset+stmt.Limit]
+	}
+	for _, row := range res.Rows {
+		values, _ := fe.takeRowValues(row)
+		res.Values = append(res.Values, values)
+	}
+	return json.Marshal(res)
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sql
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fakeCluster splits the rows of one store between two nodes by key and
+// records how many rows and groups the fragments ship to the coordinator.
+// place picks the node of a key, by default from its last character.
+type fakeCluster struct {
+	node    *Executor
+	place   func(key string) int
+	shipped int
+}
+
+func (c *fakeCluster) ScatterGatherFragment(fragment []byte) ([][]byte, error) {
+	var partials [][]byte
+	for node := 0; node < 2; node++ {
+		owns := func(key string) bool { return int(key[len(key)-1])%2 == node }
+		if c.place != nil {
+			owns = func(key string) bool { return c.place(key) == node }
+		}
+		partial, err := c.node.ExecuteFragment(fragment, owns)
+		if err != nil {
+			return nil, err
+		}
+		var res fragmentResult
+		json.Unmarshal(partial, &res)
+		c.shipped += len(res.Rows) + len(res.Groups)
+		partials = append(partials, partial)
+	}
+	return partials, nil
+}
+
+// sortedResult sorts the data rows of a result so that results whose group
+// order is unspecified can be compared.
+func sortedResult(result string) string {
+	lines := strings.Split(result, "\n")
+	if len(lines) > 2 {
+		data := lines[1 : len(lines)-1]
+		sort.Strings(data)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestExecutorDistributedSelect(t *testing.T) {
+	local, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	queries := []string{"CREATE TABLE orders (id INT PRIMARY KEY, region TEXT, amount INT)"}
+	regions := []string{"east", "west", "north"}
+	for i := 1; i <= 12; i++ {
+		queries = append(queries, fmt.Sprintf("INSERT INTO orders VALUES (%d, '%s', %d)", i, regions[i%3], i*10))
+	}
+	for _, query := range queries {
+		if _, err := local.Execute(parse(t, query)); err != nil {
+			t.Fatalf("%s failed: %v", query, err)
+		}
+	}
+
+	cluster := &fakeCluster{node: local}
+	coordinator := NewExecutor(local.store, local.auth)
+	coordinator.SetDistributedScanner(cluster, "")
+
+	tests := []string{
+		"SELECT COUNT(*), SUM(amount), AVG(amount), MIN(amount), MAX(amount) FROM orders",
+		"SELECT COUNT(*) FROM orders WHERE region = 'east'",
+		"SELECT region, COUNT(*), SUM(amount) FROM orders GROUP BY region",
+		"SELECT region, AVG(amount) FROM orders GROUP BY region HAVING AVG(amount) > 60",
+		"SELECT MIN(region), MAX(region) FROM orders",
+		"SELECT id, amount FROM orders WHERE amount > 50 ORDER BY id LIMIT 3 OFFSET 1",
+		"SELECT DISTINCT region FROM orders ORDER BY region",
+		"SELECT id, UPPER(region) FROM orders WHERE region = 'west' ORDER BY id DESC",
+	}
+	for _, query := range tests {
+		want, err := local.Execute(parse(t, query))
+		if err != nil {
+			t.Fatalf("%s failed locally: %v", query, err)
+		}
+		got, err := coordinator.Execute(parse(t, query))
+		if err != nil {
+			t.Fatalf("%s failed distributed: %v", query, err)
+		}
+		if sortedResult(got) != sortedResult(want) {
+			t.Errorf("%s:\ndistributed:\n%s\nlocal:\n%s", query, got, want)
+		}
+	}
+
+	// A grouped count ships one partial per group and node, not the rows
+	cluster.shipped = 0
+	if _, err := coordinator.Execute(parse(t, "SELECT region, COUNT(*) FROM orders GROUP BY region")); err != nil {
+		t.Fatalf("GROUP BY failed: %v", err)
+	}
+	if cluster.shipped < len(regions) || cluster.shipped > 2*len(regions) {
+		t.Errorf("Expected %d to %d partial groups, shipped %d", len(regions), 2*len(regions), cluster.shipped)
+	}
+
+	// Each node ships at most OFFSET+LIMIT rows
+	cluster.shipped = 0
+	if _, err := coordinator.Execute(parse(t, "SELECT id FROM orders ORDER BY id LIMIT 2 OFFSET 1")); err != nil {
+		t.Fatalf("LIMIT failed: %v", err)
+	}
+	if cluster.shipped > 2*3 {
+		t.Errorf("Expected at most 6 rows, shipped %d", cluster.shipped)
+	}
+}
@@ -115,6 +115,7 @@ and JSON representation of the row. This enables the WATCH feature.
 package sql
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"errors"
@@ -150,6 +151,31 @@ type DBManager interface {
 	DatabaseExists(name string) bool
 }
 
+// ClusterAdmin defines the interface for Raft cluster membership changes.
+// This allows the executor to run ALTER CLUSTER without depending on the
+// cluster package.
+type ClusterAdmin interface {
+	AddNode(addr string, learner bool) error
+	RemoveNode(addr string) error
+	TransferLeadership(addr string) error
+}
+
+// ClusterInspector defines the interface for INSPECT CLUSTER, which shows
+// the partition moves, splits and merges the cluster balancer would make.
+type ClusterInspector interface {
+	// RebalancePlanRows returns one row of action, partition, from_node,
+	// to_node and reason per planned step.
+	RebalancePlanRows() [][]string
+}
+
+// ClusterRepairer defines the interface for REPAIR PARTITION, which runs
+// an anti-entropy repair of a partition's replicas.
+type ClusterRepairer interface {
+	// RepairPartitionStats repairs a partition and returns the number of
+	// differing key ranges and keys streamed to its replicas.
+	RepairPartitionStats(id int) (ranges, keys int, err error)
+}
+
 // AuditQueryOptions specifies options for querying audit logs.
 type AuditQueryOptions struct {
 	StartTime  time.Time
@@ -209,6 +235,10 @@ type Executor struct {
 	// tx is the current active transaction (nil if not in a transaction).
 	tx *storage.Transaction
 
+	// batchStore is the store underneath tx when tx only batches the writes
+	// of a single statement (see inStatementBatch).
+	batchStore storage.Engine
+
 	// OnInsert is a callback invoked after each successful INSERT.
 	// It receives the table name and JSON representation of the inserted row.
 	// This enables reactive features like WATCH subscriptions.
@@ -250,6 +280,11 @@ type Executor struct {
 	// collator provides string comparison based on database collation settings.
 	collator storage.Collator
 
+	// collation and locale are the settings collator was built from, so
+	// background work can build its own collator.
+	collation storage.Collation
+	locale    string
+
 	// encoder provides encoding validation based on database encoding settings.
 	encoder storage.Encoder
 
@@ -262,6 +297,48 @@ type Executor struct {
 
 	// catalogs maintains a cache of catalogs for each database.
 	catalogs map[string]*Catalog
+
+	// autoAnalyzeFraction is the fraction of a table's rows that must change
+	// before it is analyzed again automatically (0 disables auto-analyze).
+	autoAnalyzeFraction float64
+
+	// clusterAdmin changes the cluster membership for ALTER CLUSTER.
+	// It is nil unless the server runs in a Raft cluster.
+	clusterAdmin ClusterAdmin
+
+	// clusterInspector plans rebalances for INSPECT CLUSTER; nil outside
+	// cluster mode.
+	clusterInspector ClusterInspector
+
+	// clusterRepairer runs REPAIR PARTITION; nil outside cluster mode.
+	clusterRepairer ClusterRepairer
+
+	// distributed pushes SELECTs down to the partition owners of a
+	// cluster; nil runs every query against the local store.
+	// distributedDB is the database the executor's store belongs to.
+	distributed   DistributedScanner
+	distributedDB string
+
+	// rowValues maps each formatted result row to the values of the rows
+	// formatted as it, in order, so ExecuteResult never parses result text.
+	// It is nil unless a structured result is being built.
+	rowValues map[string][][]interface{}
+
+	// rowBudget is called with the values of each row as it is projected;
+	// the first error it returns is kept in rowErr and stops the statement.
+	rowBudget func(values []interface{}) error
+	rowErr    error
+
+	// rowStream receives the rows of the SELECT it names as they are
+	// projected instead of the result text; nil collects every row.
+	rowStream *rowStreamer
+
+	// lastInsertID is the auto-increment value of the last row inserted
+	// by this execution context.
+	lastInsertID int64
+
+	// ctx cancels the statement being executed; nil never cancels.
+	ctx context.Context
 }
 
 // getStorage returns the storage engine for the specified database.
@@ -297,6 +374,117 @@ type aggState struct {
 	concatValues []string
 }
 
+// add folds the value a row holds for agg into the state.
+func (s *aggState) add(agg *AggregateExpr, row map[string]interface{}) {
+	if agg.Function == "COUNT" {
+		if agg.Column == "*" {
+			s.count++
+		} else if _, exists := row[agg.Column]; exists {
+			s.count++
+		}
+		return
+	}
+
+	// For SUM, AVG, MIN, MAX we need the column value
+	colVal, exists := row[agg.Column]
+	if !exists {
+		return
+	}
+
+	// Try to parse as number
+	strVal := fmt.Sprintf("%v", colVal)
+	numVal, err := strconv.ParseFloat(strVal, 64)
+
+	switch agg.Function {
+	case "SUM", "AVG":
+		if err == nil {
+			s.sum += numVal
+			s.count++
+		}
+	case "MIN":
+		if err == nil {
+			s.mergeMin(&numVal, nil)
+		} else {
+			s.mergeMin(nil, &strVal)
+		}
+		s.count++
+	case "MAX":
+		if err == nil {
+			s.mergeMax(&numVal, nil)
+		} else {
+			s.mergeMax(nil, &strVal)
+		}
+		s.count++
+	case "GROUP_CONCAT", "STRING_AGG":
+		s.concatValues = append(s.concatValues, strVal)
+		s.count++
+	}
+}
+
+// merge folds another partial state of the same aggregate into the state.
+// Partial states of SUM, COUNT, MIN and MAX combine directly, and AVG
+// combines through its running sum and count.
+func (s *aggState) merge(other *aggState) {
+	s.count += other.count
+	s.sum += other.sum
+	s.mergeMin(other.min, other.minStr)
+	s.mergeMax(other.max, other.maxStr)
+	s.concatValues = append(s.concatValues, other.concatValues...)
+}
+
+func (s *aggState) mergeMin(num *float64, str *string) {
+	if num != nil && (s.min == nil || *num < *s.min) {
+		s.min = num
+	}
+	if str != nil && (s.minStr == nil || *str < *s.minStr) {
+		s.minStr = str
+	}
+}
+
+func (s *aggState) mergeMax(num *float64, str *string) {
+	if num != nil && (s.max == nil || *num > *s.max) {
+		s.max = num
+	}
+	if str != nil && (s.maxStr == nil || *str > *s.maxStr) {
+		s.maxStr = str
+	}
+}
+
+// format returns the final value of agg computed from the state.
+func (s *aggState) format(agg *AggregateExpr) string {
+	switch agg.Function {
+	case "COUNT":
+		return fmt.Sprintf("%d", s.count)
+	case "SUM":
+		return fmt.Sprintf("%.2f", s.sum)
+	case "AVG":
+		if s.count > 0 {
+			return fmt.Sprintf("%.2f", s.sum/float64(s.count))
+		}
+	case "MIN":
+		if s.min != nil {
+			return fmt.Sprintf("%.2f", *s.min)
+		} else if s.minStr != nil {
+			return *s.minStr
+		}
+	case "MAX":
+		if s.max != nil {
+			return fmt.Sprintf("%.2f", *s.max)
+		} else if s.maxStr != nil {
+			return *s.maxStr
+		}
+	case "GROUP_CONCAT", "STRING_AGG":
+		if len(s.concatValues) > 0 {
+			sep := agg.Separator
+			if sep == "" {
+				sep = ","
+			}
+			return strings.Join(s.concatValues, sep)
+		}
+	}
+	return "NULL"
+}
+
 // NewExecutor creates a new Executor with the given storage and auth manager.
 // It initializes a new Catalog to manage table schemas.
 //
@@ -313,6 +501,8 @@ func NewExecutor(store storage.Engine, auth *auth.AuthManager) *Executor {
 		collator: storage.GetCollator(storage.CollationDefault, "en_US"),
 		encoder:  storage.GetEncoder(storage.EncodingDefault),
 		catalogs: make(map[string]*Catalog),
+
+		autoAnalyzeFraction: DefaultAutoAnalyzeFraction,
 	}
 
 	// Initialize index manager
@@ -375,6 +565,7 @@ func (e *Executor) getCatalog(dbName string) (*Catalog, error) {
 // SetCollation sets the collation for string comparisons.
 func (e *Executor) SetCollation(collation storage.Collation, locale string) {
 	e.collator = storage.GetCollator(collation, locale)
+	e.collation, e.locale = collation, locale
 }
 
 // SetEncoding sets the encoding for data validation.
@@ -415,6 +606,35 @@ func (e *Executor) SetDatabaseManager(dbMgr DBManager) {
 	e.dbMgr = dbMgr
 }
 
+// SetAutoAnalyzeFraction sets the fraction of a table's rows that must be
+// modified before the table is analyzed again automatically.
+// A fraction of 0 disables automatic ANALYZE.
+func (e *Executor) SetAutoAnalyzeFraction(fraction float64) {
+	e.autoAnalyzeFraction = fraction
+}
+
+// SetClusterAdmin sets the cluster membership manager used by ALTER CLUSTER.
+func (e *Executor) SetClusterAdmin(admin ClusterAdmin) {
+	e.clusterAdmin = admin
+}
+
+// SetClusterInspector sets the cluster balancer used by INSPECT CLUSTER.
+func (e *Executor) SetClusterInspector(inspector ClusterInspector) {
+	e.clusterInspector = inspector
+}
+
+// SetClusterRepairer sets the anti-entropy repairer used by REPAIR PARTITION.
+func (e *Executor) SetClusterRepairer(repairer ClusterRepairer) {
+	e.clusterRepairer = repairer
+}
+
+// SetDistributedScanner enables distributed execution of SELECTs through
+// scanner. database is the database the executor's store belongs to.
+func (e *Executor) SetDistributedScanner(scanner DistributedScanner, database string) {
+	e.distributed = scanner
+	e.distributedDB = database
+}
+
 // SetSessionContext sets the session context for audit logging.
 func (e *Executor) SetSessionContext(sessionID, clientAddr, database string) {
 	e.sessionID = sessionID
@@ -486,6 +706,10 @@ func (e *Executor) ExecuteWithUser(stmt Statement, user string) (string, error)
 
 // Execute runs the given SQL statement and returns the result as a string.
 func (e *Executor) Execute(stmt Statement) (string, error) {
+	if err := e.checkCanceled(); err != nil {
+		return "", err
+	}
+
 	switch s := stmt.(type) {
 	case *CreateTableStmt:
 		// CREATE TABLE requires admin privileges.
@@ -567,7 +791,7 @@ func (e *Executor) Execute(stmt Statement) (string, error) {
 		if err := e.checkAccess(s.DatabaseName, s.TableName); err != nil {
 			return "", err
 		}
-		return e.executeInsert(s)
+		return e.inStatementBatch(func(b *Executor) (string, error) { return b.executeInsert(s) })
 
 	case *UpdateStmt:
 		// UPDATE requires access to the target table.
@@ -575,7 +799,7 @@ func (e *Executor) Execute(stmt Statement) (string, error) {
 		if err := e.checkAccess(s.DatabaseName, s.TableName); err != nil {
 			return "", err
 		}
-		return e.executeUpdate(s)
+		return e.inStatementBatch(func(b *Executor) (string, error) { return b.executeUpdate(s) })
 
 	case *DeleteStmt:
 		// DELETE requires access to the target table.
@@ -583,7 +807,7 @@ func (e *Executor) Execute(stmt Statement) (string, error) {
 		if err := e.checkAccess(s.DatabaseName, s.TableName); err != nil {
 			return "", err
 		}
-		return e.executeDelete(s)
+		return e.inStatementBatch(func(b *Executor) (string, error) { return b.executeDelete(s) })
 
 	case *SelectStmt:
 		// SELECT requires access to the primary table.
@@ -677,6 +901,20 @@ func (e *Executor) Execute(stmt Statement) (string, error) {
 		}
 		return e.executeAlterTable(s)
 
+	case *AlterClusterStmt:
+		// ALTER CLUSTER requires admin privileges.
+		if e.currentUser != "" && e.currentUser != "admin" {
+			return "", ferrors.PermissionDenied("")
+		}
+		return e.executeAlterCluster(s)
+
+	case *RepairPartitionStmt:
+		// REPAIR PARTITION requires admin privileges.
+		if e.currentUser != "" && e.currentUser != "admin" {
+			return "", ferrors.PermissionDenied("")
+		}
+		return e.executeRepairPartition(s)
+
 	case *CreateViewStmt:
 		// CREATE VIEW requires admin privileges.
 		if e.currentUser != "" && e.currentUser != "admin" {
@@ -719,6 +957,11 @@ func (e *Executor) Execute(stmt Statement) (string, error) {
 		}
 		return e.executeTruncateTable(s)
 
+	case *AnalyzeStmt:
+		// ANALYZE checks table access itself; analyzing every table
+		// requires admin privileges.
+		return e.executeAnalyze(s)
+
 	case *CreateDatabaseStmt:
 		// CREATE DATABASE requires admin privileges.
 		if e.currentUser != "" && e.currentUser != "admin" {
@@ -749,6 +992,44 @@ func (e *Executor) Execute(stmt Statement) (string, error) {
 	return "", ferrors.NewExecutionError("unknown statement")
 }
 
+// executeAlterCluster executes an ALTER CLUSTER statement.
+// The change is committed through the Raft log before it returns.
+func (e *Executor) executeAlterCluster(stmt *AlterClusterStmt) (string, error) {
+	if e.clusterAdmin == nil {
+		return "", ferrors.NewExecutionError("ALTER CLUSTER requires a Raft cluster")
+	}
+
+	var err error
+	switch stmt.Action {
+	case AlterClusterAddNode:
+		err = e.clusterAdmin.AddNode(stmt.Address, stmt.Learner)
+	case AlterClusterRemoveNode:
+		err = e.clusterAdmin.RemoveNode(stmt.Address)
+	case AlterClusterTransferLeadership:
+		err = e.clusterAdmin.TransferLeadership(stmt.Address)
+	default:
+		return "", ferrors.NewExecutionError(fmt.Sprintf("unknown ALTER CLUSTER action %s", stmt.Action))
+	}
+	if err != nil {
+		return "", ferrors.NewExecutionError(fmt.Sprintf("ALTER CLUSTER %s failed: %v", stmt.Action, err))
+	}
+	return "ALTER CLUSTER OK", nil
+}
+
+// executeRepairPartition executes a REPAIR PARTITION statement.
+// It returns once every replica of the partition matches its leader.
+func (e *Executor) executeRepairPartition(stmt *RepairPartitionStmt) (string, error) {
+	if e.clusterRepairer == nil {
+		return "", ferrors.NewExecutionError("REPAIR PARTITION requires cluster mode")
+	}
+
+	ranges, keys, err := e.clusterRepairer.RepairPartitionStats(stmt.PartitionID)
+	if err != nil {
+		return "", ferrors.NewExecutionError(fmt.Sprintf("REPAIR PARTITION %d failed: %v", stmt.PartitionID, err))
+	}
+	return fmt.Sprintf("REPAIR PARTITION %d OK (%d ranges, %d keys streamed)", stmt.PartitionID, ranges, keys), nil
+}
+
 // executeUse executes a USE statement.
 func (e *Executor) executeUse(stmt *UseDatabaseStmt) (string, error) {
 	if e.dbMgr == nil {
@@ -1086,7 +1367,37 @@ func (e *Executor) executeCreate(stmt *CreateTableStmt) (string, error) {
 		return "", err
 	}
 
+	// Validate the PARTITION BY clause
+	var partition *PartitionSpec
+	if stmt.PartitionBy != nil {
+		partition, err = e.preparePartitionSpec(stmt.Columns, stmt.PartitionBy)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Validate the TTL options
+	if stmt.TTL != nil {
+		if err := validateTTL(stmt.Columns, stmt.TTL); err != nil {
+			return "", err
+		}
+	}
+
+	// Validate the SHARD BY and REFERENCE options
+	if err := validateDistribution(stmt); err != nil {
+		return "", err
+	}
+
 	err = cat.CreateTableWithConstraints(stmt.TableName, stmt.Columns, stmt.Constraints)
+	if err == nil && partition != nil {
+		err = cat.SetPartitionSpec(stmt.TableName, partition)
+	}
+	if err == nil && stmt.TTL != nil {
+		err = cat.SetTTL(stmt.TableName, stmt.TTL)
+	}
+	if err == nil && (stmt.ShardBy != "" || stmt.Reference) {
+		err = cat.SetDistribution(stmt.TableName, stmt.ShardBy, stmt.Reference)
+	}
 	if err != nil {
 		e.logAuditEvent("CREATE_TABLE", "table", stmt.TableName, operation, "FAILED", err.Error(), time.Since(start).Milliseconds())
 		return "", err
@@ -1186,8 +1497,21 @@ func (e *Executor) executeInsert(stmt *InsertStmt) (string, error) {
 			return "", err
 		}
 		insertedCount++
+
+		// Remember the generated key for structured results
+		for i, col := range table.Columns {
+			if col.IsAutoIncrement() {
+				if id, err := parseIntValue(normalizedValues[i]); err == nil {
+					e.lastInsertID = id
+				}
+				break
+			}
+		}
 	}
 
+	// Refresh statistics once enough rows have changed
+	e.recordChanges(cat, stmt.TableName, insertedCount)
+
 	// Invalidate cache for this table since data has changed
 	if e.queryCache != nil {
 		e.queryCache.Invalidate(stmt.TableName)
@@ -1391,6 +1715,12 @@ func (e *Executor) insertSingleRow(cat *Catalog, dbName string, table TableSchem
 		return ferrors.NewExecutionError("BEFORE INSERT trigger failed").WithCause(err)
 	}
 
+	// Build the row
+	row := make(map[string]interface{})
+	for i, col := range table.Columns {
+		row[col.Name] = normalizedValues[i]
+	}
+
 	// Generate a unique row ID
 	seqKey := "seq:" + table.Name
 	var seq int
@@ -1400,17 +1730,15 @@ func (e *Executor) insertSingleRow(cat *Catalog, dbName string, table TableSchem
 	}
 	seq++
 
+	// Place the row in its partition, if the table is partitioned
+	rowKey, err := e.partitionedRowKey(table, fmt.Sprintf("row:%s:%d", table.Name, seq), row)
+	if err != nil {
+		return err
+	}
+
 	seqBytes, _ := json.Marshal(seq)
 	cat.store.Put(seqKey, seqBytes)
 
-	rowKey := fmt.Sprintf("row:%s:%d", table.Name, seq)
-
-	// Build the row
-	row := make(map[string]interface{})
-	for i, col := range table.Columns {
-		row[col.Name] = normalizedValues[i]
-	}
-
 	// Store the row
 	data, err := json.Marshal(row)
 	if err != nil {
@@ -1422,7 +1750,7 @@ func (e *Executor) insertSingleRow(cat *Catalog, dbName string, table TableSchem
 
 	// Update indexes
 	if cat.IndexMgr != nil {
-		cat.IndexMgr.OnInsert(table.Name, rowKey, row)
+		e.indexUpdates(cat).OnInsert(table.Name, rowKey, row)
 	}
 
 	// Invoke OnInsert callback
@@ -1443,7 +1771,7 @@ func (e *Executor) insertSingleRow(cat *Catalog, dbName string, table TableSchem
 // checkUniqueConstraintsWithConflict checks unique constraints and returns the conflicting row key.
 func (e *Executor) checkUniqueConstraintsWithConflict(cat *Catalog, table TableSchema, values []string, excludeRowKey string) (string, error) {
 	prefix := "row:" + table.Name + ":"
-	rows, err := cat.store.Scan(prefix)
+	rows, err := e.scan(cat.store, prefix)
 	if err != nil {
 		return "", err
 	}
@@ -1504,6 +1832,15 @@ func (e *Executor) updateConflictingRow(cat *Catalog, table TableSchema, rowKey
 		return err
 	}
 
+	// Move the row if the update changed its partition
+	newKey, err := e.partitionedRowKey(table, rowKey, row)
+	if err != nil {
+		return err
+	}
+	if newKey != rowKey {
+		return moveRowData(cat.store, rowKey, newKey, data)
+	}
+
 	return cat.store.Put(rowKey, data)
 }
 
@@ -1519,7 +1856,7 @@ func parseIntValue(value string) (int64, error) {
 func (e *Executor) checkUniqueConstraints(cat *Catalog, table TableSchema, values []string, excludeRowKey string) error {
 	// Get all existing rows
 	prefix := "row:" + table.Name + ":"
-	rows, err := cat.store.Scan(prefix)
+	rows, err := e.scan(cat.store, prefix)
 	if err != nil {
 		return err
 	}
@@ -1651,7 +1988,7 @@ func (e *Executor) checkForeignKeyConstraints(cat *Catalog, table TableSchema, v
 		// Scan the referenced table for the value
 		// Use the correct store from the catalog (which wraps the store)
 		prefix := "row:" + fk.RefTable + ":"
-		rows, err := cat.store.Scan(prefix)
+		rows, err := e.scan(cat.store, prefix)
 		if err != nil {
 			return err
 		}
@@ -1832,9 +2169,8 @@ func (e *Executor) executeUpdate(stmt *UpdateStmt) (string, error) {
 		return "", ferrors.NewExecutionError("BEFORE UPDATE trigger failed").WithCause(err)
 	}
 
-	// Scan all rows in the table.
-	prefix := "row:" + stmt.TableName + ":"
-	rows, err := cat.store.Scan(prefix)
+	// Scan the rows in the table, skipping partitions the WHERE rules out.
+	rows, err := e.scanTableRows(cat, stmt.TableName, conditionWhere(stmt.Where))
 	if err != nil {
 		return "", err
 	}
@@ -1927,13 +2263,27 @@ func (e *Executor) executeUpdate(stmt *UpdateStmt) (string, error) {
 			return "", err
 		}
 
-		// Save the updated row.
+		// Save the updated row, moving it if its partition changed.
+		newKey, err := e.partitionedRowKey(table, key, row)
+		if err != nil {
+			return "", err
+		}
 		newData, _ := json.Marshal(row)
-		cat.store.Put(key, newData)
+		if newKey != key {
+			if err := moveRowData(cat.store, key, newKey, newData); err != nil {
+				return "", err
+			}
+			if cat.IndexMgr != nil {
+				e.indexUpdates(cat).OnDelete(stmt.TableName, key, oldRow)
+				e.indexUpdates(cat).OnInsert(stmt.TableName, newKey, row)
+			}
+		} else {
+			cat.store.Put(key, newData)
 
-		// Update indexes
-		if cat.IndexMgr != nil {
-			cat.IndexMgr.OnUpdate(stmt.TableName, key, oldRow, row)
+			// Update indexes
+			if cat.IndexMgr != nil {
+				e.indexUpdates(cat).OnUpdate(stmt.TableName, key, oldRow, row)
+			}
 		}
 
 		// Invoke OnUpdate callback for WATCH functionality
@@ -1950,6 +2300,9 @@ func (e *Executor) executeUpdate(stmt *UpdateStmt) (string, error) {
 		return "", ferrors.NewExecutionError("AFTER UPDATE trigger failed").WithCause(err)
 	}
 
+	// Refresh statistics once enough rows have changed
+	e.recordChanges(cat, stmt.TableName, count)
+
 	// Invalidate cache for this table since data has changed
 	if e.queryCache != nil {
 		e.queryCache.Invalidate(stmt.TableName)
@@ -2001,9 +2354,8 @@ func (e *Executor) executeDelete(stmt *DeleteStmt) (string, error) {
 		return "", ferrors.NewExecutionError("BEFORE DELETE trigger failed").WithCause(err)
 	}
 
-	// Scan all rows in the table.
-	prefix := "row:" + stmt.TableName + ":"
-	rows, err := cat.store.Scan(prefix)
+	// Scan the rows in the table, skipping partitions the WHERE rules out.
+	rows, err := e.scanTableRows(cat, stmt.TableName, conditionWhere(stmt.Where))
 	if err != nil {
 		return "", err
 	}
@@ -2033,24 +2385,9 @@ func (e *Executor) executeDelete(stmt *DeleteStmt) (string, error) {
 			}
 		}
 
-		// Check for foreign key references from other tables
-		if err := e.handleForeignKeyReferencesOnDelete(cat, stmt.TableName, row); err != nil {
+		if err := e.deleteRow(cat, stmt.TableName, key, row); err != nil {
 			return "", err
 		}
-
-		// Update indexes before deleting
-		if cat.IndexMgr != nil {
-			cat.IndexMgr.OnDelete(stmt.TableName, key, row)
-		}
-
-		// Invoke OnDelete callback for WATCH functionality
-		if e.OnDelete != nil {
-			rowData, _ := json.Marshal(row)
-			e.OnDelete(stmt.TableName, string(rowData))
-		}
-
-		// Delete the row from storage.
-		cat.store.Delete(key)
 		count++
 	}
 
@@ -2059,6 +2396,9 @@ func (e *Executor) executeDelete(stmt *DeleteStmt) (string, error) {
 		return "", ferrors.NewExecutionError("AFTER DELETE trigger failed").WithCause(err)
 	}
 
+	// Refresh statistics once enough rows have changed
+	e.recordChanges(cat, stmt.TableName, count)
+
 	// Invalidate cache for this table since data has changed
 	if e.queryCache != nil {
 		e.queryCache.Invalidate(stmt.TableName)
@@ -2067,6 +2407,30 @@ func (e *Executor) executeDelete(stmt *DeleteStmt) (string, error) {
 	return fmt.Sprintf("DELETE %d", count), nil
 }
 
+// deleteRow deletes a single row after applying the foreign key actions of
+// referencing tables, and keeps indexes and WATCH subscribers up to date.
+func (e *Executor) deleteRow(cat *Catalog, tableName, key string, row map[string]interface{}) error {
+	// Check for foreign key references from other tables
+	if err := e.handleForeignKeyReferencesOnDelete(cat, tableName, row); err != nil {
+		return err
+	}
+
+	// Update indexes before deleting
+	if cat.IndexMgr != nil {
+		e.indexUpdates(cat).OnDelete(tableName, key, row)
+	}
+
+	// Invoke OnDelete callback for WATCH functionality
+	if e.OnDelete != nil {
+		rowData, _ := json.Marshal(row)
+		e.OnDelete(tableName, string(rowData))
+	}
+
+	// Delete the row from storage.
+	cat.store.Delete(key)
+	return nil
+}
+
 // handleForeignKeyReferencesOnDelete handles foreign key references when a row is deleted.
 // It applies the appropriate referential action (RESTRICT, CASCADE, SET NULL) based on the FK definition.
 // Returns an error if the operation should be prevented (RESTRICT/NO ACTION with dependent rows).
@@ -2133,7 +2497,7 @@ func (e *Executor) handleForeignKeyReferencesOnDelete(cat *Catalog, tableName st
 					}
 					// Update indexes
 					if cat.IndexMgr != nil {
-						cat.IndexMgr.OnDelete(tblName, key, refRow)
+						e.indexUpdates(cat).OnDelete(tblName, key, refRow)
 					}
 					// Invoke OnDelete callback
 					if e.OnDelete != nil {
@@ -2160,7 +2524,7 @@ func (e *Executor) handleForeignKeyReferencesOnDelete(cat *Catalog, tableName st
 					}
 					// Update indexes
 					if cat.IndexMgr != nil {
-						cat.IndexMgr.OnUpdate(tblName, key, oldRow, refRow)
+						e.indexUpdates(cat).OnUpdate(tblName, key, oldRow, refRow)
 					}
 					// Invoke OnUpdate callback
 					if e.OnUpdate != nil {
@@ -2194,7 +2558,7 @@ func (e *Executor) handleForeignKeyReferencesOnDelete(cat *Catalog, tableName st
 					}
 					// Update indexes
 					if cat.IndexMgr != nil {
-						cat.IndexMgr.OnUpdate(tblName, key, oldRow, refRow)
+						e.indexUpdates(cat).OnUpdate(tblName, key, oldRow, refRow)
 					}
 				}
 			}
@@ -2311,7 +2675,7 @@ func (e *Executor) handleForeignKeyReferencesOnUpdate(cat *Catalog, tableName st
 					}
 					// Update indexes
 					if cat.IndexMgr != nil {
-						cat.IndexMgr.OnUpdate(tblName, key, oldRefRow, refRow)
+						e.indexUpdates(cat).OnUpdate(tblName, key, oldRefRow, refRow)
 					}
 					// Invoke OnUpdate callback
 					if e.OnUpdate != nil {
@@ -2338,7 +2702,7 @@ func (e *Executor) handleForeignKeyReferencesOnUpdate(cat *Catalog, tableName st
 					}
 					// Update indexes
 					if cat.IndexMgr != nil {
-						cat.IndexMgr.OnUpdate(tblName, key, oldRefRow, refRow)
+						e.indexUpdates(cat).OnUpdate(tblName, key, oldRefRow, refRow)
 					}
 
 				case ReferentialActionSetDefault:
@@ -2367,7 +2731,7 @@ func (e *Executor) handleForeignKeyReferencesOnUpdate(cat *Catalog, tableName st
 					}
 					// Update indexes
 					if cat.IndexMgr != nil {
-						cat.IndexMgr.OnUpdate(tblName, key, oldRefRow, refRow)
+						e.indexUpdates(cat).OnUpdate(tblName, key, oldRefRow, refRow)
 					}
 				}
 			}
@@ -2489,8 +2853,11 @@ func (e *Executor) executeSelect(stmt *SelectStmt) (string, error) {
 	// Generate cache key from the query and user context.
 	// Cache key includes: table name, columns, where clause, order by, limit, offset, user.
 	// We only cache simple queries (no JOINs, no aggregates, no subqueries in WHERE).
+	// Distributed queries are not cached since writes on other nodes do not
+	// invalidate the cache. Structured results bypass the cache because they
+	// need the projected values of each row.
 	cacheKey := e.generateSelectCacheKey(stmt)
-	canCache := cacheKey != "" && e.queryCache != nil && stmt.Join == nil && len(stmt.Aggregates) == 0
+	canCache := cacheKey != "" && e.queryCache != nil && stmt.Join == nil && len(stmt.Aggregates) == 0 && e.distributed == nil && e.rowValues == nil && e.rowStream == nil
 
 	// Try to get result from cache
 	if canCache {
@@ -2536,12 +2903,18 @@ func (e *Executor) executeSelect(stmt *SelectStmt) (string, error) {
 		}
 	}
 
+	// In a cluster, push the query down to the partition owners so that
+	// only filtered rows and partial aggregates cross the network.
+	if e.canDistribute(cat, stmt) {
+		return e.executeDistributedSelect(stmt, rls)
+	}
+
 	// Try to use an index for the WHERE clause if available.
 	// This provides O(log N) lookup instead of O(N) full table scan.
 	var rows map[string][]byte
 	// var err error // Removed redeclaration
 
-	if stmt.Where != nil && cat.IndexMgr != nil && cat.IndexMgr.HasIndex(stmt.TableName, stmt.Where.Column) {
+	if e.canUseIndex(cat, stmt) {
 		// Use index lookup for O(log N) performance
 		rowKey, found := cat.IndexMgr.Lookup(stmt.TableName, stmt.Where.Column, stmt.Where.Value)
 		if found {
@@ -2554,14 +2927,28 @@ func (e *Executor) executeSelect(stmt *SelectStmt) (string, error) {
 			rows = make(map[string][]byte) // Empty result
 		}
 	} else {
-		// Fall back to full table scan
-		prefix := "row:" + stmt.TableName + ":"
-		rows, err = cat.store.Scan(prefix)
+		// Fall back to a table scan, pruning partitions when the WHERE
+		// clause only refers to this table
+		var where *WhereClause
+		if stmt.Join == nil {
+			where = stmt.WhereExt
+			if where == nil {
+				where = conditionWhere(stmt.Where)
+			}
+		}
+		rows, err = e.scanTableRows(cat, stmt.TableName, where)
 		if err != nil {
 			return "", err
 		}
 	}
 
+	// Stream the rows as they are projected when the caller asked for it.
+	// The table has been read, so later rows see the same data as the first.
+	stream, err := e.startStream(stmt)
+	if err != nil {
+		return "", err
+	}
+
 	var result []string
 
 	// If there's a JOIN, load the join table data.
@@ -2579,14 +2966,21 @@ func (e *Executor) executeSelect(stmt *SelectStmt) (string, error) {
 			return "", ferrors.TableNotFound(stmt.Join.TableName)
 		}
 		p := "row:" + stmt.Join.TableName + ":"
-		joinRows, err = joinCat.store.Scan(p)
+		joinRows, err = e.scan(joinCat.store, p)
 		if err != nil {
 			return "", err
 		}
 	}
 
 	// Process each row from the primary table.
+	processed := 0
 	for _, val := range rows {
+		if processed++; processed%canceledCheckInterval == 0 || e.rowErr != nil {
+			if err := e.checkCanceled(); err != nil {
+				return "", err
+			}
+		}
+
 		var row map[string]interface{}
 		json.Unmarshal(val, &row)
 
@@ -2599,55 +2993,13 @@ func (e *Executor) executeSelect(stmt *SelectStmt) (string, error) {
 		}
 
 		if stmt.Join != nil {
-			// Handle different join types
-			matched := false
-
-			// Nested Loop Join: iterate through all rows in the join table.
-			for _, jVal := range joinRows {
-				var jRow map[string]interface{}
-				json.Unmarshal(jVal, &jRow)
-
-				// Combine columns from both tables.
-				combinedRow := make(map[string]interface{})
-				for k, v := range flatRow {
-					combinedRow[k] = v
-				}
-				for k, v := range jRow {
-					combinedRow[k] = v
-					combinedRow[stmt.Join.TableName+"."+k] = v
-				}
-
-				// Check the JOIN ON condition.
-				leftVal, ok1 := combinedRow[stmt.Join.On.Column]
-				rightVal, ok2 := combinedRow[stmt.Join.On.Value]
-
-				// If the right side isn't a column, treat it as a literal.
-				if !ok2 {
-					rightVal = stmt.Join.On.Value
-				}
-
-				// If the values match, process the combined row.
-				if ok1 && fmt.Sprintf("%v", leftVal) == fmt.Sprintf("%v", rightVal) {
-					e.processRow(combinedRow, stmt, &result, rls)
-					matched = true
-				}
+			e.joinRow(cat, stmt, flatRow, joinRows, &result, rls)
+		} else if stream {
+			if _, values, ok := e.projectRow(flatRow, stmt, rls); ok {
+				e.streamRow(values)
 			}
-
-			// For LEFT JOIN: if no match found, include left row with NULLs for right table
-			if !matched && (stmt.Join.JoinType == JoinTypeLeft || stmt.Join.JoinType == JoinTypeFull) {
-				// Create a row with NULLs for the join table columns
-				combinedRow := make(map[string]interface{})
-				for k, v := range flatRow {
-					combinedRow[k] = v
-				}
-				// Add NULL values for join table columns
-				if joinTable, ok := cat.GetTable(stmt.Join.TableName); ok {
-					for _, col := range joinTable.Columns {
-						combinedRow[col.Name] = "NULL"
-						combinedRow[stmt.Join.TableName+"."+col.Name] = "NULL"
-					}
-				}
-				e.processRow(combinedRow, stmt, &result, rls)
+			if e.streamFull() {
+				break
 			}
 		} else {
 			// No JOIN - process the row directly.
@@ -2704,45 +3056,120 @@ func (e *Executor) executeSelect(stmt *SelectStmt) (string, error) {
 		}
 	}
 
-	// Apply ORDER BY if present.
-	// Note: This is a simplified implementation that sorts the formatted
-	// result strings. A production implementation would sort the row
-	// structs before formatting.
-	if stmt.OrderBy != nil {
-		col := stmt.OrderBy.Column
-		desc := stmt.OrderBy.Direction == "DESC"
-
-		sort.Slice(result, func(i, j int) bool {
-			// Suppress unused variable warning.
-			// In a real implementation, we would extract the column value
-			// from each row and compare those values.
-			_ = col
-
-			// Extract the first value from each CSV row for comparison.
-			v1 := strings.TrimSpace(result[i])
-			v2 := strings.TrimSpace(result[j])
+	result = e.sortDistinctRows(stmt, result)
 
-			// Try to compare as integers.
-			var i1, i2 int
-			_, err1 := fmt.Sscanf(v1, "%d", &i1)
-			_, err2 := fmt.Sscanf(v2, "%d", &i2)
+	// If there are aggregate functions, compute them
+	if len(stmt.Aggregates) > 0 {
+		// If GROUP BY is present, compute aggregates per group
+		if len(stmt.GroupBy) > 0 {
+			return e.computeGroupedAggregates(cat, stmt, rows, rls)
+		}
+		return e.computeAggregates(cat, stmt, rows, rls)
+	}
 
-			if err1 == nil && err2 == nil {
-				if desc {
-					return i1 > i2
-				}
-				return i1 < i2
-			}
+	finalResult := e.formatSelectResult(stmt, result)
 
-			// Fallback to string comparison.
-			if desc {
-				return v1 > v2
-			}
-			return v1 < v2
-		})
+	// Cache the result for future queries
+	if canCache && e.queryCache != nil {
+		// Collect tables referenced by this query for cache invalidation
+		tables := []string{stmt.TableName}
+		e.queryCache.Set(cacheKey, finalResult, tables)
 	}
 
-	// Apply DISTINCT if present - remove duplicate rows
+	return finalResult, nil
+}
+
+// joinRow joins one flattened row of the primary table with the rows of
+// the joined table using a nested loop, and processes every combined row.
+// Without a match, a LEFT or FULL join processes the row with NULLs for
+// the joined table's columns.
+func (e *Executor) joinRow(cat *Catalog, stmt *SelectStmt, flatRow map[string]interface{}, joinRows map[string][]byte, result *[]string, rls *Condition) {
+	// Handle different join types
+	matched := false
+
+	// Nested Loop Join: iterate through all rows in the join table.
+	for _, jVal := range joinRows {
+		var jRow map[string]interface{}
+		json.Unmarshal(jVal, &jRow)
+
+		// Combine columns from both tables.
+		combinedRow := make(map[string]interface{})
+		for k, v := range flatRow {
+			combinedRow[k] = v
+		}
+		for k, v := range jRow {
+			combinedRow[k] = v
+			combinedRow[stmt.Join.TableName+"."+k] = v
+		}
+
+		// Check the JOIN ON condition.
+		leftVal, ok1 := combinedRow[stmt.Join.On.Column]
+		rightVal, ok2 := combinedRow[stmt.Join.On.Value]
+
+		// If the right side isn't a column, treat it as a literal.
+		if !ok2 {
+			rightVal = stmt.Join.On.Value
+		}
+
+		// If the values match, process the combined row.
+		if ok1 && fmt.Sprintf("%v", leftVal) == fmt.Sprintf("%v", rightVal) {
+			e.processRow(combinedRow, stmt, result, rls)
+			matched = true
+		}
+	}
+
+	// For LEFT JOIN: if no match found, include left row with NULLs for right table
+	if !matched && (stmt.Join.JoinType == JoinTypeLeft || stmt.Join.JoinType == JoinTypeFull) {
+		// Create a row with NULLs for the join table columns
+		combinedRow := make(map[string]interface{})
+		for k, v := range flatRow {
+			combinedRow[k] = v
+		}
+		// Add NULL values for join table columns
+		if joinTable, ok := cat.GetTable(stmt.Join.TableName); ok {
+			for _, col := range joinTable.Columns {
+				combinedRow[col.Name] = "NULL"
+				combinedRow[stmt.Join.TableName+"."+col.Name] = "NULL"
+			}
+		}
+		e.processRow(combinedRow, stmt, result, rls)
+	}
+}
+
+// sortDistinctRows applies ORDER BY and DISTINCT to formatted result rows.
+// Note: This is a simplified implementation that sorts the formatted
+// result strings by their first value. A production implementation would
+// sort the row structs before formatting.
+func (e *Executor) sortDistinctRows(stmt *SelectStmt, result []string) []string {
+	if stmt.OrderBy != nil {
+		desc := stmt.OrderBy.Direction == "DESC"
+
+		sort.Slice(result, func(i, j int) bool {
+			// Extract the first value from each CSV row for comparison.
+			v1 := strings.TrimSpace(result[i])
+			v2 := strings.TrimSpace(result[j])
+
+			// Try to compare as integers.
+			var i1, i2 int
+			_, err1 := fmt.Sscanf(v1, "%d", &i1)
+			_, err2 := fmt.Sscanf(v2, "%d", &i2)
+
+			if err1 == nil && err2 == nil {
+				if desc {
+					return i1 > i2
+				}
+				return i1 < i2
+			}
+
+			// Fallback to string comparison.
+			if desc {
+				return v1 > v2
+			}
+			return v1 < v2
+		})
+	}
+
+	// Apply DISTINCT if present - remove duplicate rows
 	if stmt.Distinct {
 		seen := make(map[string]bool)
 		uniqueResult := make([]string, 0, len(result))
@@ -2754,7 +3181,12 @@ func (e *Executor) executeSelect(stmt *SelectStmt) (string, error) {
 		}
 		result = uniqueResult
 	}
+	return result
+}
 
+// formatSelectResult applies OFFSET and LIMIT to sorted result rows and
+// formats them with a header row and a row count summary line.
+func (e *Executor) formatSelectResult(stmt *SelectStmt, result []string) string {
 	// Apply OFFSET if present.
 	if stmt.Offset > 0 && len(result) > stmt.Offset {
 		result = result[stmt.Offset:]
@@ -2767,20 +3199,36 @@ func (e *Executor) executeSelect(stmt *SelectStmt) (string, error) {
 		result = result[:stmt.Limit]
 	}
 
-	// If there are aggregate functions, compute them
-	if len(stmt.Aggregates) > 0 {
-		// If GROUP BY is present, compute aggregates per group
-		if len(stmt.GroupBy) > 0 {
-			return e.computeGroupedAggregates(cat, stmt, rows, rls)
-		}
-		return e.computeAggregates(cat, stmt, rows, rls)
-	}
-
 	// Build the result with row count information.
 	// Format: header row, data rows, then a summary line with row count.
 	rowCount := len(result)
 
-	// Build header row from column names and function expressions
+	header := strings.Join(selectHeaders(stmt), ", ")
+
+	if rowCount == 0 {
+		return fmt.Sprintf("%s\n(0 rows)", header)
+	}
+	return fmt.Sprintf("%s\n%s\n(%d rows)", header, strings.Join(result, "\n"), rowCount)
+}
+
+// selectHeaders returns the result column names of a SELECT: its columns
+// and scalar functions, or for aggregate queries its GROUP BY columns and
+// aggregate expressions.
+func selectHeaders(stmt *SelectStmt) []string {
+	if len(stmt.Aggregates) > 0 {
+		headers := append([]string{}, stmt.GroupBy...)
+		for _, agg := range stmt.Aggregates {
+			if agg.Alias != "" {
+				headers = append(headers, agg.Alias)
+			} else if agg.Column == "*" {
+				headers = append(headers, strings.ToLower(agg.Function))
+			} else {
+				headers = append(headers, fmt.Sprintf("%s(%s)", strings.ToLower(agg.Function), agg.Column))
+			}
+		}
+		return headers
+	}
+
 	headers := make([]string, 0, len(stmt.Columns)+len(stmt.Functions))
 	headers = append(headers, stmt.Columns...)
 	for _, fn := range stmt.Functions {
@@ -2791,30 +3239,14 @@ func (e *Executor) executeSelect(stmt *SelectStmt) (string, error) {
 			headers = append(headers, fmt.Sprintf("%s(%s)", strings.ToLower(fn.Function), strings.Join(fn.Arguments, ", ")))
 		}
 	}
-	header := strings.Join(headers, ", ")
-
-	var finalResult string
-	if rowCount == 0 {
-		finalResult = fmt.Sprintf("%s\n(0 rows)", header)
-	} else {
-		finalResult = fmt.Sprintf("%s\n%s\n(%d rows)", header, strings.Join(result, "\n"), rowCount)
-	}
-
-	// Cache the result for future queries
-	if canCache && e.queryCache != nil {
-		// Collect tables referenced by this query for cache invalidation
-		tables := []string{stmt.TableName}
-		e.queryCache.Set(cacheKey, finalResult, tables)
-	}
-
-	return finalResult, nil
+	return headers
 }
 
 // generateSelectCacheKey generates a cache key for a SELECT statement.
 // Returns empty string if the query should not be cached (e.g., has subqueries).
 func (e *Executor) generateSelectCacheKey(stmt *SelectStmt) string {
 	// Don't cache queries with subqueries in WHERE clause
-	if stmt.WhereExt != nil && stmt.WhereExt.IsSubquery {
+	if whereHasSubquery(stmt.WhereExt) {
 		return ""
 	}
 
@@ -2832,8 +3264,18 @@ func (e *Executor) generateSelectCacheKey(stmt *SelectStmt) string {
 		parts = append(parts, fmt.Sprintf("WHERE:%s=%s", stmt.Where.Column, stmt.Where.Value))
 	}
 
-	if stmt.WhereExt != nil {
-		parts = append(parts, fmt.Sprintf("WHERE_EXT:%s%s%s", stmt.WhereExt.Column, stmt.WhereExt.Operator, stmt.WhereExt.Value))
+	// Every chained condition is part of the key
+	for cond := stmt.WhereExt; cond != nil; {
+		parts = append(parts, fmt.Sprintf("WHERE_EXT:%s%s%s%q%s:%s", cond.Column, cond.Operator, cond.Value, cond.Values, cond.BetweenLow, cond.BetweenHigh))
+		if cond.And != nil {
+			parts = append(parts, "AND")
+			cond = cond.And
+		} else {
+			if cond.Or != nil {
+				parts = append(parts, "OR")
+			}
+			cond = cond.Or
+		}
 	}
 
 	if stmt.OrderBy != nil {
@@ -3117,19 +3559,29 @@ func formatUnionResultWithHeader(header string, rows []string) string {
 //   - result: Pointer to the result slice to append to
 //   - rls: Optional RLS condition to apply
 func (e *Executor) processRow(row map[string]interface{}, stmt *SelectStmt, result *[]string, rls *Condition) {
+	if parts, values, ok := e.projectRow(row, stmt, rls); ok {
+		// Add the formatted row to the result.
+		*result = append(*result, e.formatRow(parts, values))
+	}
+}
+
+// projectRow filters a row by the WHERE clause and RLS condition of stmt
+// and returns the formatted and raw values of its selected columns and
+// scalar functions.
+func (e *Executor) projectRow(row map[string]interface{}, stmt *SelectStmt, rls *Condition) ([]string, []interface{}, bool) {
 	// Apply extended WHERE filter with subquery support.
 	if stmt.WhereExt != nil {
 		if !e.evaluateWhereClause(stmt.WhereExt, row) {
-			return
+			return nil, nil, false
 		}
 	} else if stmt.Where != nil {
 		// Fallback to simple WHERE for backward compatibility
 		colVal, exists := row[stmt.Where.Column]
 		if !exists {
-			return
+			return nil, nil, false
 		}
 		if fmt.Sprintf("%v", colVal) != stmt.Where.Value {
-			return
+			return nil, nil, false
 		}
 	}
 
@@ -3137,30 +3589,64 @@ func (e *Executor) processRow(row map[string]interface{}, stmt *SelectStmt, resu
 	if rls != nil {
 		colVal, exists := row[rls.Column]
 		if !exists {
-			return
+			return nil, nil, false
 		}
 		if fmt.Sprintf("%v", colVal) != rls.Value {
-			return
+			return nil, nil, false
 		}
 	}
 
 	// Project the requested columns.
 	// Build a CSV row with the values of the selected columns.
 	var outRow []string
+	values := make([]interface{}, 0, len(stmt.Columns)+len(stmt.Functions))
 	for _, col := range stmt.Columns {
-		if v, ok := row[col]; ok {
+		v, ok := row[col]
+		if ok {
 			outRow = append(outRow, fmt.Sprintf("%v", v))
 		}
+		values = append(values, nullableValue(v))
 	}
 
 	// Evaluate scalar functions and add their results
 	for _, fn := range stmt.Functions {
 		result := e.evaluateScalarFunction(fn, row)
 		outRow = append(outRow, result)
+		values = append(values, nullableValue(result))
+	}
+
+	return outRow, values, true
+}
+
+// formatRow joins projected values into a result row, recording the row's
+// values when a structured result is being built.
+func (e *Executor) formatRow(parts []string, values []interface{}) string {
+	row := strings.Join(parts, ", ")
+	e.recordRowValues(row, values)
+	return row
+}
+
+// recordRowValues records the values of a formatted result row when a
+// structured result is being built. Rows that format alike are queued.
+// The row is charged to the row budget first.
+func (e *Executor) recordRowValues(row string, values []interface{}) {
+	if e.rowBudget != nil && e.rowErr == nil {
+		e.rowErr = e.rowBudget(values)
+	}
+	if e.rowValues != nil {
+		e.rowValues[row] = append(e.rowValues[row], values)
 	}
+}
 
-	// Add the formatted row to the result.
-	*result = append(*result, strings.Join(outRow, ", "))
+// takeRowValues returns the values recorded for the next formatted result
+// row equal to row.
+func (e *Executor) takeRowValues(row string) ([]interface{}, bool) {
+	queue := e.rowValues[row]
+	if len(queue) == 0 {
+		return nil, false
+	}
+	e.rowValues[row] = queue[1:]
+	return queue[0], true
 }
 
 // evaluateScalarFunction evaluates a scalar function against a row.
@@ -3649,9 +4135,48 @@ func (e *Executor) evaluateScalarFunction(fn *FunctionExpr, row map[string]inter
 	return "NULL"
 }
 
-// evaluateWhereClause evaluates an extended WHERE clause against a row.
-// It supports subqueries with IN, NOT IN, EXISTS, IS NULL, and IS NOT NULL operators.
+// evaluateWhereClause evaluates an extended WHERE clause, including its
+// chained AND/OR conditions, against a row. AND binds tighter than OR.
 func (e *Executor) evaluateWhereClause(where *WhereClause, row map[string]interface{}) bool {
+	for _, group := range whereOrGroups(where) {
+		matched := true
+		for _, cond := range group {
+			if !e.evaluateWhereCondition(cond, row) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// whereOrGroups splits the flat AND/OR chain built by parseCompoundCondition
+// into the OR of groups of AND-ed conditions, so that
+// "a AND b OR c" means "(a AND b) OR c" as in standard SQL.
+func whereOrGroups(where *WhereClause) [][]*WhereClause {
+	var groups [][]*WhereClause
+	var group []*WhereClause
+	for cond := where; cond != nil; {
+		group = append(group, cond)
+		if cond.And != nil {
+			cond = cond.And
+			continue
+		}
+		if cond.Or != nil {
+			groups = append(groups, group)
+			group = nil
+		}
+		cond = cond.Or
+	}
+	return append(groups, group)
+}
+
+// evaluateWhereCondition evaluates a single WHERE condition against a row.
+// It supports subqueries with IN, NOT IN, EXISTS, IS NULL, and IS NOT NULL operators.
+func (e *Executor) evaluateWhereCondition(where *WhereClause, row map[string]interface{}) bool {
 	// Handle EXISTS operator
 	if where.Operator == "EXISTS" {
 		if where.Subquery != nil {
@@ -3849,10 +4374,10 @@ func compareValues(a, b string) int {
 
 // compareValuesWithCollator compares two values using the provided collator for strings.
 func compareValuesWithCollator(a, b string, collator storage.Collator) int {
-	// Try to compare as integers
-	var ai, bi int
-	_, err1 := fmt.Sscanf(a, "%d", &ai)
-	_, err2 := fmt.Sscanf(b, "%d", &bi)
+	// Try to compare as integers. The whole value must parse, so that
+	// dates such as 2024-01-15 compare as strings rather than as 2024.
+	ai, err1 := strconv.ParseInt(a, 10, 64)
+	bi, err2 := strconv.ParseInt(b, 10, 64)
 	if err1 == nil && err2 == nil {
 		if ai < bi {
 			return -1
@@ -3863,9 +4388,8 @@ func compareValuesWithCollator(a, b string, collator storage.Collator) int {
 	}
 
 	// Try to compare as floats
-	var af, bf float64
-	_, err1 = fmt.Sscanf(a, "%f", &af)
-	_, err2 = fmt.Sscanf(b, "%f", &bf)
+	af, err1 := strconv.ParseFloat(a, 64)
+	bf, err2 := strconv.ParseFloat(b, 64)
 	if err1 == nil && err2 == nil {
 		if af < bf {
 			return -1
@@ -4138,6 +4662,65 @@ func (e *Executor) SetTransaction(tx *storage.Transaction) {
 	e.tx = tx
 }
 
+// WithTransaction returns a copy of the executor whose statements read and
+// write the executor's database through tx, so their changes only reach
+// storage when tx commits. Schemas and indexes stay shared with the
+// executor; index changes are applied when tx commits, and the query cache
+// is bypassed, so other sessions never see uncommitted rows.
+func (e *Executor) WithTransaction(tx *storage.Transaction) *Executor {
+	ephemeral := *e
+	ephemeral.tx = tx
+	ephemeral.store = tx
+	ephemeral.queryCache = nil
+	ephemeral.catalogs = make(map[string]*Catalog, len(e.catalogs))
+	for name, cat := range e.catalogs {
+		if cat.store != e.store {
+			ephemeral.catalogs[name] = cat
+			continue
+		}
+		view := *cat
+		view.store = tx
+		ephemeral.catalogs[name] = &view
+		if cat == e.catalog {
+			ephemeral.catalog = &view
+		}
+	}
+	if ephemeral.catalog == e.catalog && e.catalog != nil {
+		view := *e.catalog
+		view.store = tx
+		ephemeral.catalog = &view
+	}
+	return &ephemeral
+}
+
+// inStatementBatch runs a data-changing statement so that its writes reach
+// storage as one batch. When the store replicates through consensus, each
+// key written on its own would cost a round trip to a quorum; run through a
+// transaction, the statement's writes are proposed as a single entry when it
+// completes, and apply atomically on every node. Inside a transaction, or on
+// a store that writes locally, run executes directly.
+func (e *Executor) inStatementBatch(run func(*Executor) (string, error)) (string, error) {
+	if e.tx != nil || !storage.ProposesWrites(e.store) {
+		return run(e)
+	}
+
+	tx := storage.NewTransaction(e.store)
+	batch := e.WithTransaction(tx)
+	batch.batchStore = e.store
+	result, err := run(batch)
+	if err == nil {
+		err = tx.Commit()
+	} else {
+		tx.Rollback()
+	}
+	if err != nil {
+		return "", err
+	}
+	// Cached results were computed without the statement's writes
+	e.InvalidateAllCache()
+	return result, nil
+}
+
 // executePrepare handles PREPARE statements.
 // It compiles the query and stores it for later execution.
 //
@@ -4195,163 +4778,7 @@ func (e *Executor) GetPreparedStatementManager() *PreparedStatementManager {
 //
 // Returns a single row with the aggregate results.
 func (e *Executor) computeAggregates(cat *Catalog, stmt *SelectStmt, rows map[string][]byte, rls *Condition) (string, error) {
-	// Initialize aggregate accumulators
-	states := make(map[int]*aggState)
-	for i := range stmt.Aggregates {
-		states[i] = &aggState{}
-	}
-
-	// Process each row
-	for _, val := range rows {
-		var row map[string]interface{}
-		if err := json.Unmarshal(val, &row); err != nil {
-			continue
-		}
-
-		// Apply WHERE filter
-		if stmt.Where != nil {
-			colVal, exists := row[stmt.Where.Column]
-			if !exists {
-				continue
-			}
-			if fmt.Sprintf("%v", colVal) != stmt.Where.Value {
-				continue
-			}
-		}
-
-		// Apply RLS filter
-		if rls != nil {
-			colVal, exists := row[rls.Column]
-			if !exists {
-				continue
-			}
-			if fmt.Sprintf("%v", colVal) != rls.Value {
-				continue
-			}
-		}
-
-		// Update each aggregate
-		for i, agg := range stmt.Aggregates {
-			state := states[i]
-
-			if agg.Function == "COUNT" {
-				if agg.Column == "*" {
-					state.count++
-				} else if _, exists := row[agg.Column]; exists {
-					state.count++
-				}
-				continue
-			}
-
-			// For SUM, AVG, MIN, MAX we need the column value
-			colVal, exists := row[agg.Column]
-			if !exists {
-				continue
-			}
-
-			// Try to parse as number
-			strVal := fmt.Sprintf("%v", colVal)
-			numVal, err := strconv.ParseFloat(strVal, 64)
-
-			switch agg.Function {
-			case "SUM":
-				if err == nil {
-					state.sum += numVal
-					state.count++
-				}
-			case "AVG":
-				if err == nil {
-					state.sum += numVal
-					state.count++
-				}
-			case "MIN":
-				if err == nil {
-					if state.min == nil || numVal < *state.min {
-						state.min = &numVal
-					}
-				} else {
-					if state.minStr == nil || strVal < *state.minStr {
-						state.minStr = &strVal
-					}
-				}
-				state.count++
-			case "MAX":
-				if err == nil {
-					if state.max == nil || numVal > *state.max {
-						state.max = &numVal
-					}
-				} else {
-					if state.maxStr == nil || strVal > *state.maxStr {
-						state.maxStr = &strVal
-					}
-				}
-				state.count++
-			case "GROUP_CONCAT", "STRING_AGG":
-				state.concatValues = append(state.concatValues, strVal)
-				state.count++
-			}
-		}
-	}
-
-	// Build header row from aggregate expressions
-	var headers []string
-	for _, agg := range stmt.Aggregates {
-		if agg.Alias != "" {
-			headers = append(headers, agg.Alias)
-		} else if agg.Column == "*" {
-			headers = append(headers, strings.ToLower(agg.Function))
-		} else {
-			headers = append(headers, fmt.Sprintf("%s(%s)", strings.ToLower(agg.Function), agg.Column))
-		}
-	}
-
-	// Build result row
-	var results []string
-	for i, agg := range stmt.Aggregates {
-		state := states[i]
-
-		switch agg.Function {
-		case "COUNT":
-			results = append(results, fmt.Sprintf("%d", state.count))
-		case "SUM":
-			results = append(results, fmt.Sprintf("%.2f", state.sum))
-		case "AVG":
-			if state.count > 0 {
-				results = append(results, fmt.Sprintf("%.2f", state.sum/float64(state.count)))
-			} else {
-				results = append(results, "NULL")
-			}
-		case "MIN":
-			if state.min != nil {
-				results = append(results, fmt.Sprintf("%.2f", *state.min))
-			} else if state.minStr != nil {
-				results = append(results, *state.minStr)
-			} else {
-				results = append(results, "NULL")
-			}
-		case "MAX":
-			if state.max != nil {
-				results = append(results, fmt.Sprintf("%.2f", *state.max))
-			} else if state.maxStr != nil {
-				results = append(results, *state.maxStr)
-			} else {
-				results = append(results, "NULL")
-			}
-		case "GROUP_CONCAT", "STRING_AGG":
-			if len(state.concatValues) > 0 {
-				sep := agg.Separator
-				if sep == "" {
-					sep = ","
-				}
-				results = append(results, strings.Join(state.concatValues, sep))
-			} else {
-				results = append(results, "NULL")
-			}
-		}
-	}
-
-	header := strings.Join(headers, ", ")
-	return fmt.Sprintf("%s\n%s\n(1 row)", header, strings.Join(results, ", ")), nil
+	return e.formatAggregates(stmt, e.accumulateAggregates(stmt, rows, rls)), nil
 }
 
 // computeGroupedAggregates computes aggregate function results grouped by specified columns.
@@ -4365,15 +4792,30 @@ func (e *Executor) computeAggregates(cat *Catalog, stmt *SelectStmt, rows map[st
 //
 // Returns multiple rows with group key columns and aggregate results.
 func (e *Executor) computeGroupedAggregates(cat *Catalog, stmt *SelectStmt, rows map[string][]byte, rls *Condition) (string, error) {
-	// Group rows by the GROUP BY columns
-	type groupData struct {
-		keyValues []string                 // Values of GROUP BY columns
-		rows      []map[string]interface{} // Rows in this group
+	return e.formatAggregates(stmt, e.accumulateAggregates(stmt, rows, rls)), nil
+}
+
+// aggGroup holds the aggregate accumulators of one GROUP BY group. A query
+// without GROUP BY accumulates into a single group with an empty key.
+type aggGroup struct {
+	keyValues []string          // Values of GROUP BY columns
+	states    map[int]*aggState // Accumulator per aggregate expression
+}
+
+// newAggGroup returns a group with empty accumulators for n aggregates.
+func newAggGroup(keyValues []string, n int) *aggGroup {
+	group := &aggGroup{keyValues: keyValues, states: make(map[int]*aggState, n)}
+	for i := 0; i < n; i++ {
+		group.states[i] = &aggState{}
 	}
+	return group
+}
 
-	groups := make(map[string]*groupData)
+// accumulateAggregates folds the matching rows into per-group aggregate
+// accumulators keyed by the joined GROUP BY values.
+func (e *Executor) accumulateAggregates(stmt *SelectStmt, rows map[string][]byte, rls *Condition) map[string]*aggGroup {
+	groups := make(map[string]*aggGroup)
 
-	// Process each row and assign to groups
 	for _, val := range rows {
 		var row map[string]interface{}
 		if err := json.Unmarshal(val, &row); err != nil {
@@ -4413,173 +4855,58 @@ func (e *Executor) computeGroupedAggregates(cat *Catalog, stmt *SelectStmt, rows
 		}
 		groupKey := strings.Join(keyParts, "|")
 
-		if groups[groupKey] == nil {
-			groups[groupKey] = &groupData{
-				keyValues: keyParts,
-				rows:      []map[string]interface{}{},
-			}
+		group := groups[groupKey]
+		if group == nil {
+			group = newAggGroup(keyParts, len(stmt.Aggregates))
+			groups[groupKey] = group
+		}
+		for i, agg := range stmt.Aggregates {
+			group.states[i].add(agg, row)
 		}
-		groups[groupKey].rows = append(groups[groupKey].rows, row)
 	}
+	return groups
+}
 
-	// Compute aggregates for each group
-	var resultRows []string
+// formatAggregates builds the result of an aggregate query from its
+// accumulators. Without GROUP BY it returns a single row; otherwise one row
+// per group that passes the HAVING filter.
+func (e *Executor) formatAggregates(stmt *SelectStmt, groups map[string]*aggGroup) string {
+	// Build header row: GROUP BY columns + aggregate expressions
+	header := strings.Join(selectHeaders(stmt), ", ")
+
+	if len(stmt.GroupBy) == 0 {
+		group := groups[""]
+		if group == nil {
+			group = newAggGroup(nil, len(stmt.Aggregates))
+		}
+		var results []string
+		for i, agg := range stmt.Aggregates {
+			results = append(results, group.states[i].format(agg))
+		}
+		return fmt.Sprintf("%s\n%s\n(1 row)", header, e.formatRow(results, nullableValues(results)))
+	}
 
+	var resultRows []string
 	for _, group := range groups {
-		// Initialize aggregate accumulators for this group
-		states := make(map[int]*aggState)
-		for i := range stmt.Aggregates {
-			states[i] = &aggState{}
-		}
-
-		// Process each row in the group
-		for _, row := range group.rows {
-			for i, agg := range stmt.Aggregates {
-				state := states[i]
-
-				if agg.Function == "COUNT" {
-					if agg.Column == "*" {
-						state.count++
-					} else if _, exists := row[agg.Column]; exists {
-						state.count++
-					}
-					continue
-				}
+		// Apply HAVING filter if present
+		if stmt.Having != nil && !e.evaluateHaving(stmt.Having, group.states, stmt.Aggregates) {
+			continue
+		}
 
-				// For SUM, AVG, MIN, MAX we need the column value
-				colVal, exists := row[agg.Column]
-				if !exists {
-					continue
-				}
+		// Build result row: group key columns + aggregate results
+		rowParts := append([]string{}, group.keyValues...)
+		for i, agg := range stmt.Aggregates {
+			rowParts = append(rowParts, group.states[i].format(agg))
+		}
+		resultRows = append(resultRows, e.formatRow(rowParts, nullableValues(rowParts)))
+	}
 
-				strVal := fmt.Sprintf("%v", colVal)
-				numVal, err := strconv.ParseFloat(strVal, 64)
-
-				switch agg.Function {
-				case "SUM":
-					if err == nil {
-						state.sum += numVal
-						state.count++
-					}
-				case "AVG":
-					if err == nil {
-						state.sum += numVal
-						state.count++
-					}
-				case "MIN":
-					if err == nil {
-						if state.min == nil || numVal < *state.min {
-							state.min = &numVal
-						}
-					} else {
-						if state.minStr == nil || strVal < *state.minStr {
-							state.minStr = &strVal
-						}
-					}
-					state.count++
-				case "MAX":
-					if err == nil {
-						if state.max == nil || numVal > *state.max {
-							state.max = &numVal
-						}
-					} else {
-						if state.maxStr == nil || strVal > *state.maxStr {
-							state.maxStr = &strVal
-						}
-					}
-					state.count++
-				case "GROUP_CONCAT", "STRING_AGG":
-					state.concatValues = append(state.concatValues, strVal)
-					state.count++
-				}
-			}
-		}
-
-		// Apply HAVING filter if present
-		if stmt.Having != nil {
-			havingPassed := e.evaluateHaving(stmt.Having, states, stmt.Aggregates)
-			if !havingPassed {
-				continue
-			}
-		}
-
-		// Build result row: group key columns + aggregate results
-		var rowParts []string
-
-		// Add GROUP BY column values
-		rowParts = append(rowParts, group.keyValues...)
-
-		// Add aggregate results
-		for i, agg := range stmt.Aggregates {
-			state := states[i]
-
-			switch agg.Function {
-			case "COUNT":
-				rowParts = append(rowParts, fmt.Sprintf("%d", state.count))
-			case "SUM":
-				rowParts = append(rowParts, fmt.Sprintf("%.2f", state.sum))
-			case "AVG":
-				if state.count > 0 {
-					rowParts = append(rowParts, fmt.Sprintf("%.2f", state.sum/float64(state.count)))
-				} else {
-					rowParts = append(rowParts, "NULL")
-				}
-			case "MIN":
-				if state.min != nil {
-					rowParts = append(rowParts, fmt.Sprintf("%.2f", *state.min))
-				} else if state.minStr != nil {
-					rowParts = append(rowParts, *state.minStr)
-				} else {
-					rowParts = append(rowParts, "NULL")
-				}
-			case "MAX":
-				if state.max != nil {
-					rowParts = append(rowParts, fmt.Sprintf("%.2f", *state.max))
-				} else if state.maxStr != nil {
-					rowParts = append(rowParts, *state.maxStr)
-				} else {
-					rowParts = append(rowParts, "NULL")
-				}
-			case "GROUP_CONCAT", "STRING_AGG":
-				if len(state.concatValues) > 0 {
-					sep := agg.Separator
-					if sep == "" {
-						sep = ","
-					}
-					rowParts = append(rowParts, strings.Join(state.concatValues, sep))
-				} else {
-					rowParts = append(rowParts, "NULL")
-				}
-			}
-		}
-
-		resultRows = append(resultRows, strings.Join(rowParts, ", "))
-	}
-
-	// Build header row: GROUP BY columns + aggregate expressions
-	var headers []string
-
-	// Add GROUP BY column names
-	headers = append(headers, stmt.GroupBy...)
-
-	// Add aggregate column names
-	for _, agg := range stmt.Aggregates {
-		if agg.Alias != "" {
-			headers = append(headers, agg.Alias)
-		} else if agg.Column == "*" {
-			headers = append(headers, strings.ToLower(agg.Function))
-		} else {
-			headers = append(headers, fmt.Sprintf("%s(%s)", strings.ToLower(agg.Function), agg.Column))
-		}
-	}
-
-	header := strings.Join(headers, ", ")
-	rowCount := len(resultRows)
-	if rowCount == 0 {
-		return fmt.Sprintf("%s\n(0 rows)", header), nil
-	}
-	return fmt.Sprintf("%s\n%s\n(%d rows)", header, strings.Join(resultRows, "\n"), rowCount), nil
-}
+	rowCount := len(resultRows)
+	if rowCount == 0 {
+		return fmt.Sprintf("%s\n(0 rows)", header)
+	}
+	return fmt.Sprintf("%s\n%s\n(%d rows)", header, strings.Join(resultRows, "\n"), rowCount)
+}
 
 // evaluateHaving evaluates a HAVING clause against computed aggregate states.
 // Returns true if the group passes the HAVING filter.
@@ -4703,11 +5030,38 @@ func (e *Executor) executeInspect(stmt *InspectStmt) (string, error) {
 		return e.inspectAudit(stmt)
 	case "AUDIT_STATS":
 		return e.inspectAuditStats()
+	case "CLUSTER":
+		return e.inspectCluster()
 	default:
 		return "", ferrors.NewSyntaxError(fmt.Sprintf("unknown inspect target: %s", stmt.Target))
 	}
 }
 
+// inspectCluster returns the rebalance plan of the cluster balancer
+// without applying it.
+func (e *Executor) inspectCluster() (string, error) {
+	if e.clusterInspector == nil {
+		return "", ferrors.NewExecutionError("INSPECT CLUSTER requires cluster mode")
+	}
+
+	header := "action, partition, from_node, to_node, reason"
+	rows := e.clusterInspector.RebalancePlanRows()
+	if len(rows) == 0 {
+		return fmt.Sprintf("%s\n(0 rows)", header), nil
+	}
+
+	results := make([]string, 0, len(rows))
+	for _, row := range rows {
+		for i, value := range row {
+			if value == "" {
+				row[i] = "-"
+			}
+		}
+		results = append(results, strings.Join(row, ", "))
+	}
+	return fmt.Sprintf("%s\n%s\n(%d rows)", header, strings.Join(results, "\n"), len(results)), nil
+}
+
 // inspectUsers returns information about all database users.
 func (e *Executor) inspectUsers() (string, error) {
 	// Scan for all user keys with the _sys_users: prefix
@@ -5325,6 +5679,23 @@ func (e *Executor) inspectTable(cat *Catalog, tableName string) (string, error)
 	}
 	results = append(results, fmt.Sprintf("Row count: %d", rowCount))
 
+	// Partitions and their row counts
+	if table.Partition != nil {
+		results = append(results, fmt.Sprintf("Partitioned by: %s (%s)", table.Partition.Strategy, table.Partition.Column))
+		partRows := make(map[string]int)
+		for key := range rows {
+			partRows[partitionOfKey(tableName, key)]++
+		}
+		for _, def := range table.Partition.Partitions {
+			results = append(results, fmt.Sprintf("  %s %s: %d rows", def.Name, def.Bound(), partRows[def.Name]))
+		}
+	}
+
+	// Row expiry
+	if table.TTL != nil {
+		results = append(results, fmt.Sprintf("TTL: %s", table.TTL))
+	}
+
 	// Indexes on this table
 	if cat.IndexMgr != nil {
 		indexes := cat.IndexMgr.ListIndexes()
@@ -5353,6 +5724,30 @@ func (e *Executor) inspectTable(cat *Catalog, tableName string) (string, error)
 	}
 	results = append(results, fmt.Sprintf("Storage size: %d bytes", storageSize))
 
+	// Statistics gathered by ANALYZE
+	var stats *TableStats
+	if cat.StatsMgr != nil {
+		stats = cat.StatsMgr.Get(tableName)
+	}
+	if stats == nil {
+		results = append(results, "Statistics: none (run ANALYZE)")
+	} else {
+		results = append(results, fmt.Sprintf("Statistics: %d rows, %d sampled, analyzed %s",
+			stats.RowCount, stats.SampledRows, stats.AnalyzedAt.Format("2006-01-02 15:04:05")))
+		for _, col := range table.Columns {
+			cs, ok := stats.Columns[col.Name]
+			if !ok {
+				continue
+			}
+			colInfo := fmt.Sprintf("  %s: null fraction %.2f, distinct %.0f", col.Name, cs.NullFraction, cs.NDistinct)
+			if len(cs.Histogram) > 0 {
+				colInfo += fmt.Sprintf(", histogram %d buckets [%s .. %s]",
+					max(len(cs.Histogram)-1, 1), cs.Histogram[0], cs.Histogram[len(cs.Histogram)-1])
+			}
+			results = append(results, colInfo)
+		}
+	}
+
 	// Timestamps
 	if !table.CreatedAt.IsZero() {
 		results = append(results, fmt.Sprintf("Created: %s", table.CreatedAt.Format("2006-01-02 15:04:05")))
@@ -5430,6 +5825,31 @@ func (e *Executor) inspectStatus(cat *Catalog) (string, error) {
 			stats.UsedFrames, stats.PoolSize, stats.HitRate))
 	}
 
+	// TTL reaper progress
+	var ttlTables []string
+	for name, table := range cat.Tables {
+		if table.TTL != nil {
+			ttlTables = append(ttlTables, name)
+		}
+	}
+	if len(ttlTables) > 0 {
+		sort.Strings(ttlTables)
+		results = append(results, fmt.Sprintf("TTL tables: %d", len(ttlTables)))
+		for _, name := range ttlTables {
+			p, ok := ttlProgressFor(cat.store, name)
+			switch {
+			case !ok:
+				results = append(results, fmt.Sprintf("  %s: not reaped yet", name))
+			case p.LastError != "":
+				results = append(results, fmt.Sprintf("  %s: last run %s failed: %s (%d rows expired in total)",
+					name, p.LastRun.Format("2006-01-02 15:04:05"), p.LastError, p.TotalExpired))
+			default:
+				results = append(results, fmt.Sprintf("  %s: last run %s expired %d rows (%d in total)",
+					name, p.LastRun.Format("2006-01-02 15:04:05"), p.LastExpired, p.TotalExpired))
+			}
+		}
+	}
+
 	results = append(results, "Storage: Unified Disk Engine")
 	results = append(results, "Status: Active")
 
@@ -5760,6 +6180,45 @@ func (e *Executor) executeAlterTable(stmt *AlterTableStmt) (string, error) {
 
 		return "ALTER TABLE OK", nil
 
+	case AlterActionAddPartition, AlterActionAttachPartition, AlterActionDetachPartition, AlterActionDropPartition:
+		if stmt.Partition == nil {
+			return "", ferrors.MissingRequired("partition")
+		}
+		if table.Partition == nil {
+			return "", ferrors.InvalidValue("partition", fmt.Sprintf("table %s is not partitioned", stmt.TableName))
+		}
+
+		var err error
+		switch stmt.Action {
+		case AlterActionAddPartition:
+			err = e.addPartition(cat, table, *stmt.Partition)
+		case AlterActionAttachPartition:
+			err = e.attachPartition(cat, table, *stmt.Partition)
+		case AlterActionDetachPartition:
+			err = e.detachPartition(cat, table, stmt.Partition.Name)
+		case AlterActionDropPartition:
+			err = e.dropPartition(cat, table, stmt.Partition.Name)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		// Invalidate cache for this table since rows may have moved
+		if e.queryCache != nil {
+			e.queryCache.Invalidate(stmt.TableName)
+		}
+
+		// Invoke OnSchemaChange callback
+		if e.OnSchemaChange != nil {
+			details, _ := json.Marshal(map[string]interface{}{
+				"action":    strings.ReplaceAll(string(stmt.Action), " ", "_"),
+				"partition": stmt.Partition.Name,
+			})
+			e.OnSchemaChange("ALTER_TABLE", stmt.TableName, string(details))
+		}
+
+		return "ALTER TABLE OK", nil
+
 	case AlterActionAddConstraint:
 		if stmt.Constraint == nil {
 			return "", ferrors.MissingRequired("constraint definition")
@@ -6042,7 +6501,7 @@ func (e *Executor) executeDropTable(stmt *DropTableStmt) (string, error) {
 		if cat.IndexMgr != nil {
 			var row map[string]interface{}
 			if err := json.Unmarshal(rowData, &row); err == nil {
-				cat.IndexMgr.OnDelete(stmt.TableName, key, row)
+				e.indexUpdates(cat).OnDelete(stmt.TableName, key, row)
 			}
 		}
 		cat.store.Delete(key)
@@ -6066,6 +6525,11 @@ func (e *Executor) executeDropTable(stmt *DropTableStmt) (string, error) {
 		cat.TriggerMgr.DropAllTriggersForTable(stmt.TableName)
 	}
 
+	// Drop the table's statistics
+	if cat.StatsMgr != nil {
+		cat.StatsMgr.Drop(stmt.TableName)
+	}
+
 	// Remove the table schema from the catalog
 	if err := cat.DropTable(stmt.TableName); err != nil {
 		return "", err
@@ -6111,22 +6575,8 @@ func (e *Executor) executeTruncateTable(stmt *TruncateTableStmt) (string, error)
 	}
 
 	// Check for foreign key references from other tables
-	for tableName, tableSchema := range cat.Tables {
-		if tableName == stmt.TableName {
-			continue
-		}
-		for _, col := range tableSchema.Columns {
-			for _, constraint := range col.Constraints {
-				if constraint.Type == ConstraintForeignKey && constraint.ForeignKey != nil && constraint.ForeignKey.Table == stmt.TableName {
-					// Check if the referencing table has any rows
-					refPrefix := "row:" + tableName + ":"
-					refRows, err := cat.store.Scan(refPrefix)
-					if err == nil && len(refRows) > 0 {
-						return "", ferrors.ConstraintViolation("FOREIGN KEY", fmt.Sprintf("cannot truncate table %s: referenced by foreign key in table %s with existing data", stmt.TableName, tableName))
-					}
-				}
-			}
-		}
+	if err := e.checkNoReferencingRows(cat, stmt.TableName, "truncate table"); err != nil {
+		return "", err
 	}
 
 	// Delete all rows in the table
@@ -6141,7 +6591,7 @@ func (e *Executor) executeTruncateTable(stmt *TruncateTableStmt) (string, error)
 		if cat.IndexMgr != nil {
 			var row map[string]interface{}
 			if err := json.Unmarshal(rowData, &row); err == nil {
-				cat.IndexMgr.OnDelete(stmt.TableName, key, row)
+				e.indexUpdates(cat).OnDelete(stmt.TableName, key, row)
 			}
 		}
 		cat.store.Delete(key)
@@ -6155,6 +6605,11 @@ func (e *Executor) executeTruncateTable(stmt *TruncateTableStmt) (string, error)
 		}
 	}
 
+	// Statistics describe rows that no longer exist
+	if cat.StatsMgr != nil {
+		cat.StatsMgr.Drop(stmt.TableName)
+	}
+
 	// Invalidate cache for this table since data has changed
 	if e.queryCache != nil {
 		e.queryCache.Invalidate(stmt.TableName)
@@ -6163,6 +6618,526 @@ func (e *Executor) executeTruncateTable(stmt *TruncateTableStmt) (string, error)
 	return "TRUNCATE TABLE OK", nil
 }
 
+// executeAnalyze gathers statistics for a table, or for every table in the
+// database when no table is named, and stores them for the planner.
+//
+// Returns "ANALYZE OK" on success, or an error.
+func (e *Executor) executeAnalyze(stmt *AnalyzeStmt) (string, error) {
+	if stmt.TableName == "" {
+		// Analyzing every table requires admin privileges
+		if e.currentUser != "" && e.currentUser != "admin" {
+			return "", ferrors.PermissionDenied("")
+		}
+	} else if err := e.checkAccess(stmt.DatabaseName, stmt.TableName); err != nil {
+		return "", err
+	}
+
+	// Get the catalog for the target database
+	cat, err := e.getCatalog(stmt.DatabaseName)
+	if err != nil {
+		return "", err
+	}
+
+	var tableNames []string
+	if stmt.TableName != "" {
+		tableNames = append(tableNames, stmt.TableName)
+	} else {
+		for name := range cat.Tables {
+			tableNames = append(tableNames, name)
+		}
+		sort.Strings(tableNames)
+	}
+
+	for _, name := range tableNames {
+		if err := e.analyzeTable(cat, name); err != nil {
+			return "", err
+		}
+	}
+
+	return "ANALYZE OK", nil
+}
+
+// analyzeTable samples a table and saves its statistics.
+func (e *Executor) analyzeTable(cat *Catalog, tableName string) error {
+	table, ok := cat.GetTable(tableName)
+	if !ok {
+		return ferrors.TableNotFound(tableName)
+	}
+	if cat.StatsMgr == nil {
+		return nil
+	}
+
+	stats, err := analyzeTable(cat.store, table, e.compareStrings)
+	if err != nil {
+		return ferrors.NewStorageError("failed to analyze table").WithCause(err)
+	}
+	if err := cat.StatsMgr.Save(stats); err != nil {
+		return ferrors.NewStorageError("failed to save table statistics").WithCause(err)
+	}
+	return nil
+}
+
+// recordChanges counts the rows modified by a statement and, once enough
+// of the table has changed, analyzes it again in the background so the
+// statement does not wait for the sample. Inside a transaction the refresh
+// waits for the next statement outside it.
+func (e *Executor) recordChanges(cat *Catalog, tableName string, n int) {
+	if cat.StatsMgr == nil {
+		return
+	}
+	if !cat.StatsMgr.RecordChanges(tableName, n, e.autoAnalyzeFraction) {
+		return
+	}
+	store := cat.store
+	if e.tx != nil {
+		// The rows are not committed yet. A statement batch commits as the
+		// statement ends, so its tables are analyzed from the store below.
+		if e.batchStore == nil || cat.store != storage.Engine(e.tx) {
+			return
+		}
+		store = e.batchStore
+	}
+	table, ok := cat.GetTable(tableName)
+	if !ok || !cat.StatsMgr.beginAnalyze(tableName) {
+		return
+	}
+
+	// Collators are not safe for concurrent use, so the analysis builds
+	// its own from the executor's settings.
+	statsMgr := cat.StatsMgr
+	collation, locale := e.collation, e.locale
+	go func() {
+		defer statsMgr.endAnalyze(tableName)
+		compare := strings.Compare
+		if collation != "" {
+			compare = storage.GetCollator(collation, locale).Compare
+		}
+		// Best effort: a failed refresh keeps the previous statistics
+		if stats, err := analyzeTable(store, table, compare); err == nil {
+			statsMgr.Save(stats)
+		}
+	}()
+}
+
+// indexUpdater maintains the indexes of a table as its rows change.
+type indexUpdater interface {
+	OnInsert(table, rowKey string, row map[string]interface{})
+	OnUpdate(table, rowKey string, oldRow, newRow map[string]interface{})
+	OnDelete(table, rowKey string, row map[string]interface{})
+}
+
+// indexUpdates returns where the index changes of rows written to cat go.
+// Rows written through a transaction only reach storage when it commits,
+// so their index changes wait for the commit too and are dropped with the
+// rows if it rolls back; other sessions never find uncommitted rows, or
+// miss committed ones, through the shared indexes.
+func (e *Executor) indexUpdates(cat *Catalog) indexUpdater {
+	if e.tx != nil && cat.store == storage.Engine(e.tx) {
+		return &txIndexUpdates{tx: e.tx, indexes: cat.IndexMgr}
+	}
+	return cat.IndexMgr
+}
+
+// txIndexUpdates defers index changes until a transaction commits.
+type txIndexUpdates struct {
+	tx      *storage.Transaction
+	indexes *storage.IndexManager
+}
+
+func (u *txIndexUpdates) OnInsert(table, rowKey string, row map[string]interface{}) {
+	u.tx.OnCommit(func() { u.indexes.OnInsert(table, rowKey, row) })
+}
+
+func (u *txIndexUpdates) OnUpdate(table, rowKey string, oldRow, newRow map[string]interface{}) {
+	u.tx.OnCommit(func() { u.indexes.OnUpdate(table, rowKey, oldRow, newRow) })
+}
+
+func (u *txIndexUpdates) OnDelete(table, rowKey string, row map[string]interface{}) {
+	u.tx.OnCommit(func() { u.indexes.OnDelete(table, rowKey, row) })
+}
+
+// canUseIndex reports whether a SELECT's WHERE clause can be answered with
+// an index lookup. Indexes map a value to a single row, so only an equality
+// that is not part of an OR qualifies, and the index is skipped when the
+// table statistics estimate that the value matches several rows. Indexes
+// only hold committed rows, so a transaction scans the tables it reads
+// instead and sees its own writes.
+func (e *Executor) canUseIndex(cat *Catalog, stmt *SelectStmt) bool {
+	if e.tx != nil && cat.store == storage.Engine(e.tx) {
+		return false
+	}
+	if stmt.Where == nil || (stmt.WhereExt != nil && len(whereOrGroups(stmt.WhereExt)) > 1) {
+		return false
+	}
+	if cat.IndexMgr == nil || !cat.IndexMgr.HasIndex(stmt.TableName, stmt.Where.Column) {
+		return false
+	}
+
+	if cat.StatsMgr != nil {
+		if stats := cat.StatsMgr.Get(stmt.TableName); stats != nil {
+			if rows, ok := stats.EstimateEqualRows(stmt.Where.Column); ok && rows > indexLookupMaxRows {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// checkNoReferencingRows fails if another table with rows has a foreign
+// key referencing tableName. action describes the operation for the error.
+func (e *Executor) checkNoReferencingRows(cat *Catalog, tableName, action string) error {
+	for refName, refSchema := range cat.Tables {
+		if refName == tableName {
+			continue
+		}
+		for _, col := range refSchema.Columns {
+			for _, constraint := range col.Constraints {
+				if constraint.Type == ConstraintForeignKey && constraint.ForeignKey != nil && constraint.ForeignKey.Table == tableName {
+					// Check if the referencing table has any rows
+					refRows, err := cat.store.Scan("row:" + refName + ":")
+					if err == nil && len(refRows) > 0 {
+						return ferrors.ConstraintViolation("FOREIGN KEY", fmt.Sprintf("cannot %s %s: referenced by foreign key in table %s with existing data", action, tableName, refName))
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// compareCollated compares two values numerically when both are numbers,
+// and otherwise with the executor's collator.
+func (e *Executor) compareCollated(a, b string) int {
+	return compareValuesWithCollator(a, b, e.collator)
+}
+
+// scanTableRows returns the rows of a table that may match where. For a
+// partitioned table only the partitions that survive pruning are read.
+func (e *Executor) scanTableRows(cat *Catalog, tableName string, where *WhereClause) (map[string][]byte, error) {
+	table, ok := cat.GetTable(tableName)
+	if !ok || table.Partition == nil || where == nil {
+		return e.scan(cat.store, "row:"+tableName+":")
+	}
+
+	rows := make(map[string][]byte)
+	for _, def := range table.Partition.Prune(where, e.compareCollated) {
+		partRows, err := e.scan(cat.store, partitionKeyPrefix(tableName, def.Name))
+		if err != nil {
+			return nil, err
+		}
+		for key, val := range partRows {
+			rows[key] = val
+		}
+	}
+	return rows, nil
+}
+
+// partitionedRowKey returns the storage key of a row given its current or
+// newly allocated key. Rows of a partitioned table are placed under the
+// prefix of the partition their partition key routes to, and the row id
+// carries the hash tag of the table's distribution.
+func (e *Executor) partitionedRowKey(table TableSchema, key string, row map[string]interface{}) (string, error) {
+	if table.Partition == nil {
+		if table.ShardKey == "" && !table.Reference {
+			return key, nil
+		}
+		return "row:" + table.Name + ":" + distributedRowID(table, rowKeyID(key), row), nil
+	}
+
+	value := partitionValue(table.Partition, row)
+	def, ok := table.Partition.Route(value, e.compareCollated)
+	if !ok {
+		if isNullPartitionValue(value) {
+			value = "NULL"
+		}
+		return "", ferrors.ConstraintViolation("PARTITION", fmt.Sprintf("no partition of table %s accepts %s = %s", table.Name, table.Partition.Column, value))
+	}
+	return partitionKeyPrefix(table.Name, def.Name) + distributedRowID(table, rowKeyID(key), row), nil
+}
+
+// preparePartitionSpec validates a PARTITION BY clause against the table's
+// columns and normalizes its bounds to the partition key's type.
+func (e *Executor) preparePartitionSpec(columns []ColumnDef, spec *PartitionSpec) (*PartitionSpec, error) {
+	if partitionColumnType(columns, spec.Column) == "" {
+		return nil, ferrors.ColumnNotFound(spec.Column, "")
+	}
+
+	prepared := &PartitionSpec{Strategy: spec.Strategy, Column: spec.Column}
+	for _, def := range spec.Partitions {
+		def, err := e.preparePartition(columns, prepared, def)
+		if err != nil {
+			return nil, err
+		}
+		prepared.Partitions = append(prepared.Partitions, def)
+	}
+	return prepared, nil
+}
+
+// preparePartition normalizes a new partition's bounds and checks it
+// against the existing partitions of spec.
+func (e *Executor) preparePartition(columns []ColumnDef, spec *PartitionSpec, def PartitionDef) (PartitionDef, error) {
+	def, err := normalizePartitionDef(def, partitionColumnType(columns, spec.Column))
+	if err != nil {
+		return def, err
+	}
+	if err := validatePartition(spec, def, e.compareCollated); err != nil {
+		return def, err
+	}
+	return def, nil
+}
+
+// moveRowData writes a row under newKey and deletes oldKey in one batch, so
+// the row is never lost or duplicated when one of the writes fails.
+func moveRowData(store storage.Engine, oldKey, newKey string, data []byte) error {
+	err := storage.WriteBatch(store, []storage.TxOperation{
+		{Op: storage.OpPut, Key: newKey, Value: data},
+		{Op: storage.OpDelete, Key: oldKey},
+	})
+	if err != nil {
+		return ferrors.NewStorageError("failed to move row").WithCause(err)
+	}
+	return nil
+}
+
+// moveRow moves a row to a new key and updates the table's indexes.
+func (e *Executor) moveRow(cat *Catalog, tableName, oldKey, newKey string, row map[string]interface{}, data []byte) error {
+	if err := moveRowData(cat.store, oldKey, newKey, data); err != nil {
+		return err
+	}
+	if cat.IndexMgr != nil {
+		e.indexUpdates(cat).OnDelete(tableName, oldKey, row)
+		e.indexUpdates(cat).OnInsert(tableName, newKey, row)
+	}
+	return nil
+}
+
+// claimDefaultRows moves the rows of the DEFAULT partition that belong to
+// a newly added partition into it.
+func (e *Executor) claimDefaultRows(cat *Catalog, table TableSchema, def PartitionDef) error {
+	dflt, ok := table.Partition.defaultPartition()
+	if !ok || def.IsDefault {
+		return nil
+	}
+
+	rows, err := cat.store.Scan(partitionKeyPrefix(table.Name, dflt.Name))
+	if err != nil {
+		return ferrors.NewStorageError("failed to scan partition rows").WithCause(err)
+	}
+	for key, data := range rows {
+		var row map[string]interface{}
+		if err := json.Unmarshal(data, &row); err != nil {
+			continue
+		}
+		value := partitionValue(table.Partition, row)
+		if isNullPartitionValue(value) || !def.accepts(table.Partition.Strategy, value, e.compareCollated) {
+			continue
+		}
+		if err := e.moveRow(cat, table.Name, key, partitionKeyPrefix(table.Name, def.Name)+rowKeyID(key), row, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addPartition adds an empty partition to a partitioned table. Rows of the
+// DEFAULT partition that belong to it are moved into it.
+func (e *Executor) addPartition(cat *Catalog, table TableSchema, def PartitionDef) error {
+	def, err := e.preparePartition(table.Columns, table.Partition, def)
+	if err != nil {
+		return err
+	}
+	if _, exists := cat.GetTable(def.Name); exists {
+		return ferrors.TableAlreadyExists(def.Name)
+	}
+
+	spec := *table.Partition
+	spec.Partitions = append(append([]PartitionDef(nil), spec.Partitions...), def)
+	if err := cat.SetPartitionSpec(table.Name, &spec); err != nil {
+		return err
+	}
+	return e.claimDefaultRows(cat, table, def)
+}
+
+// dropPartition deletes a partition and its rows. Only the partition's own
+// keys are read, which makes it a cheap way to expire old ranges.
+func (e *Executor) dropPartition(cat *Catalog, table TableSchema, name string) error {
+	if _, ok := table.Partition.Find(name); !ok {
+		return ferrors.InvalidValue("partition", fmt.Sprintf("partition %s of table %s does not exist", name, table.Name))
+	}
+	if err := e.checkNoReferencingRows(cat, table.Name, "drop partition of"); err != nil {
+		return err
+	}
+
+	rows, err := cat.store.Scan(partitionKeyPrefix(table.Name, name))
+	if err != nil {
+		return ferrors.NewStorageError("failed to scan partition rows").WithCause(err)
+	}
+	for key, data := range rows {
+		if cat.IndexMgr != nil {
+			var row map[string]interface{}
+			if err := json.Unmarshal(data, &row); err == nil {
+				e.indexUpdates(cat).OnDelete(table.Name, key, row)
+			}
+		}
+		cat.store.Delete(key)
+	}
+	e.recordChanges(cat, table.Name, len(rows))
+
+	return cat.SetPartitionSpec(table.Name, table.Partition.without(name))
+}
+
+// detachPartition turns a partition into a standalone table with the
+// parent's columns and constraints.
+func (e *Executor) detachPartition(cat *Catalog, table TableSchema, name string) error {
+	if _, ok := table.Partition.Find(name); !ok {
+		return ferrors.InvalidValue("partition", fmt.Sprintf("partition %s of table %s does not exist", name, table.Name))
+	}
+	if _, exists := cat.GetTable(name); exists {
+		return ferrors.TableAlreadyExists(name)
+	}
+
+	columns := append([]ColumnDef(nil), table.Columns...)
+	constraints := append([]TableConstraint(nil), table.Constraints...)
+	if err := cat.CreateTableWithConstraints(name, columns, constraints); err != nil {
+		return err
+	}
+
+	rows, err := cat.store.Scan(partitionKeyPrefix(table.Name, name))
+	if err != nil {
+		return ferrors.NewStorageError("failed to scan partition rows").WithCause(err)
+	}
+	maxID := 0
+	for key, data := range rows {
+		id := stripShardTag(rowKeyID(key))
+		if n, err := strconv.Atoi(id); err == nil && n > maxID {
+			maxID = n
+		}
+		if err := moveRowData(cat.store, key, "row:"+name+":"+id, data); err != nil {
+			return err
+		}
+		if cat.IndexMgr != nil {
+			var row map[string]interface{}
+			if err := json.Unmarshal(data, &row); err == nil {
+				e.indexUpdates(cat).OnDelete(table.Name, key, row)
+			}
+		}
+	}
+	seqBytes, _ := json.Marshal(maxID)
+	cat.store.Put("seq:"+name, seqBytes)
+	e.recordChanges(cat, table.Name, len(rows))
+
+	// The detached table keeps the parent's indexes
+	if cat.IndexMgr != nil {
+		for _, column := range cat.IndexMgr.GetIndexedColumns(table.Name) {
+			if err := e.createIndex(cat, name, column); err != nil {
+				return err
+			}
+		}
+	}
+
+	return cat.SetPartitionSpec(table.Name, table.Partition.without(name))
+}
+
+// createIndex builds an index on rows written to cat. Indexes are built
+// from committed rows, so inside a transaction the index is built when it
+// commits.
+func (e *Executor) createIndex(cat *Catalog, table, column string) error {
+	if e.tx != nil && cat.store == storage.Engine(e.tx) {
+		e.tx.OnCommit(func() { cat.IndexMgr.CreateIndex(table, column) })
+		return nil
+	}
+	return cat.IndexMgr.CreateIndex(table, column)
+}
+
+// attachPartition turns an existing table into a partition. The table must
+// have the parent's columns and every row must fit the partition bound.
+// Its rows move under the parent and the standalone table is dropped.
+func (e *Executor) attachPartition(cat *Catalog, table TableSchema, def PartitionDef) error {
+	child, ok := cat.GetTable(def.Name)
+	if !ok {
+		return ferrors.TableNotFound(def.Name)
+	}
+	if child.Name == table.Name || child.Partition != nil {
+		return ferrors.InvalidValue("partition", fmt.Sprintf("table %s cannot be attached as a partition", child.Name))
+	}
+	if !sameColumns(table.Columns, child.Columns) {
+		return ferrors.InvalidValue("partition", fmt.Sprintf("table %s does not have the same columns as %s", child.Name, table.Name))
+	}
+	if err := e.checkNoReferencingRows(cat, child.Name, "attach"); err != nil {
+		return err
+	}
+
+	def, err := e.preparePartition(table.Columns, table.Partition, def)
+	if err != nil {
+		return err
+	}
+
+	// Every row must belong to the new partition
+	spec := *table.Partition
+	spec.Partitions = append(append([]PartitionDef(nil), spec.Partitions...), def)
+	rows, err := cat.store.Scan("row:" + child.Name + ":")
+	if err != nil {
+		return ferrors.NewStorageError("failed to scan table rows").WithCause(err)
+	}
+	parsed := make(map[string]map[string]interface{}, len(rows))
+	for key, data := range rows {
+		var row map[string]interface{}
+		if err := json.Unmarshal(data, &row); err != nil {
+			return ferrors.InternalError("failed to decode row").WithCause(err)
+		}
+		value := partitionValue(&spec, row)
+		if routed, ok := spec.Route(value, e.compareCollated); !ok || routed.Name != def.Name {
+			return ferrors.ConstraintViolation("PARTITION", fmt.Sprintf("row of table %s with %s = %s violates the partition bound", child.Name, spec.Column, value))
+		}
+		parsed[key] = row
+	}
+
+	if err := cat.SetPartitionSpec(table.Name, &spec); err != nil {
+		return err
+	}
+	if err := e.claimDefaultRows(cat, table, def); err != nil {
+		return err
+	}
+
+	// Move the rows under the parent with fresh ids
+	seqKey := "seq:" + table.Name
+	var seq int
+	if seqVal, err := cat.store.Get(seqKey); err == nil {
+		json.Unmarshal(seqVal, &seq)
+	}
+	for key, data := range rows {
+		seq++
+		newKey := partitionKeyPrefix(table.Name, def.Name) + distributedRowID(table, strconv.Itoa(seq), parsed[key])
+		if err := cat.store.Put(newKey, data); err != nil {
+			return ferrors.NewStorageError("failed to move row").WithCause(err)
+		}
+		if cat.IndexMgr != nil {
+			e.indexUpdates(cat).OnInsert(table.Name, newKey, parsed[key])
+			e.indexUpdates(cat).OnDelete(child.Name, key, parsed[key])
+		}
+		cat.store.Delete(key)
+	}
+	seqBytes, _ := json.Marshal(seq)
+	cat.store.Put(seqKey, seqBytes)
+	e.recordChanges(cat, table.Name, len(rows))
+
+	// Drop the standalone table
+	cat.store.Delete("seq:" + child.Name)
+	if cat.IndexMgr != nil {
+		cat.IndexMgr.DropAllIndexesForTable(child.Name)
+	}
+	if cat.TriggerMgr != nil {
+		cat.TriggerMgr.DropAllTriggersForTable(child.Name)
+	}
+	if cat.StatsMgr != nil {
+		cat.StatsMgr.Drop(child.Name)
+	}
+	if e.queryCache != nil {
+		e.queryCache.Invalidate(child.Name)
+	}
+	return cat.DropTable(child.Name)
+}
+
 // executeTriggers executes all triggers for a table with the specified timing and event.
 func (e *Executor) executeTriggers(cat *Catalog, dbName, tableName string, timing TriggerTiming, event TriggerEvent) error {
 	if cat.TriggerMgr == nil {
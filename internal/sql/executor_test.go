@@ -17,6 +17,7 @@
 package sql
 
 import (
+	"fmt"
 	"os"
 	"strings"
 	"testing"
@@ -2155,6 +2156,88 @@ func TestIntrospectTableWithConstraints(t *testing.T) {
 	}
 }
 
+// fakeClusterInspector returns a fixed rebalance plan.
+type fakeClusterInspector struct {
+	rows [][]string
+}
+
+func (f *fakeClusterInspector) RebalancePlanRows() [][]string {
+	return f.rows
+}
+
+// fakeClusterRepairer records the partitions it repairs.
+type fakeClusterRepairer struct {
+	repaired []int
+}
+
+func (f *fakeClusterRepairer) RepairPartitionStats(id int) (int, int, error) {
+	if id > 255 {
+		return 0, 0, fmt.Errorf("partition %d not found", id)
+	}
+	f.repaired = append(f.repaired, id)
+	return 2, 9, nil
+}
+
+func TestRepairPartition(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	if _, err := exec.Execute(&RepairPartitionStmt{PartitionID: 3}); err == nil {
+		t.Error("Expected REPAIR PARTITION to fail outside cluster mode")
+	}
+
+	repairer := &fakeClusterRepairer{}
+	exec.SetClusterRepairer(repairer)
+	result, err := exec.Execute(&RepairPartitionStmt{PartitionID: 3})
+	if err != nil {
+		t.Fatalf("REPAIR PARTITION failed: %v", err)
+	}
+	if result != "REPAIR PARTITION 3 OK (2 ranges, 9 keys streamed)" || len(repairer.repaired) != 1 {
+		t.Errorf("Unexpected result %q", result)
+	}
+	if _, err := exec.Execute(&RepairPartitionStmt{PartitionID: 999}); err == nil {
+		t.Error("Expected an error repairing an unknown partition")
+	}
+}
+
+func TestInspectCluster(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	if _, err := exec.Execute(&InspectStmt{Target: "CLUSTER"}); err == nil {
+		t.Error("Expected INSPECT CLUSTER to fail outside cluster mode")
+	}
+
+	inspector := &fakeClusterInspector{}
+	exec.SetClusterInspector(inspector)
+	result, err := exec.Execute(&InspectStmt{Target: "CLUSTER"})
+	if err != nil {
+		t.Fatalf("INSPECT CLUSTER failed: %v", err)
+	}
+	if !strings.HasSuffix(result, "(0 rows)") {
+		t.Errorf("Expected an empty plan, got:\n%s", result)
+	}
+
+	inspector.rows = [][]string{
+		{"SPLIT", "7", "", "", "120000 keys > 100000"},
+		{"MOVE", "12", "node1", "node3", "node load 0.90 vs 0.20"},
+	}
+	result, err = exec.Execute(&InspectStmt{Target: "CLUSTER"})
+	if err != nil {
+		t.Fatalf("INSPECT CLUSTER failed: %v", err)
+	}
+	for _, want := range []string{
+		"action, partition, from_node, to_node, reason",
+		"SPLIT, 7, -, -, 120000 keys > 100000",
+		"MOVE, 12, node1, node3, node load 0.90 vs 0.20",
+		"(2 rows)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected %q in INSPECT CLUSTER output, got:\n%s", want, result)
+		}
+	}
+}
+
 // Tests for DISTINCT
 func TestDistinct(t *testing.T) {
 	exec, cleanup := setupExecutorTest(t)
@@ -3807,3 +3890,177 @@ func TestExecutorRevokePermissionDenied(t *testing.T) {
 		t.Errorf("Expected 'permission denied' error, got: %v", err)
 	}
 }
+
+func TestExecutorWithTransaction(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	run := func(e *Executor, query string) string {
+		t.Helper()
+		stmt, err := NewParser(NewLexer(query)).Parse()
+		if err != nil {
+			t.Fatalf("Parse %q failed: %v", query, err)
+		}
+		result, err := e.Execute(stmt)
+		if err != nil {
+			t.Fatalf("Execute %q failed: %v", query, err)
+		}
+		return result
+	}
+
+	run(exec, "CREATE TABLE items (id INT, name TEXT)")
+	run(exec, "INSERT INTO items VALUES (1, 'a')")
+	run(exec, "SELECT name FROM items") // cached
+
+	tx := storage.NewTransaction(exec.store)
+	txExec := exec.WithTransaction(tx)
+	run(txExec, "INSERT INTO items VALUES (2, 'b')")
+	run(txExec, "UPDATE items SET name = 'c' WHERE id = 1")
+
+	// The transaction sees its writes; the executor does not
+	if result := run(txExec, "SELECT name FROM items"); !strings.Contains(result, "b") || !strings.Contains(result, "c") {
+		t.Errorf("Transaction does not see its writes: %q", result)
+	}
+	if result := run(exec, "SELECT name FROM items"); strings.Contains(result, "b") || strings.Contains(result, "c") {
+		t.Errorf("Uncommitted writes visible outside the transaction: %q", result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	exec.InvalidateAllCache()
+	if result := run(exec, "SELECT name FROM items"); !strings.Contains(result, "b") || !strings.Contains(result, "c") {
+		t.Errorf("Committed writes not visible: %q", result)
+	}
+
+	// A rolled back transaction leaves no rows behind
+	tx = storage.NewTransaction(exec.store)
+	run(exec.WithTransaction(tx), "DELETE FROM items WHERE id = 2")
+	tx.Rollback()
+	if result := run(exec, "SELECT name FROM items"); !strings.Contains(result, "b") {
+		t.Errorf("Rolled back delete removed a row: %q", result)
+	}
+}
+
+func TestStatementWritesProposedAsOneBatch(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	run := func(query string) (string, error) {
+		t.Helper()
+		stmt, err := NewParser(NewLexer(query)).Parse()
+		if err != nil {
+			t.Fatalf("Parse %q failed: %v", query, err)
+		}
+		return exec.Execute(stmt)
+	}
+	for _, query := range []string{
+		"CREATE TABLE items (id INT, name TEXT)",
+		"INSERT INTO items VALUES (1, 'a')",
+		"INSERT INTO items VALUES (2, 'b')",
+		"INSERT INTO items VALUES (3, 'c')",
+	} {
+		if _, err := run(query); err != nil {
+			t.Fatalf("Execute %q failed: %v", query, err)
+		}
+	}
+
+	// A proposer that commits each batch as the next log entry
+	store := exec.store.(*storage.UnifiedStorageEngine)
+	var batches, index int
+	var reject bool
+	store.SetWriteProposer(func(ops []storage.TxOperation) error {
+		if reject {
+			return fmt.Errorf("no quorum")
+		}
+		batches++
+		index++
+		return store.ApplyCommittedAt(uint64(index), ops)
+	})
+
+	if _, err := run("UPDATE items SET name = 'x'"); err != nil {
+		t.Fatalf("UPDATE failed: %v", err)
+	}
+	if batches != 1 {
+		t.Errorf("Expected the UPDATE to be proposed as 1 batch, got %d", batches)
+	}
+	if result, _ := run("SELECT name FROM items WHERE name = 'x'"); strings.Count(result, "x") != 3 {
+		t.Errorf("Expected every row updated, got %q", result)
+	}
+
+	// A statement the cluster rejects changes nothing
+	reject = true
+	if _, err := run("DELETE FROM items WHERE id = 2"); err == nil {
+		t.Fatal("Expected the rejected DELETE to fail")
+	}
+	reject = false
+	if result, _ := run("SELECT id FROM items WHERE id = 2"); !strings.Contains(result, "2") {
+		t.Errorf("Rejected DELETE removed the row: %q", result)
+	}
+}
+
+func TestWhereAndOrPrecedence(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	for _, query := range []string{
+		"CREATE TABLE flags (id INT, a INT, b INT, c INT)",
+		"INSERT INTO flags VALUES (1, 1, 0, 0)",
+		"INSERT INTO flags VALUES (2, 0, 0, 1)",
+		"INSERT INTO flags VALUES (3, 1, 1, 0)",
+		"INSERT INTO flags VALUES (4, 0, 1, 1)",
+	} {
+		if _, err := exec.Execute(parse(t, query)); err != nil {
+			t.Fatalf("%s failed: %v", query, err)
+		}
+	}
+
+	tests := []struct {
+		where string
+		want  []string
+	}{
+		// (a AND b) OR c
+		{"a = 1 AND b = 1 OR c = 1", []string{"2", "3", "4"}},
+		// a OR (b AND c)
+		{"a = 1 OR b = 1 AND c = 1", []string{"1", "3", "4"}},
+		{"a = 1 AND b = 0 AND c = 0", []string{"1"}},
+		{"a = 0 OR b = 0 OR c = 0", []string{"1", "2", "3", "4"}},
+	}
+	for _, tt := range tests {
+		result, err := exec.Execute(parse(t, "SELECT id FROM flags WHERE "+tt.where+" ORDER BY id"))
+		if err != nil {
+			t.Fatalf("SELECT WHERE %s failed: %v", tt.where, err)
+		}
+		lines := strings.Split(result, "\n")
+		got := lines[1 : len(lines)-1]
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("WHERE %s returned %v, want %v", tt.where, got, tt.want)
+		}
+	}
+}
+
+func TestCompareValuesWithCollator(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"9", "10", -1},
+		{"-5", "3", -1},
+		{"2.5", "10", -1},
+		{"1e3", "999", 1},
+		{"10", "10.0", 0},
+		// Values that only start with a number compare as strings
+		{"2024-01-15", "2024-02-01", -1},
+		{"2024-02-01", "2024-01-15", 1},
+		{"10abc", "9", -1},
+		{"apple", "banana", -1},
+	}
+	for _, tt := range tests {
+		if got := compareValuesWithCollator(tt.a, tt.b, nil); got != tt.want {
+			t.Errorf("compareValuesWithCollator(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if got := compareValuesWithCollator("Apple", "apple", storage.GetCollator(storage.CollationCaseInsensitive, "")); got != 0 {
+		t.Errorf("Expected the collator to compare strings, got %d", got)
+	}
+}
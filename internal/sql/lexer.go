@@ -270,6 +270,8 @@ func (l *Lexer) NextToken() Token {
 			"TRIGGER", "BEFORE", "AFTER", "EACH", "ROW",
 			// TRUNCATE
 			"TRUNCATE",
+			// Statistics
+			"ANALYZE",
 			// JOIN types
 			"LEFT", "RIGHT", "INNER", "OUTER", "FULL", "CROSS", "NATURAL",
 			// Transaction control
@@ -342,18 +344,29 @@ func (l *Lexer) NextToken() Token {
 		return Token{Type: TokenNumber, Value: l.input[start:l.pos], Line: startLine, Column: startCol}
 	}
 
-	// String literal: enclosed in single quotes.
-	// Example: 'hello world'
+	// String literal: enclosed in single quotes, with '' for a quote.
+	// Example: 'hello world', 'it''s'
 	if ch == '\'' {
 		l.advance() // Skip opening quote
 		start := l.pos
+		escaped := false
 
 		// Consume until closing quote.
-		for l.pos < len(l.input) && l.input[l.pos] != '\'' {
+		for l.pos < len(l.input) {
+			if l.input[l.pos] == '\'' {
+				if l.pos+1 >= len(l.input) || l.input[l.pos+1] != '\'' {
+					break
+				}
+				escaped = true
+				l.advance()
+			}
 			l.advance()
 		}
 
 		lit := l.input[start:l.pos]
+		if escaped {
+			lit = strings.ReplaceAll(lit, "''", "'")
+		}
 
 		// Skip closing quote if present.
 		if l.pos < len(l.input) {
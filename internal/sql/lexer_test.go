@@ -95,10 +95,10 @@ func TestLexerNumbers(t *testing.T) {
 }
 
 func TestLexerStrings(t *testing.T) {
-	input := "'hello' 'world' 'user@example.com'"
+	input := "'hello' 'world' 'user@example.com' 'it''s' ''"
 	lexer := NewLexer(input)
 
-	expected := []string{"hello", "world", "user@example.com"}
+	expected := []string{"hello", "world", "user@example.com", "it's", ""}
 
 	for _, exp := range expected {
 		tok := lexer.NextToken()
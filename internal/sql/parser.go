@@ -238,7 +238,7 @@ func (p *Parser) Parse() (Statement, error) {
 		case "CREATE":
 			// Distinguish between CREATE TABLE, CREATE USER, CREATE INDEX, CREATE PROCEDURE, CREATE VIEW, CREATE TRIGGER, CREATE DATABASE, and CREATE ROLE
 			// by looking at the next token.
-			if p.peek.Value == "TABLE" {
+			if p.peek.Value == "TABLE" || (p.peek.Type == TokenIdent && strings.EqualFold(p.peek.Value, "REFERENCE")) {
 				return p.parseCreate()
 			} else if p.peek.Value == "USER" {
 				return p.parseCreateUser()
@@ -296,10 +296,15 @@ func (p *Parser) Parse() (Statement, error) {
 			return p.parseAlter()
 		case "TRUNCATE":
 			return p.parseTruncate()
+		case "ANALYZE":
+			return p.parseAnalyze()
 		case "USE":
 			return p.parseUse()
 		}
 	}
+	if p.cur.Type == TokenIdent && strings.EqualFold(p.cur.Value, "REPAIR") {
+		return p.parseRepair()
+	}
 	return nil, ferrors.NewSyntaxError(fmt.Sprintf("unexpected token %q", p.cur.Value)).
 		WithDetail(fmt.Sprintf("at line %d, col %d", p.cur.Line, p.cur.Column))
 }
@@ -597,6 +602,13 @@ func (p *Parser) parseRevokeRole() (*RevokeRoleStmt, error) {
 //
 // Returns a CreateTableStmt AST node.
 func (p *Parser) parseCreate() (*CreateTableStmt, error) {
+	// Parse optional REFERENCE, accepted as an identifier.
+	reference := false
+	if p.peek.Type == TokenIdent && strings.EqualFold(p.peek.Value, "REFERENCE") {
+		p.nextToken() // consume REFERENCE
+		reference = true
+	}
+
 	// Expect TABLE keyword.
 	if !p.expectPeek(TokenKeyword) || p.cur.Value != "TABLE" {
 		return nil, p.syntaxError("TABLE")
@@ -620,7 +632,7 @@ func (p *Parser) parseCreate() (*CreateTableStmt, error) {
 	if err != nil {
 		return nil, err
 	}
-	stmt := &CreateTableStmt{DatabaseName: dbName, TableName: tableName, IfNotExists: ifNotExists}
+	stmt := &CreateTableStmt{DatabaseName: dbName, TableName: tableName, IfNotExists: ifNotExists, Reference: reference}
 
 	// Expect opening parenthesis.
 	if !p.expectPeek(TokenLParen) {
@@ -704,9 +716,293 @@ func (p *Parser) parseCreate() (*CreateTableStmt, error) {
 		}
 		return nil, p.syntaxError(")")
 	}
+
+	// Parse optional SHARD BY clause; SHARD is accepted as an identifier.
+	if p.peek.Type == TokenIdent && strings.EqualFold(p.peek.Value, "SHARD") {
+		p.nextToken() // consume SHARD
+		column, err := p.parseShardBy()
+		if err != nil {
+			return nil, err
+		}
+		stmt.ShardBy = column
+	}
+
+	// Parse optional PARTITION BY clause.
+	if p.peek.Type == TokenKeyword && p.peek.Value == "PARTITION" {
+		p.nextToken() // consume PARTITION
+		spec, err := p.parsePartitionBy()
+		if err != nil {
+			return nil, err
+		}
+		stmt.PartitionBy = spec
+	}
+
+	// Parse optional WITH (...) table options.
+	if p.peek.Type == TokenKeyword && p.peek.Value == "WITH" {
+		p.nextToken() // consume WITH
+		ttl, err := p.parseTableOptions()
+		if err != nil {
+			return nil, err
+		}
+		stmt.TTL = ttl
+	}
 	return stmt, nil
 }
 
+// parseShardBy parses the SHARD BY clause of CREATE TABLE.
+// The current token is SHARD.
+// Syntax: SHARD BY (<column>)
+//
+// Returns the shard key column.
+func (p *Parser) parseShardBy() (string, error) {
+	if !p.expectPeek(TokenKeyword) || p.cur.Value != "BY" {
+		return "", p.syntaxError("BY after SHARD")
+	}
+	if !p.expectPeek(TokenLParen) {
+		return "", p.syntaxError("( before shard key column")
+	}
+	if p.peek.Type != TokenIdent && p.peek.Type != TokenKeyword {
+		return "", p.syntaxError("shard key column")
+	}
+	p.nextToken()
+	column := p.cur.Value
+	if !p.expectPeek(TokenRParen) {
+		return "", p.syntaxError(") after shard key column")
+	}
+	return column, nil
+}
+
+// parseTableOptions parses the WITH (...) options of CREATE TABLE.
+// The current token is WITH.
+// Syntax: WITH (<option> = <value>, ...)
+// Supported options: TTL ('<interval>'), TTL_COLUMN (<column>)
+//
+// Returns the table's TTLSpec.
+func (p *Parser) parseTableOptions() (*TTLSpec, error) {
+	if !p.expectPeek(TokenLParen) {
+		return nil, p.syntaxError("( after WITH")
+	}
+
+	ttl := &TTLSpec{}
+	for {
+		p.nextToken()
+		if p.cur.Type != TokenIdent && p.cur.Type != TokenKeyword {
+			return nil, p.syntaxErrorCur("table option name")
+		}
+		optionName := strings.ToUpper(p.cur.Value)
+
+		if !p.expectPeek(TokenEqual) {
+			return nil, p.syntaxError("= after " + optionName)
+		}
+		p.nextToken()
+		if p.cur.Type != TokenString && p.cur.Type != TokenIdent && p.cur.Type != TokenNumber {
+			return nil, p.syntaxErrorCur("value for option " + optionName)
+		}
+
+		switch optionName {
+		case "TTL":
+			ttl.Interval = p.cur.Value
+		case "TTL_COLUMN":
+			ttl.Column = p.cur.Value
+		default:
+			return nil, p.syntaxErrorCur("known table option (" + optionName + " is unknown)")
+		}
+
+		if p.peek.Type != TokenComma {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(TokenRParen) {
+		return nil, p.syntaxError(") after table options")
+	}
+	if ttl.Column == "" {
+		return nil, p.syntaxErrorCur("TTL_COLUMN option")
+	}
+	return ttl, nil
+}
+
+// parsePartitionBy parses the PARTITION BY clause of CREATE TABLE.
+// The current token is PARTITION.
+// Syntax: PARTITION BY RANGE|LIST|HASH (<column>) [(PARTITION <name> <bound>, ...)]
+//
+// Returns a PartitionSpec.
+func (p *Parser) parsePartitionBy() (*PartitionSpec, error) {
+	if !p.expectPeek(TokenKeyword) || p.cur.Value != "BY" {
+		return nil, p.syntaxError("BY after PARTITION")
+	}
+
+	// RANGE is a keyword; LIST and HASH are accepted as identifiers
+	p.nextToken()
+	spec := &PartitionSpec{Strategy: PartitionStrategy(strings.ToUpper(p.cur.Value))}
+	switch spec.Strategy {
+	case PartitionByRange, PartitionByList, PartitionByHash:
+	default:
+		return nil, p.syntaxErrorCur("RANGE, LIST, or HASH after PARTITION BY")
+	}
+
+	if !p.expectPeek(TokenLParen) {
+		return nil, p.syntaxError("( before partition key column")
+	}
+	if p.peek.Type != TokenIdent && p.peek.Type != TokenKeyword {
+		return nil, p.syntaxError("partition key column")
+	}
+	p.nextToken()
+	spec.Column = p.cur.Value
+	if !p.expectPeek(TokenRParen) {
+		return nil, p.syntaxError(") after partition key column")
+	}
+
+	// Parse optional partition definitions
+	if p.peek.Type != TokenLParen {
+		return spec, nil
+	}
+	p.nextToken() // consume (
+	for {
+		if !p.expectPeek(TokenKeyword) || p.cur.Value != "PARTITION" {
+			return nil, p.syntaxError("PARTITION")
+		}
+		def, err := p.parsePartitionDef()
+		if err != nil {
+			return nil, err
+		}
+		spec.Partitions = append(spec.Partitions, *def)
+
+		if p.peek.Type != TokenComma {
+			break
+		}
+		p.nextToken() // consume comma
+	}
+	if !p.expectPeek(TokenRParen) {
+		return nil, p.syntaxError(") after partition definitions")
+	}
+	return spec, nil
+}
+
+// parsePartitionDef parses a partition name followed by its bound.
+// Syntax: <name> DEFAULT
+//
+//	<name> FOR VALUES FROM (<low>) TO (<high>)
+//	<name> FOR VALUES IN (<value>, ...)
+//	<name> FOR VALUES WITH (MODULUS <m>, REMAINDER <r>)
+//
+// Returns a PartitionDef.
+func (p *Parser) parsePartitionDef() (*PartitionDef, error) {
+	if !p.expectPeek(TokenIdent) {
+		return nil, p.syntaxError("partition name")
+	}
+	def := &PartitionDef{Name: p.cur.Value}
+
+	if p.peek.Type == TokenKeyword && p.peek.Value == "DEFAULT" {
+		p.nextToken()
+		def.IsDefault = true
+		return def, nil
+	}
+
+	if !p.expectPeek(TokenKeyword) || p.cur.Value != "FOR" {
+		return nil, p.syntaxError("FOR VALUES or DEFAULT after partition name")
+	}
+	if !p.expectPeek(TokenKeyword) || p.cur.Value != "VALUES" {
+		return nil, p.syntaxError("VALUES after FOR")
+	}
+
+	p.nextToken()
+	switch p.cur.Value {
+	case "FROM":
+		from, err := p.parsePartitionBoundValue()
+		if err != nil {
+			return nil, err
+		}
+		if !p.expectPeek(TokenKeyword) || p.cur.Value != "TO" {
+			return nil, p.syntaxError("TO after FROM bound")
+		}
+		to, err := p.parsePartitionBoundValue()
+		if err != nil {
+			return nil, err
+		}
+		def.From, def.To = from, to
+
+	case "IN":
+		if !p.expectPeek(TokenLParen) {
+			return nil, p.syntaxError("( after IN")
+		}
+		for {
+			p.nextToken()
+			if p.cur.Type != TokenString && p.cur.Type != TokenNumber && p.cur.Type != TokenIdent {
+				return nil, p.syntaxErrorCur("partition value")
+			}
+			def.Values = append(def.Values, p.cur.Value)
+			if p.peek.Type != TokenComma {
+				break
+			}
+			p.nextToken() // consume comma
+		}
+		if !p.expectPeek(TokenRParen) {
+			return nil, p.syntaxError(") after partition values")
+		}
+
+	case "WITH":
+		if !p.expectPeek(TokenLParen) {
+			return nil, p.syntaxError("( after WITH")
+		}
+		for _, name := range []string{"MODULUS", "REMAINDER"} {
+			p.nextToken()
+			if strings.ToUpper(p.cur.Value) != name {
+				return nil, p.syntaxErrorCur(name)
+			}
+			if !p.expectPeek(TokenNumber) {
+				return nil, p.syntaxError("number after " + name)
+			}
+			n, err := strconv.Atoi(p.cur.Value)
+			if err != nil {
+				return nil, p.syntaxErrorCur("integer after " + name)
+			}
+			if name == "MODULUS" {
+				def.Modulus = n
+				if !p.expectPeek(TokenComma) {
+					return nil, p.syntaxError(", after MODULUS")
+				}
+			} else {
+				def.Remainder = n
+			}
+		}
+		if !p.expectPeek(TokenRParen) {
+			return nil, p.syntaxError(") after REMAINDER")
+		}
+
+	default:
+		return nil, p.syntaxErrorCur("FROM, IN, or WITH after FOR VALUES")
+	}
+
+	return def, nil
+}
+
+// parsePartitionBoundValue parses a parenthesized RANGE bound.
+// MINVALUE and MAXVALUE denote an open bound and return "".
+func (p *Parser) parsePartitionBoundValue() (string, error) {
+	if !p.expectPeek(TokenLParen) {
+		return "", p.syntaxError("( before range bound")
+	}
+	p.nextToken()
+	if p.cur.Type != TokenString && p.cur.Type != TokenNumber && p.cur.Type != TokenIdent {
+		return "", p.syntaxErrorCur("range bound")
+	}
+	value := p.cur.Value
+	if p.cur.Type == TokenIdent {
+		switch strings.ToUpper(value) {
+		case "MINVALUE", "MAXVALUE":
+			value = ""
+		default:
+			return "", p.syntaxErrorCur("range bound")
+		}
+	}
+	if !p.expectPeek(TokenRParen) {
+		return "", p.syntaxError(") after range bound")
+	}
+	return value, nil
+}
+
 // parseColumnConstraints parses column-level constraints after the column type.
 // Supported constraints: PRIMARY KEY, NOT NULL, UNIQUE, AUTO_INCREMENT, DEFAULT, REFERENCES
 func (p *Parser) parseColumnConstraints() ([]ColumnConstraint, error) {
@@ -2474,7 +2770,7 @@ func (p *Parser) parseInspect() (*InspectStmt, error) {
 	// Normalize to uppercase for case-insensitive matching
 	target := strings.ToUpper(p.cur.Value)
 	switch target {
-	case "USERS", "TABLES", "INDEXES", "SERVER", "STATUS", "DATABASES", "ROLES", "PRIVILEGES":
+	case "USERS", "TABLES", "INDEXES", "SERVER", "STATUS", "DATABASES", "ROLES", "PRIVILEGES", "CLUSTER":
 		// These targets don't take an object name
 		return &InspectStmt{Target: target}, nil
 	case "AUDIT":
@@ -3201,6 +3497,47 @@ func (p *Parser) parseTruncate() (*TruncateTableStmt, error) {
 	return &TruncateTableStmt{DatabaseName: dbName, TableName: tableName}, nil
 }
 
+// parseAnalyze parses an ANALYZE statement.
+// Syntax: ANALYZE [<table_name>]
+//
+// Example: ANALYZE orders
+//
+// Returns an AnalyzeStmt AST node.
+func (p *Parser) parseAnalyze() (*AnalyzeStmt, error) {
+	// Skip ANALYZE keyword; without a table name every table is analyzed
+	if p.peek.Type == TokenEOF || p.peek.Type == TokenSemicolon {
+		return &AnalyzeStmt{}, nil
+	}
+
+	dbName, tableName, err := p.parseTableIdentifier()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AnalyzeStmt{DatabaseName: dbName, TableName: tableName}, nil
+}
+
+// parseRepair parses a REPAIR statement.
+// Syntax: REPAIR PARTITION <n>
+//
+// Example: REPAIR PARTITION 17
+//
+// REPAIR is not a reserved keyword. Returns a RepairPartitionStmt AST node.
+func (p *Parser) parseRepair() (*RepairPartitionStmt, error) {
+	if p.peek.Type != TokenKeyword || p.peek.Value != "PARTITION" {
+		return nil, p.syntaxError("PARTITION after REPAIR")
+	}
+	p.nextToken()
+	if !p.expectPeek(TokenNumber) {
+		return nil, p.syntaxError("partition number after REPAIR PARTITION")
+	}
+	id, err := strconv.Atoi(p.cur.Value)
+	if err != nil || id < 0 {
+		return nil, p.syntaxErrorCur("partition number after REPAIR PARTITION")
+	}
+	return &RepairPartitionStmt{PartitionID: id}, nil
+}
+
 // parseAlter parses an ALTER statement (ALTER TABLE or ALTER USER).
 // Syntax:
 //
@@ -3209,12 +3546,19 @@ func (p *Parser) parseTruncate() (*TruncateTableStmt, error) {
 //	ALTER TABLE <table_name> RENAME COLUMN <old_name> TO <new_name>
 //	ALTER TABLE <table_name> MODIFY COLUMN <column_name> <new_type>
 //	ALTER USER <username> IDENTIFIED BY '<new_password>'
+//	ALTER CLUSTER ADD NODE '<addr>' [AS LEARNER]
+//	ALTER CLUSTER REMOVE NODE '<addr>'
+//	ALTER CLUSTER TRANSFER LEADERSHIP [TO '<addr>']
 //
-// Returns an AlterTableStmt or AlterUserStmt AST node.
+// Returns an AlterTableStmt, AlterUserStmt or AlterClusterStmt AST node.
 func (p *Parser) parseAlter() (Statement, error) {
-	// Check what follows ALTER
+	// Check what follows ALTER (CLUSTER is not a reserved keyword)
+	if p.peek.Type == TokenIdent && strings.ToUpper(p.peek.Value) == "CLUSTER" {
+		p.nextToken()
+		return p.parseAlterCluster()
+	}
 	if !p.expectPeek(TokenKeyword) {
-		return nil, p.syntaxError("TABLE or USER after ALTER")
+		return nil, p.syntaxError("TABLE, USER or CLUSTER after ALTER")
 	}
 
 	switch p.cur.Value {
@@ -3223,10 +3567,69 @@ func (p *Parser) parseAlter() (Statement, error) {
 	case "TABLE":
 		return p.parseAlterTable()
 	default:
-		return nil, p.syntaxError("TABLE or USER after ALTER")
+		return nil, p.syntaxErrorCur("TABLE, USER or CLUSTER after ALTER")
 	}
 }
 
+// parseAlterCluster parses an ALTER CLUSTER statement.
+// Syntax:
+//
+//	ALTER CLUSTER ADD NODE '<addr>' [AS LEARNER]
+//	ALTER CLUSTER REMOVE NODE '<addr>'
+//	ALTER CLUSTER TRANSFER LEADERSHIP [TO '<addr>']
+//
+// NODE, REMOVE, TRANSFER, LEADERSHIP and LEARNER are not reserved keywords.
+//
+// Returns an AlterClusterStmt AST node.
+func (p *Parser) parseAlterCluster() (*AlterClusterStmt, error) {
+	stmt := &AlterClusterStmt{}
+
+	p.nextToken()
+	switch strings.ToUpper(p.cur.Value) {
+	case "ADD":
+		stmt.Action = AlterClusterAddNode
+	case "REMOVE":
+		stmt.Action = AlterClusterRemoveNode
+	case "TRANSFER":
+		stmt.Action = AlterClusterTransferLeadership
+		if strings.ToUpper(p.peek.Value) != "LEADERSHIP" {
+			return nil, p.syntaxError("LEADERSHIP after TRANSFER")
+		}
+		p.nextToken()
+		// Without TO, the most up-to-date voter takes over
+		if p.peek.Type != TokenKeyword || p.peek.Value != "TO" {
+			return stmt, nil
+		}
+		p.nextToken()
+		if !p.expectPeek(TokenString) {
+			return nil, p.syntaxError("node address after TO")
+		}
+		stmt.Address = p.cur.Value
+		return stmt, nil
+	default:
+		return nil, p.syntaxErrorCur("ADD, REMOVE or TRANSFER after ALTER CLUSTER")
+	}
+
+	if strings.ToUpper(p.peek.Value) != "NODE" {
+		return nil, p.syntaxError("NODE after " + strings.ToUpper(p.cur.Value))
+	}
+	p.nextToken()
+	if !p.expectPeek(TokenString) {
+		return nil, p.syntaxError("node address after NODE")
+	}
+	stmt.Address = p.cur.Value
+
+	if stmt.Action == AlterClusterAddNode && p.peek.Type == TokenKeyword && p.peek.Value == "AS" {
+		p.nextToken()
+		if strings.ToUpper(p.peek.Value) != "LEARNER" {
+			return nil, p.syntaxError("LEARNER after AS")
+		}
+		p.nextToken()
+		stmt.Learner = true
+	}
+	return stmt, nil
+}
+
 // parseAlterUser parses an ALTER USER statement.
 // Syntax: ALTER USER <username> IDENTIFIED BY '<new_password>'
 //
@@ -3275,21 +3678,48 @@ func (p *Parser) parseAlterTable() (*AlterTableStmt, error) {
 	}
 	stmt := &AlterTableStmt{TableName: p.cur.Value}
 
-	// Parse the action
+	// Parse the action (ATTACH and DETACH are not reserved keywords)
 	p.nextToken()
-	if p.cur.Type != TokenKeyword {
+	action := strings.ToUpper(p.cur.Value)
+	if p.cur.Type != TokenKeyword && action != "ATTACH" && action != "DETACH" {
 		return nil, p.syntaxError("ADD, DROP, RENAME, or MODIFY after table name")
 	}
 
-	switch p.cur.Value {
+	switch action {
+	case "ATTACH", "DETACH":
+		if !p.expectPeek(TokenKeyword) || p.cur.Value != "PARTITION" {
+			return nil, p.syntaxError("PARTITION after " + action)
+		}
+		if action == "DETACH" {
+			stmt.Action = AlterActionDetachPartition
+			if !p.expectPeek(TokenIdent) {
+				return nil, p.syntaxError("partition name after DETACH PARTITION")
+			}
+			stmt.Partition = &PartitionDef{Name: p.cur.Value}
+			break
+		}
+		stmt.Action = AlterActionAttachPartition
+		def, err := p.parsePartitionDef()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Partition = def
+
 	case "ADD":
-		// Check if it's ADD COLUMN or ADD CONSTRAINT
+		// Check if it's ADD COLUMN, ADD CONSTRAINT or ADD PARTITION
 		p.nextToken()
 		if p.cur.Type != TokenKeyword {
 			return nil, p.syntaxError("COLUMN or CONSTRAINT after ADD")
 		}
 
-		if p.cur.Value == "COLUMN" {
+		if p.cur.Value == "PARTITION" {
+			stmt.Action = AlterActionAddPartition
+			def, err := p.parsePartitionDef()
+			if err != nil {
+				return nil, err
+			}
+			stmt.Partition = def
+		} else if p.cur.Value == "COLUMN" {
 			stmt.Action = AlterActionAddColumn
 			// Parse column name
 			if !p.expectPeek(TokenIdent) {
@@ -3339,6 +3769,12 @@ func (p *Parser) parseAlterTable() (*AlterTableStmt, error) {
 				return nil, p.syntaxError("column name after DROP COLUMN")
 			}
 			stmt.ColumnName = p.cur.Value
+		} else if p.cur.Value == "PARTITION" {
+			stmt.Action = AlterActionDropPartition
+			if !p.expectPeek(TokenIdent) {
+				return nil, p.syntaxError("partition name after DROP PARTITION")
+			}
+			stmt.Partition = &PartitionDef{Name: p.cur.Value}
 		} else if p.cur.Value == "CONSTRAINT" {
 			stmt.Action = AlterActionDropConstraint
 			// Parse constraint name
@@ -113,6 +113,167 @@ func TestParseCreateIndex(t *testing.T) {
 	}
 }
 
+func TestParseAnalyze(t *testing.T) {
+	stmt := parse(t, "ANALYZE shop.orders")
+	analyzeStmt, ok := stmt.(*AnalyzeStmt)
+	if !ok {
+		t.Fatalf("Expected AnalyzeStmt, got %T", stmt)
+	}
+	if analyzeStmt.DatabaseName != "shop" || analyzeStmt.TableName != "orders" {
+		t.Errorf("Expected shop.orders, got %s.%s", analyzeStmt.DatabaseName, analyzeStmt.TableName)
+	}
+
+	stmt = parse(t, "ANALYZE")
+	if analyzeStmt, ok := stmt.(*AnalyzeStmt); !ok || analyzeStmt.TableName != "" {
+		t.Errorf("Expected AnalyzeStmt for all tables, got %#v", stmt)
+	}
+}
+
+func TestParsePartitionBy(t *testing.T) {
+	stmt := parse(t, `CREATE TABLE events (id INT, created_at TEXT) PARTITION BY RANGE (created_at) (
+		PARTITION events_old FOR VALUES FROM (MINVALUE) TO ('2024-01-01'),
+		PARTITION events_2024 FOR VALUES FROM ('2024-01-01') TO ('2025-01-01'),
+		PARTITION events_rest DEFAULT)`)
+	createStmt, ok := stmt.(*CreateTableStmt)
+	if !ok {
+		t.Fatalf("Expected CreateTableStmt, got %T", stmt)
+	}
+	spec := createStmt.PartitionBy
+	if spec == nil || spec.Strategy != PartitionByRange || spec.Column != "created_at" {
+		t.Fatalf("Expected RANGE (created_at), got %#v", spec)
+	}
+	if len(spec.Partitions) != 3 {
+		t.Fatalf("Expected 3 partitions, got %d", len(spec.Partitions))
+	}
+	if p := spec.Partitions[0]; p.Name != "events_old" || p.From != "" || p.To != "2024-01-01" {
+		t.Errorf("Unexpected first partition %#v", p)
+	}
+	if !spec.Partitions[2].IsDefault {
+		t.Errorf("Expected a DEFAULT partition, got %#v", spec.Partitions[2])
+	}
+
+	stmt = parse(t, "CREATE TABLE users (id INT, region TEXT) PARTITION BY HASH (id) (PARTITION users_0 FOR VALUES WITH (MODULUS 2, REMAINDER 0))")
+	if p := stmt.(*CreateTableStmt).PartitionBy.Partitions[0]; p.Modulus != 2 || p.Remainder != 0 {
+		t.Errorf("Unexpected hash partition %#v", p)
+	}
+
+	tests := []struct {
+		input  string
+		action AlterTableAction
+		name   string
+	}{
+		{"ALTER TABLE users ADD PARTITION users_eu FOR VALUES IN ('de', 'fr')", AlterActionAddPartition, "users_eu"},
+		{"ALTER TABLE users ATTACH PARTITION users_us FOR VALUES IN ('us')", AlterActionAttachPartition, "users_us"},
+		{"ALTER TABLE users DETACH PARTITION users_eu", AlterActionDetachPartition, "users_eu"},
+		{"ALTER TABLE users DROP PARTITION users_eu", AlterActionDropPartition, "users_eu"},
+	}
+	for _, tt := range tests {
+		alterStmt, ok := parse(t, tt.input).(*AlterTableStmt)
+		if !ok {
+			t.Fatalf("%s: expected AlterTableStmt", tt.input)
+		}
+		if alterStmt.Action != tt.action || alterStmt.Partition == nil || alterStmt.Partition.Name != tt.name {
+			t.Errorf("%s: got action %s, partition %#v", tt.input, alterStmt.Action, alterStmt.Partition)
+		}
+	}
+}
+
+func TestParseShardBy(t *testing.T) {
+	stmt := parse(t, "CREATE TABLE invoices (id INT, tenant_id INT) SHARD BY (tenant_id) PARTITION BY HASH (id)")
+	createStmt, ok := stmt.(*CreateTableStmt)
+	if !ok {
+		t.Fatalf("Expected CreateTableStmt, got %T", stmt)
+	}
+	if createStmt.ShardBy != "tenant_id" || createStmt.Reference || createStmt.PartitionBy == nil {
+		t.Errorf("Unexpected statement %#v", createStmt)
+	}
+
+	stmt = parse(t, "CREATE REFERENCE TABLE IF NOT EXISTS countries (code TEXT, name TEXT)")
+	if createStmt, ok := stmt.(*CreateTableStmt); !ok || !createStmt.Reference || !createStmt.IfNotExists || createStmt.TableName != "countries" {
+		t.Errorf("Expected a reference table, got %#v", stmt)
+	}
+
+	for _, input := range []string{
+		"CREATE TABLE t (id INT) SHARD (id)",
+		"CREATE TABLE t (id INT) SHARD BY id",
+		"CREATE REFERENCE t (id INT)",
+	} {
+		if _, err := NewParser(NewLexer(input)).Parse(); err == nil {
+			t.Errorf("%s: expected a syntax error", input)
+		}
+	}
+}
+
+func TestParseAlterCluster(t *testing.T) {
+	tests := []struct {
+		input   string
+		action  AlterClusterAction
+		address string
+		learner bool
+	}{
+		{"ALTER CLUSTER ADD NODE 'node4:9997'", AlterClusterAddNode, "node4:9997", false},
+		{"alter cluster add node 'node5:9997' as learner", AlterClusterAddNode, "node5:9997", true},
+		{"ALTER CLUSTER REMOVE NODE 'node1:9997'", AlterClusterRemoveNode, "node1:9997", false},
+		{"ALTER CLUSTER TRANSFER LEADERSHIP TO 'node2:9997'", AlterClusterTransferLeadership, "node2:9997", false},
+		{"ALTER CLUSTER TRANSFER LEADERSHIP", AlterClusterTransferLeadership, "", false},
+	}
+	for _, tt := range tests {
+		stmt, ok := parse(t, tt.input).(*AlterClusterStmt)
+		if !ok {
+			t.Fatalf("%s: expected AlterClusterStmt", tt.input)
+		}
+		if stmt.Action != tt.action || stmt.Address != tt.address || stmt.Learner != tt.learner {
+			t.Errorf("%s: got %#v", tt.input, stmt)
+		}
+	}
+
+	for _, input := range []string{
+		"ALTER CLUSTER ADD 'node4:9997'",
+		"ALTER CLUSTER REMOVE NODE node1",
+		"ALTER CLUSTER ADD NODE 'node4:9997' AS VOTER",
+		"ALTER CLUSTER RESTART",
+	} {
+		if _, err := NewParser(NewLexer(input)).Parse(); err == nil {
+			t.Errorf("%s: expected a syntax error", input)
+		}
+	}
+}
+
+func TestParseRepairPartition(t *testing.T) {
+	for input, id := range map[string]int{"REPAIR PARTITION 17": 17, "repair partition 0;": 0} {
+		stmt, ok := parse(t, input).(*RepairPartitionStmt)
+		if !ok || stmt.PartitionID != id {
+			t.Errorf("%s: got %#v", input, stmt)
+		}
+	}
+	for _, input := range []string{"REPAIR PARTITION", "REPAIR TABLE orders", "REPAIR PARTITION 'a'"} {
+		if _, err := NewParser(NewLexer(input)).Parse(); err == nil {
+			t.Errorf("%s: expected a syntax error", input)
+		}
+	}
+}
+
+func TestParseTableOptions(t *testing.T) {
+	stmt := parse(t, "CREATE TABLE sessions (id INT, created_at TIMESTAMP) WITH (ttl = '30 days', ttl_column = created_at)")
+	createStmt, ok := stmt.(*CreateTableStmt)
+	if !ok {
+		t.Fatalf("Expected CreateTableStmt, got %T", stmt)
+	}
+	if createStmt.TTL == nil || createStmt.TTL.Interval != "30 days" || createStmt.TTL.Column != "created_at" {
+		t.Errorf("Unexpected TTL %#v", createStmt.TTL)
+	}
+
+	for _, input := range []string{
+		"CREATE TABLE s (id INT) WITH (ttl = '1 day')",
+		"CREATE TABLE s (id INT) WITH (fillfactor = 70, ttl_column = id)",
+		"CREATE TABLE s (id INT) WITH ttl_column = id",
+	} {
+		if _, err := NewParser(NewLexer(input)).Parse(); err == nil {
+			t.Errorf("Expected parse error for %q", input)
+		}
+	}
+}
+
 func TestParsePrepare(t *testing.T) {
 	input := "PREPARE get_user AS SELECT * FROM users WHERE id = $1"
 	lexer := NewLexer(input)
@@ -1714,6 +1875,7 @@ func TestParseIntrospectRoles(t *testing.T) {
 		{"INSPECT USER alice ROLES", "USER_ROLES", "alice"},
 		{"INSPECT USER alice PRIVILEGES", "USER_PRIVILEGES", "alice"},
 		{"INSPECT PRIVILEGES", "PRIVILEGES", ""},
+		{"INSPECT CLUSTER", "CLUSTER", ""},
 	}
 
 	for _, tt := range tests {
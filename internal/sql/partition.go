@@ -0,0 +1,456 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Table Partitioning:
+===================
+
+A partitioned table splits its rows by the value of one column. Each
+partition's rows live under their own key prefix below the table's prefix:
+
+	Unpartitioned:  row:<table>:<id>
+	Partitioned:    row:<table>:<partition>:<id>
+
+Because partition prefixes nest inside the table prefix, a full table scan
+still sees every row, while a query whose WHERE clause restricts the
+partition key only scans the partitions that can match (pruning), and
+dropping a partition only touches that partition's keys.
+
+Strategies:
+  - RANGE: FROM is inclusive, TO is exclusive; MINVALUE/MAXVALUE are open
+  - LIST:  an explicit list of values
+  - HASH:  FNV-1a hash of the value modulo MODULUS equals REMAINDER
+
+Rows whose key matches no partition (including NULL keys) go to the
+DEFAULT partition, or are rejected if there is none.
+
+This is partitioning within a single node; distributing data across nodes
+is handled by internal/cluster.
+*/
+package sql
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	ferrors "flydb/internal/errors"
+)
+
+// partitionKeyPrefix returns the storage key prefix of a partition's rows.
+func partitionKeyPrefix(table, partition string) string {
+	return "row:" + table + ":" + partition + ":"
+}
+
+// partitionOfKey returns the partition a row key belongs to, or "" for a
+// row of an unpartitioned table.
+func partitionOfKey(table, key string) string {
+	rest := strings.TrimPrefix(key, "row:"+table+":")
+	if i := strings.LastIndexByte(rest, ':'); i >= 0 {
+		return rest[:i]
+	}
+	return ""
+}
+
+// rowKeyID returns the row id at the end of a row key.
+func rowKeyID(key string) string {
+	return key[strings.LastIndexByte(key, ':')+1:]
+}
+
+// isNullPartitionValue reports whether a partition key value is NULL.
+func isNullPartitionValue(v string) bool {
+	return v == "" || v == "NULL" || v == "<nil>"
+}
+
+// partitionValue returns a row's partition key value as a string.
+func partitionValue(spec *PartitionSpec, row map[string]interface{}) string {
+	v, ok := row[spec.Column]
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// conditionWhere converts a simple equality condition to a WHERE clause
+// for pruning.
+func conditionWhere(c *Condition) *WhereClause {
+	if c == nil {
+		return nil
+	}
+	return &WhereClause{Column: c.Column, Operator: "=", Value: c.Value}
+}
+
+// partitionColumnType returns the type of the partition key column, or ""
+// if the table has no such column.
+func partitionColumnType(columns []ColumnDef, column string) string {
+	for _, col := range columns {
+		if col.Name == column {
+			return col.Type
+		}
+	}
+	return ""
+}
+
+// sameColumns reports whether two tables have the same column names and
+// types in the same order.
+func sameColumns(a, b []ColumnDef) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || !strings.EqualFold(a[i].Type, b[i].Type) {
+			return false
+		}
+	}
+	return true
+}
+
+// Find returns the partition with the given name.
+func (s *PartitionSpec) Find(name string) (PartitionDef, bool) {
+	for _, def := range s.Partitions {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return PartitionDef{}, false
+}
+
+// without returns a copy of the spec without the named partition.
+func (s *PartitionSpec) without(name string) *PartitionSpec {
+	spec := &PartitionSpec{Strategy: s.Strategy, Column: s.Column}
+	for _, def := range s.Partitions {
+		if def.Name != name {
+			spec.Partitions = append(spec.Partitions, def)
+		}
+	}
+	return spec
+}
+
+// defaultPartition returns the DEFAULT partition, if any.
+func (s *PartitionSpec) defaultPartition() (PartitionDef, bool) {
+	for _, def := range s.Partitions {
+		if def.IsDefault {
+			return def, true
+		}
+	}
+	return PartitionDef{}, false
+}
+
+// Route returns the partition that holds rows whose partition key is value.
+func (s *PartitionSpec) Route(value string, compare func(a, b string) int) (PartitionDef, bool) {
+	if !isNullPartitionValue(value) {
+		for _, def := range s.Partitions {
+			if !def.IsDefault && def.accepts(s.Strategy, value, compare) {
+				return def, true
+			}
+		}
+	}
+	return s.defaultPartition()
+}
+
+// accepts reports whether a non-default partition holds value.
+func (d PartitionDef) accepts(strategy PartitionStrategy, value string, compare func(a, b string) int) bool {
+	switch strategy {
+	case PartitionByRange:
+		return (d.From == "" || compare(value, d.From) >= 0) && (d.To == "" || compare(value, d.To) < 0)
+	case PartitionByList:
+		for _, v := range d.Values {
+			if compare(value, v) == 0 {
+				return true
+			}
+		}
+	case PartitionByHash:
+		return d.Modulus > 0 && int(partitionHash(value)%uint32(d.Modulus)) == d.Remainder
+	}
+	return false
+}
+
+// partitionHash hashes a partition key value for HASH partitioning.
+func partitionHash(value string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(value))
+	return h.Sum32()
+}
+
+// Bound returns the partition bound in CREATE TABLE syntax.
+func (d PartitionDef) Bound() string {
+	switch {
+	case d.IsDefault:
+		return "DEFAULT"
+	case d.Modulus > 0:
+		return fmt.Sprintf("FOR VALUES WITH (MODULUS %d, REMAINDER %d)", d.Modulus, d.Remainder)
+	case len(d.Values) > 0:
+		quoted := make([]string, len(d.Values))
+		for i, v := range d.Values {
+			quoted[i] = "'" + v + "'"
+		}
+		return fmt.Sprintf("FOR VALUES IN (%s)", strings.Join(quoted, ", "))
+	}
+	from, to := "MINVALUE", "MAXVALUE"
+	if d.From != "" {
+		from = "'" + d.From + "'"
+	}
+	if d.To != "" {
+		to = "'" + d.To + "'"
+	}
+	return fmt.Sprintf("FOR VALUES FROM (%s) TO (%s)", from, to)
+}
+
+// normalizePartitionDef converts a partition's bounds to the canonical form
+// of the partition key's column type, so they compare like stored values.
+func normalizePartitionDef(def PartitionDef, colType string) (PartitionDef, error) {
+	normalize := func(v string) (string, error) {
+		if v == "" {
+			return v, nil
+		}
+		return NormalizeValue(colType, v)
+	}
+
+	var err error
+	if def.From, err = normalize(def.From); err != nil {
+		return def, err
+	}
+	if def.To, err = normalize(def.To); err != nil {
+		return def, err
+	}
+	values := make([]string, len(def.Values))
+	for i, v := range def.Values {
+		if values[i], err = normalize(v); err != nil {
+			return def, err
+		}
+	}
+	if len(values) > 0 {
+		def.Values = values
+	}
+	return def, nil
+}
+
+// validatePartition checks that a new partition suits the table's strategy
+// and does not overlap any existing partition.
+func validatePartition(spec *PartitionSpec, def PartitionDef, compare func(a, b string) int) error {
+	if def.Name == "" {
+		return ferrors.MissingRequired("partition name")
+	}
+	if _, exists := spec.Find(def.Name); exists {
+		return ferrors.InvalidValue("partition", fmt.Sprintf("partition %s already exists", def.Name))
+	}
+
+	if def.IsDefault {
+		if spec.Strategy == PartitionByHash {
+			return ferrors.InvalidValue("partition", "a hash-partitioned table cannot have a DEFAULT partition")
+		}
+		if existing, ok := spec.defaultPartition(); ok {
+			return ferrors.InvalidValue("partition", fmt.Sprintf("partition %s is already the DEFAULT partition", existing.Name))
+		}
+		return nil
+	}
+
+	mismatch := ferrors.InvalidValue("partition", fmt.Sprintf("partition %s bound %s does not match PARTITION BY %s", def.Name, def.Bound(), spec.Strategy))
+	switch spec.Strategy {
+	case PartitionByRange:
+		if len(def.Values) > 0 || def.Modulus > 0 {
+			return mismatch
+		}
+		if def.From != "" && def.To != "" && compare(def.From, def.To) >= 0 {
+			return ferrors.InvalidValue("partition", fmt.Sprintf("partition %s has an empty range", def.Name))
+		}
+		for _, p := range spec.Partitions {
+			if p.IsDefault {
+				continue
+			}
+			if (def.From == "" || p.To == "" || compare(def.From, p.To) < 0) &&
+				(p.From == "" || def.To == "" || compare(p.From, def.To) < 0) {
+				return partitionOverlap(def, p)
+			}
+		}
+
+	case PartitionByList:
+		if len(def.Values) == 0 {
+			return mismatch
+		}
+		for _, v := range def.Values {
+			if isNullPartitionValue(v) {
+				return ferrors.InvalidValue("partition", "NULL values belong to the DEFAULT partition")
+			}
+			for _, p := range spec.Partitions {
+				if !p.IsDefault && p.accepts(PartitionByList, v, compare) {
+					return partitionOverlap(def, p)
+				}
+			}
+		}
+
+	case PartitionByHash:
+		if def.Modulus <= 0 {
+			return mismatch
+		}
+		if def.Remainder < 0 || def.Remainder >= def.Modulus {
+			return ferrors.InvalidValue("partition", "hash REMAINDER must be between 0 and MODULUS - 1")
+		}
+		// Two hash partitions share values when their remainders agree
+		// modulo the greatest common divisor of their moduli.
+		for _, p := range spec.Partitions {
+			g := gcd(def.Modulus, p.Modulus)
+			if def.Remainder%g == p.Remainder%g {
+				return partitionOverlap(def, p)
+			}
+		}
+
+	default:
+		return ferrors.InvalidValue("partition", fmt.Sprintf("unknown partition strategy %s", spec.Strategy))
+	}
+	return nil
+}
+
+// partitionOverlap returns the error for two overlapping partitions.
+func partitionOverlap(def, existing PartitionDef) error {
+	return ferrors.InvalidValue("partition", fmt.Sprintf("partition %s would overlap partition %s", def.Name, existing.Name))
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// Prune returns the partitions that may hold rows matching where, in
+// definition order. Conditions on other columns, and conditions the
+// pruner does not understand, keep every partition.
+func (s *PartitionSpec) Prune(where *WhereClause, compare func(a, b string) int) []PartitionDef {
+	keep := s.prune(where, compare)
+	var parts []PartitionDef
+	for i, def := range s.Partitions {
+		if keep[i] {
+			parts = append(parts, def)
+		}
+	}
+	return parts
+}
+
+// prune returns, per partition, whether it may hold matching rows. AND
+// binds tighter than OR, as in evaluateWhereClause.
+func (s *PartitionSpec) prune(where *WhereClause, compare func(a, b string) int) []bool {
+	if where == nil {
+		return s.pruneCondition(nil, compare)
+	}
+	keep := make([]bool, len(s.Partitions))
+	for _, group := range whereOrGroups(where) {
+		groupKeep := s.pruneCondition(group[0], compare)
+		for _, cond := range group[1:] {
+			next := s.pruneCondition(cond, compare)
+			for i := range groupKeep {
+				groupKeep[i] = groupKeep[i] && next[i]
+			}
+		}
+		for i := range keep {
+			keep[i] = keep[i] || groupKeep[i]
+		}
+	}
+	return keep
+}
+
+// pruneCondition evaluates a single WHERE condition.
+func (s *PartitionSpec) pruneCondition(where *WhereClause, compare func(a, b string) int) []bool {
+	keep := make([]bool, len(s.Partitions))
+	all := func() []bool {
+		for i := range keep {
+			keep[i] = true
+		}
+		return keep
+	}
+	if where == nil || where.IsSubquery || where.Column != s.Column {
+		return all()
+	}
+
+	// markRoute keeps the partition a value is routed to
+	markRoute := func(value string) {
+		if def, ok := s.Route(value, compare); ok {
+			for i := range s.Partitions {
+				if s.Partitions[i].Name == def.Name {
+					keep[i] = true
+				}
+			}
+		}
+	}
+
+	switch where.Operator {
+	case "=":
+		markRoute(where.Value)
+		return keep
+	case "IN":
+		for _, v := range where.Values {
+			markRoute(v)
+		}
+		return keep
+	case "<", "<=", ">", ">=", "BETWEEN":
+		if s.Strategy == PartitionByHash {
+			return all()
+		}
+	default:
+		return all()
+	}
+
+	// Range predicates: keep partitions whose values can fall in [lo, hi]
+	var lo, hi string
+	loIncl, hiIncl := true, true
+	switch where.Operator {
+	case "<":
+		hi, hiIncl = where.Value, false
+	case "<=":
+		hi = where.Value
+	case ">":
+		lo, loIncl = where.Value, false
+	case ">=":
+		lo = where.Value
+	case "BETWEEN":
+		lo, hi = where.BetweenLow, where.BetweenHigh
+	}
+	inInterval := func(v string) bool {
+		if lo != "" {
+			if c := compare(v, lo); c < 0 || (c == 0 && !loIncl) {
+				return false
+			}
+		}
+		if hi != "" {
+			if c := compare(v, hi); c > 0 || (c == 0 && !hiIncl) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for i, def := range s.Partitions {
+		switch {
+		case def.IsDefault:
+			keep[i] = true
+		case s.Strategy == PartitionByList:
+			for _, v := range def.Values {
+				if inInterval(v) {
+					keep[i] = true
+					break
+				}
+			}
+		default:
+			// RANGE [From, To) intersects the interval unless it lies
+			// entirely below lo or entirely above hi.
+			below := lo != "" && def.To != "" && compare(def.To, lo) <= 0
+			above := hi != "" && def.From != "" &&
+				(compare(def.From, hi) > 0 || (compare(def.From, hi) == 0 && !hiIncl))
+			keep[i] = !below && !above
+		}
+	}
+	return keep
+}
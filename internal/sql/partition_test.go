@@ -0,0 +1,279 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sql
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// createEvents creates an events table partitioned by year of created_at
+// and inserts one row into each partition.
+func createEvents(t *testing.T, exec *Executor) {
+	t.Helper()
+	queries := []string{
+		`CREATE TABLE events (id INT PRIMARY KEY, created_at TEXT, kind TEXT) PARTITION BY RANGE (created_at) (
+			PARTITION events_2023 FOR VALUES FROM ('2023-01-01') TO ('2024-01-01'),
+			PARTITION events_2024 FOR VALUES FROM ('2024-01-01') TO ('2025-01-01'),
+			PARTITION events_other DEFAULT)`,
+		"INSERT INTO events VALUES (1, '2023-06-01', 'click')",
+		"INSERT INTO events VALUES (2, '2024-03-15', 'view')",
+		"INSERT INTO events VALUES (3, '2025-02-01', 'click')",
+	}
+	for _, query := range queries {
+		if _, err := exec.Execute(parse(t, query)); err != nil {
+			t.Fatalf("%s failed: %v", query, err)
+		}
+	}
+}
+
+// partitionRows returns the number of rows stored in a partition.
+func partitionRows(t *testing.T, exec *Executor, table, partition string) int {
+	t.Helper()
+	rows, err := exec.store.Scan(partitionKeyPrefix(table, partition))
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	return len(rows)
+}
+
+func TestPartitionSpecRouteAndPrune(t *testing.T) {
+	compare := func(a, b string) int { return compareValuesWithCollator(a, b, nil) }
+	spec := &PartitionSpec{
+		Strategy: PartitionByRange,
+		Column:   "n",
+		Partitions: []PartitionDef{
+			{Name: "low", To: "10"},
+			{Name: "mid", From: "10", To: "100"},
+			{Name: "high", From: "100"},
+		},
+	}
+
+	routes := map[string]string{"-5": "low", "9": "low", "10": "mid", "99": "mid", "100": "high"}
+	for value, want := range routes {
+		if def, ok := spec.Route(value, compare); !ok || def.Name != want {
+			t.Errorf("Route(%s) = %s, want %s", value, def.Name, want)
+		}
+	}
+	if _, ok := spec.Route("NULL", compare); ok {
+		t.Error("Expected NULL to have no partition without a DEFAULT")
+	}
+
+	tests := []struct {
+		where *WhereClause
+		want  string
+	}{
+		{&WhereClause{Column: "n", Operator: "=", Value: "50"}, "mid"},
+		{&WhereClause{Column: "n", Operator: ">=", Value: "50"}, "mid,high"},
+		{&WhereClause{Column: "n", Operator: "<", Value: "10"}, "low"},
+		{&WhereClause{Column: "n", Operator: "=", Value: "5", Or: &WhereClause{Column: "n", Operator: "=", Value: "500"}}, "low,high"},
+		{&WhereClause{Column: "n", Operator: ">", Value: "5", And: &WhereClause{Column: "n", Operator: "<", Value: "20"}}, "low,mid"},
+		{&WhereClause{Column: "other", Operator: "=", Value: "1"}, "low,mid,high"},
+		// AND binds tighter than OR: (n = 5 AND n = 500) OR n = 50
+		{&WhereClause{Column: "n", Operator: "=", Value: "5", And: &WhereClause{Column: "n", Operator: "=", Value: "500", Or: &WhereClause{Column: "n", Operator: "=", Value: "50"}}}, "mid"},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, def := range spec.Prune(tt.where, compare) {
+			names = append(names, def.Name)
+		}
+		if got := strings.Join(names, ","); got != tt.want {
+			t.Errorf("Prune(%s %s %s) = %s, want %s", tt.where.Column, tt.where.Operator, tt.where.Value, got, tt.want)
+		}
+	}
+}
+
+func TestExecutorRangePartitioning(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	createEvents(t, exec)
+
+	for _, partition := range []string{"events_2023", "events_2024", "events_other"} {
+		if n := partitionRows(t, exec, "events", partition); n != 1 {
+			t.Errorf("Expected 1 row in %s, got %d", partition, n)
+		}
+	}
+
+	result, err := exec.Execute(parse(t, "SELECT id FROM events WHERE created_at >= '2024-01-01' AND created_at < '2025-01-01'"))
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if !strings.Contains(result, "2") || !strings.HasSuffix(result, "(1 rows)") {
+		t.Errorf("Expected only row 2, got:\n%s", result)
+	}
+
+	result, err = exec.Execute(parse(t, "SELECT id FROM events WHERE kind = 'click'"))
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if !strings.HasSuffix(result, "(2 rows)") {
+		t.Errorf("Expected 2 rows across partitions, got:\n%s", result)
+	}
+
+	// Updating the partition key moves the row
+	if _, err := exec.Execute(parse(t, "UPDATE events SET created_at = '2024-07-01' WHERE id = 1")); err != nil {
+		t.Fatalf("UPDATE failed: %v", err)
+	}
+	if n := partitionRows(t, exec, "events", "events_2024"); n != 2 {
+		t.Errorf("Expected 2 rows in events_2024 after UPDATE, got %d", n)
+	}
+	if n := partitionRows(t, exec, "events", "events_2023"); n != 0 {
+		t.Errorf("Expected events_2023 to be empty after UPDATE, got %d", n)
+	}
+
+	if _, err := exec.Execute(parse(t, "DELETE FROM events WHERE created_at = '2024-07-01'")); err != nil {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	if n := partitionRows(t, exec, "events", "events_2024"); n != 1 {
+		t.Errorf("Expected 1 row in events_2024 after DELETE, got %d", n)
+	}
+
+	result, err = exec.Execute(&InspectStmt{Target: "TABLE", ObjectName: "events"})
+	if err != nil {
+		t.Fatalf("INSPECT TABLE failed: %v", err)
+	}
+	if !strings.Contains(result, "Partitioned by: RANGE (created_at)") ||
+		!strings.Contains(result, "events_2024 FOR VALUES FROM ('2024-01-01') TO ('2025-01-01'): 1 rows") {
+		t.Errorf("Expected partitions in INSPECT TABLE output, got:\n%s", result)
+	}
+}
+
+func TestExecutorListAndHashPartitioning(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	queries := []string{
+		`CREATE TABLE customers (id INT PRIMARY KEY, region TEXT) PARTITION BY LIST (region) (
+			PARTITION customers_eu FOR VALUES IN ('de', 'fr'),
+			PARTITION customers_us FOR VALUES IN ('us'))`,
+		`CREATE TABLE sessions (id INT PRIMARY KEY) PARTITION BY HASH (id) (
+			PARTITION sessions_0 FOR VALUES WITH (MODULUS 2, REMAINDER 0),
+			PARTITION sessions_1 FOR VALUES WITH (MODULUS 2, REMAINDER 1))`,
+		"INSERT INTO customers VALUES (1, 'de')",
+		"INSERT INTO customers VALUES (2, 'us')",
+		"INSERT INTO customers VALUES (3, 'fr')",
+	}
+	for _, query := range queries {
+		if _, err := exec.Execute(parse(t, query)); err != nil {
+			t.Fatalf("%s failed: %v", query, err)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		if _, err := exec.Execute(&InsertStmt{TableName: "sessions", Values: []string{strconv.Itoa(i)}}); err != nil {
+			t.Fatalf("INSERT failed: %v", err)
+		}
+	}
+
+	if n := partitionRows(t, exec, "customers", "customers_eu"); n != 2 {
+		t.Errorf("Expected 2 rows in customers_eu, got %d", n)
+	}
+
+	// A value without a partition is rejected when there is no DEFAULT
+	if _, err := exec.Execute(parse(t, "INSERT INTO customers VALUES (4, 'jp')")); err == nil {
+		t.Error("Expected INSERT without a matching partition to fail")
+	}
+
+	even, odd := partitionRows(t, exec, "sessions", "sessions_0"), partitionRows(t, exec, "sessions", "sessions_1")
+	if even+odd != 20 || even == 0 || odd == 0 {
+		t.Errorf("Expected 20 rows spread over both hash partitions, got %d and %d", even, odd)
+	}
+
+	// Overlapping partitions are rejected
+	if _, err := exec.Execute(parse(t, "ALTER TABLE customers ADD PARTITION customers_de FOR VALUES IN ('de')")); err == nil {
+		t.Error("Expected overlapping LIST partition to be rejected")
+	}
+	if _, err := exec.Execute(parse(t, "CREATE TABLE bad (id INT) PARTITION BY HASH (id) (PARTITION bad_d DEFAULT)")); err == nil {
+		t.Error("Expected DEFAULT partition of a HASH table to be rejected")
+	}
+}
+
+func TestExecutorPartitionMaintenance(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	createEvents(t, exec)
+
+	// Adding a partition claims matching rows from the DEFAULT partition
+	if _, err := exec.Execute(parse(t, "ALTER TABLE events ADD PARTITION events_2025 FOR VALUES FROM ('2025-01-01') TO ('2026-01-01')")); err != nil {
+		t.Fatalf("ADD PARTITION failed: %v", err)
+	}
+	if n := partitionRows(t, exec, "events", "events_2025"); n != 1 {
+		t.Errorf("Expected the 2025 row to move to events_2025, got %d rows", n)
+	}
+	if n := partitionRows(t, exec, "events", "events_other"); n != 0 {
+		t.Errorf("Expected events_other to be empty, got %d rows", n)
+	}
+
+	// DROP PARTITION removes only that partition's rows
+	if _, err := exec.Execute(parse(t, "ALTER TABLE events DROP PARTITION events_2023")); err != nil {
+		t.Fatalf("DROP PARTITION failed: %v", err)
+	}
+	result, err := exec.Execute(parse(t, "SELECT id FROM events"))
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if !strings.HasSuffix(result, "(2 rows)") {
+		t.Errorf("Expected 2 rows after DROP PARTITION, got:\n%s", result)
+	}
+
+	// DETACH turns the partition into its own table with the parent's indexes
+	if _, err := exec.Execute(parse(t, "CREATE INDEX idx_events_kind ON events (kind)")); err != nil {
+		t.Fatalf("CREATE INDEX failed: %v", err)
+	}
+	if _, err := exec.Execute(parse(t, "ALTER TABLE events DETACH PARTITION events_2024")); err != nil {
+		t.Fatalf("DETACH PARTITION failed: %v", err)
+	}
+	if _, ok := exec.GetCatalog().IndexMgr.Lookup("events_2024", "kind", "view"); !ok {
+		t.Error("Expected the detached table to have an index on kind")
+	}
+	result, err = exec.Execute(parse(t, "SELECT id, kind FROM events_2024"))
+	if err != nil {
+		t.Fatalf("SELECT from detached partition failed: %v", err)
+	}
+	if !strings.Contains(result, "view") {
+		t.Errorf("Expected the detached row, got:\n%s", result)
+	}
+	if _, err := exec.Execute(parse(t, "INSERT INTO events_2024 VALUES (10, '2024-09-09', 'view')")); err != nil {
+		t.Fatalf("INSERT into detached table failed: %v", err)
+	}
+	if _, err := exec.Execute(parse(t, "INSERT INTO events VALUES (11, '2024-05-05', 'view')")); err != nil {
+		t.Fatalf("INSERT into DEFAULT partition failed: %v", err)
+	}
+
+	// ATTACH checks the bound and moves the table's rows back
+	if _, err := exec.Execute(parse(t, "ALTER TABLE events ATTACH PARTITION events_2024 FOR VALUES FROM ('2024-06-01') TO ('2025-01-01')")); err == nil {
+		t.Error("Expected ATTACH with rows outside the bound to fail")
+	}
+	if _, err := exec.Execute(parse(t, "ALTER TABLE events ATTACH PARTITION events_2024 FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')")); err != nil {
+		t.Fatalf("ATTACH PARTITION failed: %v", err)
+	}
+	if _, ok := exec.GetCatalog().GetTable("events_2024"); ok {
+		t.Error("Expected the attached table to be removed")
+	}
+	if n := partitionRows(t, exec, "events", "events_2024"); n != 3 {
+		t.Errorf("Expected 3 rows in events_2024 after ATTACH, got %d", n)
+	}
+	result, err = exec.Execute(parse(t, "SELECT id FROM events WHERE created_at < '2025-01-01'"))
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if !strings.HasSuffix(result, "(3 rows)") {
+		t.Errorf("Expected 3 rows, got:\n%s", result)
+	}
+}
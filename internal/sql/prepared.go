@@ -47,10 +47,11 @@ package sql
 import (
 	"fmt"
 	"regexp"
-	"strings"
+	"strconv"
 	"sync"
 
 	ferrors "flydb/internal/errors"
+	"flydb/internal/literal"
 )
 
 // PreparedStatement represents a compiled prepared statement.
@@ -112,6 +113,25 @@ func (m *PreparedStatementManager) Prepare(name, query string) error {
 
 // Execute runs a prepared statement with the given parameters.
 func (m *PreparedStatementManager) Execute(name string, params []interface{}) (string, error) {
+	query, err := m.Bind(name, params)
+	if err != nil {
+		return "", err
+	}
+
+	// Parse and execute the query
+	lexer := NewLexer(query)
+	parser := NewParser(lexer)
+	parsedStmt, err := parser.Parse()
+	if err != nil {
+		return "", ferrors.InternalError("failed to parse prepared statement").WithCause(err)
+	}
+
+	return m.executor.Execute(parsedStmt)
+}
+
+// Bind returns the query of a prepared statement with params substituted
+// for its placeholders.
+func (m *PreparedStatementManager) Bind(name string, params []interface{}) (string, error) {
 	m.mu.RLock()
 	stmt, exists := m.statements[name]
 	m.mu.RUnlock()
@@ -125,32 +145,28 @@ func (m *PreparedStatementManager) Execute(name string, params []interface{}) (s
 		return "", ferrors.ParameterMismatch(stmt.ParamCount, len(params))
 	}
 
-	// Substitute parameters into the query
-	query := stmt.Query
-	for i, param := range params {
-		placeholder := fmt.Sprintf("$%d", i+1)
-		value := formatParamValue(param)
-		query = strings.ReplaceAll(query, placeholder, value)
-	}
-
-	// Parse and execute the query
-	lexer := NewLexer(query)
-	parser := NewParser(lexer)
-	parsedStmt, err := parser.Parse()
-	if err != nil {
-		return "", ferrors.InternalError("failed to parse prepared statement").WithCause(err)
-	}
+	return BindParameters(stmt.Query, params), nil
+}
 
-	return m.executor.Execute(parsedStmt)
+// BindParameters substitutes params for the placeholders $1, $2, ... of a
+// query. The query is scanned once, so $1 does not match the start of $10
+// and placeholders inside substituted values are left alone.
+// Placeholders without a parameter are kept.
+func BindParameters(query string, params []interface{}) string {
+	return paramRegex.ReplaceAllStringFunc(query, func(placeholder string) string {
+		n, err := strconv.Atoi(placeholder[1:])
+		if err != nil || n < 1 || n > len(params) {
+			return placeholder
+		}
+		return formatParamValue(params[n-1])
+	})
 }
 
 // formatParamValue formats a parameter value for substitution.
 func formatParamValue(param interface{}) string {
 	switch v := param.(type) {
 	case string:
-		// Escape single quotes in strings
-		escaped := strings.ReplaceAll(v, "'", "''")
-		return "'" + escaped + "'"
+		return literal.Quote(v)
 	case nil:
 		return "NULL"
 	default:
@@ -187,3 +187,24 @@ func TestPreparedStatementDuplicateName(t *testing.T) {
 	}
 }
 
+
+func TestBindParameters(t *testing.T) {
+	params := make([]interface{}, 10)
+	for i := range params {
+		params[i] = i + 1
+	}
+	params[9] = "O'Brien"
+
+	got := BindParameters("SELECT * FROM t WHERE a = $1 AND b = $10", params)
+	want := "SELECT * FROM t WHERE a = 1 AND b = 'O''Brien'"
+	if got != want {
+		t.Errorf("BindParameters = %q, want %q", got, want)
+	}
+
+	// Placeholders in values are not substituted again
+	got = BindParameters("SELECT $2, $1, $3", []interface{}{"x", "costs $1"})
+	want = "SELECT 'costs $1', 'x', $3"
+	if got != want {
+		t.Errorf("BindParameters = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,355 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Structured Results:
+===================
+
+Execute returns a statement's result as text: a header line, one line per
+row with values joined by ", ", and a "(N rows)" summary, or a tag such as
+"INSERT 3" for DML. ExecuteResult returns the same text together with its
+structured form, so clients do not have to parse tables:
+
+  - Columns carry the column name and its FlyDB type, taken from the table
+    schemas for table columns and from the function for aggregates and
+    scalar functions. Columns of other tabular results are TEXT.
+  - Rows hold typed values: integers as int64, FLOAT, DOUBLE and REAL as
+    float64, BOOLEAN as bool and NULL as nil. Everything else, DECIMAL
+    included, stays a string so no precision is lost. The executor records
+    each row's values as it projects them, so values are never parsed back
+    out of the text; rows store NULL as the string NULL, which is what
+    becomes nil.
+  - DML reports the affected row count, and INSERT the auto-increment value
+    of the last row it inserted.
+*/
+package sql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ResultColumn describes a column of a structured result.
+type ResultColumn struct {
+	Name string // Column name as shown in the result header
+	Type string // FlyDB column type (INT, TEXT, ...)
+}
+
+// Result is the structured form of a statement result.
+type Result struct {
+	Message      string          // The formatted text returned by Execute
+	Columns      []ResultColumn  // Result columns, empty for non-tabular results
+	Rows         [][]interface{} // Typed row values
+	RowCount     int             // Number of rows in Rows
+	RowsAffected int64           // Rows changed by INSERT, UPDATE or DELETE
+	LastInsertID int64           // Auto-increment value of the last inserted row
+}
+
+var (
+	// rowCountPattern matches the summary line of a tabular result.
+	rowCountPattern = regexp.MustCompile(`^\(\d+ rows?\)$`)
+
+	// dmlTagPattern matches the result of INSERT, UPDATE and DELETE.
+	dmlTagPattern = regexp.MustCompile(`^(INSERT|UPDATE|DELETE) (\d+)$`)
+)
+
+// ExecuteResult runs the statement as user and returns its structured result.
+func (e *Executor) ExecuteResult(stmt Statement, user string) (*Result, error) {
+	return e.executeResult(stmt, user, nil)
+}
+
+// executeResult runs the statement as user, streaming its rows to stream
+// when the executor can, and returns its structured result.
+func (e *Executor) executeResult(stmt Statement, user string, stream *rowStreamer) (*Result, error) {
+	// Like ExecuteWithUser, run on a copy so concurrent connections do not
+	// share the captured rows.
+	ephemeral := *e
+	ephemeral.currentUser = user
+	ephemeral.rowValues = make(map[string][][]interface{})
+	ephemeral.lastInsertID = 0
+	ephemeral.rowStream = stream
+
+	message, err := ephemeral.Execute(stmt)
+	if err == nil {
+		err = ephemeral.rowErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stream != nil && stream.started {
+		return &Result{Message: message}, nil
+	}
+
+	result := NewTextResult(message)
+	if result.RowsAffected > 0 {
+		if _, ok := stmt.(*InsertStmt); ok {
+			result.LastInsertID = ephemeral.lastInsertID
+		}
+	}
+	if len(result.Columns) == 0 {
+		return result, nil
+	}
+
+	if sel := resultSelect(stmt); sel != nil {
+		if headers := selectHeaders(sel); strings.Join(headers, ", ") == headerLine(message) {
+			types := ephemeral.selectColumnTypes(sel)
+			result.Columns = make([]ResultColumn, len(headers))
+			for i, name := range headers {
+				result.Columns[i] = ResultColumn{Name: name, Type: types[i]}
+			}
+		}
+	}
+
+	// Take each row's values from those recorded by the executor. Only rows
+	// the executor did not project, such as those of SHOW statements, are
+	// split from the text.
+	lines := strings.Split(message, "\n")
+	result.Rows = result.Rows[:0]
+	for _, line := range lines[1 : len(lines)-1] {
+		values, ok := ephemeral.takeRowValues(line)
+		if !ok || len(values) != len(result.Columns) {
+			values = textRow(splitResultRow(line, len(result.Columns)))
+		}
+		result.Rows = append(result.Rows, typedRow(values, result.Columns))
+	}
+	return result, nil
+}
+
+// NewTextResult builds a structured result from result text alone. Tables
+// become TEXT columns of strings and DML tags their affected row count; any
+// other text is returned as the message only.
+func NewTextResult(message string) *Result {
+	result := &Result{Message: message}
+
+	if m := dmlTagPattern.FindStringSubmatch(message); m != nil {
+		result.RowsAffected, _ = strconv.ParseInt(m[2], 10, 64)
+		return result
+	}
+
+	lines := strings.Split(message, "\n")
+	if len(lines) < 2 || !rowCountPattern.MatchString(lines[len(lines)-1]) {
+		return result
+	}
+
+	for _, name := range strings.Split(lines[0], ", ") {
+		result.Columns = append(result.Columns, ResultColumn{Name: name, Type: string(TypeTEXT)})
+	}
+	for _, line := range lines[1 : len(lines)-1] {
+		result.Rows = append(result.Rows, textRow(splitResultRow(line, len(result.Columns))))
+	}
+	result.RowCount = len(result.Rows)
+	return result
+}
+
+// resultSelect returns the SELECT whose columns name the result of stmt.
+func resultSelect(stmt Statement) *SelectStmt {
+	switch s := stmt.(type) {
+	case *SelectStmt:
+		return s
+	case *UnionStmt:
+		return s.Left
+	case *IntersectStmt:
+		return s.Left
+	case *ExceptStmt:
+		return s.Left
+	}
+	return nil
+}
+
+// headerLine returns the first line of a result text.
+func headerLine(message string) string {
+	header, _, _ := strings.Cut(message, "\n")
+	return header
+}
+
+// splitResultRow splits a formatted row into n values. Extra separators,
+// which come from values containing ", ", are kept in the last value.
+func splitResultRow(line string, n int) []string {
+	values := strings.SplitN(line, ", ", n)
+	for len(values) < n {
+		values = append(values, "")
+	}
+	return values
+}
+
+// textRow returns the values of a row split from result text as strings.
+func textRow(values []string) []interface{} {
+	row := make([]interface{}, len(values))
+	for i, value := range values {
+		row[i] = value
+	}
+	return row
+}
+
+// nullableValue returns a projected value with SQL NULL, which rows store
+// as the string NULL, as nil.
+func nullableValue(v interface{}) interface{} {
+	if s, ok := v.(string); ok && s == "NULL" {
+		return nil
+	}
+	return v
+}
+
+// nullableValues returns formatted values with NULL as nil.
+func nullableValues(values []string) []interface{} {
+	row := make([]interface{}, len(values))
+	for i, value := range values {
+		row[i] = nullableValue(value)
+	}
+	return row
+}
+
+// selectColumnTypes returns the FlyDB types of the result columns of stmt,
+// in the order of selectHeaders.
+func (e *Executor) selectColumnTypes(stmt *SelectStmt) []string {
+	columnTypes := make(map[string]string)
+	if cat, err := e.getCatalog(stmt.DatabaseName); err == nil {
+		tables := []string{stmt.TableName}
+		if stmt.Join != nil {
+			tables = append(tables, stmt.Join.TableName)
+		}
+		for _, name := range tables {
+			table, ok := cat.GetTable(name)
+			if !ok {
+				continue
+			}
+			for _, col := range table.Columns {
+				colType := canonicalColumnType(col.Type)
+				columnTypes[name+"."+col.Name] = colType
+				if _, ok := columnTypes[col.Name]; !ok {
+					columnTypes[col.Name] = colType
+				}
+			}
+		}
+	}
+	typeOf := func(column string) string {
+		if t, ok := columnTypes[column]; ok {
+			return t
+		}
+		return string(TypeTEXT)
+	}
+
+	var types []string
+	if len(stmt.Aggregates) > 0 {
+		for _, col := range stmt.GroupBy {
+			types = append(types, typeOf(col))
+		}
+		for _, agg := range stmt.Aggregates {
+			types = append(types, aggregateType(agg, typeOf(agg.Column)))
+		}
+		return types
+	}
+
+	for _, col := range stmt.Columns {
+		types = append(types, typeOf(col))
+	}
+	for _, fn := range stmt.Functions {
+		switch strings.ToUpper(fn.Function) {
+		case "LENGTH", "LEN":
+			types = append(types, string(TypeINT))
+		default:
+			types = append(types, string(TypeTEXT))
+		}
+	}
+	return types
+}
+
+// aggregateType returns the result type of an aggregate over a column of
+// type columnType. Numeric results are formatted as decimals.
+func aggregateType(agg *AggregateExpr, columnType string) string {
+	switch strings.ToUpper(agg.Function) {
+	case "COUNT":
+		return string(TypeBIGINT)
+	case "SUM", "AVG":
+		return string(TypeDOUBLE)
+	case "MIN", "MAX":
+		if isNumericType(columnType) {
+			return string(TypeDOUBLE)
+		}
+		return columnType
+	}
+	return string(TypeTEXT)
+}
+
+// canonicalColumnType maps a declared column type such as "varchar(20)" or
+// an alias to its FlyDB type name.
+func canonicalColumnType(typeName string) string {
+	upper := strings.ToUpper(strings.TrimSpace(typeName))
+	if i := strings.IndexByte(upper, '('); i >= 0 {
+		upper = strings.TrimSpace(upper[:i])
+	}
+	if canonical, ok := ValidColumnTypes[upper]; ok {
+		return string(canonical)
+	}
+	return upper
+}
+
+// isNumericType reports whether values of the type are numbers.
+func isNumericType(typeName string) bool {
+	switch ColumnType(typeName) {
+	case TypeINT, TypeSMALLINT, TypeBIGINT, TypeSERIAL, TypeFLOAT, TypeDOUBLE, TypeREAL, TypeDECIMAL:
+		return true
+	}
+	return false
+}
+
+// typedRow converts the values of a row to the Go types of their columns.
+func typedRow(values []interface{}, columns []ResultColumn) []interface{} {
+	row := make([]interface{}, len(values))
+	for i, value := range values {
+		row[i] = typedValue(value, columns[i].Type)
+	}
+	return row
+}
+
+// typedValue converts a value to the Go type of a column type. Values that
+// do not convert are returned as strings.
+func typedValue(value interface{}, typeName string) interface{} {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		text = v
+	case bool:
+		if ColumnType(typeName) == TypeBOOLEAN {
+			return v
+		}
+		text = strconv.FormatBool(v)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		text = fmt.Sprint(v)
+	}
+
+	switch ColumnType(typeName) {
+	case TypeINT, TypeSMALLINT, TypeBIGINT, TypeSERIAL:
+		if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return v
+		}
+	case TypeFLOAT, TypeDOUBLE, TypeREAL:
+		if v, err := strconv.ParseFloat(text, 64); err == nil {
+			return v
+		}
+	case TypeBOOLEAN:
+		if v, err := strconv.ParseBool(text); err == nil {
+			return v
+		}
+	}
+	return text
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sql
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestExecuteResult(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	run := func(query string) *Result {
+		t.Helper()
+		stmt, err := NewParser(NewLexer(query)).Parse()
+		if err != nil {
+			t.Fatalf("Parse %q failed: %v", query, err)
+		}
+		result, err := exec.ExecuteResult(stmt, "")
+		if err != nil {
+			t.Fatalf("Execute %q failed: %v", query, err)
+		}
+		return result
+	}
+
+	run("CREATE TABLE users (id SERIAL PRIMARY KEY, name TEXT, age INT, active BOOLEAN, score FLOAT)")
+	result := run("INSERT INTO users (name, age, active, score) VALUES ('Smith, Jane', 30, true, 1.5)")
+	if result.RowsAffected != 1 || result.LastInsertID != 1 {
+		t.Errorf("Unexpected INSERT result: %+v", result)
+	}
+	result = run("INSERT INTO users (name, age, active) VALUES ('Bob', 25, false)")
+	if result.Message != "INSERT 1" || result.LastInsertID != 2 {
+		t.Errorf("Unexpected INSERT result: %+v", result)
+	}
+
+	// Values containing the row separator keep their columns
+	result = run("SELECT * FROM users ORDER BY id")
+	wantColumns := []ResultColumn{
+		{Name: "id", Type: "SERIAL"},
+		{Name: "name", Type: "TEXT"},
+		{Name: "age", Type: "INT"},
+		{Name: "active", Type: "BOOLEAN"},
+		{Name: "score", Type: "FLOAT"},
+	}
+	if !reflect.DeepEqual(result.Columns, wantColumns) {
+		t.Errorf("Expected columns %v, got %v", wantColumns, result.Columns)
+	}
+	wantRows := [][]interface{}{
+		{int64(1), "Smith, Jane", int64(30), true, 1.5},
+		{int64(2), "Bob", int64(25), false, nil},
+	}
+	if result.RowCount != 2 || !reflect.DeepEqual(result.Rows, wantRows) {
+		t.Errorf("Expected rows %v, got %v", wantRows, result.Rows)
+	}
+
+	result = run("SELECT COUNT(*), AVG(age), MAX(name) FROM users")
+	wantColumns = []ResultColumn{
+		{Name: "count", Type: "BIGINT"},
+		{Name: "avg(age)", Type: "DOUBLE"},
+		{Name: "max(name)", Type: "TEXT"},
+	}
+	if !reflect.DeepEqual(result.Columns, wantColumns) {
+		t.Errorf("Expected columns %v, got %v", wantColumns, result.Columns)
+	}
+	if want := [][]interface{}{{int64(2), 27.5, "Smith, Jane"}}; !reflect.DeepEqual(result.Rows, want) {
+		t.Errorf("Expected rows %v, got %v", want, result.Rows)
+	}
+
+	// Values containing the separator are never split, NULL is nil and
+	// rows that format alike each get their values
+	run("INSERT INTO users (name, age) VALUES ('Smith, Jane', 30)")
+	result = run("SELECT name, age FROM users WHERE age = 30")
+	if want := []interface{}{"Smith, Jane", int64(30)}; len(result.Rows) != 2 || !reflect.DeepEqual(result.Rows[0], want) || !reflect.DeepEqual(result.Rows[1], want) {
+		t.Errorf("Expected two rows %v, got %v", want, result.Rows)
+	}
+	result = run("SELECT name, age, score FROM users WHERE age = 30")
+	wantRows = [][]interface{}{
+		{"Smith, Jane", int64(30), 1.5},
+		{"Smith, Jane", int64(30), nil},
+	}
+	sort.Slice(result.Rows, func(i, j int) bool { return result.Rows[i][2] != nil })
+	if !reflect.DeepEqual(result.Rows, wantRows) {
+		t.Errorf("Expected rows %v, got %v", wantRows, result.Rows)
+	}
+	result = run("SELECT name, COUNT(*) FROM users GROUP BY name")
+	if len(result.Rows) != 2 {
+		t.Fatalf("Expected 2 groups, got %v", result.Rows)
+	}
+	for _, row := range result.Rows {
+		if len(row) != 2 || (row[0] == "Smith, Jane") != (row[1] == int64(2)) {
+			t.Errorf("Unexpected group row %v", row)
+		}
+	}
+	run("DELETE FROM users WHERE id = 3")
+
+	result = run("UPDATE users SET age = 31 WHERE name = 'Bob'")
+	if result.RowsAffected != 1 || result.LastInsertID != 0 || len(result.Columns) != 0 {
+		t.Errorf("Unexpected UPDATE result: %+v", result)
+	}
+	result = run("DELETE FROM users WHERE name = 'Bob'")
+	if result.RowsAffected != 1 {
+		t.Errorf("Unexpected DELETE result: %+v", result)
+	}
+}
+
+func TestNewTextResult(t *testing.T) {
+	result := NewTextResult("table_name, rows\nusers, 2\n(1 rows)")
+	if len(result.Columns) != 2 || result.Columns[1].Type != "TEXT" || result.RowCount != 1 {
+		t.Fatalf("Unexpected table result: %+v", result)
+	}
+	if want := []interface{}{"users", "2"}; !reflect.DeepEqual(result.Rows[0], want) {
+		t.Errorf("Expected row %v, got %v", want, result.Rows[0])
+	}
+
+	// Text alone cannot tell NULL from a string, so values stay strings
+	result = NewTextResult("name, value\nmode, NULL\n(1 rows)")
+	if want := []interface{}{"mode", "NULL"}; !reflect.DeepEqual(result.Rows[0], want) {
+		t.Errorf("Expected row %v, got %v", want, result.Rows[0])
+	}
+
+	if result := NewTextResult("UPDATE 3"); result.RowsAffected != 3 || len(result.Columns) != 0 {
+		t.Errorf("Unexpected DML result: %+v", result)
+	}
+	if result := NewTextResult("CREATE TABLE OK"); result.Message != "CREATE TABLE OK" || len(result.Columns) != 0 {
+		t.Errorf("Unexpected message result: %+v", result)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Table Distribution:
+===================
+
+In a cluster, the rows of a table are placed on partitions by hashing
+their storage key. How much of the key is hashed depends on the table:
+
+	Default:         row:<table>:<id>               the whole key
+	SHARD BY (col):  row:<table>:{<hash of col>}<id> the hash tag only
+	REFERENCE:       row:<table>:{}<id>              every node
+
+The hash tag is derived from the shard key value alone, so the rows of
+every table sharded by the same value (for example a tenant id) land on
+the same partition. A join of two such tables on their shard keys, or of
+any table with a reference table, finds all matching rows on one node
+and runs on the partition owners like a single-table query.
+
+The tag is part of the row id segment of the key, after any partition
+prefix, so local PARTITION BY works the same on sharded tables.
+*/
+package sql
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	ferrors "flydb/internal/errors"
+)
+
+// referenceTag is the hash tag of rows replicated to every node.
+const referenceTag = "{}"
+
+// shardTag returns the hash tag placing the rows with a shard key value.
+func shardTag(value interface{}) string {
+	h := fnv.New32a()
+	if value != nil {
+		h.Write([]byte(fmt.Sprintf("%v", value)))
+	}
+	return fmt.Sprintf("{%08x}", h.Sum32())
+}
+
+// stripShardTag removes the hash tag from the row id of a row key.
+func stripShardTag(id string) string {
+	if strings.HasPrefix(id, "{") {
+		if i := strings.IndexByte(id, '}'); i >= 0 {
+			return id[i+1:]
+		}
+	}
+	return id
+}
+
+// distributedRowID returns the row id of a row with the hash tag its
+// table's distribution requires.
+func distributedRowID(table TableSchema, id string, row map[string]interface{}) string {
+	id = stripShardTag(id)
+	switch {
+	case table.Reference:
+		return referenceTag + id
+	case table.ShardKey != "":
+		return shardTag(row[table.ShardKey]) + id
+	}
+	return id
+}
+
+// validateDistribution checks the SHARD BY and REFERENCE options of
+// CREATE TABLE against the table's columns.
+func validateDistribution(stmt *CreateTableStmt) error {
+	if stmt.ShardBy == "" {
+		return nil
+	}
+	if stmt.Reference {
+		return ferrors.NewExecutionError("a reference table cannot have a shard key")
+	}
+	if partitionColumnType(stmt.Columns, stmt.ShardBy) == "" {
+		return ferrors.ColumnNotFound(stmt.ShardBy, stmt.TableName)
+	}
+	return nil
+}
+
+// colocatedJoin reports whether every row a join matches is on the same
+// node as the primary row: the joined table is a reference table, or both
+// tables are sharded and joined on their shard keys.
+func colocatedJoin(table, joined TableSchema, on *Condition) bool {
+	if joined.Reference {
+		return true
+	}
+	if table.ShardKey == "" || joined.ShardKey == "" || on == nil {
+		return false
+	}
+
+	column := func(ref, tableName string) string {
+		return strings.TrimPrefix(ref, tableName+".")
+	}
+	left, right := on.Column, on.Value
+	if column(left, table.Name) == table.ShardKey && column(right, joined.Name) == joined.ShardKey {
+		return true
+	}
+	return column(right, table.Name) == table.ShardKey && column(left, joined.Name) == joined.ShardKey
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExecutorShardedTables(t *testing.T) {
+	local, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	queries := []string{
+		"CREATE TABLE orders (id INT PRIMARY KEY, tenant_id INT, country TEXT, amount INT) SHARD BY (tenant_id)",
+		"CREATE TABLE invoices (id INT PRIMARY KEY, tenant_id INT, total INT) SHARD BY (tenant_id)",
+		"CREATE REFERENCE TABLE countries (code TEXT PRIMARY KEY, name TEXT)",
+		"INSERT INTO countries VALUES ('nl', 'Netherlands')",
+		"INSERT INTO countries VALUES ('de', 'Germany')",
+	}
+	for i := 1; i <= 8; i++ {
+		queries = append(queries,
+			fmt.Sprintf("INSERT INTO orders VALUES (%d, %d, '%s', %d)", i, i%4, []string{"nl", "de", "fr"}[i%3], i*10),
+			fmt.Sprintf("INSERT INTO invoices VALUES (%d, %d, %d)", i, i%3, i*100))
+	}
+	for _, query := range queries {
+		if _, err := local.Execute(parse(t, query)); err != nil {
+			t.Fatalf("%s failed: %v", query, err)
+		}
+	}
+
+	// Rows of the same tenant carry the same hash tag in every table
+	rows, _ := local.store.Scan("row:")
+	for key, data := range rows {
+		switch {
+		case strings.HasPrefix(key, "row:countries:"):
+			if !strings.HasPrefix(rowKeyID(key), referenceTag) {
+				t.Errorf("Reference row %s has no reference tag", key)
+			}
+		case strings.HasPrefix(key, "row:orders:"), strings.HasPrefix(key, "row:invoices:"):
+			var row map[string]interface{}
+			if err := json.Unmarshal(data, &row); err != nil {
+				t.Fatalf("Invalid row %s: %v", key, err)
+			}
+			if !strings.HasPrefix(rowKeyID(key), shardTag(row["tenant_id"])) {
+				t.Errorf("Row %s is not tagged with tenant %v", key, row["tenant_id"])
+			}
+		}
+	}
+
+	// An update of the shard key moves the row to the new tenant's tag
+	if _, err := local.Execute(parse(t, "UPDATE orders SET tenant_id = 9 WHERE id = 1")); err != nil {
+		t.Fatalf("UPDATE failed: %v", err)
+	}
+	moved, _ := local.store.Scan("row:orders:" + shardTag("9"))
+	if len(moved) != 1 {
+		t.Errorf("Expected 1 row tagged with tenant 9, got %d", len(moved))
+	}
+
+	// Keys are placed by their tag; reference rows live on the coordinator
+	cluster := &fakeCluster{node: local, place: func(key string) int {
+		id := rowKeyID(key)
+		if strings.HasPrefix(id, referenceTag) {
+			return 0
+		}
+		return int(id[1]) % 2
+	}}
+	coordinator := NewExecutor(local.store, local.auth)
+	coordinator.SetDistributedScanner(cluster, "")
+
+	tests := []string{
+		"SELECT orders.id, invoices.id FROM orders JOIN invoices ON orders.tenant_id = invoices.tenant_id ORDER BY orders.id",
+		"SELECT orders.id, countries.name FROM orders LEFT JOIN countries ON orders.country = countries.code ORDER BY orders.id",
+		"SELECT name FROM countries ORDER BY name",
+		"SELECT tenant_id, SUM(amount) FROM orders GROUP BY tenant_id",
+	}
+	for _, query := range tests {
+		want, err := local.Execute(parse(t, query))
+		if err != nil {
+			t.Fatalf("%s failed locally: %v", query, err)
+		}
+		if strings.Count(want, "\n") < 2 {
+			t.Fatalf("%s: expected rows, got %q", query, want)
+		}
+		cluster.shipped = 0
+		got, err := coordinator.Execute(parse(t, query))
+		if err != nil {
+			t.Fatalf("%s failed distributed: %v", query, err)
+		}
+		if sortedResult(got) != sortedResult(want) {
+			t.Errorf("%s:\ndistributed:\n%s\nlocal:\n%s", query, got, want)
+		}
+		if cluster.shipped == 0 {
+			t.Errorf("%s: expected the query to run on the partition owners", query)
+		}
+	}
+
+	// A join that is not on the shard keys runs on the coordinator
+	cat, _ := local.getCatalog("")
+	stmt := parse(t, "SELECT orders.id FROM orders JOIN invoices ON orders.id = invoices.id").(*SelectStmt)
+	if coordinator.canDistribute(cat, stmt) {
+		t.Error("Expected a join on other columns not to be distributed")
+	}
+
+	for _, query := range []string{
+		"CREATE TABLE bad (id INT) SHARD BY (tenant_id)",
+		"CREATE REFERENCE TABLE bad (id INT) SHARD BY (id)",
+		"ALTER TABLE orders DROP COLUMN tenant_id",
+	} {
+		if _, err := local.Execute(parse(t, query)); err == nil {
+			t.Errorf("%s: expected an error", query)
+		}
+	}
+}
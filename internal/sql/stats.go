@@ -0,0 +1,417 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Table Statistics:
+=================
+
+ANALYZE samples the rows of a table and records, per column, the fraction
+of NULLs, an estimate of the number of distinct values and an equi-depth
+histogram of the non-NULL values. The planner uses the estimated number of
+rows matching an equality predicate to choose between an index lookup and
+a table scan; the other statistics are informational and shown by INSPECT
+TABLE.
+
+Statistics are stored next to the schema under a reserved key prefix:
+
+	Key:   _sys_stats:<table_name>
+	Value: JSON-encoded TableStats
+
+ANALYZE reads only the keys of a table and reservoir-samples at most
+analyzeSampleRows of them; only the sampled rows are read and decoded.
+
+Rows modified by INSERT, UPDATE and DELETE are counted per table. Once the
+count exceeds autoAnalyzeMinRows plus a configurable fraction of the row
+count seen by the last ANALYZE, the table is analyzed again in the
+background, off the statement that crossed the threshold.
+*/
+package sql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"flydb/internal/storage"
+)
+
+// statsKeyPrefix is the storage key prefix for table statistics.
+const statsKeyPrefix = "_sys_stats:"
+
+const (
+	// analyzeSampleRows is the maximum number of rows ANALYZE samples.
+	analyzeSampleRows = 30000
+
+	// statsHistogramBuckets is the maximum number of histogram buckets.
+	statsHistogramBuckets = 100
+
+	// autoAnalyzeMinRows is the number of modified rows that always
+	// triggers an automatic ANALYZE, on top of the configured fraction.
+	autoAnalyzeMinRows = 50
+
+	// indexLookupMaxRows is the estimated number of rows matching an
+	// equality predicate above which the planner scans instead of using an
+	// index, since an index lookup returns a single row.
+	indexLookupMaxRows = 1.5
+
+	// DefaultAutoAnalyzeFraction is the fraction of a table's rows that must
+	// change before it is analyzed again automatically.
+	DefaultAutoAnalyzeFraction = 0.2
+)
+
+// TableStats holds the statistics gathered by ANALYZE for one table.
+type TableStats struct {
+	Table       string                  `json:"table"`
+	RowCount    int64                   `json:"row_count"`
+	SampledRows int                     `json:"sampled_rows"`
+	AnalyzedAt  time.Time               `json:"analyzed_at"`
+	Columns     map[string]*ColumnStats `json:"columns"`
+}
+
+// ColumnStats holds the statistics of one column.
+type ColumnStats struct {
+	NullFraction float64 `json:"null_fraction"` // Fraction of rows that are NULL
+	NDistinct    float64 `json:"n_distinct"`    // Estimated number of distinct non-NULL values
+	Numeric      bool    `json:"numeric"`       // Histogram bounds are ordered numerically
+
+	// Histogram holds equi-depth bucket bounds: each adjacent pair delimits
+	// roughly the same number of non-NULL rows.
+	Histogram []string `json:"histogram,omitempty"`
+}
+
+// EstimateEqualRows estimates how many rows have column equal to a single
+// value. It returns false if the column has no statistics.
+func (s *TableStats) EstimateEqualRows(column string) (float64, bool) {
+	col, ok := s.Columns[column]
+	if !ok {
+		return 0, false
+	}
+	nonNull := float64(s.RowCount) * (1 - col.NullFraction)
+	if col.NDistinct < 1 {
+		return nonNull, true
+	}
+	return nonNull / col.NDistinct, true
+}
+
+// StatsManager stores and caches table statistics for one database.
+//
+// Thread Safety: All methods are safe for concurrent use.
+type StatsManager struct {
+	store   storage.Engine
+	stats   map[string]*TableStats
+	changes *changeCounter
+	mu      sync.RWMutex
+}
+
+// NewStatsManager creates a StatsManager and loads stored statistics.
+func NewStatsManager(store storage.Engine) *StatsManager {
+	sm := &StatsManager{
+		store:   store,
+		stats:   make(map[string]*TableStats),
+		changes: changeCounterFor(store),
+	}
+	sm.load()
+	return sm
+}
+
+// load reads all stored statistics into memory.
+func (sm *StatsManager) load() {
+	data, err := sm.store.Scan(statsKeyPrefix)
+	if err != nil {
+		return
+	}
+	for _, val := range data {
+		var ts TableStats
+		if err := json.Unmarshal(val, &ts); err == nil {
+			sm.stats[ts.Table] = &ts
+		}
+	}
+}
+
+// Get returns the statistics for a table, or nil if it was never analyzed.
+func (sm *StatsManager) Get(table string) *TableStats {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.stats[table]
+}
+
+// Save stores the statistics of a table and resets its change counter.
+func (sm *StatsManager) Save(ts *TableStats) error {
+	data, err := json.Marshal(ts)
+	if err != nil {
+		return err
+	}
+	if err := sm.store.Put(statsKeyPrefix+ts.Table, data); err != nil {
+		return err
+	}
+
+	sm.mu.Lock()
+	sm.stats[ts.Table] = ts
+	sm.mu.Unlock()
+	sm.changes.reset(ts.Table)
+	return nil
+}
+
+// Drop removes the statistics of a table.
+func (sm *StatsManager) Drop(table string) error {
+	sm.mu.Lock()
+	delete(sm.stats, table)
+	sm.mu.Unlock()
+	sm.changes.reset(table)
+	return sm.store.Delete(statsKeyPrefix + table)
+}
+
+// RecordChanges adds n modified rows to a table's change counter and
+// reports whether the table is now due for an automatic ANALYZE under the
+// given fraction. A fraction of 0 or less disables automatic ANALYZE.
+func (sm *StatsManager) RecordChanges(table string, n int, fraction float64) bool {
+	if n <= 0 {
+		return false
+	}
+	changed := sm.changes.add(table, int64(n))
+	if fraction <= 0 {
+		return false
+	}
+
+	var rows int64
+	if ts := sm.Get(table); ts != nil {
+		rows = ts.RowCount
+	}
+	return float64(changed) >= autoAnalyzeMinRows+fraction*float64(rows)
+}
+
+// beginAnalyze reports whether an automatic ANALYZE of table may start, and
+// if so marks it as running until endAnalyze is called.
+func (sm *StatsManager) beginAnalyze(table string) bool {
+	return sm.changes.begin(table)
+}
+
+// endAnalyze marks the automatic ANALYZE of table as finished.
+func (sm *StatsManager) endAnalyze(table string) {
+	sm.changes.end(table)
+}
+
+// changeCounter counts modified rows per table since the last ANALYZE and
+// tracks which tables are being analyzed in the background.
+type changeCounter struct {
+	mu        sync.Mutex
+	counts    map[string]int64
+	analyzing map[string]bool
+}
+
+func (c *changeCounter) add(table string, n int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[table] += n
+	return c.counts[table]
+}
+
+func (c *changeCounter) reset(table string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.counts, table)
+}
+
+func (c *changeCounter) begin(table string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.analyzing[table] {
+		return false
+	}
+	c.analyzing[table] = true
+	return true
+}
+
+func (c *changeCounter) end(table string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.analyzing, table)
+}
+
+// changeCounters holds one changeCounter per storage engine. Counters live
+// here rather than in the StatsManager because the server builds a fresh
+// executor, and so a fresh Catalog, per statement for non-default databases.
+// Entries are released when their database is closed.
+var changeCounters = struct {
+	sync.Mutex
+	byStore map[storage.Engine]*changeCounter
+}{byStore: make(map[storage.Engine]*changeCounter)}
+
+func changeCounterFor(store storage.Engine) *changeCounter {
+	trackStore()
+	changeCounters.Lock()
+	defer changeCounters.Unlock()
+	c, ok := changeCounters.byStore[store]
+	if !ok {
+		c = &changeCounter{counts: make(map[string]int64), analyzing: make(map[string]bool)}
+		changeCounters.byStore[store] = c
+	}
+	return c
+}
+
+// releaseStoreOnce registers releaseStore the first time per-engine state
+// is created.
+var releaseStoreOnce sync.Once
+
+// trackStore makes sure the per-engine state in changeCounters and
+// ttlProgress is released when its database is closed.
+func trackStore() {
+	releaseStoreOnce.Do(func() { storage.OnClose(releaseStore) })
+}
+
+// releaseStore drops the per-engine state of a closed store.
+func releaseStore(store storage.Engine) {
+	changeCounters.Lock()
+	delete(changeCounters.byStore, store)
+	changeCounters.Unlock()
+
+	ttlProgress.Lock()
+	delete(ttlProgress.byStore, store)
+	ttlProgress.Unlock()
+}
+
+// analyzeTable gathers statistics for a table from a sample of its rows.
+func analyzeTable(store storage.Engine, table TableSchema, compare func(a, b string) int) (*TableStats, error) {
+	// Reservoir-sample the keys so only the sampled rows are read, and
+	// statistics are built from at most analyzeSampleRows rows.
+	var keys []string
+	seen := 0
+	err := storage.ScanKeys(store, "row:"+table.Name+":", "", func(key string) bool {
+		seen++
+		if len(keys) < analyzeSampleRows {
+			keys = append(keys, key)
+		} else if j := rand.Intn(seen); j < analyzeSampleRows {
+			keys[j] = key
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sample := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		data, err := store.Get(key)
+		if err != nil {
+			// Deleted since the keys were scanned
+			continue
+		}
+		var row map[string]interface{}
+		if err := json.Unmarshal(data, &row); err != nil {
+			continue
+		}
+		sample = append(sample, row)
+	}
+
+	ts := &TableStats{
+		Table:       table.Name,
+		RowCount:    int64(seen),
+		SampledRows: len(sample),
+		AnalyzedAt:  time.Now(),
+		Columns:     make(map[string]*ColumnStats, len(table.Columns)),
+	}
+	for _, col := range table.Columns {
+		ts.Columns[col.Name] = analyzeColumn(sample, col.Name, int64(seen), compare)
+	}
+	return ts, nil
+}
+
+// analyzeColumn computes the statistics of one column from the sample.
+func analyzeColumn(sample []map[string]interface{}, column string, totalRows int64, compare func(a, b string) int) *ColumnStats {
+	cs := &ColumnStats{}
+	if len(sample) == 0 {
+		return cs
+	}
+
+	values := make([]string, 0, len(sample))
+	counts := make(map[string]int)
+	numeric := true
+	for _, row := range sample {
+		v, ok := row[column]
+		if !ok || v == nil {
+			continue
+		}
+		s, isString := v.(string)
+		if !isString {
+			s = fmt.Sprintf("%v", v)
+		}
+		if s == "" || s == "NULL" {
+			continue
+		}
+		if numeric {
+			if _, err := strconv.ParseFloat(s, 64); err != nil {
+				numeric = false
+			}
+		}
+		values = append(values, s)
+		counts[s]++
+	}
+
+	n := len(values)
+	cs.NullFraction = float64(len(sample)-n) / float64(len(sample))
+	if n == 0 {
+		return cs
+	}
+
+	// Estimate distinct values with the Haas-Stokes estimator; when the
+	// sample is the whole table the distinct count is exact.
+	distinct := float64(len(counts))
+	if int64(len(sample)) >= totalRows {
+		cs.NDistinct = distinct
+	} else {
+		singletons := 0
+		for _, c := range counts {
+			if c == 1 {
+				singletons++
+			}
+		}
+		nonNullRows := float64(totalRows) * (1 - cs.NullFraction)
+		if singletons == n {
+			// Every sampled value is unique; assume the column is too
+			cs.NDistinct = nonNullRows
+		} else {
+			f1 := float64(singletons)
+			cs.NDistinct = float64(n) * distinct / (float64(n) - f1 + f1*float64(n)/nonNullRows)
+			cs.NDistinct = max(distinct, min(cs.NDistinct, nonNullRows))
+		}
+	}
+
+	// Equi-depth histogram over the sorted non-NULL values
+	cs.Numeric = numeric
+	if numeric {
+		sort.Slice(values, func(i, j int) bool {
+			a, _ := strconv.ParseFloat(values[i], 64)
+			b, _ := strconv.ParseFloat(values[j], 64)
+			return a < b
+		})
+	} else {
+		sort.Slice(values, func(i, j int) bool { return compare(values[i], values[j]) < 0 })
+	}
+	buckets := min(statsHistogramBuckets, n-1)
+	if buckets < 1 {
+		cs.Histogram = []string{values[0]}
+		return cs
+	}
+	cs.Histogram = make([]string, buckets+1)
+	for i := 0; i <= buckets; i++ {
+		cs.Histogram[i] = values[i*(n-1)/buckets]
+	}
+	return cs
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sql
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"flydb/internal/storage"
+)
+
+// fillOrders creates an orders table with n rows spread over four statuses.
+// Every tenth row has a NULL note.
+func fillOrders(t *testing.T, exec *Executor, n int) {
+	t.Helper()
+	if _, err := exec.Execute(parse(t, "CREATE TABLE orders (id INT PRIMARY KEY, status TEXT, note TEXT)")); err != nil {
+		t.Fatalf("CREATE TABLE failed: %v", err)
+	}
+	statuses := []string{"new", "paid", "shipped", "closed"}
+	for i := 0; i < n; i++ {
+		note := fmt.Sprintf("'note %d'", i)
+		if i%10 == 0 {
+			note = "NULL"
+		}
+		query := fmt.Sprintf("INSERT INTO orders VALUES (%d, '%s', %s)", i, statuses[i%4], note)
+		if _, err := exec.Execute(parse(t, query)); err != nil {
+			t.Fatalf("INSERT failed: %v", err)
+		}
+	}
+}
+
+// waitForStats waits for the background ANALYZE of a table to save
+// statistics with the given row count.
+func waitForStats(t *testing.T, exec *Executor, table string, rows int64) *TableStats {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := exec.GetCatalog().StatsMgr.Get(table)
+		if stats != nil && stats.RowCount == rows {
+			return stats
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %s to be analyzed automatically with %d rows, got %+v", table, rows, stats)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExecutorAnalyze(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+	exec.SetAutoAnalyzeFraction(0)
+
+	fillOrders(t, exec, 200)
+
+	result, err := exec.Execute(parse(t, "ANALYZE orders"))
+	if err != nil {
+		t.Fatalf("ANALYZE failed: %v", err)
+	}
+	if result != "ANALYZE OK" {
+		t.Errorf("Expected 'ANALYZE OK', got '%s'", result)
+	}
+
+	stats := exec.GetCatalog().StatsMgr.Get("orders")
+	if stats == nil {
+		t.Fatal("Expected statistics for orders")
+	}
+	if stats.RowCount != 200 {
+		t.Errorf("Expected 200 rows, got %d", stats.RowCount)
+	}
+	if got := stats.Columns["status"].NDistinct; got != 4 {
+		t.Errorf("Expected 4 distinct statuses, got %v", got)
+	}
+	if got := stats.Columns["id"].NDistinct; got != 200 {
+		t.Errorf("Expected 200 distinct ids, got %v", got)
+	}
+	if got := stats.Columns["note"].NullFraction; got < 0.09 || got > 0.11 {
+		t.Errorf("Expected null fraction 0.1 for note, got %v", got)
+	}
+	if h := stats.Columns["id"].Histogram; len(h) < 2 || h[0] != "0" || h[len(h)-1] != "199" {
+		t.Errorf("Expected numeric histogram from 0 to 199, got %v", h)
+	}
+
+	// Statistics survive a new catalog over the same store
+	if NewCatalog(exec.store).StatsMgr.Get("orders") == nil {
+		t.Error("Expected statistics to be persisted")
+	}
+
+	result, err = exec.Execute(&InspectStmt{Target: "TABLE", ObjectName: "orders"})
+	if err != nil {
+		t.Fatalf("INSPECT TABLE failed: %v", err)
+	}
+	if !strings.Contains(result, "Statistics: 200 rows") || !strings.Contains(result, "status: null fraction 0.00, distinct 4") {
+		t.Errorf("Expected statistics in INSPECT TABLE output, got:\n%s", result)
+	}
+
+	// TRUNCATE discards the statistics
+	if _, err := exec.Execute(parse(t, "TRUNCATE TABLE orders")); err != nil {
+		t.Fatalf("TRUNCATE failed: %v", err)
+	}
+	if exec.GetCatalog().StatsMgr.Get("orders") != nil {
+		t.Error("Expected statistics to be dropped by TRUNCATE")
+	}
+}
+
+func TestExecutorAutoAnalyze(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	fillOrders(t, exec, autoAnalyzeMinRows)
+	waitForStats(t, exec, "orders", autoAnalyzeMinRows)
+
+	// Changing less than the threshold keeps the previous statistics
+	if _, err := exec.Execute(parse(t, "DELETE FROM orders WHERE status = 'new'")); err != nil {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	if got := exec.GetCatalog().StatsMgr.Get("orders").RowCount; got != autoAnalyzeMinRows {
+		t.Errorf("Expected statistics to be unchanged, got %d rows", got)
+	}
+}
+
+func TestPlannerSkipsIndexForCommonValues(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+	exec.SetAutoAnalyzeFraction(0)
+
+	fillOrders(t, exec, 40)
+	if _, err := exec.Execute(parse(t, "CREATE INDEX idx_orders_status ON orders (status)")); err != nil {
+		t.Fatalf("CREATE INDEX failed: %v", err)
+	}
+	if _, err := exec.Execute(parse(t, "ANALYZE")); err != nil {
+		t.Fatalf("ANALYZE failed: %v", err)
+	}
+
+	// The index maps each status to a single row, so the planner must scan
+	result, err := exec.Execute(parse(t, "SELECT id FROM orders WHERE status = 'paid'"))
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if !strings.HasSuffix(result, "(10 rows)") {
+		t.Errorf("Expected 10 rows, got:\n%s", result)
+	}
+}
+
+func TestStoreStateReleasedOnClose(t *testing.T) {
+	store, err := storage.NewStorageEngine(storage.StorageConfig{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create storage engine: %v", err)
+	}
+	NewStatsManager(store).RecordChanges("orders", 1, DefaultAutoAnalyzeFraction)
+	recordTTLRun(store, "orders", 1, nil)
+
+	db := &storage.Database{Name: "test", Store: store}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	changeCounters.Lock()
+	_, counted := changeCounters.byStore[store]
+	changeCounters.Unlock()
+	if counted {
+		t.Error("Expected the change counter to be released")
+	}
+	if _, ok := ttlProgressFor(store, "orders"); ok {
+		t.Error("Expected the TTL progress to be released")
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Streamed Results:
+=================
+
+ExecuteStream hands a statement's rows to a RowStream as the executor
+projects them instead of collecting them into a Result. Only a SELECT from
+a single table streams, and only when nothing needs every row before the
+first can be returned: no JOIN, ORDER BY, DISTINCT, GROUP BY, aggregates,
+OFFSET or subquery in WHERE. The table is read before the columns are
+sent, so the rows are a snapshot however slowly the stream takes them, but
+only the raw rows are held: each is projected as it is streamed, and LIMIT
+stops the projection once enough rows were streamed. Views, distributed
+queries and all other statements run as ExecuteResult does and their rows
+are replayed into the stream afterwards.
+
+Streamed rows are not charged to the executor's row budget, since they are
+not held; the stream decides what to keep. An error from the stream stops
+the statement and is returned by ExecuteStream.
+*/
+package sql
+
+import "fmt"
+
+// RowStream receives the rows of a tabular result as they are produced.
+// Columns is called once before the first row.
+type RowStream interface {
+	Columns(columns []ResultColumn) error
+	Row(values []interface{}) error
+}
+
+// rowStreamer streams the rows of one SELECT to a RowStream.
+type rowStreamer struct {
+	stmt    *SelectStmt // The statement whose rows are streamed
+	stream  RowStream
+	columns []ResultColumn
+	rows    int
+	started bool // Whether the executor streamed the rows itself
+}
+
+// ExecuteStream runs the statement as user and streams its rows to stream.
+// The returned Result has no Rows; RowCount is the number of rows streamed.
+// Statements without a tabular result do not call stream.
+func (e *Executor) ExecuteStream(stmt Statement, user string, stream RowStream) (*Result, error) {
+	var streamer *rowStreamer
+	if sel, ok := stmt.(*SelectStmt); ok && streamsRows(sel) {
+		streamer = &rowStreamer{stmt: sel, stream: stream}
+	}
+
+	result, err := e.executeResult(stmt, user, streamer)
+	if err != nil {
+		return nil, err
+	}
+	if streamer != nil && streamer.started {
+		result.Message = fmt.Sprintf("%s\n(%d rows)", headerLine(result.Message), streamer.rows)
+		result.Columns = streamer.columns
+		result.Rows = nil
+		result.RowCount = streamer.rows
+		return result, nil
+	}
+
+	// The rows were collected; replay them.
+	if err := StreamResult(result, stream); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StreamResult hands the rows of a collected result to stream and removes
+// them from the result, keeping RowCount.
+func StreamResult(result *Result, stream RowStream) error {
+	if len(result.Columns) > 0 {
+		if err := stream.Columns(result.Columns); err != nil {
+			return err
+		}
+		for _, row := range result.Rows {
+			if err := stream.Row(row); err != nil {
+				return err
+			}
+		}
+	}
+	result.RowCount = len(result.Rows)
+	result.Rows = nil
+	return nil
+}
+
+// streamsRows reports whether the rows of stmt can be returned in scan
+// order as they are projected.
+func streamsRows(stmt *SelectStmt) bool {
+	return stmt.Join == nil && stmt.Subquery == nil && stmt.OrderBy == nil && !stmt.Distinct &&
+		len(stmt.Aggregates) == 0 && len(stmt.GroupBy) == 0 && stmt.Having == nil && stmt.Offset == 0 &&
+		!whereHasSubquery(stmt.WhereExt)
+}
+
+// startStream reports whether the rows of stmt are streamed, and if so
+// sends the result columns. It is called once the columns are known.
+func (e *Executor) startStream(stmt *SelectStmt) (bool, error) {
+	s := e.rowStream
+	if s == nil || s.stmt != stmt {
+		return false, nil
+	}
+	headers := selectHeaders(stmt)
+	types := e.selectColumnTypes(stmt)
+	s.columns = make([]ResultColumn, len(headers))
+	for i, name := range headers {
+		s.columns[i] = ResultColumn{Name: name, Type: types[i]}
+	}
+	s.started = true
+	return true, s.stream.Columns(s.columns)
+}
+
+// streamRow sends the values of a projected row to the stream. The first
+// error is kept in rowErr, which stops the scan.
+func (e *Executor) streamRow(values []interface{}) {
+	s := e.rowStream
+	if e.rowErr != nil {
+		return
+	}
+	if e.rowErr = s.stream.Row(typedRow(values, s.columns)); e.rowErr == nil {
+		s.rows++
+	}
+}
+
+// streamFull reports whether the stream holds as many rows as the LIMIT
+// of its statement.
+func (e *Executor) streamFull() bool {
+	return e.rowStream.stmt.Limit > 0 && e.rowStream.rows >= e.rowStream.stmt.Limit
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// collectStream records what a statement streams. It fails the rows after
+// the first failAfter when failAfter is positive.
+type collectStream struct {
+	columns   []ResultColumn
+	rows      [][]interface{}
+	failAfter int
+}
+
+func (s *collectStream) Columns(columns []ResultColumn) error {
+	if s.columns != nil || s.rows != nil {
+		return errors.New("columns sent twice or after rows")
+	}
+	s.columns = columns
+	return nil
+}
+
+func (s *collectStream) Row(values []interface{}) error {
+	if s.failAfter > 0 && len(s.rows) == s.failAfter {
+		return errors.New("stream closed")
+	}
+	s.rows = append(s.rows, values)
+	return nil
+}
+
+func TestExecuteStream(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	parse := func(query string) Statement {
+		t.Helper()
+		stmt, err := NewParser(NewLexer(query)).Parse()
+		if err != nil {
+			t.Fatalf("Parse %q failed: %v", query, err)
+		}
+		return stmt
+	}
+	stream := func(query string, s *collectStream) (*Result, error) {
+		t.Helper()
+		return exec.ExecuteStream(parse(query), "", s)
+	}
+
+	if _, err := exec.Execute(parse("CREATE TABLE items (id INT, name TEXT)")); err != nil {
+		t.Fatalf("CREATE TABLE failed: %v", err)
+	}
+	s := &collectStream{}
+	result, err := stream("INSERT INTO items (id, name) VALUES (1, 'a')", s)
+	if err != nil || result.RowsAffected != 1 || s.columns != nil {
+		t.Fatalf("Unexpected INSERT result: %+v, %v, columns %v", result, err, s.columns)
+	}
+	for i := 2; i <= 10; i++ {
+		if _, err := exec.Execute(parse(fmt.Sprintf("INSERT INTO items (id, name) VALUES (%d, 'n%d')", i, i))); err != nil {
+			t.Fatalf("INSERT failed: %v", err)
+		}
+	}
+
+	// A plain scan streams typed rows and stops at its LIMIT
+	s = &collectStream{}
+	result, err = stream("SELECT id, name FROM items LIMIT 4", s)
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	wantColumns := []ResultColumn{{Name: "id", Type: "INT"}, {Name: "name", Type: "TEXT"}}
+	if !reflect.DeepEqual(s.columns, wantColumns) || len(s.rows) != 4 || result.RowCount != 4 || result.Rows != nil {
+		t.Errorf("Unexpected stream: columns %v, rows %v, result %+v", s.columns, s.rows, result)
+	}
+	if _, ok := s.rows[0][0].(int64); !ok {
+		t.Errorf("Expected typed values, got %T", s.rows[0][0])
+	}
+
+	// Queries that need every row first are replayed in order
+	s = &collectStream{}
+	if _, err := stream("SELECT id FROM items ORDER BY id DESC LIMIT 2", s); err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if want := [][]interface{}{{int64(10)}, {int64(9)}}; !reflect.DeepEqual(s.rows, want) {
+		t.Errorf("Expected rows %v, got %v", want, s.rows)
+	}
+
+	// An error from the stream stops the statement
+	s = &collectStream{failAfter: 3}
+	if _, err := stream("SELECT * FROM items", s); err == nil || err.Error() != "stream closed" {
+		t.Errorf("Expected the stream's error, got %v", err)
+	}
+	if len(s.rows) != 3 {
+		t.Errorf("Expected 3 rows before the error, got %d", len(s.rows))
+	}
+}
@@ -0,0 +1,354 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Row Expiry (TTL):
+=================
+
+A table created WITH (ttl = '30 days', ttl_column = created_at) expires each
+row 30 days after its created_at value. Without a ttl option the column holds
+the expiry time itself. The column may be a TIMESTAMP, DATETIME or DATE, a
+text column holding such a value, or an integer holding Unix seconds. Rows
+whose TTL column is NULL never expire.
+
+Expired rows are removed by a background reaper that the server runs
+periodically. The reaper deletes rows in batches through the same path as
+DELETE, so foreign key actions, indexes, triggers, WATCH subscribers and the
+WAL see an ordinary delete. Each batch fires the table's statement-level
+DELETE triggers once. Batches walk the table's keys in order from where the
+previous batch stopped, so a pass reads each row once.
+
+Reaper progress is kept per storage engine and table, and reported by
+INSPECT STATUS.
+*/
+package sql
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ferrors "flydb/internal/errors"
+	"flydb/internal/storage"
+)
+
+// DefaultTTLBatchSize is the number of expired rows the reaper deletes per
+// batch unless configured otherwise.
+const DefaultTTLBatchSize = 1000
+
+// ttlColumnTypes are the column types a TTL can be based on.
+var ttlColumnTypes = map[ColumnType]bool{
+	TypeTIMESTAMP: true,
+	TypeDATETIME:  true,
+	TypeDATE:      true,
+	TypeTEXT:      true,
+	TypeVARCHAR:   true,
+	TypeINT:       true,
+	TypeBIGINT:    true,
+}
+
+// ttlInterval is a parsed TTL such as '30 days'.
+type ttlInterval struct {
+	n    int
+	unit string // SECOND, MINUTE, HOUR, DAY, WEEK, MONTH or YEAR
+}
+
+// parseTTLInterval parses an interval of the form '<n> <unit>', where unit
+// is a second, minute, hour, day, week, month or year, singular or plural.
+func parseTTLInterval(s string) (ttlInterval, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return ttlInterval{}, ferrors.InvalidValue("ttl", fmt.Sprintf("'%s' is not an interval", s)).WithHint("use '<number> <unit>', for example '30 days'")
+	}
+	n, err := strconv.Atoi(fields[0])
+	if err != nil || n <= 0 {
+		return ttlInterval{}, ferrors.InvalidValue("ttl", fmt.Sprintf("'%s' is not a positive whole number of units", s))
+	}
+
+	unit := strings.TrimSuffix(strings.ToUpper(fields[1]), "S")
+	switch unit {
+	case "SECOND", "MINUTE", "HOUR", "DAY", "WEEK", "MONTH", "YEAR":
+		return ttlInterval{n: n, unit: unit}, nil
+	}
+	return ttlInterval{}, ferrors.InvalidValue("ttl", fmt.Sprintf("unknown unit in '%s'", s)).WithHint("use seconds, minutes, hours, days, weeks, months or years")
+}
+
+// addTo returns t plus the interval.
+func (i ttlInterval) addTo(t time.Time) time.Time {
+	switch i.unit {
+	case "SECOND":
+		return t.Add(time.Duration(i.n) * time.Second)
+	case "MINUTE":
+		return t.Add(time.Duration(i.n) * time.Minute)
+	case "HOUR":
+		return t.Add(time.Duration(i.n) * time.Hour)
+	case "DAY":
+		return t.AddDate(0, 0, i.n)
+	case "WEEK":
+		return t.AddDate(0, 0, 7*i.n)
+	case "MONTH":
+		return t.AddDate(0, i.n, 0)
+	default:
+		return t.AddDate(i.n, 0, 0)
+	}
+}
+
+// validateTTL checks a TTL specification against the table's columns.
+func validateTTL(columns []ColumnDef, spec *TTLSpec) error {
+	var col *ColumnDef
+	for i := range columns {
+		if columns[i].Name == spec.Column {
+			col = &columns[i]
+		}
+	}
+	if col == nil {
+		return ferrors.ColumnNotFound(spec.Column, "")
+	}
+	if !ttlColumnTypes[ColumnType(strings.ToUpper(col.Type))] {
+		return ferrors.InvalidValue("ttl_column", fmt.Sprintf("column %s has type %s", spec.Column, col.Type)).WithHint("the TTL column must be a TIMESTAMP, DATETIME, DATE, text or integer column")
+	}
+	if spec.Interval != "" {
+		if _, err := parseTTLInterval(spec.Interval); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expiresAt returns when a row expires, or false if it never does.
+func (s *TTLSpec) expiresAt(row map[string]interface{}) (time.Time, bool) {
+	var t time.Time
+	switch v := row[s.Column].(type) {
+	case nil:
+		return t, false
+	case float64:
+		t = time.Unix(int64(v), 0)
+	default:
+		str := fmt.Sprintf("%v", v)
+		if str == "" || strings.EqualFold(str, "NULL") {
+			return t, false
+		}
+		if secs, err := strconv.ParseInt(str, 10, 64); err == nil {
+			t = time.Unix(secs, 0)
+		} else if parsed, err := parseDateTime(str); err == nil {
+			t = parsed
+		} else {
+			return t, false
+		}
+	}
+
+	if s.Interval == "" {
+		return t, true
+	}
+	interval, err := parseTTLInterval(s.Interval)
+	if err != nil {
+		return t, false
+	}
+	return interval.addTo(t), true
+}
+
+// String returns the TTL in CREATE TABLE syntax.
+func (s *TTLSpec) String() string {
+	if s.Interval == "" {
+		return fmt.Sprintf("ttl_column = %s", s.Column)
+	}
+	return fmt.Sprintf("ttl = '%s', ttl_column = %s", s.Interval, s.Column)
+}
+
+// TTLProgress reports the reaper's progress on one table.
+type TTLProgress struct {
+	Table        string    // Table name
+	LastRun      time.Time // When the reaper last finished with the table
+	LastExpired  int       // Rows deleted by the last run
+	TotalExpired uint64    // Rows deleted since the server started
+	LastError    string    // Error of the last run, if it failed
+}
+
+// ttlProgress holds reaper progress per storage engine and table. Like
+// changeCounters it lives outside the Catalog because the server builds a
+// fresh executor per statement for non-default databases, and it is
+// released the same way when the database is closed.
+var ttlProgress = struct {
+	sync.Mutex
+	byStore map[storage.Engine]map[string]*TTLProgress
+}{byStore: make(map[storage.Engine]map[string]*TTLProgress)}
+
+// recordTTLRun records the outcome of reaping a table.
+func recordTTLRun(store storage.Engine, table string, expired int, err error) {
+	trackStore()
+	ttlProgress.Lock()
+	defer ttlProgress.Unlock()
+
+	tables, ok := ttlProgress.byStore[store]
+	if !ok {
+		tables = make(map[string]*TTLProgress)
+		ttlProgress.byStore[store] = tables
+	}
+	p, ok := tables[table]
+	if !ok {
+		p = &TTLProgress{Table: table}
+		tables[table] = p
+	}
+	p.LastRun = time.Now()
+	p.LastExpired = expired
+	p.TotalExpired += uint64(expired)
+	p.LastError = ""
+	if err != nil {
+		p.LastError = err.Error()
+	}
+}
+
+// ttlProgressFor returns the reaper progress of a table, if it has run.
+func ttlProgressFor(store storage.Engine, table string) (TTLProgress, bool) {
+	ttlProgress.Lock()
+	defer ttlProgress.Unlock()
+
+	if p, ok := ttlProgress.byStore[store][table]; ok {
+		return *p, true
+	}
+	return TTLProgress{}, false
+}
+
+// ReapExpiredRows deletes the expired rows of every table with a TTL in the
+// executor's current database, batchSize rows at a time. It returns the
+// number of rows deleted. A failure on one table does not stop the others;
+// the first error is returned.
+func (e *Executor) ReapExpiredRows(batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultTTLBatchSize
+	}
+
+	cat, err := e.getCatalog("")
+	if err != nil {
+		return 0, err
+	}
+	dbName := e.currentDatabase
+	if dbName == "" {
+		dbName = storage.DefaultDatabaseName
+	}
+
+	var tables []string
+	for name, table := range cat.Tables {
+		if table.TTL != nil {
+			tables = append(tables, name)
+		}
+	}
+	sort.Strings(tables)
+
+	total := 0
+	var firstErr error
+	for _, name := range tables {
+		expired := 0
+		var err error
+		// Each batch resumes after the last key the previous one examined,
+		// so a pass reads every row of the table once.
+		for cursor := ""; ; {
+			var n int
+			n, cursor, err = e.reapExpiredBatch(cat, dbName, name, cursor, batchSize)
+			expired += n
+			if err != nil || cursor == "" {
+				break
+			}
+		}
+		recordTTLRun(cat.store, name, expired, err)
+		total += expired
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return total, firstErr
+}
+
+// reapExpiredBatch deletes up to limit expired rows of a table, examining
+// rows in key order from cursor. It returns how many rows were deleted and
+// the cursor of the next batch, which is empty once the table is done.
+func (e *Executor) reapExpiredBatch(cat *Catalog, dbName, tableName, cursor string, limit int) (int, string, error) {
+	table, ok := cat.GetTable(tableName)
+	if !ok || table.TTL == nil {
+		return 0, "", nil
+	}
+
+	// Collect the expired rows first so that triggers only fire when
+	// something is deleted. Keys are listed limit at a time and their
+	// rows read afterwards, since the engine may not be called back
+	// during a key scan.
+	prefix := "row:" + tableName + ":"
+	now := time.Now()
+	expired := make(map[string]map[string]interface{})
+	for len(expired) < limit {
+		keys := make([]string, 0, limit)
+		err := storage.ScanKeys(cat.store, prefix, cursor, func(key string) bool {
+			keys = append(keys, key)
+			return len(keys) < limit
+		})
+		if err != nil {
+			return 0, "", ferrors.NewStorageError("failed to scan table rows").WithCause(err)
+		}
+		if len(keys) == 0 {
+			cursor = ""
+			break
+		}
+
+		for _, key := range keys {
+			// Resume just after the last key examined
+			cursor = key + "\x00"
+			val, err := cat.store.Get(key)
+			if err != nil {
+				continue
+			}
+			var row map[string]interface{}
+			if err := json.Unmarshal(val, &row); err != nil {
+				continue
+			}
+			if at, ok := table.TTL.expiresAt(row); ok && !at.After(now) {
+				expired[key] = row
+				if len(expired) == limit {
+					break
+				}
+			}
+		}
+	}
+	if len(expired) == 0 {
+		return 0, cursor, nil
+	}
+
+	if err := e.executeTriggers(cat, dbName, tableName, TriggerTimingBefore, TriggerEventDelete); err != nil {
+		return 0, "", ferrors.NewExecutionError("BEFORE DELETE trigger failed").WithCause(err)
+	}
+
+	count := 0
+	for key, row := range expired {
+		if err := e.deleteRow(cat, tableName, key, row); err != nil {
+			e.recordChanges(cat, tableName, count)
+			return count, "", err
+		}
+		count++
+	}
+
+	if err := e.executeTriggers(cat, dbName, tableName, TriggerTimingAfter, TriggerEventDelete); err != nil {
+		return count, "", ferrors.NewExecutionError("AFTER DELETE trigger failed").WithCause(err)
+	}
+
+	e.recordChanges(cat, tableName, count)
+	if e.queryCache != nil {
+		e.queryCache.Invalidate(tableName)
+	}
+	return count, cursor, nil
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sql
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTTLInterval(t *testing.T) {
+	base := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"30 days", base.AddDate(0, 0, 30)},
+		{"1 day", base.AddDate(0, 0, 1)},
+		{"12 hours", base.Add(12 * time.Hour)},
+		{"2 WEEKS", base.AddDate(0, 0, 14)},
+		{"1 month", base.AddDate(0, 1, 0)},
+	}
+	for _, tt := range tests {
+		interval, err := parseTTLInterval(tt.input)
+		if err != nil {
+			t.Errorf("parseTTLInterval(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got := interval.addTo(base); !got.Equal(tt.want) {
+			t.Errorf("parseTTLInterval(%q) adds to %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "30", "days", "-1 day", "3 fortnights"} {
+		if _, err := parseTTLInterval(input); err == nil {
+			t.Errorf("Expected parseTTLInterval(%q) to fail", input)
+		}
+	}
+}
+
+func TestExecutorTTLValidation(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	invalid := []string{
+		"CREATE TABLE s1 (id INT, created_at TIMESTAMP) WITH (ttl = '30 days', ttl_column = missing)",
+		"CREATE TABLE s2 (id INT, created_at TIMESTAMP) WITH (ttl = 'soon', ttl_column = created_at)",
+		"CREATE TABLE s3 (id INT, active BOOLEAN) WITH (ttl = '1 day', ttl_column = active)",
+	}
+	for _, query := range invalid {
+		if _, err := exec.Execute(parse(t, query)); err == nil {
+			t.Errorf("Expected %q to fail", query)
+		}
+	}
+
+	if _, err := exec.Execute(parse(t, "CREATE TABLE sessions (id INT PRIMARY KEY, created_at TIMESTAMP) WITH (ttl = '30 days', ttl_column = created_at)")); err != nil {
+		t.Fatalf("CREATE TABLE failed: %v", err)
+	}
+	if _, err := exec.Execute(&AlterTableStmt{TableName: "sessions", Action: AlterActionDropColumn, ColumnName: "created_at"}); err == nil {
+		t.Error("Expected dropping the TTL column to fail")
+	}
+
+	result, err := exec.Execute(&InspectStmt{Target: "TABLE", ObjectName: "sessions"})
+	if err != nil {
+		t.Fatalf("INSPECT TABLE failed: %v", err)
+	}
+	if !strings.Contains(result, "TTL: ttl = '30 days', ttl_column = created_at") {
+		t.Errorf("Expected TTL in INSPECT TABLE output, got:\n%s", result)
+	}
+}
+
+func TestExecutorReapExpiredRows(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	old := time.Now().AddDate(0, 0, -31).UTC().Format(time.RFC3339)
+	recent := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	queries := []string{
+		"CREATE TABLE sessions (id INT PRIMARY KEY, created_at TIMESTAMP) WITH (ttl = '30 days', ttl_column = created_at)",
+		"CREATE TABLE session_data (id INT, session_id INT REFERENCES sessions(id) ON DELETE CASCADE)",
+		"CREATE TABLE reaped (note TEXT)",
+		"CREATE INDEX idx_sessions_created ON sessions (created_at)",
+	}
+	for i := 1; i <= 5; i++ {
+		queries = append(queries, fmt.Sprintf("INSERT INTO sessions VALUES (%d, '%s')", i, old))
+	}
+	queries = append(queries,
+		fmt.Sprintf("INSERT INTO sessions VALUES (6, '%s')", recent),
+		"INSERT INTO sessions VALUES (7, NULL)",
+		"INSERT INTO session_data VALUES (1, 1)",
+		"INSERT INTO session_data VALUES (2, 6)",
+	)
+	for _, query := range queries {
+		if _, err := exec.Execute(parse(t, query)); err != nil {
+			t.Fatalf("%s failed: %v", query, err)
+		}
+	}
+	if _, err := exec.Execute(&CreateTriggerStmt{
+		TriggerName: "log_reap",
+		Timing:      TriggerTimingAfter,
+		Event:       TriggerEventDelete,
+		TableName:   "sessions",
+		ActionSQL:   "INSERT INTO reaped VALUES ( 'batch' )",
+	}); err != nil {
+		t.Fatalf("CREATE TRIGGER failed: %v", err)
+	}
+
+	// Five expired rows in batches of two take three batches
+	expired, err := exec.ReapExpiredRows(2)
+	if err != nil {
+		t.Fatalf("ReapExpiredRows failed: %v", err)
+	}
+	if expired != 5 {
+		t.Errorf("Expected 5 expired rows, got %d", expired)
+	}
+
+	result, err := exec.Execute(parse(t, "SELECT id FROM sessions"))
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if !strings.HasSuffix(result, "(2 rows)") {
+		t.Errorf("Expected the recent and NULL rows to remain, got:\n%s", result)
+	}
+
+	// Foreign key actions, triggers and indexes see an ordinary delete
+	result, err = exec.Execute(parse(t, "SELECT id FROM session_data"))
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if !strings.HasSuffix(result, "(1 rows)") {
+		t.Errorf("Expected the cascade to remove the expired session's data, got:\n%s", result)
+	}
+	result, err = exec.Execute(parse(t, "SELECT note FROM reaped"))
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if !strings.HasSuffix(result, "(3 rows)") {
+		t.Errorf("Expected the DELETE trigger to fire once per batch, got:\n%s", result)
+	}
+	result, err = exec.Execute(parse(t, fmt.Sprintf("SELECT id FROM sessions WHERE created_at = '%s'", old)))
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if !strings.HasSuffix(result, "(0 rows)") {
+		t.Errorf("Expected the index to no longer return expired rows, got:\n%s", result)
+	}
+
+	result, err = exec.Execute(&InspectStmt{Target: "STATUS"})
+	if err != nil {
+		t.Fatalf("INSPECT STATUS failed: %v", err)
+	}
+	if !strings.Contains(result, "TTL tables: 1") || !strings.Contains(result, "expired 5 rows (5 in total)") {
+		t.Errorf("Expected reaper progress in INSPECT STATUS output, got:\n%s", result)
+	}
+
+	// A second pass finds nothing to do
+	if expired, err := exec.ReapExpiredRows(2); err != nil || expired != 0 {
+		t.Errorf("Expected nothing to expire, got %d (%v)", expired, err)
+	}
+}
+
+func TestExecutorReapExpiryColumn(t *testing.T) {
+	exec, cleanup := setupExecutorTest(t)
+	defer cleanup()
+
+	past := time.Now().Add(-time.Minute).Unix()
+	future := time.Now().Add(time.Hour).Unix()
+	queries := []string{
+		"CREATE TABLE tokens (token TEXT PRIMARY KEY, expires_at BIGINT) WITH (ttl_column = expires_at)",
+		fmt.Sprintf("INSERT INTO tokens VALUES ('a', %d)", past),
+		fmt.Sprintf("INSERT INTO tokens VALUES ('b', %d)", future),
+	}
+	for _, query := range queries {
+		if _, err := exec.Execute(parse(t, query)); err != nil {
+			t.Fatalf("%s failed: %v", query, err)
+		}
+	}
+
+	expired, err := exec.ReapExpiredRows(DefaultTTLBatchSize)
+	if err != nil {
+		t.Fatalf("ReapExpiredRows failed: %v", err)
+	}
+	if expired != 1 {
+		t.Errorf("Expected 1 expired token, got %d", expired)
+	}
+}
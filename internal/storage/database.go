@@ -157,12 +157,34 @@ type Database struct {
 	CreatedAt time.Time         // When the database was created (from metadata)
 }
 
+// closeHooks are called with the storage engine of each database that is
+// closed.
+var closeHooks struct {
+	sync.Mutex
+	fns []func(Engine)
+}
+
+// OnClose registers fn to be called with a database's storage engine just
+// before the engine is closed, so packages that keep state per engine can
+// release it.
+func OnClose(fn func(Engine)) {
+	closeHooks.Lock()
+	defer closeHooks.Unlock()
+	closeHooks.fns = append(closeHooks.fns, fn)
+}
+
 // Close closes the database and releases resources.
 func (d *Database) Close() error {
-	if d.Store != nil {
-		return d.Store.Close()
+	if d.Store == nil {
+		return nil
 	}
-	return nil
+	closeHooks.Lock()
+	fns := closeHooks.fns
+	closeHooks.Unlock()
+	for _, fn := range fns {
+		fn(d.Store)
+	}
+	return d.Store.Close()
 }
 
 // LoadMetadata loads the database metadata from storage.
@@ -286,6 +308,164 @@ type DatabaseManager struct {
 	encConfig EncryptionConfig     // Encryption configuration
 	mu        sync.RWMutex         // Protects databases map
 	systemStore Engine             // Reference to system DB store for catalog replication
+
+	// commitPolicy is the default synchronous_commit policy applied by Commit.
+	// Engines created by the manager do not wait on each write; durability is
+	// enforced once per statement or transaction through Commit.
+	commitPolicy SyncPolicy
+
+	// writeProposer, when set, receives every write made to a database
+	// instead of the database's own store (see SetWriteProposer).
+	writeProposer func(database string, ops []TxOperation) error
+}
+
+// SetCommitPolicy sets the default synchronous_commit policy.
+func (m *DatabaseManager) SetCommitPolicy(policy SyncPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commitPolicy = policy
+}
+
+// CommitPolicy returns the default synchronous_commit policy.
+func (m *DatabaseManager) CommitPolicy() SyncPolicy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.commitPolicy
+}
+
+// Commit waits until every write made so far to any loaded database is as
+// durable as policy requires. Callers invoke it when a statement or
+// transaction completes, before acknowledging it to the client. Loaded
+// databases are committed together so writes to the system database made on
+// behalf of a statement (users, grants) are covered as well.
+func (m *DatabaseManager) Commit(policy SyncPolicy) error {
+	m.mu.RLock()
+	committers := make([]Committer, 0, len(m.databases))
+	for _, db := range m.databases {
+		if c, ok := db.Store.(Committer); ok {
+			committers = append(committers, c)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, c := range committers {
+		if err := c.Commit(policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetWriteProposer routes writes to every database, including databases
+// loaded or created later, through fn. A single Put or Delete arrives as a
+// batch of one. In cluster mode with Raft, fn proposes the batch to the Raft
+// log as one entry and returns once it has been committed and applied
+// locally through ApplyCommittedAt.
+func (m *DatabaseManager) SetWriteProposer(fn func(database string, ops []TxOperation) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writeProposer = fn
+	for _, db := range m.databases {
+		m.attachWriteProposer(db)
+	}
+}
+
+// attachWriteProposer routes db's writes through the write proposer.
+// The caller must hold m.mu.
+func (m *DatabaseManager) attachWriteProposer(db *Database) {
+	unified, ok := db.Store.(*UnifiedStorageEngine)
+	if !ok {
+		return
+	}
+	propose := m.writeProposer
+	if propose == nil {
+		unified.SetWriteProposer(nil)
+		return
+	}
+	name := db.Name
+	unified.SetWriteProposer(func(ops []TxOperation) error {
+		return propose(name, ops)
+	})
+}
+
+// ApplyCommitted applies a write that the cluster has committed to the named
+// database. A database this node does not have yet is created first, so
+// followers converge on the leader's set of databases.
+func (m *DatabaseManager) ApplyCommitted(database string, op byte, key string, value []byte) error {
+	ops := []TxOperation{{Op: op, Key: key, Value: value}}
+	return m.applyCommitted(database, ops, func(unified *UnifiedStorageEngine) error {
+		return unified.ApplyCommitted(op, key, value)
+	})
+}
+
+// ApplyCommittedAt applies the writes of the Raft log entry at index to the
+// named database, like ApplyCommitted. The database persists index with the
+// writes and skips entries it has already applied; see
+// UnifiedStorageEngine.ApplyCommittedAt.
+func (m *DatabaseManager) ApplyCommittedAt(database string, index uint64, ops []TxOperation) error {
+	return m.applyCommitted(database, ops, func(unified *UnifiedStorageEngine) error {
+		return unified.ApplyCommittedAt(index, ops)
+	})
+}
+
+// applyCommitted runs apply to write ops to the named database, creating it
+// first if needed.
+func (m *DatabaseManager) applyCommitted(database string, ops []TxOperation, apply func(*UnifiedStorageEngine) error) error {
+	if !m.DatabaseExists(database) {
+		if err := m.CreateDatabaseIfNotExists(database); err != nil {
+			return err
+		}
+	}
+	db, err := m.GetDatabase(database)
+	if err != nil {
+		return err
+	}
+
+	unified, ok := db.Store.(*UnifiedStorageEngine)
+	if !ok {
+		return fmt.Errorf("database '%s' does not support replicated writes", database)
+	}
+	if err := apply(unified); err != nil {
+		return err
+	}
+
+	// Keep the in-memory metadata in step with the replicated copy
+	for _, op := range ops {
+		if op.Key == databaseMetadataKey && op.Op == OpPut {
+			db.LoadMetadata()
+			break
+		}
+	}
+	return nil
+}
+
+// ScanDatabase returns the key-value pairs in the named database that match
+// the given prefix.
+func (m *DatabaseManager) ScanDatabase(database, prefix string) (map[string][]byte, error) {
+	db, err := m.GetDatabase(database)
+	if err != nil {
+		return nil, err
+	}
+	return db.Store.Scan(prefix)
+}
+
+// ScanDatabaseKeys calls fn with each key in the named database that matches
+// prefix and is not less than start, in key order, until fn returns false.
+func (m *DatabaseManager) ScanDatabaseKeys(database, prefix, start string, fn func(key string) bool) error {
+	db, err := m.GetDatabase(database)
+	if err != nil {
+		return err
+	}
+	return ScanKeys(db.Store, prefix, start, fn)
+}
+
+// GetFromDatabase returns the value of key in the named database.
+func (m *DatabaseManager) GetFromDatabase(database, key string) ([]byte, error) {
+	db, err := m.GetDatabase(database)
+	if err != nil {
+		return nil, err
+	}
+	return db.Store.Get(key)
 }
 
 // SetSystemStore sets the system database store.
@@ -342,6 +522,7 @@ func (m *DatabaseManager) HandleReplicatedDatabaseEvent(key string, value []byte
 		}
 		
 		m.mu.Lock()
+		m.attachWriteProposer(db)
 		m.databases[dbName] = db
 		m.mu.Unlock()
 		fmt.Printf("Database '%s' replicated successfully\n", dbName)
@@ -372,13 +553,15 @@ func NewDatabaseManager(dataDir string, encConfig EncryptionConfig) (*DatabaseMa
 }
 
 // createStorageEngine creates a storage engine for a database.
-// It uses the unified disk-based storage engine with WAL.
+// It uses the unified disk-based storage engine with WAL. Writes do not wait
+// for the WAL individually; see Commit.
 func (m *DatabaseManager) createStorageEngine(dbPath string) (Engine, error) {
 	config := StorageConfig{
 		DataDir:            dbPath,
 		BufferPoolSize:     0, // Auto-size
 		CheckpointInterval: 60 * time.Second,
 		Encryption:         m.encConfig,
+		SyncPolicy:         SyncPolicy{Mode: SyncOff},
 	}
 	return NewStorageEngine(config)
 }
@@ -509,18 +692,51 @@ func (m *DatabaseManager) CreateDatabaseWithOptions(name string, opts CreateData
 	}
 
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	db, err := m.createDatabaseLocked(name, opts)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Writes through the system store are made after m.mu is released: with
+	// Raft they wait until the cluster has applied them, which needs the
+	// manager.
+	if m.systemStore != nil {
+		catalogKey := databaseCatalogPrefix + name
+		// We just store the name as value for now
+		if err := m.systemStore.Put(catalogKey, []byte(name)); err != nil {
+			// Log error but continue - local creation succeeded
+			fmt.Printf("Warning: failed to replicate database creation: %v\n", err)
+		}
+	}
+
+	// The metadata was saved locally; save it through the cluster as well
+	// so followers get the same options
+	m.mu.RLock()
+	replicated := m.writeProposer != nil
+	m.mu.RUnlock()
+	if replicated {
+		if err := db.SaveMetadata(); err != nil {
+			fmt.Printf("Warning: failed to replicate database metadata: %v\n", err)
+		}
+	}
+
+	return nil
+}
 
+// createDatabaseLocked creates the database's storage and metadata and
+// registers it. The caller must hold m.mu.
+func (m *DatabaseManager) createDatabaseLocked(name string, opts CreateDatabaseOptions) (*Database, error) {
 	// Check if database already exists
 	dbPath := m.getDatabasePath(name)
 	if _, err := os.Stat(dbPath); err == nil {
-		return fmt.Errorf("database '%s' already exists", name)
+		return nil, fmt.Errorf("database '%s' already exists", name)
 	}
 
 	// Create the storage engine
 	store, err := m.createStorageEngine(dbPath)
 	if err != nil {
-		return fmt.Errorf("failed to create database '%s': %w", name, err)
+		return nil, fmt.Errorf("failed to create database '%s': %w", name, err)
 	}
 
 	// Create metadata
@@ -550,23 +766,14 @@ func (m *DatabaseManager) CreateDatabaseWithOptions(name string, opts CreateData
 	if err := db.SaveMetadata(); err != nil {
 		store.Close()
 		os.RemoveAll(dbPath)
-		return fmt.Errorf("failed to save database metadata: %w", err)
-	}
-
-	// Persist metadata to the system store for replication
-	if m.systemStore != nil {
-		catalogKey := databaseCatalogPrefix + name
-		// We just store the name as value for now
-		if err := m.systemStore.Put(catalogKey, []byte(name)); err != nil {
-			// Log error but continue - local creation succeeded
-			fmt.Printf("Warning: failed to replicate database creation: %v\n", err)
-		}
+		return nil, fmt.Errorf("failed to save database metadata: %w", err)
 	}
 
 	// Store in the map
+	m.attachWriteProposer(db)
 	m.databases[name] = db
 
-	return nil
+	return db, nil
 }
 
 // CreateDatabaseIfNotExists creates a database if it doesn't already exist.
@@ -626,6 +833,7 @@ func (m *DatabaseManager) CreateDatabaseIfNotExistsWithOptions(name string, opts
 		return fmt.Errorf("failed to save database metadata: %w", err)
 	}
 
+	m.attachWriteProposer(db)
 	m.databases[name] = db
 
 	return nil
@@ -742,6 +950,7 @@ func (m *DatabaseManager) GetDatabase(name string) (*Database, error) {
 	}
 	db.CreatedAt = db.Metadata.CreatedAt
 
+	m.attachWriteProposer(db)
 	m.databases[name] = db
 
 	return db, nil
@@ -287,3 +287,89 @@ func TestDatabaseHelperMethods(t *testing.T) {
 		t.Errorf("IsReadOnly() should return false by default")
 	}
 }
+
+func TestDatabaseManagerWriteProposer(t *testing.T) {
+	leader, err := NewDatabaseManager(t.TempDir(), EncryptionConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create DatabaseManager: %v", err)
+	}
+	defer leader.Close()
+	follower, err := NewDatabaseManager(t.TempDir(), EncryptionConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create DatabaseManager: %v", err)
+	}
+	defer follower.Close()
+
+	// A proposer that "commits" each batch by applying it on both managers
+	var proposed []string
+	var index uint64
+	leader.SetWriteProposer(func(database string, ops []TxOperation) error {
+		for _, op := range ops {
+			proposed = append(proposed, database+"/"+op.Key)
+		}
+		index++
+		if err := leader.ApplyCommittedAt(database, index, ops); err != nil {
+			return err
+		}
+		return follower.ApplyCommittedAt(database, index, ops)
+	})
+
+	opts := DefaultCreateDatabaseOptions()
+	opts.Owner = "alice"
+	if err := leader.CreateDatabaseWithOptions("appdb", opts); err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	db, err := leader.GetDatabase("appdb")
+	if err != nil {
+		t.Fatalf("Failed to get database: %v", err)
+	}
+	if err := db.Store.Put("row:users:1", []byte("alice")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if len(proposed) != 2 || proposed[1] != "appdb/row:users:1" {
+		t.Errorf("Expected the metadata and the row to be proposed, got %v", proposed)
+	}
+
+	// The follower created the database and applied both writes
+	replica, err := follower.GetDatabase("appdb")
+	if err != nil {
+		t.Fatalf("Follower did not create the database: %v", err)
+	}
+	if value, err := replica.Store.Get("row:users:1"); err != nil || string(value) != "alice" {
+		t.Errorf("Expected the row on the follower, got %q (%v)", value, err)
+	}
+	if replica.GetOwner() != "alice" {
+		t.Errorf("Expected the replicated owner alice, got %q", replica.GetOwner())
+	}
+
+	// A transaction's writes are proposed as one batch
+	batches := 0
+	leader.SetWriteProposer(func(database string, ops []TxOperation) error {
+		batches++
+		index++
+		return leader.ApplyCommittedAt(database, index, ops)
+	})
+	tx := NewTransaction(db.Store)
+	tx.Put("row:users:2", []byte("bob"))
+	tx.Put("row:users:3", []byte("carol"))
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if batches != 1 {
+		t.Errorf("Expected the transaction to be proposed as 1 batch, got %d", batches)
+	}
+	if value, err := db.Store.Get("row:users:3"); err != nil || string(value) != "carol" {
+		t.Errorf("Expected the committed row, got %q (%v)", value, err)
+	}
+
+	// A write the cluster rejects is not applied locally
+	leader.SetWriteProposer(func(string, []TxOperation) error {
+		return os.ErrDeadlineExceeded
+	})
+	if err := db.Store.Delete("row:users:1"); err == nil {
+		t.Error("Expected the rejected delete to fail")
+	}
+	if _, err := db.Store.Get("row:users:1"); err != nil {
+		t.Errorf("Rejected delete was applied: %v", err)
+	}
+}
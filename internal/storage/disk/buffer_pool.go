@@ -148,6 +148,9 @@ type BufferPool struct {
 	misses     atomic.Int64
 	prefetches atomic.Int64
 
+	// writeHook runs before dirty pages are written to the file
+	writeHook func() error
+
 	// Prefetching
 	prefetchChan   chan PageID
 	prefetchDone   chan struct{}
@@ -242,6 +245,25 @@ func NewBufferPool(heapFile *HeapFile, poolSize int) *BufferPool {
 	return bp
 }
 
+// SetWriteHook sets a function that runs before dirty pages are written back,
+// whether on eviction or on a flush. The disk engine uses it to make the WAL
+// durable ahead of the pages it describes.
+func (bp *BufferPool) SetWriteHook(fn func() error) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	bp.writeHook = fn
+}
+
+// writePageLocked runs the write hook and writes a dirty frame's page.
+func (bp *BufferPool) writePageLocked(frame *Frame) error {
+	if bp.writeHook != nil {
+		if err := bp.writeHook(); err != nil {
+			return err
+		}
+	}
+	return bp.heapFile.WritePage(frame.page)
+}
+
 // prefetchWorker handles asynchronous page prefetching.
 func (bp *BufferPool) prefetchWorker() {
 	bp.prefetchActive.Store(true)
@@ -384,7 +406,7 @@ func (bp *BufferPool) getFrame() (*Frame, error) {
 
 	frame := victimElement.Value.(*Frame)
 	if frame.dirty {
-		if err := bp.heapFile.WritePage(frame.page); err != nil {
+		if err := bp.writePageLocked(frame); err != nil {
 			return nil, err
 		}
 	}
@@ -452,7 +474,7 @@ func (bp *BufferPool) FlushPage(pageID PageID) error {
 		return nil
 	}
 	if frame.dirty {
-		if err := bp.heapFile.WritePage(frame.page); err != nil {
+		if err := bp.writePageLocked(frame); err != nil {
 			return err
 		}
 		frame.dirty = false
@@ -465,8 +487,16 @@ func (bp *BufferPool) FlushAllPages() error {
 	bp.mu.Lock()
 	defer bp.mu.Unlock()
 
+	hooked := false
 	for _, frame := range bp.pageTable {
 		if frame.dirty {
+			// One run of the hook covers every page of the flush
+			if !hooked && bp.writeHook != nil {
+				if err := bp.writeHook(); err != nil {
+					return err
+				}
+				hooked = true
+			}
 			if err := bp.heapFile.WritePage(frame.page); err != nil {
 				return err
 			}
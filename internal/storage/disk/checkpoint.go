@@ -41,9 +41,11 @@ Checkpoint Process:
 
  3. Sync the heap file (fsync) to ensure durability
 
- 4. Write checkpoint marker file with timestamp
+ 4. Flush and sync the key directory, marking it clean
 
- 5. Update checkpoint statistics
+ 5. Write checkpoint marker file with timestamp
+
+ 6. Update checkpoint statistics
 
     ┌─────────────────────────────────────────────────────────────┐
     │                    Before Checkpoint                        │
@@ -121,6 +123,7 @@ import (
 // A checkpoint flushes all dirty pages to disk and records the current state.
 type CheckpointManager struct {
 	bufferPool      *BufferPool
+	flush           func() error
 	checkpointDir   string
 	interval        time.Duration
 	mu              sync.Mutex
@@ -155,6 +158,15 @@ func NewCheckpointManager(bp *BufferPool, config CheckpointConfig) (*CheckpointM
 	return cm, nil
 }
 
+// SetFlushFunc replaces the default page flush with fn.
+// The disk engine uses this to flush the heap and the key directory together
+// while holding its write lock.
+func (cm *CheckpointManager) SetFlushFunc(fn func() error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.flush = fn
+}
+
 // Start begins the background checkpoint process.
 func (cm *CheckpointManager) Start() {
 	if cm.interval <= 0 {
@@ -194,14 +206,20 @@ func (cm *CheckpointManager) Checkpoint() error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
-	// Flush all dirty pages
-	if err := cm.bufferPool.FlushAllPages(); err != nil {
-		return err
-	}
+	if cm.flush != nil {
+		if err := cm.flush(); err != nil {
+			return err
+		}
+	} else {
+		// Flush all dirty pages
+		if err := cm.bufferPool.FlushAllPages(); err != nil {
+			return err
+		}
 
-	// Sync the heap file
-	if err := cm.bufferPool.HeapFile().Sync(); err != nil {
-		return err
+		// Sync the heap file
+		if err := cm.bufferPool.HeapFile().Sync(); err != nil {
+			return err
+		}
 	}
 
 	// Write checkpoint marker file
@@ -28,8 +28,8 @@ Architecture Overview:
 	┌──────────────────────────────────────────────────────────────────┐
 	│                    DiskStorageEngine                             │
 	│  ┌─────────────────────────────────────────────────────────────┐ │
-	│  │                    Key Index (in-memory)                    │ │
-	│  │  HashMap: key → RecordLocation{PageID, SlotID}              │ │
+	│  │                    Key Directory (keydir.db)                │ │
+	│  │  B+tree: key → RecordLocation{PageID, SlotID}               │ │
 	│  └─────────────────────────────────────────────────────────────┘ │
 	│                              │                                   │
 	│                              ▼                                   │
@@ -53,11 +53,13 @@ Architecture Overview:
 Key Components:
 ===============
 
-1. Key Index (In-Memory):
-  - Hash map from key to RecordLocation{PageID, SlotID}
-  - Provides O(1) key lookups
-  - Rebuilt from disk on startup
-  - Trade-off: Uses memory but enables fast lookups
+1. Key Directory (On-Disk):
+  - B+tree from key to RecordLocation{PageID, SlotID}
+  - Provides O(log N) key lookups and ordered prefix scans
+  - Checkpointed together with the heap, so startup only reads its meta page
+  - Rolled back to the last checkpoint after a crash and brought forward by
+    replaying the WAL tail; rebuilt from the heap only if that is impossible
+  - See key_directory.go for details
 
 2. Buffer Pool:
   - Caches frequently accessed pages in memory
@@ -83,7 +85,7 @@ Records are stored in a simple format:
 	│ Key Length (4 bytes) │ Key (variable) │ Value (variable) │
 	└──────────────────────────────────────────────────────────┘
 
-This format allows efficient key extraction during index rebuilding.
+This format allows efficient key extraction during key directory rebuilds.
 
 Write Path:
 ===========
@@ -93,14 +95,14 @@ Write Path:
  3. If key exists, delete old record
  4. Find page with space or allocate new page
  5. Insert record into page
- 6. Update in-memory key index
+ 6. Update key directory
  7. Release lock
 
 Read Path:
 ==========
 
  1. Acquire read lock
- 2. Look up key in in-memory index → RecordLocation
+ 2. Look up key in key directory → RecordLocation
  3. Fetch page from buffer pool (may hit cache or disk)
  4. Read record from page using slot ID
  5. Decode and return value
@@ -111,7 +113,7 @@ Scan Optimization:
 
 The Scan operation uses several optimizations:
 
-1. Prefix Filtering: Only fetch records matching the prefix
+1. Prefix Filtering: Only visit directory leaves covering the prefix
 2. Page Sorting: Access pages in sequential order for better I/O
 3. Prefetching: Load upcoming pages asynchronously
 
@@ -128,6 +130,8 @@ Thread Safety:
 All operations are protected by a read-write mutex:
   - Read operations (Get, Scan) use RLock for concurrency
   - Write operations (Put, Delete) use Lock for exclusivity
+  - Checkpoints take the write lock so the heap and key directory are
+    flushed at the same point in the write stream
 
 References:
 ===========
@@ -136,17 +140,18 @@ References:
   - See heap_file.go for page storage
   - See page.go for slotted page format
   - See checkpoint.go for checkpoint management
+  - See key_directory.go for the persistent key directory
   - See internal/storage/wal.go for write-ahead logging
 */
 package disk
 
 import (
+	"context"
 	"encoding/binary"
 	"errors"
 	"os"
 	"path/filepath"
 	"sort"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -163,12 +168,69 @@ type WALInterface interface {
 	Replay(fromOffset int64, callback func(op byte, key string, value []byte)) error
 }
 
-// WAL operation types (must match storage.OpPut and storage.OpDelete)
+// WAL operation types (must match storage.OpPut, storage.OpDelete and
+// storage.OpBatch)
 const (
 	OpPut    byte = 1
 	OpDelete byte = 2
+	OpBatch  byte = 3
 )
 
+// ErrInvalidBatch is returned for a write batch that cannot be applied or
+// decoded.
+var ErrInvalidBatch = errors.New("invalid write batch")
+
+// BatchWrite is one write of a batch applied with WriteBatch.
+type BatchWrite struct {
+	Op    byte // OpPut or OpDelete
+	Key   string
+	Value []byte
+}
+
+// encodeBatch encodes writes as the value of an OpBatch WAL record. Each
+// write is encoded like a record: [op][key length][key][value length][value].
+func encodeBatch(writes []BatchWrite) []byte {
+	size := 0
+	for _, w := range writes {
+		size += 1 + 4 + len(w.Key) + 4 + len(w.Value)
+	}
+	buf := make([]byte, 0, size)
+	for _, w := range writes {
+		buf = append(buf, w.Op)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(w.Key)))
+		buf = append(buf, w.Key...)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(w.Value)))
+		buf = append(buf, w.Value...)
+	}
+	return buf
+}
+
+// decodeBatch decodes the value of an OpBatch WAL record.
+func decodeBatch(data []byte) ([]BatchWrite, error) {
+	var writes []BatchWrite
+	for len(data) > 0 {
+		if len(data) < 5 {
+			return nil, ErrInvalidBatch
+		}
+		w := BatchWrite{Op: data[0]}
+		keyLen := int(binary.BigEndian.Uint32(data[1:5]))
+		data = data[5:]
+		if len(data) < keyLen+4 {
+			return nil, ErrInvalidBatch
+		}
+		w.Key = string(data[:keyLen])
+		valLen := int(binary.BigEndian.Uint32(data[keyLen : keyLen+4]))
+		data = data[keyLen+4:]
+		if len(data) < valLen {
+			return nil, ErrInvalidBatch
+		}
+		w.Value = data[:valLen:valLen]
+		data = data[valLen:]
+		writes = append(writes, w)
+	}
+	return writes, nil
+}
+
 // DiskStorageEngine implements the storage.Engine interface using disk-based storage.
 // It uses a heap file for data storage, a buffer pool for caching, and WAL for durability.
 type DiskStorageEngine struct {
@@ -177,10 +239,13 @@ type DiskStorageEngine struct {
 	checkpoint *CheckpointManager
 	wal        WALInterface
 	mu         sync.RWMutex
-	keyIndex   map[string]RecordLocation // In-memory index: key -> location
+	keyDir     *KeyDirectory // Persistent index: key -> location
 	closed     bool
 	encrypted  bool
 
+	stopCheckpoint sync.Once
+	recoveryOffset int64 // WAL offset covered by the last clean checkpoint
+
 	// Statistics
 	keyCount atomic.Int64
 	dataSize atomic.Int64
@@ -241,12 +306,13 @@ func NewDiskStorageEngine(config DiskEngineConfig) (*DiskStorageEngine, error) {
 		dataDir:    config.DataDir,
 		bufferPool: bufferPool,
 		wal:        config.WAL,
-		keyIndex:   make(map[string]RecordLocation),
 		encrypted:  config.Encrypted,
 	}
+	bufferPool.SetWriteHook(engine.syncWAL)
 
-	// Load index from disk
-	if err := engine.loadIndex(); err != nil {
+	// Open the key directory, rebuilding it only if it was not checkpointed
+	// against the current heap
+	if err := engine.openKeyDirectory(keyDirPoolSize(poolSize)); err != nil {
 		bufferPool.Close()
 		return nil, err
 	}
@@ -260,17 +326,71 @@ func NewDiskStorageEngine(config DiskEngineConfig) (*DiskStorageEngine, error) {
 		var err error
 		engine.checkpoint, err = NewCheckpointManager(bufferPool, checkpointConfig)
 		if err != nil {
+			engine.keyDir.Close()
 			bufferPool.Close()
 			return nil, err
 		}
+		engine.checkpoint.SetFlushFunc(engine.Checkpoint)
 		engine.checkpoint.Start()
 	}
 
 	return engine, nil
 }
 
-// loadIndex scans all pages to rebuild the in-memory key index.
-func (e *DiskStorageEngine) loadIndex() error {
+// keyDirPoolSize sizes the key directory's buffer pool relative to the heap's.
+// Directory entries are small, so a fraction of the heap pool covers the
+// upper levels of the tree and the hot leaves.
+func keyDirPoolSize(heapPoolSize int) int {
+	size := heapPoolSize / 4
+	if size < 64 {
+		size = 64
+	}
+	return size
+}
+
+// openKeyDirectory opens keydir.db. A directory left dirty by a crash is
+// rolled back to its last checkpoint, and the WAL tail replayed on startup
+// brings it forward; it is rebuilt from the heap only if it cannot be.
+func (e *DiskStorageEngine) openKeyDirectory(poolSize int) error {
+	path := filepath.Join(e.dataDir, "keydir.db")
+	keyDir, err := OpenKeyDirectory(path, poolSize)
+	if err != nil {
+		return err
+	}
+
+	heapPages := e.bufferPool.HeapFile().PageCount()
+	usable := keyDir.IsConsistentWith(heapPages)
+	if !usable {
+		if usable, err = keyDir.Recover(heapPages); err != nil {
+			keyDir.Close()
+			return err
+		}
+	}
+	if usable {
+		e.keyDir = keyDir
+		e.recoveryOffset = keyDir.WALOffset()
+		e.keyCount.Store(keyDir.KeyCount())
+		e.dataSize.Store(keyDir.DataSize())
+		return nil
+	}
+
+	// Start from an empty directory file rather than patching a stale one
+	keyDir.Close()
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	if e.keyDir, err = OpenKeyDirectory(path, poolSize); err != nil {
+		return err
+	}
+	if err := e.rebuildKeyDirectory(); err != nil {
+		e.keyDir.Close()
+		return err
+	}
+	return e.checkpointLocked()
+}
+
+// rebuildKeyDirectory scans all heap pages to repopulate the key directory.
+func (e *DiskStorageEngine) rebuildKeyDirectory() error {
 	pageCount := e.bufferPool.HeapFile().PageCount()
 	var totalSize int64
 
@@ -299,18 +419,79 @@ func (e *DiskStorageEngine) loadIndex() error {
 			}
 			key := e.extractKeyFromRecord(record)
 			if key != "" {
-				e.keyIndex[key] = RecordLocation{PageID: pageID, SlotID: slotID}
+				if _, err := e.keyDir.Put(key, RecordLocation{PageID: pageID, SlotID: slotID}); err != nil {
+					e.bufferPool.UnpinPage(pageID, false)
+					return err
+				}
 				totalSize += int64(len(record))
 			}
 		}
 		e.bufferPool.UnpinPage(pageID, false)
 	}
 
-	e.keyCount.Store(int64(len(e.keyIndex)))
+	e.keyDir.AddDataSize(totalSize)
+	e.keyCount.Store(e.keyDir.KeyCount())
 	e.dataSize.Store(totalSize)
 	return nil
 }
 
+// Checkpoint flushes the heap and the key directory at a single point in the
+// write stream, so the next open can use the directory without a rebuild.
+func (e *DiskStorageEngine) Checkpoint() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return errors.New("engine is closed")
+	}
+	return e.checkpointLocked()
+}
+
+func (e *DiskStorageEngine) checkpointLocked() error {
+	// Log records must be on disk before the pages they describe
+	if e.wal != nil {
+		if err := e.wal.Sync(); err != nil {
+			return err
+		}
+	}
+	if err := e.bufferPool.FlushAllPages(); err != nil {
+		return err
+	}
+	heapFile := e.bufferPool.HeapFile()
+	if err := heapFile.Sync(); err != nil {
+		return err
+	}
+
+	// Every WAL record written so far is reflected in the flushed heap
+	var walOffset int64
+	if e.wal != nil {
+		size, err := e.wal.Size()
+		if err != nil {
+			return err
+		}
+		walOffset = size
+	}
+	return e.keyDir.Checkpoint(heapFile.PageCount(), walOffset)
+}
+
+// syncWAL makes the WAL durable before the buffer pool writes back a heap
+// page, so that no page reaches disk ahead of the log records describing it.
+// Recovery relies on this: a checkpointed record is only ever deleted by a
+// write that the WAL tail will replay.
+func (e *DiskStorageEngine) syncWAL() error {
+	if e.wal == nil {
+		return nil
+	}
+	return e.wal.Sync()
+}
+
+// RecoveryOffset returns the WAL offset from which records must be replayed
+// on startup. It is the WAL size at the last checkpoint, or 0 when the key
+// directory had to be rebuilt and the whole WAL must be replayed.
+func (e *DiskStorageEngine) RecoveryOffset() int64 {
+	return e.recoveryOffset
+}
+
 func (e *DiskStorageEngine) extractKeyFromRecord(record []byte) string {
 	if len(record) < 4 {
 		return ""
@@ -355,6 +536,10 @@ func (e *DiskStorageEngine) Put(key string, value []byte) error {
 		return errors.New("engine is closed")
 	}
 
+	if len(key) > MaxKeyDirectoryKeySize {
+		return ErrKeyTooLarge
+	}
+
 	// Write to WAL first for durability
 	if e.wal != nil {
 		if err := e.wal.Write(OpPut, key, value); err != nil {
@@ -362,19 +547,25 @@ func (e *DiskStorageEngine) Put(key string, value []byte) error {
 		}
 	}
 
+	return e.putLocked(key, value)
+}
+
+// putLocked writes the record to the heap and points the key directory at it.
+// The caller must hold the write lock.
+func (e *DiskStorageEngine) putLocked(key string, value []byte) error {
 	record := encodeRecord(key, value)
-	isNew := true
 
-	// If key exists, try to update in place or delete old record
-	if loc, exists := e.keyIndex[key]; exists {
-		isNew = false
-		// Delete old record first
-		page, err := e.bufferPool.FetchPage(loc.PageID)
+	// If key exists, delete the old record first
+	oldLoc, exists, err := e.keyDir.Get(key)
+	if err != nil {
+		return err
+	}
+	if exists {
+		page, err := e.bufferPool.FetchPage(oldLoc.PageID)
 		if err == nil {
-			page.DeleteRecord(loc.SlotID)
-			e.bufferPool.UnpinPage(loc.PageID, true)
+			page.DeleteRecord(oldLoc.SlotID)
+			e.bufferPool.UnpinPage(oldLoc.PageID, true)
 		}
-		delete(e.keyIndex, key)
 	}
 
 	// Find a page with enough space or allocate new one
@@ -383,17 +574,26 @@ func (e *DiskStorageEngine) Put(key string, value []byte) error {
 		return err
 	}
 
-	e.keyIndex[key] = RecordLocation{PageID: pageID, SlotID: slotID}
+	isNew, err := e.keyDir.Put(key, RecordLocation{PageID: pageID, SlotID: slotID})
+	if err != nil {
+		return err
+	}
 
 	// Update statistics
 	if isNew {
 		e.keyCount.Add(1)
 	}
-	e.dataSize.Add(int64(len(record)))
+	e.addDataSize(int64(len(record)))
 
 	return nil
 }
 
+// addDataSize updates the data size statistic and its persisted copy.
+func (e *DiskStorageEngine) addDataSize(delta int64) {
+	e.dataSize.Add(delta)
+	e.keyDir.AddDataSize(delta)
+}
+
 func (e *DiskStorageEngine) insertRecord(record []byte) (PageID, uint16, error) {
 	// Try to find existing page with space
 	pageCount := e.bufferPool.HeapFile().PageCount()
@@ -443,7 +643,10 @@ func (e *DiskStorageEngine) Get(key string) ([]byte, error) {
 		return nil, errors.New("engine is closed")
 	}
 
-	loc, exists := e.keyIndex[key]
+	loc, exists, err := e.keyDir.Get(key)
+	if err != nil {
+		return nil, err
+	}
 	if !exists {
 		return nil, ErrPageNotFound // Will be mapped to storage.ErrNotFound
 	}
@@ -459,8 +662,14 @@ func (e *DiskStorageEngine) Get(key string) ([]byte, error) {
 		return nil, ErrPageNotFound
 	}
 
-	_, value, err := decodeRecord(record)
-	return value, err
+	recordKey, value, err := decodeRecord(record)
+	if err != nil {
+		return nil, err
+	}
+	if recordKey != key {
+		return nil, ErrPageNotFound
+	}
+	return value, nil
 }
 
 // Delete removes a key and its associated value.
@@ -472,7 +681,10 @@ func (e *DiskStorageEngine) Delete(key string) error {
 		return errors.New("engine is closed")
 	}
 
-	loc, exists := e.keyIndex[key]
+	loc, exists, err := e.keyDir.Get(key)
+	if err != nil {
+		return err
+	}
 	if !exists {
 		return nil // Idempotent delete
 	}
@@ -491,7 +703,9 @@ func (e *DiskStorageEngine) Delete(key string) error {
 
 	page.DeleteRecord(loc.SlotID)
 	e.bufferPool.UnpinPage(loc.PageID, true)
-	delete(e.keyIndex, key)
+	if _, err := e.keyDir.Delete(key); err != nil {
+		return err
+	}
 
 	// Update statistics
 	e.keyCount.Add(-1)
@@ -513,59 +727,102 @@ func (e *DiskStorageEngine) ApplyReplicatedPut(key string, value []byte) error {
 		return errors.New("engine is closed")
 	}
 
+	if len(key) > MaxKeyDirectoryKeySize {
+		return ErrKeyTooLarge
+	}
+
 	// DO NOT write to WAL - this is a replicated operation
 	// The caller (follower) should have already written to local WAL
 
-	record := encodeRecord(key, value)
-	isNew := true
+	return e.putLocked(key, value)
+}
 
-	// If key exists, try to update in place or delete old record
-	if loc, exists := e.keyIndex[key]; exists {
-		isNew = false
-		// Delete old record first
-		page, err := e.bufferPool.FetchPage(loc.PageID)
-		if err == nil {
-			page.DeleteRecord(loc.SlotID)
-			e.bufferPool.UnpinPage(loc.PageID, true)
-		}
-		delete(e.keyIndex, key)
-	}
+// ApplyReplicatedDelete applies a replicated DELETE operation without writing to WAL.
+// This is used by followers in cluster mode to apply changes received from the leader.
+// The WAL entry has already been written by WriteReplicatedWAL.
+func (e *DiskStorageEngine) ApplyReplicatedDelete(key string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
-	// Find a page with enough space or allocate new one
-	pageID, slotID, err := e.insertRecord(record)
-	if err != nil {
-		return err
+	if e.closed {
+		return errors.New("engine is closed")
 	}
 
-	e.keyIndex[key] = RecordLocation{PageID: pageID, SlotID: slotID}
+	// DO NOT write to WAL - this is a replicated operation
+	return e.deleteLocked(key)
+}
 
-	// Update statistics
-	if isNew {
-		e.keyCount.Add(1)
+// WriteBatch logs writes as a single OpBatch WAL record and then applies
+// them, so crash recovery replays either all of the writes or none.
+func (e *DiskStorageEngine) WriteBatch(writes []BatchWrite) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return errors.New("engine is closed")
+	}
+	for _, w := range writes {
+		if w.Op != OpPut && w.Op != OpDelete {
+			return ErrInvalidBatch
+		}
+		if len(w.Key) > MaxKeyDirectoryKeySize {
+			return ErrKeyTooLarge
+		}
 	}
-	e.dataSize.Add(int64(len(record)))
 
-	return nil
+	if e.wal != nil {
+		if err := e.wal.Write(OpBatch, "", encodeBatch(writes)); err != nil {
+			return err
+		}
+	}
+	return e.applyBatchLocked(writes)
 }
 
-// ApplyReplicatedDelete applies a replicated DELETE operation without writing to WAL.
-// This is used by followers in cluster mode to apply changes received from the leader.
-// The WAL entry has already been written by WriteReplicatedWAL.
-func (e *DiskStorageEngine) ApplyReplicatedDelete(key string) error {
+// ApplyReplicatedBatch applies the value of an OpBatch WAL record without
+// writing to the WAL. It is used to replay the WAL.
+func (e *DiskStorageEngine) ApplyReplicatedBatch(data []byte) error {
+	writes, err := decodeBatch(data)
+	if err != nil {
+		return err
+	}
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	if e.closed {
 		return errors.New("engine is closed")
 	}
+	return e.applyBatchLocked(writes)
+}
+
+// applyBatchLocked applies the writes of a batch in order.
+// The caller must hold the write lock.
+func (e *DiskStorageEngine) applyBatchLocked(writes []BatchWrite) error {
+	for _, w := range writes {
+		var err error
+		if w.Op == OpPut {
+			err = e.putLocked(w.Key, w.Value)
+		} else {
+			err = e.deleteLocked(w.Key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	loc, exists := e.keyIndex[key]
+// deleteLocked removes the record of a key, if it exists, from the heap and
+// the key directory. The caller must hold the write lock.
+func (e *DiskStorageEngine) deleteLocked(key string) error {
+	loc, exists, err := e.keyDir.Get(key)
+	if err != nil {
+		return err
+	}
 	if !exists {
 		return nil // Idempotent delete
 	}
 
-	// DO NOT write to WAL - this is a replicated operation
-
 	page, err := e.bufferPool.FetchPage(loc.PageID)
 	if err != nil {
 		return err
@@ -573,7 +830,9 @@ func (e *DiskStorageEngine) ApplyReplicatedDelete(key string) error {
 
 	page.DeleteRecord(loc.SlotID)
 	e.bufferPool.UnpinPage(loc.PageID, true)
-	delete(e.keyIndex, key)
+	if _, err := e.keyDir.Delete(key); err != nil {
+		return err
+	}
 
 	// Update statistics
 	e.keyCount.Add(-1)
@@ -594,6 +853,30 @@ func (e *DiskStorageEngine) WriteReplicatedWAL(op byte, key string, value []byte
 // Scan returns all key-value pairs matching the given prefix.
 // Uses prefetching to improve performance for sequential access patterns.
 func (e *DiskStorageEngine) Scan(prefix string) (map[string][]byte, error) {
+	return e.ScanContext(context.Background(), prefix)
+}
+
+// ScanKeys calls fn with each key matching prefix that is not less than
+// start, in key order, until fn returns false. Values are not read. fn must
+// not call back into the engine.
+func (e *DiskStorageEngine) ScanKeys(prefix, start string, fn func(key string) bool) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closed {
+		return errors.New("engine is closed")
+	}
+	return e.keyDir.ScanPrefixFrom(prefix, start, func(key string, _ RecordLocation) bool {
+		return fn(key)
+	})
+}
+
+// scanCheckInterval is the number of keys scanned between checks of the
+// scan's context.
+const scanCheckInterval = 256
+
+// ScanContext is like Scan but returns ctx.Err() as soon as ctx is done.
+func (e *DiskStorageEngine) ScanContext(ctx context.Context, prefix string) (map[string][]byte, error) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
@@ -609,10 +892,18 @@ func (e *DiskStorageEngine) Scan(prefix string) (map[string][]byte, error) {
 		loc RecordLocation
 	}
 	var matchingKeys []keyLoc
-	for key, loc := range e.keyIndex {
-		if strings.HasPrefix(key, prefix) {
-			matchingKeys = append(matchingKeys, keyLoc{key, loc})
+	err := e.keyDir.ScanPrefix(prefix, func(key string, loc RecordLocation) bool {
+		if len(matchingKeys)%scanCheckInterval == 0 && ctx.Err() != nil {
+			return false
 		}
+		matchingKeys = append(matchingKeys, keyLoc{key, loc})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	// Sort by page ID for sequential access, then by key
@@ -631,6 +922,12 @@ func (e *DiskStorageEngine) Scan(prefix string) (map[string][]byte, error) {
 
 	// Fetch values for matching keys
 	for i, kl := range matchingKeys {
+		if i%scanCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
 		// Prefetch next pages as we go
 		if i+prefetchAhead < len(matchingKeys) {
 			e.bufferPool.Prefetch(matchingKeys[i+prefetchAhead].loc.PageID)
@@ -643,8 +940,8 @@ func (e *DiskStorageEngine) Scan(prefix string) (map[string][]byte, error) {
 
 		record, err := page.GetRecord(kl.loc.SlotID)
 		if err == nil {
-			_, value, decErr := decodeRecord(record)
-			if decErr == nil {
+			recordKey, value, decErr := decodeRecord(record)
+			if decErr == nil && recordKey == kl.key {
 				result[kl.key] = value
 			}
 		}
@@ -655,7 +952,16 @@ func (e *DiskStorageEngine) Scan(prefix string) (map[string][]byte, error) {
 }
 
 // Close shuts down the storage engine.
+// A final checkpoint marks the key directory clean so the next open does not
+// need to rebuild it.
 func (e *DiskStorageEngine) Close() error {
+	// The checkpoint loop takes the engine lock, so stop it first
+	e.stopCheckpoint.Do(func() {
+		if e.checkpoint != nil {
+			e.checkpoint.Stop()
+		}
+	})
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -664,16 +970,19 @@ func (e *DiskStorageEngine) Close() error {
 	}
 	e.closed = true
 
-	if e.checkpoint != nil {
-		e.checkpoint.Stop()
-	}
+	checkpointErr := e.checkpointLocked()
 
 	// Close WAL if we own it
 	if e.wal != nil {
 		e.wal.Close()
 	}
-
-	return e.bufferPool.Close()
+	if err := e.keyDir.Close(); err != nil && checkpointErr == nil {
+		checkpointErr = err
+	}
+	if err := e.bufferPool.Close(); err != nil {
+		return err
+	}
+	return checkpointErr
 }
 
 // Sync forces all pending writes to be persisted to durable storage.
@@ -701,6 +1010,11 @@ func (e *DiskStorageEngine) BufferPool() *BufferPool {
 	return e.bufferPool
 }
 
+// KeyDirectory returns the persistent key directory.
+func (e *DiskStorageEngine) KeyDirectory() *KeyDirectory {
+	return e.keyDir
+}
+
 // KeyCount returns the number of keys in the store.
 func (e *DiskStorageEngine) KeyCount() int64 {
 	return e.keyCount.Load()
@@ -250,6 +250,18 @@ func (hf *HeapFile) FreePage(pageID PageID) error {
 	return hf.writeHeader()
 }
 
+// Truncate drops every page after the first pageCount pages. It is used to
+// discard pages allocated after a checkpoint; the free list is not adjusted.
+func (hf *HeapFile) Truncate(pageCount uint32) error {
+	hf.mu.Lock()
+	defer hf.mu.Unlock()
+	if err := hf.file.Truncate(hf.pageOffset(PageID(pageCount + 1))); err != nil {
+		return err
+	}
+	hf.pageCount = pageCount
+	return hf.writeHeader()
+}
+
 func (hf *HeapFile) readPageLocked(pageID PageID) (*Page, error) {
 	if pageID == InvalidPageID || uint32(pageID) > hf.pageCount {
 		return nil, ErrPageNotFound
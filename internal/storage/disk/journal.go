@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package disk
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+)
+
+// journalEntrySize is the size of one journal entry:
+// [pageID:4][crc32:4][page:PageSize].
+const journalEntrySize = 8 + PageSize
+
+// pageJournal is a rollback journal for a page file. Before a page that
+// existed at the last checkpoint is modified for the first time, its
+// checkpointed image is appended to the journal, and the journal is synced
+// before any modified page is written back. After a crash, restoring the
+// journaled images returns the file to its checkpointed state.
+type pageJournal struct {
+	mu      sync.Mutex
+	file    *os.File
+	limit   PageID          // Pages above limit did not exist at the checkpoint
+	saved   map[PageID]bool // Pages journaled since the checkpoint
+	pending bool            // Entries appended since the last sync
+}
+
+// openPageJournal opens the journal at path, creating it if needed. Its
+// contents are kept until restore or reset.
+func openPageJournal(path string) (*pageJournal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &pageJournal{file: file, saved: make(map[PageID]bool)}, nil
+}
+
+// needs reports whether page id must be journaled before it is modified.
+func (j *pageJournal) needs(id PageID) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return id <= j.limit && !j.saved[id]
+}
+
+// save appends the checkpointed image of a page. It does not sync; sync
+// must be called before the modified page is written to the file.
+func (j *pageJournal) save(page *Page) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	id := page.PageID()
+	if id > j.limit || j.saved[id] {
+		return nil
+	}
+	entry := make([]byte, journalEntrySize)
+	binary.BigEndian.PutUint32(entry[0:4], uint32(id))
+	copy(entry[8:], page.Data())
+	binary.BigEndian.PutUint32(entry[4:8], crc32.ChecksumIEEE(entry[8:]))
+	if _, err := j.file.WriteAt(entry, int64(len(j.saved))*journalEntrySize); err != nil {
+		return err
+	}
+	j.saved[id] = true
+	j.pending = true
+	return nil
+}
+
+// sync makes the saved images durable. It is the buffer pool's write hook.
+func (j *pageJournal) sync() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.pending {
+		return nil
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+	j.pending = false
+	return nil
+}
+
+// restore writes every complete journaled image back to hf. An entry torn
+// by a crash was never synced, so the page it describes was never written
+// back and needs no restoring.
+func (j *pageJournal) restore(hf *HeapFile) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry := make([]byte, journalEntrySize)
+	for off := int64(0); ; off += journalEntrySize {
+		if _, err := j.file.ReadAt(entry, off); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+		if crc32.ChecksumIEEE(entry[8:]) != binary.BigEndian.Uint32(entry[4:8]) {
+			return nil
+		}
+		page := &Page{}
+		page.SetData(append([]byte(nil), entry[8:]...))
+		if page.PageID() != PageID(binary.BigEndian.Uint32(entry[0:4])) {
+			return ErrKeyDirectoryFormat
+		}
+		if err := hf.WritePage(page); err != nil {
+			return err
+		}
+	}
+}
+
+// reset empties the journal after a checkpoint. limit is the page count of
+// the checkpointed file.
+func (j *pageJournal) reset(limit PageID) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.file.Truncate(0); err != nil {
+		return err
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+	j.limit = limit
+	j.saved = make(map[PageID]bool)
+	j.pending = false
+	return nil
+}
+
+func (j *pageJournal) close() error {
+	return j.file.Close()
+}
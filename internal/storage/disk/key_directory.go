@@ -0,0 +1,743 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Persistent Key Directory
+========================
+
+The key directory maps every primary key to the RecordLocation of its record
+in the heap file. Earlier versions kept this mapping in a Go map that was
+rebuilt by reading every page of data.db on startup, so open time and memory
+grew linearly with the size of the database.
+
+The key directory is now an on-disk B+tree stored in its own page file
+(keydir.db) and cached through a dedicated buffer pool. Only the pages that
+are actually touched are kept in memory, and opening a database only reads
+the directory's meta page.
+
+File Layout:
+============
+
+	┌─────────────────────────────────────────────────────────────┐
+	│              File Header (shared HeapFile format)           │
+	├─────────────────────────────────────────────────────────────┤
+	│  Page 1: Meta page                                          │
+	│  [Magic] [Version] [State] [Root] [Keys] [Bytes] [Heap]     │
+	│  [WAL offset] [Directory pages]                             │
+	├─────────────────────────────────────────────────────────────┤
+	│  Page 2..N: B+tree nodes (PageTypeIndex)                    │
+	└─────────────────────────────────────────────────────────────┘
+
+Node Format:
+============
+
+Nodes reuse the standard 24-byte page header. SlotCount holds the number of
+keys, Flags marks leaf nodes and NextPageID links leaves left to right so that
+prefix scans can walk the leaf level in key order.
+
+	Leaf:      [keyLen:2][key][pageID:4][slotID:2] ...
+	Internal:  [child0:4] [keyLen:2][key][child:4] ...
+
+Keys are limited to MaxKeyDirectoryKeySize bytes so that a node split always
+produces two nodes that fit in a page. Deletes remove the entry from its leaf
+without rebalancing; empty leaves stay linked and are reused by later inserts
+into the same key range.
+
+Checkpointing:
+==============
+
+The directory is checkpointed together with the heap. A checkpoint flushes the
+heap, then the directory pages, and finally writes the meta page with the
+state set to "clean". The first modification after a checkpoint durably flips
+the state back to "dirty" before any directory page can reach disk.
+
+The meta page also records the WAL size at the checkpoint. Every WAL record
+before that offset is already reflected in the flushed heap, so startup
+recovery only replays the tail of the WAL.
+
+Crash Recovery:
+===============
+
+A dirty directory means the process stopped between checkpoints, so some
+directory pages may have reached disk and others not. Before a page that
+existed at the checkpoint is modified for the first time, its checkpointed
+image is appended to a rollback journal (keydir.db-journal), and the journal
+is synced before any directory page is written back.
+
+On open, a dirty directory is returned to its checkpointed state by restoring
+the journaled images and dropping pages allocated since the checkpoint. The
+engine then replays the WAL from the checkpoint's offset, which brings the
+directory and the heap forward together. Heap records are never moved once
+written, so the checkpointed locations stay valid.
+
+The directory is rebuilt from the heap only when it cannot be rolled back:
+when it was never checkpointed, or when the heap no longer matches it.
+*/
+package disk
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Key directory constants
+const (
+	KeyDirectoryMagic   uint32 = 0x464B4559 // "FKEY"
+	KeyDirectoryVersion uint32 = 1
+
+	// MaxKeyDirectoryKeySize is the largest key the directory accepts.
+	MaxKeyDirectoryKeySize = 2048
+
+	keyDirMetaPageID PageID = 1
+
+	keyDirStateDirty byte = 0
+	keyDirStateClean byte = 1
+
+	nodeFlagLeaf byte = 0x01
+
+	nodeCapacity      = PageSize - PageHeaderSize
+	leafEntryTail     = 6 // pageID (4) + slotID (2)
+	internalEntryTail = 4 // child pageID (4)
+)
+
+// Errors
+var (
+	ErrKeyTooLarge        = errors.New("key too large for key directory")
+	ErrKeyDirectoryFormat = errors.New("invalid key directory file")
+)
+
+// keyDirMeta is the in-memory copy of the directory's meta page.
+type keyDirMeta struct {
+	state     byte
+	root      PageID
+	keyCount  int64
+	dataSize  int64
+	heapPages uint32
+	walOffset int64
+	dirPages  uint32 // Directory page count at the checkpoint; 0 if never checkpointed
+}
+
+// keyDirNode is a decoded B+tree node.
+type keyDirNode struct {
+	id       PageID
+	leaf     bool
+	next     PageID
+	keys     []string
+	locs     []RecordLocation // Leaf only
+	children []PageID         // Internal only, len(keys)+1
+}
+
+// KeyDirectory is a persistent, ordered map from keys to record locations.
+type KeyDirectory struct {
+	path       string
+	heapFile   *HeapFile
+	bufferPool *BufferPool
+	mu         sync.RWMutex
+	meta       keyDirMeta
+	wasClean   bool
+	journal    *pageJournal
+}
+
+// OpenKeyDirectory opens the key directory at path, creating it if needed.
+// poolSize is the number of directory pages cached in memory.
+func OpenKeyDirectory(path string, poolSize int) (*KeyDirectory, error) {
+	var hf *HeapFile
+	var err error
+	created := false
+
+	if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+		hf, err = CreateHeapFile(path)
+		created = true
+	} else {
+		hf, err = OpenHeapFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	kd := &KeyDirectory{path: path, heapFile: hf}
+
+	if created {
+		err = kd.initialize()
+	} else {
+		err = kd.readMeta()
+	}
+	if err == nil {
+		kd.journal, err = openPageJournal(path + "-journal")
+	}
+	if err != nil {
+		hf.Close()
+		return nil, err
+	}
+
+	// A dirty directory keeps its journal until Recover has used it
+	kd.wasClean = kd.meta.state == keyDirStateClean
+	if created || kd.wasClean {
+		if err := kd.journal.reset(PageID(kd.meta.dirPages)); err != nil {
+			kd.journal.close()
+			hf.Close()
+			return nil, err
+		}
+	}
+	kd.bufferPool = NewBufferPool(hf, poolSize)
+	kd.bufferPool.SetWriteHook(kd.journal.sync)
+	return kd, nil
+}
+
+// initialize lays out an empty directory: a meta page and an empty root leaf.
+func (kd *KeyDirectory) initialize() error {
+	metaID, err := kd.heapFile.AllocatePage()
+	if err != nil {
+		return err
+	}
+	rootID, err := kd.heapFile.AllocatePage()
+	if err != nil {
+		return err
+	}
+	if metaID != keyDirMetaPageID {
+		return ErrKeyDirectoryFormat
+	}
+
+	root := &keyDirNode{id: rootID, leaf: true}
+	page := NewPage(rootID, PageTypeIndex)
+	root.encode(page)
+	if err := kd.heapFile.WritePage(page); err != nil {
+		return err
+	}
+
+	// A freshly created directory is empty, which is only correct for an
+	// empty heap, so it starts dirty and the engine decides whether to rebuild.
+	kd.meta = keyDirMeta{state: keyDirStateDirty, root: rootID}
+	if err := kd.writeMeta(); err != nil {
+		return err
+	}
+	return kd.heapFile.Sync()
+}
+
+func (kd *KeyDirectory) readMeta() error {
+	page, err := kd.heapFile.ReadPage(keyDirMetaPageID)
+	if err != nil {
+		return err
+	}
+	data := page.Data()[PageHeaderSize:]
+	if binary.BigEndian.Uint32(data[0:4]) != KeyDirectoryMagic {
+		return ErrKeyDirectoryFormat
+	}
+	if binary.BigEndian.Uint32(data[4:8]) != KeyDirectoryVersion {
+		return ErrVersionMismatch
+	}
+	kd.meta = keyDirMeta{
+		state:     data[8],
+		root:      PageID(binary.BigEndian.Uint32(data[9:13])),
+		keyCount:  int64(binary.BigEndian.Uint64(data[13:21])),
+		dataSize:  int64(binary.BigEndian.Uint64(data[21:29])),
+		heapPages: binary.BigEndian.Uint32(data[29:33]),
+		walOffset: int64(binary.BigEndian.Uint64(data[33:41])),
+		dirPages:  binary.BigEndian.Uint32(data[41:45]),
+	}
+	return nil
+}
+
+// writeMeta writes the meta page directly to the file, bypassing the buffer
+// pool so that state changes are ordered with respect to Sync calls.
+func (kd *KeyDirectory) writeMeta() error {
+	page := NewPage(keyDirMetaPageID, PageTypeMeta)
+	data := page.Data()[PageHeaderSize:]
+	binary.BigEndian.PutUint32(data[0:4], KeyDirectoryMagic)
+	binary.BigEndian.PutUint32(data[4:8], KeyDirectoryVersion)
+	data[8] = kd.meta.state
+	binary.BigEndian.PutUint32(data[9:13], uint32(kd.meta.root))
+	binary.BigEndian.PutUint64(data[13:21], uint64(kd.meta.keyCount))
+	binary.BigEndian.PutUint64(data[21:29], uint64(kd.meta.dataSize))
+	binary.BigEndian.PutUint32(data[29:33], kd.meta.heapPages)
+	binary.BigEndian.PutUint64(data[33:41], uint64(kd.meta.walOffset))
+	binary.BigEndian.PutUint32(data[41:45], kd.meta.dirPages)
+	return kd.heapFile.WritePage(page)
+}
+
+// IsConsistentWith reports whether the directory was cleanly checkpointed
+// against a heap with the given page count and can be used without a rebuild.
+func (kd *KeyDirectory) IsConsistentWith(heapPages uint32) bool {
+	kd.mu.RLock()
+	defer kd.mu.RUnlock()
+	return kd.wasClean && kd.meta.heapPages == heapPages
+}
+
+// Recover returns a directory left dirty by a crash to its state at the last
+// checkpoint, so that replaying the WAL from WALOffset brings it up to date.
+// heapPages is the current page count of the heap. It returns false if the
+// directory cannot be rolled back and must be rebuilt from the heap instead.
+func (kd *KeyDirectory) Recover(heapPages uint32) (bool, error) {
+	kd.mu.Lock()
+	defer kd.mu.Unlock()
+
+	// The heap only grows, so a smaller heap is not the one checkpointed
+	if kd.wasClean || kd.meta.dirPages == 0 || heapPages < kd.meta.heapPages {
+		return false, nil
+	}
+	if err := kd.journal.restore(kd.heapFile); err != nil {
+		return false, err
+	}
+	if err := kd.heapFile.Truncate(kd.meta.dirPages); err != nil {
+		return false, err
+	}
+	if err := kd.heapFile.Sync(); err != nil {
+		return false, err
+	}
+	return true, kd.journal.reset(PageID(kd.meta.dirPages))
+}
+
+// markDirtyLocked durably records that the directory is being modified.
+func (kd *KeyDirectory) markDirtyLocked() error {
+	if kd.meta.state == keyDirStateDirty {
+		return nil
+	}
+	kd.meta.state = keyDirStateDirty
+	if err := kd.writeMeta(); err != nil {
+		return err
+	}
+	return kd.heapFile.Sync()
+}
+
+// Get returns the location of key.
+func (kd *KeyDirectory) Get(key string) (RecordLocation, bool, error) {
+	kd.mu.RLock()
+	defer kd.mu.RUnlock()
+
+	node, err := kd.findLeaf(key, nil)
+	if err != nil {
+		return RecordLocation{}, false, err
+	}
+	i := sort.SearchStrings(node.keys, key)
+	if i < len(node.keys) && node.keys[i] == key {
+		return node.locs[i], true, nil
+	}
+	return RecordLocation{}, false, nil
+}
+
+// Put inserts or replaces the location of key.
+// It returns true if the key was not present before.
+func (kd *KeyDirectory) Put(key string, loc RecordLocation) (bool, error) {
+	if len(key) > MaxKeyDirectoryKeySize {
+		return false, ErrKeyTooLarge
+	}
+
+	kd.mu.Lock()
+	defer kd.mu.Unlock()
+
+	if err := kd.markDirtyLocked(); err != nil {
+		return false, err
+	}
+
+	var path []*keyDirNode
+	leaf, err := kd.findLeaf(key, &path)
+	if err != nil {
+		return false, err
+	}
+
+	i := sort.SearchStrings(leaf.keys, key)
+	if i < len(leaf.keys) && leaf.keys[i] == key {
+		leaf.locs[i] = loc
+		return false, kd.writeNode(leaf)
+	}
+
+	leaf.keys = insertString(leaf.keys, i, key)
+	leaf.locs = append(leaf.locs, RecordLocation{})
+	copy(leaf.locs[i+1:], leaf.locs[i:])
+	leaf.locs[i] = loc
+	kd.meta.keyCount++
+
+	return true, kd.storeAndSplit(leaf, path)
+}
+
+// Delete removes key from the directory.
+// It returns true if the key was present.
+func (kd *KeyDirectory) Delete(key string) (bool, error) {
+	kd.mu.Lock()
+	defer kd.mu.Unlock()
+
+	leaf, err := kd.findLeaf(key, nil)
+	if err != nil {
+		return false, err
+	}
+	i := sort.SearchStrings(leaf.keys, key)
+	if i >= len(leaf.keys) || leaf.keys[i] != key {
+		return false, nil
+	}
+
+	if err := kd.markDirtyLocked(); err != nil {
+		return false, err
+	}
+
+	leaf.keys = append(leaf.keys[:i], leaf.keys[i+1:]...)
+	leaf.locs = append(leaf.locs[:i], leaf.locs[i+1:]...)
+	kd.meta.keyCount--
+	return true, kd.writeNode(leaf)
+}
+
+// ScanPrefix calls fn for every key with the given prefix in key order.
+// Iteration stops early if fn returns false.
+func (kd *KeyDirectory) ScanPrefix(prefix string, fn func(key string, loc RecordLocation) bool) error {
+	return kd.ScanPrefixFrom(prefix, "", fn)
+}
+
+// ScanPrefixFrom is like ScanPrefix but starts at the first key not less
+// than start, so a scan can resume where an earlier one stopped.
+func (kd *KeyDirectory) ScanPrefixFrom(prefix, start string, fn func(key string, loc RecordLocation) bool) error {
+	kd.mu.RLock()
+	defer kd.mu.RUnlock()
+
+	seek := max(prefix, start)
+	node, err := kd.findLeaf(seek, nil)
+	if err != nil {
+		return err
+	}
+	i := sort.SearchStrings(node.keys, seek)
+
+	for {
+		for ; i < len(node.keys); i++ {
+			if !strings.HasPrefix(node.keys[i], prefix) {
+				return nil
+			}
+			if !fn(node.keys[i], node.locs[i]) {
+				return nil
+			}
+		}
+		if node.next == InvalidPageID {
+			return nil
+		}
+		if node, err = kd.readNode(node.next); err != nil {
+			return err
+		}
+		i = 0
+	}
+}
+
+// AddDataSize adjusts the persisted data size statistic by delta bytes.
+func (kd *KeyDirectory) AddDataSize(delta int64) {
+	kd.mu.Lock()
+	kd.meta.dataSize += delta
+	kd.mu.Unlock()
+}
+
+// KeyCount returns the number of keys in the directory.
+func (kd *KeyDirectory) KeyCount() int64 {
+	kd.mu.RLock()
+	defer kd.mu.RUnlock()
+	return kd.meta.keyCount
+}
+
+// DataSize returns the persisted data size statistic.
+func (kd *KeyDirectory) DataSize() int64 {
+	kd.mu.RLock()
+	defer kd.mu.RUnlock()
+	return kd.meta.dataSize
+}
+
+// WALOffset returns the WAL size recorded by the last checkpoint.
+func (kd *KeyDirectory) WALOffset() int64 {
+	kd.mu.RLock()
+	defer kd.mu.RUnlock()
+	return kd.meta.walOffset
+}
+
+// Checkpoint flushes all directory pages and marks the directory clean.
+// heapPages is the page count of the heap that was flushed just before,
+// which lets the next open detect a heap that moved on without it, and
+// walOffset is the WAL size covered by that heap flush.
+func (kd *KeyDirectory) Checkpoint(heapPages uint32, walOffset int64) error {
+	kd.mu.Lock()
+	defer kd.mu.Unlock()
+
+	if err := kd.bufferPool.FlushAllPages(); err != nil {
+		return err
+	}
+	if err := kd.heapFile.Sync(); err != nil {
+		return err
+	}
+	kd.meta.state = keyDirStateClean
+	kd.meta.heapPages = heapPages
+	kd.meta.walOffset = walOffset
+	kd.meta.dirPages = kd.heapFile.PageCount()
+	if err := kd.writeMeta(); err != nil {
+		return err
+	}
+	if err := kd.heapFile.Sync(); err != nil {
+		return err
+	}
+	return kd.journal.reset(PageID(kd.meta.dirPages))
+}
+
+// Close releases the directory's buffer pool and file.
+// Callers that want a fast restart should Checkpoint first.
+func (kd *KeyDirectory) Close() error {
+	kd.mu.Lock()
+	defer kd.mu.Unlock()
+	err := kd.bufferPool.Close()
+	if jerr := kd.journal.close(); err == nil {
+		err = jerr
+	}
+	return err
+}
+
+// BufferPool returns the directory's buffer pool.
+func (kd *KeyDirectory) BufferPool() *BufferPool {
+	return kd.bufferPool
+}
+
+// findLeaf descends from the root to the leaf that may contain key.
+// If path is non-nil, the internal nodes visited are appended to it.
+func (kd *KeyDirectory) findLeaf(key string, path *[]*keyDirNode) (*keyDirNode, error) {
+	node, err := kd.readNode(kd.meta.root)
+	if err != nil {
+		return nil, err
+	}
+	for !node.leaf {
+		if path != nil {
+			*path = append(*path, node)
+		}
+		// Child i holds keys in [keys[i-1], keys[i]).
+		i := sort.Search(len(node.keys), func(j int) bool { return node.keys[j] > key })
+		if node, err = kd.readNode(node.children[i]); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+// storeAndSplit writes node, splitting it and propagating separators up the
+// path of ancestors while nodes overflow their page.
+func (kd *KeyDirectory) storeAndSplit(node *keyDirNode, path []*keyDirNode) error {
+	for node.size() > nodeCapacity {
+		right, separator, err := kd.split(node)
+		if err != nil {
+			return err
+		}
+		if err := kd.writeNode(node); err != nil {
+			return err
+		}
+		if err := kd.writeNode(right); err != nil {
+			return err
+		}
+
+		if len(path) == 0 {
+			page, rootID, err := kd.bufferPool.NewPage()
+			if err != nil {
+				return err
+			}
+			root := &keyDirNode{
+				id:       rootID,
+				keys:     []string{separator},
+				children: []PageID{node.id, right.id},
+			}
+			root.encode(page)
+			kd.bufferPool.UnpinPage(rootID, true)
+			kd.meta.root = rootID
+			return nil
+		}
+
+		parent := path[len(path)-1]
+		path = path[:len(path)-1]
+		i := sort.Search(len(parent.keys), func(j int) bool { return parent.keys[j] > separator })
+		parent.keys = insertString(parent.keys, i, separator)
+		parent.children = append(parent.children, InvalidPageID)
+		copy(parent.children[i+2:], parent.children[i+1:])
+		parent.children[i+1] = right.id
+		node = parent
+	}
+	return kd.writeNode(node)
+}
+
+// split moves the upper half of node (by encoded size) into a new page and
+// returns the new right sibling and the separator key for the parent.
+func (kd *KeyDirectory) split(node *keyDirNode) (*keyDirNode, string, error) {
+	_, rightID, err := kd.bufferPool.NewPage()
+	if err != nil {
+		return nil, "", err
+	}
+	kd.bufferPool.UnpinPage(rightID, true)
+
+	right := &keyDirNode{id: rightID, leaf: node.leaf}
+	half := node.size() / 2
+	used := 0
+	mid := 0
+	for mid < len(node.keys)-1 {
+		used += node.entrySize(mid)
+		if used >= half {
+			break
+		}
+		mid++
+	}
+	if mid == 0 {
+		mid = 1
+	}
+
+	var separator string
+	if node.leaf {
+		right.keys = append(right.keys, node.keys[mid:]...)
+		right.locs = append(right.locs, node.locs[mid:]...)
+		node.keys = node.keys[:mid:mid]
+		node.locs = node.locs[:mid:mid]
+		right.next = node.next
+		node.next = rightID
+		separator = right.keys[0]
+	} else {
+		separator = node.keys[mid]
+		right.keys = append(right.keys, node.keys[mid+1:]...)
+		right.children = append(right.children, node.children[mid+1:]...)
+		node.keys = node.keys[:mid:mid]
+		node.children = node.children[: mid+1 : mid+1]
+	}
+	return right, separator, nil
+}
+
+func (kd *KeyDirectory) readNode(id PageID) (*keyDirNode, error) {
+	page, err := kd.bufferPool.FetchPage(id)
+	if err != nil {
+		return nil, err
+	}
+	defer kd.bufferPool.UnpinPage(id, false)
+	return decodeKeyDirNode(page)
+}
+
+func (kd *KeyDirectory) writeNode(node *keyDirNode) error {
+	// Journal the checkpointed image before the page first changes. The
+	// file still holds it: the page cannot have been written back since.
+	if kd.journal.needs(node.id) {
+		image, err := kd.heapFile.ReadPage(node.id)
+		if err != nil {
+			return err
+		}
+		if err := kd.journal.save(image); err != nil {
+			return err
+		}
+	}
+	page, err := kd.bufferPool.FetchPage(node.id)
+	if err != nil {
+		return err
+	}
+	node.encode(page)
+	return kd.bufferPool.UnpinPage(node.id, true)
+}
+
+// size returns the encoded size of the node body in bytes.
+func (n *keyDirNode) size() int {
+	size := 0
+	if !n.leaf {
+		size = 4 // child0
+	}
+	for i := range n.keys {
+		size += n.entrySize(i)
+	}
+	return size
+}
+
+// entrySize returns the encoded size of the node's i-th key and the
+// location or child pointer that follows it.
+func (n *keyDirNode) entrySize(i int) int {
+	if n.leaf {
+		return 2 + len(n.keys[i]) + leafEntryTail
+	}
+	return 2 + len(n.keys[i]) + internalEntryTail
+}
+
+// encode serializes the node into page, replacing its previous contents.
+func (n *keyDirNode) encode(page *Page) {
+	page.initHeader(n.id, PageTypeIndex)
+	h := page.Header()
+	if n.leaf {
+		h.Flags = nodeFlagLeaf
+	}
+	h.SlotCount = uint16(len(n.keys))
+	h.NextPageID = n.next
+
+	data := page.data[PageHeaderSize:]
+	off := 0
+	if !n.leaf {
+		binary.BigEndian.PutUint32(data[off:], uint32(n.children[0]))
+		off += 4
+	}
+	for i, k := range n.keys {
+		binary.BigEndian.PutUint16(data[off:], uint16(len(k)))
+		off += 2
+		off += copy(data[off:], k)
+		if n.leaf {
+			binary.BigEndian.PutUint32(data[off:], uint32(n.locs[i].PageID))
+			binary.BigEndian.PutUint16(data[off+4:], n.locs[i].SlotID)
+			off += leafEntryTail
+		} else {
+			binary.BigEndian.PutUint32(data[off:], uint32(n.children[i+1]))
+			off += internalEntryTail
+		}
+	}
+	h.FreeSpaceStart = uint16(PageHeaderSize + off)
+	page.setHeader(h)
+	page.dirty = true
+}
+
+func decodeKeyDirNode(page *Page) (*keyDirNode, error) {
+	h := page.Header()
+	if h.PageType != PageTypeIndex {
+		return nil, ErrKeyDirectoryFormat
+	}
+	n := &keyDirNode{
+		id:   h.PageID,
+		leaf: h.Flags&nodeFlagLeaf != 0,
+		next: h.NextPageID,
+		keys: make([]string, 0, h.SlotCount),
+	}
+
+	data := page.data[PageHeaderSize:]
+	off := 0
+	if !n.leaf {
+		n.children = make([]PageID, 0, h.SlotCount+1)
+		n.children = append(n.children, PageID(binary.BigEndian.Uint32(data[off:])))
+		off += 4
+	} else {
+		n.locs = make([]RecordLocation, 0, h.SlotCount)
+	}
+	for i := 0; i < int(h.SlotCount); i++ {
+		keyLen := int(binary.BigEndian.Uint16(data[off:]))
+		off += 2
+		if off+keyLen > len(data) {
+			return nil, ErrKeyDirectoryFormat
+		}
+		n.keys = append(n.keys, string(data[off:off+keyLen]))
+		off += keyLen
+		if n.leaf {
+			n.locs = append(n.locs, RecordLocation{
+				PageID: PageID(binary.BigEndian.Uint32(data[off:])),
+				SlotID: binary.BigEndian.Uint16(data[off+4:]),
+			})
+			off += leafEntryTail
+		} else {
+			n.children = append(n.children, PageID(binary.BigEndian.Uint32(data[off:])))
+			off += internalEntryTail
+		}
+	}
+	return n, nil
+}
+
+func insertString(s []string, i int, v string) []string {
+	s = append(s, "")
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
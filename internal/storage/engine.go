@@ -90,7 +90,11 @@ This provides optimal performance without manual tuning.
 */
 package storage
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"sort"
+)
 
 // ErrNotFound is returned when a requested key does not exist in the store.
 // This is a sentinel error that callers can check using errors.Is().
@@ -160,3 +164,106 @@ type Engine interface {
 	//   defer engine.Close()
 	Close() error
 }
+
+// ContextScanner is implemented by engines whose scans stop early when a
+// context is canceled, so a canceled or timed-out query does not have to
+// wait for a large scan to finish.
+type ContextScanner interface {
+	// ScanContext is like Scan but returns ctx.Err() as soon as ctx is done.
+	ScanContext(ctx context.Context, prefix string) (map[string][]byte, error)
+}
+
+// KeyScanner is implemented by engines that can list keys in order without
+// reading their values, so callers that only need some of the keys under a
+// prefix avoid reading every value, and can resume a scan where it stopped.
+type KeyScanner interface {
+	// ScanKeys calls fn with each key matching prefix that is not less
+	// than start, in key order, until fn returns false.
+	ScanKeys(prefix, start string, fn func(key string) bool) error
+}
+
+// ScanKeys calls fn with each key in engine matching prefix that is not
+// less than start, in key order, until fn returns false. Engines that do
+// not implement KeyScanner are scanned in full.
+func ScanKeys(engine Engine, prefix, start string, fn func(key string) bool) error {
+	if scanner, ok := engine.(KeyScanner); ok {
+		return scanner.ScanKeys(prefix, start, fn)
+	}
+	rows, err := engine.Scan(prefix)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(rows))
+	for key := range rows {
+		if key >= start {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !fn(key) {
+			break
+		}
+	}
+	return nil
+}
+
+// BatchWriter is implemented by engines that can take several writes at
+// once. Engines that replicate through a consensus log propose a batch as a
+// single entry, so its writes commit together.
+type BatchWriter interface {
+	// WriteBatch applies ops in order.
+	WriteBatch(ops []TxOperation) error
+	// ProposesWrites reports whether writes go through a consensus log,
+	// where each write costs a round trip and batching pays off.
+	ProposesWrites() bool
+}
+
+// WriteBatch applies ops to engine in order, as one batch when engine is a
+// BatchWriter and one write at a time otherwise.
+func WriteBatch(engine Engine, ops []TxOperation) error {
+	if writer, ok := engine.(BatchWriter); ok {
+		return writer.WriteBatch(ops)
+	}
+	for _, op := range ops {
+		var err error
+		switch op.Op {
+		case OpPut:
+			err = engine.Put(op.Key, op.Value)
+		case OpDelete:
+			err = engine.Delete(op.Key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProposesWrites reports whether engine replicates its writes through a
+// consensus log, in which case callers should group related writes with
+// WriteBatch.
+func ProposesWrites(engine Engine) bool {
+	writer, ok := engine.(BatchWriter)
+	return ok && writer.ProposesWrites()
+}
+
+// ScanContext scans the keys matching prefix in engine, stopping early when
+// ctx is done. Engines that do not implement ContextScanner are checked
+// before and after the scan.
+func ScanContext(ctx context.Context, engine Engine, prefix string) (map[string][]byte, error) {
+	if scanner, ok := engine.(ContextScanner); ok {
+		return scanner.ScanContext(ctx, prefix)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rows, err := engine.Scan(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestScanKeysFrom(t *testing.T) {
+	store, cleanup := setupTestEngine(t)
+	defer cleanup()
+
+	for i := 0; i < 20; i++ {
+		store.Put(fmt.Sprintf("row:users:%02d", i), []byte("{}"))
+	}
+	store.Put("row:usersx:00", []byte("{}"))
+
+	// The engine and the Scan fallback used for a transaction agree
+	for name, engine := range map[string]Engine{"engine": store, "transaction": NewTransaction(store)} {
+		var keys []string
+		err := ScanKeys(engine, "row:users:", "row:users:15", func(key string) bool {
+			keys = append(keys, key)
+			return len(keys) < 3
+		})
+		if err != nil {
+			t.Fatalf("%s: ScanKeys failed: %v", name, err)
+		}
+		if got := strings.Join(keys, ","); got != "row:users:15,row:users:16,row:users:17" {
+			t.Errorf("%s: ScanKeys returned %s", name, got)
+		}
+
+		count := 0
+		ScanKeys(engine, "row:users:", "", func(string) bool { count++; return true })
+		if count != 20 {
+			t.Errorf("%s: Expected 20 keys from the start of the prefix, got %d", name, count)
+		}
+	}
+}
+
+func TestScanContextCanceled(t *testing.T) {
+	store, cleanup := setupTestEngine(t)
+	defer cleanup()
+
+	for i := 0; i < 1000; i++ {
+		store.Put(fmt.Sprintf("row:users:%d", i), []byte("{}"))
+	}
+
+	rows, err := ScanContext(context.Background(), store, "row:users:")
+	if err != nil || len(rows) != 1000 {
+		t.Fatalf("ScanContext returned %d rows, %v", len(rows), err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ScanContext(ctx, store, "row:users:"); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if _, err := NewTransaction(store).ScanContext(ctx, "row:users:"); err != context.Canceled {
+		t.Errorf("Expected context.Canceled from a transaction, got %v", err)
+	}
+}
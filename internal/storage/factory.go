@@ -18,7 +18,11 @@ This unified approach provides optimal performance for both small datasets
 package storage
 
 import (
+	"context"
+	"encoding/binary"
+	"fmt"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"flydb/internal/storage/disk"
@@ -40,6 +44,12 @@ type StorageConfig struct {
 
 	// Encryption configuration.
 	Encryption EncryptionConfig
+
+	// SyncPolicy controls how long Put and Delete wait for their WAL record
+	// to reach disk. The zero value waits for an fsync (group-committed with
+	// concurrent writers). Callers that enforce durability at commit
+	// boundaries use SyncOff here and call Commit themselves.
+	SyncPolicy SyncPolicy
 }
 
 // DefaultStorageConfig returns default storage configuration.
@@ -49,6 +59,7 @@ func DefaultStorageConfig() StorageConfig {
 		DataDir:            "./data",
 		BufferPoolSize:     0, // Auto-size based on available memory
 		CheckpointInterval: 60 * time.Second,
+		SyncPolicy:         SyncPolicy{Mode: SyncAlways},
 	}
 }
 
@@ -95,6 +106,7 @@ func NewStorageEngine(config StorageConfig) (*UnifiedStorageEngine, error) {
 		diskEngine: diskEngine,
 		wal:        wal,
 		config:     config,
+		syncPolicy: config.SyncPolicy,
 	}
 
 	// Replay WAL to recover any uncommitted operations
@@ -143,6 +155,14 @@ type UnifiedStorageEngine struct {
 	wal        *WAL
 	config     StorageConfig
 	replicationHook func(key string, value []byte)
+
+	syncMu     sync.RWMutex
+	syncPolicy SyncPolicy
+
+	proposerMu    sync.RWMutex
+	writeProposer func(ops []TxOperation) error
+
+	raftApplyMu sync.Mutex // Serializes ApplyCommittedAt
 }
 
 // SetReplicationHook sets a callback to be invoked when a replicated PUT is applied.
@@ -151,26 +171,63 @@ func (e *UnifiedStorageEngine) SetReplicationHook(fn func(key string, value []by
 	e.replicationHook = fn
 }
 
+// SetWriteProposer routes Put, Delete and WriteBatch through fn instead of
+// writing them locally. In cluster mode with Raft, fn proposes the writes to
+// the Raft log as one entry and returns once a quorum has committed it and it
+// has been applied on this node through ApplyCommittedAt. A nil fn restores
+// local writes.
+func (e *UnifiedStorageEngine) SetWriteProposer(fn func(ops []TxOperation) error) {
+	e.proposerMu.Lock()
+	defer e.proposerMu.Unlock()
+	e.writeProposer = fn
+}
+
+func (e *UnifiedStorageEngine) proposer() func(ops []TxOperation) error {
+	e.proposerMu.RLock()
+	defer e.proposerMu.RUnlock()
+	return e.writeProposer
+}
+
+// ProposesWrites reports whether writes are routed through a write proposer.
+func (e *UnifiedStorageEngine) ProposesWrites() bool {
+	return e.proposer() != nil
+}
+
 // replayWAL replays the WAL to recover any operations not yet in the disk engine.
+// Replay starts at the offset covered by the last checkpoint, and records are
+// applied without being logged again since they are already in the WAL.
 func (e *UnifiedStorageEngine) replayWAL() error {
-	return e.wal.Replay(0, func(op byte, key string, value []byte) {
+	replayed := false
+	err := e.wal.Replay(e.diskEngine.RecoveryOffset(), func(op byte, key string, value []byte) {
+		replayed = true
 		switch op {
 		case OpPut:
-			// Check if key exists in disk engine
-			_, err := e.diskEngine.Get(key)
-			if err != nil {
-				// Key not in disk engine, apply from WAL
-				e.diskEngine.Put(key, value)
-			}
+			e.diskEngine.ApplyReplicatedPut(key, value)
 		case OpDelete:
-			e.diskEngine.Delete(key)
+			e.diskEngine.ApplyReplicatedDelete(key)
+		case OpBatch:
+			e.diskEngine.ApplyReplicatedBatch(value)
 		}
 	})
+	if err != nil || !replayed {
+		return err
+	}
+	// Checkpoint so that another crash does not replay the same tail
+	return e.diskEngine.Checkpoint()
 }
 
 // Put stores a value associated with a key.
+// It returns once the WAL record is as durable as the engine's sync policy
+// requires; the wait happens outside the disk engine lock so concurrent
+// writers share an fsync.
 func (e *UnifiedStorageEngine) Put(key string, value []byte) error {
-	return e.diskEngine.Put(key, value)
+	if propose := e.proposer(); propose != nil {
+		return propose([]TxOperation{{Op: OpPut, Key: key, Value: value}})
+	}
+	if err := e.diskEngine.Put(key, value); err != nil {
+		return err
+	}
+	return e.wal.Commit(e.SyncPolicy())
 }
 
 // Get retrieves the value associated with a key.
@@ -184,7 +241,148 @@ func (e *UnifiedStorageEngine) Get(key string) ([]byte, error) {
 
 // Delete removes a key and its associated value.
 func (e *UnifiedStorageEngine) Delete(key string) error {
-	return e.diskEngine.Delete(key)
+	if propose := e.proposer(); propose != nil {
+		return propose([]TxOperation{{Op: OpDelete, Key: key}})
+	}
+	if err := e.diskEngine.Delete(key); err != nil {
+		return err
+	}
+	return e.wal.Commit(e.SyncPolicy())
+}
+
+// WriteBatch applies ops in order. With a write proposer set they are
+// proposed as a single entry, so they commit together with one round trip to
+// the cluster; otherwise each is written as by Put or Delete.
+func (e *UnifiedStorageEngine) WriteBatch(ops []TxOperation) error {
+	if len(ops) == 0 {
+		return nil
+	}
+	if propose := e.proposer(); propose != nil {
+		return propose(ops)
+	}
+	for _, op := range ops {
+		var err error
+		switch op.Op {
+		case OpPut:
+			err = e.diskEngine.Put(op.Key, op.Value)
+		case OpDelete:
+			err = e.diskEngine.Delete(op.Key)
+		default:
+			err = fmt.Errorf("unknown write operation %d", op.Op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return e.wal.Commit(e.SyncPolicy())
+}
+
+// ApplyCommitted applies a write that the cluster has committed. It writes
+// locally even when a write proposer is set, and invokes the replication
+// hook for puts so followers pick up side effects such as database creation.
+func (e *UnifiedStorageEngine) ApplyCommitted(op byte, key string, value []byte) error {
+	var err error
+	switch op {
+	case OpPut:
+		err = e.diskEngine.Put(key, value)
+	case OpDelete:
+		err = e.diskEngine.Delete(key)
+	default:
+		return fmt.Errorf("unknown write operation %d", op)
+	}
+	if err != nil {
+		return err
+	}
+	if err := e.wal.Commit(e.SyncPolicy()); err != nil {
+		return err
+	}
+	if op == OpPut && e.replicationHook != nil {
+		e.replicationHook(key, value)
+	}
+	return nil
+}
+
+// raftAppliedKey holds the index of the last Raft log entry applied to the
+// database, as 8 big-endian bytes.
+const raftAppliedKey = "_sys_raft_applied"
+
+// ApplyCommittedAt applies the writes of the Raft log entry at index, like
+// ApplyCommitted does for a single write. The writes and the index are
+// logged as one WAL record, so they are applied atomically and the
+// database's applied index survives a restart exactly in step with its
+// contents; an entry the database has already applied is skipped.
+func (e *UnifiedStorageEngine) ApplyCommittedAt(index uint64, ops []TxOperation) error {
+	e.raftApplyMu.Lock()
+	defer e.raftApplyMu.Unlock()
+
+	if applied, err := e.RaftAppliedIndex(); err != nil {
+		return err
+	} else if index <= applied {
+		return nil
+	}
+
+	batch := make([]disk.BatchWrite, 0, len(ops)+1)
+	for _, op := range ops {
+		if op.Op != OpPut && op.Op != OpDelete {
+			return fmt.Errorf("unknown write operation %d", op.Op)
+		}
+		batch = append(batch, disk.BatchWrite(op))
+	}
+	var indexBuf [8]byte
+	binary.BigEndian.PutUint64(indexBuf[:], index)
+	batch = append(batch, disk.BatchWrite{Op: OpPut, Key: raftAppliedKey, Value: indexBuf[:]})
+
+	if err := e.diskEngine.WriteBatch(batch); err != nil {
+		return err
+	}
+	if err := e.wal.Commit(e.SyncPolicy()); err != nil {
+		return err
+	}
+	if e.replicationHook != nil {
+		for _, op := range ops {
+			if op.Op == OpPut {
+				e.replicationHook(op.Key, op.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// RaftAppliedIndex returns the index of the last Raft log entry applied to
+// the database through ApplyCommittedAt, or 0 if there is none.
+func (e *UnifiedStorageEngine) RaftAppliedIndex() (uint64, error) {
+	data, err := e.diskEngine.Get(raftAppliedKey)
+	if err == disk.ErrPageNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(data) != 8 {
+		return 0, fmt.Errorf("invalid Raft applied index")
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
+// Commit waits until every write made so far is as durable as policy
+// requires. It is used to enforce a session's synchronous_commit setting at
+// statement and transaction boundaries.
+func (e *UnifiedStorageEngine) Commit(policy SyncPolicy) error {
+	return e.wal.Commit(policy)
+}
+
+// SyncPolicy returns the policy applied to each Put and Delete.
+func (e *UnifiedStorageEngine) SyncPolicy() SyncPolicy {
+	e.syncMu.RLock()
+	defer e.syncMu.RUnlock()
+	return e.syncPolicy
+}
+
+// SetSyncPolicy changes the policy applied to each Put and Delete.
+func (e *UnifiedStorageEngine) SetSyncPolicy(policy SyncPolicy) {
+	e.syncMu.Lock()
+	defer e.syncMu.Unlock()
+	e.syncPolicy = policy
 }
 
 // Scan returns all key-value pairs matching the given prefix.
@@ -192,6 +390,17 @@ func (e *UnifiedStorageEngine) Scan(prefix string) (map[string][]byte, error) {
 	return e.diskEngine.Scan(prefix)
 }
 
+// ScanContext is like Scan but stops early when ctx is done.
+func (e *UnifiedStorageEngine) ScanContext(ctx context.Context, prefix string) (map[string][]byte, error) {
+	return e.diskEngine.ScanContext(ctx, prefix)
+}
+
+// ScanKeys calls fn with each key matching prefix that is not less than
+// start, in key order, until fn returns false.
+func (e *UnifiedStorageEngine) ScanKeys(prefix, start string, fn func(key string) bool) error {
+	return e.diskEngine.ScanKeys(prefix, start, fn)
+}
+
 // Close shuts down the storage engine.
 func (e *UnifiedStorageEngine) Close() error {
 	// Sync before closing
@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SyncMode selects how a commit waits for its WAL records to reach disk.
+type SyncMode int
+
+const (
+	// SyncAlways waits until the records are fsynced. Concurrent
+	// committers share a single fsync (group commit).
+	SyncAlways SyncMode = iota
+
+	// SyncBatch returns immediately; the records are fsynced by a background
+	// flusher within the policy interval.
+	SyncBatch
+
+	// SyncOff returns immediately; the records, already written to the OS,
+	// are only fsynced at the next checkpoint, Sync or synchronous commit.
+	SyncOff
+)
+
+// DefaultSyncBatchInterval is the interval used by "batch" without an
+// explicit duration.
+const DefaultSyncBatchInterval = 10 * time.Millisecond
+
+// SyncPolicy is a synchronous_commit setting: a mode plus, for SyncBatch,
+// the maximum delay before the records are fsynced.
+//
+// The zero value is SyncAlways.
+type SyncPolicy struct {
+	Mode     SyncMode
+	Interval time.Duration
+}
+
+// ParseSyncPolicy parses a synchronous_commit value.
+//
+// Accepted forms are "always", "batch", "batch(<duration>)" and "off",
+// where <duration> uses time.ParseDuration syntax, e.g. "batch(5ms)".
+// "on" and "true" are accepted as aliases for "always", "false" for "off".
+func ParseSyncPolicy(s string) (SyncPolicy, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	switch v {
+	case "always", "on", "true":
+		return SyncPolicy{Mode: SyncAlways}, nil
+	case "off", "false":
+		return SyncPolicy{Mode: SyncOff}, nil
+	case "batch":
+		return SyncPolicy{Mode: SyncBatch, Interval: DefaultSyncBatchInterval}, nil
+	}
+
+	if strings.HasPrefix(v, "batch(") && strings.HasSuffix(v, ")") {
+		interval, err := time.ParseDuration(strings.TrimSpace(v[len("batch(") : len(v)-1]))
+		if err != nil || interval <= 0 {
+			return SyncPolicy{}, fmt.Errorf("invalid synchronous_commit %q: batch interval must be a positive duration", s)
+		}
+		return SyncPolicy{Mode: SyncBatch, Interval: interval}, nil
+	}
+
+	return SyncPolicy{}, fmt.Errorf("invalid synchronous_commit %q (must be always, batch(interval), or off)", s)
+}
+
+// String returns the policy in the form accepted by ParseSyncPolicy.
+func (p SyncPolicy) String() string {
+	switch p.Mode {
+	case SyncBatch:
+		return fmt.Sprintf("batch(%s)", p.batchInterval())
+	case SyncOff:
+		return "off"
+	default:
+		return "always"
+	}
+}
+
+// batchInterval returns the batch interval, falling back to the default.
+func (p SyncPolicy) batchInterval() time.Duration {
+	if p.Interval <= 0 {
+		return DefaultSyncBatchInterval
+	}
+	return p.Interval
+}
+
+// Committer is implemented by engines that can wait for durability at
+// statement and transaction boundaries instead of on every write.
+type Committer interface {
+	Commit(policy SyncPolicy) error
+}
@@ -28,9 +28,25 @@ FlyDB uses an optimistic concurrency model with the following properties:
 
   - Atomicity: All operations in a transaction are applied together or not at all
   - Consistency: Transactions maintain database invariants
-  - Isolation: Read-committed isolation level (reads see committed data only)
+  - Isolation: Reads see committed data only; see Isolation Levels below
   - Durability: Committed transactions are persisted to WAL
 
+Isolation Levels:
+=================
+
+Writes are buffered until commit, so no level ever sees the uncommitted
+writes of another transaction:
+
+  - Read Uncommitted and Read Committed: every read returns the latest
+    committed value
+  - Repeatable Read: the first value read for a key is kept and returned by
+    every later read; scans may still return keys committed since (phantoms)
+  - Serializable: Repeatable Read, and Commit fails with
+    ErrSerializationFailure if a key the transaction read, or the set of
+    keys a scan returned, changed before it commits
+
+Read-only transactions reject Put and Delete with ErrReadOnlyTransaction.
+
 Transaction Lifecycle:
 ======================
 
@@ -50,10 +66,52 @@ Usage:
 package storage
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"strings"
 	"sync"
 )
 
+// ErrReadOnlyTransaction is returned when a read-only transaction writes.
+var ErrReadOnlyTransaction = errors.New("cannot write in a read-only transaction")
+
+// ErrSerializationFailure is returned by Commit when a serializable
+// transaction read data that another writer changed before it committed.
+var ErrSerializationFailure = errors.New("could not serialize access due to concurrent update")
+
+// IsolationLevel is the isolation level of a transaction. The values match
+// the isolation level codes of the binary protocol.
+type IsolationLevel int
+
+const (
+	IsolationReadUncommitted IsolationLevel = iota
+	IsolationReadCommitted
+	IsolationRepeatableRead
+	IsolationSerializable
+)
+
+// TxOptions configures a transaction.
+type TxOptions struct {
+	Isolation IsolationLevel
+	ReadOnly  bool
+
+	// Commit, if set, applies the buffered writes at commit instead of the
+	// store, for example through a distributed transaction coordinator.
+	Commit func(ops []TxOperation) error
+}
+
+// serializableCommitMu serializes the validation and apply phases of
+// serializable commits, so two of them cannot both validate before either
+// writes.
+var serializableCommitMu sync.Mutex
+
+// readVersion is the value a transaction first read for a key.
+type readVersion struct {
+	value []byte
+	found bool
+}
+
 // TxState represents the current state of a transaction.
 type TxState int
 
@@ -74,6 +132,7 @@ type TxOperation struct {
 type Savepoint struct {
 	Name           string
 	BufferPosition int               // Position in buffer when savepoint was created
+	HookPosition   int               // Number of commit hooks when savepoint was created
 	ReadCacheSnap  map[string][]byte // Snapshot of readCache
 	DeleteSetSnap  map[string]bool   // Snapshot of deleteSet
 }
@@ -91,20 +150,53 @@ type Transaction struct {
 	deleteSet  map[string]bool   // Keys marked for deletion
 	state      TxState
 	savepoints []Savepoint // Stack of savepoints
+	onCommit   []func()    // Run in order once the buffer is applied
 	mu         sync.Mutex
+
+	isolation IsolationLevel
+	readOnly  bool
+	commit    func(ops []TxOperation) error // Applies the buffer; nil writes it to store
+	reads     map[string]readVersion     // First value read per key, Repeatable Read and above
+	scans     map[string]map[string]bool // Keys returned per scanned prefix, Serializable only
 }
 
 // NewTransaction creates a new transaction on the given storage engine.
 // The transaction starts in the Active state.
 func NewTransaction(store Engine) *Transaction {
-	return &Transaction{
+	return NewTransactionWithOptions(store, TxOptions{Isolation: IsolationReadCommitted})
+}
+
+// NewTransactionWithOptions creates a new transaction with the given
+// isolation level and access mode.
+func NewTransactionWithOptions(store Engine, opts TxOptions) *Transaction {
+	tx := &Transaction{
 		store:      store,
 		buffer:     make([]TxOperation, 0),
 		readCache:  make(map[string][]byte),
 		deleteSet:  make(map[string]bool),
 		state:      TxStateActive,
 		savepoints: make([]Savepoint, 0),
+		isolation:  opts.Isolation,
+		readOnly:   opts.ReadOnly,
+		commit:     opts.Commit,
+	}
+	if tx.isolation >= IsolationRepeatableRead {
+		tx.reads = make(map[string]readVersion)
+	}
+	if tx.isolation >= IsolationSerializable {
+		tx.scans = make(map[string]map[string]bool)
 	}
+	return tx
+}
+
+// Isolation returns the isolation level of the transaction.
+func (tx *Transaction) Isolation() IsolationLevel {
+	return tx.isolation
+}
+
+// ReadOnly returns true if the transaction rejects writes.
+func (tx *Transaction) ReadOnly() bool {
+	return tx.readOnly
 }
 
 // Put adds a write operation to the transaction buffer.
@@ -116,6 +208,9 @@ func (tx *Transaction) Put(key string, value []byte) error {
 	if tx.state != TxStateActive {
 		return errors.New("transaction is not active")
 	}
+	if tx.readOnly {
+		return ErrReadOnlyTransaction
+	}
 
 	tx.buffer = append(tx.buffer, TxOperation{
 		Op:    OpPut,
@@ -136,6 +231,9 @@ func (tx *Transaction) Delete(key string) error {
 	if tx.state != TxStateActive {
 		return errors.New("transaction is not active")
 	}
+	if tx.readOnly {
+		return ErrReadOnlyTransaction
+	}
 
 	tx.buffer = append(tx.buffer, TxOperation{
 		Op:  OpDelete,
@@ -166,13 +264,33 @@ func (tx *Transaction) Get(key string) ([]byte, error) {
 		return val, nil
 	}
 
-	// Fall back to storage
-	return tx.store.Get(key)
+	// Fall back to storage, keeping the first value read when reads
+	// must repeat
+	if tx.reads == nil {
+		return tx.store.Get(key)
+	}
+	if read, ok := tx.reads[key]; ok {
+		if !read.found {
+			return nil, ErrNotFound
+		}
+		return read.value, nil
+	}
+	val, err := tx.store.Get(key)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	tx.reads[key] = readVersion{value: val, found: err == nil}
+	return val, err
 }
 
 // Scan returns all key-value pairs matching the prefix,
 // including uncommitted changes from this transaction.
 func (tx *Transaction) Scan(prefix string) (map[string][]byte, error) {
+	return tx.ScanContext(context.Background(), prefix)
+}
+
+// ScanContext is like Scan but stops early when ctx is done.
+func (tx *Transaction) ScanContext(ctx context.Context, prefix string) (map[string][]byte, error) {
 	tx.mu.Lock()
 	defer tx.mu.Unlock()
 
@@ -181,10 +299,13 @@ func (tx *Transaction) Scan(prefix string) (map[string][]byte, error) {
 	}
 
 	// Get data from storage
-	result, err := tx.store.Scan(prefix)
+	result, err := ScanContext(ctx, tx.store, prefix)
 	if err != nil {
 		return nil, err
 	}
+	if tx.reads != nil {
+		tx.repeatScan(prefix, result)
+	}
 
 	// Apply transaction buffer changes
 	for key, val := range tx.readCache {
@@ -201,11 +322,75 @@ func (tx *Transaction) Scan(prefix string) (map[string][]byte, error) {
 	return result, nil
 }
 
+// repeatScan replaces the values in a scan result with those the
+// transaction read first, and records the values it reads for the first
+// time. The caller must hold tx.mu.
+func (tx *Transaction) repeatScan(prefix string, result map[string][]byte) {
+	for key, val := range result {
+		read, ok := tx.reads[key]
+		switch {
+		case !ok:
+			tx.reads[key] = readVersion{value: val, found: true}
+		case read.found:
+			result[key] = read.value
+		default:
+			delete(result, key)
+		}
+	}
+	// Keys read before and deleted since are still seen
+	for key, read := range tx.reads {
+		if read.found && strings.HasPrefix(key, prefix) {
+			if _, ok := result[key]; !ok {
+				result[key] = read.value
+			}
+		}
+	}
+
+	if tx.scans != nil {
+		keys := make(map[string]bool, len(result))
+		for key := range result {
+			keys[key] = true
+		}
+		tx.scans[prefix] = keys
+	}
+}
+
+// validate checks that nothing a serializable transaction read has changed
+// in storage. The caller must hold tx.mu and serializableCommitMu.
+func (tx *Transaction) validate() error {
+	for key, read := range tx.reads {
+		val, err := tx.store.Get(key)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		if (err == nil) != read.found || !bytes.Equal(val, read.value) {
+			return ErrSerializationFailure
+		}
+	}
+	for prefix, keys := range tx.scans {
+		current, err := tx.store.Scan(prefix)
+		if err != nil {
+			return err
+		}
+		if len(current) != len(keys) {
+			return ErrSerializationFailure
+		}
+		for key := range current {
+			if !keys[key] {
+				return ErrSerializationFailure
+			}
+		}
+	}
+	return nil
+}
+
 // Commit applies all buffered operations to the underlying storage.
 // After Commit, the transaction cannot be used for further operations.
 //
 // The commit is atomic - either all operations succeed or none do.
-// On success, all changes are persisted to the WAL.
+// On success, all changes are persisted to the WAL. A serializable
+// transaction whose reads are no longer current is rolled back and
+// ErrSerializationFailure is returned.
 func (tx *Transaction) Commit() error {
 	tx.mu.Lock()
 	defer tx.mu.Unlock()
@@ -214,26 +399,49 @@ func (tx *Transaction) Commit() error {
 		return errors.New("transaction is not active")
 	}
 
-	// Apply all buffered operations to storage
-	for _, op := range tx.buffer {
-		var err error
-		if op.Op == OpPut {
-			err = tx.store.Put(op.Key, op.Value)
-		} else if op.Op == OpDelete {
-			err = tx.store.Delete(op.Key)
-		}
-		if err != nil {
-			// On error, we've partially committed - this is a limitation
-			// A production system would use a more sophisticated approach
+	if tx.isolation >= IsolationSerializable {
+		serializableCommitMu.Lock()
+		defer serializableCommitMu.Unlock()
+		if err := tx.validate(); err != nil {
 			tx.state = TxStateRolledBack
 			return err
 		}
 	}
 
+	// Apply all buffered operations to storage. An engine that replicates
+	// through consensus commits them as one entry; elsewhere an error
+	// leaves the earlier writes applied unless a commit hook makes the
+	// batch atomic
+	apply := tx.commit
+	if apply == nil {
+		apply = func(ops []TxOperation) error { return WriteBatch(tx.store, ops) }
+	}
+	if err := apply(tx.buffer); err != nil {
+		tx.state = TxStateRolledBack
+		return err
+	}
+
 	tx.state = TxStateCommitted
+	for _, fn := range tx.onCommit {
+		fn()
+	}
+	tx.onCommit = nil
 	return nil
 }
 
+// OnCommit registers fn to run once the transaction's writes are applied,
+// for state kept outside storage that must only see committed rows, such
+// as indexes. fn is dropped if the transaction rolls back, or rolls back
+// to a savepoint created before fn was registered. fn must not use the
+// transaction.
+func (tx *Transaction) OnCommit(fn func()) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.state == TxStateActive {
+		tx.onCommit = append(tx.onCommit, fn)
+	}
+}
+
 // Rollback discards all buffered operations.
 // After Rollback, the transaction cannot be used for further operations.
 func (tx *Transaction) Rollback() error {
@@ -248,10 +456,20 @@ func (tx *Transaction) Rollback() error {
 	tx.buffer = nil
 	tx.readCache = nil
 	tx.deleteSet = nil
+	tx.onCommit = nil
 	tx.state = TxStateRolledBack
 	return nil
 }
 
+// Close rolls the transaction back if it is still active. It lets a
+// Transaction stand in for the Engine it buffers writes for.
+func (tx *Transaction) Close() error {
+	if tx.IsActive() {
+		return tx.Rollback()
+	}
+	return nil
+}
+
 // IsActive returns true if the transaction is still active.
 func (tx *Transaction) IsActive() bool {
 	tx.mu.Lock()
@@ -289,6 +507,7 @@ func (tx *Transaction) CreateSavepoint(name string) error {
 	tx.savepoints = append(tx.savepoints, Savepoint{
 		Name:           name,
 		BufferPosition: len(tx.buffer),
+		HookPosition:   len(tx.onCommit),
 		ReadCacheSnap:  readCacheSnap,
 		DeleteSetSnap:  deleteSetSnap,
 	})
@@ -323,6 +542,7 @@ func (tx *Transaction) RollbackToSavepoint(name string) error {
 
 	// Rollback buffer to savepoint position
 	tx.buffer = tx.buffer[:sp.BufferPosition]
+	tx.onCommit = tx.onCommit[:sp.HookPosition]
 
 	// Restore readCache and deleteSet
 	tx.readCache = make(map[string][]byte)
@@ -17,6 +17,7 @@
 package storage
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -144,3 +145,152 @@ func TestTransactionDelete(t *testing.T) {
 	}
 }
 
+func TestTransactionReadOnly(t *testing.T) {
+	store, cleanup := setupTestEngine(t)
+	defer cleanup()
+
+	tx := NewTransactionWithOptions(store, TxOptions{Isolation: IsolationReadCommitted, ReadOnly: true})
+	if err := tx.Put("key1", []byte("value1")); err != ErrReadOnlyTransaction {
+		t.Errorf("Expected ErrReadOnlyTransaction from Put, got %v", err)
+	}
+	if err := tx.Delete("key1"); err != ErrReadOnlyTransaction {
+		t.Errorf("Expected ErrReadOnlyTransaction from Delete, got %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Errorf("Commit failed: %v", err)
+	}
+}
+
+func TestTransactionCommitHook(t *testing.T) {
+	store, cleanup := setupTestEngine(t)
+	defer cleanup()
+
+	var committed []TxOperation
+	tx := NewTransactionWithOptions(store, TxOptions{
+		Isolation: IsolationReadCommitted,
+		Commit: func(ops []TxOperation) error {
+			committed = append(committed, ops...)
+			return nil
+		},
+	})
+	tx.Put("key1", []byte("value1"))
+	tx.Delete("key2")
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if len(committed) != 2 || committed[0].Key != "key1" || committed[1].Op != OpDelete {
+		t.Errorf("Expected the hook to receive both writes in order, got %v", committed)
+	}
+	if _, err := store.Get("key1"); err != ErrNotFound {
+		t.Error("Expected the hook to replace the write to the store")
+	}
+}
+
+func TestTransactionOnCommit(t *testing.T) {
+	store, cleanup := setupTestEngine(t)
+	defer cleanup()
+
+	var ran []string
+	record := func(name string) func() {
+		return func() { ran = append(ran, name) }
+	}
+
+	tx := NewTransaction(store)
+	tx.OnCommit(record("a"))
+	tx.CreateSavepoint("sp")
+	tx.OnCommit(record("b"))
+	if len(ran) != 0 {
+		t.Fatalf("Hooks ran before commit: %v", ran)
+	}
+	tx.RollbackToSavepoint("sp")
+	tx.OnCommit(record("c"))
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if strings.Join(ran, ",") != "a,c" {
+		t.Errorf("Hooks run = %v, want a,c", ran)
+	}
+
+	ran = nil
+	tx = NewTransaction(store)
+	tx.OnCommit(record("a"))
+	tx.Rollback()
+	if len(ran) != 0 {
+		t.Errorf("Hooks ran after rollback: %v", ran)
+	}
+}
+
+func TestTransactionRepeatableRead(t *testing.T) {
+	store, cleanup := setupTestEngine(t)
+	defer cleanup()
+
+	store.Put("row:1", []byte("a"))
+	store.Put("row:2", []byte("b"))
+
+	committed := NewTransaction(store)
+	repeatable := NewTransactionWithOptions(store, TxOptions{Isolation: IsolationRepeatableRead})
+	committed.Get("row:1")
+	repeatable.Get("row:1")
+	repeatable.Scan("row:")
+
+	// Another writer changes, deletes and adds rows
+	store.Put("row:1", []byte("changed"))
+	store.Delete("row:2")
+	store.Put("row:3", []byte("c"))
+
+	if val, _ := committed.Get("row:1"); string(val) != "changed" {
+		t.Errorf("Read committed saw %q, want the committed value", val)
+	}
+	if val, _ := repeatable.Get("row:1"); string(val) != "a" {
+		t.Errorf("Repeatable read saw %q, want the first value read", val)
+	}
+
+	rows, err := repeatable.Scan("row:")
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if string(rows["row:1"]) != "a" || string(rows["row:2"]) != "b" {
+		t.Errorf("Repeatable read scan = %v, want the rows read first", rows)
+	}
+	if _, ok := rows["row:3"]; !ok {
+		t.Error("Expected the new row to appear as a phantom")
+	}
+	if err := repeatable.Commit(); err != nil {
+		t.Errorf("Repeatable read commit failed: %v", err)
+	}
+}
+
+func TestTransactionSerializableConflict(t *testing.T) {
+	store, cleanup := setupTestEngine(t)
+	defer cleanup()
+
+	store.Put("row:1", []byte("a"))
+
+	// A transaction whose reads are unchanged commits
+	tx := NewTransactionWithOptions(store, TxOptions{Isolation: IsolationSerializable})
+	tx.Get("row:1")
+	tx.Put("row:2", []byte("b"))
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	// A changed read fails the commit and discards the writes
+	tx = NewTransactionWithOptions(store, TxOptions{Isolation: IsolationSerializable})
+	tx.Get("row:1")
+	tx.Put("row:3", []byte("c"))
+	store.Put("row:1", []byte("changed"))
+	if err := tx.Commit(); err != ErrSerializationFailure {
+		t.Fatalf("Expected ErrSerializationFailure, got %v", err)
+	}
+	if _, err := store.Get("row:3"); err != ErrNotFound {
+		t.Error("Expected the failed transaction's write to be discarded")
+	}
+
+	// So does a row added to a range the transaction scanned
+	tx = NewTransactionWithOptions(store, TxOptions{Isolation: IsolationSerializable})
+	tx.Scan("row:")
+	store.Put("row:4", []byte("d"))
+	if err := tx.Commit(); err != ErrSerializationFailure {
+		t.Fatalf("Expected ErrSerializationFailure for a phantom, got %v", err)
+	}
+}
@@ -76,13 +76,24 @@ can safely call Write() concurrently.
 Durability Considerations:
 ==========================
 
-Currently, the WAL does not call fsync() after each write. This means
-that in the event of a power failure, some recent writes may be lost.
-A production system would offer configurable durability levels:
-
-  - fsync after every write (safest, slowest)
-  - fsync periodically (balanced)
-  - no fsync (fastest, least durable)
+Write hands the record to the OS before it returns, so a process crash
+never loses it. How long a caller then waits for the record to be fsynced
+is chosen per commit with a SyncPolicy (the synchronous_commit setting):
+
+  - always: Commit blocks until the record is fsynced
+  - batch(interval): a background flusher fsyncs within the interval
+  - off: Commit returns at once; fsync happens at the next checkpoint,
+    explicit Sync or commit of another session, so only an OS crash or
+    power loss can lose the record
+
+Group Commit:
+=============
+
+Under "always", committers do not each issue their own fsync. The first
+committer to find no fsync in progress becomes the leader and fsyncs once
+for every record written so far. Committers that write meanwhile wait; the
+next leader fsyncs for all of them together. Under load this turns N fsyncs
+into a few.
 */
 package storage
 
@@ -95,6 +106,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 )
 
 // wrapPathError wraps a path-related error with helpful context.
@@ -120,6 +132,11 @@ const (
 	// OpDelete represents a Delete operation in the WAL.
 	// The record contains only the key (value is empty).
 	OpDelete byte = 2
+
+	// OpBatch represents several Put and Delete operations that are
+	// replayed together or not at all. The key is empty and the value
+	// holds the operations, each encoded like a record.
+	OpBatch byte = 3
 )
 
 // WAL file header constants.
@@ -140,6 +157,9 @@ const (
 	WALFlagCompressed  byte = 0x02 // Bit 1: compression enabled
 )
 
+// ErrWALClosed is returned when writing to a closed WAL.
+var ErrWALClosed = errors.New("WAL is closed")
+
 // ErrEncryptionMismatch is returned when trying to open an encrypted database
 // without encryption enabled, or vice versa.
 var ErrEncryptionMismatch = errors.New("encryption configuration mismatch")
@@ -213,9 +233,32 @@ type WAL struct {
 	// file is the underlying WAL file handle.
 	file *os.File
 
-	// mu protects concurrent writes to the WAL.
+	// mu protects concurrent writes to the WAL and the group commit state.
 	mu sync.Mutex
 
+	// written is the file offset up to which records have been written,
+	// synced the offset up to which the file has been fsynced.
+	written int64
+	synced  int64
+
+	// syncing is set while a leader fsyncs outside mu; syncsDone is
+	// signalled when it finishes.
+	syncing   bool
+	syncsDone *sync.Cond
+
+	// syncDeadline is when the background flusher must next fsync on behalf
+	// of batch committers. Zero means no fsync is due.
+	syncDeadline time.Time
+
+	// err is the first write or fsync failure; once set, every later commit
+	// fails because the written records may be lost.
+	err error
+
+	closed bool
+	kick   chan struct{} // wakes the background flusher
+	stop   chan struct{}
+	done   chan struct{}
+
 	// encryptor handles encryption/decryption of WAL entries.
 	// If nil, encryption is disabled.
 	encryptor *Encryptor
@@ -343,7 +386,24 @@ func OpenWALWithEncryption(path string, config EncryptionConfig) (*WAL, error) {
 		}
 	}
 
-	return &WAL{file: f, encryptor: encryptor}, nil
+	stat, err = f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat database file: %w", err)
+	}
+
+	w := &WAL{
+		file:      f,
+		encryptor: encryptor,
+		written:   stat.Size(),
+		synced:    stat.Size(),
+		kick:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	w.syncsDone = sync.NewCond(&w.mu)
+	go w.runFlusher()
+	return w, nil
 }
 
 // writeWALHeader writes the WAL file header.
@@ -446,8 +506,9 @@ func (w *WAL) IsEncrypted() bool {
 	return w.encryptor != nil
 }
 
-// Write appends an operation to the WAL file.
-// This method is thread-safe and blocks until the write completes.
+// Write appends an operation to the WAL.
+// The record is written to the file before Write returns; use Commit or
+// Sync to wait for it to be fsynced.
 //
 // Unencrypted Record Format:
 //
@@ -466,17 +527,9 @@ func (w *WAL) IsEncrypted() bool {
 //   - key: The key for this operation
 //   - value: The value (can be nil for Delete operations)
 //
-// Returns an error if the write fails.
+// Returns an error if the record cannot be encoded or written, or a
+// previous write or fsync failed.
 func (w *WAL) Write(op byte, key string, value []byte) error {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
-	// CRITICAL: Always seek to end before writing.
-	// Other methods like ReplayWithPosition move the file pointer.
-	if _, err := w.file.Seek(0, io.SeekEnd); err != nil {
-		return fmt.Errorf("failed to seek to end of WAL: %w", err)
-	}
-
 	// Calculate buffer size: Op(1) + KeyLen(4) + Key + ValueLen(4) + Value
 	buf := make([]byte, 1+4+len(key)+4+len(value))
 
@@ -499,49 +552,202 @@ func (w *WAL) Write(op byte, key string, value []byte) error {
 			return err
 		}
 
-		// Write encrypted record with length prefix
-		encBuf := make([]byte, 4+len(encrypted))
-		binary.BigEndian.PutUint32(encBuf, uint32(len(encrypted)))
-		copy(encBuf[4:], encrypted)
+		// Encrypted record with length prefix
+		buf = make([]byte, 4+len(encrypted))
+		binary.BigEndian.PutUint32(buf, uint32(len(encrypted)))
+		copy(buf[4:], encrypted)
+	}
 
-		_, err = w.file.Write(encBuf)
-		return err
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrWALClosed
+	}
+	if w.err != nil {
+		return w.err
 	}
 
-	// Write the entire record atomically.
-	_, err := w.file.Write(buf)
-	return err
+	if _, err := w.file.WriteAt(buf, w.written); err != nil {
+		w.err = fmt.Errorf("WAL write failed: %w", err)
+		return w.err
+	}
+	w.written += int64(len(buf))
+	return nil
+}
+
+// Commit waits until every record written so far is as durable as policy
+// requires. Under SyncAlways concurrent callers share fsyncs; under SyncBatch
+// the background flusher fsyncs within the interval, and under SyncOff
+// nothing is waited for.
+func (w *WAL) Commit(policy SyncPolicy) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch policy.Mode {
+	case SyncAlways:
+		return w.syncLocked(w.written)
+	case SyncBatch:
+		if w.err == nil && w.synced < w.written {
+			deadline := time.Now().Add(policy.batchInterval())
+			if w.syncDeadline.IsZero() || deadline.Before(w.syncDeadline) {
+				w.syncDeadline = deadline
+				w.wakeFlusher()
+			}
+		}
+	}
+	return w.err
+}
+
+// syncLocked fsyncs the log up to at least target. Only one goroutine, the
+// leader, fsyncs at a time; its fsync covers every record written before it
+// started, including those of goroutines waiting behind it, and it releases
+// mu meanwhile so that writers are not held up.
+// The caller must hold mu.
+func (w *WAL) syncLocked(target int64) error {
+	for {
+		if w.err != nil {
+			return w.err
+		}
+		if w.synced >= target {
+			return nil
+		}
+		if w.syncing {
+			w.syncsDone.Wait()
+			continue
+		}
+
+		w.syncing = true
+		upto := w.written
+		if !w.syncDeadline.IsZero() {
+			// This fsync also covers any batch committers waiting for one
+			w.syncDeadline = time.Time{}
+		}
+		w.mu.Unlock()
+		err := w.file.Sync()
+		w.mu.Lock()
+
+		w.syncing = false
+		if err != nil {
+			w.err = fmt.Errorf("WAL fsync failed: %w", err)
+		} else if upto > w.synced {
+			w.synced = upto
+		}
+		w.syncsDone.Broadcast()
+	}
+}
+
+// wakeFlusher nudges the background flusher to recompute its next deadline.
+// The caller must hold mu.
+func (w *WAL) wakeFlusher() {
+	select {
+	case w.kick <- struct{}{}:
+	default:
+	}
+}
+
+// runFlusher is the background flusher. It fsyncs for batch committers
+// when their deadline is due.
+func (w *WAL) runFlusher() {
+	defer close(w.done)
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		w.mu.Lock()
+		if !w.syncDeadline.IsZero() && !time.Now().Before(w.syncDeadline) {
+			w.syncDeadline = time.Time{}
+			w.syncLocked(w.written)
+		}
+
+		wait := time.Hour
+		if !w.syncDeadline.IsZero() {
+			wait = time.Until(w.syncDeadline)
+		}
+		w.mu.Unlock()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(max(wait, 0))
+
+		select {
+		case <-w.stop:
+			return
+		case <-w.kick:
+		case <-timer.C:
+		}
+	}
 }
 
-// Sync flushes all pending writes to the underlying storage.
+// Sync fsyncs all written records.
 // This ensures durability by forcing the OS to write buffered data to disk.
 //
 // Returns an error if the sync fails.
 func (w *WAL) Sync() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	return w.file.Sync()
+	return w.syncLocked(w.written)
 }
 
-// Close closes the underlying WAL file.
+// Close fsyncs written records, stops the background flusher and closes
+// the underlying WAL file.
 // After Close is called, no other methods should be called on this WAL.
 //
-// Returns an error if the file cannot be closed.
+// Returns an error if the final flush fails or the file cannot be closed.
 func (w *WAL) Close() error {
-	return w.file.Close()
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.stop)
+	<-w.done
+
+	w.mu.Lock()
+	err := w.syncLocked(w.written)
+	w.mu.Unlock()
+
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
 }
 
-// Size returns the current size of the WAL file in bytes.
-// This is used for replication - followers track their offset
-// and request records from that position.
+// Size returns the current size of the WAL file in bytes, which covers
+// every record written so far. This is used for replication - followers
+// track their offset and request records from that position.
 //
-// Returns the file size in bytes, or an error if the stat fails.
+// Returns the file size in bytes, or the error of a failed write.
 func (w *WAL) Size() (int64, error) {
-	info, err := w.file.Stat()
-	if err != nil {
-		return 0, err
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.written, nil
+}
+
+// readerFrom returns a reader over the file from offset up to the current
+// end of the log. Reading through a section keeps concurrent appends out of
+// the replay.
+func (w *WAL) readerFrom(offset int64) (*io.SectionReader, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return nil, w.err
+	}
+	if offset > w.written {
+		offset = w.written
 	}
-	return info.Size(), nil
+	return io.NewSectionReader(w.file, offset, w.written-offset), nil
 }
 
 // Replay reads the WAL from startOffset and invokes fn for each record found.
@@ -578,11 +784,11 @@ func (w *WAL) Replay(startOffset int64, fn func(op byte, key string, value []byt
 		actualOffset = WALHeaderSize
 	}
 
-	// Seek to the starting position.
-	if _, err := w.file.Seek(actualOffset, io.SeekStart); err != nil {
-		return fmt.Errorf("failed to seek in WAL: %w", err)
+	section, err := w.readerFrom(actualOffset)
+	if err != nil {
+		return err
 	}
-	reader := bufio.NewReader(w.file)
+	reader := bufio.NewReader(section)
 
 	// Use encrypted or unencrypted replay based on configuration
 	if w.encryptor != nil {
@@ -696,42 +902,39 @@ func (w *WAL) replayEncrypted(reader *bufio.Reader, fn func(op byte, key string,
 //   - newOffset: The file position after reading all available records
 //   - err: Error if reading fails (EOF is not an error)
 func (w *WAL) ReplayWithPosition(startOffset int64, fn func(op byte, key string, value []byte)) (int64, error) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
 	// Adjust offset to account for header if starting from beginning
 	actualOffset := startOffset
 	if startOffset == 0 {
 		actualOffset = WALHeaderSize
 	}
 
-	// Seek to the starting position
-	if _, err := w.file.Seek(actualOffset, io.SeekStart); err != nil {
-		return startOffset, fmt.Errorf("failed to seek in WAL: %w", err)
+	section, err := w.readerFrom(actualOffset)
+	if err != nil {
+		return startOffset, err
 	}
+	reader := bufio.NewReader(section)
 
 	// Track current position
 	currentPos := actualOffset
 
 	// Use encrypted or unencrypted replay based on configuration
-	var err error
 	if w.encryptor != nil {
-		currentPos, err = w.replayEncryptedWithPosition(currentPos, fn)
+		currentPos, err = w.replayEncryptedWithPosition(reader, currentPos, fn)
 	} else {
-		currentPos, err = w.replayUnencryptedWithPosition(currentPos, fn)
+		currentPos, err = w.replayUnencryptedWithPosition(reader, currentPos, fn)
 	}
 
 	return currentPos, err
 }
 
 // replayUnencryptedWithPosition reads unencrypted WAL records and tracks position.
-func (w *WAL) replayUnencryptedWithPosition(startPos int64, fn func(op byte, key string, value []byte)) (int64, error) {
+func (w *WAL) replayUnencryptedWithPosition(reader *bufio.Reader, startPos int64, fn func(op byte, key string, value []byte)) (int64, error) {
 	currentPos := startPos
 
 	for {
 		// Read Operation Byte
 		opBuf := make([]byte, 1)
-		n, err := w.file.Read(opBuf)
+		n, err := reader.Read(opBuf)
 		if err == io.EOF {
 			break
 		}
@@ -743,28 +946,28 @@ func (w *WAL) replayUnencryptedWithPosition(startPos int64, fn func(op byte, key
 
 		// Read Key Length
 		var keyLen uint32
-		if err := binary.Read(w.file, binary.BigEndian, &keyLen); err != nil {
+		if err := binary.Read(reader, binary.BigEndian, &keyLen); err != nil {
 			return currentPos, err
 		}
 		currentPos += 4
 
 		// Read Key
 		keyBuf := make([]byte, keyLen)
-		if _, err := io.ReadFull(w.file, keyBuf); err != nil {
+		if _, err := io.ReadFull(reader, keyBuf); err != nil {
 			return currentPos, err
 		}
 		currentPos += int64(keyLen)
 
 		// Read Value Length
 		var valLen uint32
-		if err := binary.Read(w.file, binary.BigEndian, &valLen); err != nil {
+		if err := binary.Read(reader, binary.BigEndian, &valLen); err != nil {
 			return currentPos, err
 		}
 		currentPos += 4
 
 		// Read Value
 		valBuf := make([]byte, valLen)
-		if _, err := io.ReadFull(w.file, valBuf); err != nil {
+		if _, err := io.ReadFull(reader, valBuf); err != nil {
 			return currentPos, err
 		}
 		currentPos += int64(valLen)
@@ -776,13 +979,13 @@ func (w *WAL) replayUnencryptedWithPosition(startPos int64, fn func(op byte, key
 }
 
 // replayEncryptedWithPosition reads encrypted WAL records and tracks position.
-func (w *WAL) replayEncryptedWithPosition(startPos int64, fn func(op byte, key string, value []byte)) (int64, error) {
+func (w *WAL) replayEncryptedWithPosition(reader *bufio.Reader, startPos int64, fn func(op byte, key string, value []byte)) (int64, error) {
 	currentPos := startPos
 
 	for {
 		// Read encrypted record length (4 bytes)
 		var encLen uint32
-		if err := binary.Read(w.file, binary.BigEndian, &encLen); err != nil {
+		if err := binary.Read(reader, binary.BigEndian, &encLen); err != nil {
 			if err == io.EOF {
 				break
 			}
@@ -792,7 +995,7 @@ func (w *WAL) replayEncryptedWithPosition(startPos int64, fn func(op byte, key s
 
 		// Read encrypted payload
 		encBuf := make([]byte, encLen)
-		if _, err := io.ReadFull(w.file, encBuf); err != nil {
+		if _, err := io.ReadFull(reader, encBuf); err != nil {
 			return currentPos, err
 		}
 		currentPos += int64(encLen)
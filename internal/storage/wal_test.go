@@ -19,9 +19,13 @@ package storage
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func setupTestWAL(t *testing.T) (*WAL, string, func()) {
@@ -604,3 +608,328 @@ func TestWALHeaderEncryptedFlag(t *testing.T) {
 		t.Errorf("Expected flags %d for encrypted, got %d", WALFlagEncrypted, header[5])
 	}
 }
+
+func TestParseSyncPolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    SyncPolicy
+		wantErr bool
+	}{
+		{"always", SyncPolicy{Mode: SyncAlways}, false},
+		{"ON", SyncPolicy{Mode: SyncAlways}, false},
+		{"off", SyncPolicy{Mode: SyncOff}, false},
+		{"batch", SyncPolicy{Mode: SyncBatch, Interval: DefaultSyncBatchInterval}, false},
+		{"batch(5ms)", SyncPolicy{Mode: SyncBatch, Interval: 5 * time.Millisecond}, false},
+		{"batch(0s)", SyncPolicy{}, true},
+		{"batch(soon)", SyncPolicy{}, true},
+		{"sometimes", SyncPolicy{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSyncPolicy(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSyncPolicy(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseSyncPolicy(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+		if !tt.wantErr {
+			if again, err := ParseSyncPolicy(got.String()); err != nil || again != got {
+				t.Errorf("String() of %q does not round-trip: %q", tt.input, got.String())
+			}
+		}
+	}
+}
+
+func TestWALGroupCommit(t *testing.T) {
+	wal, walPath, cleanup := setupTestWAL(t)
+	defer cleanup()
+
+	const writers, perWriter = 8, 50
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < perWriter; j++ {
+				key := fmt.Sprintf("w%d:k%d", id, j)
+				if err := wal.Write(OpPut, key, []byte("value")); err != nil {
+					errs <- err
+					return
+				}
+				if err := wal.Commit(SyncPolicy{Mode: SyncAlways}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Write/Commit failed: %v", err)
+	}
+
+	// Committed records are on disk without any further flush
+	info, err := os.Stat(walPath)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	size, _ := wal.Size()
+	if info.Size() != size {
+		t.Errorf("Expected committed size %d on disk, got %d", size, info.Size())
+	}
+
+	count := 0
+	if err := wal.Replay(0, func(op byte, key string, value []byte) { count++ }); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if count != writers*perWriter {
+		t.Errorf("Expected %d records, got %d", writers*perWriter, count)
+	}
+}
+
+func TestWALBatchAndOffCommit(t *testing.T) {
+	wal, walPath, cleanup := setupTestWAL(t)
+	defer cleanup()
+
+	if err := wal.Write(OpPut, "batched", []byte("value")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := wal.Commit(SyncPolicy{Mode: SyncBatch, Interval: 5 * time.Millisecond}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	// The background flusher writes and fsyncs the record within the interval
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		wal.mu.Lock()
+		synced, end := wal.synced, wal.written
+		wal.mu.Unlock()
+		if synced == end {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Batch commit not synced: synced=%d end=%d", synced, end)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// With "off" the record is not fsynced, but it is in the file at once,
+	// so a process crash cannot lose it
+	if err := wal.Write(OpPut, "unsynced", []byte("value")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := wal.Commit(SyncPolicy{Mode: SyncOff}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	info, err := os.Stat(walPath)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if size, _ := wal.Size(); info.Size() != size {
+		t.Errorf("Expected unsynced record in the file: size %d, file %d", size, info.Size())
+	}
+	var keys []string
+	if err := wal.Replay(0, func(op byte, key string, value []byte) { keys = append(keys, key) }); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if len(keys) != 2 || keys[1] != "unsynced" {
+		t.Errorf("Expected both records on replay, got %v", keys)
+	}
+
+	// Close makes everything durable
+	wal.Close()
+	reopened, err := OpenWAL(walPath)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	count := 0
+	reopened.Replay(0, func(op byte, key string, value []byte) { count++ })
+	if count != 2 {
+		t.Errorf("Expected 2 records after reopen, got %d", count)
+	}
+}
+
+// fillEngine writes n rows split across two tables and deletes every tenth
+// row of the first table. It returns the number of live rows per table.
+func fillEngine(t *testing.T, engine Engine, n int) (int, int) {
+	t.Helper()
+	value := []byte(strings.Repeat("v", 100))
+	users, orders := 0, 0
+	for i := 0; i < n; i++ {
+		if err := engine.Put(fmt.Sprintf("row:users:%06d", i), value); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		if err := engine.Put(fmt.Sprintf("row:orders:%06d", i), value); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		users++
+		orders++
+	}
+	for i := 0; i < n; i += 10 {
+		if err := engine.Delete(fmt.Sprintf("row:users:%06d", i)); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		users--
+	}
+	return users, orders
+}
+
+// copyDataFiles copies the files of src to dst as they are on disk, which is
+// what a restart after a crash would find.
+func copyDataFiles(t *testing.T, src, dst string) {
+	t.Helper()
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		t.Fatalf("Failed to read data dir: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(src, entry.Name()))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", entry.Name(), err)
+		}
+		if err := os.WriteFile(filepath.Join(dst, entry.Name()), data, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", entry.Name(), err)
+		}
+	}
+}
+
+func TestWALReplayAfterCrash(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "flydb-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	liveDir := filepath.Join(tmpDir, "live")
+	crashDir := filepath.Join(tmpDir, "crash")
+	os.Mkdir(liveDir, 0755)
+	os.Mkdir(crashDir, 0755)
+
+	// A small pool makes both the heap and the directory write pages back
+	// between checkpoints
+	config := StorageConfig{DataDir: liveDir, BufferPoolSize: 64, SyncPolicy: SyncPolicy{Mode: SyncOff}}
+	engine, err := NewStorageEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create storage engine: %v", err)
+	}
+	defer engine.Close()
+	users, orders := fillEngine(t, engine, 1000)
+	if err := engine.DiskEngine().Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+
+	// Writes after the checkpoint grow, update and shrink the directory;
+	// long keys make it outgrow its pool
+	pad := strings.Repeat("k", 200)
+	for i := 0; i < 5000; i++ {
+		if err := engine.Put(fmt.Sprintf("row:items:%06d:%s", i, pad), []byte("item")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if err := engine.Put("row:users:000001", []byte("updated")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	for i := 0; i < 1000; i += 7 {
+		if err := engine.Delete(fmt.Sprintf("row:orders:%06d", i)); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		orders--
+	}
+	if err := engine.WAL().Sync(); err != nil {
+		t.Fatalf("WAL sync failed: %v", err)
+	}
+	copyDataFiles(t, liveDir, crashDir)
+
+	config.DataDir = crashDir
+	recovered, err := NewStorageEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to recover storage engine: %v", err)
+	}
+	defer recovered.Close()
+
+	// A rebuild would have replayed the WAL from the start
+	if recovered.DiskEngine().RecoveryOffset() == 0 {
+		t.Error("Expected the key directory to be rolled back, not rebuilt")
+	}
+	if got := recovered.Stats().KeyCount; got != int64(users+orders+5000) {
+		t.Errorf("Expected %d keys, got %d", users+orders+5000, got)
+	}
+	for prefix, want := range map[string]int{"row:users:": users, "row:orders:": orders, "row:items:": 5000} {
+		if rows, err := recovered.Scan(prefix); err != nil || len(rows) != want {
+			t.Errorf("Expected %d rows under %s, got %d (%v)", want, prefix, len(rows), err)
+		}
+	}
+	if value, err := recovered.Get("row:users:000001"); err != nil || string(value) != "updated" {
+		t.Errorf("Expected updated value, got %q (%v)", value, err)
+	}
+	if _, err := recovered.Get("row:orders:000007"); err != ErrNotFound {
+		t.Errorf("Expected deleted order to be missing, got %v", err)
+	}
+}
+
+func TestApplyCommittedAtSurvivesCrash(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "flydb-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	liveDir := filepath.Join(tmpDir, "live")
+	crashDir := filepath.Join(tmpDir, "crash")
+	os.Mkdir(liveDir, 0755)
+	os.Mkdir(crashDir, 0755)
+
+	config := StorageConfig{DataDir: liveDir, SyncPolicy: SyncPolicy{Mode: SyncOff}}
+	engine, err := NewStorageEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create storage engine: %v", err)
+	}
+	defer engine.Close()
+	for index, value := range []string{"a", "b", "c"} {
+		if err := engine.ApplyCommittedAt(uint64(index+1), []TxOperation{{Op: OpPut, Key: "k", Value: []byte(value)}}); err != nil {
+			t.Fatalf("ApplyCommittedAt failed: %v", err)
+		}
+	}
+	batch := []TxOperation{{Op: OpDelete, Key: "gone"}, {Op: OpPut, Key: "k2", Value: []byte("x")}}
+	if err := engine.ApplyCommittedAt(4, batch); err != nil {
+		t.Fatalf("ApplyCommittedAt failed: %v", err)
+	}
+	if err := engine.WAL().Sync(); err != nil {
+		t.Fatalf("WAL sync failed: %v", err)
+	}
+	copyDataFiles(t, liveDir, crashDir)
+
+	config.DataDir = crashDir
+	recovered, err := NewStorageEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to recover storage engine: %v", err)
+	}
+	defer recovered.Close()
+
+	if applied, err := recovered.RaftAppliedIndex(); err != nil || applied != 4 {
+		t.Fatalf("Expected applied index 4 after recovery, got %d (%v)", applied, err)
+	}
+	if value, err := recovered.Get("k2"); err != nil || string(value) != "x" {
+		t.Errorf("Expected the batched write after recovery, got %q (%v)", value, err)
+	}
+
+	// Replayed entries are skipped; new ones are applied
+	if err := recovered.ApplyCommittedAt(2, []TxOperation{{Op: OpPut, Key: "k", Value: []byte("b")}}); err != nil {
+		t.Fatalf("ApplyCommittedAt failed: %v", err)
+	}
+	if value, err := recovered.Get("k"); err != nil || string(value) != "c" {
+		t.Errorf("Expected a replayed entry to be skipped, got %q (%v)", value, err)
+	}
+	if err := recovered.ApplyCommittedAt(5, []TxOperation{{Op: OpPut, Key: "k", Value: []byte("d")}}); err != nil {
+		t.Fatalf("ApplyCommittedAt failed: %v", err)
+	}
+	if value, err := recovered.Get("k"); err != nil || string(value) != "d" {
+		t.Errorf("Expected the next entry to be applied, got %q (%v)", value, err)
+	}
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package flydb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"flydb/internal/literal"
+)
+
+// placeholder is a $n placeholder found in a query.
+type placeholder struct {
+	start, end int // Byte offsets of the placeholder
+	index      int // n
+}
+
+// placeholders returns the placeholders of query, skipping string
+// literals, quoted identifiers and comments.
+func placeholders(query string) []placeholder {
+	var found []placeholder
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'' || c == '"':
+			// Doubled quotes inside a literal end it and start it again
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				return found
+			}
+			i += end + 1
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return found
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return found
+			}
+			i += end + 3
+		case c == '$':
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			if j == i+1 {
+				continue
+			}
+			index, err := strconv.Atoi(query[i+1 : j])
+			if err != nil {
+				continue
+			}
+			found = append(found, placeholder{start: i, end: j, index: index})
+			i = j - 1
+		}
+	}
+	return found
+}
+
+// numInput returns the highest placeholder number of query.
+func numInput(query string) int {
+	n := 0
+	for _, p := range placeholders(query) {
+		if p.index > n {
+			n = p.index
+		}
+	}
+	return n
+}
+
+// bind substitutes args, as SQL literals, for the placeholders of query.
+func bind(query string, args []driver.Value) (string, error) {
+	literals := make([]string, len(args))
+	for i, arg := range args {
+		literal, err := formatValue(arg)
+		if err != nil {
+			return "", fmt.Errorf("argument $%d: %w", i+1, err)
+		}
+		literals[i] = literal
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, p := range placeholders(query) {
+		if p.index < 1 || p.index > len(args) {
+			return "", fmt.Errorf("flydb: placeholder $%d has no argument (%d given)", p.index, len(args))
+		}
+		b.WriteString(query[last:p.start])
+		b.WriteString(literals[p.index-1])
+		last = p.end
+	}
+	b.WriteString(query[last:])
+	return b.String(), nil
+}
+
+// formatValue formats a driver value as an SQL literal.
+func formatValue(v driver.Value) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "NULL", nil
+	case int64:
+		return formatNumber(strconv.FormatInt(v, 10)), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", fmt.Errorf("flydb: cannot bind %v", v)
+		}
+		return formatNumber(strconv.FormatFloat(v, 'g', -1, 64)), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case string:
+		return literal.Quote(v), nil
+	case []byte:
+		return literal.Quote(string(v)), nil
+	case time.Time:
+		return literal.Quote(v.Format(time.RFC3339Nano)), nil
+	default:
+		return "", fmt.Errorf("flydb: unsupported argument type %T", v)
+	}
+}
+
+// formatNumber inlines a formatted number when the lexer reads it as one,
+// and quotes it otherwise: a bare -5 after a minus sign would start a
+// comment, and 1e+21 is not a number to the lexer.
+func formatNumber(s string) string {
+	if literal.IsNumber(s) {
+		return s
+	}
+	return literal.Quote(s)
+}
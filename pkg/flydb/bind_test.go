@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package flydb
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		args  []driver.Value
+		want  string
+	}{
+		{
+			name:  "values",
+			query: "INSERT INTO t VALUES ($1, $2, $3, $4, $5)",
+			args:  []driver.Value{int64(1), 2.5, true, nil, "it's"},
+			want:  "INSERT INTO t VALUES (1, 2.5, TRUE, NULL, 'it''s')",
+		},
+		{
+			name:  "reused and out of order",
+			query: "SELECT * FROM t WHERE a = $2 OR b = $1 OR c = $2",
+			args:  []driver.Value{"x", int64(10)},
+			want:  "SELECT * FROM t WHERE a = 10 OR b = 'x' OR c = 10",
+		},
+		{
+			name:  "two digit placeholders",
+			query: "SELECT $1, $10",
+			args:  []driver.Value{int64(1), int64(2), int64(3), int64(4), int64(5), int64(6), int64(7), int64(8), int64(9), int64(10)},
+			want:  "SELECT 1, 10",
+		},
+		{
+			name:  "quoted and commented",
+			query: "SELECT '$1', \"$1\", $1 -- $1\n/* $1 */ FROM t WHERE s = 'it''s $1'",
+			args:  []driver.Value{int64(5)},
+			want:  "SELECT '$1', \"$1\", 5 -- $1\n/* $1 */ FROM t WHERE s = 'it''s $1'",
+		},
+		{
+			name:  "negative and exponent",
+			query: "SELECT a-$1, $2, $3 FROM t",
+			args:  []driver.Value{int64(-5), 1e21, -0.5},
+			want:  "SELECT a-'-5', '1e+21', '-0.5' FROM t",
+		},
+		{
+			name:  "time",
+			query: "SELECT $1",
+			args:  []driver.Value{time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+			want:  "SELECT '2026-01-02T03:04:05Z'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bind(tt.query, tt.args)
+			if err != nil {
+				t.Fatalf("bind failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("bind returned %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := bind("SELECT $2", []driver.Value{int64(1)}); err == nil {
+		t.Error("bind accepted a placeholder without an argument")
+	}
+	if n := numInput("SELECT $3, '$4', $1"); n != 3 {
+		t.Errorf("numInput returned %d, want 3", n)
+	}
+}
@@ -0,0 +1,527 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package flydb is the Go client for FlyDB's binary protocol.
+
+It can be used directly, through Conn, or as a database/sql driver
+registered under the name "flydb":
+
+	db, err := sql.Open("flydb", "jdbc:flydb://localhost:8889/mydb?user=admin&password=secret")
+	rows, err := db.QueryContext(ctx, "SELECT id, name FROM users WHERE id = $1", 42)
+
+Data source names use the ODBC and JDBC connection string formats:
+
+	Server=localhost;Port=8889;Database=mydb;Uid=admin;Pwd=secret
+	jdbc:flydb://localhost:8889/mydb?user=admin&password=secret
+
+Both also accept the tls and multiplex options. With multiplex=true all
+the connections of a sql.DB (or of a Connector) are streams of one
+multiplexed TCP connection, so a process holds one socket per server
+instead of one per session.
+
+Parameters:
+
+Placeholders are written $1, $2, ... as in the server's SQL dialect. The
+client substitutes the arguments as SQL literals before sending a statement,
+so a statement is one round trip whether or not it has parameters.
+
+Cancellation:
+
+When the context of a running statement is canceled, the client sends a
+cancel request with the session's cancel key on a separate connection (or
+stream) and returns the context's error.
+*/
+package flydb
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"flydb/internal/compression"
+	"flydb/internal/protocol"
+	"flydb/internal/sdk"
+)
+
+// cancelGrace is how long a canceled statement may take to end before its
+// connection is abandoned.
+const cancelGrace = 5 * time.Second
+
+// Config describes how to connect to a FlyDB server.
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Database string // Initial database (empty for the default)
+
+	// ConnectTimeout bounds dialing and authentication (0 = no limit
+	// beyond the context's).
+	ConnectTimeout time.Duration
+
+	// TLSConfig enables TLS when set.
+	TLSConfig *tls.Config
+
+	// Compression lists the wire compression algorithms to offer,
+	// preferred first (see protocol.NegotiateCompression).
+	Compression []string
+
+	// Multiplex makes the sessions of a Connector share one connection.
+	Multiplex bool
+
+	// ReadOnly starts sessions in read-only mode.
+	ReadOnly bool
+
+	// StatementTimeout sets the session's statement_timeout (0 = none).
+	StatementTimeout time.Duration
+}
+
+// ParseDSN parses an ODBC or JDBC connection string into a Config.
+func ParseDSN(dsn string) (*Config, error) {
+	cc, err := sdk.ParseConnectionString(dsn)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{
+		Host:      cc.Host,
+		Port:      cc.Port,
+		Username:  cc.Username,
+		Password:  cc.Password,
+		Database:  cc.Database,
+		Multiplex: cc.Multiplex,
+		ReadOnly:  cc.ReadOnly,
+	}
+	if cc.ConnectTimeout > 0 {
+		cfg.ConnectTimeout = time.Duration(cc.ConnectTimeout) * time.Second
+	}
+	if cc.TLS {
+		cfg.TLSConfig = &tls.Config{ServerName: cc.Host}
+	}
+	return cfg, nil
+}
+
+// address returns the host:port of the server.
+func (cfg *Config) address() string {
+	host := cfg.Host
+	if host == "" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(cfg.Port))
+}
+
+// Result is the result of a statement.
+type Result struct {
+	Message      string
+	Columns      []string
+	ColumnTypes  []string
+	Rows         [][]interface{}
+	RowsAffected int64
+	LastInsertID int64
+}
+
+// IsolationLevel is a transaction isolation level.
+type IsolationLevel int
+
+// Isolation levels, in the server's numbering.
+const (
+	ReadUncommitted IsolationLevel = iota
+	ReadCommitted
+	RepeatableRead
+	Serializable
+)
+
+// TxOptions are the options of a transaction.
+type TxOptions struct {
+	Isolation IsolationLevel
+	ReadOnly  bool
+}
+
+// Conn is an authenticated session with a FlyDB server. Its methods may be
+// called from several goroutines, but they run one at a time.
+type Conn struct {
+	cfg *Config
+
+	// conn is a TCP or TLS connection, or a stream of a multiplexed one.
+	conn        net.Conn
+	reader      *bufio.Reader
+	writer      *bufio.Writer
+	frames      *protocol.FrameWriter
+	compression *protocol.Compression // nil unless negotiated
+
+	// openCancel opens the connection cancel requests are sent on.
+	openCancel func(ctx context.Context) (net.Conn, error)
+	cancelKey  string
+	database   string
+
+	mu     sync.Mutex
+	broken bool // An I/O error left the connection unusable
+	inTx   bool
+}
+
+// Connect dials the server and opens a session on a connection of its own.
+// Use a Connector to share a multiplexed connection between sessions.
+func Connect(ctx context.Context, cfg *Config) (*Conn, error) {
+	dial := func(ctx context.Context) (net.Conn, error) {
+		return dialServer(ctx, cfg)
+	}
+	conn, err := dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(ctx, cfg, conn, dial)
+}
+
+// dialServer opens a TCP or TLS connection to the server.
+func dialServer(ctx context.Context, cfg *Config) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: cfg.ConnectTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", cfg.address())
+	if err != nil {
+		return nil, err
+	}
+	if cfg.TLSConfig == nil {
+		return conn, nil
+	}
+	tlsConn := tls.Client(conn, cfg.TLSConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// newConn authenticates on conn and applies the session settings of cfg.
+func newConn(ctx context.Context, cfg *Config, conn net.Conn, openCancel func(context.Context) (net.Conn, error)) (*Conn, error) {
+	writer, frames := protocol.NewCompressedWriter(conn)
+	c := &Conn{
+		cfg:        cfg,
+		conn:       conn,
+		reader:     bufio.NewReader(conn),
+		writer:     writer,
+		frames:     frames,
+		openCancel: openCancel,
+	}
+
+	if cfg.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.ConnectTimeout)
+		defer cancel()
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := c.auth(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	if cfg.ReadOnly {
+		if err := c.SetOption(ctx, "read_only", true); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if cfg.StatementTimeout > 0 {
+		if err := c.SetOption(ctx, "statement_timeout", cfg.StatementTimeout.String()); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// auth authenticates the session, asking for binary results and the
+// configured compression.
+func (c *Conn) auth() error {
+	data, err := (&protocol.AuthMessage{
+		Username:    c.cfg.Username,
+		Password:    c.cfg.Password,
+		Database:    c.cfg.Database,
+		Compression: c.cfg.Compression,
+	}).Encode()
+	if err != nil {
+		return err
+	}
+	if err := protocol.WriteMessageVersion(c.writer, protocol.ProtocolVersionBinary, protocol.MsgAuth, data); err != nil {
+		return fmt.Errorf("failed to send auth message: %w", err)
+	}
+	if err := c.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to send auth message: %w", err)
+	}
+
+	msg, err := c.compression.ReadMessage(c.reader)
+	if err != nil {
+		return fmt.Errorf("failed to read auth response: %w", err)
+	}
+	if msg.Header.Type == protocol.MsgError {
+		return newServerError(msg.Payload)
+	}
+	if msg.Header.Type != protocol.MsgAuthResult {
+		return fmt.Errorf("unexpected response type: %d", msg.Header.Type)
+	}
+	result, err := protocol.DecodeAuthResultMessage(msg.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to decode auth result: %w", err)
+	}
+	if !result.Success {
+		return &Error{Code: 401, Message: result.Message}
+	}
+
+	if result.Compression != "" {
+		algorithm, err := compression.ParseAlgorithm(result.Compression)
+		if err != nil {
+			return fmt.Errorf("server chose unsupported compression: %w", err)
+		}
+		c.compression = protocol.NewCompression(algorithm)
+		c.frames.SetCompression(c.compression)
+	}
+	c.cancelKey = result.CancelKey
+	c.database = result.Database
+	return nil
+}
+
+// Query runs a statement with args bound to its placeholders.
+func (c *Conn) Query(ctx context.Context, query string, args ...interface{}) (*Result, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		v, err := driver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument $%d: %w", i+1, err)
+		}
+		values[i] = v
+	}
+	return c.query(ctx, query, values)
+}
+
+// query runs a statement with converted args.
+func (c *Conn) query(ctx context.Context, query string, args []driver.Value) (*Result, error) {
+	if len(args) > 0 {
+		var err error
+		if query, err = bind(query, args); err != nil {
+			return nil, err
+		}
+	}
+	msg, err := c.request(ctx, protocol.MsgQuery, &protocol.QueryMessage{Query: query}, protocol.MsgQueryResult)
+	if err != nil {
+		return nil, err
+	}
+	result, err := protocol.DecodeQueryResult(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode query result: %w", err)
+	}
+	if !result.Success {
+		return nil, &Error{Code: 500, Message: result.Message}
+	}
+	return &Result{
+		Message:      result.Message,
+		Columns:      result.Columns,
+		ColumnTypes:  result.ColumnTypes,
+		Rows:         result.Rows,
+		RowsAffected: result.RowsAffected,
+		LastInsertID: result.LastInsertID,
+	}, nil
+}
+
+// Begin starts a transaction.
+func (c *Conn) Begin(ctx context.Context, opts TxOptions) error {
+	if opts.Isolation < ReadUncommitted || opts.Isolation > Serializable {
+		return fmt.Errorf("flydb: unknown isolation level %d", opts.Isolation)
+	}
+	msg := &protocol.BeginTxMessage{IsolationLevel: int(opts.Isolation), ReadOnly: opts.ReadOnly}
+	if _, err := c.request(ctx, protocol.MsgBeginTx, msg, protocol.MsgTxResult); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.inTx = true
+	c.mu.Unlock()
+	return nil
+}
+
+// Commit commits the transaction.
+func (c *Conn) Commit(ctx context.Context) error {
+	return c.endTx(ctx, protocol.MsgCommitTx)
+}
+
+// Rollback rolls the transaction back.
+func (c *Conn) Rollback(ctx context.Context) error {
+	return c.endTx(ctx, protocol.MsgRollbackTx)
+}
+
+// endTx commits or rolls back the transaction.
+func (c *Conn) endTx(ctx context.Context, msgType protocol.MessageType) error {
+	_, err := c.request(ctx, msgType, emptyMessage{}, protocol.MsgTxResult)
+	c.mu.Lock()
+	c.inTx = false
+	c.mu.Unlock()
+	return err
+}
+
+// InTransaction reports whether a transaction is open.
+func (c *Conn) InTransaction() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.inTx
+}
+
+// SetOption sets a session option such as statement_timeout.
+func (c *Conn) SetOption(ctx context.Context, option string, value interface{}) error {
+	msg := &protocol.SetOptionMessage{Option: option, Value: value}
+	_, err := c.request(ctx, protocol.MsgSetOption, msg, protocol.MsgSessionResult)
+	return err
+}
+
+// Ping checks that the session is alive.
+func (c *Conn) Ping(ctx context.Context) error {
+	_, err := c.request(ctx, protocol.MsgPing, emptyMessage{}, protocol.MsgPong)
+	return err
+}
+
+// Database returns the database the session started in.
+func (c *Conn) Database() string {
+	return c.database
+}
+
+// Close ends the session.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.broken = true
+	return c.conn.Close()
+}
+
+// Broken reports whether the session can no longer be used.
+func (c *Conn) Broken() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.broken
+}
+
+// emptyMessage is the payload of requests that carry none.
+type emptyMessage struct{}
+
+// Encode returns an empty payload.
+func (emptyMessage) Encode() ([]byte, error) { return nil, nil }
+
+// request sends a message and returns the reply, which must be of type
+// want. Server errors are returned as *Error.
+func (c *Conn) request(ctx context.Context, msgType protocol.MessageType, m interface{ Encode() ([]byte, error) }, want protocol.MessageType) (*protocol.Message, error) {
+	payload, err := m.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.broken {
+		return nil, driver.ErrBadConn
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := protocol.WriteMessage(c.writer, msgType, payload); err == nil {
+		err = c.writer.Flush()
+	}
+	if err != nil {
+		c.broken = true
+		return nil, err
+	}
+
+	stop := c.watchCancel(ctx)
+	msg, err := c.compression.ReadMessage(c.reader)
+	canceled := stop()
+	switch {
+	case err != nil:
+		c.broken = true
+		if canceled {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	case msg.Header.Type == protocol.MsgError:
+		if canceled {
+			return nil, ctx.Err()
+		}
+		return nil, newServerError(msg.Payload)
+	case msg.Header.Type != want:
+		return nil, fmt.Errorf("unexpected response type: %d", msg.Header.Type)
+	}
+	return msg, nil
+}
+
+// watchCancel cancels the statement in flight if ctx is done before the
+// returned function is called. That function reports whether it did. If
+// the server does not answer within cancelGrace the read is interrupted.
+func (c *Conn) watchCancel(ctx context.Context) func() bool {
+	if ctx.Done() == nil {
+		return func() bool { return false }
+	}
+
+	done := make(chan struct{})
+	canceled := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.sendCancel()
+			select {
+			case <-done:
+			case <-time.After(cancelGrace):
+				c.conn.SetReadDeadline(time.Now())
+			}
+			canceled <- true
+		case <-done:
+			canceled <- false
+		}
+	}()
+
+	return func() bool {
+		close(done)
+		return <-canceled
+	}
+}
+
+// sendCancel asks the server to cancel the session's running statement.
+func (c *Conn) sendCancel() {
+	if c.cancelKey == "" || c.openCancel == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cancelGrace)
+	defer cancel()
+
+	conn, err := c.openCancel(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	data, err := (&protocol.CancelMessage{CancelKey: c.cancelKey}).Encode()
+	if err != nil {
+		return
+	}
+	if err := protocol.WriteMessage(conn, protocol.MsgCancel, data); err != nil {
+		return
+	}
+	protocol.ReadMessage(conn)
+}
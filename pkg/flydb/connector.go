@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package flydb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"flydb/internal/protocol"
+)
+
+// Connector opens sessions with one configuration. When the configuration
+// asks for multiplexing, the sessions are streams of one shared connection,
+// which is dialed again if it fails.
+//
+// Connector implements driver.Connector, so it can be passed to
+// sql.OpenDB. sql.DB closes it when the DB is closed.
+type Connector struct {
+	cfg *Config
+
+	mu     sync.Mutex
+	mux    *protocol.MultiplexConn // Shared connection (Multiplex only)
+	closed bool
+}
+
+// NewConnector creates a connector for cfg.
+func NewConnector(cfg *Config) *Connector {
+	return &Connector{cfg: cfg}
+}
+
+// Open opens a session.
+func (c *Connector) Open(ctx context.Context) (*Conn, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(ctx, c.cfg, conn, c.dial)
+}
+
+// Connect opens a session for database/sql.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &driverConn{conn: conn}, nil
+}
+
+// Driver returns the flydb driver.
+func (c *Connector) Driver() driver.Driver {
+	return &Driver{}
+}
+
+// Close closes the shared connection, ending the sessions on it.
+func (c *Connector) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.mux == nil {
+		return nil
+	}
+	err := c.mux.Close()
+	c.mux = nil
+	return err
+}
+
+// dial returns a connection for a new session: a connection of its own, or
+// a stream of the shared connection.
+func (c *Connector) dial(ctx context.Context) (net.Conn, error) {
+	if !c.cfg.Multiplex {
+		return dialServer(ctx, c.cfg)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, errors.New("flydb: connector is closed")
+	}
+	if c.mux != nil {
+		stream, err := c.mux.OpenStream()
+		if err != protocol.ErrStreamClosed {
+			return stream, err
+		}
+		c.mux = nil
+	}
+
+	conn, err := dialServer(ctx, c.cfg)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	mux, err := protocol.Multiplex(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	c.mux = mux
+	return mux.OpenStream()
+}
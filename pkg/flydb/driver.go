@@ -0,0 +1,273 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package flydb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func init() {
+	sql.Register("flydb", &Driver{})
+}
+
+// Driver is the database/sql driver for FlyDB.
+type Driver struct{}
+
+// Open opens a session for dsn. Sessions opened this way do not share a
+// connection; sql.Open uses OpenConnector, whose sessions do.
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	cfg, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Multiplex = false
+	return NewConnector(cfg).Connect(context.Background())
+}
+
+// OpenConnector returns a Connector for dsn.
+func (d *Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	cfg, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return NewConnector(cfg), nil
+}
+
+// driverConn adapts a Conn to database/sql.
+type driverConn struct {
+	conn *Conn
+}
+
+// Prepare returns a statement for query.
+func (c *driverConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext returns a statement for query. Statements are bound and
+// sent when executed, so preparing does not contact the server.
+func (c *driverConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+// Close ends the session.
+func (c *driverConn) Close() error {
+	return c.conn.Close()
+}
+
+// Begin starts a transaction.
+func (c *driverConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx starts a transaction with the isolation level and read-only
+// mode of opts.
+func (c *driverConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var isolation IsolationLevel
+	switch sql.IsolationLevel(opts.Isolation) {
+	case sql.LevelDefault, sql.LevelReadCommitted:
+		isolation = ReadCommitted
+	case sql.LevelReadUncommitted:
+		isolation = ReadUncommitted
+	case sql.LevelRepeatableRead:
+		isolation = RepeatableRead
+	case sql.LevelSerializable:
+		isolation = Serializable
+	default:
+		return nil, fmt.Errorf("flydb: unsupported isolation level %s", sql.IsolationLevel(opts.Isolation))
+	}
+	if err := c.conn.Begin(ctx, TxOptions{Isolation: isolation, ReadOnly: opts.ReadOnly}); err != nil {
+		return nil, err
+	}
+	return &tx{conn: c.conn}, nil
+}
+
+// ExecContext runs a statement that returns no rows.
+func (c *driverConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	r, err := c.query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return result{rowsAffected: r.RowsAffected, lastInsertID: r.LastInsertID}, nil
+}
+
+// QueryContext runs a statement that returns rows.
+func (c *driverConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	r, err := c.query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{result: r}, nil
+}
+
+// query runs a statement with positional args.
+func (c *driverConn) query(ctx context.Context, query string, args []driver.NamedValue) (*Result, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, fmt.Errorf("flydb: named argument %q is not supported", arg.Name)
+		}
+		values[i] = arg.Value
+	}
+	return c.conn.query(ctx, query, values)
+}
+
+// Ping checks that the session is alive.
+func (c *driverConn) Ping(ctx context.Context) error {
+	if err := c.conn.Ping(ctx); err != nil {
+		if c.conn.Broken() {
+			return driver.ErrBadConn
+		}
+		return err
+	}
+	return nil
+}
+
+// ResetSession is called before the session is reused.
+func (c *driverConn) ResetSession(ctx context.Context) error {
+	if c.conn.Broken() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+// IsValid reports whether the session can be reused.
+func (c *driverConn) IsValid() bool {
+	return !c.conn.Broken()
+}
+
+// stmt is a statement of a driverConn.
+type stmt struct {
+	conn  *driverConn
+	query string
+}
+
+// Close releases the statement.
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput returns the highest placeholder number of the statement.
+func (s *stmt) NumInput() int {
+	return numInput(s.query)
+}
+
+// Exec runs the statement.
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+// ExecContext runs the statement.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+// Query runs the statement.
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+// QueryContext runs the statement.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+// namedValues converts positional values to named values.
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+// tx is a transaction of a driverConn.
+type tx struct {
+	conn *Conn
+}
+
+// Commit commits the transaction.
+func (t *tx) Commit() error {
+	return t.conn.Commit(context.Background())
+}
+
+// Rollback rolls the transaction back.
+func (t *tx) Rollback() error {
+	return t.conn.Rollback(context.Background())
+}
+
+// result is the result of an Exec.
+type result struct {
+	rowsAffected int64
+	lastInsertID int64
+}
+
+// LastInsertId returns the ID of the last inserted row.
+func (r result) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+// RowsAffected returns the number of rows the statement changed.
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+// rows iterates over the rows of a Result.
+type rows struct {
+	result *Result
+	next   int
+}
+
+// Columns returns the column names.
+func (r *rows) Columns() []string {
+	return r.result.Columns
+}
+
+// ColumnTypeDatabaseTypeName returns the type name of column i.
+func (r *rows) ColumnTypeDatabaseTypeName(i int) string {
+	if i < len(r.result.ColumnTypes) {
+		return strings.ToUpper(r.result.ColumnTypes[i])
+	}
+	return ""
+}
+
+// Close releases the rows.
+func (r *rows) Close() error {
+	r.next = len(r.result.Rows)
+	return nil
+}
+
+// Next copies the next row into dest.
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.Rows) {
+		return io.EOF
+	}
+	row := r.result.Rows[r.next]
+	r.next++
+	if len(row) != len(dest) {
+		return errors.New("flydb: row has the wrong number of columns")
+	}
+	for i, v := range row {
+		dest[i] = v
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package flydb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"flydb/internal/auth"
+	"flydb/internal/server"
+	"flydb/internal/storage"
+)
+
+// startServer starts a server with an admin user and returns a Config for
+// it.
+func startServer(t *testing.T) *Config {
+	t.Helper()
+	tmpDir, err := os.MkdirTemp("", "flydb_client_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	store, err := storage.NewStorageEngine(storage.StorageConfig{DataDir: tmpDir, BufferPoolSize: 256})
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		t.Fatalf("Failed to create storage engine: %v", err)
+	}
+	if err := auth.NewAuthManager(store).InitializeAdmin("admin"); err != nil {
+		store.Close()
+		os.RemoveAll(tmpDir)
+		t.Fatalf("Failed to initialize admin user: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	srv := server.NewServerWithStore(fmt.Sprintf(":%d", port), store)
+	go srv.Start()
+	time.Sleep(100 * time.Millisecond)
+	t.Cleanup(func() {
+		srv.Stop()
+		store.Close()
+		time.Sleep(10 * time.Millisecond)
+		os.RemoveAll(tmpDir)
+	})
+
+	return &Config{
+		Host:           "127.0.0.1",
+		Port:           port,
+		Username:       "admin",
+		Password:       "admin",
+		ConnectTimeout: 30 * time.Second,
+	}
+}
+
+func TestDriver(t *testing.T) {
+	for _, multiplex := range []bool{false, true} {
+		t.Run(fmt.Sprintf("multiplex=%v", multiplex), func(t *testing.T) {
+			cfg := startServer(t)
+			cfg.Multiplex = multiplex
+			db := sql.OpenDB(NewConnector(cfg))
+			defer db.Close()
+			ctx := context.Background()
+
+			count := func() int64 {
+				t.Helper()
+				var n int64
+				if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&n); err != nil {
+					t.Fatalf("COUNT failed: %v", err)
+				}
+				return n
+			}
+
+			if _, err := db.ExecContext(ctx, "CREATE TABLE users (id INT, name TEXT)"); err != nil {
+				t.Fatalf("CREATE TABLE failed: %v", err)
+			}
+			if _, err := db.ExecContext(ctx, "INSERT INTO users VALUES ($1, $2)", 1, "O'Brien"); err != nil {
+				t.Fatalf("INSERT failed: %v", err)
+			}
+			var name string
+			if err := db.QueryRowContext(ctx, "SELECT name FROM users WHERE id = $1", 1).Scan(&name); err != nil || name != "O'Brien" {
+				t.Fatalf("SELECT got %q, %v", name, err)
+			}
+
+			// Rolled back and committed transactions
+			tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+			if err != nil {
+				t.Fatalf("BeginTx failed: %v", err)
+			}
+			tx.ExecContext(ctx, "INSERT INTO users VALUES ($1, $2)", 2, "rolled back")
+			if err := tx.Rollback(); err != nil {
+				t.Fatalf("Rollback failed: %v", err)
+			}
+			if n := count(); n != 1 {
+				t.Errorf("Table has %d rows after rollback, want 1", n)
+			}
+			tx, err = db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatalf("BeginTx failed: %v", err)
+			}
+			tx.ExecContext(ctx, "INSERT INTO users VALUES ($1, $2)", 2, "committed")
+			if err := tx.Commit(); err != nil {
+				t.Fatalf("Commit failed: %v", err)
+			}
+			if n := count(); n != 2 {
+				t.Errorf("Table has %d rows after commit, want 2", n)
+			}
+			if _, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSnapshot}); err == nil {
+				t.Error("BeginTx accepted an unsupported isolation level")
+			}
+
+			// Server errors carry their SQLSTATE
+			var serverErr *Error
+			if _, err := db.ExecContext(ctx, "SELECT * FROM missing"); !errors.As(err, &serverErr) || serverErr.SQLState == "" {
+				t.Errorf("Query of a missing table returned %v, want an *Error with a SQLSTATE", err)
+			}
+
+			// Canceled contexts end statements
+			canceled, cancel := context.WithCancel(ctx)
+			cancel()
+			if _, err := db.ExecContext(canceled, "SELECT * FROM users"); !errors.Is(err, context.Canceled) {
+				t.Errorf("Canceled query returned %v", err)
+			}
+
+			// Sessions run concurrently
+			db.SetMaxOpenConns(4)
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					var n int64
+					if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE id > $1", 0).Scan(&n); err != nil || n != 2 {
+						t.Errorf("Concurrent COUNT got %d, %v", n, err)
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func TestConn(t *testing.T) {
+	cfg := startServer(t)
+	cfg.StatementTimeout = time.Minute
+	ctx := context.Background()
+
+	conn, err := Connect(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if _, err := conn.Query(ctx, "CREATE TABLE t (id INT, score FLOAT, ok BOOLEAN)"); err != nil {
+		t.Fatalf("CREATE TABLE failed: %v", err)
+	}
+	if _, err := conn.Query(ctx, "INSERT INTO t VALUES ($1, $2, $3)", 7, 2.5, true); err != nil {
+		t.Fatalf("INSERT failed: %v", err)
+	}
+	result, err := conn.Query(ctx, "SELECT id, score, ok FROM t")
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if len(result.Rows) != 1 || len(result.Columns) != 3 {
+		t.Fatalf("SELECT returned %v %v", result.Columns, result.Rows)
+	}
+	if row := result.Rows[0]; row[0] != int64(7) || row[1] != 2.5 || row[2] != true {
+		t.Errorf("Row is %#v", row)
+	}
+
+	if err := conn.Begin(ctx, TxOptions{Isolation: RepeatableRead}); err != nil || !conn.InTransaction() {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	if err := conn.Rollback(ctx); err != nil || conn.InTransaction() {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	conn.Close()
+	if _, err := conn.Query(ctx, "SELECT id FROM t"); err == nil {
+		t.Error("Query on a closed connection succeeded")
+	}
+}
+
+func TestParseDSN(t *testing.T) {
+	cfg, err := ParseDSN("jdbc:flydb://db.example.com:8889/sales?user=app&password=secret&tls=true&multiplex=true")
+	if err != nil {
+		t.Fatalf("ParseDSN failed: %v", err)
+	}
+	if cfg.Host != "db.example.com" || cfg.Port != 8889 || cfg.Database != "sales" ||
+		cfg.Username != "app" || cfg.Password != "secret" || !cfg.Multiplex {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+	if cfg.TLSConfig == nil || cfg.TLSConfig.ServerName != "db.example.com" {
+		t.Errorf("TLS not configured: %+v", cfg.TLSConfig)
+	}
+
+	cfg, err = ParseDSN("Server=localhost;Port=8889;Uid=admin;Pwd=pw;ReadOnly=true")
+	if err != nil {
+		t.Fatalf("ParseDSN failed: %v", err)
+	}
+	if cfg.Host != "localhost" || cfg.Port != 8889 || !cfg.ReadOnly || cfg.TLSConfig != nil || cfg.Multiplex {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) 2026 Firefly Software Solutions Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package flydb
+
+import (
+	"fmt"
+
+	"flydb/internal/errors"
+	"flydb/internal/protocol"
+)
+
+// Error is an error reported by the server.
+type Error struct {
+	Code     int    // Protocol status code, such as 401 or 500
+	ErrCode  int    // FlyDB error code, 0 if the message carried none
+	SQLState string // SQLSTATE of ErrCode, empty if the message carried none
+	Message  string
+}
+
+// Error returns the server's message.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// newServerError decodes the payload of an Error message. Messages of
+// FlyDB errors start with "ERROR <code> (<category>)", which gives the
+// error code and SQLSTATE.
+func newServerError(payload []byte) *Error {
+	msg, err := protocol.DecodeErrorMessage(payload)
+	if err != nil {
+		return &Error{Code: 500, Message: "invalid error message from server"}
+	}
+	e := &Error{Code: msg.Code, Message: msg.Message}
+	if _, err := fmt.Sscanf(msg.Message, "ERROR %d (", &e.ErrCode); err == nil {
+		e.SQLState = string(errors.ToSQLSTATE(errors.ErrorCode(e.ErrCode)))
+	}
+	return e
+}